@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log/slog"
 	"net/http"
 	"os"
@@ -9,6 +11,32 @@ import (
 )
 
 func main() {
+	local := flag.Bool("local", false, "run a single-user server without Firebase, keeping data under -data")
+	dataDir := flag.String("data", "", "data directory of local mode, the user config directory by default")
+	flag.Parse()
+
+	if *local {
+		if err := backend.ConfigureLocal(*dataDir); err != nil {
+			slog.Error("local mode error", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if flag.Arg(0) == "migrate" {
+		command := "up"
+
+		if flag.NArg() > 1 {
+			command = flag.Arg(1)
+		}
+
+		if err := backend.Migrate(context.Background(), command); err != nil {
+			slog.Error("migration error", "error", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if err := run(); err != nil {
 		slog.Error("server error", "error", err)
 		os.Exit(1)
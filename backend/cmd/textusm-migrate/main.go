@@ -0,0 +1,125 @@
+// Command textusm-migrate copies items, gist items, settings and share conditions between the Firestore, Postgres and SQLite stores.
+//
+//	textusm-migrate -from firestore -to postgres -to-url postgres://... -checkpoint migrate.json
+//
+// Firestore is reached with GOOGLE_APPLICATION_CREDENTIALS_JSON and DATABASE_GOOGLE_APPLICATION_CREDENTIALS_JSON as in the API server.
+// SHARE_ENCRYPT_KEY must be the one the API server uses, since share conditions are looked up by a hash of the item ID.
+// Listing every user of a Postgres store needs a role with BYPASSRLS.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/migrate"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("migration failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	from := flag.String("from", "", "source store: firestore, postgres or sqlite")
+	fromURL := flag.String("from-url", "", "database URL of the source store")
+	to := flag.String("to", "", "target store: firestore, postgres or sqlite")
+	toURL := flag.String("to-url", "", "database URL of the target store")
+	checkpointPath := flag.String("checkpoint", "", "file recording progress so an interrupted migration can resume")
+	verify := flag.Bool("verify", true, "compare row counts and checksums after copying")
+	verifyOnly := flag.Bool("verify-only", false, "only compare the stores without copying")
+	flag.Parse()
+
+	if *from == *to && *fromURL == *toURL {
+		return errors.New("source and target must differ")
+	}
+
+	shareEncryptKey := os.Getenv("SHARE_ENCRYPT_KEY")
+
+	if shareEncryptKey == "" {
+		return errors.New("SHARE_ENCRYPT_KEY is required")
+	}
+
+	source, err := openStore(*from, *fromURL)
+
+	if err != nil {
+		return err
+	}
+
+	target, err := openStore(*to, *toURL)
+
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := migrate.LoadCheckpoint(*checkpointPath)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	m := migrate.New(source, target, shareEncryptKey, checkpoint)
+
+	if !*verifyOnly {
+		stats, err := m.Run(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Migration finished", "users", stats.Users, "items", stats.Items, "public", stats.Public, "trash", stats.Trash, "gists", stats.Gists, "settings", stats.Settings, "shares", stats.Shares)
+	}
+
+	if !*verify && !*verifyOnly {
+		return nil
+	}
+
+	report, err := m.Verify(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range migrate.Kinds {
+		s, t := report.Source[kind], report.Target[kind]
+		fmt.Printf("%-9s source %8d %s  target %8d %s\n", kind, s.Count, s.Checksum(), t.Count, t.Checksum())
+	}
+
+	for _, mismatch := range report.Mismatches {
+		slog.Warn("Data differs", "userID", mismatch.UserID, "kind", mismatch.Kind)
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("%d mismatches found", len(report.Mismatches))
+	}
+
+	return nil
+}
+
+func openStore(dbType, databaseURL string) (*migrate.Store, error) {
+	if dbType != migrate.Firestore && databaseURL == "" {
+		return nil, fmt.Errorf("a database URL is required for %s", dbType)
+	}
+
+	cfg, err := config.NewConfig(&config.Env{
+		Credentials:         os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_JSON"),
+		DatabaseCredentials: os.Getenv("DATABASE_GOOGLE_APPLICATION_CREDENTIALS_JSON"),
+		DatabaseURL:         databaseURL,
+		DBType:              dbType,
+		DBMaxConns:          4,
+		DBMinConns:          1,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewStore(dbType, cfg)
+}
@@ -0,0 +1,35 @@
+// Package db embeds the dbmate migrations so the server can apply them without the migration files on disk.
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgresql/migrations/*.sql sqlite/migrations/*.sql mysql/migrations/*.sql
+var migrations embed.FS
+
+// PostgresMigrations returns the migrations of the Postgres schema.
+func PostgresMigrations() fs.FS {
+	return mustSub("postgresql/migrations")
+}
+
+// SqliteMigrations returns the migrations of the SQLite schema.
+func SqliteMigrations() fs.FS {
+	return mustSub("sqlite/migrations")
+}
+
+// MysqlMigrations returns the migrations of the MySQL schema.
+func MysqlMigrations() fs.FS {
+	return mustSub("mysql/migrations")
+}
+
+func mustSub(dir string) fs.FS {
+	sub, err := fs.Sub(migrations, dir)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
@@ -0,0 +1,125 @@
+-- migrate:up
+CREATE TABLE items (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  uid varchar(128) NOT NULL,
+  diagram_id char(36) NOT NULL,
+  location varchar(16) NOT NULL,
+  diagram varchar(32) NOT NULL,
+  is_bookmark boolean NOT NULL DEFAULT false,
+  is_public boolean NOT NULL DEFAULT false,
+  title varchar(1024),
+  text longtext NOT NULL,
+  thumbnail mediumtext,
+  revision varchar(255),
+  created_at datetime(6) NOT NULL,
+  updated_at datetime(6) NOT NULL,
+  deleted_at datetime(6),
+  UNIQUE KEY items_uid_location_diagram_id_idx (uid, location, diagram_id),
+  KEY items_uid_location_updated_at_idx (uid, location, updated_at),
+  KEY items_deleted_at_idx (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE share_conditions (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  hashkey varchar(255) NOT NULL,
+  uid varchar(128) NOT NULL,
+  diagram_id char(36) NOT NULL,
+  location varchar(16) NOT NULL,
+  allow_ip_list text,
+  allow_email_list text,
+  expire_time bigint,
+  password varchar(255),
+  token text NOT NULL,
+  code varchar(64),
+  remaining_views int,
+  created_at datetime(6) NOT NULL,
+  updated_at datetime(6) NOT NULL,
+  UNIQUE KEY share_hashkey_idx (hashkey),
+  UNIQUE KEY share_code_idx (code),
+  UNIQUE KEY share_uid_location_diagram_id_idx (uid, location, diagram_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE settings (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  uid varchar(128) NOT NULL,
+  diagram varchar(32) NOT NULL,
+  activity_color varchar(32) NOT NULL,
+  activity_background_color varchar(32) NOT NULL,
+  background_color varchar(32) NOT NULL,
+  height int NOT NULL,
+  font varchar(255) NOT NULL,
+  line_color varchar(32) NOT NULL,
+  label_color varchar(32) NOT NULL,
+  lock_editing boolean,
+  text_color varchar(32),
+  toolbar boolean,
+  scale double NOT NULL,
+  show_grid boolean,
+  story_color varchar(32) NOT NULL,
+  story_background_color varchar(32) NOT NULL,
+  task_color varchar(32) NOT NULL,
+  task_background_color varchar(32) NOT NULL,
+  width int NOT NULL,
+  zoom_control boolean,
+  created_at datetime(6) NOT NULL,
+  updated_at datetime(6) NOT NULL,
+  UNIQUE KEY settings_uid_diagram_idx (uid, diagram)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE github_tokens (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  uid varchar(128) NOT NULL,
+  access_token text NOT NULL,
+  scope varchar(255) NOT NULL,
+  created_at datetime(6) NOT NULL,
+  updated_at datetime(6) NOT NULL,
+  UNIQUE KEY github_tokens_uid_idx (uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE images (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  image_id char(36) NOT NULL,
+  uid varchar(128) NOT NULL,
+  diagram_id char(36) NOT NULL,
+  location varchar(16) NOT NULL,
+  content_type varchar(255) NOT NULL,
+  size bigint NOT NULL,
+  hash char(64) NOT NULL,
+  created_at datetime(6) NOT NULL,
+  UNIQUE KEY images_image_id_idx (image_id),
+  KEY images_uid_idx (uid, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE account_deletions (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  deletion_id char(36) NOT NULL,
+  uid varchar(128) NOT NULL,
+  status varchar(16) NOT NULL,
+  requested_at datetime(6) NOT NULL,
+  scheduled_at datetime(6) NOT NULL,
+  lease_until datetime(6),
+  completed_at datetime(6),
+  receipt text,
+  UNIQUE KEY account_deletions_deletion_id_idx (deletion_id),
+  KEY account_deletions_uid_idx (uid, status),
+  KEY account_deletions_status_scheduled_at_idx (status, scheduled_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE audit_logs (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  uid varchar(128) NOT NULL,
+  event varchar(64) NOT NULL,
+  target_id varchar(255) NOT NULL,
+  detail text NOT NULL,
+  created_at datetime(6) NOT NULL,
+  KEY audit_logs_uid_idx (uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- migrate:down
+DROP TABLE audit_logs;
+DROP TABLE account_deletions;
+DROP TABLE images;
+DROP TABLE github_tokens;
+DROP TABLE settings;
+DROP TABLE share_conditions;
+DROP TABLE items;
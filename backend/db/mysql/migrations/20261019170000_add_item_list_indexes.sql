@@ -0,0 +1,6 @@
+-- migrate:up
+-- Titles are too long for a full index key, so lists sorted by title are sorted after the uid and location lookup.
+CREATE INDEX items_uid_location_created_at_idx ON items (uid, location, created_at);
+
+-- migrate:down
+DROP INDEX items_uid_location_created_at_idx ON items;
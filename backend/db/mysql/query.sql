@@ -0,0 +1,600 @@
+-- MySQL has no row level security, so every query on the data of a user filters on uid. Shares are the
+-- exception: they are looked up by hash key or code by the users they are shared with.
+
+-- name: GetItem :one
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail) THEN thumbnail
+  END AS thumbnail,
+  revision,
+  created_at,
+  updated_at,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND diagram_id = sqlc.arg(diagram_id);
+
+-- name: ListItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail) THEN thumbnail
+  END AS thumbnail,
+  revision,
+  created_at,
+  updated_at,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  updated_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByCreatedAt :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail) THEN thumbnail
+  END AS thumbnail,
+  revision,
+  created_at,
+  updated_at,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByTitle :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail) THEN thumbnail
+  END AS thumbnail,
+  revision,
+  created_at,
+  updated_at,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  title,
+  id
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByIDs :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail) THEN thumbnail
+  END AS thumbnail,
+  revision,
+  created_at,
+  updated_at,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND diagram_id IN (sqlc.slice(diagram_ids))
+  AND deleted_at IS NULL;
+
+-- name: ListItemIDs :many
+SELECT
+  diagram_id
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND deleted_at IS NULL
+ORDER BY
+  diagram_id;
+
+-- name: ListUIDs :many
+SELECT
+  uid
+FROM
+  items
+UNION
+SELECT
+  uid
+FROM
+  settings
+UNION
+SELECT
+  uid
+FROM
+  share_conditions
+ORDER BY
+  uid;
+
+-- name: CreateItem :exec
+INSERT INTO
+  items (
+    uid,
+    diagram,
+    diagram_id,
+    is_bookmark,
+    is_public,
+    title,
+    text,
+    thumbnail,
+    location,
+    revision,
+    created_at,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateItem :exec
+UPDATE items
+SET
+  diagram = ?,
+  is_bookmark = ?,
+  is_public = ?,
+  title = ?,
+  text = ?,
+  thumbnail = ?,
+  revision = ?,
+  updated_at = ?
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?;
+
+-- name: DeleteItem :exec
+DELETE FROM items
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?;
+
+-- name: UnpublishItem :exec
+UPDATE items
+SET
+  is_public = false
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?;
+
+-- name: ListTrashedItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail) THEN thumbnail
+  END AS thumbnail,
+  revision,
+  created_at,
+  updated_at,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListExpiredTrashedItems :many
+SELECT
+  uid,
+  diagram_id
+FROM
+  items
+WHERE
+  deleted_at < ?
+ORDER BY
+  deleted_at
+LIMIT
+  ?;
+
+-- name: TrashItem :execrows
+UPDATE items
+SET
+  is_public = false,
+  deleted_at = ?
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?
+  AND deleted_at IS NULL;
+
+-- name: RestoreItem :execrows
+UPDATE items
+SET
+  deleted_at = NULL
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?
+  AND deleted_at IS NOT NULL;
+
+-- name: GetShareCondition :one
+SELECT
+  *
+FROM
+  share_conditions
+WHERE
+  hashkey = ?;
+
+-- name: GetShareConditionByCode :one
+SELECT
+  *
+FROM
+  share_conditions
+WHERE
+  code = ?;
+
+-- name: CreateShareCondition :exec
+INSERT INTO
+  share_conditions (
+    uid,
+    hashkey,
+    diagram_id,
+    location,
+    allow_ip_list,
+    allow_email_list,
+    expire_time,
+    password,
+    token,
+    code,
+    remaining_views,
+    created_at,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DecrementShareConditionViews :execresult
+-- MySQL has no UPDATE ... RETURNING, so the views left are handed back through LAST_INSERT_ID.
+UPDATE share_conditions
+SET
+  remaining_views = LAST_INSERT_ID(remaining_views - 1),
+  updated_at = ?
+WHERE
+  hashkey = ?
+  AND remaining_views > 0;
+
+-- name: DeleteShareCondition :exec
+DELETE FROM share_conditions
+WHERE
+  uid = ?
+  AND hashkey = ?;
+
+-- name: DeleteShareConditionItem :exec
+DELETE FROM share_conditions
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?;
+
+-- name: GetSettings :one
+SELECT
+  *
+FROM
+  settings
+WHERE
+  uid = ?
+  AND diagram = ?;
+
+-- name: UpsertSettings :exec
+INSERT INTO
+  settings (
+    uid,
+    diagram,
+    activity_color,
+    activity_background_color,
+    background_color,
+    height,
+    line_color,
+    label_color,
+    lock_editing,
+    text_color,
+    toolbar,
+    scale,
+    show_grid,
+    story_color,
+    story_background_color,
+    task_color,
+    task_background_color,
+    width,
+    zoom_control,
+    font,
+    created_at,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+  activity_color = VALUES(activity_color),
+  activity_background_color = VALUES(activity_background_color),
+  background_color = VALUES(background_color),
+  height = VALUES(height),
+  line_color = VALUES(line_color),
+  label_color = VALUES(label_color),
+  lock_editing = VALUES(lock_editing),
+  text_color = VALUES(text_color),
+  toolbar = VALUES(toolbar),
+  scale = VALUES(scale),
+  show_grid = VALUES(show_grid),
+  story_color = VALUES(story_color),
+  story_background_color = VALUES(story_background_color),
+  task_color = VALUES(task_color),
+  task_background_color = VALUES(task_background_color),
+  width = VALUES(width),
+  zoom_control = VALUES(zoom_control),
+  font = VALUES(font),
+  updated_at = VALUES(updated_at);
+
+-- name: DeleteSettings :exec
+DELETE FROM settings
+WHERE
+  uid = ?
+  AND diagram = ?;
+
+-- name: GetGithubToken :one
+SELECT
+  *
+FROM
+  github_tokens
+WHERE
+  uid = ?;
+
+-- name: UpsertGithubToken :exec
+INSERT INTO
+  github_tokens (uid, access_token, scope, created_at, updated_at)
+VALUES
+  (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+  access_token = VALUES(access_token),
+  scope = VALUES(scope),
+  updated_at = VALUES(updated_at);
+
+-- name: DeleteGithubToken :exec
+DELETE FROM github_tokens
+WHERE
+  uid = ?;
+
+-- name: GetImage :one
+SELECT
+  *
+FROM
+  images
+WHERE
+  image_id = ?;
+
+-- name: GetImageUsage :one
+SELECT
+  CAST(COALESCE(SUM(size), 0) AS SIGNED) AS usage_bytes
+FROM
+  images
+WHERE
+  uid = ?;
+
+-- name: GetImageUsageForUpdate :one
+SELECT
+  CAST(COALESCE(SUM(size), 0) AS SIGNED) AS usage_bytes
+FROM
+  images
+WHERE
+  uid = ?
+FOR UPDATE;
+
+-- name: InsertImage :exec
+INSERT INTO
+  images (image_id, uid, diagram_id, location, content_type, size, hash, created_at)
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteImage :exec
+DELETE FROM images
+WHERE
+  uid = ?
+  AND image_id = ?;
+
+-- name: ListImages :many
+SELECT
+  *
+FROM
+  images
+WHERE
+  uid = ?
+ORDER BY
+  created_at;
+
+-- name: GetAccountDeletion :one
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  deletion_id = ?;
+
+-- name: GetActiveAccountDeletion :one
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  uid = ?
+  AND status IN ('pending', 'purging')
+LIMIT
+  1;
+
+-- name: ListDueAccountDeletions :many
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  scheduled_at <= ?
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < ?
+    )
+  )
+ORDER BY
+  scheduled_at
+LIMIT
+  ?;
+
+-- name: CreateAccountDeletion :exec
+INSERT INTO
+  account_deletions (deletion_id, uid, status, requested_at, scheduled_at)
+VALUES
+  (?, ?, ?, ?, ?);
+
+-- name: ClaimAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'purging',
+  lease_until = ?
+WHERE
+  deletion_id = ?
+  AND scheduled_at <= ?
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < ?
+    )
+  );
+
+-- name: CancelAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'cancelled'
+WHERE
+  deletion_id = ?
+  AND status = 'pending';
+
+-- name: CompleteAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'completed',
+  lease_until = NULL,
+  completed_at = ?,
+  receipt = ?
+WHERE
+  deletion_id = ?
+  AND status = 'purging';
+
+-- name: InsertAuditLog :exec
+INSERT INTO
+  audit_logs (uid, event, target_id, detail, created_at)
+VALUES
+  (?, ?, ?, ?, ?);
@@ -0,0 +1,9 @@
+-- migrate:up
+ALTER TABLE share_conditions ADD COLUMN code varchar;
+
+CREATE UNIQUE INDEX share_code_idx ON share_conditions (code);
+
+-- migrate:down
+DROP INDEX share_code_idx;
+
+ALTER TABLE share_conditions DROP COLUMN code;
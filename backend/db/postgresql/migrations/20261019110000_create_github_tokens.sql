@@ -0,0 +1,21 @@
+-- migrate:up
+CREATE TABLE
+  github_tokens (
+    id bigserial PRIMARY KEY,
+    uid varchar NOT NULL,
+    access_token text NOT NULL,
+    scope varchar NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    updated_at timestamp NOT NULL DEFAULT now()
+  );
+
+CREATE UNIQUE INDEX github_tokens_uid_idx ON github_tokens (uid);
+
+ALTER TABLE github_tokens FORCE ROW LEVEL SECURITY;
+
+ALTER TABLE github_tokens ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY github_tokens_uid_policy ON github_tokens AS PERMISSIVE FOR ALL TO public USING (uid = current_setting('app.uid'::varchar));
+
+-- migrate:down
+DROP TABLE github_tokens;
@@ -0,0 +1,5 @@
+-- migrate:up
+ALTER TABLE items ADD COLUMN revision varchar;
+
+-- migrate:down
+ALTER TABLE items DROP COLUMN revision;
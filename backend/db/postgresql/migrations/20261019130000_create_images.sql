@@ -0,0 +1,20 @@
+-- migrate:up
+CREATE TABLE
+  images (
+    id bigserial PRIMARY KEY,
+    image_id uuid NOT NULL,
+    uid varchar NOT NULL,
+    diagram_id uuid NOT NULL,
+    location location NOT NULL,
+    content_type varchar NOT NULL,
+    size bigint NOT NULL,
+    hash varchar NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now()
+  );
+
+CREATE UNIQUE INDEX images_image_id_idx ON images (image_id);
+
+CREATE INDEX images_uid_idx ON images (uid);
+
+-- migrate:down
+DROP TABLE images;
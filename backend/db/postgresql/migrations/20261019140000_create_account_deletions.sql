@@ -0,0 +1,38 @@
+-- migrate:up
+CREATE TABLE
+  account_deletions (
+    id bigserial PRIMARY KEY,
+    deletion_id uuid NOT NULL,
+    uid varchar NOT NULL,
+    status varchar NOT NULL,
+    requested_at timestamp NOT NULL,
+    scheduled_at timestamp NOT NULL,
+    lease_until timestamp,
+    completed_at timestamp,
+    receipt text
+  );
+
+CREATE UNIQUE INDEX account_deletions_deletion_id_idx ON account_deletions (deletion_id);
+
+CREATE UNIQUE INDEX account_deletions_active_uid_idx ON account_deletions (uid)
+WHERE
+  status IN ('pending', 'purging');
+
+CREATE INDEX account_deletions_status_scheduled_at_idx ON account_deletions (status, scheduled_at);
+
+CREATE TABLE
+  audit_logs (
+    id bigserial PRIMARY KEY,
+    uid varchar NOT NULL,
+    event varchar NOT NULL,
+    target_id varchar NOT NULL,
+    detail text NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT now()
+  );
+
+CREATE INDEX audit_logs_uid_idx ON audit_logs (uid);
+
+-- migrate:down
+DROP TABLE audit_logs;
+
+DROP TABLE account_deletions;
@@ -0,0 +1,7 @@
+-- migrate:up
+ALTER TABLE items ADD COLUMN deleted_at timestamp without time zone;
+CREATE INDEX items_deleted_at_idx ON items (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- migrate:down
+DROP INDEX items_deleted_at_idx;
+ALTER TABLE items DROP COLUMN deleted_at;
@@ -0,0 +1,63 @@
+-- migrate:up
+-- The trash purge lists the expired items of every user, which row level security hides from the application role.
+-- expired_trashed_items runs as textusm_trash_sweeper instead, a role that cannot log in and may only read the uid
+-- and diagram_id of trashed items, so the application role never needs to bypass row level security.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'textusm_trash_sweeper') THEN
+    CREATE ROLE textusm_trash_sweeper NOLOGIN;
+  END IF;
+END
+$$;
+
+GRANT USAGE ON SCHEMA public TO textusm_trash_sweeper;
+
+GRANT SELECT (uid, diagram_id, deleted_at) ON items TO textusm_trash_sweeper;
+
+CREATE POLICY items_trash_sweep_policy ON items FOR SELECT TO textusm_trash_sweeper USING (deleted_at IS NOT NULL);
+
+-- app.uid is cleared so that items_uid_policy matches nothing and does not fail on sessions that never set it.
+CREATE FUNCTION expired_trashed_items(deleted_before timestamp without time zone, max_items integer)
+RETURNS TABLE (uid character varying, diagram_id uuid)
+LANGUAGE sql STABLE SECURITY DEFINER
+SET search_path = public, pg_temp
+SET app.uid = ''
+AS $$
+  SELECT
+    items.uid,
+    items.diagram_id
+  FROM
+    items
+  WHERE
+    items.deleted_at < deleted_before
+  ORDER BY
+    items.deleted_at
+  LIMIT
+    max_items
+$$;
+
+-- Handing the function over requires membership of the role, which is only held for as long as it takes.
+DO $$
+DECLARE
+  member boolean := pg_has_role(current_user, 'textusm_trash_sweeper', 'MEMBER');
+BEGIN
+  IF NOT member THEN
+    EXECUTE format('GRANT textusm_trash_sweeper TO %I', current_user);
+  END IF;
+
+  ALTER FUNCTION expired_trashed_items(timestamp without time zone, integer) OWNER TO textusm_trash_sweeper;
+
+  IF NOT member THEN
+    EXECUTE format('REVOKE textusm_trash_sweeper FROM %I', current_user);
+  END IF;
+END
+$$;
+
+-- migrate:down
+DROP FUNCTION expired_trashed_items(timestamp without time zone, integer);
+
+DROP POLICY items_trash_sweep_policy ON items;
+
+REVOKE SELECT (uid, diagram_id, deleted_at) ON items FROM textusm_trash_sweeper;
+
+REVOKE USAGE ON SCHEMA public FROM textusm_trash_sweeper;
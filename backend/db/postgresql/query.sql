@@ -1,25 +1,198 @@
 -- name: GetItem :one
 SELECT
-  *
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text)::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail)::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
 FROM
   items
 WHERE
-  location = $1
-  AND diagram_id = $2;
+  location = sqlc.arg(location)
+  AND diagram_id = sqlc.arg(diagram_id);
 
 -- name: ListItems :many
 SELECT
-  *
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text)::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail)::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
 FROM
   items
 WHERE
-  location = $1
-  AND is_public = $2
-  AND is_bookmark = $3
+  location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  updated_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByCreatedAt :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text)::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail)::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByTitle :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text)::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail)::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  title,
+  id
 LIMIT
-  $4
+  sqlc.arg('limit')
 OFFSET
-  $5;
+  sqlc.arg('offset');
+
+-- name: ListItemsByIDs :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text)::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail)::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = sqlc.arg(location)
+  AND diagram_id = ANY(sqlc.arg(diagram_ids)::uuid[])
+  AND deleted_at IS NULL;
+
+-- name: ListItemIDs :many
+SELECT
+  diagram_id
+FROM
+  items
+WHERE
+  uid = $1
+  AND location = $2
+  AND deleted_at IS NULL
+ORDER BY
+  diagram_id;
+
+-- name: ListUIDs :many
+SELECT
+  uid
+FROM
+  items
+UNION
+SELECT
+  uid
+FROM
+  settings
+UNION
+SELECT
+  uid
+FROM
+  share_conditions
+ORDER BY
+  uid;
 
 -- name: CreateItem :exec
 INSERT INTO
@@ -32,10 +205,13 @@ INSERT INTO
     title,
     text,
     thumbnail,
-    location
+    location,
+    revision,
+    created_at,
+    updated_at
   )
 VALUES
-  ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
 
 -- name: UpdateItem :exec
 UPDATE items
@@ -47,14 +223,16 @@ SET
   text = $5,
   thumbnail = $6,
   location = $7,
-  updated_at = NOW()
+  revision = $8,
+  updated_at = $9
 WHERE
-  diagram_id = $8;
+  diagram_id = $10;
 
 -- name: DeleteItem :exec
 DELETE FROM items
 WHERE
-  diagram_id = $1;
+  uid = $1
+  AND diagram_id = $2;
 
 -- name: GetShareCondition :one
 SELECT
@@ -64,6 +242,14 @@ FROM
 WHERE
   hashkey = $1;
 
+-- name: GetShareConditionByCode :one
+SELECT
+  *
+FROM
+  share_conditions
+WHERE
+  code = $1;
+
 -- name: GetShareConditionItem :one
 SELECT
   *
@@ -84,10 +270,23 @@ INSERT INTO
     allow_email_list,
     expire_time,
     password,
-    token
+    token,
+    code,
+    remaining_views
   )
 VALUES
-  ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: DecrementShareConditionViews :one
+UPDATE share_conditions
+SET
+  remaining_views = remaining_views - 1,
+  updated_at = NOW()
+WHERE
+  hashkey = $1
+  AND remaining_views > 0
+RETURNING
+  remaining_views;
 
 -- name: DeleteShareCondition :exec
 DELETE FROM share_conditions
@@ -129,7 +328,8 @@ INSERT INTO
     task_color,
     task_background_color,
     width,
-    zoom_control
+    zoom_control,
+    font
   )
 VALUES
   (
@@ -151,7 +351,8 @@ VALUES
     $16,
     $17,
     $18,
-    $19
+    $19,
+    $20
   );
 
 -- name: UpdateSettings :exec
@@ -173,6 +374,233 @@ SET
   task_color = $14,
   task_background_color = $15,
   width = $16,
-  zoom_control = $17
+  zoom_control = $17,
+  font = $18
+WHERE
+  diagram = $19;
+
+-- name: GetGithubToken :one
+SELECT
+  *
+FROM
+  github_tokens
+WHERE
+  uid = $1;
+
+-- name: UpsertGithubToken :exec
+INSERT INTO
+  github_tokens (uid, access_token, scope)
+VALUES
+  ($1, $2, $3)
+ON CONFLICT (uid) DO UPDATE
+SET
+  access_token = excluded.access_token,
+  scope = excluded.scope,
+  updated_at = now();
+
+-- name: DeleteGithubToken :exec
+DELETE FROM github_tokens
+WHERE
+  uid = $1;
+
+-- name: GetImage :one
+SELECT
+  *
+FROM
+  images
+WHERE
+  image_id = $1;
+
+-- name: GetImageUsage :one
+SELECT
+  COALESCE(SUM(size), 0)::bigint AS usage
+FROM
+  images
 WHERE
-  diagram = $18;
+  uid = $1;
+
+-- name: LockImageUsage :exec
+SELECT
+  pg_advisory_xact_lock(hashtextextended('images:' || sqlc.arg(uid)::text, 0));
+
+-- name: InsertImage :exec
+INSERT INTO
+  images (image_id, uid, diagram_id, location, content_type, size, hash)
+VALUES
+  ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: DeleteImage :exec
+DELETE FROM images
+WHERE
+  uid = $1
+  AND image_id = $2;
+
+-- name: ListImages :many
+SELECT
+  *
+FROM
+  images
+WHERE
+  uid = $1
+ORDER BY
+  created_at;
+
+-- name: DeleteSettings :exec
+DELETE FROM settings
+WHERE
+  uid = $1
+  AND diagram = $2;
+
+-- name: GetAccountDeletion :one
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  deletion_id = $1;
+
+-- name: GetActiveAccountDeletion :one
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  uid = $1
+  AND status IN ('pending', 'purging');
+
+-- name: ListDueAccountDeletions :many
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  scheduled_at <= $1
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < $1
+    )
+  )
+ORDER BY
+  scheduled_at
+LIMIT
+  $2;
+
+-- name: CreateAccountDeletion :exec
+INSERT INTO
+  account_deletions (deletion_id, uid, status, requested_at, scheduled_at)
+VALUES
+  ($1, $2, $3, $4, $5);
+
+-- name: ClaimAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'purging',
+  lease_until = $2
+WHERE
+  deletion_id = $1
+  AND scheduled_at <= $3
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < $3
+    )
+  );
+
+-- name: CancelAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'cancelled'
+WHERE
+  deletion_id = $1
+  AND status = 'pending';
+
+-- name: CompleteAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'completed',
+  lease_until = NULL,
+  completed_at = $2,
+  receipt = $3
+WHERE
+  deletion_id = $1
+  AND status = 'purging';
+
+-- name: InsertAuditLog :exec
+INSERT INTO
+  audit_logs (uid, event, target_id, detail, created_at)
+VALUES
+  ($1, $2, $3, $4, $5);
+
+-- name: ListTrashedItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN sqlc.arg(load_text)::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN sqlc.arg(load_thumbnail)::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListExpiredTrashedItems :many
+SELECT
+  uid,
+  diagram_id
+FROM
+  expired_trashed_items(
+    sqlc.arg(deleted_before)::timestamp,
+    sqlc.arg(max_items)::integer
+  );
+
+-- name: TrashItem :execrows
+UPDATE items
+SET
+  is_public = FALSE,
+  deleted_at = $1
+WHERE
+  location = $2
+  AND diagram_id = $3
+  AND deleted_at IS NULL;
+
+-- name: RestoreItem :execrows
+UPDATE items
+SET
+  deleted_at = NULL
+WHERE
+  location = $1
+  AND diagram_id = $2
+  AND deleted_at IS NOT NULL;
+
+-- name: UnpublishItem :exec
+UPDATE items
+SET
+  is_public = FALSE
+WHERE
+  uid = $1
+  AND diagram_id = $2;
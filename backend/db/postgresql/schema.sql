@@ -47,10 +47,173 @@ CREATE TYPE public.location AS ENUM (
 );
 
 
+--
+-- Name: expired_trashed_items(timestamp without time zone, integer); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.expired_trashed_items(deleted_before timestamp without time zone, max_items integer) RETURNS TABLE(uid character varying, diagram_id uuid)
+    LANGUAGE sql STABLE SECURITY DEFINER
+    SET search_path TO 'public', 'pg_temp'
+    SET "app.uid" TO ''
+    AS $$
+  SELECT
+    items.uid,
+    items.diagram_id
+  FROM
+    items
+  WHERE
+    items.deleted_at < deleted_before
+  ORDER BY
+    items.deleted_at
+  LIMIT
+    max_items
+$$;
+
+
 SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: account_deletions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.account_deletions (
+    id bigint NOT NULL,
+    deletion_id uuid NOT NULL,
+    uid character varying NOT NULL,
+    status character varying NOT NULL,
+    requested_at timestamp without time zone NOT NULL,
+    scheduled_at timestamp without time zone NOT NULL,
+    lease_until timestamp without time zone,
+    completed_at timestamp without time zone,
+    receipt text
+);
+
+
+--
+-- Name: account_deletions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.account_deletions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: account_deletions_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.account_deletions_id_seq OWNED BY public.account_deletions.id;
+
+
+--
+-- Name: audit_logs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.audit_logs (
+    id bigint NOT NULL,
+    uid character varying NOT NULL,
+    event character varying NOT NULL,
+    target_id character varying NOT NULL,
+    detail text DEFAULT ''::text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: audit_logs_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.audit_logs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: audit_logs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.audit_logs_id_seq OWNED BY public.audit_logs.id;
+
+
+--
+-- Name: github_tokens; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_tokens (
+    id bigint NOT NULL,
+    uid character varying NOT NULL,
+    access_token text NOT NULL,
+    scope character varying NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+ALTER TABLE ONLY public.github_tokens FORCE ROW LEVEL SECURITY;
+
+
+--
+-- Name: github_tokens_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.github_tokens_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: github_tokens_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.github_tokens_id_seq OWNED BY public.github_tokens.id;
+
+
+--
+-- Name: images; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.images (
+    id bigint NOT NULL,
+    image_id uuid NOT NULL,
+    uid character varying NOT NULL,
+    diagram_id uuid NOT NULL,
+    location public.location NOT NULL,
+    content_type character varying NOT NULL,
+    size bigint NOT NULL,
+    hash character varying NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: images_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.images_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: images_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.images_id_seq OWNED BY public.images.id;
+
+
 --
 -- Name: items; Type: TABLE; Schema: public; Owner: -
 --
@@ -67,7 +230,9 @@ CREATE TABLE public.items (
     text text NOT NULL,
     thumbnail text,
     created_at timestamp without time zone DEFAULT now(),
-    updated_at timestamp without time zone DEFAULT now()
+    updated_at timestamp without time zone DEFAULT now(),
+    revision character varying,
+    deleted_at timestamp without time zone
 );
 
 ALTER TABLE ONLY public.items FORCE ROW LEVEL SECURITY;
@@ -169,7 +334,9 @@ CREATE TABLE public.share_conditions (
     password character varying,
     token character varying NOT NULL,
     created_at timestamp without time zone DEFAULT now(),
-    updated_at timestamp without time zone DEFAULT now()
+    updated_at timestamp without time zone DEFAULT now(),
+    code character varying,
+    remaining_views integer
 );
 
 ALTER TABLE ONLY public.share_conditions FORCE ROW LEVEL SECURITY;
@@ -194,6 +361,34 @@ CREATE SEQUENCE public.share_conditions_id_seq
 ALTER SEQUENCE public.share_conditions_id_seq OWNED BY public.share_conditions.id;
 
 
+--
+-- Name: account_deletions id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_deletions ALTER COLUMN id SET DEFAULT nextval('public.account_deletions_id_seq'::regclass);
+
+
+--
+-- Name: audit_logs id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_logs ALTER COLUMN id SET DEFAULT nextval('public.audit_logs_id_seq'::regclass);
+
+
+--
+-- Name: github_tokens id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_tokens ALTER COLUMN id SET DEFAULT nextval('public.github_tokens_id_seq'::regclass);
+
+
+--
+-- Name: images id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.images ALTER COLUMN id SET DEFAULT nextval('public.images_id_seq'::regclass);
+
+
 --
 -- Name: items id; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -215,6 +410,38 @@ ALTER TABLE ONLY public.settings ALTER COLUMN id SET DEFAULT nextval('public.set
 ALTER TABLE ONLY public.share_conditions ALTER COLUMN id SET DEFAULT nextval('public.share_conditions_id_seq'::regclass);
 
 
+--
+-- Name: account_deletions account_deletions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_deletions
+    ADD CONSTRAINT account_deletions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: audit_logs audit_logs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_logs
+    ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: github_tokens github_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_tokens
+    ADD CONSTRAINT github_tokens_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: images images_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.images
+    ADD CONSTRAINT images_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: items items_diagram_id_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -255,6 +482,69 @@ ALTER TABLE ONLY public.share_conditions
     ADD CONSTRAINT share_conditions_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: account_deletions_active_uid_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX account_deletions_active_uid_idx ON public.account_deletions USING btree (uid) WHERE ((status)::text = ANY ((ARRAY['pending'::character varying, 'purging'::character varying])::text[]));
+
+
+--
+-- Name: account_deletions_deletion_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX account_deletions_deletion_id_idx ON public.account_deletions USING btree (deletion_id);
+
+
+--
+-- Name: account_deletions_status_scheduled_at_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX account_deletions_status_scheduled_at_idx ON public.account_deletions USING btree (status, scheduled_at);
+
+
+--
+-- Name: audit_logs_uid_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX audit_logs_uid_idx ON public.audit_logs USING btree (uid);
+
+
+--
+-- Name: github_tokens_uid_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX github_tokens_uid_idx ON public.github_tokens USING btree (uid);
+
+
+--
+-- Name: images_image_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX images_image_id_idx ON public.images USING btree (image_id);
+
+
+--
+-- Name: images_uid_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX images_uid_idx ON public.images USING btree (uid);
+
+
+--
+-- Name: items_deleted_at_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX items_deleted_at_idx ON public.items USING btree (deleted_at) WHERE (deleted_at IS NOT NULL);
+
+
+--
+-- Name: items_uid_location_created_at_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX items_uid_location_created_at_idx ON public.items USING btree (uid, location, created_at, id);
+
+
 --
 -- Name: items_uid_location_diagram_id_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -262,6 +552,20 @@ ALTER TABLE ONLY public.share_conditions
 CREATE UNIQUE INDEX items_uid_location_diagram_id_idx ON public.items USING btree (uid, location, diagram_id);
 
 
+--
+-- Name: items_uid_location_title_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX items_uid_location_title_idx ON public.items USING btree (uid, location, title, id);
+
+
+--
+-- Name: items_uid_location_updated_at_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX items_uid_location_updated_at_idx ON public.items USING btree (uid, location, updated_at, id);
+
+
 --
 -- Name: settings_uid_diagram_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -269,6 +573,13 @@ CREATE UNIQUE INDEX items_uid_location_diagram_id_idx ON public.items USING btre
 CREATE UNIQUE INDEX settings_uid_diagram_idx ON public.settings USING btree (uid, diagram);
 
 
+--
+-- Name: share_code_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX share_code_idx ON public.share_conditions USING btree (code);
+
+
 --
 -- Name: share_hashkey_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -283,12 +594,32 @@ CREATE UNIQUE INDEX share_hashkey_idx ON public.share_conditions USING btree (ha
 CREATE UNIQUE INDEX share_uid_location_diagram_id_idx ON public.share_conditions USING btree (uid, location, diagram_id);
 
 
+--
+-- Name: github_tokens; Type: ROW SECURITY; Schema: public; Owner: -
+--
+
+ALTER TABLE public.github_tokens ENABLE ROW LEVEL SECURITY;
+
+--
+-- Name: github_tokens github_tokens_uid_policy; Type: POLICY; Schema: public; Owner: -
+--
+
+CREATE POLICY github_tokens_uid_policy ON public.github_tokens USING (((uid)::text = current_setting(('app.uid'::character varying)::text)));
+
+
 --
 -- Name: items; Type: ROW SECURITY; Schema: public; Owner: -
 --
 
 ALTER TABLE public.items ENABLE ROW LEVEL SECURITY;
 
+--
+-- Name: items items_trash_sweep_policy; Type: POLICY; Schema: public; Owner: -
+--
+
+CREATE POLICY items_trash_sweep_policy ON public.items FOR SELECT TO textusm_trash_sweeper USING ((deleted_at IS NOT NULL));
+
+
 --
 -- Name: items items_uid_policy; Type: POLICY; Schema: public; Owner: -
 --
@@ -332,4 +663,13 @@ CREATE POLICY share_conditions_uid_policy ON public.share_conditions USING (((ui
 --
 
 INSERT INTO public.schema_migrations (version) VALUES
-    ('20241012091142');
+    ('20241012091142'),
+    ('20261019090000'),
+    ('20261019100000'),
+    ('20261019110000'),
+    ('20261019120000'),
+    ('20261019130000'),
+    ('20261019140000'),
+    ('20261019150000'),
+    ('20261019170000'),
+    ('20261020090000');
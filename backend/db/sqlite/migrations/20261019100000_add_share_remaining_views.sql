@@ -0,0 +1,5 @@
+-- migrate:up
+ALTER TABLE share_conditions ADD COLUMN remaining_views integer;
+
+-- migrate:down
+ALTER TABLE share_conditions DROP COLUMN remaining_views;
@@ -0,0 +1,15 @@
+-- migrate:up
+CREATE TABLE
+  github_tokens (
+    id integer PRIMARY KEY,
+    uid text NOT NULL,
+    access_token text NOT NULL,
+    scope text NOT NULL,
+    created_at integer NOT NULL,
+    updated_at integer NOT NULL
+  );
+
+CREATE UNIQUE INDEX github_tokens_uid_idx ON github_tokens (uid);
+
+-- migrate:down
+DROP TABLE github_tokens;
@@ -0,0 +1,5 @@
+-- migrate:up
+ALTER TABLE items ADD COLUMN revision text;
+
+-- migrate:down
+ALTER TABLE items DROP COLUMN revision;
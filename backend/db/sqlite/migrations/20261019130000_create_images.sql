@@ -0,0 +1,20 @@
+-- migrate:up
+CREATE TABLE
+  images (
+    id integer PRIMARY KEY,
+    image_id text NOT NULL,
+    uid text NOT NULL,
+    diagram_id text NOT NULL,
+    location text NOT NULL,
+    content_type text NOT NULL,
+    size integer NOT NULL,
+    hash text NOT NULL,
+    created_at integer NOT NULL
+  );
+
+CREATE UNIQUE INDEX images_image_id_idx ON images (image_id);
+
+CREATE INDEX images_uid_idx ON images (uid);
+
+-- migrate:down
+DROP TABLE images;
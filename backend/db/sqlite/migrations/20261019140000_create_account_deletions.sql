@@ -0,0 +1,38 @@
+-- migrate:up
+CREATE TABLE
+  account_deletions (
+    id integer PRIMARY KEY,
+    deletion_id text NOT NULL,
+    uid text NOT NULL,
+    status text NOT NULL,
+    requested_at integer NOT NULL,
+    scheduled_at integer NOT NULL,
+    lease_until integer,
+    completed_at integer,
+    receipt text
+  );
+
+CREATE UNIQUE INDEX account_deletions_deletion_id_idx ON account_deletions (deletion_id);
+
+CREATE UNIQUE INDEX account_deletions_active_uid_idx ON account_deletions (uid)
+WHERE
+  status IN ('pending', 'purging');
+
+CREATE INDEX account_deletions_status_scheduled_at_idx ON account_deletions (status, scheduled_at);
+
+CREATE TABLE
+  audit_logs (
+    id integer PRIMARY KEY,
+    uid text NOT NULL,
+    event text NOT NULL,
+    target_id text NOT NULL,
+    detail text NOT NULL DEFAULT '',
+    created_at integer NOT NULL
+  );
+
+CREATE INDEX audit_logs_uid_idx ON audit_logs (uid);
+
+-- migrate:down
+DROP TABLE audit_logs;
+
+DROP TABLE account_deletions;
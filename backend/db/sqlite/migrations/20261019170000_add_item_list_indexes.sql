@@ -0,0 +1,9 @@
+-- migrate:up
+CREATE INDEX items_uid_location_updated_at_idx ON items (uid, location, updated_at);
+CREATE INDEX items_uid_location_created_at_idx ON items (uid, location, created_at);
+CREATE INDEX items_uid_location_title_idx ON items (uid, location, title);
+
+-- migrate:down
+DROP INDEX items_uid_location_title_idx;
+DROP INDEX items_uid_location_created_at_idx;
+DROP INDEX items_uid_location_updated_at_idx;
@@ -1,27 +1,203 @@
 -- name: GetItem :one
 SELECT
-  *
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(sqlc.arg(load_text) AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(sqlc.arg(load_thumbnail) AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
 FROM
   items
 WHERE
-  uid = ?
-  AND location = ?
-  AND diagram_id = ?;
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND diagram_id = sqlc.arg(diagram_id);
 
 -- name: ListItems :many
 SELECT
-  *
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(sqlc.arg(load_text) AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(sqlc.arg(load_thumbnail) AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
 FROM
   items
 WHERE
-  uid = ?
-  AND location = ?
-  AND is_public = ?
-  AND is_bookmark = ?
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  updated_at DESC,
+  id DESC
 LIMIT
-  ?
+  sqlc.arg('limit')
 OFFSET
-  ?;
+  sqlc.arg('offset');
+
+-- name: ListItemsByCreatedAt :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(sqlc.arg(load_text) AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(sqlc.arg(load_thumbnail) AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByTitle :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(sqlc.arg(load_text) AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(sqlc.arg(load_thumbnail) AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND is_public = sqlc.arg(is_public)
+  AND is_bookmark = COALESCE(sqlc.narg(is_bookmark), is_bookmark)
+  AND diagram = COALESCE(sqlc.narg(diagram), diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  title,
+  id
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListItemsByIDs :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(sqlc.arg(load_text) AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(sqlc.arg(load_thumbnail) AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND diagram_id IN (sqlc.slice(diagram_ids))
+  AND deleted_at IS NULL;
+
+-- name: ListItemIDs :many
+SELECT
+  diagram_id
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND deleted_at IS NULL
+ORDER BY
+  diagram_id;
+
+-- name: ListUIDs :many
+SELECT
+  uid
+FROM
+  items
+UNION
+SELECT
+  uid
+FROM
+  settings
+UNION
+SELECT
+  uid
+FROM
+  share_conditions
+ORDER BY
+  uid;
 
 -- name: CreateItem :exec
 INSERT INTO
@@ -35,11 +211,12 @@ INSERT INTO
     text,
     thumbnail,
     location,
+    revision,
     created_at,
     updated_at
   )
 VALUES
-  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: UpdateItem :exec
 UPDATE items
@@ -51,6 +228,7 @@ SET
   text = ?,
   thumbnail = ?,
   location = ?,
+  revision = ?,
   updated_at = ?
 WHERE
   uid = ?
@@ -70,6 +248,14 @@ FROM
 WHERE
   hashkey = ?;
 
+-- name: GetShareConditionByCode :one
+SELECT
+  *
+FROM
+  share_conditions
+WHERE
+  code = ?;
+
 -- name: GetShareConditionItem :one
 SELECT
   *
@@ -92,11 +278,24 @@ INSERT INTO
     expire_time,
     password,
     token,
+    code,
+    remaining_views,
     created_at,
     updated_at
   )
 VALUES
-  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DecrementShareConditionViews :one
+UPDATE share_conditions
+SET
+  remaining_views = remaining_views - 1,
+  updated_at = ?
+WHERE
+  hashkey = ?
+  AND remaining_views > 0
+RETURNING
+  remaining_views;
 
 -- name: DeleteShareCondition :exec
 DELETE FROM share_conditions
@@ -141,6 +340,7 @@ INSERT INTO
     task_background_color,
     width,
     zoom_control,
+    font,
     created_at,
     updated_at
   )
@@ -166,6 +366,7 @@ VALUES
     ?,
     ?,
     ?,
+    ?,
     ?
   );
 
@@ -189,7 +390,235 @@ SET
   task_background_color = ?,
   width = ?,
   zoom_control = ?,
+  font = ?,
   updated_at = ?
 WHERE
   uid = ?
   AND diagram = ?;
+
+-- name: GetGithubToken :one
+SELECT
+  *
+FROM
+  github_tokens
+WHERE
+  uid = ?;
+
+-- name: UpsertGithubToken :exec
+INSERT INTO
+  github_tokens (uid, access_token, scope, created_at, updated_at)
+VALUES
+  (?, ?, ?, ?, ?)
+ON CONFLICT (uid) DO UPDATE
+SET
+  access_token = excluded.access_token,
+  scope = excluded.scope,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteGithubToken :exec
+DELETE FROM github_tokens
+WHERE
+  uid = ?;
+
+-- name: GetImage :one
+SELECT
+  *
+FROM
+  images
+WHERE
+  image_id = ?;
+
+-- name: GetImageUsage :one
+SELECT
+  CAST(COALESCE(SUM(size), 0) AS integer) AS usage
+FROM
+  images
+WHERE
+  uid = ?;
+
+-- name: InsertImage :exec
+INSERT INTO
+  images (image_id, uid, diagram_id, location, content_type, size, hash, created_at)
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteImage :exec
+DELETE FROM images
+WHERE
+  uid = ?
+  AND image_id = ?;
+
+-- name: ListImages :many
+SELECT
+  *
+FROM
+  images
+WHERE
+  uid = ?
+ORDER BY
+  created_at;
+
+-- name: DeleteSettings :exec
+DELETE FROM settings
+WHERE
+  uid = ?
+  AND diagram = ?;
+
+-- name: GetAccountDeletion :one
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  deletion_id = ?;
+
+-- name: GetActiveAccountDeletion :one
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  uid = ?
+  AND status IN ('pending', 'purging');
+
+-- name: ListDueAccountDeletions :many
+SELECT
+  *
+FROM
+  account_deletions
+WHERE
+  scheduled_at <= ?
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < ?
+    )
+  )
+ORDER BY
+  scheduled_at
+LIMIT
+  ?;
+
+-- name: CreateAccountDeletion :exec
+INSERT INTO
+  account_deletions (deletion_id, uid, status, requested_at, scheduled_at)
+VALUES
+  (?, ?, ?, ?, ?);
+
+-- name: ClaimAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'purging',
+  lease_until = ?
+WHERE
+  deletion_id = ?
+  AND scheduled_at <= ?
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < ?
+    )
+  );
+
+-- name: CancelAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'cancelled'
+WHERE
+  deletion_id = ?
+  AND status = 'pending';
+
+-- name: CompleteAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'completed',
+  lease_until = NULL,
+  completed_at = ?,
+  receipt = ?
+WHERE
+  deletion_id = ?
+  AND status = 'purging';
+
+-- name: InsertAuditLog :exec
+INSERT INTO
+  audit_logs (uid, event, target_id, detail, created_at)
+VALUES
+  (?, ?, ?, ?, ?);
+
+-- name: ListTrashedItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(sqlc.arg(load_text) AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(sqlc.arg(load_thumbnail) AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = sqlc.arg(uid)
+  AND location = sqlc.arg(location)
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  sqlc.arg('limit')
+OFFSET
+  sqlc.arg('offset');
+
+-- name: ListExpiredTrashedItems :many
+SELECT
+  uid,
+  diagram_id
+FROM
+  items
+WHERE
+  deleted_at < ?
+ORDER BY
+  deleted_at
+LIMIT
+  ?;
+
+-- name: TrashItem :execrows
+UPDATE items
+SET
+  is_public = 0,
+  deleted_at = ?
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?
+  AND deleted_at IS NULL;
+
+-- name: RestoreItem :execrows
+UPDATE items
+SET
+  deleted_at = NULL
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?
+  AND deleted_at IS NOT NULL;
+
+-- name: UnpublishItem :exec
+UPDATE items
+SET
+  is_public = 0
+WHERE
+  uid = ?
+  AND diagram_id = ?;
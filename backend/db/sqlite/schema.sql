@@ -12,7 +12,7 @@ CREATE TABLE items (
     thumbnail text,
     created_at INTEGER NOT NULL,
     updated_at INTEGER NOT NULL
-  );
+  , revision text, deleted_at integer);
 CREATE TABLE share_conditions (
     id integer PRIMARY KEY,
     hashkey text NOT NULL,
@@ -26,7 +26,7 @@ CREATE TABLE share_conditions (
     token text NOT NULL,
     created_at integer NOT NULL,
     updated_at integer NOT NULL
-  );
+  , code text, remaining_views integer);
 CREATE TABLE settings (
     id integer PRIMARY KEY,
     uid text NOT NULL,
@@ -52,10 +52,70 @@ CREATE TABLE settings (
     created_at integer NOT NULL,
     updated_at integer NOT NULL
   );
+CREATE TABLE github_tokens (
+    id integer PRIMARY KEY,
+    uid text NOT NULL,
+    access_token text NOT NULL,
+    scope text NOT NULL,
+    created_at integer NOT NULL,
+    updated_at integer NOT NULL
+  );
+CREATE TABLE images (
+    id integer PRIMARY KEY,
+    image_id text NOT NULL,
+    uid text NOT NULL,
+    diagram_id text NOT NULL,
+    location text NOT NULL,
+    content_type text NOT NULL,
+    size integer NOT NULL,
+    hash text NOT NULL,
+    created_at integer NOT NULL
+  );
+CREATE TABLE account_deletions (
+    id integer PRIMARY KEY,
+    deletion_id text NOT NULL,
+    uid text NOT NULL,
+    status text NOT NULL,
+    requested_at integer NOT NULL,
+    scheduled_at integer NOT NULL,
+    lease_until integer,
+    completed_at integer,
+    receipt text
+  );
+CREATE TABLE audit_logs (
+    id integer PRIMARY KEY,
+    uid text NOT NULL,
+    event text NOT NULL,
+    target_id text NOT NULL,
+    detail text NOT NULL DEFAULT '',
+    created_at integer NOT NULL
+  );
+CREATE UNIQUE INDEX account_deletions_deletion_id_idx ON account_deletions (deletion_id);
+CREATE UNIQUE INDEX account_deletions_active_uid_idx ON account_deletions (uid)
+WHERE
+  status IN ('pending', 'purging');
+CREATE INDEX account_deletions_status_scheduled_at_idx ON account_deletions (status, scheduled_at);
+CREATE INDEX audit_logs_uid_idx ON audit_logs (uid);
+CREATE UNIQUE INDEX github_tokens_uid_idx ON github_tokens (uid);
+CREATE UNIQUE INDEX images_image_id_idx ON images (image_id);
+CREATE INDEX images_uid_idx ON images (uid);
+CREATE INDEX items_deleted_at_idx ON items (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX items_uid_location_created_at_idx ON items (uid, location, created_at);
 CREATE UNIQUE INDEX items_uid_location_diagram_id_idx ON items (uid, location, diagram_id);
+CREATE INDEX items_uid_location_title_idx ON items (uid, location, title);
+CREATE INDEX items_uid_location_updated_at_idx ON items (uid, location, updated_at);
 CREATE UNIQUE INDEX settings_uid_diagram_idx ON settings (uid, diagram);
+CREATE UNIQUE INDEX share_code_idx ON share_conditions (code);
 CREATE UNIQUE INDEX share_hashkey_idx ON share_conditions (hashkey);
 CREATE UNIQUE INDEX share_uid_location_diagram_id_idx ON share_conditions (uid, location, diagram_id);
 -- Dbmate schema migrations
 INSERT INTO "schema_migrations" (version) VALUES
-  ('20241012091142');
+  ('20241012091142'),
+  ('20261019090000'),
+  ('20261019100000'),
+  ('20261019110000'),
+  ('20261019120000'),
+  ('20261019130000'),
+  ('20261019140000'),
+  ('20261019150000'),
+  ('20261019170000');
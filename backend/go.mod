@@ -4,16 +4,20 @@ go 1.26.2
 
 require (
 	cloud.google.com/go/firestore v1.22.0
+	cloud.google.com/go/storage v1.62.1
 	firebase.google.com/go/v4 v4.20.0
 	github.com/99designs/gqlgen v0.17.91
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/go-chi/chi/v5 v5.3.0
 	github.com/go-chi/cors v1.2.2
 	github.com/go-chi/httprate v0.15.0
+	github.com/go-sql-driver/mysql v1.9.3
 	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/google/uuid v1.6.0
 	github.com/google/wire v0.7.0
 	github.com/jackc/pgx/v5 v5.10.0
 	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/klauspost/compress v1.18.0
 	github.com/mattn/go-sqlite3 v1.14.46
 	github.com/samber/lo v1.53.0
 	github.com/samber/mo v1.17.0
@@ -21,6 +25,8 @@ require (
 	github.com/vektah/gqlparser/v2 v2.5.34
 	golang.org/x/crypto v0.53.0
 	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0
+	golang.org/x/image v0.41.0
+	golang.org/x/sync v0.21.0
 	google.golang.org/api v0.284.0
 	google.golang.org/grpc v1.81.1
 )
@@ -37,7 +43,6 @@ require (
 	cloud.google.com/go/iam v1.11.0 // indirect
 	cloud.google.com/go/longrunning v1.0.0 // indirect
 	cloud.google.com/go/monitoring v1.29.0 // indirect
-	cloud.google.com/go/storage v1.62.1 // indirect
 	dario.cat/mergo v1.0.1 // indirect
 	filippo.io/edwards25519 v1.1.1 // indirect
 	github.com/4meepo/tagalign v1.4.1 // indirect
@@ -109,7 +114,6 @@ require (
 	github.com/go-jose/go-jose/v4 v4.1.4 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
-	github.com/go-sql-driver/mysql v1.9.3 // indirect
 	github.com/go-toolsmith/astcast v1.1.0 // indirect
 	github.com/go-toolsmith/astcopy v1.1.0 // indirect
 	github.com/go-toolsmith/astequal v1.2.0 // indirect
@@ -274,7 +278,6 @@ require (
 	golang.org/x/mod v0.36.0 // indirect
 	golang.org/x/net v0.55.0 // indirect
 	golang.org/x/oauth2 v0.36.0 // indirect
-	golang.org/x/sync v0.21.0 // indirect
 	golang.org/x/sys v0.46.0 // indirect
 	golang.org/x/text v0.38.0 // indirect
 	golang.org/x/time v0.15.0 // indirect
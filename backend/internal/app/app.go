@@ -24,6 +24,10 @@ func Server() (server *http.Server, cleanup func(), err error) {
 		server, cleanup, err = InitializePostgresServer()
 	case "sqlite":
 		server, cleanup, err = InitializeSqliteServer()
+	case "mysql":
+		server, cleanup, err = InitializeMysqlServer()
+	case "memory":
+		server, cleanup, err = InitializeMemoryServer()
 	default:
 		server, cleanup, err = InitializeFirebaseServer()
 	}
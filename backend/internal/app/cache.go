@@ -0,0 +1,47 @@
+package app
+
+import (
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/domain/event"
+	settingsModel "github.com/harehare/textusm/internal/domain/model/settings"
+	"github.com/harehare/textusm/internal/infra/cache"
+)
+
+// defaultCacheSize is the size of the caches when CACHE_SIZE is not set and changes reach every instance.
+const defaultCacheSize = 10000
+
+// provideItemCache keeps public items in memory. It returns nil, which turns the cache off, when CACHE_SIZE is zero.
+func provideItemCache(env *config.Env, bus event.Bus) cache.ItemStore {
+	size := cacheSize(env, bus)
+
+	if size <= 0 {
+		return nil
+	}
+
+	return cache.NewLRU[string, cache.ItemReads](size, env.CacheTTL)
+}
+
+// provideSettingsCache keeps settings in memory. It returns nil, which turns the cache off, when CACHE_SIZE is zero.
+func provideSettingsCache(env *config.Env, bus event.Bus) cache.SettingsStore {
+	size := cacheSize(env, bus)
+
+	if size <= 0 {
+		return nil
+	}
+
+	return cache.NewLRU[cache.SettingsKey, settingsModel.Settings](size, env.CacheTTL)
+}
+
+// cacheSize returns CACHE_SIZE, or when it is not set, turns the caches off unless bus tells other instances
+// about changes. Otherwise each instance would serve what it cached until CACHE_TTL runs out.
+func cacheSize(env *config.Env, bus event.Bus) int {
+	if env.CacheSize != nil {
+		return *env.CacheSize
+	}
+
+	if _, local := bus.(*event.LocalBus); local {
+		return 0
+	}
+
+	return defaultCacheSize
+}
@@ -0,0 +1,10 @@
+//go:build !embed
+
+package app
+
+import "github.com/harehare/textusm/internal/app/handler"
+
+// provideFrontend serves no frontend: the app is only embedded with the embed tag.
+func provideFrontend() (handler.Frontend, error) {
+	return nil, nil
+}
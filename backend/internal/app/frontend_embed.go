@@ -0,0 +1,19 @@
+//go:build embed
+
+package app
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/harehare/textusm/internal/app/handler"
+)
+
+// dist is the frontend build, copied here by the embed:build script of the frontend.
+//
+//go:embed all:dist
+var dist embed.FS
+
+func provideFrontend() (handler.Frontend, error) {
+	return fs.Sub(dist, "dist")
+}
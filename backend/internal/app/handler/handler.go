@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
+	"expvar"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"path"
+	"strings"
 	"time"
 
 	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
@@ -14,13 +19,32 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/go-chi/httprate"
 	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/domain/service/image"
+	"github.com/harehare/textusm/internal/idtoken"
+	"github.com/harehare/textusm/internal/infra/cache"
 	"github.com/harehare/textusm/internal/presentation/api"
 	"github.com/harehare/textusm/internal/presentation/api/middleware"
 	resolver "github.com/harehare/textusm/internal/presentation/graphql"
+	"github.com/harehare/textusm/internal/util"
 )
 
-func NewHandler(env *config.Env, config *config.Config, resolvers *resolver.Resolver, restApi *api.Api, logger *slog.Logger) (*chi.Mux, error) {
+// Frontend is the built single page app. It is only served when the binary is built with the embed tag.
+type Frontend fs.FS
+
+func NewHandler(env *config.Env, config *config.Config, resolvers *resolver.Resolver, restApi *api.Api, logger *slog.Logger, migrator *migration.Migrator, frontend Frontend) (*chi.Mux, error) {
+	auth := middleware.AuthMiddleware(config.TokenVerifier)
+
+	if env.LocalMode {
+		auth = middleware.LocalAuthMiddleware()
+	}
+
 	r := chi.NewRouter()
+
+	if env.LocalMode {
+		r.Use(middleware.LoopbackHostMiddleware(env.Port))
+	}
+
 	r.Use(chiMiddleware.Compress(5))
 	r.Use(chiMiddleware.RequestID)
 	r.Use(chiMiddleware.RealIP)
@@ -41,27 +65,73 @@ func NewHandler(env *config.Env, config *config.Config, resolvers *resolver.Reso
 		}
 	})
 
+	if migrator != nil {
+		r.Get("/healthcheck/migrations", migrationStatus(migrator))
+	}
+
+	if env.MetricsEnabled {
+		r.Get("/metrics/compression", stats(util.CompressionStats))
+		r.Get("/metrics/idtoken", stats(idtoken.Stats))
+		r.Get("/metrics/cache", stats(cache.Stats))
+	}
+
+	r.Get("/.well-known/jwks.json", restApi.JWKS)
+
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(chiMiddleware.AllowContentType("application/json"))
 		r.Use(middleware.IPMiddleware())
 		r.Use(cors)
 
 		r.Route("/", func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(config.FirebaseApp))
+			r.Use(chiMiddleware.AllowContentType("application/json"))
+			r.Use(auth)
 			r.Use(httprate.LimitByIP(10, 1*time.Minute))
 			r.Route("/token", func(r chi.Router) {
 				r.Delete("/revoke", restApi.RevokeGistToken)
 				r.Delete("/gist/revoke", restApi.RevokeGistToken)
+				r.Post("/gist", restApi.ConnectGithub)
+				r.Delete("/gist", restApi.DisconnectGithub)
 			})
 		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth)
+			r.Use(httprate.LimitByIP(600, 1*time.Minute))
+			r.Get("/thumbnails/{id}", restApi.Thumbnail)
+			r.Get("/images/{id}", restApi.Image)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(chiMiddleware.AllowContentType(image.ContentTypes()...))
+			r.Use(auth)
+			r.Use(httprate.LimitByIP(30, 1*time.Minute))
+			r.Post("/images", restApi.UploadImage)
+			r.Delete("/images/{id}", restApi.DeleteImage)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth)
+			r.Use(httprate.LimitByIP(5, 1*time.Minute))
+			r.Get("/account/export", restApi.ExportAccount)
+			r.With(chiMiddleware.AllowContentType("application/zip")).Post("/account/import", restApi.ImportAccount)
+			r.Delete("/account", restApi.DeleteAccount)
+			r.Get("/account/deletion", restApi.AccountDeletion)
+			r.Delete("/account/deletion", restApi.CancelAccountDeletion)
+		})
+
+		// The account no longer exists once its receipt is issued, so the receipt is fetched by the deletion ID alone.
+		r.Group(func(r chi.Router) {
+			r.Use(httprate.LimitByIP(30, 1*time.Minute))
+			r.Get("/account/deletions/{id}/receipt", restApi.DeletionReceipt)
+		})
 	})
 
 	r.Route("/graphql", func(r chi.Router) {
 		r.Use(chiMiddleware.AllowContentType("application/json"))
-		r.Use(middleware.AuthMiddleware(config.FirebaseApp))
+		r.Use(auth)
 		r.Use(middleware.IPMiddleware())
 		r.Use(cors)
 		r.Use(httprate.LimitByIP(100, 1*time.Minute))
+		r.Use(resolvers.WithLoaders)
 
 		graphql := gqlHandler.New(resolver.NewExecutableSchema(resolver.Config{Resolvers: resolvers}))
 		graphql.AddTransport(transport.Options{})
@@ -72,7 +142,62 @@ func NewHandler(env *config.Env, config *config.Config, resolvers *resolver.Reso
 		r.Handle("/", graphql)
 	})
 
+	if frontend != nil {
+		r.Get("/*", serveFrontend(frontend))
+	}
+
 	slog.SetDefault(logger)
 
 	return r, nil
 }
+
+// serveFrontend serves the files of the app, and index.html for the paths routed by the app itself.
+func serveFrontend(frontend fs.FS) http.HandlerFunc {
+	files := http.FileServerFS(frontend)
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+
+		if _, err := fs.Stat(frontend, name); name != "" && err != nil {
+			http.ServeFileFS(rw, r, frontend, "index.html")
+			return
+		}
+
+		files.ServeHTTP(rw, r)
+	}
+}
+
+// migrationStatus reports the migration status, with 503 when the schema does not match the binary.
+func migrationStatus(migrator *migration.Migrator) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		status, err := migrator.Status(r.Context())
+
+		if err != nil {
+			slog.Error("failed to read migration status", "error", err)
+			http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+
+		if !status.UpToDate() {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if err := json.NewEncoder(rw).Encode(status); err != nil {
+			slog.Error("failed to write migration status", "error", err)
+		}
+	}
+}
+
+// stats reports the counters of m, such as the bytes saved by compressing diagram text or the reads answered
+// by a cache, since the server started.
+func stats(m *expvar.Map) http.HandlerFunc {
+	return func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+
+		if _, err := rw.Write([]byte(m.String())); err != nil {
+			slog.Error("failed to write stats", "error", err)
+		}
+	}
+}
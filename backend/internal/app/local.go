@@ -0,0 +1,52 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ConfigureLocal sets up the environment of local mode: a single-user server without Firebase that keeps its
+// data in a SQLite file and its blobs in files under dataDir. Variables that are already set are kept, so
+// the port, the blob store and the encryption keys can still be chosen.
+func ConfigureLocal(dataDir string) error {
+	if dataDir == "" {
+		configDir, err := os.UserConfigDir()
+
+		if err != nil {
+			return err
+		}
+
+		dataDir = filepath.Join(configDir, "textusm")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dataDir, "blobs"), 0o700); err != nil {
+		return err
+	}
+
+	for key, value := range map[string]string{"LOCAL_MODE": "true", "DB_TYPE": "sqlite"} {
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+
+	defaults := map[string]string{
+		"DATABASE_URL":   filepath.Join(dataDir, "textusm.db"),
+		"BLOB_STORE":     "local",
+		"BLOB_LOCAL_DIR": filepath.Join(dataDir, "blobs"),
+		"API_VERSION":    "local",
+		"PORT":           "8081",
+		"GO_ENV":         "production",
+	}
+
+	for key, value := range defaults {
+		if os.Getenv(key) != "" {
+			continue
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	schema "github.com/harehare/textusm/db"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/db/mysqldb"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrate runs the migrate subcommand against DATABASE_URL. "up" applies the pending migrations and "status"
+// prints the migration status as JSON.
+func Migrate(ctx context.Context, command string) error {
+	dbType := strings.ToLower(os.Getenv("DB_TYPE"))
+	databaseURL := os.Getenv("DATABASE_URL")
+
+	if databaseURL == "" {
+		return fmt.Errorf("DATABASE_URL is required to migrate %s", dbType)
+	}
+
+	cfg := &config.Config{}
+
+	switch dbType {
+	case "postgres":
+		pool, err := pgxpool.New(ctx, databaseURL)
+
+		if err != nil {
+			return err
+		}
+
+		defer pool.Close()
+		cfg.PostgresConn = pool
+	case "sqlite":
+		db, err := sqlitedb.Open(ctx, databaseURL, 1)
+
+		if err != nil {
+			return err
+		}
+
+		defer db.Close()
+		cfg.Sqlite = db
+	case "mysql":
+		db, err := mysqldb.Open(ctx, databaseURL, 1, 1)
+
+		if err != nil {
+			return err
+		}
+
+		defer db.Close()
+		cfg.MysqlConn = db
+	default:
+		return fmt.Errorf("DB_TYPE %q has no schema to migrate", dbType)
+	}
+
+	m, err := newMigrator(dbType, cfg)
+
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := m.Up(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		slog.Info("migrated database", "applied", len(applied))
+		return nil
+	case "status":
+		status, err := m.Status(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(status)
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up or status", command)
+	}
+}
+
+func newMigrator(dbType string, cfg *config.Config) (*migration.Migrator, error) {
+	if dbType == "sqlite" {
+		migrations, err := migration.Load(schema.SqliteMigrations())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return migration.New(migration.NewSqliteDriver(cfg.Sqlite.Writer()), migrations), nil
+	}
+
+	if dbType == "mysql" {
+		migrations, err := migration.Load(schema.MysqlMigrations())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return migration.New(migration.NewMysqlDriver(cfg.MysqlConn), migrations), nil
+	}
+
+	migrations, err := migration.Load(schema.PostgresMigrations())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return migration.New(migration.NewPostgresDriver(cfg.PostgresConn), migrations), nil
+}
+
+// startMigrator applies the pending migrations when migrateOnStart is set. The server refuses to start when
+// the schema is newer than the binary, or when migrations are pending and are not applied at startup.
+func startMigrator(env *config.Env, dbType string, cfg *config.Config) (*migration.Migrator, error) {
+	m, err := newMigrator(dbType, cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	if env.MigrateOnStart {
+		if _, err := m.Up(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	status, err := m.Status(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := status.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -16,7 +16,15 @@ import (
 	"github.com/harehare/textusm/internal/config"
 )
 
-func NewServer(handler *chi.Mux, env *config.Env, config *config.Config) (server *http.Server, cleanup func()) {
+// Worker runs in the background for as long as the server does.
+type Worker interface {
+	Run(ctx context.Context)
+}
+
+// Workers are the workers started with the server.
+type Workers []Worker
+
+func NewServer(handler *chi.Mux, env *config.Env, config *config.Config, workers Workers) (server *http.Server, cleanup func()) {
 	done := make(chan bool, 1)
 	quit := make(chan os.Signal, 1)
 
@@ -24,7 +32,7 @@ func NewServer(handler *chi.Mux, env *config.Env, config *config.Config) (server
 	signal.Notify(quit, syscall.SIGTERM)
 
 	server = &http.Server{
-		Addr:              fmt.Sprintf(":%s", env.Port),
+		Addr:              addr(env),
 		Handler:           handler,
 		ReadTimeout:       16 * time.Second,
 		WriteTimeout:      16 * time.Second,
@@ -36,13 +44,29 @@ func NewServer(handler *chi.Mux, env *config.Env, config *config.Config) (server
 	defer cancel()
 	go gracefulShutdown(ctx, server, quit, done)
 
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	for _, worker := range workers {
+		go worker.Run(workerCtx)
+	}
+
 	cleanup = func() {
+		stopWorker()
 		if config.PostgresConn != nil {
 			config.PostgresConn.Close()
 		}
-		if config.SqlConn != nil {
-			if err := config.SqlConn.Close(); err != nil {
-				slog.Error("failed to close sqlite connection", "error", err)
+		if config.Sqlite != nil {
+			if err := config.Sqlite.Close(); err != nil {
+				slog.Error("failed to close sqlite database", "error", err)
+			}
+		}
+		if config.MysqlConn != nil {
+			if err := config.MysqlConn.Close(); err != nil {
+				slog.Error("failed to close mysql database", "error", err)
+			}
+		}
+		if config.Memory != nil {
+			if err := config.Memory.Close(); err != nil {
+				slog.Error("failed to write memory database snapshot", "error", err)
 			}
 		}
 		if config.FirestoreClient != nil {
@@ -69,3 +93,12 @@ func gracefulShutdown(ctx context.Context, server *http.Server, quit <-chan os.S
 	}
 	close(done)
 }
+
+// addr listens on the loopback interface only in local mode, where requests are not authenticated.
+func addr(env *config.Env) string {
+	if env.LocalMode {
+		return fmt.Sprintf("127.0.0.1:%s", env.Port)
+	}
+
+	return fmt.Sprintf(":%s", env.Port)
+}
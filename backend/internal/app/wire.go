@@ -5,6 +5,7 @@
 package app
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/google/wire"
@@ -12,12 +13,30 @@ import (
 	"github.com/harehare/textusm/internal/app/server"
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/domain/event"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	gistRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	"github.com/harehare/textusm/internal/domain/service/account"
 	"github.com/harehare/textusm/internal/domain/service/diagramitem"
 	"github.com/harehare/textusm/internal/domain/service/gistitem"
+	"github.com/harehare/textusm/internal/domain/service/gitsync"
+	"github.com/harehare/textusm/internal/domain/service/image"
 	"github.com/harehare/textusm/internal/domain/service/settings"
+	"github.com/harehare/textusm/internal/domain/service/thumbnail"
+	"github.com/harehare/textusm/internal/git"
 	"github.com/harehare/textusm/internal/github"
+	"github.com/harehare/textusm/internal/infra/blob"
+	"github.com/harehare/textusm/internal/infra/cache"
 	"github.com/harehare/textusm/internal/infra/firebase"
+	"github.com/harehare/textusm/internal/infra/local"
+	"github.com/harehare/textusm/internal/infra/memory"
+	"github.com/harehare/textusm/internal/infra/mysql"
 	"github.com/harehare/textusm/internal/infra/postgres"
+	"github.com/harehare/textusm/internal/infra/s3"
 	"github.com/harehare/textusm/internal/infra/sqlite"
 	"github.com/harehare/textusm/internal/presentation/api"
 	resolver "github.com/harehare/textusm/internal/presentation/graphql"
@@ -31,6 +50,129 @@ func provideGithubClientSecret(env *config.Env) github.ClientSecret {
 	return github.ClientSecret(env.GithubClientSecret)
 }
 
+func provideGithubBaseURL(env *config.Env) github.BaseURL {
+	return github.BaseURL(env.GithubAPIBaseURL)
+}
+
+func provideGithubOAuthBaseURL(env *config.Env) github.OAuthBaseURL {
+	return github.OAuthBaseURL(env.GithubOAuthBaseURL)
+}
+
+func provideGitRemote(env *config.Env) git.Remote {
+	return git.Remote(env.GitSyncRemote)
+}
+
+func provideGitBranch(env *config.Env) git.Branch {
+	return git.Branch(env.GitSyncBranch)
+}
+
+func provideGitWorkDir(env *config.Env) git.WorkDir {
+	return git.WorkDir(env.GitSyncWorkDir)
+}
+
+func provideBlobStore(env *config.Env, config *config.Config) (blobRepo.BlobStore, error) {
+	switch env.BlobStore {
+	case "":
+		return nil, nil
+	case "firebase":
+		return firebase.NewBlobStore(config), nil
+	case "s3":
+		return s3.NewBlobStore(env), nil
+	case "local":
+		return local.NewBlobStore(local.Dir(env.BlobLocalDir)), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", env.BlobStore)
+	}
+}
+
+func provideBlobTextThreshold(env *config.Env) blob.TextThreshold {
+	return blob.TextThreshold(env.BlobTextThreshold)
+}
+
+func provideFirebaseItemRepository(config *config.Config, store blobRepo.BlobStore, threshold blob.TextThreshold, itemCache cache.ItemStore, bus event.Bus) itemRepo.ItemRepository {
+	return cache.NewItemRepository(blob.NewItemRepository(firebase.NewItemRepository(config), store, threshold), itemCache, bus)
+}
+
+func providePostgresItemRepository(config *config.Config, store blobRepo.BlobStore, threshold blob.TextThreshold, itemCache cache.ItemStore, bus event.Bus) itemRepo.ItemRepository {
+	return cache.NewItemRepository(blob.NewItemRepository(postgres.NewItemRepository(config), store, threshold), itemCache, bus)
+}
+
+func provideSqliteItemRepository(config *config.Config, store blobRepo.BlobStore, threshold blob.TextThreshold, itemCache cache.ItemStore, bus event.Bus) itemRepo.ItemRepository {
+	return cache.NewItemRepository(blob.NewItemRepository(sqlite.NewItemRepository(config), store, threshold), itemCache, bus)
+}
+
+func provideMysqlItemRepository(config *config.Config, store blobRepo.BlobStore, threshold blob.TextThreshold, itemCache cache.ItemStore, bus event.Bus) itemRepo.ItemRepository {
+	return cache.NewItemRepository(blob.NewItemRepository(mysql.NewItemRepository(config), store, threshold), itemCache, bus)
+}
+
+func provideMemoryItemRepository(config *config.Config, store blobRepo.BlobStore, threshold blob.TextThreshold, itemCache cache.ItemStore, bus event.Bus) itemRepo.ItemRepository {
+	return cache.NewItemRepository(blob.NewItemRepository(memory.NewItemRepository(config), store, threshold), itemCache, bus)
+}
+
+func provideFirebaseGistItemRepository(config *config.Config, store blobRepo.BlobStore) gistRepo.GistItemRepository {
+	return blob.NewGistItemRepository(firebase.NewGistItemRepository(config), store)
+}
+
+func providePostgresGistItemRepository(config *config.Config, store blobRepo.BlobStore) gistRepo.GistItemRepository {
+	return blob.NewGistItemRepository(postgres.NewGistItemRepository(config), store)
+}
+
+func provideSqliteGistItemRepository(config *config.Config, store blobRepo.BlobStore) gistRepo.GistItemRepository {
+	return blob.NewGistItemRepository(sqlite.NewGistItemRepository(config), store)
+}
+
+func provideMysqlGistItemRepository(config *config.Config, store blobRepo.BlobStore) gistRepo.GistItemRepository {
+	return blob.NewGistItemRepository(mysql.NewGistItemRepository(config), store)
+}
+
+func provideMemoryGistItemRepository(config *config.Config, store blobRepo.BlobStore) gistRepo.GistItemRepository {
+	return blob.NewGistItemRepository(memory.NewGistItemRepository(config), store)
+}
+
+func provideFirebaseSettingsRepository(config *config.Config, store cache.SettingsStore, bus event.Bus) settingsRepo.SettingsRepository {
+	return cache.NewSettingsRepository(firebase.NewSettingsRepository(config), store, bus)
+}
+
+func providePostgresSettingsRepository(config *config.Config, store cache.SettingsStore, bus event.Bus) settingsRepo.SettingsRepository {
+	return cache.NewSettingsRepository(postgres.NewSettingsRepository(config), store, bus)
+}
+
+func provideSqliteSettingsRepository(config *config.Config, store cache.SettingsStore, bus event.Bus) settingsRepo.SettingsRepository {
+	return cache.NewSettingsRepository(sqlite.NewSettingsRepository(config), store, bus)
+}
+
+func provideMysqlSettingsRepository(config *config.Config, store cache.SettingsStore, bus event.Bus) settingsRepo.SettingsRepository {
+	return cache.NewSettingsRepository(mysql.NewSettingsRepository(config), store, bus)
+}
+
+func provideMemorySettingsRepository(config *config.Config, store cache.SettingsStore, bus event.Bus) settingsRepo.SettingsRepository {
+	return cache.NewSettingsRepository(memory.NewSettingsRepository(config), store, bus)
+}
+
+func provideThumbnailSignKey(env *config.Env) thumbnail.SignKey {
+	if env.ThumbnailSignKey == "" {
+		return thumbnail.SignKey(env.ShareEncryptKey)
+	}
+
+	return thumbnail.SignKey(env.ThumbnailSignKey)
+}
+
+func provideAPIRoot(env *config.Env) thumbnail.BaseURL {
+	return thumbnail.BaseURL(env.APIRoot)
+}
+
+func provideImageBaseURL(env *config.Env) image.BaseURL {
+	return image.BaseURL(env.APIRoot)
+}
+
+func provideImageMaxSize(env *config.Env) image.MaxSize {
+	return image.MaxSize(env.ImageMaxSize)
+}
+
+func provideImageQuota(env *config.Env) image.Quota {
+	return image.Quota(env.ImageQuota)
+}
+
 func provideShareEncryptKey(env *config.Env) diagramitem.ShareEncryptKey {
 	return diagramitem.ShareEncryptKey(env.ShareEncryptKey)
 }
@@ -43,25 +185,119 @@ func provideEncryptPrivateKey(env *config.Env) diagramitem.EncryptPrivateKey {
 	return diagramitem.EncryptPrivateKey(env.EncryptPrivateKey)
 }
 
+func provideEncryptPreviousPublicKeys(env *config.Env) diagramitem.EncryptPreviousPublicKeys {
+	return diagramitem.EncryptPreviousPublicKeys(env.EncryptPreviousPublicKeys)
+}
+
+func provideGracePeriod(env *config.Env) account.GracePeriod {
+	return account.GracePeriod(env.AccountDeletionGracePeriod)
+}
+
+func providePurgeInterval(env *config.Env) account.PurgeInterval {
+	return account.PurgeInterval(env.AccountDeletionPurgeInterval)
+}
+
+func provideTrashRetention(env *config.Env) diagramitem.TrashRetention {
+	return diagramitem.TrashRetention(env.TrashRetention)
+}
+
+func provideTrashPurgeInterval(env *config.Env) diagramitem.TrashPurgeInterval {
+	return diagramitem.TrashPurgeInterval(env.TrashPurgeInterval)
+}
+
+func providePostgresMigrator(env *config.Env, cfg *config.Config) (*migration.Migrator, error) {
+	return startMigrator(env, "postgres", cfg)
+}
+
+func provideSqliteMigrator(env *config.Env, cfg *config.Config) (*migration.Migrator, error) {
+	return startMigrator(env, "sqlite", cfg)
+}
+
+func provideMysqlMigrator(env *config.Env, cfg *config.Config) (*migration.Migrator, error) {
+	return startMigrator(env, "mysql", cfg)
+}
+
+// provideNoMigrator is used with Firestore and the memory database, which have no schema to migrate.
+func provideNoMigrator() *migration.Migrator {
+	return nil
+}
+
+func provideUserRepository(env *config.Env, config *config.Config, githubClient *github.Client) userRepo.UserRepository {
+	if env.LocalMode {
+		return local.NewUserRepository(githubClient)
+	}
+
+	return firebase.NewUserRepository(config, githubClient)
+}
+
+func provideMemoryUserRepository(env *config.Env, config *config.Config, githubClient *github.Client) userRepo.UserRepository {
+	if env.LocalMode {
+		return local.NewUserRepository(githubClient)
+	}
+
+	return memory.NewUserRepository(config, githubClient)
+}
+
+func provideWorkers(deletion *account.DeletionService, trash *diagramitem.TrashPurger) server.Workers {
+	return server.Workers{deletion, trash}
+}
+
 func InitializeFirebaseServer() (*http.Server, func(), error) {
 	wire.Build(
 		config.Set,
 		provideGithubClientID,
 		provideGithubClientSecret,
+		provideGithubBaseURL,
+		provideGithubOAuthBaseURL,
+		github.NewClient,
+		provideGitRemote,
+		provideGitBranch,
+		provideGitWorkDir,
+		git.NewRepository,
 		provideShareEncryptKey,
 		provideEncryptPublicKey,
 		provideEncryptPrivateKey,
+		provideEncryptPreviousPublicKeys,
+		diagramitem.NewKeyring,
 		db.NewFirestoreTx,
-		firebase.NewItemRepository,
-		firebase.NewGistItemRepository,
-		firebase.NewSettingsRepository,
+		provideBlobStore,
+		provideBlobTextThreshold,
+		provideFirebaseItemRepository,
+		provideFirebaseGistItemRepository,
+		provideFirebaseSettingsRepository,
+		provideItemCache,
+		provideSettingsCache,
+		event.NewLocalBus,
 		firebase.NewShareRepository,
+		firebase.NewGithubTokenRepository,
+		firebase.NewImageRepository,
+		firebase.NewCatalogRepository,
 		firebase.NewUserRepository,
+		firebase.NewDeletionRepository,
+		firebase.NewAuditLogRepository,
 		diagramitem.NewService,
 		gistitem.NewService,
+		gitsync.NewService,
 		settings.NewService,
+		provideThumbnailSignKey,
+		provideAPIRoot,
+		thumbnail.NewService,
+		provideImageBaseURL,
+		provideImageMaxSize,
+		provideImageQuota,
+		image.NewService,
+		account.NewService,
+		provideGracePeriod,
+		providePurgeInterval,
+		account.NewDeletionService,
+		provideTrashRetention,
+		provideTrashPurgeInterval,
+		diagramitem.NewTrashPurger,
+		provideWorkers,
 		resolver.New,
 		api.New,
+		provideNoMigrator,
+		provideFrontend,
 		handler.NewHandler,
 		server.NewServer,
 	)
@@ -73,20 +309,57 @@ func InitializePostgresServer() (*http.Server, func(), error) {
 		config.Set,
 		provideGithubClientID,
 		provideGithubClientSecret,
+		provideGithubBaseURL,
+		provideGithubOAuthBaseURL,
+		github.NewClient,
+		provideGitRemote,
+		provideGitBranch,
+		provideGitWorkDir,
+		git.NewRepository,
 		provideShareEncryptKey,
 		provideEncryptPublicKey,
 		provideEncryptPrivateKey,
+		provideEncryptPreviousPublicKeys,
+		diagramitem.NewKeyring,
 		db.NewPostgresTx,
-		postgres.NewItemRepository,
-		postgres.NewGistItemRepository,
-		postgres.NewSettingsRepository,
+		provideBlobStore,
+		provideBlobTextThreshold,
+		providePostgresItemRepository,
+		providePostgresGistItemRepository,
+		providePostgresSettingsRepository,
+		provideItemCache,
+		provideSettingsCache,
+		postgres.NewEventBus,
 		postgres.NewShareRepository,
-		firebase.NewUserRepository,
+		postgres.NewGithubTokenRepository,
+		postgres.NewImageRepository,
+		postgres.NewCatalogRepository,
+		provideUserRepository,
+		postgres.NewDeletionRepository,
+		postgres.NewAuditLogRepository,
 		diagramitem.NewService,
 		gistitem.NewService,
+		gitsync.NewService,
 		settings.NewService,
+		provideThumbnailSignKey,
+		provideAPIRoot,
+		thumbnail.NewService,
+		provideImageBaseURL,
+		provideImageMaxSize,
+		provideImageQuota,
+		image.NewService,
+		account.NewService,
+		provideGracePeriod,
+		providePurgeInterval,
+		account.NewDeletionService,
+		provideTrashRetention,
+		provideTrashPurgeInterval,
+		diagramitem.NewTrashPurger,
+		provideWorkers,
 		resolver.New,
 		api.New,
+		providePostgresMigrator,
+		provideFrontend,
 		handler.NewHandler,
 		server.NewServer,
 	)
@@ -98,20 +371,181 @@ func InitializeSqliteServer() (*http.Server, func(), error) {
 		config.Set,
 		provideGithubClientID,
 		provideGithubClientSecret,
+		provideGithubBaseURL,
+		provideGithubOAuthBaseURL,
+		github.NewClient,
+		provideGitRemote,
+		provideGitBranch,
+		provideGitWorkDir,
+		git.NewRepository,
 		provideShareEncryptKey,
 		provideEncryptPublicKey,
 		provideEncryptPrivateKey,
+		provideEncryptPreviousPublicKeys,
+		diagramitem.NewKeyring,
 		db.NewDBTx,
-		sqlite.NewItemRepository,
-		sqlite.NewGistItemRepository,
-		sqlite.NewSettingsRepository,
+		provideBlobStore,
+		provideBlobTextThreshold,
+		provideSqliteItemRepository,
+		provideSqliteGistItemRepository,
+		provideSqliteSettingsRepository,
+		provideItemCache,
+		provideSettingsCache,
+		event.NewLocalBus,
 		sqlite.NewShareRepository,
-		firebase.NewUserRepository,
+		sqlite.NewGithubTokenRepository,
+		sqlite.NewImageRepository,
+		sqlite.NewCatalogRepository,
+		provideUserRepository,
+		sqlite.NewDeletionRepository,
+		sqlite.NewAuditLogRepository,
+		diagramitem.NewService,
+		gistitem.NewService,
+		gitsync.NewService,
+		settings.NewService,
+		provideThumbnailSignKey,
+		provideAPIRoot,
+		thumbnail.NewService,
+		provideImageBaseURL,
+		provideImageMaxSize,
+		provideImageQuota,
+		image.NewService,
+		account.NewService,
+		provideGracePeriod,
+		providePurgeInterval,
+		account.NewDeletionService,
+		provideTrashRetention,
+		provideTrashPurgeInterval,
+		diagramitem.NewTrashPurger,
+		provideWorkers,
+		resolver.New,
+		api.New,
+		provideSqliteMigrator,
+		provideFrontend,
+		handler.NewHandler,
+		server.NewServer,
+	)
+	return &http.Server{}, func() {}, nil
+}
+
+func InitializeMysqlServer() (*http.Server, func(), error) {
+	wire.Build(
+		config.Set,
+		provideGithubClientID,
+		provideGithubClientSecret,
+		provideGithubBaseURL,
+		provideGithubOAuthBaseURL,
+		github.NewClient,
+		provideGitRemote,
+		provideGitBranch,
+		provideGitWorkDir,
+		git.NewRepository,
+		provideShareEncryptKey,
+		provideEncryptPublicKey,
+		provideEncryptPrivateKey,
+		provideEncryptPreviousPublicKeys,
+		diagramitem.NewKeyring,
+		db.NewMysqlTx,
+		provideBlobStore,
+		provideBlobTextThreshold,
+		provideMysqlItemRepository,
+		provideMysqlGistItemRepository,
+		provideMysqlSettingsRepository,
+		provideItemCache,
+		provideSettingsCache,
+		event.NewLocalBus,
+		mysql.NewShareRepository,
+		mysql.NewGithubTokenRepository,
+		mysql.NewImageRepository,
+		mysql.NewCatalogRepository,
+		provideUserRepository,
+		mysql.NewDeletionRepository,
+		mysql.NewAuditLogRepository,
+		diagramitem.NewService,
+		gistitem.NewService,
+		gitsync.NewService,
+		settings.NewService,
+		provideThumbnailSignKey,
+		provideAPIRoot,
+		thumbnail.NewService,
+		provideImageBaseURL,
+		provideImageMaxSize,
+		provideImageQuota,
+		image.NewService,
+		account.NewService,
+		provideGracePeriod,
+		providePurgeInterval,
+		account.NewDeletionService,
+		provideTrashRetention,
+		provideTrashPurgeInterval,
+		diagramitem.NewTrashPurger,
+		provideWorkers,
+		resolver.New,
+		api.New,
+		provideMysqlMigrator,
+		provideFrontend,
+		handler.NewHandler,
+		server.NewServer,
+	)
+	return &http.Server{}, func() {}, nil
+}
+
+func InitializeMemoryServer() (*http.Server, func(), error) {
+	wire.Build(
+		config.Set,
+		provideGithubClientID,
+		provideGithubClientSecret,
+		provideGithubBaseURL,
+		provideGithubOAuthBaseURL,
+		github.NewClient,
+		provideGitRemote,
+		provideGitBranch,
+		provideGitWorkDir,
+		git.NewRepository,
+		provideShareEncryptKey,
+		provideEncryptPublicKey,
+		provideEncryptPrivateKey,
+		provideEncryptPreviousPublicKeys,
+		diagramitem.NewKeyring,
+		db.NewMemoryTx,
+		provideBlobStore,
+		provideBlobTextThreshold,
+		provideMemoryItemRepository,
+		provideMemoryGistItemRepository,
+		provideMemorySettingsRepository,
+		provideItemCache,
+		provideSettingsCache,
+		event.NewLocalBus,
+		memory.NewShareRepository,
+		memory.NewGithubTokenRepository,
+		memory.NewImageRepository,
+		memory.NewCatalogRepository,
+		provideMemoryUserRepository,
+		memory.NewDeletionRepository,
+		memory.NewAuditLogRepository,
 		diagramitem.NewService,
 		gistitem.NewService,
+		gitsync.NewService,
 		settings.NewService,
+		provideThumbnailSignKey,
+		provideAPIRoot,
+		thumbnail.NewService,
+		provideImageBaseURL,
+		provideImageMaxSize,
+		provideImageQuota,
+		image.NewService,
+		account.NewService,
+		provideGracePeriod,
+		providePurgeInterval,
+		account.NewDeletionService,
+		provideTrashRetention,
+		provideTrashPurgeInterval,
+		diagramitem.NewTrashPurger,
+		provideWorkers,
 		resolver.New,
 		api.New,
+		provideNoMigrator,
+		provideFrontend,
 		handler.NewHandler,
 		server.NewServer,
 	)
@@ -7,16 +7,35 @@
 package app
 
 import (
+	"fmt"
 	"github.com/harehare/textusm/internal/app/handler"
 	"github.com/harehare/textusm/internal/app/server"
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/domain/event"
+	"github.com/harehare/textusm/internal/domain/repository/blob"
+	diagramitem2 "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	gistitem2 "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	"github.com/harehare/textusm/internal/domain/repository/settings"
+	"github.com/harehare/textusm/internal/domain/repository/user"
+	"github.com/harehare/textusm/internal/domain/service/account"
 	"github.com/harehare/textusm/internal/domain/service/diagramitem"
 	"github.com/harehare/textusm/internal/domain/service/gistitem"
+	"github.com/harehare/textusm/internal/domain/service/gitsync"
+	"github.com/harehare/textusm/internal/domain/service/image"
 	"github.com/harehare/textusm/internal/domain/service/settings"
+	"github.com/harehare/textusm/internal/domain/service/thumbnail"
+	"github.com/harehare/textusm/internal/git"
 	"github.com/harehare/textusm/internal/github"
+	blob2 "github.com/harehare/textusm/internal/infra/blob"
+	"github.com/harehare/textusm/internal/infra/cache"
 	"github.com/harehare/textusm/internal/infra/firebase"
+	"github.com/harehare/textusm/internal/infra/local"
+	"github.com/harehare/textusm/internal/infra/memory"
+	"github.com/harehare/textusm/internal/infra/mysql"
 	"github.com/harehare/textusm/internal/infra/postgres"
+	"github.com/harehare/textusm/internal/infra/s3"
 	"github.com/harehare/textusm/internal/infra/sqlite"
 	"github.com/harehare/textusm/internal/presentation/api"
 	"github.com/harehare/textusm/internal/presentation/graphql"
@@ -34,28 +53,71 @@ func InitializeFirebaseServer() (*http.Server, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	itemRepository := firebase.NewItemRepository(configConfig)
+	blobStore, err := provideBlobStore(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	textThreshold := provideBlobTextThreshold(env)
+	bus := event.NewLocalBus()
+	v := provideItemCache(env, bus)
+	itemRepository := provideFirebaseItemRepository(configConfig, blobStore, textThreshold, v, bus)
+	gistItemRepository := provideFirebaseGistItemRepository(configConfig, blobStore)
 	shareRepository := firebase.NewShareRepository(configConfig)
-	userRepository := firebase.NewUserRepository(configConfig)
+	baseURL := provideGithubBaseURL(env)
+	oAuthBaseURL := provideGithubOAuthBaseURL(env)
+	client := github.NewClient(baseURL, oAuthBaseURL)
+	userRepository := firebase.NewUserRepository(configConfig, client)
+	githubTokenRepository := firebase.NewGithubTokenRepository(configConfig)
 	transaction := db.NewFirestoreTx(configConfig)
 	clientID := provideGithubClientID(env)
 	clientSecret := provideGithubClientSecret(env)
 	shareEncryptKey := provideShareEncryptKey(env)
 	encryptPublicKey := provideEncryptPublicKey(env)
 	encryptPrivateKey := provideEncryptPrivateKey(env)
-	service := diagramitem.NewService(itemRepository, shareRepository, userRepository, transaction, clientID, clientSecret, shareEncryptKey, encryptPublicKey, encryptPrivateKey)
-	gistItemRepository := firebase.NewGistItemRepository(configConfig)
-	gistitemService := gistitem.NewService(gistItemRepository, transaction, clientID, clientSecret)
-	settingsRepository := firebase.NewSettingsRepository(configConfig)
+	encryptPreviousPublicKeys := provideEncryptPreviousPublicKeys(env)
+	keyring := diagramitem.NewKeyring(encryptPublicKey, encryptPrivateKey, encryptPreviousPublicKeys)
+	service := diagramitem.NewService(itemRepository, gistItemRepository, shareRepository, userRepository, githubTokenRepository, transaction, clientID, clientSecret, client, shareEncryptKey, keyring)
+	gistitemService := gistitem.NewService(gistItemRepository, githubTokenRepository, transaction, clientID, clientSecret, client)
+	remote := provideGitRemote(env)
+	branch := provideGitBranch(env)
+	workDir := provideGitWorkDir(env)
+	repository := git.NewRepository(remote, branch, workDir)
+	gitsyncService := gitsync.NewService(itemRepository, transaction, repository)
+	v2 := provideSettingsCache(env, bus)
+	settingsRepository := provideFirebaseSettingsRepository(configConfig, v2, bus)
 	settingsService := settings.NewService(settingsRepository, transaction, clientID, clientSecret)
-	resolver := graphql.New(service, gistitemService, settingsService, configConfig)
-	apiApi := api.New(service, gistitemService, settingsService)
+	signKey := provideThumbnailSignKey(env)
+	thumbnailBaseURL := provideAPIRoot(env)
+	thumbnailService := thumbnail.NewService(itemRepository, gistItemRepository, shareRepository, service, transaction, signKey, thumbnailBaseURL)
+	resolver := graphql.New(service, gistitemService, gitsyncService, settingsService, thumbnailService, configConfig)
+	imageRepository := firebase.NewImageRepository(configConfig)
+	maxSize := provideImageMaxSize(env)
+	quota := provideImageQuota(env)
+	imageBaseURL := provideImageBaseURL(env)
+	imageService := image.NewService(imageRepository, blobStore, service, transaction, maxSize, quota, imageBaseURL)
+	catalogRepository := firebase.NewCatalogRepository(configConfig)
+	accountService := account.NewService(catalogRepository, itemRepository, gistItemRepository, settingsRepository, shareRepository, service, transaction, shareEncryptKey)
+	deletionRepository := firebase.NewDeletionRepository(configConfig)
+	auditLogRepository := firebase.NewAuditLogRepository(configConfig)
+	gracePeriod := provideGracePeriod(env)
+	purgeInterval := providePurgeInterval(env)
+	deletionService := account.NewDeletionService(accountService, imageRepository, blobStore, githubTokenRepository, userRepository, deletionRepository, auditLogRepository, keyring, gracePeriod, purgeInterval)
+	apiApi := api.New(service, gistitemService, settingsService, thumbnailService, imageService, accountService, deletionService)
 	logger := config.NewLogger(env)
-	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger)
+	migrator := provideNoMigrator()
+	frontend, err := provideFrontend()
+	if err != nil {
+		return nil, nil, err
+	}
+	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger, migrator, frontend)
 	if err != nil {
 		return nil, nil, err
 	}
-	httpServer, cleanup := server.NewServer(mux, env, configConfig)
+	trashRetention := provideTrashRetention(env)
+	trashPurgeInterval := provideTrashPurgeInterval(env)
+	trashPurger := diagramitem.NewTrashPurger(itemRepository, transaction, trashRetention, trashPurgeInterval)
+	workers := provideWorkers(deletionService, trashPurger)
+	httpServer, cleanup := server.NewServer(mux, env, configConfig, workers)
 	return httpServer, func() {
 		cleanup()
 	}, nil
@@ -70,29 +132,79 @@ func InitializePostgresServer() (*http.Server, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	itemRepository := postgres.NewItemRepository(configConfig)
+	blobStore, err := provideBlobStore(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	textThreshold := provideBlobTextThreshold(env)
+	bus, cleanup := postgres.NewEventBus(configConfig)
+	v := provideItemCache(env, bus)
+	itemRepository := providePostgresItemRepository(configConfig, blobStore, textThreshold, v, bus)
+	gistItemRepository := providePostgresGistItemRepository(configConfig, blobStore)
 	shareRepository := postgres.NewShareRepository(configConfig)
-	userRepository := firebase.NewUserRepository(configConfig)
+	baseURL := provideGithubBaseURL(env)
+	oAuthBaseURL := provideGithubOAuthBaseURL(env)
+	client := github.NewClient(baseURL, oAuthBaseURL)
+	userRepository := provideUserRepository(env, configConfig, client)
+	githubTokenRepository := postgres.NewGithubTokenRepository(configConfig)
 	transaction := db.NewPostgresTx(configConfig)
 	clientID := provideGithubClientID(env)
 	clientSecret := provideGithubClientSecret(env)
 	shareEncryptKey := provideShareEncryptKey(env)
 	encryptPublicKey := provideEncryptPublicKey(env)
 	encryptPrivateKey := provideEncryptPrivateKey(env)
-	service := diagramitem.NewService(itemRepository, shareRepository, userRepository, transaction, clientID, clientSecret, shareEncryptKey, encryptPublicKey, encryptPrivateKey)
-	gistItemRepository := postgres.NewGistItemRepository(configConfig)
-	gistitemService := gistitem.NewService(gistItemRepository, transaction, clientID, clientSecret)
-	settingsRepository := postgres.NewSettingsRepository(configConfig)
+	encryptPreviousPublicKeys := provideEncryptPreviousPublicKeys(env)
+	keyring := diagramitem.NewKeyring(encryptPublicKey, encryptPrivateKey, encryptPreviousPublicKeys)
+	service := diagramitem.NewService(itemRepository, gistItemRepository, shareRepository, userRepository, githubTokenRepository, transaction, clientID, clientSecret, client, shareEncryptKey, keyring)
+	gistitemService := gistitem.NewService(gistItemRepository, githubTokenRepository, transaction, clientID, clientSecret, client)
+	remote := provideGitRemote(env)
+	branch := provideGitBranch(env)
+	workDir := provideGitWorkDir(env)
+	repository := git.NewRepository(remote, branch, workDir)
+	gitsyncService := gitsync.NewService(itemRepository, transaction, repository)
+	v2 := provideSettingsCache(env, bus)
+	settingsRepository := providePostgresSettingsRepository(configConfig, v2, bus)
 	settingsService := settings.NewService(settingsRepository, transaction, clientID, clientSecret)
-	resolver := graphql.New(service, gistitemService, settingsService, configConfig)
-	apiApi := api.New(service, gistitemService, settingsService)
+	signKey := provideThumbnailSignKey(env)
+	thumbnailBaseURL := provideAPIRoot(env)
+	thumbnailService := thumbnail.NewService(itemRepository, gistItemRepository, shareRepository, service, transaction, signKey, thumbnailBaseURL)
+	resolver := graphql.New(service, gistitemService, gitsyncService, settingsService, thumbnailService, configConfig)
+	imageRepository := postgres.NewImageRepository(configConfig)
+	maxSize := provideImageMaxSize(env)
+	quota := provideImageQuota(env)
+	imageBaseURL := provideImageBaseURL(env)
+	imageService := image.NewService(imageRepository, blobStore, service, transaction, maxSize, quota, imageBaseURL)
+	catalogRepository := postgres.NewCatalogRepository(configConfig)
+	accountService := account.NewService(catalogRepository, itemRepository, gistItemRepository, settingsRepository, shareRepository, service, transaction, shareEncryptKey)
+	deletionRepository := postgres.NewDeletionRepository(configConfig)
+	auditLogRepository := postgres.NewAuditLogRepository(configConfig)
+	gracePeriod := provideGracePeriod(env)
+	purgeInterval := providePurgeInterval(env)
+	deletionService := account.NewDeletionService(accountService, imageRepository, blobStore, githubTokenRepository, userRepository, deletionRepository, auditLogRepository, keyring, gracePeriod, purgeInterval)
+	apiApi := api.New(service, gistitemService, settingsService, thumbnailService, imageService, accountService, deletionService)
 	logger := config.NewLogger(env)
-	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger)
+	migrator, err := providePostgresMigrator(env, configConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	frontend, err := provideFrontend()
 	if err != nil {
+		cleanup()
 		return nil, nil, err
 	}
-	httpServer, cleanup := server.NewServer(mux, env, configConfig)
+	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger, migrator, frontend)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	trashRetention := provideTrashRetention(env)
+	trashPurgeInterval := provideTrashPurgeInterval(env)
+	trashPurger := diagramitem.NewTrashPurger(itemRepository, transaction, trashRetention, trashPurgeInterval)
+	workers := provideWorkers(deletionService, trashPurger)
+	httpServer, cleanup2 := server.NewServer(mux, env, configConfig, workers)
 	return httpServer, func() {
+		cleanup2()
 		cleanup()
 	}, nil
 }
@@ -106,28 +218,235 @@ func InitializeSqliteServer() (*http.Server, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	itemRepository := sqlite.NewItemRepository(configConfig)
+	blobStore, err := provideBlobStore(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	textThreshold := provideBlobTextThreshold(env)
+	bus := event.NewLocalBus()
+	v := provideItemCache(env, bus)
+	itemRepository := provideSqliteItemRepository(configConfig, blobStore, textThreshold, v, bus)
+	gistItemRepository := provideSqliteGistItemRepository(configConfig, blobStore)
 	shareRepository := sqlite.NewShareRepository(configConfig)
-	userRepository := firebase.NewUserRepository(configConfig)
+	baseURL := provideGithubBaseURL(env)
+	oAuthBaseURL := provideGithubOAuthBaseURL(env)
+	client := github.NewClient(baseURL, oAuthBaseURL)
+	userRepository := provideUserRepository(env, configConfig, client)
+	githubTokenRepository := sqlite.NewGithubTokenRepository(configConfig)
 	transaction := db.NewDBTx(configConfig)
 	clientID := provideGithubClientID(env)
 	clientSecret := provideGithubClientSecret(env)
 	shareEncryptKey := provideShareEncryptKey(env)
 	encryptPublicKey := provideEncryptPublicKey(env)
 	encryptPrivateKey := provideEncryptPrivateKey(env)
-	service := diagramitem.NewService(itemRepository, shareRepository, userRepository, transaction, clientID, clientSecret, shareEncryptKey, encryptPublicKey, encryptPrivateKey)
-	gistItemRepository := sqlite.NewGistItemRepository(configConfig)
-	gistitemService := gistitem.NewService(gistItemRepository, transaction, clientID, clientSecret)
-	settingsRepository := sqlite.NewSettingsRepository(configConfig)
+	encryptPreviousPublicKeys := provideEncryptPreviousPublicKeys(env)
+	keyring := diagramitem.NewKeyring(encryptPublicKey, encryptPrivateKey, encryptPreviousPublicKeys)
+	service := diagramitem.NewService(itemRepository, gistItemRepository, shareRepository, userRepository, githubTokenRepository, transaction, clientID, clientSecret, client, shareEncryptKey, keyring)
+	gistitemService := gistitem.NewService(gistItemRepository, githubTokenRepository, transaction, clientID, clientSecret, client)
+	remote := provideGitRemote(env)
+	branch := provideGitBranch(env)
+	workDir := provideGitWorkDir(env)
+	repository := git.NewRepository(remote, branch, workDir)
+	gitsyncService := gitsync.NewService(itemRepository, transaction, repository)
+	v2 := provideSettingsCache(env, bus)
+	settingsRepository := provideSqliteSettingsRepository(configConfig, v2, bus)
 	settingsService := settings.NewService(settingsRepository, transaction, clientID, clientSecret)
-	resolver := graphql.New(service, gistitemService, settingsService, configConfig)
-	apiApi := api.New(service, gistitemService, settingsService)
+	signKey := provideThumbnailSignKey(env)
+	thumbnailBaseURL := provideAPIRoot(env)
+	thumbnailService := thumbnail.NewService(itemRepository, gistItemRepository, shareRepository, service, transaction, signKey, thumbnailBaseURL)
+	resolver := graphql.New(service, gistitemService, gitsyncService, settingsService, thumbnailService, configConfig)
+	imageRepository := sqlite.NewImageRepository(configConfig)
+	maxSize := provideImageMaxSize(env)
+	quota := provideImageQuota(env)
+	imageBaseURL := provideImageBaseURL(env)
+	imageService := image.NewService(imageRepository, blobStore, service, transaction, maxSize, quota, imageBaseURL)
+	catalogRepository := sqlite.NewCatalogRepository(configConfig)
+	accountService := account.NewService(catalogRepository, itemRepository, gistItemRepository, settingsRepository, shareRepository, service, transaction, shareEncryptKey)
+	deletionRepository := sqlite.NewDeletionRepository(configConfig)
+	auditLogRepository := sqlite.NewAuditLogRepository(configConfig)
+	gracePeriod := provideGracePeriod(env)
+	purgeInterval := providePurgeInterval(env)
+	deletionService := account.NewDeletionService(accountService, imageRepository, blobStore, githubTokenRepository, userRepository, deletionRepository, auditLogRepository, keyring, gracePeriod, purgeInterval)
+	apiApi := api.New(service, gistitemService, settingsService, thumbnailService, imageService, accountService, deletionService)
 	logger := config.NewLogger(env)
-	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger)
+	migrator, err := provideSqliteMigrator(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	frontend, err := provideFrontend()
+	if err != nil {
+		return nil, nil, err
+	}
+	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger, migrator, frontend)
 	if err != nil {
 		return nil, nil, err
 	}
-	httpServer, cleanup := server.NewServer(mux, env, configConfig)
+	trashRetention := provideTrashRetention(env)
+	trashPurgeInterval := provideTrashPurgeInterval(env)
+	trashPurger := diagramitem.NewTrashPurger(itemRepository, transaction, trashRetention, trashPurgeInterval)
+	workers := provideWorkers(deletionService, trashPurger)
+	httpServer, cleanup := server.NewServer(mux, env, configConfig, workers)
+	return httpServer, func() {
+		cleanup()
+	}, nil
+}
+
+func InitializeMysqlServer() (*http.Server, func(), error) {
+	env, err := config.NewEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	configConfig, err := config.NewConfig(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	blobStore, err := provideBlobStore(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	textThreshold := provideBlobTextThreshold(env)
+	bus := event.NewLocalBus()
+	v := provideItemCache(env, bus)
+	itemRepository := provideMysqlItemRepository(configConfig, blobStore, textThreshold, v, bus)
+	gistItemRepository := provideMysqlGistItemRepository(configConfig, blobStore)
+	shareRepository := mysql.NewShareRepository(configConfig)
+	baseURL := provideGithubBaseURL(env)
+	oAuthBaseURL := provideGithubOAuthBaseURL(env)
+	client := github.NewClient(baseURL, oAuthBaseURL)
+	userRepository := provideUserRepository(env, configConfig, client)
+	githubTokenRepository := mysql.NewGithubTokenRepository(configConfig)
+	transaction := db.NewMysqlTx(configConfig)
+	clientID := provideGithubClientID(env)
+	clientSecret := provideGithubClientSecret(env)
+	shareEncryptKey := provideShareEncryptKey(env)
+	encryptPublicKey := provideEncryptPublicKey(env)
+	encryptPrivateKey := provideEncryptPrivateKey(env)
+	encryptPreviousPublicKeys := provideEncryptPreviousPublicKeys(env)
+	keyring := diagramitem.NewKeyring(encryptPublicKey, encryptPrivateKey, encryptPreviousPublicKeys)
+	service := diagramitem.NewService(itemRepository, gistItemRepository, shareRepository, userRepository, githubTokenRepository, transaction, clientID, clientSecret, client, shareEncryptKey, keyring)
+	gistitemService := gistitem.NewService(gistItemRepository, githubTokenRepository, transaction, clientID, clientSecret, client)
+	remote := provideGitRemote(env)
+	branch := provideGitBranch(env)
+	workDir := provideGitWorkDir(env)
+	repository := git.NewRepository(remote, branch, workDir)
+	gitsyncService := gitsync.NewService(itemRepository, transaction, repository)
+	v2 := provideSettingsCache(env, bus)
+	settingsRepository := provideMysqlSettingsRepository(configConfig, v2, bus)
+	settingsService := settings.NewService(settingsRepository, transaction, clientID, clientSecret)
+	signKey := provideThumbnailSignKey(env)
+	thumbnailBaseURL := provideAPIRoot(env)
+	thumbnailService := thumbnail.NewService(itemRepository, gistItemRepository, shareRepository, service, transaction, signKey, thumbnailBaseURL)
+	resolver := graphql.New(service, gistitemService, gitsyncService, settingsService, thumbnailService, configConfig)
+	imageRepository := mysql.NewImageRepository(configConfig)
+	maxSize := provideImageMaxSize(env)
+	quota := provideImageQuota(env)
+	imageBaseURL := provideImageBaseURL(env)
+	imageService := image.NewService(imageRepository, blobStore, service, transaction, maxSize, quota, imageBaseURL)
+	catalogRepository := mysql.NewCatalogRepository(configConfig)
+	accountService := account.NewService(catalogRepository, itemRepository, gistItemRepository, settingsRepository, shareRepository, service, transaction, shareEncryptKey)
+	deletionRepository := mysql.NewDeletionRepository(configConfig)
+	auditLogRepository := mysql.NewAuditLogRepository(configConfig)
+	gracePeriod := provideGracePeriod(env)
+	purgeInterval := providePurgeInterval(env)
+	deletionService := account.NewDeletionService(accountService, imageRepository, blobStore, githubTokenRepository, userRepository, deletionRepository, auditLogRepository, keyring, gracePeriod, purgeInterval)
+	apiApi := api.New(service, gistitemService, settingsService, thumbnailService, imageService, accountService, deletionService)
+	logger := config.NewLogger(env)
+	migrator, err := provideMysqlMigrator(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	frontend, err := provideFrontend()
+	if err != nil {
+		return nil, nil, err
+	}
+	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger, migrator, frontend)
+	if err != nil {
+		return nil, nil, err
+	}
+	trashRetention := provideTrashRetention(env)
+	trashPurgeInterval := provideTrashPurgeInterval(env)
+	trashPurger := diagramitem.NewTrashPurger(itemRepository, transaction, trashRetention, trashPurgeInterval)
+	workers := provideWorkers(deletionService, trashPurger)
+	httpServer, cleanup := server.NewServer(mux, env, configConfig, workers)
+	return httpServer, func() {
+		cleanup()
+	}, nil
+}
+
+func InitializeMemoryServer() (*http.Server, func(), error) {
+	env, err := config.NewEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	configConfig, err := config.NewConfig(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	blobStore, err := provideBlobStore(env, configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	textThreshold := provideBlobTextThreshold(env)
+	bus := event.NewLocalBus()
+	v := provideItemCache(env, bus)
+	itemRepository := provideMemoryItemRepository(configConfig, blobStore, textThreshold, v, bus)
+	gistItemRepository := provideMemoryGistItemRepository(configConfig, blobStore)
+	shareRepository := memory.NewShareRepository(configConfig)
+	baseURL := provideGithubBaseURL(env)
+	oAuthBaseURL := provideGithubOAuthBaseURL(env)
+	client := github.NewClient(baseURL, oAuthBaseURL)
+	userRepository := provideMemoryUserRepository(env, configConfig, client)
+	githubTokenRepository := memory.NewGithubTokenRepository(configConfig)
+	transaction := db.NewMemoryTx(configConfig)
+	clientID := provideGithubClientID(env)
+	clientSecret := provideGithubClientSecret(env)
+	shareEncryptKey := provideShareEncryptKey(env)
+	encryptPublicKey := provideEncryptPublicKey(env)
+	encryptPrivateKey := provideEncryptPrivateKey(env)
+	encryptPreviousPublicKeys := provideEncryptPreviousPublicKeys(env)
+	keyring := diagramitem.NewKeyring(encryptPublicKey, encryptPrivateKey, encryptPreviousPublicKeys)
+	service := diagramitem.NewService(itemRepository, gistItemRepository, shareRepository, userRepository, githubTokenRepository, transaction, clientID, clientSecret, client, shareEncryptKey, keyring)
+	gistitemService := gistitem.NewService(gistItemRepository, githubTokenRepository, transaction, clientID, clientSecret, client)
+	remote := provideGitRemote(env)
+	branch := provideGitBranch(env)
+	workDir := provideGitWorkDir(env)
+	repository := git.NewRepository(remote, branch, workDir)
+	gitsyncService := gitsync.NewService(itemRepository, transaction, repository)
+	v2 := provideSettingsCache(env, bus)
+	settingsRepository := provideMemorySettingsRepository(configConfig, v2, bus)
+	settingsService := settings.NewService(settingsRepository, transaction, clientID, clientSecret)
+	signKey := provideThumbnailSignKey(env)
+	thumbnailBaseURL := provideAPIRoot(env)
+	thumbnailService := thumbnail.NewService(itemRepository, gistItemRepository, shareRepository, service, transaction, signKey, thumbnailBaseURL)
+	resolver := graphql.New(service, gistitemService, gitsyncService, settingsService, thumbnailService, configConfig)
+	imageRepository := memory.NewImageRepository(configConfig)
+	maxSize := provideImageMaxSize(env)
+	quota := provideImageQuota(env)
+	imageBaseURL := provideImageBaseURL(env)
+	imageService := image.NewService(imageRepository, blobStore, service, transaction, maxSize, quota, imageBaseURL)
+	catalogRepository := memory.NewCatalogRepository(configConfig)
+	accountService := account.NewService(catalogRepository, itemRepository, gistItemRepository, settingsRepository, shareRepository, service, transaction, shareEncryptKey)
+	deletionRepository := memory.NewDeletionRepository(configConfig)
+	auditLogRepository := memory.NewAuditLogRepository(configConfig)
+	gracePeriod := provideGracePeriod(env)
+	purgeInterval := providePurgeInterval(env)
+	deletionService := account.NewDeletionService(accountService, imageRepository, blobStore, githubTokenRepository, userRepository, deletionRepository, auditLogRepository, keyring, gracePeriod, purgeInterval)
+	apiApi := api.New(service, gistitemService, settingsService, thumbnailService, imageService, accountService, deletionService)
+	logger := config.NewLogger(env)
+	migrator := provideNoMigrator()
+	frontend, err := provideFrontend()
+	if err != nil {
+		return nil, nil, err
+	}
+	mux, err := handler.NewHandler(env, configConfig, resolver, apiApi, logger, migrator, frontend)
+	if err != nil {
+		return nil, nil, err
+	}
+	trashRetention := provideTrashRetention(env)
+	trashPurgeInterval := provideTrashPurgeInterval(env)
+	trashPurger := diagramitem.NewTrashPurger(itemRepository, transaction, trashRetention, trashPurgeInterval)
+	workers := provideWorkers(deletionService, trashPurger)
+	httpServer, cleanup := server.NewServer(mux, env, configConfig, workers)
 	return httpServer, func() {
 		cleanup()
 	}, nil
@@ -143,6 +462,129 @@ func provideGithubClientSecret(env *config.Env) github.ClientSecret {
 	return github.ClientSecret(env.GithubClientSecret)
 }
 
+func provideGithubBaseURL(env *config.Env) github.BaseURL {
+	return github.BaseURL(env.GithubAPIBaseURL)
+}
+
+func provideGithubOAuthBaseURL(env *config.Env) github.OAuthBaseURL {
+	return github.OAuthBaseURL(env.GithubOAuthBaseURL)
+}
+
+func provideGitRemote(env *config.Env) git.Remote {
+	return git.Remote(env.GitSyncRemote)
+}
+
+func provideGitBranch(env *config.Env) git.Branch {
+	return git.Branch(env.GitSyncBranch)
+}
+
+func provideGitWorkDir(env *config.Env) git.WorkDir {
+	return git.WorkDir(env.GitSyncWorkDir)
+}
+
+func provideBlobStore(env *config.Env, config2 *config.Config) (blob.BlobStore, error) {
+	switch env.BlobStore {
+	case "":
+		return nil, nil
+	case "firebase":
+		return firebase.NewBlobStore(config2), nil
+	case "s3":
+		return s3.NewBlobStore(env), nil
+	case "local":
+		return local.NewBlobStore(local.Dir(env.BlobLocalDir)), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", env.BlobStore)
+	}
+}
+
+func provideBlobTextThreshold(env *config.Env) blob2.TextThreshold {
+	return blob2.TextThreshold(env.BlobTextThreshold)
+}
+
+func provideFirebaseItemRepository(config2 *config.Config, store blob.BlobStore, threshold blob2.TextThreshold, itemCache cache.ItemStore, bus event.Bus) diagramitem2.ItemRepository {
+	return cache.NewItemRepository(blob2.NewItemRepository(firebase.NewItemRepository(config2), store, threshold), itemCache, bus)
+}
+
+func providePostgresItemRepository(config2 *config.Config, store blob.BlobStore, threshold blob2.TextThreshold, itemCache cache.ItemStore, bus event.Bus) diagramitem2.ItemRepository {
+	return cache.NewItemRepository(blob2.NewItemRepository(postgres.NewItemRepository(config2), store, threshold), itemCache, bus)
+}
+
+func provideSqliteItemRepository(config2 *config.Config, store blob.BlobStore, threshold blob2.TextThreshold, itemCache cache.ItemStore, bus event.Bus) diagramitem2.ItemRepository {
+	return cache.NewItemRepository(blob2.NewItemRepository(sqlite.NewItemRepository(config2), store, threshold), itemCache, bus)
+}
+
+func provideMysqlItemRepository(config2 *config.Config, store blob.BlobStore, threshold blob2.TextThreshold, itemCache cache.ItemStore, bus event.Bus) diagramitem2.ItemRepository {
+	return cache.NewItemRepository(blob2.NewItemRepository(mysql.NewItemRepository(config2), store, threshold), itemCache, bus)
+}
+
+func provideMemoryItemRepository(config2 *config.Config, store blob.BlobStore, threshold blob2.TextThreshold, itemCache cache.ItemStore, bus event.Bus) diagramitem2.ItemRepository {
+	return cache.NewItemRepository(blob2.NewItemRepository(memory.NewItemRepository(config2), store, threshold), itemCache, bus)
+}
+
+func provideFirebaseGistItemRepository(config2 *config.Config, store blob.BlobStore) gistitem2.GistItemRepository {
+	return blob2.NewGistItemRepository(firebase.NewGistItemRepository(config2), store)
+}
+
+func providePostgresGistItemRepository(config2 *config.Config, store blob.BlobStore) gistitem2.GistItemRepository {
+	return blob2.NewGistItemRepository(postgres.NewGistItemRepository(config2), store)
+}
+
+func provideSqliteGistItemRepository(config2 *config.Config, store blob.BlobStore) gistitem2.GistItemRepository {
+	return blob2.NewGistItemRepository(sqlite.NewGistItemRepository(config2), store)
+}
+
+func provideMysqlGistItemRepository(config2 *config.Config, store blob.BlobStore) gistitem2.GistItemRepository {
+	return blob2.NewGistItemRepository(mysql.NewGistItemRepository(config2), store)
+}
+
+func provideMemoryGistItemRepository(config2 *config.Config, store blob.BlobStore) gistitem2.GistItemRepository {
+	return blob2.NewGistItemRepository(memory.NewGistItemRepository(config2), store)
+}
+
+func provideFirebaseSettingsRepository(config2 *config.Config, store cache.SettingsStore, bus event.Bus) item.SettingsRepository {
+	return cache.NewSettingsRepository(firebase.NewSettingsRepository(config2), store, bus)
+}
+
+func providePostgresSettingsRepository(config2 *config.Config, store cache.SettingsStore, bus event.Bus) item.SettingsRepository {
+	return cache.NewSettingsRepository(postgres.NewSettingsRepository(config2), store, bus)
+}
+
+func provideSqliteSettingsRepository(config2 *config.Config, store cache.SettingsStore, bus event.Bus) item.SettingsRepository {
+	return cache.NewSettingsRepository(sqlite.NewSettingsRepository(config2), store, bus)
+}
+
+func provideMysqlSettingsRepository(config2 *config.Config, store cache.SettingsStore, bus event.Bus) item.SettingsRepository {
+	return cache.NewSettingsRepository(mysql.NewSettingsRepository(config2), store, bus)
+}
+
+func provideMemorySettingsRepository(config2 *config.Config, store cache.SettingsStore, bus event.Bus) item.SettingsRepository {
+	return cache.NewSettingsRepository(memory.NewSettingsRepository(config2), store, bus)
+}
+
+func provideThumbnailSignKey(env *config.Env) thumbnail.SignKey {
+	if env.ThumbnailSignKey == "" {
+		return thumbnail.SignKey(env.ShareEncryptKey)
+	}
+
+	return thumbnail.SignKey(env.ThumbnailSignKey)
+}
+
+func provideAPIRoot(env *config.Env) thumbnail.BaseURL {
+	return thumbnail.BaseURL(env.APIRoot)
+}
+
+func provideImageBaseURL(env *config.Env) image.BaseURL {
+	return image.BaseURL(env.APIRoot)
+}
+
+func provideImageMaxSize(env *config.Env) image.MaxSize {
+	return image.MaxSize(env.ImageMaxSize)
+}
+
+func provideImageQuota(env *config.Env) image.Quota {
+	return image.Quota(env.ImageQuota)
+}
+
 func provideShareEncryptKey(env *config.Env) diagramitem.ShareEncryptKey {
 	return diagramitem.ShareEncryptKey(env.ShareEncryptKey)
 }
@@ -154,3 +596,60 @@ func provideEncryptPublicKey(env *config.Env) diagramitem.EncryptPublicKey {
 func provideEncryptPrivateKey(env *config.Env) diagramitem.EncryptPrivateKey {
 	return diagramitem.EncryptPrivateKey(env.EncryptPrivateKey)
 }
+
+func provideEncryptPreviousPublicKeys(env *config.Env) diagramitem.EncryptPreviousPublicKeys {
+	return diagramitem.EncryptPreviousPublicKeys(env.EncryptPreviousPublicKeys)
+}
+
+func provideGracePeriod(env *config.Env) account.GracePeriod {
+	return account.GracePeriod(env.AccountDeletionGracePeriod)
+}
+
+func providePurgeInterval(env *config.Env) account.PurgeInterval {
+	return account.PurgeInterval(env.AccountDeletionPurgeInterval)
+}
+
+func provideTrashRetention(env *config.Env) diagramitem.TrashRetention {
+	return diagramitem.TrashRetention(env.TrashRetention)
+}
+
+func provideTrashPurgeInterval(env *config.Env) diagramitem.TrashPurgeInterval {
+	return diagramitem.TrashPurgeInterval(env.TrashPurgeInterval)
+}
+
+func providePostgresMigrator(env *config.Env, cfg *config.Config) (*migration.Migrator, error) {
+	return startMigrator(env, "postgres", cfg)
+}
+
+func provideSqliteMigrator(env *config.Env, cfg *config.Config) (*migration.Migrator, error) {
+	return startMigrator(env, "sqlite", cfg)
+}
+
+func provideMysqlMigrator(env *config.Env, cfg *config.Config) (*migration.Migrator, error) {
+	return startMigrator(env, "mysql", cfg)
+}
+
+// provideNoMigrator is used with Firestore and the memory database, which have no schema to migrate.
+func provideNoMigrator() *migration.Migrator {
+	return nil
+}
+
+func provideUserRepository(env *config.Env, config2 *config.Config, githubClient *github.Client) user.UserRepository {
+	if env.LocalMode {
+		return local.NewUserRepository(githubClient)
+	}
+
+	return firebase.NewUserRepository(config2, githubClient)
+}
+
+func provideMemoryUserRepository(env *config.Env, config2 *config.Config, githubClient *github.Client) user.UserRepository {
+	if env.LocalMode {
+		return local.NewUserRepository(githubClient)
+	}
+
+	return memory.NewUserRepository(config2, githubClient)
+}
+
+func provideWorkers(deletion *account.DeletionService, trash *diagramitem.TrashPurger) server.Workers {
+	return server.Workers{deletion, trash}
+}
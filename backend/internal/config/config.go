@@ -11,9 +11,11 @@ import (
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/storage"
 	"github.com/google/wire"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/db/mysqldb"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/harehare/textusm/internal/idtoken"
 	"github.com/jackc/pgx/v5/pgxpool"
-
-	_ "github.com/mattn/go-sqlite3"
 	"google.golang.org/api/option"
 )
 
@@ -21,8 +23,12 @@ type Config struct {
 	FirebaseApp     *firebase.App
 	FirestoreClient *firestore.Client
 	PostgresConn    *pgxpool.Pool
-	SqlConn         *sql.Conn
+	Sqlite          *sqlitedb.DB
+	MysqlConn       *sql.DB
+	Memory          *memdb.DB
 	StorageClient   *storage.Client
+	// TokenVerifier verifies the ID tokens of requests. It is nil in local mode.
+	TokenVerifier *idtoken.Verifier
 }
 
 var Set = wire.NewSet(
@@ -32,6 +38,93 @@ var Set = wire.NewSet(
 )
 
 func NewConfig(env *Env) (*Config, error) {
+	var (
+		app             *firebase.App
+		firestoreClient *firestore.Client
+		storageClient   *storage.Client
+		err             error
+	)
+
+	ctx := context.Background()
+
+	// Local mode runs without Firebase.
+	if !env.LocalMode {
+		app, firestoreClient, storageClient, err = newFirebase(ctx, env)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var verifier *idtoken.Verifier
+
+	if app != nil {
+		verifier = idtoken.NewVerifier(app, env.TokenRevocationCheckInterval)
+	}
+
+	var (
+		pgConn    *pgxpool.Pool
+		sqliteDB  *sqlitedb.DB
+		mysqlConn *sql.DB
+		memDB     *memdb.DB
+	)
+
+	// The memory database needs no DATABASE_URL. When one is set, it is the JSON snapshot loaded at start and
+	// written back on shutdown.
+	if env.DBType == "memory" {
+		memDB, err = memdb.Open(env.DatabaseURL)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if env.DatabaseURL != "" {
+		if env.DBType == "sqlite" {
+			sqliteDB, err = sqlitedb.Open(ctx, env.DatabaseURL, int(env.DBMaxConns))
+
+			if err != nil {
+				return nil, err
+			}
+		} else if env.DBType == "mysql" {
+			mysqlConn, err = mysqldb.Open(ctx, env.DatabaseURL, int(env.DBMaxConns), int(env.DBMinConns))
+
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			cfg, err := pgxpool.ParseConfig(env.DatabaseURL)
+			if err != nil {
+				return nil, err
+			}
+
+			cfg.MaxConns = env.DBMaxConns
+			cfg.MinConns = env.DBMinConns
+			cfg.MaxConnLifetime = 30 * time.Minute
+			cfg.MaxConnIdleTime = 5 * time.Minute
+			cfg.HealthCheckPeriod = 1 * time.Minute
+
+			pgConn, err = pgxpool.NewWithConfig(ctx, cfg)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	config := Config{
+		FirebaseApp:     app,
+		FirestoreClient: firestoreClient,
+		StorageClient:   storageClient,
+		PostgresConn:    pgConn,
+		Sqlite:          sqliteDB,
+		MysqlConn:       mysqlConn,
+		Memory:          memDB,
+		TokenVerifier:   verifier,
+	}
+
+	return &config, nil
+}
+
+func newFirebase(ctx context.Context, env *Env) (*firebase.App, *firestore.Client, *storage.Client, error) {
 	var (
 		app          *firebase.App
 		fbApp        *firebase.App
@@ -43,7 +136,7 @@ func NewConfig(env *Env) (*Config, error) {
 
 		if err != nil {
 			slog.Error("error initializing app", "error", err)
-			return nil, err
+			return nil, nil, nil, err
 		}
 		cred = _cred
 	}
@@ -53,14 +146,12 @@ func NewConfig(env *Env) (*Config, error) {
 
 		if err != nil {
 			slog.Error("error initializing app", "error", err)
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		dbCred = _dbCred
 	}
 
-	ctx := context.Background()
-
 	if cred != nil && dbCred != nil {
 		firebaseConfig := &firebase.Config{
 			StorageBucket: env.StorageBucketName,
@@ -71,14 +162,14 @@ func NewConfig(env *Env) (*Config, error) {
 
 		if err != nil {
 			slog.Error("error initializing app", "error", err)
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		_fbApp, err := firebase.NewApp(ctx, firebaseConfig, dbOpt)
 
 		if err != nil {
 			slog.Error("error initializing app", "error", err)
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		app = _app
@@ -92,14 +183,14 @@ func NewConfig(env *Env) (*Config, error) {
 
 		if err != nil {
 			slog.Error("error initializing app", "error", err)
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		_fbApp, err := firebase.NewApp(ctx, firebaseConfig)
 
 		if err != nil {
 			slog.Error("error initializing app", "error", err)
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		app = _app
@@ -110,62 +201,15 @@ func NewConfig(env *Env) (*Config, error) {
 
 	if err != nil {
 		slog.Error("error initializing firestore", "error", err)
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	storage, err := fbApp.Storage(ctx)
 
 	if err != nil {
 		slog.Error("error initializing storage", "error", err)
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	var (
-		pgConn  *pgxpool.Pool
-		sqlConn *sql.Conn
-	)
-
-	if env.DatabaseURL != "" {
-		if env.DBType == "sqlite" {
-			db, err := sql.Open("sqlite3", env.DatabaseURL)
-
-			if err != nil {
-				return nil, err
-			}
-
-			conn, err := db.Conn(ctx)
-
-			if err != nil {
-				return nil, err
-			}
-			sqlConn = conn
-		} else {
-			cfg, err := pgxpool.ParseConfig(env.DatabaseURL)
-			if err != nil {
-				return nil, err
-			}
-
-			cfg.MaxConns = env.DBMaxConns
-			cfg.MinConns = env.DBMinConns
-			cfg.MaxConnLifetime = 30 * time.Minute
-			cfg.MaxConnIdleTime = 5 * time.Minute
-			cfg.HealthCheckPeriod = 1 * time.Minute
-
-			pgConn, err = pgxpool.NewWithConfig(ctx, cfg)
-
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	config := Config{
-		FirebaseApp:     app,
-		FirestoreClient: firestore,
-		StorageClient:   storage,
-		PostgresConn:    pgConn,
-		SqlConn:         sqlConn,
-	}
-
-	return &config, nil
+	return app, firestore, storage, nil
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log/slog"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
@@ -14,14 +15,62 @@ type Env struct {
 	DatabaseURL         string `required:"false" envconfig:"DATABASE_URL"`
 	GithubClientID      string `envconfig:"GITHUB_CLIENT_ID"  default:""`
 	GithubClientSecret  string `envconfig:"GITHUB_CLIENT_SECRET"  default:""`
+	GithubAPIBaseURL    string `envconfig:"GITHUB_API_BASE_URL" default:"https://api.github.com"`
+	GithubOAuthBaseURL  string `envconfig:"GITHUB_OAUTH_BASE_URL" default:"https://github.com"`
+	GitSyncRemote       string `required:"false" envconfig:"GIT_SYNC_REMOTE"`
+	GitSyncBranch       string `envconfig:"GIT_SYNC_BRANCH" default:"main"`
+	GitSyncWorkDir      string `required:"false" envconfig:"GIT_SYNC_WORKDIR"`
 	StorageBucketName   string `required:"false" envconfig:"STORAGE_BUCKET_NAME"`
-	GoEnv               string `required:"true" envconfig:"GO_ENV"`
-	DBType              string `required:"false" envconfig:"DB_TYPE"`
-	DBMaxConns          int32  `envconfig:"DB_MAX_CONNS" default:"10"`
-	DBMinConns          int32  `envconfig:"DB_MIN_CONNS" default:"2"`
-	ShareEncryptKey     string `required:"false" envconfig:"SHARE_ENCRYPT_KEY"`
-	EncryptPublicKey    string `required:"false" envconfig:"ENCRYPT_PUBLIC_KEY"`
-	EncryptPrivateKey   string `required:"false" envconfig:"ENCRYPT_PRIVATE_KEY"`
+	// BlobStore is one of firebase, s3 or local. Text and thumbnails stay in the database when it is empty.
+	BlobStore         string `required:"false" envconfig:"BLOB_STORE"`
+	BlobTextThreshold int    `envconfig:"BLOB_TEXT_THRESHOLD" default:"65536"`
+	BlobLocalDir      string `required:"false" envconfig:"BLOB_LOCAL_DIR"`
+	// ImageMaxSize and ImageQuota limit the size in bytes of an uploaded image and of all the images of a user.
+	ImageMaxSize      int64  `envconfig:"IMAGE_MAX_SIZE" default:"5242880"`
+	ImageQuota        int64  `envconfig:"IMAGE_QUOTA" default:"104857600"`
+	S3Endpoint        string `required:"false" envconfig:"S3_ENDPOINT"`
+	S3Region          string `required:"false" envconfig:"S3_REGION"`
+	S3Bucket          string `required:"false" envconfig:"S3_BUCKET"`
+	S3AccessKeyID     string `required:"false" envconfig:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `required:"false" envconfig:"S3_SECRET_ACCESS_KEY"`
+	GoEnv             string `required:"true" envconfig:"GO_ENV"`
+	DBType            string `required:"false" envconfig:"DB_TYPE"`
+	DBMaxConns        int32  `envconfig:"DB_MAX_CONNS" default:"10"`
+	DBMinConns        int32  `envconfig:"DB_MIN_CONNS" default:"2"`
+	// LocalMode runs a single-user server without Firebase. Every request is made as the local user, so the
+	// server only listens on the loopback interface.
+	LocalMode bool `envconfig:"LOCAL_MODE" default:"false"`
+	// MigrateOnStart applies the embedded migrations at startup. Otherwise the server refuses to start until
+	// they have been applied with the migrate subcommand.
+	MigrateOnStart  bool   `envconfig:"MIGRATE_ON_START" default:"true"`
+	ShareEncryptKey string `required:"false" envconfig:"SHARE_ENCRYPT_KEY"`
+	// ThumbnailSignKey signs thumbnail URLs and falls back to ShareEncryptKey. Thumbnails are inlined when both are empty.
+	ThumbnailSignKey string `required:"false" envconfig:"THUMBNAIL_SIGN_KEY"`
+	// APIRoot is the public origin of this API, such as https://api.textusm.com, used in thumbnail URLs.
+	APIRoot           string `required:"false" envconfig:"API_ROOT"`
+	EncryptPublicKey  string `required:"false" envconfig:"ENCRYPT_PUBLIC_KEY"`
+	EncryptPrivateKey string `required:"false" envconfig:"ENCRYPT_PRIVATE_KEY"`
+	// AccountDeletionGracePeriod is how long an account deletion can be cancelled. Due deletions are purged
+	// every AccountDeletionPurgeInterval, or never by this process when it is zero.
+	AccountDeletionGracePeriod   time.Duration `envconfig:"ACCOUNT_DELETION_GRACE_PERIOD" default:"168h"`
+	AccountDeletionPurgeInterval time.Duration `envconfig:"ACCOUNT_DELETION_PURGE_INTERVAL" default:"10m"`
+	// TrashRetention is how long deleted items stay in the trash. Expired items are purged every
+	// TrashPurgeInterval, or never by this process when it is zero.
+	TrashRetention     time.Duration `envconfig:"TRASH_RETENTION" default:"720h"`
+	TrashPurgeInterval time.Duration `envconfig:"TRASH_PURGE_INTERVAL" default:"1h"`
+	// CacheSize is how many settings and public items each cache keeps in memory, or zero for no caches. Changes
+	// reach the caches of other instances through PostgreSQL, so the caches are on by default with it only. With
+	// other databases a cached value may be read on another instance for up to CacheTTL after it changed.
+	CacheSize *int          `envconfig:"CACHE_SIZE"`
+	CacheTTL  time.Duration `envconfig:"CACHE_TTL" default:"1m"`
+	// MetricsEnabled serves the counters under /metrics. They are not authenticated, so only turn them on where
+	// the port is not reachable from the internet.
+	MetricsEnabled bool `envconfig:"METRICS_ENABLED" default:"false"`
+	// TokenRevocationCheckInterval is how long a verified ID token is trusted before it is checked for revocation
+	// again. Zero checks every request.
+	TokenRevocationCheckInterval time.Duration `envconfig:"TOKEN_REVOCATION_CHECK_INTERVAL" default:"5m"`
+	// Comma separated base64 PEM public keys kept valid after rotation, optionally suffixed with "@<RFC3339>".
+	EncryptPreviousPublicKeys string `required:"false" envconfig:"ENCRYPT_PREVIOUS_PUBLIC_KEYS"`
 }
 
 func NewEnv() (*Env, error) {
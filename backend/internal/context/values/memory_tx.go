@@ -0,0 +1,22 @@
+package values
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/samber/mo"
+)
+
+type memoryTxKey struct{}
+
+func GetMemoryTx(ctx context.Context) mo.Option[*memdb.Tx] {
+	v := ctx.Value(memoryTxKey{})
+	if v == nil {
+		return mo.None[*memdb.Tx]()
+	}
+	return mo.Some(v.(*memdb.Tx))
+}
+
+func WithMemoryTx(ctx context.Context, tx *memdb.Tx) context.Context {
+	return context.WithValue(ctx, memoryTxKey{}, tx)
+}
@@ -0,0 +1,193 @@
+// Package dataloader batches the lookups made while serving a request and caches their results for the rest of it.
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+const (
+	defaultWait     = time.Millisecond
+	defaultMaxBatch = 100
+)
+
+// BatchFunc looks up keys at once. Keys it returns no result for fail with NotFound.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) map[K]mo.Result[V]
+
+// Loader collects the keys loaded within a short wait into a single call of its BatchFunc, and remembers
+// the result of every key it has loaded. A Loader is meant to live for one request only.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[K]*entry[V]
+	pending *batch[K, V]
+}
+
+type entry[V any] struct {
+	done   chan struct{}
+	result mo.Result[V]
+}
+
+type batch[K comparable, V any] struct {
+	keys       []K
+	entries    []*entry[V]
+	dispatched bool
+}
+
+type Option func(*options)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// WithWait sets how long the first key of a batch waits for others to join it.
+func WithWait(wait time.Duration) Option {
+	return func(o *options) {
+		o.wait = wait
+	}
+}
+
+// WithMaxBatch sets the most keys passed to one call of the BatchFunc.
+func WithMaxBatch(maxBatch int) Option {
+	return func(o *options) {
+		o.maxBatch = maxBatch
+	}
+}
+
+func New[K comparable, V any](fetch BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	o := options{wait: defaultWait, maxBatch: defaultMaxBatch}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Loader[K, V]{fetch: fetch, wait: o.wait, maxBatch: o.maxBatch, cache: map[K]*entry[V]{}}
+}
+
+// Load returns the value of key, joining the pending batch unless the key has been loaded before.
+// The batch runs with the context of the Load that started it.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) mo.Result[V] {
+	l.mu.Lock()
+	ent, ok := l.cache[key]
+
+	if !ok {
+		ent = &entry[V]{done: make(chan struct{})}
+		l.cache[key] = ent
+		l.enqueue(ctx, key, ent)
+	}
+
+	l.mu.Unlock()
+
+	select {
+	case <-ent.done:
+		return ent.result
+	case <-ctx.Done():
+		return mo.Err[V](ctx.Err())
+	}
+}
+
+// Prime caches value for key, so that loading it needs no lookup. A key already loaded is left as is.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+
+	ent := &entry[V]{done: make(chan struct{}), result: mo.Ok(value)}
+	close(ent.done)
+	l.cache[key] = ent
+}
+
+// Clear forgets key, so that the next Load looks it up again.
+func (l *Loader[K, V]) Clear(key K) {
+	l.ClearFunc(func(k K) bool { return k == key })
+}
+
+// ClearFunc forgets every key match returns true for.
+func (l *Loader[K, V]) ClearFunc(match func(K) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for k := range l.cache {
+		if match(k) {
+			delete(l.cache, k)
+		}
+	}
+}
+
+// enqueue adds key to the pending batch. It must be called with l.mu held.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, ent *entry[V]) {
+	b := l.pending
+
+	if b == nil {
+		b = &batch[K, V]{}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(ctx, b) })
+	}
+
+	b.keys = append(b.keys, key)
+	b.entries = append(b.entries, ent)
+
+	if len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		b.dispatched = true
+		go l.run(ctx, b)
+	}
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+
+	if b.dispatched {
+		l.mu.Unlock()
+		return
+	}
+
+	b.dispatched = true
+
+	if l.pending == b {
+		l.pending = nil
+	}
+
+	l.mu.Unlock()
+	l.run(ctx, b)
+}
+
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.resolve(b, nil, fmt.Errorf("dataloader: %v", r))
+		}
+	}()
+
+	l.resolve(b, l.fetch(ctx, b.keys), nil)
+}
+
+// resolve hands the results of b to the waiting loads. With err every key of b fails with it.
+func (l *Loader[K, V]) resolve(b *batch[K, V], results map[K]mo.Result[V], err error) {
+	for i, key := range b.keys {
+		ent := b.entries[i]
+
+		switch result, ok := results[key]; {
+		case err != nil:
+			ent.result = mo.Err[V](err)
+		case ok:
+			ent.result = result
+		default:
+			ent.result = mo.Err[V](e.NotFoundError(fmt.Errorf("%v not found", key)))
+		}
+
+		close(ent.done)
+	}
+}
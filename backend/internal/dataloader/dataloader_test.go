@@ -0,0 +1,111 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) map[int]mo.Result[int] {
+	r.mu.Lock()
+	r.batches = append(r.batches, append([]int{}, keys...))
+	r.mu.Unlock()
+
+	results := map[int]mo.Result[int]{}
+
+	for _, key := range keys {
+		if key >= 0 {
+			results[key] = mo.Ok(key * 10)
+		}
+	}
+
+	return results
+}
+
+func loadAll(l *Loader[int, int], keys ...int) []mo.Result[int] {
+	var wg sync.WaitGroup
+	results := make([]mo.Result[int], len(keys))
+
+	for i, key := range keys {
+		wg.Go(func() {
+			results[i] = l.Load(context.Background(), key)
+		})
+	}
+
+	wg.Wait()
+	return results
+}
+
+func TestLoadBatchesConcurrentKeys(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, WithWait(10*time.Millisecond))
+
+	results := loadAll(l, 1, 2, 3, 2)
+
+	assert.Len(t, r.batches, 1)
+	assert.ElementsMatch(t, []int{1, 2, 3}, r.batches[0])
+	assert.Equal(t, 10, results[0].MustGet())
+	assert.Equal(t, 20, results[1].MustGet())
+	assert.Equal(t, 30, results[2].MustGet())
+	assert.Equal(t, 20, results[3].MustGet())
+}
+
+func TestLoadSplitsAtMaxBatch(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, WithWait(10*time.Millisecond), WithMaxBatch(2))
+
+	loadAll(l, 1, 2, 3, 4, 5)
+
+	total := 0
+
+	for _, b := range r.batches {
+		assert.LessOrEqual(t, len(b), 2)
+		total += len(b)
+	}
+
+	assert.Equal(t, 5, total)
+}
+
+func TestLoadCachesResults(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch)
+
+	assert.Equal(t, 10, l.Load(context.Background(), 1).MustGet())
+	assert.Equal(t, 10, l.Load(context.Background(), 1).MustGet())
+	assert.Len(t, r.batches, 1)
+
+	l.Clear(1)
+	l.Load(context.Background(), 1)
+	assert.Len(t, r.batches, 2)
+
+	l.Prime(2, 99)
+	assert.Equal(t, 99, l.Load(context.Background(), 2).MustGet())
+	assert.Len(t, r.batches, 2)
+}
+
+func TestLoadMissingKey(t *testing.T) {
+	l := New((&recorder{}).fetch)
+
+	result := l.Load(context.Background(), -1)
+
+	assert.True(t, result.IsError())
+	assert.Equal(t, e.NotFound, e.GetCode(result.Error()))
+}
+
+func TestLoadPanickingBatch(t *testing.T) {
+	l := New(func(ctx context.Context, keys []int) map[int]mo.Result[int] {
+		panic("boom")
+	})
+
+	assert.Error(t, l.Load(context.Background(), 1).Error())
+}
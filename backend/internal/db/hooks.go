@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"sync"
+)
+
+type txStateKey struct{}
+
+// txState is what a transaction knows about itself beyond the database handle the repositories use.
+type txState struct {
+	readOnly bool
+
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// withTxState starts the state of a new transaction, or of a new attempt when the transaction is retried.
+func withTxState(ctx context.Context, readOnly bool) (context.Context, *txState) {
+	state := &txState{readOnly: readOnly}
+	return context.WithValue(ctx, txStateKey{}, state), state
+}
+
+// committed runs the functions registered with AfterCommit, in the order they were registered.
+func (s *txState) committed() {
+	s.mu.Lock()
+	fns := s.afterCommit
+	s.afterCommit = nil
+	s.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// AfterCommit runs fn once the transaction in ctx has committed, and never when it is rolled back.
+// Outside a transaction fn runs right away.
+func AfterCommit(ctx context.Context, fn func()) {
+	state, ok := ctx.Value(txStateKey{}).(*txState)
+
+	if !ok {
+		fn()
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.afterCommit = append(state.afterCommit, fn)
+}
+
+// Writing reports whether ctx is in a transaction that may write, whose reads may see data that is not committed yet.
+func Writing(ctx context.Context) bool {
+	state, ok := ctx.Value(txStateKey{}).(*txState)
+	return ok && !state.readOnly
+}
@@ -0,0 +1,352 @@
+// Package memdb keeps the data of the in-memory backend.
+//
+// Tables hold their rows by value, so a write transaction works on a shallow copy of the tables and commits by
+// swapping the copy in: a transaction that fails leaves the data as it was. Write transactions are serialized
+// and read-only transactions run in parallel with each other.
+package memdb
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harehare/textusm/internal/domain/model/account"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	"github.com/harehare/textusm/internal/domain/model/user"
+)
+
+var (
+	// ErrNoRows is wrapped in the NotFound errors of the repositories, like sql.ErrNoRows in the SQLite ones.
+	ErrNoRows   = errors.New("memdb: no rows in result set")
+	ErrReadOnly = errors.New("memdb: write in a read-only transaction")
+)
+
+// Item is a diagram item or a gist item, told apart by Location as in the items table of the SQL backends.
+type Item struct {
+	UserID     string     `json:"uid"`
+	Location   string     `json:"location"`
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	Text       string     `json:"text"`
+	Thumbnail  *string    `json:"thumbnail,omitempty"`
+	Diagram    string     `json:"diagram"`
+	IsPublic   bool       `json:"isPublic"`
+	IsBookmark bool       `json:"isBookmark"`
+	Revision   string     `json:"revision,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+}
+
+func (i Item) Key() ItemKey {
+	return ItemKey{UserID: i.UserID, Location: i.Location, ID: i.ID}
+}
+
+type ItemKey struct {
+	UserID   string
+	Location string
+	ID       string
+}
+
+type Settings struct {
+	UserID   string            `json:"uid"`
+	Diagram  string            `json:"diagram"`
+	Settings settings.Settings `json:"settings"`
+}
+
+func (s Settings) Key() SettingsKey {
+	return SettingsKey{UserID: s.UserID, Diagram: s.Diagram}
+}
+
+type SettingsKey struct {
+	UserID  string
+	Diagram string
+}
+
+type Share struct {
+	HashKey        string    `json:"hashKey"`
+	UserID         string    `json:"uid"`
+	Location       string    `json:"location"`
+	ItemID         string    `json:"itemId"`
+	Token          string    `json:"token"`
+	Code           string    `json:"code,omitempty"`
+	Password       string    `json:"password,omitempty"`
+	AllowIPList    []string  `json:"allowIPList,omitempty"`
+	AllowEmailList []string  `json:"allowEmailList,omitempty"`
+	ExpireTime     int64     `json:"expireTime"`
+	RemainingViews *int      `json:"remainingViews,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type GithubToken struct {
+	UserID      string    `json:"uid"`
+	AccessToken string    `json:"accessToken"`
+	Scope       string    `json:"scope"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type Tables struct {
+	Items        map[ItemKey]Item
+	Settings     map[SettingsKey]Settings
+	Shares       map[string]Share
+	GithubTokens map[string]GithubToken
+	Images       map[string]image.Image
+	Deletions    map[string]account.Deletion
+	AuditLogs    []audit.Log
+	Users        map[string]user.User
+}
+
+func newTables() *Tables {
+	return &Tables{
+		Items:        map[ItemKey]Item{},
+		Settings:     map[SettingsKey]Settings{},
+		Shares:       map[string]Share{},
+		GithubTokens: map[string]GithubToken{},
+		Images:       map[string]image.Image{},
+		Deletions:    map[string]account.Deletion{},
+		AuditLogs:    []audit.Log{},
+		Users:        map[string]user.User{},
+	}
+}
+
+// clone copies the maps but not the rows, which are never modified in place. The audit logs are clipped so that
+// appending to the copy does not write to the backing array of the original.
+func (t *Tables) clone() *Tables {
+	return &Tables{
+		Items:        maps.Clone(t.Items),
+		Settings:     maps.Clone(t.Settings),
+		Shares:       maps.Clone(t.Shares),
+		GithubTokens: maps.Clone(t.GithubTokens),
+		Images:       maps.Clone(t.Images),
+		Deletions:    maps.Clone(t.Deletions),
+		AuditLogs:    slices.Clip(t.AuditLogs),
+		Users:        maps.Clone(t.Users),
+	}
+}
+
+type DB struct {
+	mu     sync.RWMutex
+	tables *Tables
+	path   string
+}
+
+// New returns an empty database that is not persisted.
+func New() *DB {
+	return &DB{tables: newTables()}
+}
+
+// Open returns a database loaded from the JSON snapshot at path, or an empty one when the file does not exist
+// yet. Close writes the snapshot back. An empty path opens a database that is not persisted.
+func Open(path string) (*DB, error) {
+	db := New()
+	db.path = path
+
+	if path == "" {
+		return db, nil
+	}
+
+	f, err := os.Open(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return db, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if err := db.Load(f); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Close writes the snapshot to the path the database was opened with, if any.
+func (db *DB) Close() error {
+	if db.path == "" {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	if err := db.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), db.path)
+}
+
+// Begin starts a transaction. A write transaction holds the database until it is committed or rolled back.
+func (db *DB) Begin(readOnly bool) *Tx {
+	if readOnly {
+		db.mu.RLock()
+		return &Tx{db: db, tables: db.tables, readOnly: true}
+	}
+
+	db.mu.Lock()
+	return &Tx{db: db, tables: db.tables.clone()}
+}
+
+type Tx struct {
+	db       *DB
+	tables   *Tables
+	readOnly bool
+	done     bool
+}
+
+func (tx *Tx) Tables() *Tables {
+	return tx.tables
+}
+
+func (tx *Tx) ReadOnly() bool {
+	return tx.readOnly
+}
+
+func (tx *Tx) Commit() {
+	if tx.done {
+		return
+	}
+
+	if !tx.readOnly {
+		tx.db.tables = tx.tables
+	}
+
+	tx.end()
+}
+
+// Rollback discards the changes of the transaction. It does nothing once the transaction has been committed.
+func (tx *Tx) Rollback() {
+	if tx.done {
+		return
+	}
+
+	tx.end()
+}
+
+func (tx *Tx) end() {
+	tx.done = true
+
+	if tx.readOnly {
+		tx.db.mu.RUnlock()
+	} else {
+		tx.db.mu.Unlock()
+	}
+}
+
+// snapshot is the JSON form of the tables, with rows sorted so that fixtures diff well.
+type snapshot struct {
+	Items        []Item             `json:"items"`
+	Settings     []Settings         `json:"settings"`
+	Shares       []Share            `json:"shares"`
+	GithubTokens []GithubToken      `json:"githubTokens"`
+	Images       []image.Image      `json:"images"`
+	Deletions    []account.Deletion `json:"accountDeletions"`
+	AuditLogs    []audit.Log        `json:"auditLogs"`
+	Users        []user.User        `json:"users"`
+}
+
+// Snapshot writes every table to w as JSON.
+func (db *DB) Snapshot(w io.Writer) error {
+	tx := db.Begin(true)
+	defer tx.Rollback()
+
+	t := tx.Tables()
+	s := snapshot{
+		Items: sortedValues(t.Items, func(a, b Item) int {
+			return strings.Compare(a.UserID+"\x00"+a.Location+"\x00"+a.ID, b.UserID+"\x00"+b.Location+"\x00"+b.ID)
+		}),
+		Settings: sortedValues(t.Settings, func(a, b Settings) int {
+			return strings.Compare(a.UserID+"\x00"+a.Diagram, b.UserID+"\x00"+b.Diagram)
+		}),
+		Shares:       sortedValues(t.Shares, func(a, b Share) int { return strings.Compare(a.HashKey, b.HashKey) }),
+		GithubTokens: sortedValues(t.GithubTokens, func(a, b GithubToken) int { return strings.Compare(a.UserID, b.UserID) }),
+		Images:       sortedValues(t.Images, func(a, b image.Image) int { return strings.Compare(a.ID, b.ID) }),
+		Deletions:    sortedValues(t.Deletions, func(a, b account.Deletion) int { return strings.Compare(a.ID, b.ID) }),
+		AuditLogs:    t.AuditLogs,
+		Users:        sortedValues(t.Users, func(a, b user.User) int { return strings.Compare(a.UID, b.UID) }),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(s)
+}
+
+// Load replaces every table with the JSON snapshot read from r.
+func (db *DB) Load(r io.Reader) error {
+	var s snapshot
+
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+
+	t := newTables()
+
+	for _, i := range s.Items {
+		t.Items[i.Key()] = i
+	}
+
+	for _, ss := range s.Settings {
+		t.Settings[ss.Key()] = ss
+	}
+
+	for _, sh := range s.Shares {
+		t.Shares[sh.HashKey] = sh
+	}
+
+	for _, token := range s.GithubTokens {
+		t.GithubTokens[token.UserID] = token
+	}
+
+	for _, img := range s.Images {
+		t.Images[img.ID] = img
+	}
+
+	for _, d := range s.Deletions {
+		t.Deletions[d.ID] = d
+	}
+
+	for _, u := range s.Users {
+		t.Users[u.UID] = u
+	}
+
+	t.AuditLogs = append(t.AuditLogs, s.AuditLogs...)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.tables = t
+
+	return nil
+}
+
+func sortedValues[K comparable, V any](m map[K]V, cmp func(a, b V) int) []V {
+	values := slices.AppendSeq(make([]V, 0, len(m)), maps.Values(m))
+	slices.SortFunc(values, cmp)
+	return values
+}
@@ -0,0 +1,69 @@
+package memdb
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	db := New()
+	key := ItemKey{UserID: "user", Location: "system", ID: "item"}
+
+	tx := db.Begin(false)
+	tx.Tables().Items[key] = Item{UserID: "user", Location: "system", ID: "item"}
+	tx.Tables().AuditLogs = append(tx.Tables().AuditLogs, audit.Log{UserID: "user"})
+	tx.Rollback()
+
+	tx = db.Begin(true)
+	defer tx.Rollback()
+
+	assert.Empty(t, tx.Tables().Items)
+	assert.Empty(t, tx.Tables().AuditLogs)
+}
+
+func TestCommit(t *testing.T) {
+	db := New()
+	key := ItemKey{UserID: "user", Location: "system", ID: "item"}
+
+	tx := db.Begin(false)
+	tx.Tables().Items[key] = Item{UserID: "user", Location: "system", ID: "item"}
+	tx.Commit()
+	tx.Rollback()
+
+	tx = db.Begin(true)
+	defer tx.Rollback()
+
+	assert.Contains(t, tx.Tables().Items, key)
+}
+
+func TestSnapshot(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "textusm.json")
+
+	db, err := Open(path)
+	require.NoError(t, err)
+
+	tx := db.Begin(false)
+	tx.Tables().Items[ItemKey{UserID: "user", Location: "system", ID: "b"}] = Item{UserID: "user", Location: "system", ID: "b", Title: "b", CreatedAt: now, UpdatedAt: now, DeletedAt: &now}
+	tx.Tables().Items[ItemKey{UserID: "user", Location: "gist", ID: "a"}] = Item{UserID: "user", Location: "gist", ID: "a", Title: "a", CreatedAt: now, UpdatedAt: now}
+	tx.Tables().GithubTokens["user"] = GithubToken{UserID: "user", AccessToken: "token", CreatedAt: now, UpdatedAt: now}
+	tx.Commit()
+
+	require.NoError(t, db.Close())
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+
+	var want, got bytes.Buffer
+	require.NoError(t, db.Snapshot(&want))
+	require.NoError(t, reopened.Snapshot(&got))
+
+	assert.JSONEq(t, want.String(), got.String())
+	assert.Contains(t, got.String(), `"deletedAt": "2024-01-02T03:04:05Z"`)
+}
@@ -0,0 +1,208 @@
+// Package migration applies the dbmate migrations embedded in the binary. Applied versions are recorded in the
+// schema_migrations table used by dbmate, so databases migrated by hand and by the server can be mixed.
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrSchemaTooNew      = errors.New("database schema is newer than this binary")
+	ErrPendingMigrations = errors.New("database schema has pending migrations")
+)
+
+const (
+	upMarker   = "-- migrate:up"
+	downMarker = "-- migrate:down"
+)
+
+// Migration is a single dbmate migration file.
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+}
+
+// Driver applies migrations to one kind of database.
+type Driver interface {
+	// WithLock runs fn while no other process can migrate the database.
+	WithLock(ctx context.Context, fn func(ctx context.Context) error) error
+	// Applied returns the applied versions, or none when the schema_migrations table does not exist yet.
+	Applied(ctx context.Context) ([]string, error)
+	// Apply runs the migration and records its version in one transaction. It does nothing when the
+	// version has already been applied.
+	Apply(ctx context.Context, m Migration) error
+}
+
+// Status compares the applied migrations with the embedded ones.
+type Status struct {
+	Current string   `json:"current"`
+	Latest  string   `json:"latest"`
+	Pending []string `json:"pending"`
+	// Unknown are applied versions newer than any embedded migration.
+	Unknown []string `json:"unknown"`
+}
+
+// UpToDate reports whether every embedded migration has been applied and none is unknown.
+func (s *Status) UpToDate() bool {
+	return len(s.Pending) == 0 && len(s.Unknown) == 0
+}
+
+// Err returns ErrSchemaTooNew or ErrPendingMigrations when the schema does not match the binary.
+func (s *Status) Err() error {
+	if len(s.Unknown) > 0 {
+		return fmt.Errorf("%w: %s is applied but the latest known migration is %s", ErrSchemaTooNew, s.Unknown[len(s.Unknown)-1], s.Latest)
+	}
+
+	if len(s.Pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(s.Pending, ", "))
+	}
+
+	return nil
+}
+
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+}
+
+func New(driver Driver, migrations []Migration) *Migrator {
+	return &Migrator{driver: driver, migrations: migrations}
+}
+
+// Load reads the migrations in fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(files))
+
+	for _, file := range files {
+		version, _, ok := strings.Cut(file, "_")
+
+		if !ok || version == "" {
+			return nil, fmt.Errorf("migration %s: file name does not start with a version", file)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		up, err := parseUp(string(content))
+
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: file, Up: up})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return strings.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+func parseUp(content string) (string, error) {
+	_, up, ok := strings.Cut(content, upMarker)
+
+	if !ok {
+		return "", fmt.Errorf("%q not found", upMarker)
+	}
+
+	up, _, _ = strings.Cut(up, downMarker)
+	options, up, _ := strings.Cut(up, "\n")
+
+	if strings.Contains(options, "transaction:false") {
+		return "", errors.New("migrations outside a transaction are not supported")
+	}
+
+	return strings.TrimSpace(up), nil
+}
+
+// Status reads the applied versions from the database.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	applied, err := m.driver.Applied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.status(applied), nil
+}
+
+func (m *Migrator) status(applied []string) *Status {
+	status := Status{Pending: []string{}, Unknown: []string{}}
+	known := make(map[string]struct{}, len(m.migrations))
+
+	if len(m.migrations) > 0 {
+		status.Latest = m.migrations[len(m.migrations)-1].Version
+	}
+
+	for _, migration := range m.migrations {
+		known[migration.Version] = struct{}{}
+
+		if !slices.Contains(applied, migration.Version) {
+			status.Pending = append(status.Pending, migration.Version)
+		}
+	}
+
+	slices.Sort(applied)
+
+	for _, version := range applied {
+		if _, ok := known[version]; !ok && version > status.Latest {
+			status.Unknown = append(status.Unknown, version)
+		}
+
+		status.Current = version
+	}
+
+	return &status
+}
+
+// Up applies the pending migrations and returns their versions. It fails with ErrSchemaTooNew, without applying
+// anything, when the database has been migrated by a newer binary.
+func (m *Migrator) Up(ctx context.Context) ([]string, error) {
+	var applied []string
+
+	err := m.driver.WithLock(ctx, func(ctx context.Context) error {
+		status, err := m.Status(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if len(status.Unknown) > 0 {
+			return status.Err()
+		}
+
+		for _, migration := range m.migrations {
+			if !slices.Contains(status.Pending, migration.Version) {
+				continue
+			}
+
+			if err := m.driver.Apply(ctx, migration); err != nil {
+				return fmt.Errorf("migration %s: %w", migration.Name, err)
+			}
+
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+			applied = append(applied, migration.Version)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
@@ -0,0 +1,103 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	schema "github.com/harehare/textusm/db"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSqliteMigrator(t *testing.T) (*Migrator, *sql.DB) {
+	t.Helper()
+
+	db, err := sqlitedb.Open(context.Background(), ":memory:", 1)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := Load(schema.SqliteMigrations())
+	require.NoError(t, err)
+
+	return New(NewSqliteDriver(db.Writer()), migrations), db.Writer()
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20240102000000_second.sql": {Data: []byte("-- migrate:up\nCREATE TABLE b (id integer);\n\n-- migrate:down\nDROP TABLE b;\n")},
+		"20240101000000_first.sql":  {Data: []byte("-- migrate:up\nCREATE TABLE a (id integer);\n")},
+	}
+
+	migrations, err := Load(fsys)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: "20240101000000", Name: "20240101000000_first.sql", Up: "CREATE TABLE a (id integer);"},
+		{Version: "20240102000000", Name: "20240102000000_second.sql", Up: "CREATE TABLE b (id integer);"},
+	}, migrations)
+
+	_, err = Load(fstest.MapFS{"20240101000000_first.sql": {Data: []byte("-- migrate:up transaction:false\nVACUUM;\n")}})
+	assert.Error(t, err)
+}
+
+func TestSqliteUp(t *testing.T) {
+	ctx := context.Background()
+	m, conn := newSqliteMigrator(t)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Len(t, status.Pending, len(m.migrations))
+	assert.ErrorIs(t, status.Err(), ErrPendingMigrations)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(m.migrations))
+
+	_, err = conn.ExecContext(ctx, "SELECT deleted_at FROM items")
+	require.NoError(t, err)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.UpToDate())
+	assert.Equal(t, status.Latest, status.Current)
+}
+
+func TestSqliteUpRefusesNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	m, conn := newSqliteMigrator(t)
+
+	_, err := conn.ExecContext(ctx, "CREATE TABLE schema_migrations (version varchar(128) PRIMARY KEY); INSERT INTO schema_migrations VALUES ('99990101000000')")
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+
+	assert.True(t, errors.Is(err, ErrSchemaTooNew), "Up() error = %v", err)
+	assert.Empty(t, applied)
+
+	var tables int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE name = 'items'").Scan(&tables))
+	assert.Zero(t, tables, "nothing should be applied to a newer schema")
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts := splitStatements("CREATE TABLE a (\n  id int\n);\n\nCREATE TABLE b (id int);\nDROP TABLE c")
+
+	assert.Equal(t, []string{"CREATE TABLE a (\n  id int\n);", "CREATE TABLE b (id int);", "DROP TABLE c"}, stmts)
+
+	migrations, err := Load(schema.MysqlMigrations())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for _, stmt := range splitStatements(migrations[0].Up) {
+		assert.True(t, strings.HasPrefix(stmt, "CREATE TABLE"), stmt)
+	}
+}
@@ -0,0 +1,142 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// mysqlLockName is the named lock held while migrating. It is the same for every textusm process.
+const mysqlLockName = "textusm_schema_migrations"
+
+type mysqlDriver struct {
+	db *sql.DB
+}
+
+func NewMysqlDriver(db *sql.DB) Driver {
+	return &mysqlDriver{db: db}
+}
+
+// WithLock holds the named lock on a dedicated connection, since MySQL releases it when the connection that
+// took it is closed.
+func (d *mysqlDriver) WithLock(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	conn, err := d.db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	var locked sql.NullInt64
+
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", mysqlLockName).Scan(&locked); err != nil {
+		return err
+	}
+
+	if locked.Int64 != 1 {
+		return errors.New("failed to acquire the migration lock")
+	}
+
+	defer func() {
+		if _, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", mysqlLockName); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func (d *mysqlDriver) Applied(ctx context.Context) ([]string, error) {
+	var name string
+
+	err := d.db.QueryRowContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'").Scan(&name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return []string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	versions := []string{}
+
+	for rows.Next() {
+		var version string
+
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// Apply cannot run the migration in a transaction, because MySQL commits implicitly after every DDL statement.
+// The version is recorded once all statements have run, so a migration that fails halfway is retried from the
+// start and has to be fixed by hand first. WithLock keeps two processes from applying it at the same time.
+func (d *mysqlDriver) Apply(ctx context.Context, m Migration) error {
+	if _, err := d.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version varchar(128) NOT NULL PRIMARY KEY)"); err != nil {
+		return err
+	}
+
+	var version string
+
+	err := d.db.QueryRowContext(ctx, "SELECT version FROM schema_migrations WHERE version = ?", m.Version).Scan(&version)
+
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for _, stmt := range splitStatements(m.Up) {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	_, err = d.db.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES (?)", m.Version)
+	return err
+}
+
+// splitStatements splits a migration into the statements ending with a semicolon at the end of a line, as the
+// driver sends one statement at a time.
+func splitStatements(up string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	for line := range strings.Lines(up) {
+		current.WriteString(line)
+
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if stmt := strings.TrimSpace(current.String()); stmt != ";" {
+				stmts = append(stmts, stmt)
+			}
+
+			current.Reset()
+		}
+	}
+
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts
+}
@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresLockKey is the advisory lock held while migrating. It is the same for every textusm process.
+const postgresLockKey = 0x7465787475736d
+
+type postgresDriver struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresDriver(pool *pgxpool.Pool) Driver {
+	return &postgresDriver{pool: pool}
+}
+
+func (d *postgresDriver) WithLock(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	conn, err := d.pool.Acquire(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", postgresLockKey); err != nil {
+		return err
+	}
+
+	defer func() {
+		if _, unlockErr := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", postgresLockKey); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func (d *postgresDriver) Applied(ctx context.Context) ([]string, error) {
+	var exists bool
+
+	if err := d.pool.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return []string{}, nil
+	}
+
+	rows, err := d.pool.Query(ctx, "SELECT version FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (d *postgresDriver) Apply(ctx context.Context, m Migration) error {
+	return pgx.BeginFunc(ctx, d.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version varchar(128) PRIMARY KEY)"); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT DO NOTHING", m.Version)
+
+		if err != nil || result.RowsAffected() == 0 {
+			return err
+		}
+
+		// Without arguments the migration is sent with the simple protocol, which allows several statements.
+		_, err = tx.Exec(ctx, m.Up)
+		return err
+	})
+}
@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqliteDriver struct {
+	db *sql.DB
+}
+
+func NewSqliteDriver(db *sql.DB) Driver {
+	return &sqliteDriver{db: db}
+}
+
+// WithLock does not lock anything: SQLite has no advisory locks, so Apply takes the write lock of the
+// database for each migration instead.
+func (d *sqliteDriver) WithLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (d *sqliteDriver) Applied(ctx context.Context) ([]string, error) {
+	var name string
+
+	err := d.db.QueryRowContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return []string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	versions := []string{}
+
+	for rows.Next() {
+		var version string
+
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// Apply uses BEGIN IMMEDIATE rather than a database/sql transaction so that the write lock is taken before
+// the version is checked, which keeps two processes from applying the same migration.
+func (d *sqliteDriver) Apply(ctx context.Context, m Migration) (err error) {
+	conn, err := d.db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_, rollbackErr := conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version varchar(128) PRIMARY KEY)"); err != nil {
+		return err
+	}
+
+	result, err := conn.ExecContext(ctx, "INSERT OR IGNORE INTO schema_migrations (version) VALUES (?)", m.Version)
+
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		if _, err := conn.ExecContext(ctx, m.Up); err != nil {
+			return err
+		}
+	}
+
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	return err
+}
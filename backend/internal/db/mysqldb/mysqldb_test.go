@@ -0,0 +1,34 @@
+package mysqldb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		addr   string
+		user   string
+		dbName string
+	}{
+		{dsn: "mysql://textusm:secret@db:3307/textusm?tls=false", addr: "db:3307", user: "textusm", dbName: "textusm"},
+		{dsn: "mysql://textusm:secret@db/textusm", addr: "db:3306", user: "textusm", dbName: "textusm"},
+		{dsn: "textusm:secret@tcp(db:3307)/textusm", addr: "db:3307", user: "textusm", dbName: "textusm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			cfg, err := parse(tt.dsn)
+
+			require.NoError(t, err)
+			assert.Equal(t, "tcp", cfg.Net)
+			assert.Equal(t, tt.addr, cfg.Addr)
+			assert.Equal(t, tt.user, cfg.User)
+			assert.Equal(t, "secret", cfg.Passwd)
+			assert.Equal(t, tt.dbName, cfg.DBName)
+		})
+	}
+}
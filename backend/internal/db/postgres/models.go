@@ -112,6 +112,48 @@ func (ns NullLocation) Value() (driver.Value, error) {
 	return string(ns.Location), nil
 }
 
+type AccountDeletion struct {
+	ID          int64
+	DeletionID  pgtype.UUID
+	Uid         string
+	Status      string
+	RequestedAt pgtype.Timestamp
+	ScheduledAt pgtype.Timestamp
+	LeaseUntil  pgtype.Timestamp
+	CompletedAt pgtype.Timestamp
+	Receipt     *string
+}
+
+type AuditLog struct {
+	ID        int64
+	Uid       string
+	Event     string
+	TargetID  string
+	Detail    string
+	CreatedAt pgtype.Timestamp
+}
+
+type GithubToken struct {
+	ID          int64
+	Uid         string
+	AccessToken string
+	Scope       string
+	CreatedAt   pgtype.Timestamp
+	UpdatedAt   pgtype.Timestamp
+}
+
+type Image struct {
+	ID          int64
+	ImageID     pgtype.UUID
+	Uid         string
+	DiagramID   pgtype.UUID
+	Location    Location
+	ContentType string
+	Size        int64
+	Hash        string
+	CreatedAt   pgtype.Timestamp
+}
+
 type Item struct {
 	ID         int64
 	Uid        string
@@ -125,6 +167,8 @@ type Item struct {
 	Thumbnail  *string
 	CreatedAt  pgtype.Timestamp
 	UpdatedAt  pgtype.Timestamp
+	Revision   *string
+	DeletedAt  pgtype.Timestamp
 }
 
 type SchemaMigration struct {
@@ -170,4 +214,6 @@ type ShareCondition struct {
 	Token          string
 	CreatedAt      pgtype.Timestamp
 	UpdatedAt      pgtype.Timestamp
+	Code           *string
+	RemainingViews *int32
 }
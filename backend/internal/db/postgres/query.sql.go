@@ -11,6 +11,106 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelAccountDeletion = `-- name: CancelAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'cancelled'
+WHERE
+  deletion_id = $1
+  AND status = 'pending'
+`
+
+func (q *Queries) CancelAccountDeletion(ctx context.Context, deletionID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelAccountDeletion, deletionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimAccountDeletion = `-- name: ClaimAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'purging',
+  lease_until = $2
+WHERE
+  deletion_id = $1
+  AND scheduled_at <= $3
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < $3
+    )
+  )
+`
+
+type ClaimAccountDeletionParams struct {
+	DeletionID  pgtype.UUID
+	LeaseUntil  pgtype.Timestamp
+	ScheduledAt pgtype.Timestamp
+}
+
+func (q *Queries) ClaimAccountDeletion(ctx context.Context, arg ClaimAccountDeletionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimAccountDeletion, arg.DeletionID, arg.LeaseUntil, arg.ScheduledAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const completeAccountDeletion = `-- name: CompleteAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'completed',
+  lease_until = NULL,
+  completed_at = $2,
+  receipt = $3
+WHERE
+  deletion_id = $1
+  AND status = 'purging'
+`
+
+type CompleteAccountDeletionParams struct {
+	DeletionID  pgtype.UUID
+	CompletedAt pgtype.Timestamp
+	Receipt     *string
+}
+
+func (q *Queries) CompleteAccountDeletion(ctx context.Context, arg CompleteAccountDeletionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeAccountDeletion, arg.DeletionID, arg.CompletedAt, arg.Receipt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createAccountDeletion = `-- name: CreateAccountDeletion :exec
+INSERT INTO
+  account_deletions (deletion_id, uid, status, requested_at, scheduled_at)
+VALUES
+  ($1, $2, $3, $4, $5)
+`
+
+type CreateAccountDeletionParams struct {
+	DeletionID  pgtype.UUID
+	Uid         string
+	Status      string
+	RequestedAt pgtype.Timestamp
+	ScheduledAt pgtype.Timestamp
+}
+
+func (q *Queries) CreateAccountDeletion(ctx context.Context, arg CreateAccountDeletionParams) error {
+	_, err := q.db.Exec(ctx, createAccountDeletion,
+		arg.DeletionID,
+		arg.Uid,
+		arg.Status,
+		arg.RequestedAt,
+		arg.ScheduledAt,
+	)
+	return err
+}
+
 const createItem = `-- name: CreateItem :exec
 INSERT INTO
   items (
@@ -22,10 +122,13 @@ INSERT INTO
     title,
     text,
     thumbnail,
-    location
+    location,
+    revision,
+    created_at,
+    updated_at
   )
 VALUES
-  ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 `
 
 type CreateItemParams struct {
@@ -38,6 +141,9 @@ type CreateItemParams struct {
 	Text       string
 	Thumbnail  *string
 	Location   Location
+	Revision   *string
+	CreatedAt  pgtype.Timestamp
+	UpdatedAt  pgtype.Timestamp
 }
 
 func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) error {
@@ -51,6 +157,9 @@ func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) error {
 		arg.Text,
 		arg.Thumbnail,
 		arg.Location,
+		arg.Revision,
+		arg.CreatedAt,
+		arg.UpdatedAt,
 	)
 	return err
 }
@@ -76,7 +185,8 @@ INSERT INTO
     task_color,
     task_background_color,
     width,
-    zoom_control
+    zoom_control,
+    font
   )
 VALUES
   (
@@ -98,7 +208,8 @@ VALUES
     $16,
     $17,
     $18,
-    $19
+    $19,
+    $20
   )
 `
 
@@ -122,6 +233,7 @@ type CreateSettingsParams struct {
 	TaskBackgroundColor     string
 	Width                   int32
 	ZoomControl             *bool
+	Font                    string
 }
 
 func (q *Queries) CreateSettings(ctx context.Context, arg CreateSettingsParams) error {
@@ -145,6 +257,7 @@ func (q *Queries) CreateSettings(ctx context.Context, arg CreateSettingsParams)
 		arg.TaskBackgroundColor,
 		arg.Width,
 		arg.ZoomControl,
+		arg.Font,
 	)
 	return err
 }
@@ -160,10 +273,12 @@ INSERT INTO
     allow_email_list,
     expire_time,
     password,
-    token
+    token,
+    code,
+    remaining_views
   )
 VALUES
-  ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 `
 
 type CreateShareConditionParams struct {
@@ -176,6 +291,8 @@ type CreateShareConditionParams struct {
 	ExpireTime     *int64
 	Password       *string
 	Token          string
+	Code           *string
+	RemainingViews *int32
 }
 
 func (q *Queries) CreateShareCondition(ctx context.Context, arg CreateShareConditionParams) error {
@@ -189,18 +306,90 @@ func (q *Queries) CreateShareCondition(ctx context.Context, arg CreateShareCondi
 		arg.ExpireTime,
 		arg.Password,
 		arg.Token,
+		arg.Code,
+		arg.RemainingViews,
 	)
 	return err
 }
 
+const decrementShareConditionViews = `-- name: DecrementShareConditionViews :one
+UPDATE share_conditions
+SET
+  remaining_views = remaining_views - 1,
+  updated_at = NOW()
+WHERE
+  hashkey = $1
+  AND remaining_views > 0
+RETURNING
+  remaining_views
+`
+
+func (q *Queries) DecrementShareConditionViews(ctx context.Context, hashkey string) (*int32, error) {
+	row := q.db.QueryRow(ctx, decrementShareConditionViews, hashkey)
+	var remaining_views *int32
+	err := row.Scan(&remaining_views)
+	return remaining_views, err
+}
+
+const deleteGithubToken = `-- name: DeleteGithubToken :exec
+DELETE FROM github_tokens
+WHERE
+  uid = $1
+`
+
+func (q *Queries) DeleteGithubToken(ctx context.Context, uid string) error {
+	_, err := q.db.Exec(ctx, deleteGithubToken, uid)
+	return err
+}
+
+const deleteImage = `-- name: DeleteImage :exec
+DELETE FROM images
+WHERE
+  uid = $1
+  AND image_id = $2
+`
+
+type DeleteImageParams struct {
+	Uid     string
+	ImageID pgtype.UUID
+}
+
+func (q *Queries) DeleteImage(ctx context.Context, arg DeleteImageParams) error {
+	_, err := q.db.Exec(ctx, deleteImage, arg.Uid, arg.ImageID)
+	return err
+}
+
 const deleteItem = `-- name: DeleteItem :exec
 DELETE FROM items
 WHERE
-  diagram_id = $1
+  uid = $1
+  AND diagram_id = $2
+`
+
+type DeleteItemParams struct {
+	Uid       string
+	DiagramID pgtype.UUID
+}
+
+func (q *Queries) DeleteItem(ctx context.Context, arg DeleteItemParams) error {
+	_, err := q.db.Exec(ctx, deleteItem, arg.Uid, arg.DiagramID)
+	return err
+}
+
+const deleteSettings = `-- name: DeleteSettings :exec
+DELETE FROM settings
+WHERE
+  uid = $1
+  AND diagram = $2
 `
 
-func (q *Queries) DeleteItem(ctx context.Context, diagramID pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteItem, diagramID)
+type DeleteSettingsParams struct {
+	Uid     string
+	Diagram Diagram
+}
+
+func (q *Queries) DeleteSettings(ctx context.Context, arg DeleteSettingsParams) error {
+	_, err := q.db.Exec(ctx, deleteSettings, arg.Uid, arg.Diagram)
 	return err
 }
 
@@ -232,23 +421,166 @@ func (q *Queries) DeleteShareConditionItem(ctx context.Context, arg DeleteShareC
 	return err
 }
 
+const getAccountDeletion = `-- name: GetAccountDeletion :one
+SELECT
+  id, deletion_id, uid, status, requested_at, scheduled_at, lease_until, completed_at, receipt
+FROM
+  account_deletions
+WHERE
+  deletion_id = $1
+`
+
+func (q *Queries) GetAccountDeletion(ctx context.Context, deletionID pgtype.UUID) (AccountDeletion, error) {
+	row := q.db.QueryRow(ctx, getAccountDeletion, deletionID)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.ID,
+		&i.DeletionID,
+		&i.Uid,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ScheduledAt,
+		&i.LeaseUntil,
+		&i.CompletedAt,
+		&i.Receipt,
+	)
+	return i, err
+}
+
+const getActiveAccountDeletion = `-- name: GetActiveAccountDeletion :one
+SELECT
+  id, deletion_id, uid, status, requested_at, scheduled_at, lease_until, completed_at, receipt
+FROM
+  account_deletions
+WHERE
+  uid = $1
+  AND status IN ('pending', 'purging')
+`
+
+func (q *Queries) GetActiveAccountDeletion(ctx context.Context, uid string) (AccountDeletion, error) {
+	row := q.db.QueryRow(ctx, getActiveAccountDeletion, uid)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.ID,
+		&i.DeletionID,
+		&i.Uid,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ScheduledAt,
+		&i.LeaseUntil,
+		&i.CompletedAt,
+		&i.Receipt,
+	)
+	return i, err
+}
+
+const getGithubToken = `-- name: GetGithubToken :one
+SELECT
+  id, uid, access_token, scope, created_at, updated_at
+FROM
+  github_tokens
+WHERE
+  uid = $1
+`
+
+func (q *Queries) GetGithubToken(ctx context.Context, uid string) (GithubToken, error) {
+	row := q.db.QueryRow(ctx, getGithubToken, uid)
+	var i GithubToken
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.AccessToken,
+		&i.Scope,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getImage = `-- name: GetImage :one
+SELECT
+  id, image_id, uid, diagram_id, location, content_type, size, hash, created_at
+FROM
+  images
+WHERE
+  image_id = $1
+`
+
+func (q *Queries) GetImage(ctx context.Context, imageID pgtype.UUID) (Image, error) {
+	row := q.db.QueryRow(ctx, getImage, imageID)
+	var i Image
+	err := row.Scan(
+		&i.ID,
+		&i.ImageID,
+		&i.Uid,
+		&i.DiagramID,
+		&i.Location,
+		&i.ContentType,
+		&i.Size,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImageUsage = `-- name: GetImageUsage :one
+SELECT
+  COALESCE(SUM(size), 0)::bigint AS usage
+FROM
+  images
+WHERE
+  uid = $1
+`
+
+func (q *Queries) GetImageUsage(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRow(ctx, getImageUsage, uid)
+	var usage int64
+	err := row.Scan(&usage)
+	return usage, err
+}
+
 const getItem = `-- name: GetItem :one
 SELECT
-  id, uid, diagram_id, location, diagram, is_bookmark, is_public, title, text, thumbnail, created_at, updated_at
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN $1::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN $2::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
 FROM
   items
 WHERE
-  location = $1
-  AND diagram_id = $2
+  location = $3
+  AND diagram_id = $4
 `
 
 type GetItemParams struct {
-	Location  Location
-	DiagramID pgtype.UUID
+	LoadText      bool
+	LoadThumbnail bool
+	Location      Location
+	DiagramID     pgtype.UUID
 }
 
 func (q *Queries) GetItem(ctx context.Context, arg GetItemParams) (Item, error) {
-	row := q.db.QueryRow(ctx, getItem, arg.Location, arg.DiagramID)
+	row := q.db.QueryRow(ctx, getItem,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Location,
+		arg.DiagramID,
+	)
 	var i Item
 	err := row.Scan(
 		&i.ID,
@@ -263,6 +595,8 @@ func (q *Queries) GetItem(ctx context.Context, arg GetItemParams) (Item, error)
 		&i.Thumbnail,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Revision,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -309,7 +643,7 @@ func (q *Queries) GetSettings(ctx context.Context, diagram Diagram) (Setting, er
 
 const getShareCondition = `-- name: GetShareCondition :one
 SELECT
-  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at
+  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at, code, remaining_views
 FROM
   share_conditions
 WHERE
@@ -332,13 +666,46 @@ func (q *Queries) GetShareCondition(ctx context.Context, hashkey string) (ShareC
 		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Code,
+		&i.RemainingViews,
+	)
+	return i, err
+}
+
+const getShareConditionByCode = `-- name: GetShareConditionByCode :one
+SELECT
+  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at, code, remaining_views
+FROM
+  share_conditions
+WHERE
+  code = $1
+`
+
+func (q *Queries) GetShareConditionByCode(ctx context.Context, code *string) (ShareCondition, error) {
+	row := q.db.QueryRow(ctx, getShareConditionByCode, code)
+	var i ShareCondition
+	err := row.Scan(
+		&i.ID,
+		&i.Hashkey,
+		&i.Uid,
+		&i.DiagramID,
+		&i.Location,
+		&i.AllowIpList,
+		&i.AllowEmailList,
+		&i.ExpireTime,
+		&i.Password,
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Code,
+		&i.RemainingViews,
 	)
 	return i, err
 }
 
 const getShareConditionItem = `-- name: GetShareConditionItem :one
 SELECT
-  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at
+  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at, code, remaining_views
 FROM
   share_conditions
 WHERE
@@ -367,61 +734,112 @@ func (q *Queries) GetShareConditionItem(ctx context.Context, arg GetShareConditi
 		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Code,
+		&i.RemainingViews,
 	)
 	return i, err
 }
 
-const listItems = `-- name: ListItems :many
+const insertAuditLog = `-- name: InsertAuditLog :exec
+INSERT INTO
+  audit_logs (uid, event, target_id, detail, created_at)
+VALUES
+  ($1, $2, $3, $4, $5)
+`
+
+type InsertAuditLogParams struct {
+	Uid       string
+	Event     string
+	TargetID  string
+	Detail    string
+	CreatedAt pgtype.Timestamp
+}
+
+func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) error {
+	_, err := q.db.Exec(ctx, insertAuditLog,
+		arg.Uid,
+		arg.Event,
+		arg.TargetID,
+		arg.Detail,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertImage = `-- name: InsertImage :exec
+INSERT INTO
+  images (image_id, uid, diagram_id, location, content_type, size, hash)
+VALUES
+  ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type InsertImageParams struct {
+	ImageID     pgtype.UUID
+	Uid         string
+	DiagramID   pgtype.UUID
+	Location    Location
+	ContentType string
+	Size        int64
+	Hash        string
+}
+
+func (q *Queries) InsertImage(ctx context.Context, arg InsertImageParams) error {
+	_, err := q.db.Exec(ctx, insertImage,
+		arg.ImageID,
+		arg.Uid,
+		arg.DiagramID,
+		arg.Location,
+		arg.ContentType,
+		arg.Size,
+		arg.Hash,
+	)
+	return err
+}
+
+const listDueAccountDeletions = `-- name: ListDueAccountDeletions :many
 SELECT
-  id, uid, diagram_id, location, diagram, is_bookmark, is_public, title, text, thumbnail, created_at, updated_at
+  id, deletion_id, uid, status, requested_at, scheduled_at, lease_until, completed_at, receipt
 FROM
-  items
+  account_deletions
 WHERE
-  location = $1
-  AND is_public = $2
-  AND is_bookmark = $3
+  scheduled_at <= $1
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < $1
+    )
+  )
+ORDER BY
+  scheduled_at
 LIMIT
-  $4
-OFFSET
-  $5
+  $2
 `
 
-type ListItemsParams struct {
-	Location   Location
-	IsPublic   *bool
-	IsBookmark *bool
-	Limit      int32
-	Offset     int32
+type ListDueAccountDeletionsParams struct {
+	ScheduledAt pgtype.Timestamp
+	Limit       int32
 }
 
-func (q *Queries) ListItems(ctx context.Context, arg ListItemsParams) ([]Item, error) {
-	rows, err := q.db.Query(ctx, listItems,
-		arg.Location,
-		arg.IsPublic,
-		arg.IsBookmark,
-		arg.Limit,
-		arg.Offset,
-	)
+func (q *Queries) ListDueAccountDeletions(ctx context.Context, arg ListDueAccountDeletionsParams) ([]AccountDeletion, error) {
+	rows, err := q.db.Query(ctx, listDueAccountDeletions, arg.ScheduledAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Item
+	var items []AccountDeletion
 	for rows.Next() {
-		var i Item
+		var i AccountDeletion
 		if err := rows.Scan(
 			&i.ID,
+			&i.DeletionID,
 			&i.Uid,
-			&i.DiagramID,
-			&i.Location,
-			&i.Diagram,
-			&i.IsBookmark,
-			&i.IsPublic,
-			&i.Title,
-			&i.Text,
-			&i.Thumbnail,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Status,
+			&i.RequestedAt,
+			&i.ScheduledAt,
+			&i.LeaseUntil,
+			&i.CompletedAt,
+			&i.Receipt,
 		); err != nil {
 			return nil, err
 		}
@@ -433,44 +851,726 @@ func (q *Queries) ListItems(ctx context.Context, arg ListItemsParams) ([]Item, e
 	return items, nil
 }
 
-const updateItem = `-- name: UpdateItem :exec
-UPDATE items
-SET
-  diagram = $1,
-  is_bookmark = $2,
-  is_public = $3,
-  title = $4,
-  text = $5,
-  thumbnail = $6,
-  location = $7,
-  updated_at = NOW()
-WHERE
-  diagram_id = $8
+const listExpiredTrashedItems = `-- name: ListExpiredTrashedItems :many
+SELECT
+  uid,
+  diagram_id
+FROM
+  expired_trashed_items(
+    $1::timestamp,
+    $2::integer
+  )
 `
 
-type UpdateItemParams struct {
-	Diagram    Diagram
-	IsBookmark *bool
-	IsPublic   *bool
-	Title      *string
-	Text       string
-	Thumbnail  *string
-	Location   Location
-	DiagramID  pgtype.UUID
+type ListExpiredTrashedItemsParams struct {
+	DeletedBefore pgtype.Timestamp
+	MaxItems      int32
 }
 
-func (q *Queries) UpdateItem(ctx context.Context, arg UpdateItemParams) error {
-	_, err := q.db.Exec(ctx, updateItem,
-		arg.Diagram,
-		arg.IsBookmark,
-		arg.IsPublic,
-		arg.Title,
-		arg.Text,
-		arg.Thumbnail,
-		arg.Location,
-		arg.DiagramID,
-	)
-	return err
+type ListExpiredTrashedItemsRow struct {
+	Uid       *string
+	DiagramID pgtype.UUID
+}
+
+func (q *Queries) ListExpiredTrashedItems(ctx context.Context, arg ListExpiredTrashedItemsParams) ([]ListExpiredTrashedItemsRow, error) {
+	rows, err := q.db.Query(ctx, listExpiredTrashedItems, arg.DeletedBefore, arg.MaxItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpiredTrashedItemsRow
+	for rows.Next() {
+		var i ListExpiredTrashedItemsRow
+		if err := rows.Scan(&i.Uid, &i.DiagramID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImages = `-- name: ListImages :many
+SELECT
+  id, image_id, uid, diagram_id, location, content_type, size, hash, created_at
+FROM
+  images
+WHERE
+  uid = $1
+ORDER BY
+  created_at
+`
+
+func (q *Queries) ListImages(ctx context.Context, uid string) ([]Image, error) {
+	rows, err := q.db.Query(ctx, listImages, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Image
+	for rows.Next() {
+		var i Image
+		if err := rows.Scan(
+			&i.ID,
+			&i.ImageID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.ContentType,
+			&i.Size,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemIDs = `-- name: ListItemIDs :many
+SELECT
+  diagram_id
+FROM
+  items
+WHERE
+  uid = $1
+  AND location = $2
+  AND deleted_at IS NULL
+ORDER BY
+  diagram_id
+`
+
+type ListItemIDsParams struct {
+	Uid      string
+	Location Location
+}
+
+func (q *Queries) ListItemIDs(ctx context.Context, arg ListItemIDsParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listItemIDs, arg.Uid, arg.Location)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var diagram_id pgtype.UUID
+		if err := rows.Scan(&diagram_id); err != nil {
+			return nil, err
+		}
+		items = append(items, diagram_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItems = `-- name: ListItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN $1::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN $2::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = $3
+  AND is_public = $4
+  AND is_bookmark = COALESCE($5, is_bookmark)
+  AND diagram = COALESCE($6, diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  updated_at DESC,
+  id DESC
+LIMIT
+  $7
+OFFSET
+  $8
+`
+
+type ListItemsParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Location      Location
+	IsPublic      *bool
+	IsBookmark    *bool
+	Diagram       NullDiagram
+	Limit         int32
+	Offset        int32
+}
+
+func (q *Queries) ListItems(ctx context.Context, arg ListItemsParams) ([]Item, error) {
+	rows, err := q.db.Query(ctx, listItems,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Location,
+		arg.IsPublic,
+		arg.IsBookmark,
+		arg.Diagram,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsByCreatedAt = `-- name: ListItemsByCreatedAt :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN $1::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN $2::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = $3
+  AND is_public = $4
+  AND is_bookmark = COALESCE($5, is_bookmark)
+  AND diagram = COALESCE($6, diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  $7
+OFFSET
+  $8
+`
+
+type ListItemsByCreatedAtParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Location      Location
+	IsPublic      *bool
+	IsBookmark    *bool
+	Diagram       NullDiagram
+	Limit         int32
+	Offset        int32
+}
+
+func (q *Queries) ListItemsByCreatedAt(ctx context.Context, arg ListItemsByCreatedAtParams) ([]Item, error) {
+	rows, err := q.db.Query(ctx, listItemsByCreatedAt,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Location,
+		arg.IsPublic,
+		arg.IsBookmark,
+		arg.Diagram,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsByIDs = `-- name: ListItemsByIDs :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN $1::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN $2::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = $3
+  AND diagram_id = ANY($4::uuid[])
+  AND deleted_at IS NULL
+`
+
+type ListItemsByIDsParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Location      Location
+	DiagramIds    []pgtype.UUID
+}
+
+func (q *Queries) ListItemsByIDs(ctx context.Context, arg ListItemsByIDsParams) ([]Item, error) {
+	rows, err := q.db.Query(ctx, listItemsByIDs,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Location,
+		arg.DiagramIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsByTitle = `-- name: ListItemsByTitle :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN $1::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN $2::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  location = $3
+  AND is_public = $4
+  AND is_bookmark = COALESCE($5, is_bookmark)
+  AND diagram = COALESCE($6, diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  title,
+  id
+LIMIT
+  $7
+OFFSET
+  $8
+`
+
+type ListItemsByTitleParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Location      Location
+	IsPublic      *bool
+	IsBookmark    *bool
+	Diagram       NullDiagram
+	Limit         int32
+	Offset        int32
+}
+
+func (q *Queries) ListItemsByTitle(ctx context.Context, arg ListItemsByTitleParams) ([]Item, error) {
+	rows, err := q.db.Query(ctx, listItemsByTitle,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Location,
+		arg.IsPublic,
+		arg.IsBookmark,
+		arg.Diagram,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedItems = `-- name: ListTrashedItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN $1::boolean THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN $2::boolean THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = $3
+  AND location = $4
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  $5
+OFFSET
+  $6
+`
+
+type ListTrashedItemsParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      Location
+	Limit         int32
+	Offset        int32
+}
+
+func (q *Queries) ListTrashedItems(ctx context.Context, arg ListTrashedItemsParams) ([]Item, error) {
+	rows, err := q.db.Query(ctx, listTrashedItems,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Uid,
+		arg.Location,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUIDs = `-- name: ListUIDs :many
+SELECT
+  uid
+FROM
+  items
+UNION
+SELECT
+  uid
+FROM
+  settings
+UNION
+SELECT
+  uid
+FROM
+  share_conditions
+ORDER BY
+  uid
+`
+
+func (q *Queries) ListUIDs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listUIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		items = append(items, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockImageUsage = `-- name: LockImageUsage :exec
+SELECT
+  pg_advisory_xact_lock(hashtextextended('images:' || $1::text, 0))
+`
+
+func (q *Queries) LockImageUsage(ctx context.Context, uid string) error {
+	_, err := q.db.Exec(ctx, lockImageUsage, uid)
+	return err
+}
+
+const restoreItem = `-- name: RestoreItem :execrows
+UPDATE items
+SET
+  deleted_at = NULL
+WHERE
+  location = $1
+  AND diagram_id = $2
+  AND deleted_at IS NOT NULL
+`
+
+type RestoreItemParams struct {
+	Location  Location
+	DiagramID pgtype.UUID
+}
+
+func (q *Queries) RestoreItem(ctx context.Context, arg RestoreItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreItem, arg.Location, arg.DiagramID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const trashItem = `-- name: TrashItem :execrows
+UPDATE items
+SET
+  is_public = FALSE,
+  deleted_at = $1
+WHERE
+  location = $2
+  AND diagram_id = $3
+  AND deleted_at IS NULL
+`
+
+type TrashItemParams struct {
+	DeletedAt pgtype.Timestamp
+	Location  Location
+	DiagramID pgtype.UUID
+}
+
+func (q *Queries) TrashItem(ctx context.Context, arg TrashItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, trashItem, arg.DeletedAt, arg.Location, arg.DiagramID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unpublishItem = `-- name: UnpublishItem :exec
+UPDATE items
+SET
+  is_public = FALSE
+WHERE
+  uid = $1
+  AND diagram_id = $2
+`
+
+type UnpublishItemParams struct {
+	Uid       string
+	DiagramID pgtype.UUID
+}
+
+func (q *Queries) UnpublishItem(ctx context.Context, arg UnpublishItemParams) error {
+	_, err := q.db.Exec(ctx, unpublishItem, arg.Uid, arg.DiagramID)
+	return err
+}
+
+const updateItem = `-- name: UpdateItem :exec
+UPDATE items
+SET
+  diagram = $1,
+  is_bookmark = $2,
+  is_public = $3,
+  title = $4,
+  text = $5,
+  thumbnail = $6,
+  location = $7,
+  revision = $8,
+  updated_at = $9
+WHERE
+  diagram_id = $10
+`
+
+type UpdateItemParams struct {
+	Diagram    Diagram
+	IsBookmark *bool
+	IsPublic   *bool
+	Title      *string
+	Text       string
+	Thumbnail  *string
+	Location   Location
+	Revision   *string
+	UpdatedAt  pgtype.Timestamp
+	DiagramID  pgtype.UUID
+}
+
+func (q *Queries) UpdateItem(ctx context.Context, arg UpdateItemParams) error {
+	_, err := q.db.Exec(ctx, updateItem,
+		arg.Diagram,
+		arg.IsBookmark,
+		arg.IsPublic,
+		arg.Title,
+		arg.Text,
+		arg.Thumbnail,
+		arg.Location,
+		arg.Revision,
+		arg.UpdatedAt,
+		arg.DiagramID,
+	)
+	return err
 }
 
 const updateSettings = `-- name: UpdateSettings :exec
@@ -492,9 +1592,10 @@ SET
   task_color = $14,
   task_background_color = $15,
   width = $16,
-  zoom_control = $17
+  zoom_control = $17,
+  font = $18
 WHERE
-  diagram = $18
+  diagram = $19
 `
 
 type UpdateSettingsParams struct {
@@ -515,6 +1616,7 @@ type UpdateSettingsParams struct {
 	TaskBackgroundColor     string
 	Width                   int32
 	ZoomControl             *bool
+	Font                    string
 	Diagram                 Diagram
 }
 
@@ -537,7 +1639,31 @@ func (q *Queries) UpdateSettings(ctx context.Context, arg UpdateSettingsParams)
 		arg.TaskBackgroundColor,
 		arg.Width,
 		arg.ZoomControl,
+		arg.Font,
 		arg.Diagram,
 	)
 	return err
 }
+
+const upsertGithubToken = `-- name: UpsertGithubToken :exec
+INSERT INTO
+  github_tokens (uid, access_token, scope)
+VALUES
+  ($1, $2, $3)
+ON CONFLICT (uid) DO UPDATE
+SET
+  access_token = excluded.access_token,
+  scope = excluded.scope,
+  updated_at = now()
+`
+
+type UpsertGithubTokenParams struct {
+	Uid         string
+	AccessToken string
+	Scope       string
+}
+
+func (q *Queries) UpsertGithubToken(ctx context.Context, arg UpsertGithubTokenParams) error {
+	_, err := q.db.Exec(ctx, upsertGithubToken, arg.Uid, arg.AccessToken, arg.Scope)
+	return err
+}
@@ -8,6 +8,48 @@ import (
 	"database/sql"
 )
 
+type AccountDeletion struct {
+	ID          int64
+	DeletionID  string
+	Uid         string
+	Status      string
+	RequestedAt int64
+	ScheduledAt int64
+	LeaseUntil  sql.NullInt64
+	CompletedAt sql.NullInt64
+	Receipt     sql.NullString
+}
+
+type AuditLog struct {
+	ID        int64
+	Uid       string
+	Event     string
+	TargetID  string
+	Detail    string
+	CreatedAt int64
+}
+
+type GithubToken struct {
+	ID          int64
+	Uid         string
+	AccessToken string
+	Scope       string
+	CreatedAt   int64
+	UpdatedAt   int64
+}
+
+type Image struct {
+	ID          int64
+	ImageID     string
+	Uid         string
+	DiagramID   string
+	Location    string
+	ContentType string
+	Size        int64
+	Hash        string
+	CreatedAt   int64
+}
+
 type Item struct {
 	ID         int64
 	Uid        string
@@ -21,6 +63,8 @@ type Item struct {
 	Thumbnail  sql.NullString
 	CreatedAt  int64
 	UpdatedAt  int64
+	Revision   sql.NullString
+	DeletedAt  sql.NullInt64
 }
 
 type SchemaMigration struct {
@@ -66,4 +110,6 @@ type ShareCondition struct {
 	Token          string
 	CreatedAt      int64
 	UpdatedAt      int64
+	Code           sql.NullString
+	RemainingViews sql.NullInt64
 }
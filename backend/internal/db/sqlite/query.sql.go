@@ -8,8 +8,115 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"strings"
 )
 
+const cancelAccountDeletion = `-- name: CancelAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'cancelled'
+WHERE
+  deletion_id = ?
+  AND status = 'pending'
+`
+
+func (q *Queries) CancelAccountDeletion(ctx context.Context, deletionID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelAccountDeletion, deletionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimAccountDeletion = `-- name: ClaimAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'purging',
+  lease_until = ?
+WHERE
+  deletion_id = ?
+  AND scheduled_at <= ?
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < ?
+    )
+  )
+`
+
+type ClaimAccountDeletionParams struct {
+	LeaseUntil   sql.NullInt64
+	DeletionID   string
+	ScheduledAt  int64
+	LeaseUntil_2 sql.NullInt64
+}
+
+func (q *Queries) ClaimAccountDeletion(ctx context.Context, arg ClaimAccountDeletionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimAccountDeletion,
+		arg.LeaseUntil,
+		arg.DeletionID,
+		arg.ScheduledAt,
+		arg.LeaseUntil_2,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const completeAccountDeletion = `-- name: CompleteAccountDeletion :execrows
+UPDATE account_deletions
+SET
+  status = 'completed',
+  lease_until = NULL,
+  completed_at = ?,
+  receipt = ?
+WHERE
+  deletion_id = ?
+  AND status = 'purging'
+`
+
+type CompleteAccountDeletionParams struct {
+	CompletedAt sql.NullInt64
+	Receipt     sql.NullString
+	DeletionID  string
+}
+
+func (q *Queries) CompleteAccountDeletion(ctx context.Context, arg CompleteAccountDeletionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeAccountDeletion, arg.CompletedAt, arg.Receipt, arg.DeletionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createAccountDeletion = `-- name: CreateAccountDeletion :exec
+INSERT INTO
+  account_deletions (deletion_id, uid, status, requested_at, scheduled_at)
+VALUES
+  (?, ?, ?, ?, ?)
+`
+
+type CreateAccountDeletionParams struct {
+	DeletionID  string
+	Uid         string
+	Status      string
+	RequestedAt int64
+	ScheduledAt int64
+}
+
+func (q *Queries) CreateAccountDeletion(ctx context.Context, arg CreateAccountDeletionParams) error {
+	_, err := q.db.ExecContext(ctx, createAccountDeletion,
+		arg.DeletionID,
+		arg.Uid,
+		arg.Status,
+		arg.RequestedAt,
+		arg.ScheduledAt,
+	)
+	return err
+}
+
 const createItem = `-- name: CreateItem :exec
 INSERT INTO
   items (
@@ -22,11 +129,12 @@ INSERT INTO
     text,
     thumbnail,
     location,
+    revision,
     created_at,
     updated_at
   )
 VALUES
-  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateItemParams struct {
@@ -39,6 +147,7 @@ type CreateItemParams struct {
 	Text       string
 	Thumbnail  sql.NullString
 	Location   string
+	Revision   sql.NullString
 	CreatedAt  int64
 	UpdatedAt  int64
 }
@@ -54,6 +163,7 @@ func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) error {
 		arg.Text,
 		arg.Thumbnail,
 		arg.Location,
+		arg.Revision,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -82,6 +192,7 @@ INSERT INTO
     task_background_color,
     width,
     zoom_control,
+    font,
     created_at,
     updated_at
   )
@@ -107,6 +218,7 @@ VALUES
     ?,
     ?,
     ?,
+    ?,
     ?
   )
 `
@@ -131,6 +243,7 @@ type CreateSettingsParams struct {
 	TaskBackgroundColor     string
 	Width                   int64
 	ZoomControl             sql.NullInt64
+	Font                    string
 	CreatedAt               int64
 	UpdatedAt               int64
 }
@@ -156,6 +269,7 @@ func (q *Queries) CreateSettings(ctx context.Context, arg CreateSettingsParams)
 		arg.TaskBackgroundColor,
 		arg.Width,
 		arg.ZoomControl,
+		arg.Font,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -174,11 +288,13 @@ INSERT INTO
     expire_time,
     password,
     token,
+    code,
+    remaining_views,
     created_at,
     updated_at
   )
 VALUES
-  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateShareConditionParams struct {
@@ -191,6 +307,8 @@ type CreateShareConditionParams struct {
 	ExpireTime     sql.NullInt64
 	Password       sql.NullString
 	Token          string
+	Code           sql.NullString
+	RemainingViews sql.NullInt64
 	CreatedAt      int64
 	UpdatedAt      int64
 }
@@ -206,12 +324,66 @@ func (q *Queries) CreateShareCondition(ctx context.Context, arg CreateShareCondi
 		arg.ExpireTime,
 		arg.Password,
 		arg.Token,
+		arg.Code,
+		arg.RemainingViews,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
 	return err
 }
 
+const decrementShareConditionViews = `-- name: DecrementShareConditionViews :one
+UPDATE share_conditions
+SET
+  remaining_views = remaining_views - 1,
+  updated_at = ?
+WHERE
+  hashkey = ?
+  AND remaining_views > 0
+RETURNING
+  remaining_views
+`
+
+type DecrementShareConditionViewsParams struct {
+	UpdatedAt int64
+	Hashkey   string
+}
+
+func (q *Queries) DecrementShareConditionViews(ctx context.Context, arg DecrementShareConditionViewsParams) (sql.NullInt64, error) {
+	row := q.db.QueryRowContext(ctx, decrementShareConditionViews, arg.UpdatedAt, arg.Hashkey)
+	var remaining_views sql.NullInt64
+	err := row.Scan(&remaining_views)
+	return remaining_views, err
+}
+
+const deleteGithubToken = `-- name: DeleteGithubToken :exec
+DELETE FROM github_tokens
+WHERE
+  uid = ?
+`
+
+func (q *Queries) DeleteGithubToken(ctx context.Context, uid string) error {
+	_, err := q.db.ExecContext(ctx, deleteGithubToken, uid)
+	return err
+}
+
+const deleteImage = `-- name: DeleteImage :exec
+DELETE FROM images
+WHERE
+  uid = ?
+  AND image_id = ?
+`
+
+type DeleteImageParams struct {
+	Uid     string
+	ImageID string
+}
+
+func (q *Queries) DeleteImage(ctx context.Context, arg DeleteImageParams) error {
+	_, err := q.db.ExecContext(ctx, deleteImage, arg.Uid, arg.ImageID)
+	return err
+}
+
 const deleteItem = `-- name: DeleteItem :exec
 DELETE FROM items
 WHERE
@@ -229,6 +401,23 @@ func (q *Queries) DeleteItem(ctx context.Context, arg DeleteItemParams) error {
 	return err
 }
 
+const deleteSettings = `-- name: DeleteSettings :exec
+DELETE FROM settings
+WHERE
+  uid = ?
+  AND diagram = ?
+`
+
+type DeleteSettingsParams struct {
+	Uid     string
+	Diagram string
+}
+
+func (q *Queries) DeleteSettings(ctx context.Context, arg DeleteSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSettings, arg.Uid, arg.Diagram)
+	return err
+}
+
 const deleteShareCondition = `-- name: DeleteShareCondition :exec
 DELETE FROM share_conditions
 WHERE
@@ -259,9 +448,145 @@ func (q *Queries) DeleteShareConditionItem(ctx context.Context, arg DeleteShareC
 	return err
 }
 
+const getAccountDeletion = `-- name: GetAccountDeletion :one
+SELECT
+  id, deletion_id, uid, status, requested_at, scheduled_at, lease_until, completed_at, receipt
+FROM
+  account_deletions
+WHERE
+  deletion_id = ?
+`
+
+func (q *Queries) GetAccountDeletion(ctx context.Context, deletionID string) (AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, getAccountDeletion, deletionID)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.ID,
+		&i.DeletionID,
+		&i.Uid,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ScheduledAt,
+		&i.LeaseUntil,
+		&i.CompletedAt,
+		&i.Receipt,
+	)
+	return i, err
+}
+
+const getActiveAccountDeletion = `-- name: GetActiveAccountDeletion :one
+SELECT
+  id, deletion_id, uid, status, requested_at, scheduled_at, lease_until, completed_at, receipt
+FROM
+  account_deletions
+WHERE
+  uid = ?
+  AND status IN ('pending', 'purging')
+`
+
+func (q *Queries) GetActiveAccountDeletion(ctx context.Context, uid string) (AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, getActiveAccountDeletion, uid)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.ID,
+		&i.DeletionID,
+		&i.Uid,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ScheduledAt,
+		&i.LeaseUntil,
+		&i.CompletedAt,
+		&i.Receipt,
+	)
+	return i, err
+}
+
+const getGithubToken = `-- name: GetGithubToken :one
+SELECT
+  id, uid, access_token, scope, created_at, updated_at
+FROM
+  github_tokens
+WHERE
+  uid = ?
+`
+
+func (q *Queries) GetGithubToken(ctx context.Context, uid string) (GithubToken, error) {
+	row := q.db.QueryRowContext(ctx, getGithubToken, uid)
+	var i GithubToken
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.AccessToken,
+		&i.Scope,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getImage = `-- name: GetImage :one
+SELECT
+  id, image_id, uid, diagram_id, location, content_type, size, hash, created_at
+FROM
+  images
+WHERE
+  image_id = ?
+`
+
+func (q *Queries) GetImage(ctx context.Context, imageID string) (Image, error) {
+	row := q.db.QueryRowContext(ctx, getImage, imageID)
+	var i Image
+	err := row.Scan(
+		&i.ID,
+		&i.ImageID,
+		&i.Uid,
+		&i.DiagramID,
+		&i.Location,
+		&i.ContentType,
+		&i.Size,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImageUsage = `-- name: GetImageUsage :one
+SELECT
+  CAST(COALESCE(SUM(size), 0) AS integer) AS usage
+FROM
+  images
+WHERE
+  uid = ?
+`
+
+func (q *Queries) GetImageUsage(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getImageUsage, uid)
+	var usage int64
+	err := row.Scan(&usage)
+	return usage, err
+}
+
 const getItem = `-- name: GetItem :one
 SELECT
-  id, uid, diagram_id, location, diagram, is_bookmark, is_public, title, text, thumbnail, created_at, updated_at
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
 FROM
   items
 WHERE
@@ -271,13 +596,21 @@ WHERE
 `
 
 type GetItemParams struct {
-	Uid       string
-	Location  string
-	DiagramID string
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      string
+	DiagramID     string
 }
 
 func (q *Queries) GetItem(ctx context.Context, arg GetItemParams) (Item, error) {
-	row := q.db.QueryRowContext(ctx, getItem, arg.Uid, arg.Location, arg.DiagramID)
+	row := q.db.QueryRowContext(ctx, getItem,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Uid,
+		arg.Location,
+		arg.DiagramID,
+	)
 	var i Item
 	err := row.Scan(
 		&i.ID,
@@ -292,6 +625,8 @@ func (q *Queries) GetItem(ctx context.Context, arg GetItemParams) (Item, error)
 		&i.Thumbnail,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Revision,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -344,7 +679,7 @@ func (q *Queries) GetSettings(ctx context.Context, arg GetSettingsParams) (Setti
 
 const getShareCondition = `-- name: GetShareCondition :one
 SELECT
-  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at
+  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at, code, remaining_views
 FROM
   share_conditions
 WHERE
@@ -367,13 +702,46 @@ func (q *Queries) GetShareCondition(ctx context.Context, hashkey string) (ShareC
 		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Code,
+		&i.RemainingViews,
+	)
+	return i, err
+}
+
+const getShareConditionByCode = `-- name: GetShareConditionByCode :one
+SELECT
+  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at, code, remaining_views
+FROM
+  share_conditions
+WHERE
+  code = ?
+`
+
+func (q *Queries) GetShareConditionByCode(ctx context.Context, code sql.NullString) (ShareCondition, error) {
+	row := q.db.QueryRowContext(ctx, getShareConditionByCode, code)
+	var i ShareCondition
+	err := row.Scan(
+		&i.ID,
+		&i.Hashkey,
+		&i.Uid,
+		&i.DiagramID,
+		&i.Location,
+		&i.AllowIpList,
+		&i.AllowEmailList,
+		&i.ExpireTime,
+		&i.Password,
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Code,
+		&i.RemainingViews,
 	)
 	return i, err
 }
 
 const getShareConditionItem = `-- name: GetShareConditionItem :one
 SELECT
-  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at
+  id, hashkey, uid, diagram_id, location, allow_ip_list, allow_email_list, expire_time, password, token, created_at, updated_at, code, remaining_views
 FROM
   share_conditions
 WHERE
@@ -404,64 +772,115 @@ func (q *Queries) GetShareConditionItem(ctx context.Context, arg GetShareConditi
 		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Code,
+		&i.RemainingViews,
 	)
 	return i, err
 }
 
-const listItems = `-- name: ListItems :many
+const insertAuditLog = `-- name: InsertAuditLog :exec
+INSERT INTO
+  audit_logs (uid, event, target_id, detail, created_at)
+VALUES
+  (?, ?, ?, ?, ?)
+`
+
+type InsertAuditLogParams struct {
+	Uid       string
+	Event     string
+	TargetID  string
+	Detail    string
+	CreatedAt int64
+}
+
+func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditLog,
+		arg.Uid,
+		arg.Event,
+		arg.TargetID,
+		arg.Detail,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertImage = `-- name: InsertImage :exec
+INSERT INTO
+  images (image_id, uid, diagram_id, location, content_type, size, hash, created_at)
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertImageParams struct {
+	ImageID     string
+	Uid         string
+	DiagramID   string
+	Location    string
+	ContentType string
+	Size        int64
+	Hash        string
+	CreatedAt   int64
+}
+
+func (q *Queries) InsertImage(ctx context.Context, arg InsertImageParams) error {
+	_, err := q.db.ExecContext(ctx, insertImage,
+		arg.ImageID,
+		arg.Uid,
+		arg.DiagramID,
+		arg.Location,
+		arg.ContentType,
+		arg.Size,
+		arg.Hash,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const listDueAccountDeletions = `-- name: ListDueAccountDeletions :many
 SELECT
-  id, uid, diagram_id, location, diagram, is_bookmark, is_public, title, text, thumbnail, created_at, updated_at
+  id, deletion_id, uid, status, requested_at, scheduled_at, lease_until, completed_at, receipt
 FROM
-  items
+  account_deletions
 WHERE
-  uid = ?
-  AND location = ?
-  AND is_public = ?
-  AND is_bookmark = ?
+  scheduled_at <= ?
+  AND (
+    status = 'pending'
+    OR (
+      status = 'purging'
+      AND lease_until < ?
+    )
+  )
+ORDER BY
+  scheduled_at
 LIMIT
   ?
-OFFSET
-  ?
 `
 
-type ListItemsParams struct {
-	Uid        string
-	Location   string
-	IsPublic   int64
-	IsBookmark int64
-	Limit      int64
-	Offset     int64
+type ListDueAccountDeletionsParams struct {
+	ScheduledAt int64
+	LeaseUntil  sql.NullInt64
+	Limit       int64
 }
 
-func (q *Queries) ListItems(ctx context.Context, arg ListItemsParams) ([]Item, error) {
-	rows, err := q.db.QueryContext(ctx, listItems,
-		arg.Uid,
-		arg.Location,
-		arg.IsPublic,
-		arg.IsBookmark,
-		arg.Limit,
-		arg.Offset,
-	)
+func (q *Queries) ListDueAccountDeletions(ctx context.Context, arg ListDueAccountDeletionsParams) ([]AccountDeletion, error) {
+	rows, err := q.db.QueryContext(ctx, listDueAccountDeletions, arg.ScheduledAt, arg.LeaseUntil, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Item
+	var items []AccountDeletion
 	for rows.Next() {
-		var i Item
+		var i AccountDeletion
 		if err := rows.Scan(
 			&i.ID,
+			&i.DeletionID,
 			&i.Uid,
-			&i.DiagramID,
-			&i.Location,
-			&i.Diagram,
-			&i.IsBookmark,
-			&i.IsPublic,
-			&i.Title,
-			&i.Text,
-			&i.Thumbnail,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Status,
+			&i.RequestedAt,
+			&i.ScheduledAt,
+			&i.LeaseUntil,
+			&i.CompletedAt,
+			&i.Receipt,
 		); err != nil {
 			return nil, err
 		}
@@ -476,30 +895,758 @@ func (q *Queries) ListItems(ctx context.Context, arg ListItemsParams) ([]Item, e
 	return items, nil
 }
 
-const updateItem = `-- name: UpdateItem :exec
-UPDATE items
-SET
-  diagram = ?,
-  is_bookmark = ?,
-  is_public = ?,
-  title = ?,
-  text = ?,
-  thumbnail = ?,
-  location = ?,
-  updated_at = ?
+const listExpiredTrashedItems = `-- name: ListExpiredTrashedItems :many
+SELECT
+  uid,
+  diagram_id
+FROM
+  items
 WHERE
-  uid = ?
-  AND diagram_id = ?
+  deleted_at < ?
+ORDER BY
+  deleted_at
+LIMIT
+  ?
 `
 
-type UpdateItemParams struct {
-	Diagram    string
+type ListExpiredTrashedItemsParams struct {
+	DeletedAt sql.NullInt64
+	Limit     int64
+}
+
+type ListExpiredTrashedItemsRow struct {
+	Uid       string
+	DiagramID string
+}
+
+func (q *Queries) ListExpiredTrashedItems(ctx context.Context, arg ListExpiredTrashedItemsParams) ([]ListExpiredTrashedItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredTrashedItems, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpiredTrashedItemsRow
+	for rows.Next() {
+		var i ListExpiredTrashedItemsRow
+		if err := rows.Scan(&i.Uid, &i.DiagramID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImages = `-- name: ListImages :many
+SELECT
+  id, image_id, uid, diagram_id, location, content_type, size, hash, created_at
+FROM
+  images
+WHERE
+  uid = ?
+ORDER BY
+  created_at
+`
+
+func (q *Queries) ListImages(ctx context.Context, uid string) ([]Image, error) {
+	rows, err := q.db.QueryContext(ctx, listImages, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Image
+	for rows.Next() {
+		var i Image
+		if err := rows.Scan(
+			&i.ID,
+			&i.ImageID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.ContentType,
+			&i.Size,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemIDs = `-- name: ListItemIDs :many
+SELECT
+  diagram_id
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND deleted_at IS NULL
+ORDER BY
+  diagram_id
+`
+
+type ListItemIDsParams struct {
+	Uid      string
+	Location string
+}
+
+func (q *Queries) ListItemIDs(ctx context.Context, arg ListItemIDsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listItemIDs, arg.Uid, arg.Location)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var diagram_id string
+		if err := rows.Scan(&diagram_id); err != nil {
+			return nil, err
+		}
+		items = append(items, diagram_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItems = `-- name: ListItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND is_public = ?
+  AND is_bookmark = COALESCE(?, is_bookmark)
+  AND diagram = COALESCE(?, diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  updated_at DESC,
+  id DESC
+LIMIT
+  ?
+OFFSET
+  ?
+`
+
+type ListItemsParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      string
+	IsPublic      int64
+	IsBookmark    sql.NullInt64
+	Diagram       sql.NullString
+	Limit         int64
+	Offset        int64
+}
+
+func (q *Queries) ListItems(ctx context.Context, arg ListItemsParams) ([]Item, error) {
+	rows, err := q.db.QueryContext(ctx, listItems,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Uid,
+		arg.Location,
+		arg.IsPublic,
+		arg.IsBookmark,
+		arg.Diagram,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsByCreatedAt = `-- name: ListItemsByCreatedAt :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND is_public = ?
+  AND is_bookmark = COALESCE(?, is_bookmark)
+  AND diagram = COALESCE(?, diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  ?
+OFFSET
+  ?
+`
+
+type ListItemsByCreatedAtParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      string
+	IsPublic      int64
+	IsBookmark    sql.NullInt64
+	Diagram       sql.NullString
+	Limit         int64
+	Offset        int64
+}
+
+func (q *Queries) ListItemsByCreatedAt(ctx context.Context, arg ListItemsByCreatedAtParams) ([]Item, error) {
+	rows, err := q.db.QueryContext(ctx, listItemsByCreatedAt,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Uid,
+		arg.Location,
+		arg.IsPublic,
+		arg.IsBookmark,
+		arg.Diagram,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsByIDs = `-- name: ListItemsByIDs :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id IN (/*SLICE:diagram_ids*/?)
+  AND deleted_at IS NULL
+`
+
+type ListItemsByIDsParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      string
+	DiagramIds    []string
+}
+
+func (q *Queries) ListItemsByIDs(ctx context.Context, arg ListItemsByIDsParams) ([]Item, error) {
+	query := listItemsByIDs
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.LoadText)
+	queryParams = append(queryParams, arg.LoadThumbnail)
+	queryParams = append(queryParams, arg.Uid)
+	queryParams = append(queryParams, arg.Location)
+	if len(arg.DiagramIds) > 0 {
+		for _, v := range arg.DiagramIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:diagram_ids*/?", strings.Repeat(",?", len(arg.DiagramIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:diagram_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemsByTitle = `-- name: ListItemsByTitle :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND is_public = ?
+  AND is_bookmark = COALESCE(?, is_bookmark)
+  AND diagram = COALESCE(?, diagram)
+  AND deleted_at IS NULL
+ORDER BY
+  title,
+  id
+LIMIT
+  ?
+OFFSET
+  ?
+`
+
+type ListItemsByTitleParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      string
+	IsPublic      int64
+	IsBookmark    sql.NullInt64
+	Diagram       sql.NullString
+	Limit         int64
+	Offset        int64
+}
+
+func (q *Queries) ListItemsByTitle(ctx context.Context, arg ListItemsByTitleParams) ([]Item, error) {
+	rows, err := q.db.QueryContext(ctx, listItemsByTitle,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Uid,
+		arg.Location,
+		arg.IsPublic,
+		arg.IsBookmark,
+		arg.Diagram,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedItems = `-- name: ListTrashedItems :many
+SELECT
+  id,
+  uid,
+  diagram_id,
+  location,
+  diagram,
+  is_bookmark,
+  is_public,
+  title,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN text
+    ELSE ''
+  END AS text,
+  CASE
+    WHEN CAST(? AS BOOLEAN) THEN thumbnail
+  END AS thumbnail,
+  created_at,
+  updated_at,
+  revision,
+  deleted_at
+FROM
+  items
+WHERE
+  uid = ?
+  AND location = ?
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  ?
+OFFSET
+  ?
+`
+
+type ListTrashedItemsParams struct {
+	LoadText      bool
+	LoadThumbnail bool
+	Uid           string
+	Location      string
+	Limit         int64
+	Offset        int64
+}
+
+func (q *Queries) ListTrashedItems(ctx context.Context, arg ListTrashedItemsParams) ([]Item, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashedItems,
+		arg.LoadText,
+		arg.LoadThumbnail,
+		arg.Uid,
+		arg.Location,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.DiagramID,
+			&i.Location,
+			&i.Diagram,
+			&i.IsBookmark,
+			&i.IsPublic,
+			&i.Title,
+			&i.Text,
+			&i.Thumbnail,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUIDs = `-- name: ListUIDs :many
+SELECT
+  uid
+FROM
+  items
+UNION
+SELECT
+  uid
+FROM
+  settings
+UNION
+SELECT
+  uid
+FROM
+  share_conditions
+ORDER BY
+  uid
+`
+
+func (q *Queries) ListUIDs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		items = append(items, uid)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreItem = `-- name: RestoreItem :execrows
+UPDATE items
+SET
+  deleted_at = NULL
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?
+  AND deleted_at IS NOT NULL
+`
+
+type RestoreItemParams struct {
+	Uid       string
+	Location  string
+	DiagramID string
+}
+
+func (q *Queries) RestoreItem(ctx context.Context, arg RestoreItemParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreItem, arg.Uid, arg.Location, arg.DiagramID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const trashItem = `-- name: TrashItem :execrows
+UPDATE items
+SET
+  is_public = 0,
+  deleted_at = ?
+WHERE
+  uid = ?
+  AND location = ?
+  AND diagram_id = ?
+  AND deleted_at IS NULL
+`
+
+type TrashItemParams struct {
+	DeletedAt sql.NullInt64
+	Uid       string
+	Location  string
+	DiagramID string
+}
+
+func (q *Queries) TrashItem(ctx context.Context, arg TrashItemParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashItem,
+		arg.DeletedAt,
+		arg.Uid,
+		arg.Location,
+		arg.DiagramID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unpublishItem = `-- name: UnpublishItem :exec
+UPDATE items
+SET
+  is_public = 0
+WHERE
+  uid = ?
+  AND diagram_id = ?
+`
+
+type UnpublishItemParams struct {
+	Uid       string
+	DiagramID string
+}
+
+func (q *Queries) UnpublishItem(ctx context.Context, arg UnpublishItemParams) error {
+	_, err := q.db.ExecContext(ctx, unpublishItem, arg.Uid, arg.DiagramID)
+	return err
+}
+
+const updateItem = `-- name: UpdateItem :exec
+UPDATE items
+SET
+  diagram = ?,
+  is_bookmark = ?,
+  is_public = ?,
+  title = ?,
+  text = ?,
+  thumbnail = ?,
+  location = ?,
+  revision = ?,
+  updated_at = ?
+WHERE
+  uid = ?
+  AND diagram_id = ?
+`
+
+type UpdateItemParams struct {
+	Diagram    string
 	IsBookmark int64
 	IsPublic   int64
 	Title      sql.NullString
 	Text       string
 	Thumbnail  sql.NullString
 	Location   string
+	Revision   sql.NullString
 	UpdatedAt  int64
 	Uid        string
 	DiagramID  string
@@ -514,6 +1661,7 @@ func (q *Queries) UpdateItem(ctx context.Context, arg UpdateItemParams) error {
 		arg.Text,
 		arg.Thumbnail,
 		arg.Location,
+		arg.Revision,
 		arg.UpdatedAt,
 		arg.Uid,
 		arg.DiagramID,
@@ -541,6 +1689,7 @@ SET
   task_background_color = ?,
   width = ?,
   zoom_control = ?,
+  font = ?,
   updated_at = ?
 WHERE
   uid = ?
@@ -565,6 +1714,7 @@ type UpdateSettingsParams struct {
 	TaskBackgroundColor     string
 	Width                   int64
 	ZoomControl             sql.NullInt64
+	Font                    string
 	UpdatedAt               int64
 	Uid                     string
 	Diagram                 string
@@ -589,9 +1739,41 @@ func (q *Queries) UpdateSettings(ctx context.Context, arg UpdateSettingsParams)
 		arg.TaskBackgroundColor,
 		arg.Width,
 		arg.ZoomControl,
+		arg.Font,
 		arg.UpdatedAt,
 		arg.Uid,
 		arg.Diagram,
 	)
 	return err
 }
+
+const upsertGithubToken = `-- name: UpsertGithubToken :exec
+INSERT INTO
+  github_tokens (uid, access_token, scope, created_at, updated_at)
+VALUES
+  (?, ?, ?, ?, ?)
+ON CONFLICT (uid) DO UPDATE
+SET
+  access_token = excluded.access_token,
+  scope = excluded.scope,
+  updated_at = excluded.updated_at
+`
+
+type UpsertGithubTokenParams struct {
+	Uid         string
+	AccessToken string
+	Scope       string
+	CreatedAt   int64
+	UpdatedAt   int64
+}
+
+func (q *Queries) UpsertGithubToken(ctx context.Context, arg UpsertGithubTokenParams) error {
+	_, err := q.db.ExecContext(ctx, upsertGithubToken,
+		arg.Uid,
+		arg.AccessToken,
+		arg.Scope,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	return err
+}
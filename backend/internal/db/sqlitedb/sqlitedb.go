@@ -0,0 +1,165 @@
+// Package sqlitedb opens a SQLite database for concurrent requests. SQLite allows one writer at a time, so writes
+// go through a pool of a single connection, which queues them, while reads run in parallel on read-only
+// connections. The database is put in WAL mode so that readers do not block the writer, or the other way round.
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// busyTimeout is how long a connection waits for a lock held by another process before failing with
+// "database is locked". Writers of this process wait in the queue of the writer pool instead.
+const busyTimeout = 5 * time.Second
+
+// pragmas are set on every connection.
+var pragmas = url.Values{
+	"_journal_mode": {"WAL"},
+	"_synchronous":  {"NORMAL"},
+	"_busy_timeout": {fmt.Sprint(busyTimeout.Milliseconds())},
+	"_cache_size":   {"-20000"},
+}
+
+type DB struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+// Open opens the database at dsn, a file name or a file: URI, with up to readers read-only connections. An
+// in-memory database has a single connection used for both reads and writes, as its connections cannot share it.
+func Open(ctx context.Context, dsn string, readers int) (*DB, error) {
+	if isMemory(dsn) {
+		db, err := open(ctx, dsn, url.Values{}, 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &DB{writer: db, reader: db}, nil
+	}
+
+	// The write lock is taken when a transaction begins, rather than on its first write, so that a transaction
+	// waits for the busy timeout instead of failing when it cannot upgrade its read lock.
+	writer, err := open(ctx, dsn, url.Values{"_txlock": {"immediate"}}, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := open(ctx, dsn, url.Values{"_query_only": {"true"}}, max(readers, 1))
+
+	if err != nil {
+		return nil, errors.Join(err, writer.Close())
+	}
+
+	return &DB{writer: writer, reader: reader}, nil
+}
+
+func open(ctx context.Context, dsn string, params url.Values, conns int) (*sql.DB, error) {
+	name, query, _ := strings.Cut(dsn, "?")
+	values, err := url.ParseQuery(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range []url.Values{pragmas, params} {
+		for key, value := range p {
+			if !values.Has(key) {
+				values[key] = value
+			}
+		}
+	}
+
+	if !strings.HasPrefix(name, "file:") {
+		name = "file:" + name
+	}
+
+	db, err := sql.Open("sqlite3", name+"?"+values.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
+	db.SetConnMaxLifetime(0)
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+
+	return db, nil
+}
+
+func isMemory(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// Writer is the pool of the single connection that writes.
+func (d *DB) Writer() *sql.DB {
+	return d.writer
+}
+
+// Reader is the pool of read-only connections.
+func (d *DB) Reader() *sql.DB {
+	return d.reader
+}
+
+func (d *DB) Close() error {
+	if d.reader == d.writer {
+		return d.writer.Close()
+	}
+
+	return errors.Join(d.reader.Close(), d.writer.Close())
+}
+
+// ExecContext runs the statement on the writer.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return d.writer.ExecContext(ctx, query, args...)
+}
+
+func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.pool(query).PrepareContext(ctx, query)
+}
+
+// QueryContext runs SELECT queries on a reader and the others, such as INSERT ... RETURNING, on the writer.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return d.pool(query).QueryContext(ctx, query, args...)
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return d.pool(query).QueryRowContext(ctx, query, args...)
+}
+
+func (d *DB) pool(query string) *sql.DB {
+	if isSelect(query) {
+		return d.reader
+	}
+
+	return d.writer
+}
+
+// isSelect reports whether the query starts with SELECT, after the comments that sqlc puts before it.
+func isSelect(query string) bool {
+	for {
+		query = strings.TrimSpace(query)
+
+		if !strings.HasPrefix(query, "--") {
+			break
+		}
+
+		_, query, _ = strings.Cut(query, "\n")
+	}
+
+	keyword, _, _ := strings.Cut(query, " ")
+	keyword, _, _ = strings.Cut(keyword, "\n")
+	return strings.EqualFold(keyword, "SELECT")
+}
@@ -12,11 +12,17 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Transaction interface {
 	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	// DoReadOnly runs fn in a transaction that does not write. On SQLite it runs on the read-only connections,
+	// in parallel with other readers and with the writer.
+	DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type postgresTx struct {
@@ -24,31 +30,56 @@ type postgresTx struct {
 }
 
 type dbTx struct {
-	db *sql.Conn
+	db *sqlitedb.DB
+}
+
+type mysqlTx struct {
+	db *sql.DB
 }
 
 type firestoreTx struct {
 	db *firestore.Client
 }
 
+type memoryTx struct {
+	db *memdb.DB
+}
+
 func NewPostgresTx(config *config.Config) Transaction {
 	return &postgresTx{db: config.PostgresConn}
 }
 
 func NewDBTx(config *config.Config) Transaction {
-	return &dbTx{db: config.SqlConn}
+	return &dbTx{db: config.Sqlite}
+}
+
+func NewMysqlTx(config *config.Config) Transaction {
+	return &mysqlTx{db: config.MysqlConn}
 }
 
 func NewFirestoreTx(config *config.Config) Transaction {
 	return &firestoreTx{db: config.FirestoreClient}
 }
 
+func NewMemoryTx(config *config.Config) Transaction {
+	return &memoryTx{db: config.Memory}
+}
+
 func (t *postgresTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
-	tx, err := t.db.Begin(ctx)
+	return t.do(ctx, pgx.TxOptions{}, fn)
+}
+
+func (t *postgresTx) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.do(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly}, fn)
+}
+
+func (t *postgresTx) do(ctx context.Context, options pgx.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := t.db.BeginTx(ctx, options)
 	if err != nil {
 		return err
 	}
 	ctx = values.WithPostgresTx(ctx, &tx)
+	ctx, state := withTxState(ctx, options.AccessMode == pgx.ReadOnly)
 
 	_, err = tx.Exec(ctx, fmt.Sprintf("SET LOCAL app.uid = %q;", values.GetUID(ctx).MustGet()))
 
@@ -68,17 +99,44 @@ func (t *postgresTx) Do(ctx context.Context, fn func(ctx context.Context) error)
 	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
+
+	state.committed()
 	return nil
 }
 
 func (t *dbTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
-	tx, err := t.db.BeginTx(ctx, nil)
+	return doSQL(ctx, t.db.Writer(), nil, false, fn)
+}
+
+func (t *dbTx) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return doSQL(ctx, t.db.Reader(), nil, true, fn)
+}
+
+func (t *mysqlTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return doSQL(ctx, t.db, nil, false, fn)
+}
+
+func (t *mysqlTx) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return doSQL(ctx, t.db, &sql.TxOptions{ReadOnly: true}, true, fn)
+}
+
+// doSQL runs fn in a database/sql transaction on pool, which the SQLite and MySQL repositories find in ctx.
+// readOnly tells that fn does not write, as the SQLite readers are read-only without options.
+// It joins a transaction already in ctx unless that one is read-only and fn writes, since the SQLite writer has
+// a single connection that the outer transaction holds until it ends.
+func doSQL(ctx context.Context, pool *sql.DB, options *sql.TxOptions, readOnly bool, fn func(ctx context.Context) error) error {
+	if values.GetDBTx(ctx).IsPresent() && (readOnly || Writing(ctx)) {
+		return fn(ctx)
+	}
+
+	tx, err := pool.BeginTx(ctx, options)
 
 	if err != nil {
 		return err
 	}
 
 	ctx = values.WithDBTx(ctx, tx)
+	ctx, state := withTxState(ctx, readOnly)
 
 	if err = fn(ctx); err != nil {
 		if txErr := tx.Rollback(); txErr != nil {
@@ -92,12 +150,63 @@ func (t *dbTx) Do(ctx context.Context, fn func(ctx context.Context) error) error
 	if err := tx.Commit(); err != nil {
 		return err
 	}
+
+	state.committed()
 	return nil
 }
 
 func (t *firestoreTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
-	return t.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+	return t.run(ctx, false, fn)
+}
+
+func (t *firestoreTx) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.run(ctx, true, fn, firestore.ReadOnly)
+}
+
+// run starts the state of the transaction again on every attempt, so that only the attempt that commits
+// runs its AfterCommit functions.
+func (t *firestoreTx) run(ctx context.Context, readOnly bool, fn func(ctx context.Context) error, opts ...firestore.TransactionOption) error {
+	var state *txState
+
+	err := t.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
 		ctx = values.WithFirestoreTx(ctx, tx)
+		ctx, state = withTxState(ctx, readOnly)
 		return fn(ctx)
-	})
+	}, opts...)
+
+	if err != nil {
+		return err
+	}
+
+	state.committed()
+	return nil
+}
+
+func (t *memoryTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.do(ctx, false, fn)
+}
+
+func (t *memoryTx) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.do(ctx, true, fn)
+}
+
+// do joins the transaction already in ctx, since a write transaction holds the database until it ends.
+func (t *memoryTx) do(ctx context.Context, readOnly bool, fn func(ctx context.Context) error) error {
+	if values.GetMemoryTx(ctx).IsPresent() {
+		return fn(ctx)
+	}
+
+	tx := t.db.Begin(readOnly)
+	ctx = values.WithMemoryTx(ctx, tx)
+	ctx, state := withTxState(ctx, readOnly)
+
+	if err := fn(ctx); err != nil {
+		tx.Rollback()
+		slog.Error(err.Error())
+		return err
+	}
+
+	tx.Commit()
+	state.committed()
+	return nil
 }
@@ -0,0 +1,63 @@
+// Package event tells the parts of the server that keep copies of stored data, such as caches, when the data changes.
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+type Kind string
+
+const (
+	// SettingsChanged is published when the settings of a diagram are saved or deleted. Its key is the diagram.
+	SettingsChanged Kind = "settings_changed"
+	// ItemChanged is published when an item is saved, deleted, trashed or restored. Its key is the item ID.
+	ItemChanged Kind = "item_changed"
+	// Resync is delivered when events may have been missed, so that every copy should be dropped.
+	Resync Kind = "resync"
+)
+
+type Event struct {
+	Kind   Kind   `json:"kind"`
+	UserID string `json:"userID"`
+	Key    string `json:"key"`
+}
+
+// Bus delivers the events published on this instance to its handlers and, depending on the implementation,
+// to the handlers of other instances. Handlers must not block.
+type Bus interface {
+	Publish(ctx context.Context, event Event)
+	Subscribe(handler func(Event))
+}
+
+// LocalBus delivers events to the handlers of this instance only.
+type LocalBus struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+func NewLocalBus() Bus {
+	return &LocalBus{}
+}
+
+func (b *LocalBus) Publish(_ context.Context, event Event) {
+	b.Deliver(event)
+}
+
+func (b *LocalBus) Subscribe(handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Deliver hands event to the handlers of this instance. Buses that span instances use it for events from others.
+func (b *LocalBus) Deliver(event Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
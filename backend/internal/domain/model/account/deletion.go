@@ -0,0 +1,101 @@
+package account
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type DeletionStatus string
+
+const (
+	// DeletionPending waits for the grace period to end and can still be cancelled.
+	DeletionPending DeletionStatus = "pending"
+	// DeletionPurging is being purged by a worker holding a lease until LeaseUntil.
+	DeletionPurging   DeletionStatus = "purging"
+	DeletionCancelled DeletionStatus = "cancelled"
+	DeletionCompleted DeletionStatus = "completed"
+)
+
+func (s DeletionStatus) IsValid() bool {
+	switch s {
+	case DeletionPending, DeletionPurging, DeletionCancelled, DeletionCompleted:
+		return true
+	}
+	return false
+}
+
+// IsActive reports whether the deletion has neither been cancelled nor completed.
+func (s DeletionStatus) IsActive() bool {
+	return s == DeletionPending || s == DeletionPurging
+}
+
+// Deletion is a request to delete an account. The data of the user is purged once ScheduledAt has passed,
+// and Receipt holds the signed deletion receipt afterwards.
+type Deletion struct {
+	ID          string
+	UserID      string
+	Status      DeletionStatus
+	RequestedAt time.Time
+	ScheduledAt time.Time
+	LeaseUntil  time.Time
+	CompletedAt time.Time
+	Receipt     string
+}
+
+func NewDeletion(userID string, now time.Time, gracePeriod time.Duration) *Deletion {
+	return &Deletion{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Status:      DeletionPending,
+		RequestedAt: now,
+		ScheduledAt: now.Add(gracePeriod),
+	}
+}
+
+func (d *Deletion) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":          d.ID,
+		"UserID":      d.UserID,
+		"Status":      string(d.Status),
+		"RequestedAt": d.RequestedAt,
+		"ScheduledAt": d.ScheduledAt,
+		"LeaseUntil":  d.LeaseUntil,
+		"CompletedAt": d.CompletedAt,
+		"Receipt":     d.Receipt,
+	}
+}
+
+func MapToDeletion(m map[string]interface{}) mo.Result[*Deletion] {
+	id, ok := m["ID"].(string)
+
+	if !ok {
+		return mo.Err[*Deletion](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	userID, _ := m["UserID"].(string)
+	status, _ := m["Status"].(string)
+	requestedAt, _ := m["RequestedAt"].(time.Time)
+	scheduledAt, _ := m["ScheduledAt"].(time.Time)
+	leaseUntil, _ := m["LeaseUntil"].(time.Time)
+	completedAt, _ := m["CompletedAt"].(time.Time)
+	receipt, _ := m["Receipt"].(string)
+
+	if userID == "" || !DeletionStatus(status).IsValid() {
+		return mo.Err[*Deletion](e.InvalidParameterError(errors.New("invalid account deletion")))
+	}
+
+	return mo.Ok(&Deletion{
+		ID:          id,
+		UserID:      userID,
+		Status:      DeletionStatus(status),
+		RequestedAt: requestedAt,
+		ScheduledAt: scheduledAt,
+		LeaseUntil:  leaseUntil,
+		CompletedAt: completedAt,
+		Receipt:     receipt,
+	})
+}
@@ -0,0 +1,34 @@
+package account
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewDeletion(t *testing.T) {
+	now := time.Now()
+	d := NewDeletion("uid", now, time.Hour)
+
+	if d.ID == "" || d.Status != DeletionPending || !d.ScheduledAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected deletion %+v", d)
+	}
+
+	if !d.Status.IsActive() || DeletionCancelled.IsActive() || DeletionCompleted.IsActive() {
+		t.Error("only pending and purging deletions should be active")
+	}
+}
+
+func TestMapToDeletion(t *testing.T) {
+	d := NewDeletion("uid", time.Now().Truncate(time.Second), time.Hour)
+	d.Status = DeletionCompleted
+	d.Receipt = "receipt"
+	restored := MapToDeletion(d.ToMap())
+
+	if restored.IsError() || *restored.MustGet() != *d {
+		t.Errorf("MapToDeletion() = %v, %v", restored.OrEmpty(), restored.Error())
+	}
+
+	if MapToDeletion(map[string]interface{}{"ID": "id", "UserID": "uid", "Status": "unknown"}).IsOk() {
+		t.Error("MapToDeletion() with an unknown status should fail")
+	}
+}
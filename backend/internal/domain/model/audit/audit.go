@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"time"
+)
+
+type Event string
+
+const (
+	AccountDeletionRequested Event = "account.deletion_requested"
+	AccountDeletionCancelled Event = "account.deletion_cancelled"
+	AccountDeletionCompleted Event = "account.deletion_completed"
+	AccountDeletionFailed    Event = "account.deletion_failed"
+)
+
+// Log is an append-only record of an action taken on an account. It outlives the data of the user.
+type Log struct {
+	UserID string
+	Event  Event
+	// TargetID identifies the subject of the event, such as the ID of an account deletion.
+	TargetID  string
+	Detail    string
+	CreatedAt time.Time
+}
+
+func New(userID string, event Event, targetID, detail string, now time.Time) *Log {
+	return &Log{
+		UserID:    userID,
+		Event:     event,
+		TargetID:  targetID,
+		Detail:    detail,
+		CreatedAt: now,
+	}
+}
+
+func (l *Log) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"UserID":    l.UserID,
+		"Event":     string(l.Event),
+		"TargetID":  l.TargetID,
+		"Detail":    l.Detail,
+		"CreatedAt": l.CreatedAt,
+	}
+}
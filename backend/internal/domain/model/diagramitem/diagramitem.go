@@ -3,6 +3,7 @@ package diagramitem
 import (
 	"errors"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,9 @@ var (
 	encryptKey = []byte(os.Getenv("ENCRYPT_KEY"))
 )
 
+// blobRefPrefix marks text and thumbnails that were moved to blob storage. The rest of the value is the blob key.
+const blobRefPrefix = "blob:"
+
 type DiagramItemBuilder interface {
 	WithID(ID string) DiagramItemBuilder
 	WithTitle(title string) DiagramItemBuilder
@@ -28,12 +32,14 @@ type DiagramItemBuilder interface {
 	WithIsBookmark(isPublic bool) DiagramItemBuilder
 	WithCreatedAt(createdAt time.Time) DiagramItemBuilder
 	WithUpdatedAt(updatedAt time.Time) DiagramItemBuilder
+	WithDeletedAt(deletedAt mo.Option[time.Time]) DiagramItemBuilder
 	Build() mo.Result[*DiagramItem]
 }
 
 type builder struct {
 	createdAt     time.Time
 	updatedAt     time.Time
+	deletedAt     mo.Option[time.Time]
 	thumbnail     mo.Option[string]
 	id            string
 	diagram       values.Diagram
@@ -122,6 +128,11 @@ func (b *builder) WithUpdatedAt(updatedAt time.Time) DiagramItemBuilder {
 	return b
 }
 
+func (b *builder) WithDeletedAt(deletedAt mo.Option[time.Time]) DiagramItemBuilder {
+	b.deletedAt = deletedAt
+	return b
+}
+
 func (b *builder) Build() mo.Result[*DiagramItem] {
 
 	if b.errors != nil {
@@ -138,13 +149,16 @@ func (b *builder) Build() mo.Result[*DiagramItem] {
 		isBookmark:    b.isBookmark,
 		createdAt:     b.createdAt,
 		updatedAt:     b.updatedAt,
+		deletedAt:     b.deletedAt,
 		isNew:         b.isNew,
 	})
 }
 
 type DiagramItem struct {
-	createdAt     time.Time
-	updatedAt     time.Time
+	createdAt time.Time
+	updatedAt time.Time
+	// deletedAt is set while the item is in the trash.
+	deletedAt     mo.Option[time.Time]
 	thumbnail     mo.Option[string]
 	id            string
 	diagram       values.Diagram
@@ -153,6 +167,8 @@ type DiagramItem struct {
 	isPublic      bool
 	isBookmark    bool
 	isNew         bool
+	// shareID is the share the item was opened through. It is never stored.
+	shareID string
 }
 
 func (i *DiagramItem) ID() string {
@@ -167,12 +183,23 @@ func (i *DiagramItem) Title() string {
 	return i.title
 }
 
+// Text returns the decrypted and decompressed text, or an empty text when it was not loaded.
+// Text stored before it was compressed is returned as is.
 func (i *DiagramItem) Text() string {
+	if i.TextRef().IsPresent() || i.encryptedText == "" {
+		return ""
+	}
+
 	if hasEncryptKey() {
 		text, err := util.Decrypt(encryptKey, i.encryptedText)
 		if err != nil {
 			return "invalid text"
 		}
+
+		text, err = util.Decompress(text)
+		if err != nil {
+			return "invalid text"
+		}
 		return text
 	} else {
 		return i.encryptedText
@@ -216,6 +243,72 @@ func (i *DiagramItem) UpdatedAt() time.Time {
 	return i.updatedAt
 }
 
+// DeletedAt returns when the item was moved to the trash, or nil when it is not in the trash.
+func (i *DiagramItem) DeletedAt() *time.Time {
+	if t, ok := i.deletedAt.Get(); ok {
+		return &t
+	}
+	return nil
+}
+
+func (i *DiagramItem) IsTrashed() bool {
+	return i.deletedAt.IsPresent()
+}
+
+// Trash returns a copy of the item moved to the trash at deletedAt. A trashed item is never public.
+func (i *DiagramItem) Trash(deletedAt time.Time) *DiagramItem {
+	item := *i
+	item.deletedAt = mo.Some(deletedAt)
+	item.isPublic = false
+	return &item
+}
+
+// Restore returns a copy of the item taken out of the trash.
+func (i *DiagramItem) Restore() *DiagramItem {
+	item := *i
+	item.deletedAt = mo.None[time.Time]()
+	return &item
+}
+
+// TextRef returns the blob key of text that has not been loaded from blob storage.
+func (i *DiagramItem) TextRef() mo.Option[string] {
+	return blobRef(i.encryptedText)
+}
+
+// ThumbnailRef returns the blob key of a thumbnail that has not been loaded from blob storage.
+func (i *DiagramItem) ThumbnailRef() mo.Option[string] {
+	return blobRef(i.thumbnail.OrEmpty())
+}
+
+// OffloadText returns a copy of the item that refers to the text stored under key.
+func (i *DiagramItem) OffloadText(key string) *DiagramItem {
+	item := *i
+	item.encryptedText = blobRefPrefix + key
+	return &item
+}
+
+// OffloadThumbnail returns a copy of the item that refers to the thumbnail stored under key.
+func (i *DiagramItem) OffloadThumbnail(key string) *DiagramItem {
+	item := *i
+	item.thumbnail = mo.Some(blobRefPrefix + key)
+	return &item
+}
+
+func (i *DiagramItem) LoadThumbnail(thumbnail string) *DiagramItem {
+	i.thumbnail = mo.EmptyableToOption(thumbnail)
+	return i
+}
+
+// SharedAs marks the item as opened through the share stored under shareID.
+func (i *DiagramItem) SharedAs(shareID string) *DiagramItem {
+	i.shareID = shareID
+	return i
+}
+
+func (i *DiagramItem) ShareID() mo.Option[string] {
+	return mo.EmptyableToOption(i.shareID)
+}
+
 func (i *DiagramItem) IsTextEmpty() bool {
 	return i.encryptedText == ""
 }
@@ -296,6 +389,12 @@ func MapToDiagramItem(v map[string]interface{}) mo.Result[*DiagramItem] {
 		return mo.Err[*DiagramItem](e.InvalidParameterError(e.ErrInvalidUpdatedAt))
 	}
 
+	var deletedAt mo.Option[time.Time]
+
+	if t, ok := v["DeletedAt"].(time.Time); ok {
+		deletedAt = mo.Some(t)
+	}
+
 	item := New().
 		WithID(id).
 		WithTitle(title).
@@ -306,6 +405,7 @@ func MapToDiagramItem(v map[string]interface{}) mo.Result[*DiagramItem] {
 		WithIsBookmark(isBookmark).
 		WithCreatedAt(createdAt).
 		WithUpdatedAt(updatedAt).
+		WithDeletedAt(deletedAt).
 		Build()
 
 	return item
@@ -317,7 +417,7 @@ func (i *DiagramItem) ClearText() *DiagramItem {
 }
 
 func (i *DiagramItem) ToMap() map[string]interface{} {
-	return map[string]interface{}{"ID": i.id,
+	m := map[string]interface{}{"ID": i.id,
 		"Title":         i.title,
 		"Text":          i.encryptedText,
 		"Thumbnail":     i.thumbnail.OrEmpty(),
@@ -326,16 +426,32 @@ func (i *DiagramItem) ToMap() map[string]interface{} {
 		"IsBookmark":    i.isBookmark,
 		"CreatedAt":     i.createdAt,
 		"UpdatedAt":     i.updatedAt,
-		"SaveToStorage": true}
+		"SaveToStorage": i.TextRef().IsPresent()}
+
+	if t, ok := i.deletedAt.Get(); ok {
+		m["DeletedAt"] = t
+	}
+
+	return m
+}
+
+func blobRef(v string) mo.Option[string] {
+	if key, ok := strings.CutPrefix(v, blobRefPrefix); ok {
+		return mo.Some(key)
+	}
+
+	return mo.None[string]()
 }
 
 func hasEncryptKey() bool {
 	return len(encryptKey) > 0
 }
 
+// encryptText compresses and encrypts text. Without an encryption key the text is stored as plain, readable text,
+// so it is not compressed either.
 func encryptText(text string) (*string, error) {
 	if hasEncryptKey() {
-		t, err := util.Encrypt(encryptKey, text)
+		t, err := util.Encrypt(encryptKey, util.Compress(text))
 
 		if err != nil {
 			return nil, e.EncryptionFailedError(err)
@@ -1,6 +1,12 @@
 package diagramitem
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/util"
+)
 
 func TestEncryptedTextBuild(t *testing.T) {
 	d := New().WithID("id").WithEncryptedText("encryptedText").Build()
@@ -38,3 +44,71 @@ func TestPlainTextBuild(t *testing.T) {
 		t.Fatal("Failed Text()")
 	}
 }
+
+func TestOffloadText(t *testing.T) {
+	d := New().WithID("id").WithEncryptedText("encryptedText").Build().OrEmpty()
+	offloaded := d.OffloadText("users/uid/items/id/text")
+
+	if d.TextRef().IsPresent() {
+		t.Fatal("OffloadText() should not change the original item")
+	}
+
+	if offloaded.TextRef().OrEmpty() != "users/uid/items/id/text" || offloaded.Text() != "" {
+		t.Fatal("Failed OffloadText()")
+	}
+
+	if offloaded.ToMap()["SaveToStorage"] != true || d.ToMap()["SaveToStorage"] != false {
+		t.Fatal("SaveToStorage should tell whether the text is in storage")
+	}
+
+	if offloaded.UpdateEncryptedText("encryptedText").TextRef().IsPresent() {
+		t.Fatal("Loaded text should not be a reference")
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	d := New().WithID("id").WithIsPublic(true).Build().OrEmpty()
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	trashed := d.Trash(deletedAt)
+
+	if d.IsTrashed() || !trashed.IsTrashed() || !trashed.DeletedAt().Equal(deletedAt) || trashed.IsPublic() {
+		t.Fatal("Failed Trash()")
+	}
+
+	if trashed.ToMap()["DeletedAt"] != deletedAt {
+		t.Fatal("DeletedAt should be stored for a trashed item")
+	}
+
+	if _, ok := d.ToMap()["DeletedAt"]; ok {
+		t.Fatal("DeletedAt should not be stored for an item outside the trash")
+	}
+
+	if trashed.Restore().IsTrashed() || trashed.Restore().DeletedAt() != nil {
+		t.Fatal("Failed Restore()")
+	}
+}
+
+func TestPlainTextCompression(t *testing.T) {
+	encryptKey = []byte("000000000X000000000X000000000X12")
+	text := strings.Repeat("activity\n    task\n        story\n", 500)
+	d := New().WithID("id").WithPlainText(text).Build().OrEmpty()
+
+	if len(d.EncryptedText()) >= len(text)/5 {
+		t.Fatal("Text should be compressed before it is encrypted")
+	}
+
+	if d.Text() != text {
+		t.Fatal("Failed Text()")
+	}
+
+	// Text encrypted before compression was introduced stays readable.
+	legacy, err := util.Encrypt(encryptKey, text)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if New().WithID("id").WithEncryptedText(legacy).Build().OrEmpty().Text() != text {
+		t.Fatal("Failed Text() of uncompressed text")
+	}
+}
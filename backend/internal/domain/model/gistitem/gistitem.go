@@ -1,14 +1,26 @@
 package gistitem
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/harehare/textusm/internal/domain/values"
 	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/util"
 	"github.com/samber/mo"
 )
 
+// encryptKey is read on use so the key can be provided after start-up, e.g. in tests.
+func encryptKey() []byte {
+	return []byte(os.Getenv("ENCRYPT_KEY"))
+}
+
+// blobRefPrefix marks thumbnails that were moved to blob storage. The rest of the value is the blob key.
+const blobRefPrefix = "blob:"
+
 type GistItemBuilder interface {
 	WithID(ID string) GistItemBuilder
 	WithURL(url string) GistItemBuilder
@@ -19,19 +31,24 @@ type GistItemBuilder interface {
 	WithIsBookmark(isPublic bool) GistItemBuilder
 	WithCreatedAt(createdAt time.Time) GistItemBuilder
 	WithUpdatedAt(updatedAt time.Time) GistItemBuilder
+	WithRevision(revision string) GistItemBuilder
+	WithEncryptedText(text string) GistItemBuilder
+	WithPlainText(text string) GistItemBuilder
 	Build() mo.Result[*GistItem]
 }
 
 type builder struct {
-	createdAt  time.Time
-	updatedAt  time.Time
-	thumbnail  mo.Option[string]
-	title      string
-	diagram    values.Diagram
-	id         string
-	url        string
-	errors     []error
-	isBookmark bool
+	createdAt     time.Time
+	updatedAt     time.Time
+	thumbnail     mo.Option[string]
+	title         string
+	diagram       values.Diagram
+	id            string
+	url           string
+	revision      string
+	encryptedText string
+	errors        []error
+	isBookmark    bool
 }
 
 func New() GistItemBuilder {
@@ -87,6 +104,36 @@ func (b *builder) WithUpdatedAt(updatedAt time.Time) GistItemBuilder {
 	return b
 }
 
+func (b *builder) WithRevision(revision string) GistItemBuilder {
+	b.revision = revision
+	return b
+}
+
+func (b *builder) WithEncryptedText(text string) GistItemBuilder {
+	b.encryptedText = text
+	return b
+}
+
+// WithPlainText encrypts the cached content of the gist, which is never stored in plain text.
+func (b *builder) WithPlainText(text string) GistItemBuilder {
+	key := encryptKey()
+
+	if len(key) == 0 {
+		b.errors = append(b.errors, e.EncryptionFailedError(e.ErrEncryptKeyRequired))
+		return b
+	}
+
+	t, err := util.Encrypt(key, text)
+
+	if err != nil {
+		b.errors = append(b.errors, e.EncryptionFailedError(err))
+		return b
+	}
+
+	b.encryptedText = t
+	return b
+}
+
 func (b *builder) Build() mo.Result[*GistItem] {
 
 	if len(b.errors) > 0 {
@@ -94,26 +141,31 @@ func (b *builder) Build() mo.Result[*GistItem] {
 	}
 
 	return mo.Ok(&GistItem{
-		id:         b.id,
-		url:        b.url,
-		title:      b.title,
-		diagram:    b.diagram,
-		thumbnail:  b.thumbnail,
-		isBookmark: b.isBookmark,
-		createdAt:  b.createdAt,
-		updatedAt:  b.updatedAt,
+		id:            b.id,
+		url:           b.url,
+		title:         b.title,
+		diagram:       b.diagram,
+		thumbnail:     b.thumbnail,
+		isBookmark:    b.isBookmark,
+		createdAt:     b.createdAt,
+		updatedAt:     b.updatedAt,
+		revision:      b.revision,
+		encryptedText: b.encryptedText,
 	})
 }
 
+// GistItem is a diagram stored in a GitHub gist. Revision is the gist version the cached text was read from or written as.
 type GistItem struct {
-	createdAt  time.Time
-	updatedAt  time.Time
-	thumbnail  mo.Option[string]
-	title      string
-	diagram    values.Diagram
-	id         string
-	url        string
-	isBookmark bool
+	createdAt     time.Time
+	updatedAt     time.Time
+	thumbnail     mo.Option[string]
+	title         string
+	diagram       values.Diagram
+	id            string
+	url           string
+	revision      string
+	encryptedText string
+	isBookmark    bool
 }
 
 func (i *GistItem) ID() string {
@@ -156,11 +208,58 @@ func (i *GistItem) UpdatedAt() time.Time {
 	return i.updatedAt
 }
 
+func (i *GistItem) Revision() string {
+	return i.revision
+}
+
+// Text returns the cached content of the gist at Revision, or none when it was not cached, not loaded or
+// cannot be decrypted, such as without an encrypt key.
+func (i *GistItem) Text() mo.Option[string] {
+	key := encryptKey()
+
+	if i.revision == "" || i.encryptedText == "" || len(key) == 0 {
+		return mo.None[string]()
+	}
+
+	text, err := util.Decrypt(key, i.encryptedText)
+
+	if err != nil {
+		return mo.None[string]()
+	}
+
+	return mo.Some(text)
+}
+
+func (i *GistItem) EncryptedText() string {
+	return i.encryptedText
+}
+
 func (i *GistItem) Bookmark(isBookmark bool) *GistItem {
 	i.isBookmark = isBookmark
 	return i
 }
 
+// ThumbnailRef returns the blob key of a thumbnail that has not been loaded from blob storage.
+func (i *GistItem) ThumbnailRef() mo.Option[string] {
+	if key, ok := strings.CutPrefix(i.thumbnail.OrEmpty(), blobRefPrefix); ok {
+		return mo.Some(key)
+	}
+
+	return mo.None[string]()
+}
+
+// OffloadThumbnail returns a copy of the item that refers to the thumbnail stored under key.
+func (i *GistItem) OffloadThumbnail(key string) *GistItem {
+	item := *i
+	item.thumbnail = mo.Some(blobRefPrefix + key)
+	return &item
+}
+
+func (i *GistItem) LoadThumbnail(thumbnail string) *GistItem {
+	i.thumbnail = mo.EmptyableToOption(thumbnail)
+	return i
+}
+
 func MapToGistItem(v map[string]interface{}) mo.Result[*GistItem] {
 	id, ok := v["ID"].(string)
 
@@ -217,6 +316,9 @@ func MapToGistItem(v map[string]interface{}) mo.Result[*GistItem] {
 		return mo.Err[*GistItem](e.InvalidParameterError(e.ErrInvalidUpdatedAt))
 	}
 
+	revision, _ := v["Revision"].(string)
+	text, _ := v["Text"].(string)
+
 	return New().
 		WithID(id).
 		WithURL(url).
@@ -226,6 +328,8 @@ func MapToGistItem(v map[string]interface{}) mo.Result[*GistItem] {
 		WithIsBookmark(isBookmark).
 		WithCreatedAt(createdAt).
 		WithUpdatedAt(updatedAt).
+		WithRevision(revision).
+		WithEncryptedText(text).
 		Build()
 }
 
@@ -237,5 +341,21 @@ func (i *GistItem) ToMap() map[string]interface{} {
 		"Diagram":    i.diagram,
 		"IsBookmark": i.isBookmark,
 		"CreatedAt":  i.createdAt,
-		"UpdatedAt":  i.updatedAt}
+		"UpdatedAt":  i.updatedAt,
+		"Revision":   i.revision,
+		"Text":       i.encryptedText}
+}
+
+// Conflict describes a gist that changed upstream in the same place as the local edit.
+type Conflict struct {
+	BaseRevision string
+	Revision     string
+	Base         string
+	Ours         string
+	Theirs       string
+	Merged       string
+}
+
+func (c *Conflict) Error() string {
+	return fmt.Sprintf("gist changed upstream from %s to %s", c.BaseRevision, c.Revision)
 }
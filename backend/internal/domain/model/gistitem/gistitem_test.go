@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 )
 
@@ -77,6 +78,23 @@ func TestThumbnailAbsent(t *testing.T) {
 	}
 }
 
+func TestOffloadThumbnail(t *testing.T) {
+	item := New().WithID("id").WithThumbnail(mo.Some("thumb")).Build().OrEmpty()
+	offloaded := item.OffloadThumbnail("users/uid/gists/id/thumbnail")
+
+	if offloaded.ThumbnailRef().OrEmpty() != "users/uid/gists/id/thumbnail" {
+		t.Errorf("ThumbnailRef() = %v", offloaded.ThumbnailRef())
+	}
+
+	if item.ThumbnailRef().IsPresent() || *item.Thumbnail() != "thumb" {
+		t.Error("OffloadThumbnail() should not change the original item")
+	}
+
+	if offloaded.LoadThumbnail("thumb").ThumbnailRef().IsPresent() {
+		t.Error("LoadThumbnail() should replace the blob reference")
+	}
+}
+
 func TestBookmark(t *testing.T) {
 	item := New().WithID("id").WithIsBookmark(false).Build().OrEmpty()
 	if item.IsBookmark() {
@@ -204,3 +222,52 @@ func TestToMap(t *testing.T) {
 		t.Errorf("ToMap()[IsBookmark] = %v", m["IsBookmark"])
 	}
 }
+
+func TestCachedText(t *testing.T) {
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+
+	now := time.Now()
+	item := New().WithID("id").WithTitle("title").WithDiagramString("USER_STORY_MAP").
+		WithCreatedAt(now).WithUpdatedAt(now).WithRevision("sha").WithPlainText("# text").Build().OrEmpty()
+
+	if item.EncryptedText() == "# text" {
+		t.Fatal("cached text should be encrypted")
+	}
+
+	m := item.ToMap()
+	m["Diagram"] = string(item.Diagram())
+	restored := MapToGistItem(m)
+
+	if restored.IsError() {
+		t.Fatal(restored.Error())
+	}
+
+	if restored.OrEmpty().Revision() != "sha" || restored.OrEmpty().Text().OrEmpty() != "# text" {
+		t.Errorf("unexpected cached content %s %s", restored.OrEmpty().Revision(), restored.OrEmpty().Text().OrEmpty())
+	}
+}
+
+func TestCachedTextWithoutRevision(t *testing.T) {
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+	item := New().WithID("id").WithPlainText("# text").Build().OrEmpty()
+
+	if item.Text().IsPresent() {
+		t.Error("Text() should be absent until a revision is cached")
+	}
+}
+
+func TestCachedTextWithoutEncryptKey(t *testing.T) {
+	t.Setenv("ENCRYPT_KEY", "")
+
+	item := New().WithID("id").WithRevision("sha").WithPlainText("# text").Build()
+
+	if e.GetCode(item.Error()) != e.EncryptionFailed {
+		t.Fatalf("expected encryption failed, got %v", item.Error())
+	}
+
+	stored := New().WithID("id").WithRevision("sha").WithEncryptedText("# text").Build().OrEmpty()
+
+	if stored.Text().IsPresent() {
+		t.Error("Text() should be absent without an encrypt key")
+	}
+}
@@ -0,0 +1,122 @@
+package gitsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// ManifestFile is kept in each user's directory of the repository.
+const ManifestFile = ".textusm.json"
+
+var unsafeFilename = regexp.MustCompile(`[^\p{L}\p{N}._ -]+`)
+
+// Result is the outcome of syncing a single item.
+type Result struct {
+	Message *string
+	ItemID  string
+	Path    string
+	Status  values.SyncStatus
+}
+
+func NewResult(itemID, path string, status values.SyncStatus, message string) *Result {
+	r := Result{ItemID: itemID, Path: path, Status: status}
+
+	if message != "" {
+		r.Message = &message
+	}
+
+	return &r
+}
+
+// Entry links an item to its file. Hash is the hash of the text both sides agreed on at the last sync.
+type Entry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// Manifest records which items are mirrored to the repository.
+type Manifest struct {
+	Items map[string]*Entry `json:"items"`
+}
+
+func NewManifest() *Manifest {
+	return &Manifest{Items: map[string]*Entry{}}
+}
+
+// ParseManifest reads the manifest of dir. The manifest is pushed to the repository like any other file, so
+// every entry must be a file directly inside dir, as Track creates them.
+func ParseManifest(s string, dir string) mo.Result[*Manifest] {
+	m := NewManifest()
+
+	if err := json.Unmarshal([]byte(s), m); err != nil {
+		return mo.Err[*Manifest](e.InvalidParameterError(fmt.Errorf("invalid %s: %w", ManifestFile, err)))
+	}
+
+	if m.Items == nil {
+		m.Items = map[string]*Entry{}
+	}
+
+	for itemID, entry := range m.Items {
+		if entry == nil || path.Clean(entry.Path) != entry.Path || path.Dir(entry.Path) != dir || path.Base(entry.Path) == ManifestFile {
+			return mo.Err[*Manifest](e.InvalidParameterError(fmt.Errorf("invalid %s: path of %s is outside %s", ManifestFile, itemID, dir)))
+		}
+	}
+
+	return mo.Ok(m)
+}
+
+func (m *Manifest) String() string {
+	b, _ := json.MarshalIndent(m, "", "  ")
+	return string(b) + "\n"
+}
+
+// ItemID returns the item the file at p belongs to.
+func (m *Manifest) ItemID(p string) mo.Option[string] {
+	for id, entry := range m.Items {
+		if entry.Path == p {
+			return mo.Some(id)
+		}
+	}
+
+	return mo.None[string]()
+}
+
+// Track adds the item under dir with a filename derived from its title that is not tracked yet and for which exists returns false.
+func (m *Manifest) Track(itemID, dir, title string, diagram values.Diagram, exists func(p string) bool) *Entry {
+	name := strings.TrimSpace(unsafeFilename.ReplaceAllString(title, "-"))
+	name = strings.Trim(name, ".")
+
+	if name == "" {
+		name = "untitled"
+	}
+
+	ext := diagram.Extension()
+
+	if ext == "" {
+		ext = "txt"
+	}
+
+	p := path.Join(dir, name+"."+ext)
+
+	for i := 2; m.ItemID(p).IsPresent() || exists(p); i++ {
+		p = path.Join(dir, fmt.Sprintf("%s-%d.%s", name, i, ext))
+	}
+
+	entry := &Entry{Path: p}
+	m.Items[itemID] = entry
+	return entry
+}
+
+func Hash(text string) string {
+	h := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(h[:])
+}
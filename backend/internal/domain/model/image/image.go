@@ -0,0 +1,89 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// Image is a picture uploaded for an item. The picture itself is kept in blob storage under BlobKey.
+type Image struct {
+	ID          string
+	UserID      string
+	ItemID      string
+	Location    v.Location
+	ContentType string
+	Size        int64
+	// Hash is the hex encoded SHA-256 of the picture.
+	Hash      string
+	CreatedAt time.Time
+}
+
+func New(userID, itemID string, location v.Location, contentType string, data []byte, now time.Time) *Image {
+	sum := sha256.Sum256(data)
+
+	return &Image{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		ItemID:      itemID,
+		Location:    location,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Hash:        hex.EncodeToString(sum[:]),
+		CreatedAt:   now,
+	}
+}
+
+func (i *Image) BlobKey() string {
+	return "users/" + i.UserID + "/images/" + i.ID
+}
+
+func (i *Image) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":          i.ID,
+		"UserID":      i.UserID,
+		"ItemID":      i.ItemID,
+		"Location":    i.Location.String(),
+		"ContentType": i.ContentType,
+		"Size":        i.Size,
+		"Hash":        i.Hash,
+		"CreatedAt":   i.CreatedAt,
+	}
+}
+
+func MapToImage(m map[string]interface{}) mo.Result[*Image] {
+	id, ok := m["ID"].(string)
+
+	if !ok {
+		return mo.Err[*Image](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	userID, _ := m["UserID"].(string)
+	itemID, _ := m["ItemID"].(string)
+	location, _ := m["Location"].(string)
+	contentType, _ := m["ContentType"].(string)
+	size, _ := m["Size"].(int64)
+	hash, _ := m["Hash"].(string)
+	createdAt, _ := m["CreatedAt"].(time.Time)
+
+	if userID == "" || !v.Location(location).IsValid() {
+		return mo.Err[*Image](e.InvalidParameterError(errors.New("invalid image")))
+	}
+
+	return mo.Ok(&Image{
+		ID:          id,
+		UserID:      userID,
+		ItemID:      itemID,
+		Location:    v.Location(location),
+		ContentType: contentType,
+		Size:        size,
+		Hash:        hash,
+		CreatedAt:   createdAt,
+	})
+}
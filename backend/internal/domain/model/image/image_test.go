@@ -0,0 +1,33 @@
+package image
+
+import (
+	"testing"
+	"time"
+
+	v "github.com/harehare/textusm/internal/domain/values"
+)
+
+func TestNew(t *testing.T) {
+	img := New("uid", "item", v.LocationSystem, "image/png", []byte("png"), time.Now())
+
+	if img.ID == "" || img.Size != 3 || len(img.Hash) != 64 {
+		t.Errorf("unexpected image %+v", img)
+	}
+
+	if img.BlobKey() != "users/uid/images/"+img.ID {
+		t.Errorf("BlobKey() = %s", img.BlobKey())
+	}
+}
+
+func TestMapToImage(t *testing.T) {
+	img := New("uid", "item", v.LocationGist, "image/png", []byte("png"), time.Now().Truncate(time.Second))
+	restored := MapToImage(img.ToMap())
+
+	if restored.IsError() || *restored.MustGet() != *img {
+		t.Errorf("MapToImage() = %v, %v", restored.OrEmpty(), restored.Error())
+	}
+
+	if MapToImage(map[string]interface{}{"ID": "id"}).IsOk() {
+		t.Error("MapToImage() without an owner should fail")
+	}
+}
@@ -5,29 +5,55 @@ import (
 	"strings"
 
 	"github.com/samber/lo"
+	"github.com/samber/mo"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Share struct {
 	Token          string
+	Code           string
 	Password       string
 	AllowIPList    []string
 	ExpireTime     int64
 	AllowEmailList []string
+	RemainingViews mo.Option[int]
 }
 
 type ShareCondition struct {
 	Token          string   `json:"token"`
+	Code           string   `json:"code"`
 	UsePassword    bool     `json:"usePassword"`
 	ExpireTime     int      `json:"expireTime"`
 	AllowIPList    []string `json:"allowIPList"`
 	AllowEmailList []string `json:"allowEmailList"`
+	RemainingViews *int     `json:"remainingViews"`
+}
+
+// HashPassword returns the bcrypt hash stored for a share password. An empty password stays empty.
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashed), nil
 }
 
 func (s *Share) ComparePassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(s.Password), []byte(password))
 }
 
+// ViewsExhausted reports whether a view-limited share has no views left.
+func (s *Share) ViewsExhausted() bool {
+	remaining, limited := s.RemainingViews.Get()
+	return limited && remaining <= 0
+}
+
 func (s *Share) ValidEmail(email string) bool {
 	if len(s.AllowEmailList) == 0 {
 		return true
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/samber/mo"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -146,3 +147,38 @@ func TestCheckIpCIDRBoundary(t *testing.T) {
 		t.Error("CheckIpWithinRange() should deny IP outside /8 CIDR")
 	}
 }
+
+func TestViewsExhausted(t *testing.T) {
+	tests := []struct {
+		remainingViews mo.Option[int]
+		want           bool
+	}{
+		{mo.None[int](), false},
+		{mo.Some(2), false},
+		{mo.Some(0), true},
+	}
+	for _, tt := range tests {
+		share := Share{RemainingViews: tt.remainingViews}
+
+		if got := share.ViewsExhausted(); got != tt.want {
+			t.Errorf("ViewsExhausted() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hashed, err := HashPassword("secret")
+
+	if err != nil {
+		t.Fatalf("HashPassword() returned error: %v", err)
+	}
+
+	share := Share{Password: hashed}
+	if err := share.ComparePassword("secret"); err != nil {
+		t.Errorf("ComparePassword() with hashed password should return nil, got %v", err)
+	}
+
+	if empty, _ := HashPassword(""); empty != "" {
+		t.Errorf("HashPassword(\"\") = %q, want empty", empty)
+	}
+}
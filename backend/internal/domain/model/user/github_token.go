@@ -0,0 +1,94 @@
+package user
+
+import (
+	"os"
+	"time"
+
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/util"
+	"github.com/samber/mo"
+)
+
+// encryptKey is read on use so the key can be provided after start-up, e.g. in tests.
+func encryptKey() []byte {
+	return []byte(os.Getenv("ENCRYPT_KEY"))
+}
+
+// GithubToken is the GitHub OAuth token of a user. The access token is always stored encrypted.
+type GithubToken struct {
+	createdAt            time.Time
+	updatedAt            time.Time
+	encryptedAccessToken string
+	scope                string
+}
+
+func NewGithubToken(accessToken, scope string, now time.Time) mo.Result[*GithubToken] {
+	key := encryptKey()
+
+	if len(key) == 0 {
+		return mo.Err[*GithubToken](e.EncryptionFailedError(e.ErrEncryptKeyRequired))
+	}
+
+	t, err := util.Encrypt(key, accessToken)
+
+	if err != nil {
+		return mo.Err[*GithubToken](e.EncryptionFailedError(err))
+	}
+
+	return mo.Ok(&GithubToken{
+		encryptedAccessToken: t,
+		scope:                scope,
+		createdAt:            now,
+		updatedAt:            now,
+	})
+}
+
+func NewGithubTokenFromEncrypted(encryptedAccessToken, scope string, createdAt, updatedAt time.Time) *GithubToken {
+	return &GithubToken{
+		encryptedAccessToken: encryptedAccessToken,
+		scope:                scope,
+		createdAt:            createdAt,
+		updatedAt:            updatedAt,
+	}
+}
+
+func (t *GithubToken) AccessToken() mo.Result[string] {
+	key := encryptKey()
+
+	if len(key) == 0 {
+		return mo.Err[string](e.DecryptionFailedError(e.ErrEncryptKeyRequired))
+	}
+
+	accessToken, err := util.Decrypt(key, t.encryptedAccessToken)
+
+	if err != nil {
+		return mo.Err[string](e.DecryptionFailedError(err))
+	}
+
+	return mo.Ok(accessToken)
+}
+
+func (t *GithubToken) EncryptedAccessToken() string {
+	return t.encryptedAccessToken
+}
+
+func (t *GithubToken) Scope() string {
+	return t.scope
+}
+
+func (t *GithubToken) CreatedAt() time.Time {
+	return t.createdAt
+}
+
+func (t *GithubToken) UpdatedAt() time.Time {
+	return t.updatedAt
+}
+
+func (t *GithubToken) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"AccessToken": t.encryptedAccessToken,
+		"Scope":       t.scope,
+		"CreatedAt":   t.createdAt,
+		"UpdatedAt":   t.updatedAt,
+	}
+}
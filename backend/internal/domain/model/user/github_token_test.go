@@ -0,0 +1,38 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	e "github.com/harehare/textusm/internal/error"
+)
+
+func TestNewGithubToken(t *testing.T) {
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+
+	token := NewGithubToken("gho_secret", "gist", time.Now())
+
+	if token.IsError() {
+		t.Fatal(token.Error())
+	}
+
+	if token.MustGet().EncryptedAccessToken() == "gho_secret" {
+		t.Fatal("access token should be encrypted")
+	}
+
+	restored := NewGithubTokenFromEncrypted(token.MustGet().EncryptedAccessToken(), "gist", time.Now(), time.Now())
+
+	if restored.AccessToken().OrEmpty() != "gho_secret" {
+		t.Fatalf("unexpected access token %s", restored.AccessToken().OrEmpty())
+	}
+}
+
+func TestNewGithubTokenWithoutEncryptKey(t *testing.T) {
+	t.Setenv("ENCRYPT_KEY", "")
+
+	token := NewGithubToken("gho_secret", "gist", time.Now())
+
+	if e.GetCode(token.Error()) != e.EncryptionFailed {
+		t.Fatalf("expected encryption failed, got %v", token.Error())
+	}
+}
@@ -15,3 +15,11 @@ func NewUser(u *auth.UserRecord) User {
 		Email: u.Email,
 	}
 }
+
+// LocalUID is the only user of a server running in local mode.
+const LocalUID = "local"
+
+// LocalUser returns the user every request is made as in local mode.
+func LocalUser() User {
+	return User{UID: LocalUID, Name: "Local user"}
+}
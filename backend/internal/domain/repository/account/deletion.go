@@ -0,0 +1,25 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/harehare/textusm/internal/domain/model/account"
+	"github.com/samber/mo"
+)
+
+// DeletionRepository keeps account deletion requests. Requests are not scoped to the signed-in user,
+// since they are purged by a background worker and their receipts outlive the account.
+type DeletionRepository interface {
+	FindByID(ctx context.Context, deletionID string) mo.Result[*account.Deletion]
+	// FindActive returns the pending or purging deletion of the user, or a NotFound error.
+	FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion]
+	// FindDue returns the deletions scheduled before now that are pending or whose lease has expired.
+	FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion]
+	Create(ctx context.Context, deletion *account.Deletion) mo.Result[bool]
+	// Claim marks a due deletion as purging until leaseUntil. It returns false when another worker holds it or it was cancelled.
+	Claim(ctx context.Context, deletionID string, now, leaseUntil time.Time) mo.Result[bool]
+	// Cancel cancels a pending deletion. It returns false when the deletion is no longer pending.
+	Cancel(ctx context.Context, deletionID string) mo.Result[bool]
+	Complete(ctx context.Context, deletionID string, completedAt time.Time, receipt string) mo.Result[bool]
+}
@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	"github.com/samber/mo"
+)
+
+// AuditLogRepository appends audit logs. Logs are never updated nor deleted.
+type AuditLogRepository interface {
+	Append(ctx context.Context, log *audit.Log) mo.Result[bool]
+}
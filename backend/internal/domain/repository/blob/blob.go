@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/samber/mo"
+)
+
+type Blob struct {
+	ContentType string
+	Data        []byte
+}
+
+// ParseDataURL decodes a base64 data URL such as the PNG thumbnails the frontend sends.
+func ParseDataURL(s string) (*Blob, bool) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(s, "data:"), ",")
+
+	if !ok || !strings.HasPrefix(s, "data:") || !strings.HasSuffix(meta, ";base64") {
+		return nil, false
+	}
+
+	b, err := base64.StdEncoding.DecodeString(data)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return &Blob{ContentType: strings.TrimSuffix(meta, ";base64"), Data: b}, true
+}
+
+func (b *Blob) DataURL() string {
+	return "data:" + b.ContentType + ";base64," + base64.StdEncoding.EncodeToString(b.Data)
+}
+
+// BlobStore keeps content too large for the database, such as long diagram text and thumbnails.
+// Get returns a NotFound error for a missing key and Delete succeeds for one.
+type BlobStore interface {
+	Get(ctx context.Context, key string) mo.Result[*Blob]
+	Put(ctx context.Context, key string, blob *Blob) mo.Result[bool]
+	Delete(ctx context.Context, key string) mo.Result[bool]
+}
@@ -0,0 +1,19 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/samber/mo"
+)
+
+// CatalogRepository enumerates the data stored in a backend regardless of the signed-in user.
+// It is meant for maintenance tools such as data migration and must not be exposed through the API.
+type CatalogRepository interface {
+	// UserIDs returns the IDs of every user owning data, in ascending order.
+	UserIDs(ctx context.Context) mo.Result[[]string]
+	// ItemIDs returns the IDs of the diagram items owned by userID outside the trash, in ascending order.
+	// Callers copying a whole account read the trash with ItemRepository.FindTrash, which keeps when items were trashed.
+	ItemIDs(ctx context.Context, userID string) mo.Result[[]string]
+	// GistIDs returns the IDs of the gist items owned by userID, in ascending order.
+	GistIDs(ctx context.Context, userID string) mo.Result[[]string]
+}
@@ -2,14 +2,35 @@ package diagramitem
 
 import (
 	"context"
+	"time"
 
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/values"
 	"github.com/samber/mo"
 )
 
+// TrashedItem identifies an item in the trash of a user.
+type TrashedItem struct {
+	UserID string
+	ItemID string
+}
+
+// ItemRepository keeps diagram items. FindByID and Find never return items in the trash, and Delete
+// removes an item for good whether or not it is in the trash. Reads load the fields selected by fields only.
 type ItemRepository interface {
-	FindByID(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem]
-	Find(ctx context.Context, userID string, offset, limit int, isPublic bool, isBookmark bool, shouldLoadText bool) mo.Result[[]*diagramitem.DiagramItem]
+	FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields values.Projection) mo.Result[*diagramitem.DiagramItem]
+	// FindByIDs reads the items of itemIDs at once. Items that do not exist are left out, and the order is unspecified.
+	FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields values.Projection) mo.Result[[]*diagramitem.DiagramItem]
+	// Find lists the items of the user that match filter, in the order of filter.
+	Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter values.ItemFilter, fields values.Projection) mo.Result[[]*diagramitem.DiagramItem]
 	Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem]
 	Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool]
+	// FindTrash returns the items in the trash of the user, most recently deleted first.
+	FindTrash(ctx context.Context, userID string, offset, limit int, fields values.Projection) mo.Result[[]*diagramitem.DiagramItem]
+	// FindExpiredTrash returns the items of any user that were moved to the trash before deletedBefore.
+	FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]TrashedItem]
+	// Trash moves the private item to the trash. It returns false when there is no such item outside the trash.
+	Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool]
+	// Restore takes the item out of the trash. It returns false when the item is not in the trash.
+	Restore(ctx context.Context, userID string, itemID string) mo.Result[bool]
 }
@@ -4,12 +4,16 @@ import (
 	"context"
 
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/values"
 	"github.com/samber/mo"
 )
 
+// GistItemRepository keeps gist items. Reads load the fields selected by fields only.
 type GistItemRepository interface {
-	FindByID(ctx context.Context, userID string, gistID string) mo.Result[*gistitem.GistItem]
-	Find(ctx context.Context, userID string, offset, limit int) mo.Result[[]*gistitem.GistItem]
+	FindByID(ctx context.Context, userID string, gistID string, fields values.Projection) mo.Result[*gistitem.GistItem]
+	// FindByIDs reads the items of gistIDs at once. Items that do not exist are left out, and the order is unspecified.
+	FindByIDs(ctx context.Context, userID string, gistIDs []string, fields values.Projection) mo.Result[[]*gistitem.GistItem]
+	Find(ctx context.Context, userID string, offset, limit int, fields values.Projection) mo.Result[[]*gistitem.GistItem]
 	Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem]
 	Delete(ctx context.Context, userID string, itemID string) mo.Result[bool]
 }
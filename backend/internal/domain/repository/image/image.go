@@ -0,0 +1,24 @@
+package image
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/domain/model/image"
+	"github.com/samber/mo"
+)
+
+// ImageRepository keeps the metadata of uploaded images. Images are looked up by ID regardless of
+// their owner, so that the viewers of a public or shared diagram can load them.
+type ImageRepository interface {
+	FindByID(ctx context.Context, imageID string) mo.Result[*image.Image]
+	// FindByUserID returns the images the user has uploaded, oldest first.
+	FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image]
+	// Usage returns the total size in bytes of the images the user has uploaded.
+	Usage(ctx context.Context, userID string) mo.Result[int64]
+	Save(ctx context.Context, img *image.Image) mo.Result[bool]
+	// SaveWithinQuota saves img unless the images of its user would take more than quota bytes with it, and
+	// returns false then. It must run in a write transaction, in which the saves of a user are serialized so
+	// that concurrent uploads cannot exceed the quota together.
+	SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool]
+	Delete(ctx context.Context, userID string, imageID string) mo.Result[bool]
+}
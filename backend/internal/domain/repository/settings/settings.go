@@ -11,4 +11,11 @@ import (
 type SettingsRepository interface {
 	Find(ctx context.Context, userID string, diagram values.Diagram) mo.Result[*settings.Settings]
 	Save(ctx context.Context, userID string, diagram values.Diagram, settings *settings.Settings) mo.Result[*settings.Settings]
+	Delete(ctx context.Context, userID string, diagram values.Diagram) mo.Result[bool]
+}
+
+// SettingsCache is implemented by repositories that keep settings in memory.
+type SettingsCache interface {
+	// Peek returns the settings kept in memory, without reading the database or needing a transaction.
+	Peek(userID string, diagram values.Diagram) mo.Option[*settings.Settings]
 }
@@ -4,17 +4,34 @@ import (
 	"context"
 
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	shareModel "github.com/harehare/textusm/internal/domain/model/share"
 	"github.com/samber/mo"
 )
 
+// ShareValue holds the shared item. Exactly one of DiagramItem and GistItem is set.
 type ShareValue struct {
 	DiagramItem *diagramitem.DiagramItem
+	GistItem    *gistitem.GistItem
 	ShareInfo   *shareModel.Share
+	// UserID is the owner of the shared item. It is empty for Firestore shares saved before it was recorded.
+	UserID string
+}
+
+func (v ShareValue) ItemID() string {
+	if v.GistItem != nil {
+		return v.GistItem.ID()
+	}
+	return v.DiagramItem.ID()
 }
 
 type ShareRepository interface {
 	Find(ctx context.Context, hashKey string) mo.Result[ShareValue]
+	FindByCode(ctx context.Context, code string) mo.Result[ShareValue]
 	Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *shareModel.Share) mo.Result[bool]
+	SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *shareModel.Share) mo.Result[bool]
 	Delete(ctx context.Context, userID, hashKey string) mo.Result[bool]
+	// ConsumeView atomically decrements the remaining views of the share and returns the views left.
+	// It fails with NotFound when no views are left.
+	ConsumeView(ctx context.Context, hashKey string) mo.Result[int]
 }
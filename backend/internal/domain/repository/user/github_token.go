@@ -0,0 +1,14 @@
+package user
+
+import (
+	"context"
+
+	u "github.com/harehare/textusm/internal/domain/model/user"
+	"github.com/samber/mo"
+)
+
+type GithubTokenRepository interface {
+	Find(ctx context.Context, uid string) mo.Result[*u.GithubToken]
+	Save(ctx context.Context, uid string, token *u.GithubToken) mo.Result[bool]
+	Delete(ctx context.Context, uid string) mo.Result[bool]
+}
@@ -11,4 +11,6 @@ type UserRepository interface {
 	Find(ctx context.Context, uid string) mo.Result[*u.User]
 	RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error
 	RevokeToken(ctx context.Context) error
+	// Delete removes the sign-in account of the user. It succeeds when the account does not exist.
+	Delete(ctx context.Context, uid string) error
 }
@@ -0,0 +1,80 @@
+package account
+
+import (
+	"time"
+
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	v "github.com/harehare/textusm/internal/domain/values"
+)
+
+// ArchiveVersion is the version of the manifest written by Export. Import rejects newer versions.
+// Version 2 adds the items in the trash, which older versions would restore as regular items.
+const ArchiveVersion = 2
+
+const (
+	manifestName = "manifest.json"
+	// maxEntrySize bounds a single file read from an archive, so a crafted archive cannot exhaust memory.
+	maxEntrySize = 16 * 1024 * 1024
+)
+
+// Manifest describes the content of an account archive.
+// The text and thumbnail of items are stored as separate files named by Text and Thumbnail.
+type Manifest struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	Items      []ItemEntry     `json:"items"`
+	Gists      []GistEntry     `json:"gists"`
+	Settings   []SettingsEntry `json:"settings"`
+	Shares     []ShareEntry    `json:"shares"`
+}
+
+type ItemEntry struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Diagram    v.Diagram `json:"diagram"`
+	IsPublic   bool      `json:"isPublic"`
+	IsBookmark bool      `json:"isBookmark"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Text       string    `json:"text"`
+	Thumbnail  string    `json:"thumbnail,omitempty"`
+	// DeletedAt is when the item was moved to the trash. It is not set on the other items.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// GistEntry refers to a gist. Its content stays on GitHub.
+type GistEntry struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Title      string    `json:"title"`
+	Diagram    v.Diagram `json:"diagram"`
+	IsBookmark bool      `json:"isBookmark"`
+	Revision   string    `json:"revision,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type SettingsEntry struct {
+	Diagram  v.Diagram         `json:"diagram"`
+	Settings settings.Settings `json:"settings"`
+}
+
+// ShareEntry is an active share condition. The password is kept as its bcrypt hash.
+type ShareEntry struct {
+	ItemID         string     `json:"itemID"`
+	Location       v.Location `json:"location"`
+	Code           string     `json:"code"`
+	ExpireTime     int64      `json:"expireTime"`
+	PasswordHash   string     `json:"passwordHash,omitempty"`
+	AllowIPList    []string   `json:"allowIPList"`
+	AllowEmailList []string   `json:"allowEmailList"`
+	RemainingViews *int       `json:"remainingViews"`
+}
+
+// ImportResult counts the records restored from an archive.
+type ImportResult struct {
+	Items    int `json:"items"`
+	Gists    int `json:"gists"`
+	Settings int `json:"settings"`
+	Shares   int `json:"shares"`
+}
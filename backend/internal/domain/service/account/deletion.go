@@ -0,0 +1,433 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	accountRepo "github.com/harehare/textusm/internal/domain/repository/account"
+	auditRepo "github.com/harehare/textusm/internal/domain/repository/audit"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// GracePeriod is how long a requested account deletion can be cancelled before the data is purged.
+type GracePeriod time.Duration
+
+// PurgeInterval is how often due deletions are purged. Deletions are not purged by this process when it is zero.
+type PurgeInterval time.Duration
+
+const (
+	// purgeLease is how long a worker holds a deletion before another worker may retry it.
+	purgeLease     = 30 * time.Minute
+	purgeBatchSize = 10
+)
+
+// PurgeResult counts the records removed when an account was purged.
+type PurgeResult struct {
+	Items    int `json:"items"`
+	Gists    int `json:"gists"`
+	Settings int `json:"settings"`
+	Shares   int `json:"shares"`
+	Images   int `json:"images"`
+}
+
+// DeletionService deletes accounts. A deletion is purged from every store once its grace period ends,
+// and a receipt signed with the share keyring is kept so the user can prove the deletion afterwards.
+type DeletionService struct {
+	account       *Service
+	imageRepo     imageRepo.ImageRepository
+	store         blobRepo.BlobStore
+	tokenRepo     userRepo.GithubTokenRepository
+	userRepo      userRepo.UserRepository
+	deletionRepo  accountRepo.DeletionRepository
+	auditRepo     auditRepo.AuditLogRepository
+	keyring       *itemService.Keyring
+	gracePeriod   GracePeriod
+	purgeInterval PurgeInterval
+	now           func() time.Time
+}
+
+func NewDeletionService(a *Service, i imageRepo.ImageRepository, store blobRepo.BlobStore, t userRepo.GithubTokenRepository, u userRepo.UserRepository, d accountRepo.DeletionRepository, au auditRepo.AuditLogRepository, keyring *itemService.Keyring, gracePeriod GracePeriod, purgeInterval PurgeInterval) *DeletionService {
+	return &DeletionService{
+		account:       a,
+		imageRepo:     i,
+		store:         store,
+		tokenRepo:     t,
+		userRepo:      u,
+		deletionRepo:  d,
+		auditRepo:     au,
+		keyring:       keyring,
+		gracePeriod:   gracePeriod,
+		purgeInterval: purgeInterval,
+		now:           time.Now,
+	}
+}
+
+// Request schedules the deletion of the account of the signed-in user. An active deletion is returned as is.
+func (s *DeletionService) Request(ctx context.Context) mo.Result[*account.Deletion] {
+	if err := isAuthenticated(ctx); err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	userID := values.GetUID(ctx).MustGet()
+	active := s.deletionRepo.FindActive(ctx, userID)
+
+	if active.IsOk() || e.GetCode(active.Error()) != e.NotFound {
+		return active
+	}
+
+	deletion := account.NewDeletion(userID, s.now(), time.Duration(s.gracePeriod))
+
+	if err := s.deletionRepo.Create(ctx, deletion).Error(); err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	s.audit(ctx, deletion, audit.AccountDeletionRequested, "scheduled at "+deletion.ScheduledAt.UTC().Format(time.RFC3339))
+	return mo.Ok(deletion)
+}
+
+// Find returns the active deletion of the signed-in user.
+func (s *DeletionService) Find(ctx context.Context) mo.Result[*account.Deletion] {
+	if err := isAuthenticated(ctx); err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return s.deletionRepo.FindActive(ctx, values.GetUID(ctx).MustGet())
+}
+
+// Cancel cancels the deletion of the signed-in user while it is still in its grace period.
+func (s *DeletionService) Cancel(ctx context.Context) mo.Result[*account.Deletion] {
+	active := s.Find(ctx)
+
+	if active.IsError() {
+		return active
+	}
+
+	deletion := active.MustGet()
+	cancelled := s.deletionRepo.Cancel(ctx, deletion.ID)
+
+	if cancelled.IsError() {
+		return mo.Err[*account.Deletion](cancelled.Error())
+	}
+
+	if !cancelled.MustGet() {
+		return mo.Err[*account.Deletion](e.ConflictError(e.ErrAccountDeletionStarted))
+	}
+
+	deletion.Status = account.DeletionCancelled
+	s.audit(ctx, deletion, audit.AccountDeletionCancelled, "")
+	return mo.Ok(deletion)
+}
+
+// Receipt returns the signed receipt of a completed deletion. It needs no sign-in, since the account no longer exists.
+func (s *DeletionService) Receipt(ctx context.Context, deletionID string) mo.Result[string] {
+	deletion := s.deletionRepo.FindByID(ctx, deletionID)
+
+	if deletion.IsError() {
+		return mo.Err[string](deletion.Error())
+	}
+
+	if deletion.MustGet().Status != account.DeletionCompleted {
+		return mo.Err[string](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	return mo.Ok(deletion.MustGet().Receipt)
+}
+
+// Run purges due deletions every purge interval until ctx is done.
+func (s *DeletionService) Run(ctx context.Context) {
+	if s.purgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.purgeInterval))
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDue(ctx); err != nil {
+			slog.Error("failed to purge deleted accounts", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue purges the deletions whose grace period has ended and returns how many were completed.
+// A deletion that fails is recorded in the audit log and retried once its lease expires.
+func (s *DeletionService) PurgeDue(ctx context.Context) (int, error) {
+	now := s.now()
+	due := s.deletionRepo.FindDue(ctx, now, purgeBatchSize)
+
+	if due.IsError() {
+		return 0, due.Error()
+	}
+
+	completed := 0
+
+	for _, deletion := range due.MustGet() {
+		claimed := s.deletionRepo.Claim(ctx, deletion.ID, now, now.Add(purgeLease))
+
+		if claimed.IsError() {
+			return completed, claimed.Error()
+		}
+
+		if !claimed.MustGet() {
+			continue
+		}
+
+		if err := s.complete(ctx, deletion); err != nil {
+			slog.Error("failed to purge account", "deletionID", deletion.ID, "error", err)
+			s.audit(ctx, deletion, audit.AccountDeletionFailed, err.Error())
+			continue
+		}
+
+		completed++
+	}
+
+	return completed, nil
+}
+
+func (s *DeletionService) complete(ctx context.Context, deletion *account.Deletion) error {
+	result, err := s.purge(values.WithUID(ctx, deletion.UserID), deletion.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	completedAt := s.now()
+	receipt := s.keyring.Sign(jwt.MapClaims{
+		"jti":          deletion.ID,
+		"sub":          deletion.UserID,
+		"iat":          completedAt.Unix(),
+		"requested_at": deletion.RequestedAt.Unix(),
+		"completed_at": completedAt.Unix(),
+		"purged":       result,
+	})
+
+	if receipt.IsError() {
+		return fmt.Errorf("sign deletion receipt: %w", receipt.Error())
+	}
+
+	if err := s.deletionRepo.Complete(ctx, deletion.ID, completedAt, receipt.MustGet()).Error(); err != nil {
+		return err
+	}
+
+	detail, err := json.Marshal(result)
+
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, deletion, audit.AccountDeletionCompleted, string(detail))
+	return nil
+}
+
+// purge removes every record of the user. Each step can be repeated, so an interrupted purge is simply run again.
+func (s *DeletionService) purge(ctx context.Context, userID string) (*PurgeResult, error) {
+	result := PurgeResult{}
+	itemIDs, gistIDs, err := s.account.listIDs(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, itemID := range itemIDs {
+		var shared bool
+
+		err := s.account.transaction.Do(ctx, func(ctx context.Context) error {
+			var err error
+
+			if shared, err = s.deleteShare(ctx, userID, itemID); err != nil {
+				return err
+			}
+
+			if err := s.account.repo.Delete(ctx, userID, itemID, false).Error(); err != nil {
+				return err
+			}
+
+			return s.account.repo.Delete(ctx, userID, itemID, true).Error()
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("delete item %s: %w", itemID, err)
+		}
+
+		result.Items++
+		result.Shares += countOf(shared)
+	}
+
+	// Trashed items are not listed with the others, and their shares were removed when they were trashed.
+	// The trash is read as the user, like every other step, and the first page again until it is empty.
+	purgedTrash := map[string]bool{}
+
+	for {
+		var trash []*diagramitem.DiagramItem
+
+		err := s.account.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
+			ret := s.account.repo.FindTrash(ctx, userID, 0, purgeBatchSize, v.MetadataOnly)
+
+			if ret.IsOk() {
+				trash = ret.MustGet()
+			}
+
+			return ret.Error()
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("list trash: %w", err)
+		}
+
+		if len(trash) == 0 {
+			break
+		}
+
+		for _, item := range trash {
+			// An item still listed after it was deleted would make this loop run forever.
+			if purgedTrash[item.ID()] {
+				return nil, fmt.Errorf("trashed item %s was not deleted", item.ID())
+			}
+
+			purgedTrash[item.ID()] = true
+
+			err := s.account.transaction.Do(ctx, func(ctx context.Context) error {
+				return s.account.repo.Delete(ctx, userID, item.ID(), false).Error()
+			})
+
+			if err != nil {
+				return nil, fmt.Errorf("delete trashed item %s: %w", item.ID(), err)
+			}
+
+			result.Items++
+		}
+	}
+
+	for _, gistID := range gistIDs {
+		var shared bool
+
+		err := s.account.transaction.Do(ctx, func(ctx context.Context) error {
+			var err error
+
+			if shared, err = s.deleteShare(ctx, userID, gistID); err != nil {
+				return err
+			}
+
+			return s.account.gistRepo.Delete(ctx, userID, gistID).Error()
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("delete gist %s: %w", gistID, err)
+		}
+
+		result.Gists++
+		result.Shares += countOf(shared)
+	}
+
+	err = s.account.transaction.Do(ctx, func(ctx context.Context) error {
+		result.Settings = 0
+
+		for _, diagram := range v.AllDiagram {
+			ret := s.account.settingsRepo.Find(ctx, userID, diagram)
+
+			if ret.IsError() && e.GetCode(ret.Error()) == e.NotFound {
+				continue
+			}
+
+			if ret.IsError() {
+				return ret.Error()
+			}
+
+			if err := s.account.settingsRepo.Delete(ctx, userID, diagram).Error(); err != nil {
+				return err
+			}
+
+			result.Settings++
+		}
+
+		return s.tokenRepo.Delete(ctx, userID).Error()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("delete settings: %w", err)
+	}
+
+	images := s.imageRepo.FindByUserID(ctx, userID)
+
+	if images.IsError() {
+		return nil, images.Error()
+	}
+
+	for _, img := range images.MustGet() {
+		// The picture goes first, so a failure never leaves it without the metadata pointing at it.
+		if s.store != nil {
+			if err := s.store.Delete(ctx, img.BlobKey()).Error(); err != nil {
+				return nil, fmt.Errorf("delete image %s: %w", img.ID, err)
+			}
+		}
+
+		if err := s.imageRepo.Delete(ctx, userID, img.ID).Error(); err != nil {
+			return nil, fmt.Errorf("delete image %s: %w", img.ID, err)
+		}
+
+		result.Images++
+	}
+
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		return nil, fmt.Errorf("delete user: %w", err)
+	}
+
+	return &result, nil
+}
+
+func countOf(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// deleteShare deletes the share condition of the item and reports whether there was one.
+func (s *DeletionService) deleteShare(ctx context.Context, userID, itemID string) (bool, error) {
+	hashKey := itemService.ShareID(string(s.account.shareEncryptKey), itemID)
+
+	if hashKey.IsError() {
+		return false, hashKey.Error()
+	}
+
+	share := s.account.shareRepo.Find(ctx, hashKey.MustGet())
+
+	if share.IsError() && e.GetCode(share.Error()) == e.NotFound {
+		return false, nil
+	}
+
+	if share.IsError() {
+		return false, share.Error()
+	}
+
+	return true, s.account.shareRepo.Delete(ctx, userID, hashKey.MustGet()).Error()
+}
+
+// audit records the event. A failure is only logged, since the deletion itself has already taken effect.
+func (s *DeletionService) audit(ctx context.Context, deletion *account.Deletion, event audit.Event, detail string) {
+	log := audit.New(deletion.UserID, event, deletion.ID, detail, s.now())
+
+	if err := s.auditRepo.Append(ctx, log).Error(); err != nil {
+		slog.Error("failed to write audit log", "event", event, "deletionID", deletion.ID, "error", err)
+	}
+}
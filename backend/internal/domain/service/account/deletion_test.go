@@ -0,0 +1,399 @@
+package account
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	userModel "github.com/harehare/textusm/internal/domain/model/user"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type deletionStore struct {
+	deletions map[string]*account.Deletion
+}
+
+func (s *deletionStore) FindByID(ctx context.Context, id string) mo.Result[*account.Deletion] {
+	d, ok := s.deletions[id]
+
+	if !ok {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	copied := *d
+	return mo.Ok(&copied)
+}
+
+func (s *deletionStore) FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion] {
+	for id, d := range s.deletions {
+		if d.UserID == userID && d.Status.IsActive() {
+			return s.FindByID(ctx, id)
+		}
+	}
+
+	return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+}
+
+func (s *deletionStore) FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion] {
+	due := []*account.Deletion{}
+
+	for id, d := range s.deletions {
+		if d.Status == account.DeletionPending && !d.ScheduledAt.After(now) {
+			due = append(due, s.FindByID(ctx, id).MustGet())
+		}
+	}
+
+	return mo.Ok(due)
+}
+
+func (s *deletionStore) Create(ctx context.Context, d *account.Deletion) mo.Result[bool] {
+	copied := *d
+	s.deletions[d.ID] = &copied
+	return mo.Ok(true)
+}
+
+func (s *deletionStore) Claim(ctx context.Context, id string, now, leaseUntil time.Time) mo.Result[bool] {
+	d := s.deletions[id]
+
+	if d.Status != account.DeletionPending && (d.Status != account.DeletionPurging || d.LeaseUntil.After(now)) {
+		return mo.Ok(false)
+	}
+
+	d.Status = account.DeletionPurging
+	d.LeaseUntil = leaseUntil
+	return mo.Ok(true)
+}
+
+func (s *deletionStore) Cancel(ctx context.Context, id string) mo.Result[bool] {
+	d := s.deletions[id]
+
+	if d.Status != account.DeletionPending {
+		return mo.Ok(false)
+	}
+
+	d.Status = account.DeletionCancelled
+	return mo.Ok(true)
+}
+
+func (s *deletionStore) Complete(ctx context.Context, id string, completedAt time.Time, receipt string) mo.Result[bool] {
+	d := s.deletions[id]
+	d.Status = account.DeletionCompleted
+	d.CompletedAt = completedAt
+	d.Receipt = receipt
+	return mo.Ok(true)
+}
+
+type auditStore struct {
+	logs []*audit.Log
+}
+
+func (s *auditStore) Append(ctx context.Context, log *audit.Log) mo.Result[bool] {
+	s.logs = append(s.logs, log)
+	return mo.Ok(true)
+}
+
+type imageStore struct {
+	images map[string]*image.Image
+}
+
+func (s *imageStore) FindByID(ctx context.Context, imageID string) mo.Result[*image.Image] {
+	return mo.Err[*image.Image](e.NotFoundError(e.ErrImageNotFound))
+}
+
+func (s *imageStore) FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image] {
+	images := []*image.Image{}
+
+	for _, img := range s.images {
+		if img.UserID == userID {
+			images = append(images, img)
+		}
+	}
+
+	return mo.Ok(images)
+}
+
+func (s *imageStore) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	return mo.Ok(int64(0))
+}
+
+func (s *imageStore) Save(ctx context.Context, img *image.Image) mo.Result[bool] {
+	s.images[img.ID] = img
+	return mo.Ok(true)
+}
+
+func (s *imageStore) SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool] {
+	return s.Save(ctx, img)
+}
+
+func (s *imageStore) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	delete(s.images, imageID)
+	return mo.Ok(true)
+}
+
+type blobStore map[string]*blobRepo.Blob
+
+func (s blobStore) Get(ctx context.Context, key string) mo.Result[*blobRepo.Blob] {
+	return mo.Err[*blobRepo.Blob](e.NotFoundError(e.ErrNotAuthorization))
+}
+
+func (s blobStore) Put(ctx context.Context, key string, blob *blobRepo.Blob) mo.Result[bool] {
+	s[key] = blob
+	return mo.Ok(true)
+}
+
+func (s blobStore) Delete(ctx context.Context, key string) mo.Result[bool] {
+	delete(s, key)
+	return mo.Ok(true)
+}
+
+type tokenStore map[string]*userModel.GithubToken
+
+func (s tokenStore) Find(ctx context.Context, uid string) mo.Result[*userModel.GithubToken] {
+	return mo.Err[*userModel.GithubToken](e.NotFoundError(e.ErrNotAuthorization))
+}
+
+func (s tokenStore) Save(ctx context.Context, uid string, token *userModel.GithubToken) mo.Result[bool] {
+	s[uid] = token
+	return mo.Ok(true)
+}
+
+func (s tokenStore) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	delete(s, uid)
+	return mo.Ok(true)
+}
+
+type userStore struct {
+	deleted []string
+}
+
+func (s *userStore) Find(ctx context.Context, uid string) mo.Result[*userModel.User] {
+	return mo.Err[*userModel.User](e.NotFoundError(e.ErrNotAuthorization))
+}
+
+func (s *userStore) RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
+	return nil
+}
+
+func (s *userStore) RevokeToken(ctx context.Context) error {
+	return nil
+}
+
+func (s *userStore) Delete(ctx context.Context, uid string) error {
+	s.deleted = append(s.deleted, uid)
+	return nil
+}
+
+type deletionFixture struct {
+	svc       *DeletionService
+	deletions *deletionStore
+	audits    *auditStore
+	images    *imageStore
+	blobs     blobStore
+	tokens    tokenStore
+	users     *userStore
+	now       time.Time
+}
+
+func newDeletionFixture(t *testing.T, s *store, keyring *itemService.Keyring) *deletionFixture {
+	t.Helper()
+	f := &deletionFixture{
+		deletions: &deletionStore{deletions: map[string]*account.Deletion{}},
+		audits:    &auditStore{},
+		images:    &imageStore{images: map[string]*image.Image{}},
+		blobs:     blobStore{},
+		tokens:    tokenStore{},
+		users:     &userStore{},
+		now:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	f.svc = NewDeletionService(newTestService(s, keyring), f.images, f.blobs, f.tokens, f.users, f.deletions, f.audits, keyring, GracePeriod(24*time.Hour), 0)
+	f.svc.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *deletionFixture) events() []audit.Event {
+	events := make([]audit.Event, len(f.audits.logs))
+
+	for i, log := range f.audits.logs {
+		events[i] = log.Event
+	}
+
+	return events
+}
+
+func TestDeletionPurgesAccountAfterGracePeriod(t *testing.T) {
+	keyring := newKeyring(t)
+	s := newStore()
+	f := newDeletionFixture(t, s, keyring)
+	item, _ := seedAccount(t, s, f.svc.account, "user1")
+	ctx := values.WithUID(context.Background(), "user1")
+	itemStore{s}.Save(ctx, "user2", item, false)
+	trashed := diagramitem.New().WithID("trashed").Build().MustGet().Trash(f.now)
+	s.trash["user1"] = map[string]*diagramitem.DiagramItem{trashed.ID(): trashed}
+
+	img := image.New("user1", "item", v.LocationSystem, "image/png", []byte("png"), f.now)
+	f.images.Save(ctx, img)
+	f.blobs.Put(ctx, img.BlobKey(), &blobRepo.Blob{ContentType: "image/png", Data: []byte("png")})
+	f.tokens.Save(ctx, "user1", &userModel.GithubToken{})
+
+	requested := f.svc.Request(ctx)
+
+	if requested.IsError() {
+		t.Fatalf("Request() error = %v", requested.Error())
+	}
+
+	if again := f.svc.Request(ctx); again.IsError() || again.MustGet().ID != requested.MustGet().ID {
+		t.Errorf("Request() twice should return the active deletion, got %v", again.OrEmpty())
+	}
+
+	if n, err := f.svc.PurgeDue(context.Background()); n != 0 || err != nil {
+		t.Fatalf("PurgeDue() within the grace period = %d, %v", n, err)
+	}
+
+	if len(s.items["user1"]) != 1 {
+		t.Fatal("items should be kept within the grace period")
+	}
+
+	f.now = f.now.Add(25 * time.Hour)
+
+	if n, err := f.svc.PurgeDue(context.Background()); n != 1 || err != nil {
+		t.Fatalf("PurgeDue() after the grace period = %d, %v", n, err)
+	}
+
+	if len(s.items["user1"]) != 0 || len(s.trash["user1"]) != 0 || len(s.gists["user1"]) != 0 || len(s.settings["user1"]) != 0 || len(s.shares) != 0 {
+		t.Errorf("account was not purged: %d items, %d trashed, %d gists, %d settings, %d shares", len(s.items["user1"]), len(s.trash["user1"]), len(s.gists["user1"]), len(s.settings["user1"]), len(s.shares))
+	}
+
+	if len(s.items["user2"]) != 1 {
+		t.Error("other accounts should be kept")
+	}
+
+	if len(f.images.images) != 0 || len(f.blobs) != 0 || len(f.tokens) != 0 || len(f.users.deleted) != 1 {
+		t.Errorf("images, tokens or the sign-in account were not purged")
+	}
+
+	receipt := f.svc.Receipt(context.Background(), requested.MustGet().ID)
+
+	if receipt.IsError() {
+		t.Fatalf("Receipt() error = %v", receipt.Error())
+	}
+
+	token := keyring.Verify(receipt.MustGet())
+
+	if token.IsError() {
+		t.Fatalf("receipt does not verify: %v", token.Error())
+	}
+
+	claims := token.MustGet().Claims.(jwt.MapClaims)
+	purged, _ := claims["purged"].(map[string]interface{})
+
+	if claims["sub"] != "user1" || claims["jti"] != requested.MustGet().ID || purged["items"] != 2.0 || purged["images"] != 1.0 {
+		t.Errorf("unexpected receipt claims %v", claims)
+	}
+
+	if n, _ := f.svc.PurgeDue(context.Background()); n != 0 {
+		t.Errorf("a completed deletion should not be purged again")
+	}
+
+	events := f.events()
+
+	if len(events) != 2 || events[0] != audit.AccountDeletionRequested || events[1] != audit.AccountDeletionCompleted {
+		t.Errorf("unexpected audit events %v", events)
+	}
+}
+
+// stuckTrashStore never removes items from the trash.
+type stuckTrashStore struct{ itemStore }
+
+func (s stuckTrashStore) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	return mo.Ok(true)
+}
+
+func TestDeletionFailsWhenTrashIsNotDeleted(t *testing.T) {
+	s := newStore()
+	f := newDeletionFixture(t, s, newKeyring(t))
+	f.svc.account.repo = stuckTrashStore{itemStore{s}}
+	ctx := values.WithUID(context.Background(), "user1")
+	trashed := diagramitem.New().WithID("trashed").Build().MustGet().Trash(f.now)
+	s.trash["user1"] = map[string]*diagramitem.DiagramItem{trashed.ID(): trashed}
+
+	f.svc.Request(ctx).MustGet()
+	f.now = f.now.Add(25 * time.Hour)
+
+	if n, err := f.svc.PurgeDue(context.Background()); n != 0 || err != nil {
+		t.Fatalf("PurgeDue() = %d, %v", n, err)
+	}
+
+	if events := f.events(); len(events) != 2 || events[1] != audit.AccountDeletionFailed {
+		t.Errorf("unexpected audit events %v", events)
+	}
+}
+
+func TestDeletionCanBeCancelledWithinGracePeriod(t *testing.T) {
+	s := newStore()
+	f := newDeletionFixture(t, s, newKeyring(t))
+	seedAccount(t, s, f.svc.account, "user1")
+	ctx := values.WithUID(context.Background(), "user1")
+
+	requested := f.svc.Request(ctx).MustGet()
+	cancelled := f.svc.Cancel(ctx)
+
+	if cancelled.IsError() || cancelled.MustGet().Status != account.DeletionCancelled {
+		t.Fatalf("Cancel() = %v, %v", cancelled.OrEmpty(), cancelled.Error())
+	}
+
+	if ret := f.svc.Find(ctx); e.GetCode(ret.Error()) != e.NotFound {
+		t.Errorf("Find() after Cancel() should not find a deletion, got %v", ret.OrEmpty())
+	}
+
+	f.now = f.now.Add(25 * time.Hour)
+
+	if n, _ := f.svc.PurgeDue(context.Background()); n != 0 || len(s.items["user1"]) != 1 {
+		t.Error("a cancelled deletion should not be purged")
+	}
+
+	if ret := f.svc.Receipt(context.Background(), requested.ID); e.GetCode(ret.Error()) != e.NotFound {
+		t.Errorf("Receipt() of a cancelled deletion should not be found, got %v", ret.Error())
+	}
+
+	if events := f.events(); len(events) != 2 || events[1] != audit.AccountDeletionCancelled {
+		t.Errorf("unexpected audit events %v", events)
+	}
+}
+
+func TestDeletionCannotBeCancelledOncePurging(t *testing.T) {
+	s := newStore()
+	f := newDeletionFixture(t, s, newKeyring(t))
+	ctx := values.WithUID(context.Background(), "user1")
+
+	requested := f.svc.Request(ctx).MustGet()
+
+	if !f.deletions.Claim(ctx, requested.ID, f.now, f.now.Add(purgeLease)).MustGet() {
+		t.Fatal("Claim() should succeed for a pending deletion")
+	}
+
+	if f.deletions.Claim(ctx, requested.ID, f.now, f.now.Add(purgeLease)).MustGet() {
+		t.Error("Claim() should fail while another worker holds the lease")
+	}
+
+	if ret := f.svc.Cancel(ctx); e.GetCode(ret.Error()) != e.Conflict {
+		t.Errorf("Cancel() while purging should conflict, got %v", ret.Error())
+	}
+}
+
+func TestDeletionRequiresSignIn(t *testing.T) {
+	f := newDeletionFixture(t, newStore(), nil)
+
+	if ret := f.svc.Request(context.Background()); e.GetCode(ret.Error()) != e.NoAuthorization {
+		t.Errorf("Request() without a user should fail, got %v", ret.Error())
+	}
+}
@@ -0,0 +1,647 @@
+package account
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	shareModel "github.com/harehare/textusm/internal/domain/model/share"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	gistRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// trashPageSize is how many items in the trash are read at once.
+const trashPageSize = 100
+
+// importNamespace derives the IDs of imported records that the importing user does not own yet.
+var importNamespace = uuid.MustParse("6c0c1f7e-2d43-4f0b-9a53-3d8f2f1b7a64")
+
+// Service exports all the data of the signed-in user into a zip archive and restores it from one.
+type Service struct {
+	catalog         catalogRepo.CatalogRepository
+	repo            itemRepo.ItemRepository
+	gistRepo        gistRepo.GistItemRepository
+	settingsRepo    settingsRepo.SettingsRepository
+	shareRepo       shareRepo.ShareRepository
+	itemService     *itemService.Service
+	transaction     db.Transaction
+	shareEncryptKey itemService.ShareEncryptKey
+}
+
+func NewService(c catalogRepo.CatalogRepository, r itemRepo.ItemRepository, g gistRepo.GistItemRepository, st settingsRepo.SettingsRepository, sh shareRepo.ShareRepository, is *itemService.Service, transaction db.Transaction, shareEncryptKey itemService.ShareEncryptKey) *Service {
+	return &Service{
+		catalog:         c,
+		repo:            r,
+		gistRepo:        g,
+		settingsRepo:    st,
+		shareRepo:       sh,
+		itemService:     is,
+		transaction:     transaction,
+		shareEncryptKey: shareEncryptKey,
+	}
+}
+
+func isAuthenticated(ctx context.Context) error {
+	if values.GetUID(ctx).IsAbsent() {
+		return e.NoAuthorizationError(e.ErrNotAuthorization)
+	}
+
+	return nil
+}
+
+// Export writes the archive to w while reading the items one by one, so the whole account is never held in memory.
+// The manifest is written last as it is only complete once every item was read.
+func (s *Service) Export(ctx context.Context, w io.Writer) error {
+	if err := isAuthenticated(ctx); err != nil {
+		return err
+	}
+
+	userID := values.GetUID(ctx).MustGet()
+
+	itemIDs, gistIDs, err := s.listIDs(ctx, userID)
+
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := Manifest{
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now(),
+		Items:      []ItemEntry{},
+		Gists:      []GistEntry{},
+		Settings:   []SettingsEntry{},
+		Shares:     []ShareEntry{},
+	}
+
+	exported := make(map[string]bool, len(itemIDs))
+
+	for _, itemID := range itemIDs {
+		entry, err := s.exportItem(ctx, zw, userID, itemID)
+
+		if err != nil {
+			return err
+		}
+
+		if entry.IsPresent() {
+			manifest.Items = append(manifest.Items, entry.MustGet())
+			exported[itemID] = true
+		}
+	}
+
+	trash, err := s.exportTrash(ctx, zw, userID, exported)
+
+	if err != nil {
+		return err
+	}
+
+	manifest.Items = append(manifest.Items, trash...)
+
+	err = s.transaction.Do(ctx, func(ctx context.Context) error {
+		for _, gistID := range gistIDs {
+			gist := s.gistRepo.FindByID(ctx, userID, gistID, v.MetadataOnly)
+
+			if gist.IsError() && e.GetCode(gist.Error()) == e.NotFound {
+				continue
+			}
+
+			if gist.IsError() {
+				return gist.Error()
+			}
+
+			g := gist.MustGet()
+			manifest.Gists = append(manifest.Gists, GistEntry{
+				ID:         g.ID(),
+				URL:        g.URL(),
+				Title:      g.Title(),
+				Diagram:    g.Diagram(),
+				IsBookmark: g.IsBookmark(),
+				Revision:   g.Revision(),
+				CreatedAt:  g.CreatedAt(),
+				UpdatedAt:  g.UpdatedAt(),
+			})
+		}
+
+		for _, diagram := range v.AllDiagram {
+			ret := s.settingsRepo.Find(ctx, userID, diagram)
+
+			if ret.IsError() && e.GetCode(ret.Error()) == e.NotFound {
+				continue
+			}
+
+			if ret.IsError() {
+				return ret.Error()
+			}
+
+			manifest.Settings = append(manifest.Settings, SettingsEntry{Diagram: diagram, Settings: *ret.MustGet()})
+		}
+
+		shares, err := s.activeShares(ctx, itemIDs, gistIDs)
+
+		if err != nil {
+			return err
+		}
+
+		manifest.Shares = shares
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	f, err := zw.Create(manifestName)
+
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// listIDs returns the IDs of the items and gist items owned by the user.
+func (s *Service) listIDs(ctx context.Context, userID string) (itemIDs, gistIDs []string, err error) {
+	err = s.transaction.Do(ctx, func(ctx context.Context) error {
+		items := s.catalog.ItemIDs(ctx, userID)
+
+		if items.IsError() {
+			return items.Error()
+		}
+
+		gists := s.catalog.GistIDs(ctx, userID)
+
+		if gists.IsError() {
+			return gists.Error()
+		}
+
+		itemIDs, gistIDs = items.MustGet(), gists.MustGet()
+		return nil
+	})
+
+	return itemIDs, gistIDs, err
+}
+
+func (s *Service) exportItem(ctx context.Context, zw *zip.Writer, userID, itemID string) (mo.Option[ItemEntry], error) {
+	var item *diagramitem.DiagramItem
+
+	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+		ret := s.repo.FindByID(ctx, userID, itemID, false, v.AllFields)
+
+		if ret.IsOk() {
+			item = ret.MustGet()
+		}
+
+		return ret.Error()
+	})
+
+	// The item was deleted after it was listed.
+	if e.GetCode(err) == e.NotFound {
+		return mo.None[ItemEntry](), nil
+	}
+
+	if err != nil {
+		return mo.None[ItemEntry](), err
+	}
+
+	entry, err := writeItem(zw, item)
+
+	if err != nil {
+		return mo.None[ItemEntry](), err
+	}
+
+	return mo.Some(entry), nil
+}
+
+// exportTrash writes the items in the trash a page at a time, leaving out the ones exported already.
+func (s *Service) exportTrash(ctx context.Context, zw *zip.Writer, userID string, exported map[string]bool) ([]ItemEntry, error) {
+	entries := []ItemEntry{}
+
+	for offset := 0; ; offset += trashPageSize {
+		var page []*diagramitem.DiagramItem
+
+		err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
+			ret := s.repo.FindTrash(ctx, userID, offset, trashPageSize, v.AllFields)
+
+			if ret.IsOk() {
+				page = ret.MustGet()
+			}
+
+			return ret.Error()
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page {
+			// An item trashed while paging shifts the others, so one may be read twice.
+			if exported[item.ID()] {
+				continue
+			}
+
+			entry, err := writeItem(zw, item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, entry)
+			exported[item.ID()] = true
+		}
+
+		if len(page) < trashPageSize {
+			return entries, nil
+		}
+	}
+}
+
+func writeItem(zw *zip.Writer, item *diagramitem.DiagramItem) (ItemEntry, error) {
+	entry := ItemEntry{
+		ID:         item.ID(),
+		Title:      item.Title(),
+		Diagram:    item.Diagram(),
+		IsPublic:   item.IsPublic(),
+		IsBookmark: item.IsBookmark(),
+		CreatedAt:  item.CreatedAt(),
+		UpdatedAt:  item.UpdatedAt(),
+		Text:       path.Join("items", item.ID()+".txt"),
+		DeletedAt:  item.DeletedAt(),
+	}
+
+	if err := writeEntry(zw, entry.Text, item.Text()); err != nil {
+		return ItemEntry{}, err
+	}
+
+	if thumbnail := item.Thumbnail(); thumbnail != nil && *thumbnail != "" {
+		entry.Thumbnail = path.Join("thumbnails", item.ID())
+
+		if err := writeEntry(zw, entry.Thumbnail, *thumbnail); err != nil {
+			return ItemEntry{}, err
+		}
+	}
+
+	return entry, nil
+}
+
+// activeShares returns the share conditions of the items which can still be opened.
+func (s *Service) activeShares(ctx context.Context, itemIDs, gistIDs []string) ([]ShareEntry, error) {
+	shares := []ShareEntry{}
+	now := time.Now().UnixMilli()
+
+	for _, id := range append(append([]string{}, itemIDs...), gistIDs...) {
+		hashKey := itemService.ShareID(string(s.shareEncryptKey), id)
+
+		if hashKey.IsError() {
+			return nil, hashKey.Error()
+		}
+
+		ret := s.shareRepo.Find(ctx, hashKey.MustGet())
+
+		if ret.IsError() && e.GetCode(ret.Error()) == e.NotFound {
+			continue
+		}
+
+		if ret.IsError() {
+			return nil, ret.Error()
+		}
+
+		info := ret.MustGet().ShareInfo
+
+		if info.ExpireTime <= now || info.ViewsExhausted() {
+			continue
+		}
+
+		location := v.LocationSystem
+
+		if ret.MustGet().GistItem != nil {
+			location = v.LocationGist
+		}
+
+		shares = append(shares, ShareEntry{
+			ItemID:         ret.MustGet().ItemID(),
+			Location:       location,
+			Code:           info.Code,
+			ExpireTime:     info.ExpireTime,
+			PasswordHash:   info.Password,
+			AllowIPList:    info.AllowIPList,
+			AllowEmailList: info.AllowEmailList,
+			RemainingViews: info.RemainingViews.ToPointer(),
+		})
+	}
+
+	return shares, nil
+}
+
+func writeEntry(zw *zip.Writer, name, content string) error {
+	f, err := zw.Create(name)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+// Import restores an archive written by Export into the account of the signed-in user.
+// Records the user already owns keep their IDs and are replaced. Other records get IDs derived from the user and
+// their ID in the archive, since public items and share conditions are keyed by ID across all users and an archive
+// must not reach the records of others. Either way, importing the same archive twice creates no duplicates.
+// Share conditions which have expired since the export are skipped.
+func (s *Service) Import(ctx context.Context, r io.ReaderAt, size int64) mo.Result[*ImportResult] {
+	if err := isAuthenticated(ctx); err != nil {
+		return mo.Err[*ImportResult](err)
+	}
+
+	userID := values.GetUID(ctx).MustGet()
+	zr, err := zip.NewReader(r, size)
+
+	if err != nil {
+		return mo.Err[*ImportResult](e.InvalidParameterError(errors.Join(e.ErrInvalidArchive, err)))
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var manifest Manifest
+
+	if err := readJSON(files, manifestName, &manifest); err != nil {
+		return mo.Err[*ImportResult](err)
+	}
+
+	if manifest.Version < 1 || manifest.Version > ArchiveVersion {
+		return mo.Err[*ImportResult](e.InvalidParameterError(e.ErrInvalidArchive))
+	}
+
+	ownedItems, ownedGists, err := s.ownedIDs(ctx, userID)
+
+	if err != nil {
+		return mo.Err[*ImportResult](err)
+	}
+
+	result := &ImportResult{}
+
+	for _, entry := range manifest.Items {
+		entry.ID = importedID(userID, ownedItems, entry.ID)
+		item, err := itemFromEntry(files, entry)
+
+		if err != nil {
+			return mo.Err[*ImportResult](err)
+		}
+
+		if ret := s.itemService.Save(ctx, item, false); ret.IsError() {
+			return mo.Err[*ImportResult](ret.Error())
+		}
+
+		if entry.DeletedAt != nil {
+			// Items in the trash are never public. An item already in the trash keeps the time it was trashed.
+			err := s.transaction.Do(ctx, func(ctx context.Context) error {
+				return s.repo.Trash(ctx, userID, item.ID(), *entry.DeletedAt).Error()
+			})
+
+			if err != nil {
+				return mo.Err[*ImportResult](err)
+			}
+		} else if entry.IsPublic {
+			if ret := s.itemService.Save(ctx, item, true); ret.IsError() {
+				return mo.Err[*ImportResult](ret.Error())
+			}
+		}
+
+		result.Items++
+	}
+
+	err = s.transaction.Do(ctx, func(ctx context.Context) error {
+		for _, entry := range manifest.Gists {
+			gist := gistitem.New().
+				WithID(importedID(userID, ownedGists, entry.ID)).
+				WithURL(entry.URL).
+				WithTitle(entry.Title).
+				WithThumbnail(mo.None[string]()).
+				WithDiagram(entry.Diagram).
+				WithIsBookmark(entry.IsBookmark).
+				WithRevision(entry.Revision).
+				WithCreatedAt(entry.CreatedAt).
+				WithUpdatedAt(entry.UpdatedAt).
+				Build()
+
+			if gist.IsError() {
+				return gist.Error()
+			}
+
+			if ret := s.gistRepo.Save(ctx, userID, gist.MustGet()); ret.IsError() {
+				return ret.Error()
+			}
+
+			result.Gists++
+		}
+
+		for _, entry := range manifest.Settings {
+			settings := entry.Settings
+
+			if ret := s.settingsRepo.Save(ctx, userID, entry.Diagram, &settings); ret.IsError() {
+				return ret.Error()
+			}
+
+			result.Settings++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return mo.Err[*ImportResult](err)
+	}
+
+	now := time.Now().UnixMilli()
+
+	for _, entry := range manifest.Shares {
+		if entry.ExpireTime <= now {
+			continue
+		}
+
+		owned := ownedItems
+
+		if entry.Location == v.LocationGist {
+			owned = ownedGists
+		}
+
+		ret := s.itemService.RestoreShare(ctx, importedID(userID, owned, entry.ItemID), entry.Location, &shareModel.Share{
+			Code:           entry.Code,
+			Password:       entry.PasswordHash,
+			AllowIPList:    entry.AllowIPList,
+			AllowEmailList: entry.AllowEmailList,
+			ExpireTime:     entry.ExpireTime,
+			RemainingViews: mo.PointerToOption(entry.RemainingViews),
+		})
+
+		if ret.IsError() {
+			return mo.Err[*ImportResult](ret.Error())
+		}
+
+		result.Shares++
+	}
+
+	return mo.Ok(result)
+}
+
+// ownedIDs returns the IDs of the items, including the ones in the trash, and of the gist items of the user.
+func (s *Service) ownedIDs(ctx context.Context, userID string) (items, gists map[string]bool, err error) {
+	itemIDs, gistIDs, err := s.listIDs(ctx, userID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items = make(map[string]bool, len(itemIDs))
+	gists = make(map[string]bool, len(gistIDs))
+
+	for _, id := range itemIDs {
+		items[id] = true
+	}
+
+	for _, id := range gistIDs {
+		gists[id] = true
+	}
+
+	for offset := 0; ; offset += trashPageSize {
+		var page []*diagramitem.DiagramItem
+
+		err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
+			ret := s.repo.FindTrash(ctx, userID, offset, trashPageSize, v.MetadataOnly)
+
+			if ret.IsOk() {
+				page = ret.MustGet()
+			}
+
+			return ret.Error()
+		})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, item := range page {
+			items[item.ID()] = true
+		}
+
+		if len(page) < trashPageSize {
+			return items, gists, nil
+		}
+	}
+}
+
+// importedID returns the ID a record of the archive is stored under for userID.
+func importedID(userID string, owned map[string]bool, id string) string {
+	if id == "" || owned[id] {
+		return id
+	}
+
+	return uuid.NewSHA1(importNamespace, []byte(userID+"/"+id)).String()
+}
+
+func itemFromEntry(files map[string]*zip.File, entry ItemEntry) (*diagramitem.DiagramItem, error) {
+	text, err := readEntry(files, entry.Text)
+
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail := mo.None[string]()
+
+	if entry.Thumbnail != "" {
+		t, err := readEntry(files, entry.Thumbnail)
+
+		if err != nil {
+			return nil, err
+		}
+
+		thumbnail = mo.Some(string(t))
+	}
+
+	item := diagramitem.New().
+		WithID(entry.ID).
+		WithTitle(entry.Title).
+		WithPlainText(string(text)).
+		WithThumbnail(thumbnail).
+		WithDiagram(entry.Diagram).
+		WithIsPublic(entry.IsPublic).
+		WithIsBookmark(entry.IsBookmark).
+		WithCreatedAt(entry.CreatedAt).
+		WithUpdatedAt(entry.UpdatedAt).
+		Build()
+
+	if item.IsError() {
+		return nil, item.Error()
+	}
+
+	return item.MustGet(), nil
+}
+
+func readEntry(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[name]
+
+	if !ok {
+		return nil, e.InvalidParameterError(e.ErrInvalidArchive)
+	}
+
+	rc, err := f.Open()
+
+	if err != nil {
+		return nil, e.InvalidParameterError(errors.Join(e.ErrInvalidArchive, err))
+	}
+
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+
+	if err != nil {
+		return nil, e.InvalidParameterError(errors.Join(e.ErrInvalidArchive, err))
+	}
+
+	if len(data) > maxEntrySize {
+		return nil, e.InvalidParameterError(e.ErrInvalidArchive)
+	}
+
+	return data, nil
+}
+
+func readJSON(files map[string]*zip.File, name string, out any) error {
+	data, err := readEntry(files, name)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return e.InvalidParameterError(errors.Join(e.ErrInvalidArchive, err))
+	}
+
+	return nil
+}
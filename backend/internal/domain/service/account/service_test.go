@@ -0,0 +1,593 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	shareModel "github.com/harehare/textusm/internal/domain/model/share"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/github"
+	"github.com/samber/mo"
+)
+
+type passthroughTransaction struct{}
+
+func (passthroughTransaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (passthroughTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// store keeps the data of every user in memory, keyed like Firestore.
+type store struct {
+	items    map[string]map[string]*diagramitem.DiagramItem
+	public   map[string]*diagramitem.DiagramItem
+	trash    map[string]map[string]*diagramitem.DiagramItem
+	gists    map[string]map[string]*gistitem.GistItem
+	settings map[string]map[v.Diagram]*settings.Settings
+	shares   map[string]shareRepo.ShareValue
+}
+
+func newStore() *store {
+	return &store{
+		items:    map[string]map[string]*diagramitem.DiagramItem{},
+		public:   map[string]*diagramitem.DiagramItem{},
+		trash:    map[string]map[string]*diagramitem.DiagramItem{},
+		gists:    map[string]map[string]*gistitem.GistItem{},
+		settings: map[string]map[v.Diagram]*settings.Settings{},
+		shares:   map[string]shareRepo.ShareValue{},
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *store) UserIDs(ctx context.Context) mo.Result[[]string] {
+	return mo.Ok(sortedKeys(s.items))
+}
+
+func (s *store) ItemIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return mo.Ok(sortedKeys(s.items[userID]))
+}
+
+func (s *store) GistIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return mo.Ok(sortedKeys(s.gists[userID]))
+}
+
+type itemStore struct{ *store }
+
+func (s itemStore) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	item, ok := s.items[userID][itemID]
+
+	if isPublic {
+		item, ok = s.public[itemID]
+	}
+
+	if !ok {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrNotAuthorization))
+	}
+
+	return mo.Ok(item)
+}
+
+func (s itemStore) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	items := []*diagramitem.DiagramItem{}
+
+	for _, itemID := range itemIDs {
+		if item, err := s.FindByID(ctx, userID, itemID, isPublic, fields).Get(); err == nil {
+			items = append(items, item)
+		}
+	}
+
+	return mo.Ok(items)
+}
+
+func (s itemStore) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return mo.Ok([]*diagramitem.DiagramItem{})
+}
+
+func (s itemStore) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	if isPublic {
+		s.public[item.ID()] = item
+		return mo.Ok(item)
+	}
+
+	if s.items[userID] == nil {
+		s.items[userID] = map[string]*diagramitem.DiagramItem{}
+	}
+
+	s.items[userID][item.ID()] = item
+	return mo.Ok(item)
+}
+
+func (s itemStore) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	if isPublic {
+		delete(s.public, itemID)
+	} else {
+		delete(s.items[userID], itemID)
+		delete(s.trash[userID], itemID)
+	}
+	return mo.Ok(true)
+}
+
+func (s itemStore) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	items := []*diagramitem.DiagramItem{}
+
+	for _, id := range sortedKeys(s.trash[userID]) {
+		items = append(items, s.trash[userID][id])
+	}
+
+	items = items[min(offset, len(items)):]
+	return mo.Ok(items[:min(limit, len(items))])
+}
+
+func (s itemStore) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	return mo.Ok([]itemRepo.TrashedItem{})
+}
+
+func (s itemStore) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	item, ok := s.items[userID][itemID]
+
+	if !ok {
+		return mo.Ok(false)
+	}
+
+	if s.trash[userID] == nil {
+		s.trash[userID] = map[string]*diagramitem.DiagramItem{}
+	}
+
+	s.trash[userID][itemID] = item.Trash(deletedAt)
+	delete(s.items[userID], itemID)
+	return mo.Ok(true)
+}
+
+func (s itemStore) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	item, ok := s.trash[userID][itemID]
+
+	if !ok {
+		return mo.Ok(false)
+	}
+
+	s.items[userID][itemID] = item.Restore()
+	delete(s.trash[userID], itemID)
+	return mo.Ok(true)
+}
+
+type gistStore struct{ *store }
+
+func (s gistStore) FindByID(ctx context.Context, userID string, gistID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
+	gist, ok := s.gists[userID][gistID]
+
+	if !ok {
+		return mo.Err[*gistitem.GistItem](e.NotFoundError(e.ErrNotAuthorization))
+	}
+
+	return mo.Ok(gist)
+}
+
+func (s gistStore) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	gists := []*gistitem.GistItem{}
+
+	for _, gistID := range gistIDs {
+		if gist, ok := s.gists[userID][gistID]; ok {
+			gists = append(gists, gist)
+		}
+	}
+
+	return mo.Ok(gists)
+}
+
+func (s gistStore) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	return mo.Ok([]*gistitem.GistItem{})
+}
+
+func (s gistStore) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
+	if s.gists[userID] == nil {
+		s.gists[userID] = map[string]*gistitem.GistItem{}
+	}
+
+	s.gists[userID][item.ID()] = item
+	return mo.Ok(item)
+}
+
+func (s gistStore) Delete(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	delete(s.gists[userID], itemID)
+	return mo.Ok(true)
+}
+
+type settingsStore struct{ *store }
+
+func (s settingsStore) Find(ctx context.Context, userID string, diagram v.Diagram) mo.Result[*settings.Settings] {
+	ret, ok := s.settings[userID][diagram]
+
+	if !ok {
+		return mo.Err[*settings.Settings](e.NotFoundError(e.ErrNotAuthorization))
+	}
+
+	return mo.Ok(ret)
+}
+
+func (s settingsStore) Save(ctx context.Context, userID string, diagram v.Diagram, ss *settings.Settings) mo.Result[*settings.Settings] {
+	if s.settings[userID] == nil {
+		s.settings[userID] = map[v.Diagram]*settings.Settings{}
+	}
+
+	s.settings[userID][diagram] = ss
+	return mo.Ok(ss)
+}
+
+func (s settingsStore) Delete(ctx context.Context, userID string, diagram v.Diagram) mo.Result[bool] {
+	delete(s.settings[userID], diagram)
+	return mo.Ok(true)
+}
+
+type shareStore struct{ *store }
+
+func (s shareStore) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
+	ret, ok := s.shares[hashKey]
+
+	if !ok {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(e.ErrNotAuthorization))
+	}
+
+	return mo.Ok(ret)
+}
+
+func (s shareStore) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	for _, share := range s.shares {
+		if share.ShareInfo.Code == code {
+			return mo.Ok(share)
+		}
+	}
+
+	return mo.Err[shareRepo.ShareValue](e.NotFoundError(e.ErrNotAuthorization))
+}
+
+func (s shareStore) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *shareModel.Share) mo.Result[bool] {
+	info := *shareInfo
+	s.shares[hashKey] = shareRepo.ShareValue{DiagramItem: item, ShareInfo: &info}
+	return mo.Ok(true)
+}
+
+func (s shareStore) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *shareModel.Share) mo.Result[bool] {
+	info := *shareInfo
+	s.shares[hashKey] = shareRepo.ShareValue{GistItem: item, ShareInfo: &info}
+	return mo.Ok(true)
+}
+
+func (s shareStore) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
+	delete(s.shares, hashKey)
+	return mo.Ok(true)
+}
+
+func (s shareStore) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	return mo.Ok(0)
+}
+
+func newKeyring(t *testing.T) *itemService.Keyring {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	priPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return itemService.NewKeyring(
+		itemService.EncryptPublicKey(base64.StdEncoding.EncodeToString(pubPem)),
+		itemService.EncryptPrivateKey(base64.StdEncoding.EncodeToString(priPem)),
+		"",
+	)
+}
+
+func newTestService(s *store, keyring *itemService.Keyring) *Service {
+	tx := passthroughTransaction{}
+	items := itemService.NewService(itemStore{s}, gistStore{s}, shareStore{s}, nil, nil, tx, "", "", github.NewClient("", ""), "key", keyring)
+	return NewService(s, itemStore{s}, gistStore{s}, settingsStore{s}, shareStore{s}, items, tx, "key")
+}
+
+func seedAccount(t *testing.T, s *store, svc *Service, userID string) (*diagramitem.DiagramItem, string) {
+	t.Helper()
+	ctx := values.WithUID(context.Background(), userID)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	item := diagramitem.New().
+		WithID("2b2c5e2a-6a4b-4bb0-9f5e-0c1a1b3f9d10").
+		WithTitle("title").
+		WithPlainText("# user story map").
+		WithThumbnail(mo.Some("data:image/png;base64,AAAA")).
+		WithDiagram(v.DiagramUserStoryMap).
+		WithIsBookmark(true).
+		WithCreatedAt(createdAt).
+		WithUpdatedAt(createdAt).
+		Build().MustGet()
+	gist := gistitem.New().
+		WithID("gist-id").
+		WithURL("https://gist.github.com/gist-id").
+		WithTitle("gist").
+		WithDiagram(v.DiagramMindMap).
+		WithCreatedAt(createdAt).
+		WithUpdatedAt(createdAt).
+		Build().MustGet()
+	scale := 1.0
+
+	itemStore{s}.Save(ctx, userID, item, false)
+	gistStore{s}.Save(ctx, userID, gist)
+	settingsStore{s}.Save(ctx, userID, v.DiagramUserStoryMap, &settings.Settings{Font: "Nunito", Width: 140, Scale: &scale})
+
+	code := svc.itemService.Share(ctx, item.ID(), v.LocationSystem, 3600, "password", []string{}, []string{"user@example.com"}, "my-share", 5)
+
+	if code.IsError() {
+		t.Fatal(code.Error())
+	}
+
+	return item, code.MustGet()
+}
+
+func TestExportImport(t *testing.T) {
+	keyring := newKeyring(t)
+	source := newStore()
+	item, code := seedAccount(t, source, newTestService(source, keyring), "user1")
+
+	var archive bytes.Buffer
+
+	if err := newTestService(source, keyring).Export(values.WithUID(context.Background(), "user1"), &archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest Manifest
+	if err := readJSON(map[string]*zip.File{manifestName: zr.File[len(zr.File)-1]}, manifestName, &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+
+	if manifest.Version != ArchiveVersion || len(manifest.Items) != 1 || len(manifest.Gists) != 1 || len(manifest.Settings) != 1 || len(manifest.Shares) != 1 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	target := newStore()
+	svc := newTestService(target, keyring)
+	ctx := values.WithUID(context.Background(), "user2")
+
+	for range 2 {
+		ret := svc.Import(ctx, bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+
+		if ret.IsError() {
+			t.Fatalf("Import() error = %v", ret.Error())
+		}
+
+		if *ret.MustGet() != (ImportResult{Items: 1, Gists: 1, Settings: 1, Shares: 1}) {
+			t.Errorf("Import() = %+v", ret.MustGet())
+		}
+	}
+
+	if len(target.items["user2"]) != 1 || len(target.gists["user2"]) != 1 || len(target.shares) != 1 {
+		t.Fatalf("import should not duplicate records: %d items, %d gists, %d shares", len(target.items["user2"]), len(target.gists["user2"]), len(target.shares))
+	}
+
+	// The IDs are not owned by user2 yet, so they are derived ones.
+	imported := target.items["user2"][importedID("user2", nil, item.ID())]
+
+	if imported.Text() != item.Text() || imported.Title() != item.Title() || !imported.IsBookmark() || !imported.CreatedAt().Equal(item.CreatedAt()) {
+		t.Errorf("imported item differs: %+v", imported)
+	}
+
+	if *imported.Thumbnail() != *item.Thumbnail() {
+		t.Errorf("imported thumbnail = %v", *imported.Thumbnail())
+	}
+
+	if target.gists["user2"][importedID("user2", nil, "gist-id")].URL() != "https://gist.github.com/gist-id" {
+		t.Errorf("gist URL was not restored")
+	}
+
+	share := shareStore{target}.FindByCode(ctx, code)
+
+	if share.IsError() {
+		t.Fatalf("share code %s was not restored: %v", code, share.Error())
+	}
+
+	info := share.MustGet().ShareInfo
+
+	if err := info.ComparePassword("password"); err != nil {
+		t.Errorf("share password was not restored: %v", err)
+	}
+
+	if views, _ := info.RemainingViews.Get(); views != 5 || len(info.AllowEmailList) != 1 {
+		t.Errorf("share limits were not restored: %+v", info)
+	}
+}
+
+func TestExportImportKeepsTrash(t *testing.T) {
+	keyring := newKeyring(t)
+	source := newStore()
+	item, _ := seedAccount(t, source, newTestService(source, keyring), "user1")
+	deletedAt := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	itemStore{source}.Trash(context.Background(), "user1", item.ID(), deletedAt)
+
+	var archive bytes.Buffer
+
+	if err := newTestService(source, keyring).Export(values.WithUID(context.Background(), "user1"), &archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	target := newStore()
+	ctx := values.WithUID(context.Background(), "user2")
+	ret := newTestService(target, keyring).Import(ctx, bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+
+	if ret.IsError() {
+		t.Fatalf("Import() error = %v", ret.Error())
+	}
+
+	if len(target.items["user2"]) != 0 || len(target.public) != 0 {
+		t.Fatalf("a trashed item should not be restored as a regular item")
+	}
+
+	trashed, ok := target.trash["user2"][importedID("user2", nil, item.ID())]
+
+	if !ok {
+		t.Fatal("the trashed item was not imported")
+	}
+
+	if trashed.Text() != item.Text() || trashed.DeletedAt() == nil || !trashed.DeletedAt().Equal(deletedAt) {
+		t.Errorf("imported trashed item differs: %+v", trashed)
+	}
+}
+
+func TestImportIntoSameAccountKeepsIDs(t *testing.T) {
+	keyring := newKeyring(t)
+	s := newStore()
+	svc := newTestService(s, keyring)
+	item, code := seedAccount(t, s, svc, "user1")
+	ctx := values.WithUID(context.Background(), "user1")
+
+	var archive bytes.Buffer
+
+	if err := svc.Export(ctx, &archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if ret := svc.Import(ctx, bytes.NewReader(archive.Bytes()), int64(archive.Len())); ret.IsError() {
+		t.Fatalf("Import() error = %v", ret.Error())
+	}
+
+	if _, ok := s.items["user1"][item.ID()]; !ok || len(s.items["user1"]) != 1 || len(s.gists["user1"]) != 1 || len(s.shares) != 1 {
+		t.Errorf("import into the same account should replace the records: %d items, %d gists, %d shares", len(s.items["user1"]), len(s.gists["user1"]), len(s.shares))
+	}
+
+	if (shareStore{s}).FindByCode(ctx, code).IsError() {
+		t.Errorf("share code %s should be kept", code)
+	}
+}
+
+func TestImportCannotOverwriteRecordsOfOthers(t *testing.T) {
+	keyring := newKeyring(t)
+	s := newStore()
+	svc := newTestService(s, keyring)
+	victim, _ := seedAccount(t, s, svc, "user1")
+	victimCtx := values.WithUID(context.Background(), "user1")
+
+	if ret := svc.itemService.Save(victimCtx, victim, true); ret.IsError() {
+		t.Fatal(ret.Error())
+	}
+
+	shareID := itemService.ShareID("key", victim.ID()).MustGet()
+	victimShare := s.shares[shareID].ShareInfo
+
+	// The archive of the attacker reuses the ID of the public item and share condition of user1.
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, _ := zw.Create("items/crafted.txt")
+	_, _ = f.Write([]byte("# replaced"))
+	f, _ = zw.Create(manifestName)
+	_ = json.NewEncoder(f).Encode(Manifest{
+		Version: ArchiveVersion,
+		Items: []ItemEntry{{
+			ID:        victim.ID(),
+			Title:     "replaced",
+			Diagram:   v.DiagramUserStoryMap,
+			IsPublic:  true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Text:      "items/crafted.txt",
+		}},
+		Shares: []ShareEntry{{ItemID: victim.ID(), Location: v.LocationSystem, ExpireTime: time.Now().Add(time.Hour).UnixMilli()}},
+	})
+	_ = zw.Close()
+
+	ret := svc.Import(values.WithUID(context.Background(), "user2"), bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+
+	if ret.IsError() {
+		t.Fatalf("Import() error = %v", ret.Error())
+	}
+
+	if public := s.public[victim.ID()]; public.Title() != victim.Title() || public.Text() != victim.Text() {
+		t.Errorf("public item of user1 was overwritten: %+v", public)
+	}
+
+	if s.shares[shareID].ShareInfo != victimShare {
+		t.Errorf("share condition of user1 was overwritten")
+	}
+
+	if _, ok := s.items["user2"][victim.ID()]; ok {
+		t.Errorf("imported item should not take the ID of an item of user1")
+	}
+
+	if _, ok := s.public[importedID("user2", nil, victim.ID())]; !ok {
+		t.Errorf("imported item should be public under its own ID")
+	}
+}
+
+func TestExportRequiresSignIn(t *testing.T) {
+	var archive bytes.Buffer
+
+	if err := newTestService(newStore(), nil).Export(context.Background(), &archive); e.GetCode(err) != e.NoAuthorization {
+		t.Errorf("Export() without a user should fail, got %v", err)
+	}
+
+	if archive.Len() != 0 {
+		t.Errorf("Export() without a user wrote %d bytes", archive.Len())
+	}
+}
+
+func TestImportRejectsInvalidArchives(t *testing.T) {
+	svc := newTestService(newStore(), nil)
+	ctx := values.WithUID(context.Background(), "user1")
+
+	newer := new(bytes.Buffer)
+	zw := zip.NewWriter(newer)
+	f, _ := zw.Create(manifestName)
+	_ = json.NewEncoder(f).Encode(Manifest{Version: ArchiveVersion + 1})
+	_ = zw.Close()
+
+	missingText := new(bytes.Buffer)
+	zw = zip.NewWriter(missingText)
+	f, _ = zw.Create(manifestName)
+	_ = json.NewEncoder(f).Encode(Manifest{Version: ArchiveVersion, Items: []ItemEntry{{ID: "id", Text: "items/id.txt"}}})
+	_ = zw.Close()
+
+	for name, data := range map[string][]byte{"not a zip": []byte("text"), "newer version": newer.Bytes(), "missing text": missingText.Bytes()} {
+		ret := svc.Import(ctx, bytes.NewReader(data), int64(len(data)))
+
+		if e.GetCode(ret.Error()) != e.InvalidParameter {
+			t.Errorf("Import(%s) should be rejected, got %v", name, ret.Error())
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package diagramitem
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// EncryptPreviousPublicKeys is a comma separated list of base64 encoded PEM public keys
+// that are still accepted for verification after a key rotation. Each entry may be
+// suffixed with "@<RFC3339 time>" to end its grace period at that time.
+type EncryptPreviousPublicKeys string
+
+var (
+	ErrSigningKeyNotConfigured = errors.New("signing key is not configured")
+	ErrUnknownSigningKey       = errors.New("unknown signing key")
+	ErrKeyPairMismatch         = errors.New("public key does not match the private key")
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type verificationKey struct {
+	until mo.Option[time.Time]
+	key   *rsa.PublicKey
+	kid   string
+}
+
+type Keyring struct {
+	err        error
+	now        func() time.Time
+	signingKey *rsa.PrivateKey
+	signingKid string
+	pubKey     EncryptPublicKey
+	priKey     EncryptPrivateKey
+	prevKeys   EncryptPreviousPublicKeys
+	keys       []verificationKey
+	once       sync.Once
+}
+
+func NewKeyring(pubKey EncryptPublicKey, priKey EncryptPrivateKey, prevKeys EncryptPreviousPublicKeys) *Keyring {
+	return &Keyring{
+		pubKey:   pubKey,
+		priKey:   priKey,
+		prevKeys: prevKeys,
+		now:      time.Now,
+	}
+}
+
+// Sign signs the claims with the current private key and sets the kid header.
+func (k *Keyring) Sign(claims jwt.MapClaims) mo.Result[string] {
+	if err := k.load(); err != nil {
+		return mo.Err[string](err)
+	}
+
+	if k.signingKey == nil {
+		return mo.Err[string](ErrSigningKeyNotConfigured)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS512, claims)
+	token.Header["kid"] = k.signingKid
+	tokenString, err := token.SignedString(k.signingKey)
+
+	if err != nil {
+		return mo.Err[string](err)
+	}
+
+	return mo.Ok(tokenString)
+}
+
+// Verify verifies the token with the key named by its kid header.
+// Tokens issued before kid headers were introduced are checked against every active key.
+func (k *Keyring) Verify(tokenString string) mo.Result[*jwt.Token] {
+	if err := k.load(); err != nil {
+		return mo.Err[*jwt.Token](e.ForbiddenError(err))
+	}
+
+	unverified, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+
+	if err != nil {
+		return mo.Err[*jwt.Token](e.URLExpiredError(err))
+	}
+
+	var candidates []verificationKey
+	kid, hasKid := unverified.Header["kid"].(string)
+
+	for _, key := range k.activeKeys() {
+		if !hasKid || key.kid == kid {
+			candidates = append(candidates, key)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return mo.Err[*jwt.Token](e.ForbiddenError(ErrUnknownSigningKey))
+	}
+
+	var lastErr error
+
+	for _, candidate := range candidates {
+		verifiedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, errors.New("unexpected signing method")
+			}
+			return candidate.key, nil
+		})
+
+		if err == nil && verifiedToken.Valid {
+			return mo.Ok(verifiedToken)
+		}
+
+		lastErr = err
+	}
+
+	return mo.Err[*jwt.Token](e.URLExpiredError(lastErr))
+}
+
+// JWKS returns the public keys that are currently accepted for verification.
+func (k *Keyring) JWKS() mo.Result[*JWKS] {
+	if err := k.load(); err != nil {
+		return mo.Err[*JWKS](err)
+	}
+
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range k.activeKeys() {
+		jwks.Keys = append(jwks.Keys, toJWK(key.kid, key.key))
+	}
+
+	return mo.Ok(&jwks)
+}
+
+func (k *Keyring) activeKeys() []verificationKey {
+	now := k.now()
+	var keys []verificationKey
+
+	for _, key := range k.keys {
+		if until, ok := key.until.Get(); ok && now.After(until) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (k *Keyring) load() error {
+	k.once.Do(func() {
+		k.err = k.parse()
+	})
+	return k.err
+}
+
+func (k *Keyring) parse() error {
+	var current *rsa.PublicKey
+
+	if k.priKey != "" {
+		privateKey, err := base64.StdEncoding.DecodeString(string(k.priKey))
+
+		if err != nil {
+			return err
+		}
+
+		signKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKey)
+
+		if err != nil {
+			return err
+		}
+
+		k.signingKey = signKey
+		k.signingKid = thumbprint(&signKey.PublicKey)
+		current = &signKey.PublicKey
+	}
+
+	// The current verification key is derived from the private key when one is set, so tokens it signs always verify.
+	if k.pubKey != "" {
+		publicKey, err := parsePublicKey(string(k.pubKey))
+
+		if err != nil {
+			return err
+		}
+
+		if current != nil && thumbprint(publicKey) != thumbprint(current) {
+			return ErrKeyPairMismatch
+		}
+
+		current = publicKey
+	}
+
+	if current != nil {
+		k.keys = append(k.keys, verificationKey{kid: thumbprint(current), key: current, until: mo.None[time.Time]()})
+	}
+
+	for _, entry := range strings.Split(string(k.prevKeys), ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		until := mo.None[time.Time]()
+		encoded, rawUntil, found := strings.Cut(entry, "@")
+
+		if found {
+			t, err := time.Parse(time.RFC3339, rawUntil)
+
+			if err != nil {
+				return fmt.Errorf("invalid grace period for previous public key: %w", err)
+			}
+			until = mo.Some(t)
+		}
+
+		publicKey, err := parsePublicKey(encoded)
+
+		if err != nil {
+			return err
+		}
+
+		kid := thumbprint(publicKey)
+
+		if current != nil && kid == thumbprint(current) {
+			continue
+		}
+
+		k.keys = append(k.keys, verificationKey{kid: kid, key: publicKey, until: until})
+	}
+
+	return nil
+}
+
+func parsePublicKey(encoded string) (*rsa.PublicKey, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseRSAPublicKeyFromPEM(publicKey)
+}
+
+// thumbprint returns the RFC 7638 JWK thumbprint of the key, which is used as its kid.
+func thumbprint(key *rsa.PublicKey) string {
+	jwk := toJWK("", key)
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{E: jwk.E, Kty: jwk.Kty, N: jwk.N})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func toJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS512.Alg(),
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
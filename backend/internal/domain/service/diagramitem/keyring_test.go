@@ -0,0 +1,136 @@
+package diagramitem
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+func generateTestKeys(t *testing.T) (EncryptPublicKey, EncryptPrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	priPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return EncryptPublicKey(base64.StdEncoding.EncodeToString(pubPem)), EncryptPrivateKey(base64.StdEncoding.EncodeToString(priPem))
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "test", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldPub, oldPri := generateTestKeys(t)
+	newPub, newPri := generateTestKeys(t)
+
+	oldKeyring := NewKeyring(oldPub, oldPri, "")
+	oldToken := oldKeyring.Sign(testClaims()).MustGet()
+
+	keyring := NewKeyring(newPub, newPri, EncryptPreviousPublicKeys(oldPub))
+
+	if keyring.Verify(oldToken).IsError() {
+		t.Fatal("token signed with previous key should be valid")
+	}
+
+	newToken := keyring.Sign(testClaims()).MustGet()
+
+	if keyring.Verify(newToken).IsError() {
+		t.Fatal("token signed with current key should be valid")
+	}
+
+	if oldKeyring.Verify(newToken).IsOk() {
+		t.Fatal("token signed with unknown key should be rejected")
+	}
+
+	jwks := keyring.JWKS().MustGet()
+
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(jwks.Keys))
+	}
+
+	if jwks.Keys[0].Kid != keyring.signingKid {
+		t.Fatal("current key should be listed first")
+	}
+}
+
+func TestKeyringGracePeriod(t *testing.T) {
+	oldPub, oldPri := generateTestKeys(t)
+	newPub, newPri := generateTestKeys(t)
+	oldToken := NewKeyring(oldPub, oldPri, "").Sign(testClaims()).MustGet()
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	keyring := NewKeyring(newPub, newPri, EncryptPreviousPublicKeys(string(oldPub)+"@"+until.Format(time.RFC3339)))
+	keyring.now = func() time.Time { return until.Add(-time.Minute) }
+
+	if keyring.Verify(oldToken).IsError() {
+		t.Fatal("token should be valid within grace period")
+	}
+
+	keyring.now = func() time.Time { return until.Add(time.Minute) }
+
+	if keyring.Verify(oldToken).IsOk() {
+		t.Fatal("token should be rejected after grace period")
+	}
+
+	if len(keyring.JWKS().MustGet().Keys) != 1 {
+		t.Fatal("expired key should not be published")
+	}
+}
+
+func TestKeyringLegacyTokenWithoutKid(t *testing.T) {
+	pub, pri := generateTestKeys(t)
+	keyring := NewKeyring(pub, pri, "")
+	keyring.load()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS512, testClaims())
+	tokenString, err := token.SignedString(keyring.signingKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyring.Verify(tokenString).IsError() {
+		t.Fatal("token without kid should be verified against active keys")
+	}
+}
+
+func TestKeyringInvalidGracePeriod(t *testing.T) {
+	pub, pri := generateTestKeys(t)
+	keyring := NewKeyring(pub, pri, EncryptPreviousPublicKeys(string(pub)+"@tomorrow"))
+
+	if keyring.Sign(testClaims()).IsOk() {
+		t.Fatal("invalid grace period should fail to load")
+	}
+}
+
+func TestKeyringMismatchedKeyPair(t *testing.T) {
+	_, pri := generateTestKeys(t)
+	otherPub, _ := generateTestKeys(t)
+	keyring := NewKeyring(otherPub, pri, "")
+
+	if err := keyring.load(); !errors.Is(err, ErrKeyPairMismatch) {
+		t.Fatalf("expected key pair mismatch, got %v", err)
+	}
+
+	if keyring.Sign(testClaims()).IsOk() {
+		t.Fatal("mismatched key pair should not sign tokens")
+	}
+}
@@ -3,11 +3,14 @@ package diagramitem
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"math/big"
 	"net"
+	"regexp"
 	"time"
 
 	"log/slog"
@@ -17,48 +20,63 @@ import (
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/db"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	shareModel "github.com/harehare/textusm/internal/domain/model/share"
+	userModel "github.com/harehare/textusm/internal/domain/model/user"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	gistRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
 	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
 	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	v "github.com/harehare/textusm/internal/domain/values"
 	e "github.com/harehare/textusm/internal/error"
 	"github.com/harehare/textusm/internal/github"
+	"github.com/samber/lo"
 	"github.com/samber/mo"
 )
 
 const (
-	maxExpSecond     = 365 * 24 * 60 * 60
-	minExpSecond     = 60
-	maxAllowListSize = 100
+	maxExpSecond        = 365 * 24 * 60 * 60
+	minExpSecond        = 60
+	maxAllowListSize    = 100
+	maxShareViews       = 10000
+	shareCodeLength     = 10
+	maxShareCodeRetries = 5
+	shareCodeAlphabet   = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 )
 
+var shareCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{2,63}$`)
+
 type ShareEncryptKey string
 type EncryptPublicKey string
 type EncryptPrivateKey string
 
 type Service struct {
 	repo            itemRepo.ItemRepository
+	gistRepo        gistRepo.GistItemRepository
 	shareRepo       shareRepo.ShareRepository
 	userRepo        userRepo.UserRepository
+	tokenRepo       userRepo.GithubTokenRepository
 	transaction     db.Transaction
 	clientID        github.ClientID
 	clientSecret    github.ClientSecret
+	githubClient    *github.Client
 	shareEncryptKey ShareEncryptKey
-	pubKey          EncryptPublicKey
-	priKey          EncryptPrivateKey
+	keyring         *Keyring
 }
 
-func NewService(r itemRepo.ItemRepository, s shareRepo.ShareRepository, u userRepo.UserRepository, transaction db.Transaction, clientID github.ClientID, clientSecret github.ClientSecret, shareEncryptKey ShareEncryptKey, pubKey EncryptPublicKey, priKey EncryptPrivateKey) *Service {
+func NewService(r itemRepo.ItemRepository, g gistRepo.GistItemRepository, s shareRepo.ShareRepository, u userRepo.UserRepository, t userRepo.GithubTokenRepository, transaction db.Transaction, clientID github.ClientID, clientSecret github.ClientSecret, githubClient *github.Client, shareEncryptKey ShareEncryptKey, keyring *Keyring) *Service {
 	return &Service{
 		repo:            r,
+		gistRepo:        g,
 		shareRepo:       s,
 		userRepo:        u,
+		tokenRepo:       t,
 		transaction:     transaction,
 		clientID:        clientID,
 		clientSecret:    clientSecret,
+		githubClient:    githubClient,
 		shareEncryptKey: shareEncryptKey,
-		pubKey:          pubKey,
-		priKey:          priKey,
+		keyring:         keyring,
 	}
 }
 
@@ -72,17 +90,15 @@ func isAuthenticated(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) Find(ctx context.Context, offset, limit int, isPublic bool, isBookmark bool, fields map[string]struct{}) mo.Result[[]*diagramitem.DiagramItem] {
+func (s *Service) Find(ctx context.Context, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
 	var items []*diagramitem.DiagramItem
 
-	err := s.transaction.Do(ctx, func(ctx context.Context) error {
-		_, shouldLoadText := fields["text"]
-
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
 		if err := isAuthenticated(ctx); err != nil {
 			return err
 		}
 
-		result := s.repo.Find(ctx, values.GetUID(ctx).OrEmpty(), offset, limit, isPublic, isBookmark, shouldLoadText)
+		result := s.repo.Find(ctx, values.GetUID(ctx).OrEmpty(), offset, limit, isPublic, filter, fields)
 
 		if !result.IsError() {
 			items = result.MustGet()
@@ -97,15 +113,15 @@ func (s *Service) Find(ctx context.Context, offset, limit int, isPublic bool, is
 	return mo.Ok(items)
 }
 
-func (s *Service) FindByID(ctx context.Context, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+func (s *Service) FindByID(ctx context.Context, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
 	var item *diagramitem.DiagramItem
 
-	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
 		if err := isAuthenticated(ctx); err != nil {
 			return err
 		}
 
-		result := s.repo.FindByID(ctx, values.GetUID(ctx).OrEmpty(), itemID, isPublic)
+		result := s.repo.FindByID(ctx, values.GetUID(ctx).OrEmpty(), itemID, isPublic, fields)
 
 		if !result.IsError() {
 			item = result.MustGet()
@@ -121,6 +137,31 @@ func (s *Service) FindByID(ctx context.Context, itemID string, isPublic bool) mo
 	return mo.Ok(item)
 }
 
+// FindByIDs reads the items of itemIDs in one round trip. Items that do not exist are left out.
+func (s *Service) FindByIDs(ctx context.Context, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
+		if err := isAuthenticated(ctx); err != nil {
+			return err
+		}
+
+		result := s.repo.FindByIDs(ctx, values.GetUID(ctx).OrEmpty(), itemIDs, isPublic, fields)
+
+		if !result.IsError() {
+			items = result.MustGet()
+		}
+
+		return result.Error()
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return mo.Ok(items)
+}
+
 func (s *Service) Save(ctx context.Context, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
 	var savedItem *diagramitem.DiagramItem
 	err := s.transaction.Do(ctx, func(ctx context.Context) error {
@@ -147,7 +188,7 @@ func (s *Service) Save(ctx context.Context, item *diagramitem.DiagramItem, isPub
 			savedItem = r.MustGet()
 			return nil
 		} else {
-			ret := s.repo.FindByID(ctx, userID.OrEmpty(), item.ID(), true)
+			ret := s.repo.FindByID(ctx, userID.OrEmpty(), item.ID(), true, v.MetadataOnly)
 
 			if ret.IsOk() {
 				err := s.repo.Delete(ctx, userID.OrEmpty(), item.ID(), true)
@@ -176,6 +217,8 @@ func (s *Service) Save(ctx context.Context, item *diagramitem.DiagramItem, isPub
 	return mo.Ok(savedItem)
 }
 
+// Delete moves the item to the trash. Its public copy and share condition are removed right away, so that
+// nothing in the trash can be seen by others, and Restore brings back the private item only.
 func (s *Service) Delete(ctx context.Context, itemID string, isPublic bool) error {
 	return s.transaction.Do(ctx, func(ctx context.Context) error {
 		if err := isAuthenticated(ctx); err != nil {
@@ -195,17 +238,127 @@ func (s *Service) Delete(ctx context.Context, itemID string, isPublic bool) erro
 			if !ret.OrElse(false) {
 				return e.NoAuthorizationError(e.ErrNotDiagramOwner)
 			}
+		}
+
+		// Trashing reads the item, so it goes before the writes below.
+		if err := s.repo.Trash(ctx, userID.OrEmpty(), itemID, time.Now()); err.IsError() {
+			return err.Error()
+		}
+
+		if isPublic {
 			if err := s.repo.Delete(ctx, userID.OrEmpty(), itemID, true); err.IsError() {
 				return err.Error()
 			}
 		}
 
-		if err := s.shareRepo.Delete(ctx, userID.OrEmpty(), shareID.OrEmpty()); err.IsError() {
-			return err.Error()
+		return s.shareRepo.Delete(ctx, userID.OrEmpty(), shareID.OrEmpty()).Error()
+	})
+}
+
+// FindTrash returns the items in the trash of the signed-in user, most recently deleted first.
+func (s *Service) FindTrash(ctx context.Context, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	if err := isAuthenticated(ctx); err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	var items []*diagramitem.DiagramItem
+
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
+		result := s.repo.FindTrash(ctx, values.GetUID(ctx).OrEmpty(), offset, limit, fields)
+
+		if !result.IsError() {
+			items = result.MustGet()
+		}
+
+		return result.Error()
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return mo.Ok(items)
+}
+
+// Restore takes the item out of the trash.
+func (s *Service) Restore(ctx context.Context, itemID string) mo.Result[*diagramitem.DiagramItem] {
+	if err := isAuthenticated(ctx); err != nil {
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+		restored := s.repo.Restore(ctx, values.GetUID(ctx).OrEmpty(), itemID)
+
+		if restored.IsError() {
+			return restored.Error()
 		}
 
-		return s.repo.Delete(ctx, userID.OrEmpty(), itemID, false).Error()
+		if !restored.MustGet() {
+			return e.NotFoundError(e.ErrItemNotInTrash)
+		}
+
+		return nil
 	})
+
+	if err != nil {
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	// Read once committed, since Firestore cannot read within a transaction after writing.
+	return s.FindByID(ctx, itemID, false, v.AllFields)
+}
+
+// Purge deletes an item in the trash for good.
+func (s *Service) Purge(ctx context.Context, itemID string) error {
+	return s.transaction.Do(ctx, func(ctx context.Context) error {
+		if err := isAuthenticated(ctx); err != nil {
+			return err
+		}
+
+		userID := values.GetUID(ctx).OrEmpty()
+		item := s.repo.FindByID(ctx, userID, itemID, false, v.MetadataOnly)
+
+		if item.IsOk() {
+			return e.InvalidParameterError(e.ErrItemNotInTrash)
+		}
+
+		if e.GetCode(item.Error()) != e.NotFound {
+			return item.Error()
+		}
+
+		trashed := s.inTrash(ctx, userID, itemID)
+
+		if trashed.IsError() {
+			return trashed.Error()
+		}
+
+		if !trashed.MustGet() {
+			return e.NotFoundError(e.ErrItemNotInTrash)
+		}
+
+		return s.repo.Delete(ctx, userID, itemID, false).Error()
+	})
+}
+
+// inTrash reports whether the item is in the trash of userID, reading the trash a page at a time.
+func (s *Service) inTrash(ctx context.Context, userID, itemID string) mo.Result[bool] {
+	for offset := 0; ; offset += trashPageSize {
+		page := s.repo.FindTrash(ctx, userID, offset, trashPageSize, v.MetadataOnly)
+
+		if page.IsError() {
+			return mo.Err[bool](page.Error())
+		}
+
+		for _, item := range page.MustGet() {
+			if item.ID() == itemID {
+				return mo.Ok(true)
+			}
+		}
+
+		if len(page.MustGet()) < trashPageSize {
+			return mo.Ok(false)
+		}
+	}
 }
 
 func (s *Service) Bookmark(ctx context.Context, itemID string, isBookmark bool) mo.Result[*diagramitem.DiagramItem] {
@@ -215,7 +368,7 @@ func (s *Service) Bookmark(ctx context.Context, itemID string, isBookmark bool)
 			return err
 		}
 
-		result := s.FindByID(ctx, itemID, false).FlatMap(func(item *diagramitem.DiagramItem) mo.Result[*diagramitem.DiagramItem] {
+		result := s.repo.FindByID(ctx, values.GetUID(ctx).OrEmpty(), itemID, false, v.AllFields).FlatMap(func(item *diagramitem.DiagramItem) mo.Result[*diagramitem.DiagramItem] {
 			return s.Save(ctx, item.Bookmark(isBookmark), false)
 		})
 
@@ -233,16 +386,23 @@ func (s *Service) Bookmark(ctx context.Context, itemID string, isBookmark bool)
 	return mo.Ok(item)
 }
 
+// FindShareItem returns the shared item. Shared gists are resolved to their content on the server.
 func (s *Service) FindShareItem(ctx context.Context, token string, password string) mo.Result[*diagramitem.DiagramItem] {
-	var item *diagramitem.DiagramItem
+	var (
+		item     *diagramitem.DiagramItem
+		gistItem *gistitem.GistItem
+		ownerID  string
+		shareID  string
+	)
 	err := s.transaction.Do(ctx, func(ctx context.Context) error {
-		t, err := base64.RawURLEncoding.DecodeString(token)
+		resolved := s.resolveShareToken(ctx, token)
 
-		if err != nil {
-			return err
+		if resolved.IsError() {
+			return resolved.Error()
 		}
 
-		jwtTokenResult := s.verifyToken(string(t))
+		tokenString, shareValue := resolved.MustGet().A, resolved.MustGet().B
+		jwtTokenResult := s.verifyToken(tokenString)
 
 		if jwtTokenResult.IsError() {
 			return jwtTokenResult.Error()
@@ -259,14 +419,22 @@ func (s *Service) FindShareItem(ctx context.Context, token string, password stri
 			return e.ForbiddenError(errors.New("invalid token sub claim"))
 		}
 
-		shareResponse := s.shareRepo.Find(ctx, sub)
+		if shareValue.IsAbsent() {
+			shareResponse := s.shareRepo.Find(ctx, sub)
+
+			if shareResponse.IsError() {
+				return shareResponse.Error()
+			}
 
-		if shareResponse.IsError() {
-			return shareResponse.Error()
+			shareValue = mo.Some(shareResponse.MustGet())
 		}
 
 		ip := values.GetIP(ctx)
-		shareInfo := shareResponse.OrEmpty().ShareInfo
+		shareInfo := shareValue.MustGet().ShareInfo
+
+		if shareInfo.ViewsExhausted() {
+			return e.URLExpiredError(e.ErrShareViewsExceeded)
+		}
 
 		if ip.IsAbsent() || !shareInfo.CheckIpWithinRange(ip.OrEmpty()) {
 			return e.ForbiddenError(e.ErrNotAllowIpAddress)
@@ -302,7 +470,22 @@ func (s *Service) FindShareItem(ctx context.Context, token string, password stri
 			}
 		}
 
-		item = shareResponse.OrEmpty().DiagramItem
+		if shareInfo.RemainingViews.IsPresent() {
+			consumed := s.shareRepo.ConsumeView(ctx, sub)
+
+			if e.GetCode(consumed.Error()) == e.NotFound {
+				return e.URLExpiredError(e.ErrShareViewsExceeded)
+			}
+
+			if consumed.IsError() {
+				return consumed.Error()
+			}
+		}
+
+		item = shareValue.MustGet().DiagramItem
+		gistItem = shareValue.MustGet().GistItem
+		ownerID = shareValue.MustGet().UserID
+		shareID = sub
 		return nil
 	})
 
@@ -310,7 +493,101 @@ func (s *Service) FindShareItem(ctx context.Context, token string, password stri
 		return mo.Err[*diagramitem.DiagramItem](err)
 	}
 
-	return mo.Ok(item)
+	if gistItem != nil {
+		return s.gistToDiagramItem(ctx, ownerID, gistItem).Map(func(item *diagramitem.DiagramItem) (*diagramitem.DiagramItem, error) {
+			return item.SharedAs(shareID), nil
+		})
+	}
+
+	return mo.Ok(item.SharedAs(shareID))
+}
+
+// IsViewable reports whether the current request may see the item of ownerID, as its owner, because it is public,
+// or through a share that is still open to the request's IP address and user.
+// A share with a password grants nothing here, as content such as images is fetched by URL without the password,
+// and a URL taken from the shared text must stop working when the password is changed.
+func (s *Service) IsViewable(ctx context.Context, ownerID, itemID string, location v.Location) mo.Result[bool] {
+	uid := values.GetUID(ctx)
+
+	if uid.OrEmpty() == ownerID {
+		return mo.Ok(true)
+	}
+
+	shareID := s.itemIDToShareID(itemID)
+
+	if shareID.IsError() {
+		return mo.Err[bool](shareID.Error())
+	}
+
+	viewable := false
+	// The items and shares of other users are only visible in a transaction run as their owner.
+	err := s.transaction.DoReadOnly(values.WithUID(ctx, ownerID), func(ctx context.Context) error {
+		if location == v.LocationSystem {
+			public := s.repo.FindByID(ctx, ownerID, itemID, true, v.MetadataOnly)
+
+			if public.IsOk() {
+				viewable = true
+				return nil
+			}
+
+			if e.GetCode(public.Error()) != e.NotFound {
+				return public.Error()
+			}
+		}
+
+		share := s.shareRepo.Find(ctx, shareID.MustGet())
+
+		if e.GetCode(share.Error()) == e.NotFound {
+			return nil
+		}
+
+		if share.IsError() {
+			return share.Error()
+		}
+
+		viewable = s.AllowsShareView(ctx, uid, share.MustGet(), itemID)
+		return nil
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(viewable)
+}
+
+// AllowsShareView reports whether the share is still open to itemID for the IP address of the request and the
+// viewer, the signed in user if any. Like IsViewable, it grants nothing for a share with a password.
+func (s *Service) AllowsShareView(ctx context.Context, viewer mo.Option[string], value shareRepo.ShareValue, itemID string) bool {
+	shareInfo := value.ShareInfo
+
+	if value.ItemID() != itemID || shareInfo.ViewsExhausted() || shareInfo.Password != "" {
+		return false
+	}
+
+	if expireTime := shareInfo.ExpireTime; expireTime > 0 && time.Now().UnixMilli() > expireTime {
+		return false
+	}
+
+	ip := values.GetIP(ctx)
+
+	if ip.IsAbsent() || !shareInfo.CheckIpWithinRange(ip.OrEmpty()) {
+		return false
+	}
+
+	if len(shareInfo.AllowEmailList) > 0 {
+		if viewer.IsAbsent() {
+			return false
+		}
+
+		u := s.userRepo.Find(ctx, viewer.OrEmpty())
+
+		if u.IsError() || !shareInfo.ValidEmail(u.MustGet().Email) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (s *Service) FindShareCondition(ctx context.Context, itemID string) mo.Result[*shareModel.ShareCondition] {
@@ -333,11 +610,19 @@ func (s *Service) FindShareCondition(ctx context.Context, itemID string) mo.Resu
 		}
 
 		v, _ := result.Get()
+		var remainingViews *int
+
+		if views, ok := v.ShareInfo.RemainingViews.Get(); ok {
+			remainingViews = &views
+		}
+
 		shareCondition = &shareModel.ShareCondition{
 			Token:          v.ShareInfo.Token,
+			Code:           v.ShareInfo.Code,
 			ExpireTime:     int(v.ShareInfo.ExpireTime),
 			AllowIPList:    v.ShareInfo.AllowIPList,
 			AllowEmailList: v.ShareInfo.AllowEmailList,
+			RemainingViews: remainingViews,
 		}
 
 		return nil
@@ -350,13 +635,21 @@ func (s *Service) FindShareCondition(ctx context.Context, itemID string) mo.Resu
 	return mo.Ok(shareCondition)
 }
 
-func (s *Service) Share(ctx context.Context, itemID string, expSecond int, password string, allowIPList []string, allowEmailList []string) mo.Result[string] {
+// Share stores the share condition of the item at location and returns a short code resolving to the signed token.
+// When slug is empty a random code is generated. A positive maxViews limits how many times the share can be opened.
+func (s *Service) Share(ctx context.Context, itemID string, location v.Location, expSecond int, password string, allowIPList []string, allowEmailList []string, slug string, maxViews int) mo.Result[string] {
 	if expSecond < minExpSecond || expSecond > maxExpSecond {
 		return mo.Err[string](e.InvalidParameterError(errors.New("expSecond must be between 60 and 31536000")))
 	}
 	if len(allowIPList) > maxAllowListSize || len(allowEmailList) > maxAllowListSize {
 		return mo.Err[string](e.InvalidParameterError(errors.New("allow list size exceeds maximum of 100")))
 	}
+	if maxViews < 0 || maxViews > maxShareViews {
+		return mo.Err[string](e.InvalidParameterError(errors.New("maxViews must be between 0 and 10000")))
+	}
+	if slug != "" && !shareCodePattern.MatchString(slug) {
+		return mo.Err[string](e.InvalidParameterError(e.ErrInvalidShareCode))
+	}
 
 	var shareToken string
 	err := s.transaction.Do(ctx, func(ctx context.Context) error {
@@ -366,69 +659,146 @@ func (s *Service) Share(ctx context.Context, itemID string, expSecond int, passw
 			return e.NoAuthorizationError(e.ErrNotAuthorization)
 		}
 
-		itemResult := s.repo.FindByID(ctx, userID.OrEmpty(), itemID, false)
+		hashedPassword, err := shareModel.HashPassword(password)
 
-		if itemResult.IsError() {
-			return itemResult.Error()
+		if err != nil {
+			return err
 		}
 
-		item := itemResult.OrEmpty()
-		shareID := s.itemIDToShareID(item.ID())
-
-		if shareID.IsError() {
-			return shareID.Error()
+		shareInfo := shareModel.Share{
+			Password:       hashedPassword,
+			AllowIPList:    validIpList(allowIPList),
+			AllowEmailList: allowEmailList,
+			ExpireTime:     time.Now().Add(time.Second*time.Duration(expSecond)).Unix() * int64(1000),
+			RemainingViews: mo.None[int](),
 		}
 
-		privateKey, err := base64.StdEncoding.DecodeString(string(s.priKey))
+		if maxViews > 0 {
+			shareInfo.RemainingViews = mo.Some(maxViews)
+		}
 
-		if err != nil {
+		if err := s.saveShare(ctx, userID.OrEmpty(), itemID, location, slug, &shareInfo); err != nil {
 			return err
 		}
 
-		signKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKey)
+		shareToken = shareInfo.Code
+		return nil
+	})
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return mo.Err[string](err)
+	}
+
+	return mo.Ok(shareToken)
+}
+
+// RestoreShare recreates a share condition taken from an account export, keeping its expiry, password hash and limits.
+// The code is kept unless another item uses it by now. A new token is signed as tokens are bound to the server keys.
+func (s *Service) RestoreShare(ctx context.Context, itemID string, location v.Location, shareInfo *shareModel.Share) mo.Result[string] {
+	if shareInfo.ExpireTime <= time.Now().UnixMilli() {
+		return mo.Err[string](e.InvalidParameterError(errors.New("share condition has expired")))
+	}
+	if len(shareInfo.AllowIPList) > maxAllowListSize || len(shareInfo.AllowEmailList) > maxAllowListSize {
+		return mo.Err[string](e.InvalidParameterError(errors.New("allow list size exceeds maximum of 100")))
+	}
+	if views, ok := shareInfo.RemainingViews.Get(); ok && (views < 0 || views > maxShareViews) {
+		return mo.Err[string](e.InvalidParameterError(errors.New("remainingViews must be between 0 and 10000")))
+	}
 
-		now := time.Now()
-		expireTime := now.Add(time.Second * time.Duration(expSecond)).Unix()
-		token := jwt.New(jwt.SigningMethodRS512)
-		claims := token.Claims.(jwt.MapClaims)
-		claims["jti"] = uuid.New().String()
-		claims["sub"] = shareID.OrEmpty()
-		claims["iat"] = now.Unix()
-		claims["exp"] = expireTime
-		claims["check_password"] = password != ""
-		claims["check_email"] = len(allowEmailList) > 0
+	restored := *shareInfo
+	restored.AllowIPList = validIpList(shareInfo.AllowIPList)
+	slug := shareInfo.Code
 
-		tokenString, err := token.SignedString(signKey)
+	if !shareCodePattern.MatchString(slug) {
+		slug = ""
+	}
 
-		if err != nil {
-			return err
-		}
+	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+		userID := values.GetUID(ctx)
 
-		shareInfo := shareModel.Share{
-			Token:          tokenString,
-			Password:       password,
-			AllowIPList:    validIpList(allowIPList),
-			AllowEmailList: allowEmailList,
-			ExpireTime:     expireTime * int64(1000),
+		if userID.IsAbsent() {
+			return e.NoAuthorizationError(e.ErrNotAuthorization)
 		}
 
-		if err := s.shareRepo.Save(ctx, userID.OrEmpty(), shareID.OrEmpty(), item, &shareInfo); err.IsError() {
-			return err.Error()
+		err := s.saveShare(ctx, userID.OrEmpty(), itemID, location, slug, &restored)
+
+		if errors.Is(err, e.ErrShareCodeConflict) {
+			err = s.saveShare(ctx, userID.OrEmpty(), itemID, location, "", &restored)
 		}
 
-		shareToken = base64.RawURLEncoding.EncodeToString([]byte(tokenString))
-		return nil
+		return err
 	})
 
 	if err != nil {
 		return mo.Err[string](err)
 	}
 
-	return mo.Ok(shareToken)
+	return mo.Ok(restored.Code)
+}
+
+// saveShare signs a token for shareInfo, issues its code and stores it for the item of userID at location.
+// Token and Code of shareInfo are set on success.
+func (s *Service) saveShare(ctx context.Context, userID, itemID string, location v.Location, slug string, shareInfo *shareModel.Share) error {
+	var save func(shareID string) mo.Result[bool]
+
+	if location == v.LocationGist {
+		gistResult := s.gistRepo.FindByID(ctx, userID, itemID, v.AllFields)
+
+		if gistResult.IsError() {
+			return gistResult.Error()
+		}
+
+		save = func(shareID string) mo.Result[bool] {
+			return s.shareRepo.SaveGist(ctx, userID, shareID, gistResult.MustGet(), shareInfo)
+		}
+	} else {
+		itemResult := s.repo.FindByID(ctx, userID, itemID, false, v.AllFields)
+
+		if itemResult.IsError() {
+			return itemResult.Error()
+		}
+
+		save = func(shareID string) mo.Result[bool] {
+			return s.shareRepo.Save(ctx, userID, shareID, itemResult.MustGet(), shareInfo)
+		}
+	}
+
+	shareID := s.itemIDToShareID(itemID)
+
+	if shareID.IsError() {
+		return shareID.Error()
+	}
+
+	signedToken := s.keyring.Sign(jwt.MapClaims{
+		"jti":            uuid.New().String(),
+		"sub":            shareID.OrEmpty(),
+		"iat":            time.Now().Unix(),
+		"exp":            shareInfo.ExpireTime / 1000,
+		"check_password": shareInfo.Password != "",
+		"check_email":    len(shareInfo.AllowEmailList) > 0,
+	})
+
+	if signedToken.IsError() {
+		return signedToken.Error()
+	}
+
+	code := s.issueShareCode(ctx, itemID, slug)
+
+	if code.IsError() {
+		return code.Error()
+	}
+
+	shareInfo.Token = signedToken.MustGet()
+	shareInfo.Code = code.MustGet()
+
+	err := save(shareID.OrEmpty()).Error()
+
+	// The slug can be taken between the lookup above and the save, or by a share the lookup cannot see.
+	if slug != "" && errors.Is(err, e.ErrShareCodeConflict) {
+		return e.InvalidParameterError(e.ErrShareCodeConflict)
+	}
+
+	return err
 }
 
 func (s *Service) RevokeGistToken(ctx context.Context, accessToken string) error {
@@ -447,30 +817,138 @@ func (s *Service) RevokeToken(ctx context.Context) error {
 	return s.userRepo.RevokeToken(ctx)
 }
 
+func (s *Service) JWKS() mo.Result[*JWKS] {
+	return s.keyring.JWKS()
+}
+
 func (s *Service) verifyToken(token string) mo.Result[*jwt.Token] {
-	publicKey, err := base64.StdEncoding.DecodeString(string(s.pubKey))
+	return s.keyring.Verify(token)
+}
 
-	if err != nil {
-		return mo.Err[*jwt.Token](e.ForbiddenError(err))
+// resolveShareToken returns the signed token for a share code, together with the share it belongs to.
+// Tokens issued before share codes were introduced are base64 encoded JWTs and are decoded as is.
+func (s *Service) resolveShareToken(ctx context.Context, token string) mo.Result[lo.Tuple2[string, mo.Option[shareRepo.ShareValue]]] {
+	if shareCodePattern.MatchString(token) {
+		ret := s.shareRepo.FindByCode(ctx, token)
+
+		if ret.IsOk() {
+			return mo.Ok(lo.T2(ret.MustGet().ShareInfo.Token, mo.Some(ret.MustGet())))
+		}
+
+		if e.GetCode(ret.Error()) != e.NotFound {
+			return mo.Err[lo.Tuple2[string, mo.Option[shareRepo.ShareValue]]](ret.Error())
+		}
 	}
 
-	jwtPublicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKey)
+	t, err := base64.RawURLEncoding.DecodeString(token)
+
 	if err != nil {
-		return mo.Err[*jwt.Token](e.ForbiddenError(err))
+		return mo.Err[lo.Tuple2[string, mo.Option[shareRepo.ShareValue]]](e.NotFoundError(err))
+	}
+
+	return mo.Ok(lo.T2(string(t), mo.None[shareRepo.ShareValue]()))
+}
+
+// issueShareCode reserves the slug for the item, or generates a random code when slug is empty.
+func (s *Service) issueShareCode(ctx context.Context, itemID, slug string) mo.Result[string] {
+	if slug != "" {
+		ret := s.shareRepo.FindByCode(ctx, slug)
+
+		if ret.IsOk() && ret.MustGet().ItemID() != itemID {
+			return mo.Err[string](e.InvalidParameterError(e.ErrShareCodeConflict))
+		}
+
+		if ret.IsError() && e.GetCode(ret.Error()) != e.NotFound {
+			return mo.Err[string](ret.Error())
+		}
+
+		return mo.Ok(slug)
 	}
 
-	verifiedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, errors.New("unexpected signing method")
+	for range maxShareCodeRetries {
+		code, err := generateShareCode()
+
+		if err != nil {
+			return mo.Err[string](err)
 		}
-		return jwtPublicKey, nil
+
+		ret := s.shareRepo.FindByCode(ctx, code)
+
+		if ret.IsError() && e.GetCode(ret.Error()) == e.NotFound {
+			return mo.Ok(code)
+		}
+
+		if ret.IsError() {
+			return mo.Err[string](ret.Error())
+		}
+	}
+
+	return mo.Err[string](e.ErrShareCodeConflict)
+}
+
+func (s *Service) gistToDiagramItem(ctx context.Context, ownerID string, gistItem *gistitem.GistItem) mo.Result[*diagramitem.DiagramItem] {
+	accessToken := s.ownerAccessToken(ctx, ownerID)
+
+	if accessToken.IsError() {
+		return mo.Err[*diagramitem.DiagramItem](accessToken.Error())
+	}
+
+	gist := s.githubClient.GetGist(ctx, github.GistID(gistItem.ID()), accessToken.MustGet())
+
+	if gist.IsError() {
+		return mo.Err[*diagramitem.DiagramItem](gist.Error())
+	}
+
+	content, ok := gist.MustGet().Content(gistItem.Title()).Get()
+
+	if !ok {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(errors.New("gist has no files")))
+	}
+
+	return diagramitem.New().
+		WithID(gistItem.ID()).
+		WithTitle(gistItem.Title()).
+		WithPlainText(content).
+		WithThumbnail(mo.EmptyableToOption(lo.FromPtr(gistItem.Thumbnail()))).
+		WithDiagram(gistItem.Diagram()).
+		WithIsBookmark(false).
+		WithCreatedAt(gistItem.CreatedAt()).
+		WithUpdatedAt(gistItem.UpdatedAt()).
+		Build()
+}
+
+// ownerAccessToken returns the GitHub token of the owner of a shared gist, so viewing it does not count against the
+// anonymous rate limit. It is empty when the owner is unknown or has not connected GitHub.
+func (s *Service) ownerAccessToken(ctx context.Context, ownerID string) mo.Result[string] {
+	if ownerID == "" {
+		return mo.Ok("")
+	}
+
+	var token mo.Option[*userModel.GithubToken]
+	err := s.transaction.DoReadOnly(values.WithUID(ctx, ownerID), func(ctx context.Context) error {
+		r := s.tokenRepo.Find(ctx, ownerID)
+
+		if e.GetCode(r.Error()) == e.NotFound {
+			return nil
+		}
+
+		if r.IsError() {
+			return r.Error()
+		}
+
+		token = mo.Some(r.MustGet())
+		return nil
 	})
 
-	if err != nil || !verifiedToken.Valid {
-		return mo.Err[*jwt.Token](e.URLExpiredError(err))
+	if err != nil {
+		return mo.Err[string](err)
 	}
 
-	return mo.Ok(verifiedToken)
+	if t, ok := token.Get(); ok {
+		return t.AccessToken()
+	}
+
+	return mo.Ok("")
 }
 
 func (s *Service) isPublicDiagramOwner(ctx context.Context, itemID string, ownerUserID string) mo.Result[bool] {
@@ -478,7 +956,7 @@ func (s *Service) isPublicDiagramOwner(ctx context.Context, itemID string, owner
 		return mo.Ok(true)
 	}
 
-	ret := s.repo.FindByID(ctx, ownerUserID, itemID, false)
+	ret := s.repo.FindByID(ctx, ownerUserID, itemID, false, v.MetadataOnly)
 	isOwner := ret.IsOk()
 
 	if e.GetCode(ret.Error()) == e.NotFound {
@@ -489,7 +967,12 @@ func (s *Service) isPublicDiagramOwner(ctx context.Context, itemID string, owner
 }
 
 func (s *Service) itemIDToShareID(itemID string) mo.Result[string] {
-	mac := hmac.New(sha256.New, []byte(s.shareEncryptKey))
+	return ShareID(string(s.shareEncryptKey), itemID)
+}
+
+// ShareID returns the key share conditions of itemID are stored under.
+func ShareID(shareEncryptKey, itemID string) mo.Result[string] {
+	mac := hmac.New(sha256.New, []byte(shareEncryptKey))
 	_, err := mac.Write([]byte(itemID))
 
 	if err != nil {
@@ -499,6 +982,23 @@ func (s *Service) itemIDToShareID(itemID string) mo.Result[string] {
 	return mo.Ok(hex.EncodeToString(mac.Sum(nil)))
 }
 
+func generateShareCode() (string, error) {
+	code := make([]byte, shareCodeLength)
+	max := big.NewInt(int64(len(shareCodeAlphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+
+		if err != nil {
+			return "", err
+		}
+
+		code[i] = shareCodeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
 func validIpList(ipList []string) []string {
 	allowIpList := []string{}
 	for _, ip := range ipList {
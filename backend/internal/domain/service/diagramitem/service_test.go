@@ -4,15 +4,22 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v4"
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	sm "github.com/harehare/textusm/internal/domain/model/share"
 	um "github.com/harehare/textusm/internal/domain/model/user"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
 	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/github"
 	"github.com/samber/mo"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
@@ -24,12 +31,16 @@ const (
 )
 
 func newTestService(mockItemRepo *MockItemRepository, mockShareRepo *MockShareRepository, mockUserRepo *MockUserRepository, mockTransaction *MockTransaction, shareEncryptKey string) *Service {
+	return newTestServiceWithGist(mockItemRepo, new(MockGistItemRepository), mockShareRepo, mockUserRepo, new(MockGithubTokenRepository), mockTransaction, shareEncryptKey, "")
+}
+
+func newTestServiceWithGist(mockItemRepo *MockItemRepository, mockGistRepo *MockGistItemRepository, mockShareRepo *MockShareRepository, mockUserRepo *MockUserRepository, mockTokenRepo *MockGithubTokenRepository, mockTransaction *MockTransaction, shareEncryptKey string, githubBaseURL string) *Service {
 	return NewService(
-		mockItemRepo, mockShareRepo, mockUserRepo, mockTransaction,
+		mockItemRepo, mockGistRepo, mockShareRepo, mockUserRepo, mockTokenRepo, mockTransaction,
 		"DUMMY_ID", "DUMMY_SECRET",
+		github.NewClient(github.BaseURL(githubBaseURL), ""),
 		ShareEncryptKey(shareEncryptKey),
-		EncryptPublicKey(testPubKey),
-		EncryptPrivateKey(testPriKey),
+		NewKeyring(EncryptPublicKey(testPubKey), EncryptPrivateKey(testPriKey), ""),
 	)
 }
 
@@ -37,6 +48,10 @@ type MockItemRepository struct {
 	mock.Mock
 }
 
+type MockGistItemRepository struct {
+	mock.Mock
+}
+
 type MockShareRepository struct {
 	mock.Mock
 }
@@ -45,17 +60,26 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
+type MockGithubTokenRepository struct {
+	mock.Mock
+}
+
 type MockTransaction struct {
 	mock.Mock
 }
 
-func (m *MockItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+func (m *MockItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
 	ret := m.Called(ctx, userID, itemID, isPublic)
 	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
 }
 
-func (m *MockItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, isBookmark bool, shouldLoadText bool) mo.Result[[]*diagramitem.DiagramItem] {
-	ret := m.Called(ctx, userID, offset, limit, isPublic, isBookmark, shouldLoadText)
+func (m *MockItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemIDs, isPublic)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit, isPublic, filter.IsBookmark, fields.Text, fields.Thumbnail)
 	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
 }
 
@@ -69,26 +93,91 @@ func (m *MockItemRepository) Delete(ctx context.Context, userID string, itemID s
 	return ret.Get(0).(mo.Result[bool])
 }
 
+func (m *MockItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	ret := m.Called(ctx, deletedBefore, limit)
+	return ret.Get(0).(mo.Result[[]itemRepo.TrashedItem])
+}
+
+func (m *MockItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, deletedAt)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockGistItemRepository) FindByID(ctx context.Context, userID string, gistID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
+	ret := m.Called(ctx, userID, gistID)
+	return ret.Get(0).(mo.Result[*gistitem.GistItem])
+}
+
+func (m *MockGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	ret := m.Called(ctx, userID, gistIDs)
+	return ret.Get(0).(mo.Result[[]*gistitem.GistItem])
+}
+
+func (m *MockGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	ret := m.Called(ctx, userID, offset, limit)
+	return ret.Get(0).(mo.Result[[]*gistitem.GistItem])
+}
+
+func (m *MockGistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
+	ret := m.Called(ctx, userID, item)
+	return ret.Get(0).(mo.Result[*gistitem.GistItem])
+}
+
+func (m *MockGistItemRepository) Delete(ctx context.Context, userID string, gistID string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, gistID)
+	return ret.Get(0).(mo.Result[bool])
+}
+
 func (m *MockShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
 	ret := m.Called(ctx, hashKey)
 	return ret.Get(0).(mo.Result[shareRepo.ShareValue])
 }
 
+func (m *MockShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	ret := m.Called(ctx, code)
+	return ret.Get(0).(mo.Result[shareRepo.ShareValue])
+}
+
 func (m *MockShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *sm.Share) mo.Result[bool] {
 	ret := m.Called(ctx, userID, hashKey, item, shareInfo)
 	return ret.Get(0).(mo.Result[bool])
 }
 
+func (m *MockShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *sm.Share) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey, item, shareInfo)
+	return ret.Get(0).(mo.Result[bool])
+}
+
 func (m *MockShareRepository) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
 	ret := m.Called(ctx, userID, hashKey)
 	return ret.Get(0).(mo.Result[bool])
 }
 
+func (m *MockShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	ret := m.Called(ctx, hashKey)
+	return ret.Get(0).(mo.Result[int])
+}
+
 func (m *MockUserRepository) Find(ctx context.Context, uid string) mo.Result[*um.User] {
 	ret := m.Called(ctx, uid)
 	return ret.Get(0).(mo.Result[*um.User])
 }
 
+func (m *MockUserRepository) Delete(ctx context.Context, uid string) error {
+	ret := m.Called(ctx, uid)
+	return ret.Get(0).(error)
+}
+
 func (m *MockUserRepository) RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
 	ret := m.Called(ctx, clientID, clientSecret, accessToken)
 	return ret.Get(0).(error)
@@ -99,10 +188,29 @@ func (m *MockUserRepository) RevokeToken(ctx context.Context) error {
 	return ret.Get(0).(error)
 }
 
+func (m *MockGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*um.GithubToken] {
+	ret := m.Called(ctx, uid)
+	return ret.Get(0).(mo.Result[*um.GithubToken])
+}
+
+func (m *MockGithubTokenRepository) Save(ctx context.Context, uid string, token *um.GithubToken) mo.Result[bool] {
+	ret := m.Called(ctx, uid, token)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	ret := m.Called(ctx, uid)
+	return ret.Get(0).(mo.Result[bool])
+}
+
 func (m *MockTransaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
 	return fn(ctx)
 }
 
+func (m *MockTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func TestFindDiagrams(t *testing.T) {
 	mockItemRepo := new(MockItemRepository)
 	mockShareRepo := new(MockShareRepository)
@@ -115,11 +223,10 @@ func TestFindDiagrams(t *testing.T) {
 	i := diagramitem.New().WithID("id").WithPlainText(baseText).Build().OrEmpty()
 	items := []*diagramitem.DiagramItem{i}
 
-	mockItemRepo.On("Find", ctx, "userID", 0, 10, false, false, false).Return(mo.Ok(items))
+	mockItemRepo.On("Find", ctx, "userID", 0, 10, false, false, false, false).Return(mo.Ok(items))
 
 	service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, mockTransaction, "")
-	fields := make(map[string]struct{})
-	ret := service.Find(ctx, 0, 10, false, false, fields)
+	ret := service.Find(ctx, 0, 10, false, v.ItemFilter{}, v.MetadataOnly)
 
 	if ret.IsError() {
 		t.Fatal("failed FindDiagrams")
@@ -140,7 +247,7 @@ func TestFindDiagram(t *testing.T) {
 	mockItemRepo.On("FindByID", ctx, "userID", "testID", false).Return(mo.Ok(item))
 
 	service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, mockTransaction, "")
-	ret := service.FindByID(ctx, "testID", false)
+	ret := service.FindByID(ctx, "testID", false, v.AllFields)
 
 	if ret.IsError() || ret.OrEmpty() == nil || ret.OrEmpty().Text() != baseText {
 		t.Fatal("failed FindDiagram")
@@ -178,7 +285,7 @@ func TestDeleteDiagram(t *testing.T) {
 	ctx = values.WithUID(ctx, "userID")
 
 	const encryptKey = "9cbe21a8914986ffd301e3403e14b61b52f7c348b0e3c65b762ae79118b4a4bc"
-	mockItemRepo.On("Delete", ctx, "userID", "testID", false).Return(mo.Ok(true))
+	mockItemRepo.On("Trash", ctx, "userID", "testID", mock.AnythingOfType("time.Time")).Return(mo.Ok(true))
 	mockShareRepo.On("Delete", ctx, "userID", "39fec4b1b30fc71f52616e4120ee953cff68fd0d0a4d37560a0567ae2941916b").Return(mo.Ok(true))
 
 	service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, mockTransaction, encryptKey)
@@ -186,6 +293,66 @@ func TestDeleteDiagram(t *testing.T) {
 	if err := service.Delete(ctx, "testID", false); err != nil {
 		t.Fatal("failed DeleteDiagram")
 	}
+
+	mockItemRepo.AssertNotCalled(t, "Delete", ctx, "userID", "testID", false)
+	mockShareRepo.AssertExpectations(t)
+}
+
+func TestRestoreDiagram(t *testing.T) {
+	mockItemRepo := new(MockItemRepository)
+	ctx := values.WithUID(context.Background(), "userID")
+	item := diagramitem.New().WithID("testID").Build().MustGet()
+
+	mockItemRepo.On("Restore", ctx, "userID", "testID").Return(mo.Ok(true))
+	mockItemRepo.On("Restore", ctx, "userID", "liveID").Return(mo.Ok(false))
+	mockItemRepo.On("FindByID", ctx, "userID", "testID", false).Return(mo.Ok(item))
+
+	service := newTestService(mockItemRepo, new(MockShareRepository), new(MockUserRepository), new(MockTransaction), "")
+
+	if ret := service.Restore(ctx, "testID"); ret.IsError() || ret.MustGet().ID() != "testID" {
+		t.Fatalf("Restore() = %v", ret)
+	}
+
+	if ret := service.Restore(ctx, "liveID"); e.GetCode(ret.Error()) != e.NotFound {
+		t.Fatalf("Restore() of an item outside the trash should not be found, got %v", ret.Error())
+	}
+}
+
+func TestPurgeDiagram(t *testing.T) {
+	mockItemRepo := new(MockItemRepository)
+	ctx := values.WithUID(context.Background(), "userID")
+	item := diagramitem.New().WithID("liveID").Build().MustGet()
+
+	trashed := diagramitem.New().WithID("trashedID").Build().MustGet().Trash(time.Now())
+
+	mockItemRepo.On("FindByID", ctx, "userID", "trashedID", false).Return(mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrNotDiagramOwner)))
+	mockItemRepo.On("FindByID", ctx, "userID", "missingID", false).Return(mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrNotDiagramOwner)))
+	mockItemRepo.On("FindByID", ctx, "userID", "failingID", false).Return(mo.Err[*diagramitem.DiagramItem](errors.New("connection reset")))
+	mockItemRepo.On("FindByID", ctx, "userID", "liveID", false).Return(mo.Ok(item))
+	mockItemRepo.On("FindTrash", ctx, "userID", 0, trashPageSize).Return(mo.Ok([]*diagramitem.DiagramItem{trashed}))
+	mockItemRepo.On("Delete", ctx, "userID", "trashedID", false).Return(mo.Ok(true))
+
+	service := newTestService(mockItemRepo, new(MockShareRepository), new(MockUserRepository), new(MockTransaction), "")
+
+	if err := service.Purge(ctx, "trashedID"); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	if err := service.Purge(ctx, "liveID"); e.GetCode(err) != e.InvalidParameter {
+		t.Fatalf("Purge() of an item outside the trash should fail, got %v", err)
+	}
+
+	if err := service.Purge(ctx, "missingID"); e.GetCode(err) != e.NotFound {
+		t.Fatalf("Purge() of an item in neither the items nor the trash should not be found, got %v", err)
+	}
+
+	if err := service.Purge(ctx, "failingID"); err == nil || err.Error() != "connection reset" {
+		t.Fatalf("Purge() should return the error of reading the item, got %v", err)
+	}
+
+	mockItemRepo.AssertNotCalled(t, "Delete", ctx, "userID", "liveID", false)
+	mockItemRepo.AssertNotCalled(t, "Delete", ctx, "userID", "missingID", false)
+	mockItemRepo.AssertNotCalled(t, "Delete", ctx, "userID", "failingID", false)
 }
 
 func TestShare(t *testing.T) {
@@ -216,23 +383,25 @@ func TestShare(t *testing.T) {
 	for _, test := range tests {
 		item := diagramitem.New().WithID(test.id).WithPlainText("").Build().OrEmpty()
 		a := []string{}
+		var saved *sm.Share
 		mockItemRepo.On("FindByID", ctx, "userID", test.id, false).Return(mo.Ok(item))
-		mockShareRepo.On("Save", ctx, "userID", test.hashKey, item, mock.Anything).Return(mo.Ok(true))
+		mockShareRepo.On("FindByCode", ctx, mock.Anything).Return(mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found"))))
+		mockShareRepo.On("Save", ctx, "userID", test.hashKey, item, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(4).(*sm.Share)
+		}).Return(mo.Ok(true))
 
 		service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, mockTransaction, test.key)
-		shareToken := service.Share(ctx, test.id, minExpSecond, "password", a, a)
+		shareCode := service.Share(ctx, test.id, v.LocationSystem, minExpSecond, "password", a, a, "", 0)
 
-		if shareToken.IsError() {
+		if shareCode.IsError() {
 			t.Fatal("failed ShareDiagram")
 		}
 
-		decoded, err := base64.RawURLEncoding.DecodeString(shareToken.OrEmpty())
-
-		if err != nil {
-			t.Fatal("decode failed")
+		if len(shareCode.OrEmpty()) != shareCodeLength || shareCode.OrEmpty() != saved.Code {
+			t.Fatalf("invalid share code %s", shareCode.OrEmpty())
 		}
 
-		verifiedToken := service.verifyToken(string(decoded))
+		verifiedToken := service.verifyToken(saved.Token)
 
 		if verifiedToken.IsError() || !verifiedToken.OrEmpty().Valid {
 			t.Fatal("invalid token")
@@ -243,6 +412,10 @@ func TestShare(t *testing.T) {
 		if claims["sub"] != test.hashKey {
 			t.Fatal("invalid sub")
 		}
+
+		if _, ok := verifiedToken.OrEmpty().Header["kid"].(string); !ok {
+			t.Fatal("missing kid header")
+		}
 	}
 }
 
@@ -348,12 +521,17 @@ func TestFindShareItem(t *testing.T) {
 			Email: test.email,
 		}
 		mockItemRepo.On("FindByID", mock.Anything, "userID", itemID, false).Return(mo.Ok(item))
-		mockShareRepo.On("Save", mock.Anything, "userID", mock.Anything, item, mock.Anything).Return(mo.Ok(true))
-		mockShareRepo.On("Find", mock.Anything, mock.Anything).Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &shareInfo}))
+		mockShareRepo.On("Save", mock.Anything, "userID", mock.Anything, item, mock.Anything).Run(func(args mock.Arguments) {
+			saved := args.Get(4).(*sm.Share)
+			shareInfo.Token = saved.Token
+			shareInfo.Code = saved.Code
+		}).Return(mo.Ok(true))
+		mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found")))).Once()
+		mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &shareInfo}))
 		mockUserRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(&user))
 		service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, mockTransaction, "")
-		shareId := service.Share(ctx, itemID, validExpSecond, test.inputPassword, test.allowIPList, test.allowEmailList)
-		ret := service.FindShareItem(ctx, shareId.OrEmpty(), test.inputPassword)
+		shareCode := service.Share(ctx, itemID, v.LocationSystem, validExpSecond, test.inputPassword, test.allowIPList, test.allowEmailList, "", 0)
+		ret := service.FindShareItem(ctx, shareCode.OrEmpty(), test.inputPassword)
 
 		if ret.IsOk() && test.isErr {
 			t.Fatal("test failed")
@@ -363,6 +541,274 @@ func TestFindShareItem(t *testing.T) {
 	}
 }
 
+func TestFindShareItemWithLegacyToken(t *testing.T) {
+	ctx := values.WithIP(values.WithUID(context.Background(), "userID"), "127.0.0.1")
+	item := diagramitem.New().WithID("testID").WithPlainText("test").Build().OrEmpty()
+	shareInfo := sm.Share{}
+
+	mockItemRepo := new(MockItemRepository)
+	mockShareRepo := new(MockShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockItemRepo.On("FindByID", mock.Anything, "userID", "testID", false).Return(mo.Ok(item))
+	mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found"))))
+	mockShareRepo.On("Save", mock.Anything, "userID", mock.Anything, item, mock.Anything).Run(func(args mock.Arguments) {
+		shareInfo.Token = args.Get(4).(*sm.Share).Token
+	}).Return(mo.Ok(true))
+	mockShareRepo.On("Find", mock.Anything, mock.Anything).Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &shareInfo}))
+	mockUserRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(&um.User{UID: "userID"}))
+
+	service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, new(MockTransaction), "")
+
+	if service.Share(ctx, "testID", v.LocationSystem, minExpSecond, "", []string{}, []string{}, "", 0).IsError() {
+		t.Fatal("failed ShareDiagram")
+	}
+
+	legacyToken := base64.RawURLEncoding.EncodeToString([]byte(shareInfo.Token))
+
+	if ret := service.FindShareItem(ctx, legacyToken, ""); ret.IsError() {
+		t.Fatal(ret.Error())
+	}
+}
+
+func TestShareWithSlug(t *testing.T) {
+	ctx := values.WithUID(context.Background(), "userID")
+	item := diagramitem.New().WithID("testID").WithPlainText("").Build().OrEmpty()
+	otherItem := diagramitem.New().WithID("otherID").WithPlainText("").Build().OrEmpty()
+	notFound := mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found")))
+
+	tests := []struct {
+		name    string
+		slug    string
+		found   mo.Result[shareRepo.ShareValue]
+		saved   mo.Result[bool]
+		wantErr bool
+	}{
+		{"available slug", "retro-2026", notFound, mo.Ok(true), false},
+		{"slug reused by the same item", "retro-2026", mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &sm.Share{}}), mo.Ok(true), false},
+		{"slug used by another item", "retro-2026", mo.Ok(shareRepo.ShareValue{DiagramItem: otherItem, ShareInfo: &sm.Share{}}), mo.Ok(true), true},
+		{"slug taken when saved", "retro-2026", notFound, mo.Err[bool](e.ErrShareCodeConflict), true},
+		{"invalid slug", "-a b", notFound, mo.Ok(true), true},
+		{"too short slug", "ab", notFound, mo.Ok(true), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockItemRepo := new(MockItemRepository)
+			mockShareRepo := new(MockShareRepository)
+			mockItemRepo.On("FindByID", ctx, "userID", "testID", false).Return(mo.Ok(item))
+			mockShareRepo.On("FindByCode", ctx, tt.slug).Return(tt.found)
+			mockShareRepo.On("Save", ctx, "userID", mock.Anything, item, mock.Anything).Return(tt.saved)
+
+			service := newTestService(mockItemRepo, mockShareRepo, new(MockUserRepository), new(MockTransaction), "")
+			ret := service.Share(ctx, "testID", v.LocationSystem, minExpSecond, "", []string{}, []string{}, tt.slug, 0)
+
+			if ret.IsError() != tt.wantErr {
+				t.Fatalf("Share() error = %v, wantErr %v", ret.Error(), tt.wantErr)
+			}
+
+			if tt.wantErr && e.GetCode(ret.Error()) != e.InvalidParameter {
+				t.Fatalf("Share() error code = %v, want %v", e.GetCode(ret.Error()), e.InvalidParameter)
+			}
+
+			if !tt.wantErr && ret.OrEmpty() != tt.slug {
+				t.Fatalf("Share() = %s, want %s", ret.OrEmpty(), tt.slug)
+			}
+		})
+	}
+}
+
+func TestFindShareItemWithViewLimit(t *testing.T) {
+	ctx := values.WithIP(values.WithUID(context.Background(), "userID"), "127.0.0.1")
+	item := diagramitem.New().WithID("testID").WithPlainText("test").Build().OrEmpty()
+
+	tests := []struct {
+		name      string
+		consumed  []mo.Result[int]
+		wantViews []bool
+	}{
+		{
+			name:      "single use",
+			consumed:  []mo.Result[int]{mo.Ok(0)},
+			wantViews: []bool{true, false},
+		},
+		{
+			name:      "concurrently consumed",
+			consumed:  []mo.Result[int]{mo.Err[int](e.NotFoundError(e.ErrShareViewsExceeded))},
+			wantViews: []bool{false},
+		},
+		{
+			name:      "three views",
+			consumed:  []mo.Result[int]{mo.Ok(2), mo.Ok(1), mo.Ok(0)},
+			wantViews: []bool{true, true, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shareInfo := sm.Share{}
+			mockItemRepo := new(MockItemRepository)
+			mockShareRepo := new(MockShareRepository)
+			mockUserRepo := new(MockUserRepository)
+			mockItemRepo.On("FindByID", mock.Anything, "userID", "testID", false).Return(mo.Ok(item))
+			mockShareRepo.On("Save", mock.Anything, "userID", mock.Anything, item, mock.Anything).Run(func(args mock.Arguments) {
+				shareInfo = *args.Get(4).(*sm.Share)
+			}).Return(mo.Ok(true))
+			mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found")))).Once()
+			mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &shareInfo}))
+			for _, consumed := range tt.consumed {
+				c := consumed
+				mockShareRepo.On("ConsumeView", mock.Anything, mock.Anything).Run(func(_ mock.Arguments) {
+					if v, err := c.Get(); err == nil {
+						shareInfo.RemainingViews = mo.Some(v)
+					}
+				}).Return(c).Once()
+			}
+			mockUserRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(&um.User{UID: "userID"}))
+
+			service := newTestService(mockItemRepo, mockShareRepo, mockUserRepo, new(MockTransaction), "")
+			code := service.Share(ctx, "testID", v.LocationSystem, minExpSecond, "", []string{}, []string{}, "", len(tt.consumed))
+
+			if code.IsError() {
+				t.Fatal(code.Error())
+			}
+
+			for i, want := range tt.wantViews {
+				ret := service.FindShareItem(ctx, code.OrEmpty(), "")
+
+				if ret.IsOk() != want {
+					t.Fatalf("view %d: got ok = %v, want %v", i, ret.IsOk(), want)
+				}
+
+				if !want && e.GetCode(ret.Error()) != e.URLExpired {
+					t.Fatalf("view %d: unexpected error %v", i, ret.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestShareWithInvalidMaxViews(t *testing.T) {
+	ctx := values.WithUID(context.Background(), "userID")
+	service := newTestService(new(MockItemRepository), new(MockShareRepository), new(MockUserRepository), new(MockTransaction), "")
+
+	for _, maxViews := range []int{-1, maxShareViews + 1} {
+		if service.Share(ctx, "testID", v.LocationSystem, minExpSecond, "", []string{}, []string{}, "", maxViews).IsOk() {
+			t.Fatalf("Share() with maxViews %d should fail", maxViews)
+		}
+	}
+}
+
+func TestShareGistItem(t *testing.T) {
+	ctx := values.WithIP(values.WithUID(context.Background(), "userID"), "127.0.0.1")
+	gistID := "aa5a315d61ae9438b18d0f7f7d0e0e5d"
+	gist := gistitem.New().WithID(gistID).WithTitle("roadmap").WithDiagram(v.DiagramUserStoryMap).Build().OrEmpty()
+	shareInfo := sm.Share{}
+	rateLimited := false
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("shared gist should be fetched with the owner's token, got %q", r.Header.Get("Authorization"))
+		}
+		if rateLimited {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/gists/"+gistID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"` + gistID + `","files":{"other":{"content":"other"},"roadmap":{"content":"# roadmap"}}}`))
+	}))
+	defer server.Close()
+
+	mockGistRepo := new(MockGistItemRepository)
+	mockShareRepo := new(MockShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockGithubTokenRepository)
+	mockGistRepo.On("FindByID", mock.Anything, "userID", gistID).Return(mo.Ok(gist))
+	mockShareRepo.On("SaveGist", mock.Anything, "userID", mock.Anything, gist, mock.Anything).Run(func(args mock.Arguments) {
+		shareInfo = *args.Get(4).(*sm.Share)
+	}).Return(mo.Ok(true))
+	mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found")))).Once()
+	mockShareRepo.On("FindByCode", mock.Anything, mock.Anything).Return(mo.Ok(shareRepo.ShareValue{GistItem: gist, ShareInfo: &shareInfo, UserID: "userID"}))
+	mockUserRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(&um.User{UID: "userID"}))
+	mockTokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(um.NewGithubToken("secret", "gist", time.Now()).MustGet()))
+
+	service := newTestServiceWithGist(new(MockItemRepository), mockGistRepo, mockShareRepo, mockUserRepo, mockTokenRepo, new(MockTransaction), "", server.URL)
+	code := service.Share(ctx, gistID, v.LocationGist, minExpSecond, "", []string{}, []string{}, "", 0)
+
+	if code.IsError() {
+		t.Fatal(code.Error())
+	}
+
+	item := service.FindShareItem(ctx, code.OrEmpty(), "")
+
+	if item.IsError() {
+		t.Fatal(item.Error())
+	}
+
+	if item.MustGet().Text() != "# roadmap" || item.MustGet().Diagram() != v.DiagramUserStoryMap {
+		t.Fatalf("unexpected shared gist %s", item.MustGet().Text())
+	}
+
+	mockShareRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	rateLimited = true
+	limited := service.FindShareItem(ctx, code.OrEmpty(), "")
+
+	if e.GetCode(limited.Error()) != e.RateLimited {
+		t.Fatalf("expected rate limited, got %v", limited.Error())
+	}
+}
+
+func TestIsViewable(t *testing.T) {
+	item := diagramitem.New().WithID("itemID").WithPlainText("test").Build().OrEmpty()
+	notFound := mo.Err[*diagramitem.DiagramItem](e.NotFoundError(errors.New("not found")))
+	noShare := mo.Err[shareRepo.ShareValue](e.NotFoundError(errors.New("not found")))
+	expired := time.Now().Add(-time.Minute).UnixMilli()
+
+	tests := []struct {
+		name   string
+		uid    string
+		public mo.Result[*diagramitem.DiagramItem]
+		share  mo.Result[shareRepo.ShareValue]
+		want   bool
+	}{
+		{"owner", "ownerID", notFound, noShare, true},
+		{"public item", "", mo.Ok(item), noShare, true},
+		{"private item", "otherID", notFound, noShare, false},
+		{"shared item", "", notFound, mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &sm.Share{}}), true},
+		{"expired share", "", notFound, mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &sm.Share{ExpireTime: expired}}), false},
+		{"share to another ip", "", notFound, mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &sm.Share{AllowIPList: []string{"10.0.0.1"}}}), false},
+		{"share to emails", "", notFound, mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &sm.Share{AllowEmailList: []string{"owner@example.com"}}}), false},
+		{"share with a password", "", notFound, mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &sm.Share{Password: genPassword("password")}}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := values.WithIP(context.Background(), "127.0.0.1")
+
+			if tt.uid != "" {
+				ctx = values.WithUID(ctx, tt.uid)
+			}
+
+			mockItemRepo := new(MockItemRepository)
+			mockShareRepo := new(MockShareRepository)
+			mockItemRepo.On("FindByID", mock.Anything, "ownerID", "itemID", true).Return(tt.public)
+			mockShareRepo.On("Find", mock.Anything, mock.Anything).Return(tt.share)
+
+			service := newTestService(mockItemRepo, mockShareRepo, new(MockUserRepository), new(MockTransaction), "key")
+			ret := service.IsViewable(ctx, "ownerID", "itemID", v.LocationSystem)
+
+			if ret.IsError() || ret.MustGet() != tt.want {
+				t.Errorf("IsViewable() = %v, %v, want %v", ret.OrEmpty(), ret.Error(), tt.want)
+			}
+		})
+	}
+}
+
 func genPassword(password string) string {
 	p, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(p)
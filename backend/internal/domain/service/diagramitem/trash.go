@@ -0,0 +1,95 @@
+package diagramitem
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+)
+
+// TrashRetention is how long items stay in the trash before they are deleted for good.
+type TrashRetention time.Duration
+
+// TrashPurgeInterval is how often expired items are purged from the trash. Nothing is purged by this process when it is zero.
+type TrashPurgeInterval time.Duration
+
+const (
+	trashPurgeBatchSize = 100
+	// trashPageSize is how many items in the trash are read at once when looking for one of them.
+	trashPageSize = 100
+)
+
+// TrashPurger deletes the items that have been in the trash for longer than the retention period.
+type TrashPurger struct {
+	repo        itemRepo.ItemRepository
+	transaction db.Transaction
+	retention   TrashRetention
+	interval    TrashPurgeInterval
+	now         func() time.Time
+}
+
+func NewTrashPurger(r itemRepo.ItemRepository, transaction db.Transaction, retention TrashRetention, interval TrashPurgeInterval) *TrashPurger {
+	return &TrashPurger{
+		repo:        r,
+		transaction: transaction,
+		retention:   retention,
+		interval:    interval,
+		now:         time.Now,
+	}
+}
+
+// Run purges expired items every purge interval until ctx is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(p.interval))
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PurgeExpired(ctx); err != nil {
+			slog.Error("failed to purge trash", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired deletes the expired items of every user and returns how many were deleted.
+func (p *TrashPurger) PurgeExpired(ctx context.Context) (int, error) {
+	deletedBefore := p.now().Add(-time.Duration(p.retention))
+	purged := 0
+
+	for {
+		expired := p.repo.FindExpiredTrash(ctx, deletedBefore, trashPurgeBatchSize)
+
+		if expired.IsError() {
+			return purged, expired.Error()
+		}
+
+		for _, item := range expired.MustGet() {
+			err := p.transaction.Do(values.WithUID(ctx, item.UserID), func(ctx context.Context) error {
+				return p.repo.Delete(ctx, item.UserID, item.ItemID, false).Error()
+			})
+
+			if err != nil {
+				return purged, fmt.Errorf("purge item %s: %w", item.ItemID, err)
+			}
+
+			purged++
+		}
+
+		if len(expired.MustGet()) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package diagramitem
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	mockItemRepo := new(MockItemRepository)
+	now := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	deletedBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := make([]itemRepo.TrashedItem, trashPurgeBatchSize)
+
+	for i := range batch {
+		batch[i] = itemRepo.TrashedItem{UserID: "user1", ItemID: fmt.Sprintf("item%d", i)}
+	}
+
+	mockItemRepo.On("FindExpiredTrash", mock.Anything, deletedBefore, trashPurgeBatchSize).Return(mo.Ok(batch)).Once()
+	mockItemRepo.On("FindExpiredTrash", mock.Anything, deletedBefore, trashPurgeBatchSize).Return(mo.Ok([]itemRepo.TrashedItem{{UserID: "user2", ItemID: "last"}})).Once()
+	mockItemRepo.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), false).Return(mo.Ok(true))
+
+	purger := NewTrashPurger(mockItemRepo, new(MockTransaction), TrashRetention(30*24*time.Hour), 0)
+	purger.now = func() time.Time { return now }
+
+	n, err := purger.PurgeExpired(context.Background())
+
+	if err != nil || n != trashPurgeBatchSize+1 {
+		t.Fatalf("PurgeExpired() = %d, %v", n, err)
+	}
+
+	mockItemRepo.AssertCalled(t, "Delete", mock.Anything, "user2", "last", false)
+	mockItemRepo.AssertExpectations(t)
+}
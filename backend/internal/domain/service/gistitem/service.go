@@ -2,41 +2,53 @@ package gistitem
 
 import (
 	"context"
+	"time"
 
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/db"
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	userModel "github.com/harehare/textusm/internal/domain/model/user"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
 	"github.com/harehare/textusm/internal/domain/service/user"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/harehare/textusm/internal/github"
+	"github.com/harehare/textusm/internal/merge"
 	"github.com/samber/mo"
 )
 
+const defaultGistFilename = "textusm"
+
 type Service struct {
 	repo         itemRepo.GistItemRepository
+	tokenRepo    userRepo.GithubTokenRepository
 	transaction  db.Transaction
 	clientID     github.ClientID
 	clientSecret github.ClientSecret
+	githubClient *github.Client
 }
 
-func NewService(r itemRepo.GistItemRepository, transaction db.Transaction, clientID github.ClientID, clientSecret github.ClientSecret) *Service {
+func NewService(r itemRepo.GistItemRepository, t userRepo.GithubTokenRepository, transaction db.Transaction, clientID github.ClientID, clientSecret github.ClientSecret, githubClient *github.Client) *Service {
 	return &Service{
 		repo:         r,
+		tokenRepo:    t,
 		transaction:  transaction,
 		clientID:     clientID,
 		clientSecret: clientSecret,
+		githubClient: githubClient,
 	}
 }
 
-func (s *Service) Find(ctx context.Context, offset, limit int) mo.Result[[]*gistitem.GistItem] {
+func (s *Service) Find(ctx context.Context, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
 	var items []*gistitem.GistItem
-	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
 		if err := user.IsAuthenticated(ctx); err != nil {
 			return err
 		}
 
 		userID := values.GetUID(ctx)
-		r := s.repo.Find(ctx, userID.OrEmpty(), offset, limit)
+		r := s.repo.Find(ctx, userID.OrEmpty(), offset, limit, fields)
 
 		if r.IsError() {
 			return r.Error()
@@ -53,15 +65,15 @@ func (s *Service) Find(ctx context.Context, offset, limit int) mo.Result[[]*gist
 	return mo.Ok(items)
 }
 
-func (s *Service) FindByID(ctx context.Context, gistID string) mo.Result[*gistitem.GistItem] {
+func (s *Service) FindByID(ctx context.Context, gistID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
 	var item *gistitem.GistItem
-	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
 		if err := user.IsAuthenticated(ctx); err != nil {
 			return err
 		}
 
 		userID := values.GetUID(ctx)
-		r := s.repo.FindByID(ctx, userID.OrEmpty(), gistID)
+		r := s.repo.FindByID(ctx, userID.OrEmpty(), gistID, fields)
 
 		if r.IsError() {
 			return r.Error()
@@ -78,6 +90,32 @@ func (s *Service) FindByID(ctx context.Context, gistID string) mo.Result[*gistit
 	return mo.Ok(item)
 }
 
+// FindByIDs reads the gist items of gistIDs in one round trip. Items that do not exist are left out.
+func (s *Service) FindByIDs(ctx context.Context, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	var items []*gistitem.GistItem
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
+		if err := user.IsAuthenticated(ctx); err != nil {
+			return err
+		}
+
+		userID := values.GetUID(ctx)
+		r := s.repo.FindByIDs(ctx, userID.OrEmpty(), gistIDs, fields)
+
+		if r.IsError() {
+			return r.Error()
+		}
+
+		items = r.MustGet()
+		return nil
+	})
+
+	if err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
+	}
+
+	return mo.Ok(items)
+}
+
 func (s *Service) Save(ctx context.Context, gist *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
 	var item *gistitem.GistItem
 	err := s.transaction.Do(ctx, func(ctx context.Context) error {
@@ -116,3 +154,272 @@ func (s *Service) Delete(ctx context.Context, gistID string) mo.Result[bool] {
 
 	return mo.Ok(true)
 }
+
+// Connect exchanges the OAuth code for an access token and stores it encrypted for the current user.
+func (s *Service) Connect(ctx context.Context, code string) error {
+	if err := user.IsAuthenticated(ctx); err != nil {
+		return err
+	}
+
+	if code == "" {
+		return e.InvalidParameterError(e.ErrInvalidOAuthCode)
+	}
+
+	token := s.githubClient.ExchangeCode(ctx, string(s.clientID), string(s.clientSecret), code)
+
+	if token.IsError() {
+		return token.Error()
+	}
+
+	githubToken := userModel.NewGithubToken(token.MustGet().AccessToken, token.MustGet().Scope, time.Now())
+
+	if githubToken.IsError() {
+		return githubToken.Error()
+	}
+
+	return s.transaction.Do(ctx, func(ctx context.Context) error {
+		return s.tokenRepo.Save(ctx, values.GetUID(ctx).OrEmpty(), githubToken.MustGet()).Error()
+	})
+}
+
+// Disconnect revokes the stored access token on GitHub and removes it.
+func (s *Service) Disconnect(ctx context.Context) error {
+	accessToken := s.accessToken(ctx)
+
+	if accessToken.IsError() {
+		return accessToken.Error()
+	}
+
+	if err := s.githubClient.RevokeToken(ctx, string(s.clientID), string(s.clientSecret), accessToken.MustGet()); err != nil && e.GetCode(err) != e.NotFound {
+		return err
+	}
+
+	return s.transaction.Do(ctx, func(ctx context.Context) error {
+		return s.tokenRepo.Delete(ctx, values.GetUID(ctx).OrEmpty()).Error()
+	})
+}
+
+// FindContent reads the diagram text of the gist with the stored access token.
+func (s *Service) FindContent(ctx context.Context, gistID string) mo.Result[string] {
+	item := s.FindByID(ctx, gistID, v.MetadataOnly)
+
+	if item.IsError() {
+		return mo.Err[string](item.Error())
+	}
+
+	accessToken := s.accessToken(ctx)
+
+	if accessToken.IsError() {
+		return mo.Err[string](accessToken.Error())
+	}
+
+	gist := s.githubClient.GetGist(ctx, github.GistID(item.MustGet().ID()), accessToken.MustGet())
+
+	if gist.IsError() {
+		return mo.Err[string](gist.Error())
+	}
+
+	return mo.Ok(gist.MustGet().Content(gistFilename(item.MustGet())).OrEmpty())
+}
+
+// Refresh reloads the gist from GitHub and caches its latest revision and content.
+func (s *Service) Refresh(ctx context.Context, gistID string) mo.Result[*gistitem.GistItem] {
+	item := s.FindByID(ctx, gistID, v.AllFields)
+
+	if item.IsError() {
+		return item
+	}
+
+	accessToken := s.accessToken(ctx)
+
+	if accessToken.IsError() {
+		return mo.Err[*gistitem.GistItem](accessToken.Error())
+	}
+
+	gist := s.githubClient.GetGist(ctx, github.GistID(item.MustGet().ID()), accessToken.MustGet())
+
+	if gist.IsError() {
+		return mo.Err[*gistitem.GistItem](gist.Error())
+	}
+
+	refreshed := withContent(item.MustGet(), item.MustGet().ID(), item.MustGet().URL(), gist.MustGet().Revision(), gist.MustGet().Content(gistFilename(item.MustGet())).OrEmpty())
+
+	if refreshed.IsError() {
+		return refreshed
+	}
+
+	return s.Save(ctx, refreshed.MustGet())
+}
+
+// SaveContent writes the diagram text to GitHub, creating a secret gist when the item is not saved yet.
+// baseRevision is the revision the text was edited from; when the gist changed upstream since then,
+// both sides are merged and a conflict is returned if they changed the same lines.
+func (s *Service) SaveContent(ctx context.Context, gist *gistitem.GistItem, text string, baseRevision string) mo.Result[*gistitem.GistItem] {
+	if err := user.IsAuthenticated(ctx); err != nil {
+		return mo.Err[*gistitem.GistItem](err)
+	}
+
+	accessToken := s.accessToken(ctx)
+
+	if accessToken.IsError() {
+		return mo.Err[*gistitem.GistItem](accessToken.Error())
+	}
+
+	var stored mo.Option[*gistitem.GistItem]
+	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+		r := s.repo.FindByID(ctx, values.GetUID(ctx).OrEmpty(), gist.ID(), v.AllFields)
+
+		if r.IsError() && e.GetCode(r.Error()) != e.NotFound {
+			return r.Error()
+		}
+
+		if item, err := r.Get(); err == nil {
+			stored = mo.Some(item)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return mo.Err[*gistitem.GistItem](err)
+	}
+
+	filename := gistFilename(gist)
+
+	if stored.IsAbsent() {
+		created := s.githubClient.CreateGist(ctx, accessToken.MustGet(), gist.Title(), filename, text)
+
+		if created.IsError() {
+			return mo.Err[*gistitem.GistItem](created.Error())
+		}
+
+		item := withContent(gist, created.MustGet().ID, created.MustGet().HTMLURL, created.MustGet().Revision(), text)
+
+		if item.IsError() {
+			return item
+		}
+
+		return s.Save(ctx, item.MustGet())
+	}
+
+	gistID := github.GistID(gist.ID())
+	upstream := s.githubClient.GetGist(ctx, gistID, accessToken.MustGet())
+
+	if upstream.IsError() {
+		return mo.Err[*gistitem.GistItem](upstream.Error())
+	}
+
+	if baseRevision == "" {
+		baseRevision = stored.MustGet().Revision()
+	}
+
+	if baseRevision != "" && upstream.MustGet().Revision() != baseRevision {
+		merged := s.merge(ctx, stored.MustGet(), gistID, filename, baseRevision, text, upstream.MustGet(), accessToken.MustGet())
+
+		if merged.IsError() {
+			return mo.Err[*gistitem.GistItem](merged.Error())
+		}
+
+		text = merged.MustGet()
+	}
+
+	updated := s.githubClient.UpdateGist(ctx, accessToken.MustGet(), gistID, filename, text)
+
+	if updated.IsError() {
+		return mo.Err[*gistitem.GistItem](updated.Error())
+	}
+
+	url := gist.URL()
+
+	if url == "" {
+		url = stored.MustGet().URL()
+	}
+
+	item := withContent(gist, gist.ID(), url, updated.MustGet().Revision(), text)
+
+	if item.IsError() {
+		return item
+	}
+
+	return s.Save(ctx, item.MustGet())
+}
+
+func (s *Service) merge(ctx context.Context, stored *gistitem.GistItem, gistID, filename, baseRevision, ours string, upstream *github.Gist, accessToken string) mo.Result[string] {
+	var base string
+
+	if text, ok := stored.Text().Get(); ok && stored.Revision() == baseRevision {
+		base = text
+	} else {
+		b := s.githubClient.GetGistRevision(ctx, gistID, baseRevision, accessToken)
+
+		if b.IsError() {
+			return mo.Err[string](b.Error())
+		}
+
+		base = b.MustGet().Content(filename).OrEmpty()
+	}
+
+	theirs := upstream.Content(filename).OrEmpty()
+	result := merge.ThreeWay(base, ours, theirs)
+
+	if result.Conflicted {
+		return mo.Err[string](e.ConflictError(&gistitem.Conflict{
+			BaseRevision: baseRevision,
+			Revision:     upstream.Revision(),
+			Base:         base,
+			Ours:         ours,
+			Theirs:       theirs,
+			Merged:       result.Text,
+		}))
+	}
+
+	return mo.Ok(result.Text)
+}
+
+func (s *Service) accessToken(ctx context.Context) mo.Result[string] {
+	var token *userModel.GithubToken
+	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+		if err := user.IsAuthenticated(ctx); err != nil {
+			return err
+		}
+
+		r := s.tokenRepo.Find(ctx, values.GetUID(ctx).OrEmpty())
+
+		if r.IsError() {
+			return r.Error()
+		}
+
+		token = r.MustGet()
+		return nil
+	})
+
+	if err != nil {
+		return mo.Err[string](err)
+	}
+
+	return token.AccessToken()
+}
+
+func withContent(gist *gistitem.GistItem, id, url, revision, text string) mo.Result[*gistitem.GistItem] {
+	return gistitem.New().
+		WithID(id).
+		WithURL(url).
+		WithTitle(gist.Title()).
+		WithThumbnail(mo.PointerToOption(gist.Thumbnail())).
+		WithDiagram(gist.Diagram()).
+		WithIsBookmark(gist.IsBookmark()).
+		WithCreatedAt(gist.CreatedAt()).
+		WithUpdatedAt(gist.UpdatedAt()).
+		WithRevision(revision).
+		WithPlainText(text).
+		Build()
+}
+
+// gistFilename matches the frontend, which names the gist file after the diagram title.
+func gistFilename(gist *gistitem.GistItem) string {
+	if gist.Title() == "" {
+		return defaultGistFilename
+	}
+
+	return gist.Title()
+}
@@ -2,11 +2,19 @@ package gistitem
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	userModel "github.com/harehare/textusm/internal/domain/model/user"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/github"
 	"github.com/samber/mo"
 	"github.com/stretchr/testify/mock"
 )
@@ -15,12 +23,17 @@ type MockGistItemRepository struct {
 	mock.Mock
 }
 
-func (m *MockGistItemRepository) FindByID(ctx context.Context, userID string, gistID string) mo.Result[*gistitem.GistItem] {
+func (m *MockGistItemRepository) FindByID(ctx context.Context, userID string, gistID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
 	ret := m.Called(ctx, userID, gistID)
 	return ret.Get(0).(mo.Result[*gistitem.GistItem])
 }
 
-func (m *MockGistItemRepository) Find(ctx context.Context, userID string, offset, limit int) mo.Result[[]*gistitem.GistItem] {
+func (m *MockGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	ret := m.Called(ctx, userID, gistIDs)
+	return ret.Get(0).(mo.Result[[]*gistitem.GistItem])
+}
+
+func (m *MockGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
 	ret := m.Called(ctx, userID, offset, limit)
 	return ret.Get(0).(mo.Result[[]*gistitem.GistItem])
 }
@@ -35,6 +48,25 @@ func (m *MockGistItemRepository) Delete(ctx context.Context, userID string, item
 	return ret.Get(0).(mo.Result[bool])
 }
 
+type MockGithubTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*userModel.GithubToken] {
+	ret := m.Called(ctx, uid)
+	return ret.Get(0).(mo.Result[*userModel.GithubToken])
+}
+
+func (m *MockGithubTokenRepository) Save(ctx context.Context, uid string, token *userModel.GithubToken) mo.Result[bool] {
+	ret := m.Called(ctx, uid, token)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	ret := m.Called(ctx, uid)
+	return ret.Get(0).(mo.Result[bool])
+}
+
 type MockTransaction struct {
 	mock.Mock
 }
@@ -43,8 +75,25 @@ func (m *MockTransaction) Do(ctx context.Context, fn func(ctx context.Context) e
 	return fn(ctx)
 }
 
+func (m *MockTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func newTestService(repo *MockGistItemRepository, tx *MockTransaction) *Service {
-	return NewService(repo, tx, "DUMMY_ID", "DUMMY_SECRET")
+	return NewService(repo, new(MockGithubTokenRepository), tx, "DUMMY_ID", "DUMMY_SECRET", github.NewClient("", ""))
+}
+
+func newGithubTestService(t *testing.T, repo *MockGistItemRepository, tokenRepo *MockGithubTokenRepository, handler http.Handler) *Service {
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewService(repo, tokenRepo, new(MockTransaction), "DUMMY_ID", "DUMMY_SECRET", github.NewClient(github.BaseURL(server.URL), github.OAuthBaseURL(server.URL)))
+}
+
+func storedToken(t *testing.T, accessToken string) *userModel.GithubToken {
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+	return userModel.NewGithubToken(accessToken, "gist", time.Now()).MustGet()
 }
 
 func authenticatedCtx() context.Context {
@@ -62,7 +111,7 @@ func TestFindGistItems(t *testing.T) {
 	repo.On("Find", ctx, "userID", 0, 10).Return(mo.Ok(items))
 
 	svc := newTestService(repo, tx)
-	ret := svc.Find(ctx, 0, 10)
+	ret := svc.Find(ctx, 0, 10, v.AllFields)
 
 	if ret.IsError() {
 		t.Fatalf("Find() error: %v", ret.Error())
@@ -78,7 +127,7 @@ func TestFindGistItemsUnauthenticated(t *testing.T) {
 	ctx := context.Background()
 
 	svc := newTestService(repo, tx)
-	ret := svc.Find(ctx, 0, 10)
+	ret := svc.Find(ctx, 0, 10, v.AllFields)
 
 	if ret.IsOk() {
 		t.Error("Find() without auth should return error")
@@ -93,7 +142,7 @@ func TestFindGistItemsRepositoryError(t *testing.T) {
 	repo.On("Find", ctx, "userID", 0, 10).Return(mo.Err[[]*gistitem.GistItem](errors.New("db error")))
 
 	svc := newTestService(repo, tx)
-	ret := svc.Find(ctx, 0, 10)
+	ret := svc.Find(ctx, 0, 10, v.AllFields)
 
 	if ret.IsOk() {
 		t.Error("Find() should propagate repository error")
@@ -109,7 +158,7 @@ func TestFindGistItemByID(t *testing.T) {
 	repo.On("FindByID", ctx, "userID", "gist-id").Return(mo.Ok(item))
 
 	svc := newTestService(repo, tx)
-	ret := svc.FindByID(ctx, "gist-id")
+	ret := svc.FindByID(ctx, "gist-id", v.AllFields)
 
 	if ret.IsError() {
 		t.Fatalf("FindByID() error: %v", ret.Error())
@@ -124,7 +173,7 @@ func TestFindGistItemByIDUnauthenticated(t *testing.T) {
 	tx := new(MockTransaction)
 
 	svc := newTestService(repo, tx)
-	ret := svc.FindByID(context.Background(), "gist-id")
+	ret := svc.FindByID(context.Background(), "gist-id", v.AllFields)
 
 	if ret.IsOk() {
 		t.Error("FindByID() without auth should return error")
@@ -139,7 +188,7 @@ func TestFindGistItemByIDNotFound(t *testing.T) {
 	repo.On("FindByID", ctx, "userID", "missing").Return(mo.Err[*gistitem.GistItem](errors.New("not found")))
 
 	svc := newTestService(repo, tx)
-	ret := svc.FindByID(ctx, "missing")
+	ret := svc.FindByID(ctx, "missing", v.AllFields)
 
 	if ret.IsOk() {
 		t.Error("FindByID() for missing item should return error")
@@ -238,3 +287,270 @@ func TestDeleteGistItemRepositoryError(t *testing.T) {
 		t.Error("Delete() should propagate repository error")
 	}
 }
+
+func TestConnectGithub(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if body["code"] != "valid" || body["client_id"] != "DUMMY_ID" || body["client_secret"] != "DUMMY_SECRET" {
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"access_token":"gho_token","scope":"gist","token_type":"bearer"}`))
+	})
+
+	var saved *userModel.GithubToken
+	tokenRepo.On("Save", mock.Anything, "userID", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(2).(*userModel.GithubToken)
+	}).Return(mo.Ok(true))
+
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	if err := svc.Connect(ctx, "valid"); err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+
+	if saved.EncryptedAccessToken() == "gho_token" || saved.AccessToken().OrEmpty() != "gho_token" {
+		t.Error("Connect() should store the access token encrypted")
+	}
+
+	if err := svc.Connect(ctx, "invalid"); e.GetCode(err) != e.InvalidParameter {
+		t.Errorf("Connect() with invalid code should return invalid parameter, got %v", err)
+	}
+}
+
+func TestDisconnectGithubRevokeFailed(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /applications/DUMMY_ID/token", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(storedToken(t, "gho_token")))
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	if err := svc.Disconnect(ctx); err == nil {
+		t.Fatal("Disconnect() should fail when GitHub rejects the revocation")
+	}
+
+	tokenRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestFindGistContent(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+	item := gistitem.New().WithID("gistid").WithTitle("roadmap").Build().OrEmpty()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gists/gistid", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token gho_token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"gistid","files":{"roadmap":{"content":"# roadmap"}}}`))
+	})
+
+	repo.On("FindByID", mock.Anything, "userID", "gistid").Return(mo.Ok(item))
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(storedToken(t, "gho_token")))
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	content := svc.FindContent(ctx, "gistid")
+
+	if content.IsError() {
+		t.Fatalf("FindContent() error: %v", content.Error())
+	}
+
+	if content.MustGet() != "# roadmap" {
+		t.Errorf("FindContent() = %s", content.MustGet())
+	}
+}
+
+func TestSaveGistContentCreatesGist(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+	item := gistitem.New().WithID("").WithTitle("roadmap").Build().OrEmpty()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /gists", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Files map[string]struct {
+				Content string `json:"content"`
+			} `json:"files"`
+			Public bool `json:"public"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if body.Public || body.Files["roadmap"].Content != "# roadmap" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"created","html_url":"https://gist.github.com/created","history":[{"version":"r1"}],"files":{"roadmap":{"content":"# roadmap"}}}`))
+	})
+
+	repo.On("FindByID", mock.Anything, "userID", item.ID()).Return(mo.Err[*gistitem.GistItem](e.NotFoundError(errors.New("not found"))))
+	var created *gistitem.GistItem
+	repo.On("Save", mock.Anything, "userID", mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(2).(*gistitem.GistItem)
+	}).Return(mo.Ok(item))
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(storedToken(t, "gho_token")))
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	saved := svc.SaveContent(ctx, item, "# roadmap", "")
+
+	if saved.IsError() {
+		t.Fatalf("SaveContent() error: %v", saved.Error())
+	}
+
+	if created.ID() != "created" || created.URL() != "https://gist.github.com/created" || created.Title() != "roadmap" || created.Revision() != "r1" {
+		t.Errorf("SaveContent() should save the created gist, got %s %s", created.ID(), created.URL())
+	}
+}
+
+func TestSaveGistContentNotConnected(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Err[*userModel.GithubToken](e.NotFoundError(e.ErrGithubNotConnected)))
+	svc := newGithubTestService(t, repo, tokenRepo, http.NotFoundHandler())
+
+	saved := svc.SaveContent(ctx, gistitem.New().WithID("id").Build().OrEmpty(), "text", "")
+
+	if !errors.Is(saved.Error(), e.ErrGithubNotConnected) {
+		t.Errorf("SaveContent() without token should fail, got %v", saved.Error())
+	}
+}
+
+func upstreamGist(revision, content string) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"id":      "gistid",
+		"history": []map[string]string{{"version": revision}},
+		"files":   map[string]interface{}{"roadmap": map[string]string{"content": content}},
+	})
+	return string(b)
+}
+
+func TestRefreshGist(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+	item := gistitem.New().WithID("gistid").WithTitle("roadmap").Build().OrEmpty()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gists/gistid", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(upstreamGist("r2", "# roadmap")))
+	})
+
+	var saved *gistitem.GistItem
+	repo.On("FindByID", mock.Anything, "userID", "gistid").Return(mo.Ok(item))
+	repo.On("Save", mock.Anything, "userID", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(2).(*gistitem.GistItem)
+	}).Return(mo.Ok(item))
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(storedToken(t, "gho_token")))
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	if r := svc.Refresh(ctx, "gistid"); r.IsError() {
+		t.Fatalf("Refresh() error: %v", r.Error())
+	}
+
+	if saved.Revision() != "r2" || saved.Text().OrEmpty() != "# roadmap" {
+		t.Errorf("Refresh() should cache the latest revision, got %s %s", saved.Revision(), saved.Text().OrEmpty())
+	}
+}
+
+func TestSaveGistContentMergesUpstreamChanges(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+	t.Setenv("ENCRYPT_KEY", "0123456789abcdef0123456789abcdef")
+	stored := gistitem.New().WithID("gistid").WithTitle("roadmap").WithRevision("r1").WithPlainText("# roadmap\nactivity\n    task").Build().OrEmpty()
+
+	var pushed string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gists/gistid", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(upstreamGist("r2", "# new roadmap\nactivity\n    task")))
+	})
+	mux.HandleFunc("PATCH /gists/gistid", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Files map[string]struct {
+				Content string `json:"content"`
+			} `json:"files"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		pushed = body.Files["roadmap"].Content
+		_, _ = w.Write([]byte(upstreamGist("r3", pushed)))
+	})
+
+	var saved *gistitem.GistItem
+	repo.On("FindByID", mock.Anything, "userID", "gistid").Return(mo.Ok(stored))
+	repo.On("Save", mock.Anything, "userID", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(2).(*gistitem.GistItem)
+	}).Return(mo.Ok(stored))
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(storedToken(t, "gho_token")))
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	r := svc.SaveContent(ctx, gistitem.New().WithID("gistid").WithTitle("roadmap").Build().OrEmpty(), "# roadmap\nactivity\n    task\n    task2", "r1")
+
+	if r.IsError() {
+		t.Fatalf("SaveContent() error: %v", r.Error())
+	}
+
+	expected := "# new roadmap\nactivity\n    task\n    task2"
+
+	if pushed != expected {
+		t.Errorf("SaveContent() pushed %q, want %q", pushed, expected)
+	}
+
+	if saved.Revision() != "r3" || saved.Text().OrEmpty() != expected {
+		t.Errorf("SaveContent() should cache the merged revision, got %s", saved.Revision())
+	}
+}
+
+func TestSaveGistContentConflict(t *testing.T) {
+	repo := new(MockGistItemRepository)
+	tokenRepo := new(MockGithubTokenRepository)
+	ctx := authenticatedCtx()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gists/gistid", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(upstreamGist("r2", "# theirs")))
+	})
+	mux.HandleFunc("GET /gists/gistid/r1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(upstreamGist("r1", "# base")))
+	})
+	mux.HandleFunc("PATCH /gists/gistid", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("conflicting changes should not be pushed")
+	})
+
+	stored := gistitem.New().WithID("gistid").WithTitle("roadmap").Build().OrEmpty()
+	repo.On("FindByID", mock.Anything, "userID", "gistid").Return(mo.Ok(stored))
+	tokenRepo.On("Find", mock.Anything, "userID").Return(mo.Ok(storedToken(t, "gho_token")))
+	svc := newGithubTestService(t, repo, tokenRepo, mux)
+
+	r := svc.SaveContent(ctx, stored, "# ours", "r1")
+
+	var conflict *gistitem.Conflict
+	if e.GetCode(r.Error()) != e.Conflict || !errors.As(r.Error(), &conflict) {
+		t.Fatalf("SaveContent() should return a conflict, got %v", r.Error())
+	}
+
+	if conflict.Base != "# base" || conflict.Revision != "r2" || conflict.Merged != "<<<<<<< ours\n# ours\n=======\n# theirs\n>>>>>>> theirs" {
+		t.Errorf("unexpected conflict %+v", conflict)
+	}
+
+	repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+}
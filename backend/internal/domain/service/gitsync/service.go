@@ -0,0 +1,303 @@
+package gitsync
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gitsync"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	"github.com/harehare/textusm/internal/domain/service/user"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/git"
+	"github.com/harehare/textusm/internal/util"
+	"github.com/samber/mo"
+)
+
+type Service struct {
+	repo        itemRepo.ItemRepository
+	transaction db.Transaction
+	git         *git.Repository
+}
+
+func NewService(r itemRepo.ItemRepository, transaction db.Transaction, repository *git.Repository) *Service {
+	return &Service{
+		repo:        r,
+		transaction: transaction,
+		git:         repository,
+	}
+}
+
+// Sync mirrors the user's linked items to files under their directory of the repository and pulls back files edited there.
+// itemIDs are linked before syncing. Files with a known diagram extension that are not linked yet are imported as new items.
+// An item changed on both sides since the last sync is reported as a conflict and left untouched on both sides.
+func (s *Service) Sync(ctx context.Context, itemIDs []string) mo.Result[[]*gitsync.Result] {
+	if err := user.IsAuthenticated(ctx); err != nil {
+		return mo.Err[[]*gitsync.Result](err)
+	}
+
+	if !s.git.Enabled() {
+		return mo.Err[[]*gitsync.Result](e.NotFoundError(e.ErrGitSyncNotEnabled))
+	}
+
+	userID := values.GetUID(ctx).OrEmpty()
+	// Items imported by an attempt whose push was rejected are kept, so a retry must not import their files again.
+	imported := map[string]string{}
+	var results []*gitsync.Result
+
+	err := s.git.Update(ctx, "Sync diagrams of "+userID, func(tree *git.Tree) error {
+		manifestPath := path.Join(userID, gitsync.ManifestFile)
+		manifest := gitsync.NewManifest()
+		content := tree.ReadFile(manifestPath)
+
+		if content.IsError() {
+			return content.Error()
+		}
+
+		if c, ok := content.MustGet().Get(); ok {
+			m := gitsync.ParseManifest(c, userID)
+
+			if m.IsError() {
+				return m.Error()
+			}
+
+			manifest = m.MustGet()
+		}
+
+		err := s.transaction.Do(ctx, func(ctx context.Context) error {
+			r := s.sync(ctx, tree, userID, manifest, itemIDs, imported)
+
+			if r.IsError() {
+				return r.Error()
+			}
+
+			results = r.MustGet()
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return tree.WriteFile(manifestPath, manifest.String())
+	})
+
+	if err != nil {
+		return mo.Err[[]*gitsync.Result](err)
+	}
+
+	return mo.Ok(results)
+}
+
+func (s *Service) sync(ctx context.Context, tree *git.Tree, userID string, manifest *gitsync.Manifest, itemIDs []string, imported map[string]string) mo.Result[[]*gitsync.Result] {
+	exists := func(p string) bool {
+		return tree.ReadFile(p).OrEmpty().IsPresent()
+	}
+
+	for p, itemID := range imported {
+		if manifest.ItemID(p).IsAbsent() {
+			manifest.Items[itemID] = &gitsync.Entry{Path: p}
+		}
+	}
+
+	for _, itemID := range itemIDs {
+		if _, ok := manifest.Items[itemID]; ok {
+			continue
+		}
+
+		item := s.repo.FindByID(ctx, userID, itemID, false, v.AllFields)
+
+		if item.IsError() {
+			return mo.Err[[]*gitsync.Result](item.Error())
+		}
+
+		manifest.Track(itemID, userID, item.MustGet().Title(), item.MustGet().Diagram(), exists)
+	}
+
+	tracked := make([]string, 0, len(manifest.Items))
+
+	for itemID := range manifest.Items {
+		tracked = append(tracked, itemID)
+	}
+
+	slices.Sort(tracked)
+	results := make([]*gitsync.Result, 0, len(tracked))
+
+	for _, itemID := range tracked {
+		r := s.syncItem(ctx, tree, userID, manifest, itemID)
+
+		if r.IsError() {
+			return mo.Err[[]*gitsync.Result](r.Error())
+		}
+
+		results = append(results, r.MustGet())
+	}
+
+	files := tree.ListFiles(userID)
+
+	if files.IsError() {
+		return mo.Err[[]*gitsync.Result](files.Error())
+	}
+
+	names := files.MustGet()
+	slices.Sort(names)
+
+	for _, name := range names {
+		p := path.Join(userID, name)
+		diagram, ok := v.DiagramFromExtension(path.Ext(name))
+
+		if !ok || manifest.ItemID(p).IsPresent() {
+			continue
+		}
+
+		r := s.importFile(ctx, tree, userID, manifest, p, diagram)
+
+		if r.IsError() {
+			return mo.Err[[]*gitsync.Result](r.Error())
+		}
+
+		imported[p] = r.MustGet().ItemID
+		results = append(results, r.MustGet())
+	}
+
+	return mo.Ok(results)
+}
+
+func (s *Service) syncItem(ctx context.Context, tree *git.Tree, userID string, manifest *gitsync.Manifest, itemID string) mo.Result[*gitsync.Result] {
+	entry := manifest.Items[itemID]
+	file := tree.ReadFile(entry.Path)
+
+	if file.IsError() {
+		return mo.Err[*gitsync.Result](file.Error())
+	}
+
+	item := s.repo.FindByID(ctx, userID, itemID, false, v.AllFields)
+
+	if item.IsError() {
+		if e.GetCode(item.Error()) != e.NotFound {
+			return mo.Err[*gitsync.Result](item.Error())
+		}
+
+		return s.removeFile(tree, manifest, itemID, file.MustGet())
+	}
+
+	local := item.MustGet().Text()
+	localHash := gitsync.Hash(local)
+	remote, ok := file.MustGet().Get()
+
+	if !ok {
+		if entry.Hash != "" && localHash == entry.Hash {
+			delete(manifest.Items, itemID)
+			return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusDeleted, "file was removed from the repository"))
+		}
+
+		return s.writeFile(tree, entry, itemID, local)
+	}
+
+	remoteHash := gitsync.Hash(remote)
+
+	switch {
+	case localHash == remoteHash:
+		entry.Hash = localHash
+		return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusUnchanged, ""))
+	case remoteHash == entry.Hash:
+		return s.writeFile(tree, entry, itemID, local)
+	case localHash == entry.Hash:
+		saved := s.updateItem(ctx, userID, item.MustGet(), remote)
+
+		if saved.IsError() {
+			return mo.Err[*gitsync.Result](saved.Error())
+		}
+
+		entry.Hash = remoteHash
+		return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusPulled, ""))
+	default:
+		slog.Info("git sync conflict", "itemID", itemID, "path", entry.Path)
+		return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusConflict, "item and file both changed since the last sync"))
+	}
+}
+
+func (s *Service) writeFile(tree *git.Tree, entry *gitsync.Entry, itemID, text string) mo.Result[*gitsync.Result] {
+	if err := tree.WriteFile(entry.Path, text); err != nil {
+		return mo.Err[*gitsync.Result](err)
+	}
+
+	entry.Hash = gitsync.Hash(text)
+	return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusPushed, ""))
+}
+
+// removeFile untracks an item that was deleted, removing its file unless it was edited in the repository meanwhile.
+func (s *Service) removeFile(tree *git.Tree, manifest *gitsync.Manifest, itemID string, file mo.Option[string]) mo.Result[*gitsync.Result] {
+	entry := manifest.Items[itemID]
+
+	if remote, ok := file.Get(); ok && gitsync.Hash(remote) != entry.Hash {
+		return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusConflict, "item was deleted but the file changed since the last sync"))
+	}
+
+	if err := tree.RemoveFile(entry.Path); err != nil {
+		return mo.Err[*gitsync.Result](err)
+	}
+
+	delete(manifest.Items, itemID)
+	return mo.Ok(gitsync.NewResult(itemID, entry.Path, v.SyncStatusDeleted, "item was deleted"))
+}
+
+func (s *Service) importFile(ctx context.Context, tree *git.Tree, userID string, manifest *gitsync.Manifest, p string, diagram v.Diagram) mo.Result[*gitsync.Result] {
+	content := tree.ReadFile(p)
+
+	if content.IsError() {
+		return mo.Err[*gitsync.Result](content.Error())
+	}
+
+	text := content.MustGet().OrEmpty()
+	now := time.Now()
+	item := diagramitem.New().
+		WithID("").
+		WithTitle(strings.TrimSuffix(path.Base(p), path.Ext(p))).
+		WithPlainText(text).
+		WithDiagram(diagram).
+		WithCreatedAt(now).
+		WithUpdatedAt(now).
+		Build()
+
+	if item.IsError() {
+		return mo.Err[*gitsync.Result](item.Error())
+	}
+
+	saved := s.repo.Save(ctx, userID, item.MustGet(), false)
+
+	if saved.IsError() {
+		return mo.Err[*gitsync.Result](saved.Error())
+	}
+
+	itemID := saved.MustGet().ID()
+	manifest.Items[itemID] = &gitsync.Entry{Path: p, Hash: gitsync.Hash(text)}
+	return mo.Ok(gitsync.NewResult(itemID, p, v.SyncStatusCreated, ""))
+}
+
+func (s *Service) updateItem(ctx context.Context, userID string, item *diagramitem.DiagramItem, text string) mo.Result[*diagramitem.DiagramItem] {
+	updated := diagramitem.New().
+		WithID(item.ID()).
+		WithTitle(item.Title()).
+		WithPlainText(text).
+		WithThumbnail(util.ToOption(item.Thumbnail())).
+		WithDiagram(item.Diagram()).
+		WithIsPublic(item.IsPublic()).
+		WithIsBookmark(item.IsBookmark()).
+		WithCreatedAt(item.CreatedAt()).
+		WithUpdatedAt(time.Now()).
+		Build()
+
+	if updated.IsError() {
+		return updated
+	}
+
+	return s.repo.Save(ctx, userID, updated.MustGet(), false)
+}
@@ -0,0 +1,328 @@
+package gitsync
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gitsync"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/git"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockItemRepository struct {
+	mock.Mock
+}
+
+func (m *MockItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemID, isPublic)
+	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemIDs, isPublic)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit, isPublic, filter.IsBookmark, fields.Text, fields.Thumbnail)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, item, isPublic)
+	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, isPublic)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	ret := m.Called(ctx, deletedBefore, limit)
+	return ret.Get(0).(mo.Result[[]itemRepo.TrashedItem])
+}
+
+func (m *MockItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, deletedAt)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+type MockTransaction struct {
+	mock.Mock
+}
+
+func (m *MockTransaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func authenticatedCtx() context.Context {
+	return values.WithUID(context.Background(), "userID")
+}
+
+func newBareRepository(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+
+	return dir
+}
+
+func newTestService(repo *MockItemRepository, remote string, t *testing.T) *Service {
+	return NewService(repo, new(MockTransaction), git.NewRepository(git.Remote(remote), "", git.WorkDir(t.TempDir())))
+}
+
+// push edits the remote the way another clone of the repository would.
+func push(t *testing.T, remote string, fn func(tree *git.Tree) error) {
+	t.Helper()
+
+	if err := git.NewRepository(git.Remote(remote), "", git.WorkDir(t.TempDir())).Update(context.Background(), "edit", fn); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+}
+
+func readFile(t *testing.T, remote, path string) mo.Option[string] {
+	t.Helper()
+	var content mo.Option[string]
+
+	push(t, remote, func(tree *git.Tree) error {
+		content = tree.ReadFile(path).MustGet()
+		return nil
+	})
+
+	return content
+}
+
+func newItem(text string) *diagramitem.DiagramItem {
+	return diagramitem.New().
+		WithID("item1").
+		WithTitle("Release plan").
+		WithPlainText(text).
+		WithDiagram(v.DiagramUserStoryMap).
+		WithCreatedAt(time.Now()).
+		WithUpdatedAt(time.Now()).
+		Build().
+		MustGet()
+}
+
+func statusOf(results []*gitsync.Result, itemID string) v.SyncStatus {
+	for _, r := range results {
+		if r.ItemID == itemID {
+			return r.Status
+		}
+	}
+
+	return ""
+}
+
+func TestSyncPushesLinkedItem(t *testing.T) {
+	remote := newBareRepository(t)
+	repo := new(MockItemRepository)
+	ctx := authenticatedCtx()
+
+	repo.On("FindByID", ctx, "userID", "item1", false).Return(mo.Ok(newItem("a\n    b")))
+
+	ret := newTestService(repo, remote, t).Sync(ctx, []string{"item1"})
+
+	if ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	results := ret.MustGet()
+
+	if len(results) != 1 || results[0].Status != v.SyncStatusPushed || results[0].Path != "userID/Release plan.usm" {
+		t.Fatalf("Sync() results = %+v", results[0])
+	}
+
+	if got := readFile(t, remote, "userID/Release plan.usm").OrEmpty(); got != "a\n    b" {
+		t.Errorf("file content = %q", got)
+	}
+
+	if readFile(t, remote, "userID/"+gitsync.ManifestFile).IsAbsent() {
+		t.Error("manifest should be pushed")
+	}
+}
+
+func TestSyncPullsFileEditedInRepository(t *testing.T) {
+	remote := newBareRepository(t)
+	repo := new(MockItemRepository)
+	ctx := authenticatedCtx()
+	svc := newTestService(repo, remote, t)
+
+	repo.On("FindByID", ctx, "userID", "item1", false).Return(mo.Ok(newItem("a")))
+
+	if ret := svc.Sync(ctx, []string{"item1"}); ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	push(t, remote, func(tree *git.Tree) error {
+		return tree.WriteFile("userID/Release plan.usm", "a\n    b")
+	})
+
+	var saved *diagramitem.DiagramItem
+	repo.On("Save", ctx, "userID", mock.Anything, false).Run(func(args mock.Arguments) {
+		saved = args.Get(2).(*diagramitem.DiagramItem)
+	}).Return(mo.Ok(newItem("a\n    b")))
+
+	ret := svc.Sync(ctx, nil)
+
+	if ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	if got := statusOf(ret.MustGet(), "item1"); got != v.SyncStatusPulled {
+		t.Errorf("status = %s, want PULLED", got)
+	}
+
+	if saved == nil || saved.ID() != "item1" || saved.Text() != "a\n    b" || saved.Title() != "Release plan" {
+		t.Errorf("saved item = %+v", saved)
+	}
+}
+
+func TestSyncReportsConflict(t *testing.T) {
+	remote := newBareRepository(t)
+	repo := new(MockItemRepository)
+	ctx := authenticatedCtx()
+	svc := newTestService(repo, remote, t)
+
+	repo.On("FindByID", ctx, "userID", "item1", false).Return(mo.Ok(newItem("a"))).Twice()
+
+	if ret := svc.Sync(ctx, []string{"item1"}); ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	push(t, remote, func(tree *git.Tree) error {
+		return tree.WriteFile("userID/Release plan.usm", "from repository")
+	})
+	repo.On("FindByID", ctx, "userID", "item1", false).Return(mo.Ok(newItem("from textusm")))
+
+	ret := svc.Sync(ctx, nil)
+
+	if ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	if got := statusOf(ret.MustGet(), "item1"); got != v.SyncStatusConflict {
+		t.Errorf("status = %s, want CONFLICT", got)
+	}
+
+	if got := readFile(t, remote, "userID/Release plan.usm").OrEmpty(); got != "from repository" {
+		t.Errorf("conflicting file should be left as is, got %q", got)
+	}
+
+	repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSyncImportsNewFile(t *testing.T) {
+	remote := newBareRepository(t)
+	repo := new(MockItemRepository)
+	ctx := authenticatedCtx()
+
+	push(t, remote, func(tree *git.Tree) error {
+		return tree.WriteFile("userID/schema.erd", "users\n    id int")
+	})
+
+	var saved *diagramitem.DiagramItem
+	repo.On("Save", ctx, "userID", mock.Anything, false).Run(func(args mock.Arguments) {
+		saved = args.Get(2).(*diagramitem.DiagramItem)
+	}).Return(mo.Ok(newItem("users\n    id int")))
+
+	ret := newTestService(repo, remote, t).Sync(ctx, nil)
+
+	if ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	if got := statusOf(ret.MustGet(), "item1"); got != v.SyncStatusCreated {
+		t.Errorf("status = %s, want CREATED", got)
+	}
+
+	if saved == nil || saved.Diagram() != v.DiagramErDiagram || saved.Title() != "schema" || saved.Text() != "users\n    id int" {
+		t.Errorf("saved item = %+v", saved)
+	}
+}
+
+func TestSyncRemovesFileOfDeletedItem(t *testing.T) {
+	remote := newBareRepository(t)
+	repo := new(MockItemRepository)
+	ctx := authenticatedCtx()
+	svc := newTestService(repo, remote, t)
+
+	repo.On("FindByID", ctx, "userID", "item1", false).Return(mo.Ok(newItem("a"))).Twice()
+
+	if ret := svc.Sync(ctx, []string{"item1"}); ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	repo.On("FindByID", ctx, "userID", "item1", false).Return(mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrNotDiagramOwner)))
+
+	ret := svc.Sync(ctx, nil)
+
+	if ret.IsError() {
+		t.Fatalf("Sync() error = %v", ret.Error())
+	}
+
+	if got := statusOf(ret.MustGet(), "item1"); got != v.SyncStatusDeleted {
+		t.Errorf("status = %s, want DELETED", got)
+	}
+
+	if readFile(t, remote, "userID/Release plan.usm").IsPresent() {
+		t.Error("file of the deleted item should be removed")
+	}
+}
+
+func TestSyncNotEnabled(t *testing.T) {
+	ret := newTestService(new(MockItemRepository), "", t).Sync(authenticatedCtx(), nil)
+
+	if ret.IsOk() || e.GetCode(ret.Error()) != e.NotFound {
+		t.Errorf("Sync() without a remote should return NotFound, got %v", ret)
+	}
+}
+
+func TestSyncRejectsManifestPathsOutsideUserDirectory(t *testing.T) {
+	for _, p := range []string{"otherUserID/Release plan.usm", "userID/../otherUserID/a.usm", "userID/sub/a.usm", "userID/.textusm.json"} {
+		t.Run(p, func(t *testing.T) {
+			remote := newBareRepository(t)
+			repo := new(MockItemRepository)
+			manifest := gitsync.NewManifest()
+			manifest.Items["item1"] = &gitsync.Entry{Path: p}
+
+			push(t, remote, func(tree *git.Tree) error {
+				return tree.WriteFile("userID/"+gitsync.ManifestFile, manifest.String())
+			})
+
+			ret := newTestService(repo, remote, t).Sync(authenticatedCtx(), nil)
+
+			if ret.IsOk() || e.GetCode(ret.Error()) != e.InvalidParameter {
+				t.Errorf("Sync() with %q in the manifest should return InvalidParameter, got %v", p, ret)
+			}
+
+			repo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
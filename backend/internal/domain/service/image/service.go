@@ -0,0 +1,231 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	imageModel "github.com/harehare/textusm/internal/domain/model/image"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	"github.com/harehare/textusm/internal/domain/service/diagramitem"
+	"github.com/harehare/textusm/internal/domain/service/user"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxSize is the largest image in bytes that can be uploaded.
+type MaxSize int64
+
+// Quota is the total size in bytes of the images a user can upload.
+type Quota int64
+
+// BaseURL is the origin of the API the image URLs point to. They are relative when it is empty.
+type BaseURL string
+
+const (
+	imagePath = "/api/v1/images/"
+	// maxPixels keeps an image that is small on the wire from exhausting the memory of those who view it.
+	maxPixels = 8192 * 8192
+)
+
+// formats maps the sniffed content types that can be uploaded to the names of their decoders.
+// SVG is left out on purpose, as it can carry scripts.
+var formats = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// ContentTypes returns the content types that can be uploaded.
+func ContentTypes() []string {
+	return []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+}
+
+type File struct {
+	ContentType string
+	Data        []byte
+	// ETag is a strong validator of Data.
+	ETag string
+}
+
+type Service struct {
+	repo        imageRepo.ImageRepository
+	store       blobRepo.BlobStore
+	itemService *diagramitem.Service
+	transaction db.Transaction
+	maxSize     int64
+	quota       int64
+	baseURL     string
+}
+
+func NewService(r imageRepo.ImageRepository, store blobRepo.BlobStore, itemService *diagramitem.Service, transaction db.Transaction, maxSize MaxSize, quota Quota, baseURL BaseURL) *Service {
+	return &Service{
+		repo:        r,
+		store:       store,
+		itemService: itemService,
+		transaction: transaction,
+		maxSize:     int64(maxSize),
+		quota:       int64(quota),
+		baseURL:     strings.TrimSuffix(string(baseURL), "/"),
+	}
+}
+
+func (s *Service) MaxSize() int64 {
+	return s.maxSize
+}
+
+// URL returns the URL the image is served from. It does not change, so it can be kept in the diagram text.
+func (s *Service) URL(img *imageModel.Image) string {
+	return s.baseURL + imagePath + img.ID
+}
+
+// Upload stores an image for the item at location. Its content decides its type, whatever the client claims.
+func (s *Service) Upload(ctx context.Context, itemID string, location v.Location, data []byte) mo.Result[*imageModel.Image] {
+	if err := user.IsAuthenticated(ctx); err != nil {
+		return mo.Err[*imageModel.Image](err)
+	}
+
+	if s.store == nil {
+		return mo.Err[*imageModel.Image](e.NotFoundError(e.ErrImageStoreDisabled))
+	}
+
+	if itemID == "" || !location.IsValid() {
+		return mo.Err[*imageModel.Image](e.InvalidParameterError(errors.New("invalid item id or location")))
+	}
+
+	if int64(len(data)) > s.maxSize {
+		return mo.Err[*imageModel.Image](e.InvalidParameterError(e.ErrImageTooLarge))
+	}
+
+	contentType := http.DetectContentType(data)
+	format, ok := formats[contentType]
+
+	if !ok {
+		return mo.Err[*imageModel.Image](e.InvalidParameterError(e.ErrUnsupportedImage))
+	}
+
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+
+	if err != nil || decoded != format {
+		return mo.Err[*imageModel.Image](e.InvalidParameterError(e.ErrUnsupportedImage))
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return mo.Err[*imageModel.Image](e.InvalidParameterError(e.ErrImageTooLarge))
+	}
+
+	img := imageModel.New(values.GetUID(ctx).MustGet(), itemID, location, contentType, data, time.Now())
+	err = s.transaction.Do(ctx, func(ctx context.Context) error {
+		if err := s.store.Put(ctx, img.BlobKey(), &blobRepo.Blob{ContentType: contentType, Data: data}).Error(); err != nil {
+			return err
+		}
+
+		saved := s.repo.SaveWithinQuota(ctx, img, s.quota)
+
+		if saved.IsError() {
+			s.deleteBlob(ctx, img)
+			return saved.Error()
+		}
+
+		if !saved.MustGet() {
+			s.deleteBlob(ctx, img)
+			return e.ForbiddenError(e.ErrImageQuotaExceeded)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return mo.Err[*imageModel.Image](err)
+	}
+
+	return mo.Ok(img)
+}
+
+// Find loads an image for anyone who can see the item it was uploaded for.
+// Others get NotFound, so that the existence of an image is not revealed.
+func (s *Service) Find(ctx context.Context, imageID string) mo.Result[*File] {
+	if s.store == nil {
+		return mo.Err[*File](e.NotFoundError(e.ErrImageStoreDisabled))
+	}
+
+	img := s.repo.FindByID(ctx, imageID)
+
+	if img.IsError() {
+		return mo.Err[*File](img.Error())
+	}
+
+	viewable := s.itemService.IsViewable(ctx, img.MustGet().UserID, img.MustGet().ItemID, img.MustGet().Location)
+
+	if viewable.IsError() {
+		return mo.Err[*File](viewable.Error())
+	}
+
+	if !viewable.MustGet() {
+		return mo.Err[*File](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	blob := s.store.Get(ctx, img.MustGet().BlobKey())
+
+	if blob.IsError() {
+		return mo.Err[*File](blob.Error())
+	}
+
+	return mo.Ok(&File{
+		ContentType: img.MustGet().ContentType,
+		Data:        blob.MustGet().Data,
+		ETag:        `"` + img.MustGet().Hash + `"`,
+	})
+}
+
+// Delete removes an image the user uploaded.
+func (s *Service) Delete(ctx context.Context, imageID string) mo.Result[bool] {
+	if err := user.IsAuthenticated(ctx); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	if s.store == nil {
+		return mo.Err[bool](e.NotFoundError(e.ErrImageStoreDisabled))
+	}
+
+	img := s.repo.FindByID(ctx, imageID)
+
+	if img.IsError() {
+		return mo.Err[bool](img.Error())
+	}
+
+	if img.MustGet().UserID != values.GetUID(ctx).OrEmpty() {
+		return mo.Err[bool](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	err := s.transaction.Do(ctx, func(ctx context.Context) error {
+		return s.repo.Delete(ctx, img.MustGet().UserID, imageID).Error()
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	s.deleteBlob(ctx, img.MustGet())
+	return mo.Ok(true)
+}
+
+func (s *Service) deleteBlob(ctx context.Context, img *imageModel.Image) {
+	if err := s.store.Delete(ctx, img.BlobKey()).Error(); err != nil {
+		slog.Error("failed to delete image", "key", img.BlobKey(), "error", err)
+	}
+}
@@ -0,0 +1,269 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	imageModel "github.com/harehare/textusm/internal/domain/model/image"
+	shareModel "github.com/harehare/textusm/internal/domain/model/share"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/github"
+	"github.com/harehare/textusm/internal/infra/local"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockImageRepository struct {
+	mock.Mock
+}
+
+func (m *MockImageRepository) FindByID(ctx context.Context, imageID string) mo.Result[*imageModel.Image] {
+	ret := m.Called(ctx, imageID)
+	return ret.Get(0).(mo.Result[*imageModel.Image])
+}
+
+func (m *MockImageRepository) FindByUserID(ctx context.Context, userID string) mo.Result[[]*imageModel.Image] {
+	ret := m.Called(ctx, userID)
+	return ret.Get(0).(mo.Result[[]*imageModel.Image])
+}
+
+func (m *MockImageRepository) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	ret := m.Called(ctx, userID)
+	return ret.Get(0).(mo.Result[int64])
+}
+
+func (m *MockImageRepository) Save(ctx context.Context, img *imageModel.Image) mo.Result[bool] {
+	ret := m.Called(ctx, img)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockImageRepository) SaveWithinQuota(ctx context.Context, img *imageModel.Image, quota int64) mo.Result[bool] {
+	ret := m.Called(ctx, img, quota)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockImageRepository) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, imageID)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+type MockItemRepository struct {
+	mock.Mock
+}
+
+func (m *MockItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemID, isPublic)
+	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemIDs, isPublic)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit, isPublic, filter.IsBookmark, fields.Text, fields.Thumbnail)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, item, isPublic)
+	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, isPublic)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	ret := m.Called(ctx, deletedBefore, limit)
+	return ret.Get(0).(mo.Result[[]itemRepo.TrashedItem])
+}
+
+func (m *MockItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, deletedAt)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+type MockShareRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
+	ret := m.Called(ctx, hashKey)
+	return ret.Get(0).(mo.Result[shareRepo.ShareValue])
+}
+
+func (m *MockShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	ret := m.Called(ctx, code)
+	return ret.Get(0).(mo.Result[shareRepo.ShareValue])
+}
+
+func (m *MockShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *shareModel.Share) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey, item, shareInfo)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *shareModel.Share) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey, item, shareInfo)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockShareRepository) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	ret := m.Called(ctx, hashKey)
+	return ret.Get(0).(mo.Result[int])
+}
+
+type MockTransaction struct {
+	mock.Mock
+}
+
+func (m *MockTransaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newPNG(t *testing.T) []byte {
+	t.Helper()
+	m := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	m.SetNRGBA(1, 1, color.NRGBA{R: 0xff, A: 0xff})
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func newTestService(t *testing.T, repo *MockImageRepository, items *MockItemRepository, shares *MockShareRepository) *Service {
+	diagramItems := itemService.NewService(items, nil, shares, nil, nil, new(MockTransaction), "", "", github.NewClient("", ""), "key", nil)
+	return NewService(repo, local.NewBlobStore(local.Dir(t.TempDir())), diagramItems, new(MockTransaction), 1024, 4096, "https://api.textusm.com/")
+}
+
+func TestUpload(t *testing.T) {
+	repo := new(MockImageRepository)
+	svc := newTestService(t, repo, new(MockItemRepository), new(MockShareRepository))
+	ctx := values.WithUID(context.Background(), "userID")
+	data := newPNG(t)
+
+	repo.On("SaveWithinQuota", mock.Anything, mock.Anything, int64(4096)).Return(mo.Ok(true))
+
+	img := svc.Upload(ctx, "itemID", v.LocationSystem, data)
+
+	if img.IsError() {
+		t.Fatalf("Upload() error = %v", img.Error())
+	}
+
+	if img.MustGet().ContentType != "image/png" || img.MustGet().Size != int64(len(data)) {
+		t.Errorf("unexpected image %+v", img.MustGet())
+	}
+
+	if u := svc.URL(img.MustGet()); u != "https://api.textusm.com/api/v1/images/"+img.MustGet().ID {
+		t.Errorf("URL() = %s", u)
+	}
+
+	repo.On("FindByID", mock.Anything, img.MustGet().ID).Return(img)
+	file := svc.Find(ctx, img.MustGet().ID)
+
+	if file.IsError() || !bytes.Equal(file.MustGet().Data, data) || file.MustGet().ContentType != "image/png" {
+		t.Errorf("Find() of an uploaded image = %v", file.Error())
+	}
+}
+
+func TestUploadRejectsInvalidImages(t *testing.T) {
+	repo := new(MockImageRepository)
+	svc := newTestService(t, repo, new(MockItemRepository), new(MockShareRepository))
+	ctx := values.WithUID(context.Background(), "userID")
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+	// A PNG signature in front of anything else is sniffed as a PNG, but does not decode.
+	fake := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+
+	if ret := svc.Upload(context.Background(), "itemID", v.LocationSystem, newPNG(t)); e.GetCode(ret.Error()) != e.NoAuthorization {
+		t.Errorf("Upload() without a user should fail, got %v", ret.Error())
+	}
+
+	for _, data := range [][]byte{svg, fake, bytes.Repeat(newPNG(t), 20)} {
+		if ret := svc.Upload(ctx, "itemID", v.LocationSystem, data); e.GetCode(ret.Error()) != e.InvalidParameter {
+			t.Errorf("Upload() should reject the image, got %v", ret.Error())
+		}
+	}
+
+	repo.On("SaveWithinQuota", mock.Anything, mock.Anything, int64(4096)).Return(mo.Ok(false))
+
+	if ret := svc.Upload(ctx, "itemID", v.LocationSystem, newPNG(t)); e.GetCode(ret.Error()) != e.Forbidden {
+		t.Errorf("Upload() over the quota should be forbidden, got %v", ret.Error())
+	}
+
+	repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestFindFollowsItemAccess(t *testing.T) {
+	repo := new(MockImageRepository)
+	items := new(MockItemRepository)
+	shares := new(MockShareRepository)
+	svc := newTestService(t, repo, items, shares)
+	owner := values.WithUID(context.Background(), "userID")
+
+	repo.On("SaveWithinQuota", mock.Anything, mock.Anything, int64(4096)).Return(mo.Ok(true))
+
+	img := svc.Upload(owner, "itemID", v.LocationSystem, newPNG(t)).MustGet()
+	repo.On("FindByID", mock.Anything, img.ID).Return(mo.Ok(img))
+
+	items.On("FindByID", mock.Anything, "userID", "itemID", true).Return(mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrImageNotFound))).Once()
+	shares.On("Find", mock.Anything, mock.Anything).Return(mo.Err[shareRepo.ShareValue](e.NotFoundError(e.ErrImageNotFound)))
+	viewer := values.WithIP(context.Background(), "127.0.0.1")
+
+	if ret := svc.Find(viewer, img.ID); e.GetCode(ret.Error()) != e.NotFound {
+		t.Errorf("Find() of an image of a private item should not be found, got %v", ret.Error())
+	}
+
+	item := diagramitem.New().WithID("itemID").WithPlainText("text").WithCreatedAt(time.Now()).Build().MustGet()
+	items.On("FindByID", mock.Anything, "userID", "itemID", true).Return(mo.Ok(item))
+
+	if ret := svc.Find(viewer, img.ID); ret.IsError() {
+		t.Errorf("Find() of an image of a public item error = %v", ret.Error())
+	}
+
+	if ret := svc.Delete(values.WithUID(context.Background(), "otherID"), img.ID); e.GetCode(ret.Error()) != e.NotFound {
+		t.Errorf("Delete() of an image of another user should fail, got %v", ret.Error())
+	}
+
+	repo.On("Delete", mock.Anything, "userID", img.ID).Return(mo.Ok(true))
+
+	if ret := svc.Delete(owner, img.ID); ret.IsError() {
+		t.Errorf("Delete() error = %v", ret.Error())
+	}
+}
@@ -30,12 +30,21 @@ func NewService(r settingsRepo.SettingsRepository, transaction db.Transaction, c
 	}
 }
 
+// Find returns the settings of the diagram. Settings kept in memory by the repository are returned without
+// opening a transaction.
 func (s *Service) Find(ctx context.Context, diagram v.Diagram) mo.Result[*settingsModel.Settings] {
-	var settings settingsModel.Settings
-	err := s.transaction.Do(ctx, func(ctx context.Context) error {
-		if err := user.IsAuthenticated(ctx); err != nil {
-			return err
+	if err := user.IsAuthenticated(ctx); err != nil {
+		return mo.Err[*settingsModel.Settings](err)
+	}
+
+	if cache, ok := s.repo.(settingsRepo.SettingsCache); ok {
+		if cached, ok := cache.Peek(values.GetUID(ctx).OrEmpty(), diagram).Get(); ok {
+			return mo.Ok(cached)
 		}
+	}
+
+	var settings settingsModel.Settings
+	err := s.transaction.DoReadOnly(ctx, func(ctx context.Context) error {
 		r := s.repo.Find(ctx, values.GetUID(ctx).OrEmpty(), diagram)
 
 		if r.IsError() {
@@ -26,6 +26,11 @@ func (m *MockSettingsRepository) Save(ctx context.Context, userID string, diagra
 	return ret.Get(0).(mo.Result[*settingsModel.Settings])
 }
 
+func (m *MockSettingsRepository) Delete(ctx context.Context, userID string, diagram v.Diagram) mo.Result[bool] {
+	ret := m.Called(ctx, userID, diagram)
+	return ret.Get(0).(mo.Result[bool])
+}
+
 type MockTransaction struct {
 	mock.Mock
 }
@@ -34,6 +39,10 @@ func (m *MockTransaction) Do(ctx context.Context, fn func(ctx context.Context) e
 	return fn(ctx)
 }
 
+func (m *MockTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func newTestService(repo *MockSettingsRepository, tx *MockTransaction) *Service {
 	return NewService(repo, tx, "DUMMY_ID", "DUMMY_SECRET")
 }
@@ -165,3 +174,33 @@ func TestFindMultipleDiagramTypes(t *testing.T) {
 		})
 	}
 }
+
+type MockCachedSettingsRepository struct {
+	MockSettingsRepository
+}
+
+func (m *MockCachedSettingsRepository) Peek(userID string, diagram v.Diagram) mo.Option[*settingsModel.Settings] {
+	ret := m.Called(userID, diagram)
+	return ret.Get(0).(mo.Option[*settingsModel.Settings])
+}
+
+func TestFindSettingsFromCache(t *testing.T) {
+	repo := new(MockCachedSettingsRepository)
+	tx := new(MockTransaction)
+	ctx := authenticatedCtx()
+	diagram := v.DiagramUserStoryMap
+
+	s := &settingsModel.Settings{Font: "Roboto", Width: 1440, Height: 900}
+	repo.On("Peek", "userID", diagram).Return(mo.Some(s))
+
+	svc := NewService(repo, tx, "DUMMY_ID", "DUMMY_SECRET")
+	ret := svc.Find(ctx, diagram)
+
+	if ret.IsError() {
+		t.Fatalf("Find() error: %v", ret.Error())
+	}
+	if ret.OrEmpty().Font != "Roboto" {
+		t.Errorf("Find() Font = %q, want %q", ret.OrEmpty().Font, "Roboto")
+	}
+	repo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+}
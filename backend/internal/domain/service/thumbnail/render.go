@@ -0,0 +1,109 @@
+package thumbnail
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+
+	// MaxWidth bounds the work a single request can cause. Wider requests get the largest size instead.
+	MaxWidth = 1024
+	// maxPixels keeps a crafted image from exhausting memory when it is decoded.
+	maxPixels = 4096 * 4096
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatPNG || f == FormatWebP
+}
+
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+type Thumbnail struct {
+	ContentType string
+	Data        []byte
+	// ETag is a strong validator of Data.
+	ETag string
+}
+
+// Render scales the image down to width, keeping its aspect ratio, and encodes it as format.
+// Thumbnails are never scaled up, and a width of zero keeps the original size.
+func Render(blob *blobRepo.Blob, width int, format Format) mo.Result[*Thumbnail] {
+	if width < 0 || !format.IsValid() {
+		return mo.Err[*Thumbnail](e.InvalidParameterError(errors.New("invalid thumbnail width or format")))
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(blob.Data))
+
+	if err != nil {
+		return mo.Err[*Thumbnail](e.InvalidParameterError(err))
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return mo.Err[*Thumbnail](e.InvalidParameterError(errors.New("thumbnail is too large")))
+	}
+
+	width = min(width, MaxWidth)
+
+	if width == 0 || width >= config.Width {
+		if blob.ContentType == format.ContentType() {
+			return mo.Ok(newThumbnail(format, blob.Data))
+		}
+
+		width = config.Width
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(blob.Data))
+
+	if err != nil {
+		return mo.Err[*Thumbnail](e.InvalidParameterError(err))
+	}
+
+	dst := src
+
+	if width != config.Width {
+		height := max(1, config.Height*width/config.Width)
+		scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), src, src.Bounds(), draw.Src, nil)
+		dst = scaled
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatWebP:
+		err = nativewebp.Encode(&buf, dst, nil)
+	default:
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, dst)
+	}
+
+	if err != nil {
+		return mo.Err[*Thumbnail](err)
+	}
+
+	return mo.Ok(newThumbnail(format, buf.Bytes()))
+}
+
+func newThumbnail(format Format, data []byte) *Thumbnail {
+	sum := sha256.Sum256(data)
+	return &Thumbnail{ContentType: format.ContentType(), Data: data, ETag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+}
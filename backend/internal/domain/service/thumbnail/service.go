@@ -0,0 +1,285 @@
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	gistRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	"github.com/harehare/textusm/internal/domain/service/user"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// SignKey signs thumbnail URLs, which are loaded by img elements that cannot send an Authorization header.
+type SignKey string
+
+// BaseURL is the origin of the API the thumbnail URLs point to. They are relative when it is empty.
+type BaseURL string
+
+const (
+	thumbnailPath = "/api/v1/thumbnails/"
+	// URLs stay the same for a whole window so that browsers can cache them, and are valid for one more.
+	urlWindow = 12 * time.Hour
+
+	sourceItem   = "item"
+	sourcePublic = "public"
+	sourceGist   = "gist"
+	sourceShare  = "share"
+)
+
+type Service struct {
+	repo        itemRepo.ItemRepository
+	gistRepo    gistRepo.GistItemRepository
+	shareRepo   shareRepo.ShareRepository
+	itemService *itemService.Service
+	transaction db.Transaction
+	key         []byte
+	baseURL     string
+}
+
+func NewService(r itemRepo.ItemRepository, g gistRepo.GistItemRepository, s shareRepo.ShareRepository, itemService *itemService.Service, transaction db.Transaction, key SignKey, baseURL BaseURL) *Service {
+	return &Service{
+		repo:        r,
+		gistRepo:    g,
+		shareRepo:   s,
+		itemService: itemService,
+		transaction: transaction,
+		key:         []byte(key),
+		baseURL:     strings.TrimSuffix(string(baseURL), "/"),
+	}
+}
+
+// ItemURL returns a signed URL of the thumbnail of an item the user owns or opened through a share.
+// Without a sign key, or a user to sign it for, the thumbnail is returned as is. So is the thumbnail of a share
+// with a password, as the URL cannot carry the password.
+func (s *Service) ItemURL(ctx context.Context, item *diagramitem.DiagramItem) mo.Result[*string] {
+	if item.Thumbnail() == nil || len(s.key) == 0 {
+		return mo.Ok(item.Thumbnail())
+	}
+
+	claims := jwt.MapClaims{"src": sourceItem}
+
+	if shareID, ok := item.ShareID().Get(); ok {
+		share := s.shareRepo.Find(ctx, shareID)
+
+		if share.IsError() {
+			return mo.Err[*string](share.Error())
+		}
+
+		if share.MustGet().ShareInfo.Password != "" {
+			return mo.Ok(item.Thumbnail())
+		}
+
+		claims = jwt.MapClaims{"src": sourceShare, "shr": shareID}
+
+		// Shares limited to some email addresses are checked again for the viewer on load.
+		if uid := values.GetUID(ctx); uid.IsPresent() {
+			claims["vwr"] = uid.OrEmpty()
+		}
+	} else if item.IsPublic() {
+		claims["src"] = sourcePublic
+	}
+
+	return s.url(ctx, item.ID(), item.Thumbnail(), item.UpdatedAt(), claims)
+}
+
+// GistItemURL returns a signed URL of the thumbnail of a gist item the user owns.
+func (s *Service) GistItemURL(ctx context.Context, item *gistitem.GistItem) mo.Result[*string] {
+	if item.Thumbnail() == nil {
+		return mo.Ok[*string](nil)
+	}
+
+	return s.url(ctx, item.ID(), item.Thumbnail(), item.UpdatedAt(), jwt.MapClaims{"src": sourceGist})
+}
+
+// Find renders the thumbnail of itemID. With a token the thumbnail it was signed for is loaded,
+// otherwise the thumbnail of the signed in user's item at location.
+func (s *Service) Find(ctx context.Context, itemID, token string, location v.Location, width int, format Format) mo.Result[*Thumbnail] {
+	thumbnail := s.load(ctx, itemID, token, location)
+
+	if thumbnail.IsError() {
+		return mo.Err[*Thumbnail](thumbnail.Error())
+	}
+
+	blob, ok := blobRepo.ParseDataURL(thumbnail.MustGet())
+
+	if !ok {
+		return mo.Err[*Thumbnail](e.NotFoundError(e.ErrThumbnailNotFound))
+	}
+
+	return Render(blob, width, format)
+}
+
+func (s *Service) url(ctx context.Context, itemID string, thumbnail *string, updatedAt time.Time, claims jwt.MapClaims) mo.Result[*string] {
+	if len(s.key) == 0 {
+		return mo.Ok(thumbnail)
+	}
+
+	if claims["src"] != sourceShare {
+		uid := values.GetUID(ctx)
+
+		if uid.IsAbsent() {
+			return mo.Ok(thumbnail)
+		}
+
+		claims["uid"] = uid.OrEmpty()
+	}
+
+	claims["sub"] = itemID
+	// The update time only changes the URL, so that browsers do not keep showing an old thumbnail.
+	claims["ver"] = updatedAt.Unix()
+	claims["exp"] = time.Now().Truncate(urlWindow).Add(2 * urlWindow).Unix()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+
+	if err != nil {
+		return mo.Err[*string](err)
+	}
+
+	u := s.baseURL + thumbnailPath + itemID + "?token=" + token
+	return mo.Ok(&u)
+}
+
+func (s *Service) load(ctx context.Context, itemID, token string, location v.Location) mo.Result[string] {
+	if token == "" {
+		if err := user.IsAuthenticated(ctx); err != nil {
+			return mo.Err[string](err)
+		}
+
+		src := sourceItem
+
+		if location == v.LocationGist {
+			src = sourceGist
+		}
+
+		return s.loadSource(ctx, itemID, jwt.MapClaims{"src": src, "uid": values.GetUID(ctx).OrEmpty()})
+	}
+
+	claims := s.verify(token)
+
+	if claims.IsError() {
+		return mo.Err[string](claims.Error())
+	}
+
+	if sub, _ := claims.MustGet()["sub"].(string); sub != itemID {
+		return mo.Err[string](e.ForbiddenError(errors.New("token was signed for another thumbnail")))
+	}
+
+	return s.loadSource(ctx, itemID, claims.MustGet())
+}
+
+func (s *Service) verify(token string) mo.Result[jwt.MapClaims] {
+	if len(s.key) == 0 {
+		return mo.Err[jwt.MapClaims](e.ForbiddenError(errors.New("thumbnail sign key is not configured")))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if _, ok := claims["exp"]; err == nil && !ok {
+		err = errors.New("token has no expiry")
+	}
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return mo.Err[jwt.MapClaims](e.URLExpiredError(err))
+	}
+
+	if err != nil {
+		return mo.Err[jwt.MapClaims](e.ForbiddenError(err))
+	}
+
+	return mo.Ok(claims)
+}
+
+func (s *Service) loadSource(ctx context.Context, itemID string, claims jwt.MapClaims) mo.Result[string] {
+	uid, _ := claims["uid"].(string)
+	src, _ := claims["src"].(string)
+
+	switch src {
+	case sourceItem, sourcePublic, sourceGist:
+		var thumbnail *string
+
+		// The request is usually not signed in, so the transaction is run as the owner the URL was signed for.
+		err := s.transaction.DoReadOnly(values.WithUID(ctx, uid), func(ctx context.Context) error {
+			if src == sourceGist {
+				item := s.gistRepo.FindByID(ctx, uid, itemID, v.Projection{Thumbnail: true})
+
+				if item.IsError() {
+					return item.Error()
+				}
+
+				thumbnail = item.MustGet().Thumbnail()
+				return nil
+			}
+
+			item := s.repo.FindByID(ctx, uid, itemID, src == sourcePublic, v.Projection{Thumbnail: true})
+
+			if item.IsError() {
+				return item.Error()
+			}
+
+			thumbnail = item.MustGet().Thumbnail()
+			return nil
+		})
+
+		if err != nil {
+			return mo.Err[string](err)
+		}
+
+		return thumbnailOf(thumbnail)
+	case sourceShare:
+		shareID, _ := claims["shr"].(string)
+		share := s.shareRepo.Find(ctx, shareID)
+
+		if share.IsError() {
+			return mo.Err[string](share.Error())
+		}
+
+		value := share.MustGet()
+
+		if expireTime := value.ShareInfo.ExpireTime; expireTime > 0 && time.Now().UnixMilli() > expireTime {
+			return mo.Err[string](e.URLExpiredError(errors.New("share has expired")))
+		}
+
+		if value.ItemID() != itemID {
+			return mo.Err[string](e.NotFoundError(e.ErrThumbnailNotFound))
+		}
+
+		// The share may have been limited since the URL was signed.
+		viewer, _ := claims["vwr"].(string)
+
+		if !s.itemService.AllowsShareView(ctx, mo.EmptyableToOption(viewer), value, itemID) {
+			return mo.Err[string](e.ForbiddenError(errors.New("share no longer allows this view")))
+		}
+
+		if value.GistItem != nil {
+			return thumbnailOf(value.GistItem.Thumbnail())
+		}
+
+		return thumbnailOf(value.DiagramItem.Thumbnail())
+	default:
+		return mo.Err[string](e.ForbiddenError(errors.New("unknown thumbnail source")))
+	}
+}
+
+func thumbnailOf(thumbnail *string) mo.Result[string] {
+	if thumbnail == nil {
+		return mo.Err[string](e.NotFoundError(e.ErrThumbnailNotFound))
+	}
+
+	return mo.Ok(*thumbnail)
+}
@@ -0,0 +1,310 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	shareModel "github.com/harehare/textusm/internal/domain/model/share"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/github"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/image/webp"
+)
+
+type MockItemRepository struct {
+	mock.Mock
+}
+
+func (m *MockItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemID, isPublic)
+	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, itemIDs, isPublic)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit, isPublic, filter.IsBookmark, fields.Text, fields.Thumbnail)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, item, isPublic)
+	return ret.Get(0).(mo.Result[*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, isPublic)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	ret := m.Called(ctx, userID, offset, limit)
+	return ret.Get(0).(mo.Result[[]*diagramitem.DiagramItem])
+}
+
+func (m *MockItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	ret := m.Called(ctx, deletedBefore, limit)
+	return ret.Get(0).(mo.Result[[]itemRepo.TrashedItem])
+}
+
+func (m *MockItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID, deletedAt)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, itemID)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+type MockShareRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
+	ret := m.Called(ctx, hashKey)
+	return ret.Get(0).(mo.Result[shareRepo.ShareValue])
+}
+
+func (m *MockShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	ret := m.Called(ctx, code)
+	return ret.Get(0).(mo.Result[shareRepo.ShareValue])
+}
+
+func (m *MockShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *shareModel.Share) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey, item, shareInfo)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *shareModel.Share) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey, item, shareInfo)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockShareRepository) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
+	ret := m.Called(ctx, userID, hashKey)
+	return ret.Get(0).(mo.Result[bool])
+}
+
+func (m *MockShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	ret := m.Called(ctx, hashKey)
+	return ret.Get(0).(mo.Result[int])
+}
+
+type MockTransaction struct {
+	mock.Mock
+}
+
+func (m *MockTransaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockTransaction) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	m := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func newItem(t *testing.T) *diagramitem.DiagramItem {
+	blob := &blobRepo.Blob{ContentType: "image/png", Data: newPNG(t, 320, 200)}
+
+	return diagramitem.New().
+		WithID("item1").
+		WithTitle("title").
+		WithPlainText("text").
+		WithThumbnail(mo.Some(blob.DataURL())).
+		WithDiagram(v.DiagramUserStoryMap).
+		WithCreatedAt(time.Now()).
+		WithUpdatedAt(time.Now()).
+		Build().
+		MustGet()
+}
+
+func tokenOf(t *testing.T, u *string) string {
+	t.Helper()
+
+	if u == nil || !strings.HasPrefix(*u, "https://api.textusm.com/api/v1/thumbnails/item1?") {
+		t.Fatalf("unexpected thumbnail URL %v", u)
+	}
+
+	parsed, err := url.Parse(*u)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed.Query().Get("token")
+}
+
+func TestRender(t *testing.T) {
+	data := newPNG(t, 320, 200)
+	blob := &blobRepo.Blob{ContentType: "image/png", Data: data}
+
+	original := Render(blob, 0, FormatPNG)
+
+	if original.IsError() || !bytes.Equal(original.MustGet().Data, data) {
+		t.Errorf("Render() without resizing should return the PNG as is, got %v", original.Error())
+	}
+
+	resized := Render(blob, 160, FormatWebP)
+
+	if resized.IsError() {
+		t.Fatalf("Render() error = %v", resized.Error())
+	}
+
+	m, err := webp.Decode(bytes.NewReader(resized.MustGet().Data))
+
+	if err != nil || m.Bounds().Dx() != 160 || m.Bounds().Dy() != 100 {
+		t.Errorf("resized thumbnail = %v, %v", m.Bounds(), err)
+	}
+
+	if resized.MustGet().ContentType != "image/webp" || resized.MustGet().ETag == original.MustGet().ETag {
+		t.Errorf("unexpected content type %s or etag %s", resized.MustGet().ContentType, resized.MustGet().ETag)
+	}
+
+	if ret := Render(&blobRepo.Blob{ContentType: "image/png", Data: []byte("not an image")}, 0, FormatPNG); e.GetCode(ret.Error()) != e.InvalidParameter {
+		t.Errorf("Render() of an invalid image should fail with InvalidParameter, got %v", ret.Error())
+	}
+}
+
+func TestFindWithSignedURL(t *testing.T) {
+	repo := new(MockItemRepository)
+	svc := NewService(repo, nil, nil, nil, new(MockTransaction), "key", "https://api.textusm.com/")
+	item := newItem(t)
+
+	repo.On("FindByID", mock.Anything, "userID", "item1", false).Return(mo.Ok(item))
+
+	token := tokenOf(t, svc.ItemURL(values.WithUID(context.Background(), "userID"), item).MustGet())
+	ret := svc.Find(context.Background(), "item1", token, v.LocationSystem, 64, FormatPNG)
+
+	if ret.IsError() {
+		t.Fatalf("Find() error = %v", ret.Error())
+	}
+
+	m, err := png.Decode(bytes.NewReader(ret.MustGet().Data))
+
+	if err != nil || m.Bounds().Dx() != 64 || m.Bounds().Dy() != 40 {
+		t.Errorf("thumbnail = %v, %v", m.Bounds(), err)
+	}
+
+	if ret := svc.Find(context.Background(), "item2", token, v.LocationSystem, 64, FormatPNG); e.GetCode(ret.Error()) != e.Forbidden {
+		t.Errorf("Find() of another item should be forbidden, got %v", ret.Error())
+	}
+
+	forged := NewService(repo, nil, nil, nil, new(MockTransaction), "another key", "https://api.textusm.com")
+	forgedToken := tokenOf(t, forged.ItemURL(values.WithUID(context.Background(), "userID"), item).MustGet())
+
+	if ret := svc.Find(context.Background(), "item1", forgedToken, v.LocationSystem, 64, FormatPNG); e.GetCode(ret.Error()) != e.Forbidden {
+		t.Errorf("Find() with a token signed by another key should be forbidden, got %v", ret.Error())
+	}
+}
+
+func TestFindSharedThumbnail(t *testing.T) {
+	item := newItem(t)
+	open := time.Now().Add(time.Hour).UnixMilli()
+
+	tests := []struct {
+		name      string
+		share     *shareModel.Share
+		forbidden bool
+	}{
+		{"open share", &shareModel.Share{ExpireTime: open}, false},
+		{"share limited to another IP address", &shareModel.Share{ExpireTime: open, AllowIPList: []string{"10.0.0.1"}}, true},
+		{"share limited to some email addresses", &shareModel.Share{ExpireTime: open, AllowEmailList: []string{"a@example.com"}}, true},
+		{"share without views left", &shareModel.Share{ExpireTime: open, RemainingViews: mo.Some(0)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := new(MockShareRepository)
+			signed := new(MockShareRepository)
+			items := itemService.NewService(nil, nil, shares, nil, nil, new(MockTransaction), "", "", github.NewClient("", ""), "key", nil)
+			svc := NewService(nil, nil, shares, items, new(MockTransaction), "key", "https://api.textusm.com")
+			signer := NewService(nil, nil, signed, items, new(MockTransaction), "key", "https://api.textusm.com")
+
+			signed.On("Find", mock.Anything, "shareID").Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &shareModel.Share{ExpireTime: open}}))
+			shares.On("Find", mock.Anything, "shareID").Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: tt.share}))
+
+			// The viewer of a share is usually not signed in, and the share may be limited after the URL was signed.
+			token := tokenOf(t, signer.ItemURL(context.Background(), item.SharedAs("shareID")).MustGet())
+			ret := svc.Find(values.WithIP(context.Background(), "192.168.0.1"), "item1", token, v.LocationSystem, 0, FormatWebP)
+
+			if ret.IsError() != tt.forbidden || (tt.forbidden && e.GetCode(ret.Error()) != e.Forbidden) {
+				t.Errorf("Find() error = %v, forbidden %v", ret.Error(), tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestItemURLOfPasswordShare(t *testing.T) {
+	shares := new(MockShareRepository)
+	svc := NewService(nil, nil, shares, nil, new(MockTransaction), "key", "https://api.textusm.com")
+	item := newItem(t)
+
+	shares.On("Find", mock.Anything, "shareID").Return(mo.Ok(shareRepo.ShareValue{DiagramItem: item, ShareInfo: &shareModel.Share{Password: "hash"}}))
+
+	if u := svc.ItemURL(context.Background(), item.SharedAs("shareID")).MustGet(); u == nil || *u != *item.Thumbnail() {
+		t.Error("ItemURL() of a share with a password should return the thumbnail as is")
+	}
+}
+
+func TestFindWithoutToken(t *testing.T) {
+	repo := new(MockItemRepository)
+	svc := NewService(repo, nil, nil, nil, new(MockTransaction), "key", "")
+
+	if ret := svc.Find(context.Background(), "item1", "", v.LocationSystem, 0, FormatPNG); e.GetCode(ret.Error()) != e.NoAuthorization {
+		t.Errorf("Find() without a token or user should fail, got %v", ret.Error())
+	}
+
+	ctx := values.WithUID(context.Background(), "userID")
+	repo.On("FindByID", mock.Anything, "userID", "item1", false).Return(mo.Ok(newItem(t)))
+
+	if ret := svc.Find(ctx, "item1", "", v.LocationSystem, 0, FormatPNG); ret.IsError() {
+		t.Errorf("Find() of the user's own item error = %v", ret.Error())
+	}
+}
+
+func TestItemURLWithoutSignKey(t *testing.T) {
+	item := newItem(t)
+	u := NewService(nil, nil, nil, nil, new(MockTransaction), "", "").ItemURL(values.WithUID(context.Background(), "userID"), item).MustGet()
+
+	if u == nil || *u != *item.Thumbnail() {
+		t.Error("ItemURL() without a sign key should return the thumbnail as is")
+	}
+}
@@ -20,6 +20,14 @@ func (m *MockUserRepository) Find(ctx context.Context, uid string) mo.Result[*us
 	return ret.Get(0).(mo.Result[*userModel.User])
 }
 
+func (m *MockUserRepository) Delete(ctx context.Context, uid string) error {
+	ret := m.Called(ctx, uid)
+	if ret.Get(0) == nil {
+		return nil
+	}
+	return ret.Get(0).(error)
+}
+
 func (m *MockUserRepository) RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
 	ret := m.Called(ctx, clientID, clientSecret, accessToken)
 	if ret.Get(0) == nil {
@@ -2,6 +2,7 @@ package values
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/99designs/gqlgen/graphql"
 )
@@ -79,3 +80,43 @@ func UnmarshalDiagram(v interface{}) (*Diagram, error) {
 	}
 	return &d, nil
 }
+
+var diagramExtensions = map[Diagram]string{
+	DiagramUserStoryMap:        "usm",
+	DiagramOpportunityCanvas:   "opc",
+	DiagramBusinessModelCanvas: "bmc",
+	DiagramFourls:              "4ls",
+	DiagramStartStopContinue:   "ssc",
+	DiagramKpt:                 "kpt",
+	DiagramUserPersona:         "persona",
+	DiagramMindMap:             "mmp",
+	DiagramEmpathyMap:          "emm",
+	DiagramSiteMap:             "smp",
+	DiagramGanttChart:          "gct",
+	DiagramImpactMap:           "imm",
+	DiagramErDiagram:           "erd",
+	DiagramKanban:              "kanban",
+	DiagramTable:               "table",
+	DiagramSequenceDiagram:     "sed",
+	DiagramFreeform:            "free",
+	DiagramUseCaseDiagram:      "ucd",
+	DiagramKeyboardLayout:      "kbd",
+}
+
+// Extension returns the file extension used for the diagram, the same one the frontend exports with.
+func (e Diagram) Extension() string {
+	return diagramExtensions[e]
+}
+
+// DiagramFromExtension returns the diagram stored in files with the given extension, with or without the leading dot.
+func DiagramFromExtension(ext string) (Diagram, bool) {
+	ext = strings.TrimPrefix(ext, ".")
+
+	for d, x := range diagramExtensions {
+		if x == ext {
+			return d, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,62 @@
+package values
+
+import (
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/samber/mo"
+)
+
+// ItemSort is the order in which lists of items are returned. Dates sort newest first and titles alphabetically.
+type ItemSort string
+
+const (
+	ItemSortUpdatedAt ItemSort = "UPDATED_AT"
+	ItemSortCreatedAt ItemSort = "CREATED_AT"
+	ItemSortTitle     ItemSort = "TITLE"
+)
+
+func (s ItemSort) IsValid() bool {
+	switch s {
+	case ItemSortUpdatedAt, ItemSortCreatedAt, ItemSortTitle:
+		return true
+	}
+	return false
+}
+
+func (s ItemSort) String() string {
+	return string(s)
+}
+
+func MarshalItemSort(s *ItemSort) graphql.Marshaler {
+	return graphql.MarshalString(s.String())
+}
+
+func UnmarshalItemSort(v interface{}) (*ItemSort, error) {
+	v2, err := graphql.UnmarshalString(v)
+	if err != nil {
+		return nil, err
+	}
+	s := ItemSort(v2)
+	if !s.IsValid() {
+		return nil, fmt.Errorf("%s is not a valid ItemSort", v2)
+	}
+	return &s, nil
+}
+
+// ItemFilter narrows and orders a list of items. The zero value lists every item, most recently updated first.
+type ItemFilter struct {
+	// IsBookmark lists bookmarked items only.
+	IsBookmark bool
+	Diagram    mo.Option[Diagram]
+	Sort       ItemSort
+}
+
+// SortOrDefault returns the sort of the filter, or ItemSortUpdatedAt when it has none.
+func (f ItemFilter) SortOrDefault() ItemSort {
+	if f.Sort.IsValid() {
+		return f.Sort
+	}
+
+	return ItemSortUpdatedAt
+}
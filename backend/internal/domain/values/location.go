@@ -0,0 +1,42 @@
+package values
+
+import (
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type Location string
+
+const (
+	LocationSystem Location = "SYSTEM"
+	LocationGist   Location = "GIST"
+)
+
+func (l Location) IsValid() bool {
+	switch l {
+	case LocationSystem, LocationGist:
+		return true
+	}
+	return false
+}
+
+func (l Location) String() string {
+	return string(l)
+}
+
+func MarshalLocation(l *Location) graphql.Marshaler {
+	return graphql.MarshalString(l.String())
+}
+
+func UnmarshalLocation(v interface{}) (*Location, error) {
+	v2, err := graphql.UnmarshalString(v)
+	if err != nil {
+		return nil, err
+	}
+	l := Location(v2)
+	if !l.IsValid() {
+		return nil, fmt.Errorf("%s is not a valid Location", v2)
+	}
+	return &l, nil
+}
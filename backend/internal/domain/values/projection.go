@@ -0,0 +1,23 @@
+package values
+
+// Projection selects the heavy fields of diagram and gist items that a read loads. The other fields are small and
+// always loaded. Items read without their text have an empty text, which is not decrypted.
+type Projection struct {
+	Text      bool
+	Thumbnail bool
+}
+
+var (
+	// AllFields loads whole items, for reads whose items are saved back, exported or synced.
+	AllFields = Projection{Text: true, Thumbnail: true}
+	// MetadataOnly loads neither the text nor the thumbnail, for reads that only check an item exists.
+	MetadataOnly = Projection{}
+)
+
+// ProjectionOf returns the projection of the GraphQL fields requested on an item.
+func ProjectionOf(fields map[string]struct{}) Projection {
+	_, text := fields["text"]
+	_, thumbnail := fields["thumbnail"]
+
+	return Projection{Text: text, Thumbnail: thumbnail}
+}
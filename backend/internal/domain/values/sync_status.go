@@ -0,0 +1,46 @@
+package values
+
+import (
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type SyncStatus string
+
+const (
+	SyncStatusUnchanged SyncStatus = "UNCHANGED"
+	SyncStatusPushed    SyncStatus = "PUSHED"
+	SyncStatusPulled    SyncStatus = "PULLED"
+	SyncStatusCreated   SyncStatus = "CREATED"
+	SyncStatusDeleted   SyncStatus = "DELETED"
+	SyncStatusConflict  SyncStatus = "CONFLICT"
+)
+
+func (s SyncStatus) IsValid() bool {
+	switch s {
+	case SyncStatusUnchanged, SyncStatusPushed, SyncStatusPulled, SyncStatusCreated, SyncStatusDeleted, SyncStatusConflict:
+		return true
+	}
+	return false
+}
+
+func (s SyncStatus) String() string {
+	return string(s)
+}
+
+func MarshalSyncStatus(s *SyncStatus) graphql.Marshaler {
+	return graphql.MarshalString(s.String())
+}
+
+func UnmarshalSyncStatus(v interface{}) (*SyncStatus, error) {
+	v2, err := graphql.UnmarshalString(v)
+	if err != nil {
+		return nil, err
+	}
+	s := SyncStatus(v2)
+	if !s.IsValid() {
+		return nil, fmt.Errorf("%s is not a valid SyncStatus", v2)
+	}
+	return &s, nil
+}
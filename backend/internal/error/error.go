@@ -8,22 +8,41 @@ import (
 type Code string
 
 var (
-	ErrInvalidId          = errors.New("invalid id")
-	ErrInvalidTitle       = errors.New("invalid title")
-	ErrInvalidDiagram     = errors.New("invalid diagram")
-	ErrInvalidIsPublic    = errors.New("invalid isPublic")
-	ErrInvalidIsBookmark  = errors.New("invalid isBookmark")
-	ErrInvalidCreatedAt   = errors.New("invalid createdAt")
-	ErrInvalidUpdatedAt   = errors.New("invalid updatedAt")
-	ErrInvalidURL         = errors.New("invalid URL")
-	ErrNotAuthorization   = errors.New("not authorization")
-	ErrNotAllowIpAddress  = errors.New("not allow ip address")
-	ErrSignInRequired     = errors.New("sign in required")
-	ErrNotAllowEmail      = errors.New("not allow email")
-	ErrPasswordIsRequired = errors.New("password is required")
-	ErrNotDiagramOwner    = errors.New("not diagram owner")
-	ErrUnpadError         = errors.New("unpad error. This could happen when incorrect encryption key is used")
-	ErrBlockSizeError     = errors.New("blocksize must be multiple of decoded message length")
+	ErrInvalidId               = errors.New("invalid id")
+	ErrInvalidTitle            = errors.New("invalid title")
+	ErrInvalidDiagram          = errors.New("invalid diagram")
+	ErrInvalidIsPublic         = errors.New("invalid isPublic")
+	ErrInvalidIsBookmark       = errors.New("invalid isBookmark")
+	ErrInvalidCreatedAt        = errors.New("invalid createdAt")
+	ErrInvalidUpdatedAt        = errors.New("invalid updatedAt")
+	ErrInvalidURL              = errors.New("invalid URL")
+	ErrNotAuthorization        = errors.New("not authorization")
+	ErrNotAllowIpAddress       = errors.New("not allow ip address")
+	ErrSignInRequired          = errors.New("sign in required")
+	ErrNotAllowEmail           = errors.New("not allow email")
+	ErrPasswordIsRequired      = errors.New("password is required")
+	ErrNotDiagramOwner         = errors.New("not diagram owner")
+	ErrInvalidShareCode        = errors.New("invalid share code")
+	ErrShareCodeConflict       = errors.New("share code is already in use")
+	ErrShareViewsExceeded      = errors.New("share view limit exceeded")
+	ErrEncryptKeyRequired      = errors.New("encrypt key is required")
+	ErrInvalidOAuthCode        = errors.New("invalid oauth code")
+	ErrGithubNotConnected      = errors.New("github account is not connected")
+	ErrGitSyncNotEnabled       = errors.New("git sync is not enabled")
+	ErrThumbnailNotFound       = errors.New("thumbnail not found")
+	ErrImageNotFound           = errors.New("image not found")
+	ErrImageTooLarge           = errors.New("image is too large")
+	ErrImageQuotaExceeded      = errors.New("image storage quota exceeded")
+	ErrUnsupportedImage        = errors.New("unsupported image type")
+	ErrImageStoreDisabled      = errors.New("image storage is not configured")
+	ErrInvalidArchive          = errors.New("invalid account archive")
+	ErrAccountDeletionNotFound = errors.New("account deletion not found")
+	ErrAccountDeletionStarted  = errors.New("account deletion has already started")
+	ErrItemNotInTrash          = errors.New("item is not in the trash")
+	ErrGithubRateLimited       = errors.New("github rate limit exceeded")
+	ErrUnpadError              = errors.New("unpad error. This could happen when incorrect encryption key is used")
+	ErrBlockSizeError          = errors.New("blocksize must be multiple of decoded message length")
+	ErrUnknownCompression      = errors.New("unknown text compression version")
 )
 
 const (
@@ -31,6 +50,8 @@ const (
 	Forbidden       Code = "Forbidden"
 	URLExpired      Code = "URLExpired"
 	NoAuthorization Code = "NoAuthorization"
+	Conflict        Code = "Conflict"
+	RateLimited     Code = "RateLimited"
 
 	DecryptionFailed Code = "DecryptionFailed"
 	EncryptionFailed Code = "EncryptionFailed"
@@ -86,6 +107,14 @@ func NoAuthorizationError(err error) ServiceError {
 	return ServiceError{code: NoAuthorization, err: err}
 }
 
+func ConflictError(err error) ServiceError {
+	return ServiceError{code: Conflict, err: err}
+}
+
+func RateLimitedError(err error) ServiceError {
+	return ServiceError{code: RateLimited, err: err}
+}
+
 func DecryptionFailedError(err error) ServiceError {
 	return ServiceError{code: DecryptionFailed, err: err}
 }
@@ -117,14 +146,14 @@ func GetCode(err error) Code {
 	return UnKnown
 }
 
-func (e *RepositoryError) Unwrap() error {
+func (e RepositoryError) Unwrap() error {
 	return e.err
 }
 
-func (e *ServiceError) Unwrap() error {
+func (e ServiceError) Unwrap() error {
 	return e.err
 }
 
-func (e *DomainError) Unwrap() error {
+func (e DomainError) Unwrap() error {
 	return e.err
 }
@@ -20,6 +20,8 @@ func TestErrorMessages(t *testing.T) {
 		{"ForbiddenError", ForbiddenError(baseErr), "Forbidden"},
 		{"URLExpiredError", URLExpiredError(baseErr), "URLExpired"},
 		{"NoAuthorizationError", NoAuthorizationError(baseErr), "NoAuthorization"},
+		{"ConflictError", ConflictError(baseErr), "Conflict"},
+		{"RateLimitedError", RateLimitedError(baseErr), "RateLimited"},
 		{"DecryptionFailedError", DecryptionFailedError(baseErr), "DecryptionFailed"},
 		{"EncryptionFailedError", EncryptionFailedError(baseErr), "EncryptionFailed"},
 		{"InvalidParameterError", InvalidParameterError(baseErr), "InvalidParameter"},
@@ -48,6 +50,8 @@ func TestGetCode(t *testing.T) {
 		{"ForbiddenError", ForbiddenError(baseErr), Forbidden},
 		{"URLExpiredError", URLExpiredError(baseErr), URLExpired},
 		{"NoAuthorizationError", NoAuthorizationError(baseErr), NoAuthorization},
+		{"ConflictError", ConflictError(baseErr), Conflict},
+		{"RateLimitedError", RateLimitedError(baseErr), RateLimited},
 		{"DecryptionFailedError", DecryptionFailedError(baseErr), DecryptionFailed},
 		{"EncryptionFailedError", EncryptionFailedError(baseErr), EncryptionFailed},
 		{"InvalidParameterError", InvalidParameterError(baseErr), InvalidParameter},
@@ -102,6 +106,51 @@ func TestUnwrap(t *testing.T) {
 	}
 }
 
+func TestUnwrapValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+	}{
+		{"RepositoryError", NotFoundError(ErrGithubNotConnected), ErrGithubNotConnected},
+		{"ServiceError", ForbiddenError(ErrNotAuthorization), ErrNotAuthorization},
+		{"DomainError", InvalidParameterError(ErrInvalidOAuthCode), ErrInvalidOAuthCode},
+		{"wrapped RepositoryError", fmt.Errorf("get token: %w", NotFoundError(ErrGithubNotConnected)), ErrGithubNotConnected},
+		{"wrapped ServiceError", fmt.Errorf("exchange: %w", ForbiddenError(ErrNotAuthorization)), ErrNotAuthorization},
+		{"wrapped DomainError", fmt.Errorf("exchange: %w", InvalidParameterError(ErrInvalidOAuthCode)), ErrInvalidOAuthCode},
+		{"nested", UnKnownError(fmt.Errorf("encrypt: %w", ErrEncryptKeyRequired)), ErrEncryptKeyRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", tt.err, tt.sentinel)
+			}
+
+			if errors.Is(tt.err, ErrShareCodeConflict) {
+				t.Errorf("errors.Is(%v, %v) = true, want false", tt.err, ErrShareCodeConflict)
+			}
+		})
+	}
+}
+
+func TestAsValues(t *testing.T) {
+	var repoErr RepositoryError
+	if err := fmt.Errorf("wrapped: %w", NotFoundError(ErrGithubNotConnected)); !errors.As(err, &repoErr) || !errors.Is(repoErr, ErrGithubNotConnected) {
+		t.Error("errors.As should find a wrapped RepositoryError value")
+	}
+
+	var svcErr ServiceError
+	if err := fmt.Errorf("wrapped: %w", ForbiddenError(ErrNotAuthorization)); !errors.As(err, &svcErr) || !errors.Is(svcErr, ErrNotAuthorization) {
+		t.Error("errors.As should find a wrapped ServiceError value")
+	}
+
+	var domErr DomainError
+	if err := fmt.Errorf("wrapped: %w", InvalidParameterError(ErrInvalidOAuthCode)); !errors.As(err, &domErr) || !errors.Is(domErr, ErrInvalidOAuthCode) {
+		t.Error("errors.As should find a wrapped DomainError value")
+	}
+}
+
 func TestSentinelErrors(t *testing.T) {
 	sentinels := []error{
 		ErrInvalidId,
@@ -0,0 +1,298 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/samber/mo"
+)
+
+const (
+	DefaultBranch = "main"
+	authorName    = "TextUSM"
+	authorEmail   = "noreply@textusm.com"
+	maxAttempts   = 3
+)
+
+// Remote is a git URL or a path to a local repository.
+type Remote string
+type Branch string
+
+// WorkDir is where the repository is checked out. It is owned by the server and reset on every update.
+type WorkDir string
+
+var (
+	ErrPathOutsideRepository = errors.New("path is outside the repository")
+	errRejected              = errors.New("push rejected")
+)
+
+// Repository is a working copy of a remote repository that is kept in step with the remote branch.
+type Repository struct {
+	mu     sync.Mutex
+	remote string
+	branch string
+	dir    string
+}
+
+func NewRepository(remote Remote, branch Branch, dir WorkDir) *Repository {
+	b := string(branch)
+	d := string(dir)
+
+	if b == "" {
+		b = DefaultBranch
+	}
+
+	if d == "" {
+		d = filepath.Join(os.TempDir(), "textusm-git-sync")
+	}
+
+	return &Repository{
+		remote: string(remote),
+		branch: b,
+		dir:    d,
+	}
+}
+
+// Enabled reports whether a remote is configured.
+func (r *Repository) Enabled() bool {
+	return r.remote != ""
+}
+
+// Update checks out the latest remote branch, runs fn against it and pushes whatever fn changed.
+// fn is run again on a fresh checkout when the push is rejected because the remote moved on.
+func (r *Repository) Update(ctx context.Context, message string, fn func(tree *Tree) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+
+	for range maxAttempts {
+		if err = r.checkout(ctx); err != nil {
+			return err
+		}
+
+		if err = fn(&Tree{dir: r.dir}); err != nil {
+			return err
+		}
+
+		err = r.commit(ctx, message)
+
+		if err == nil || !errors.Is(err, errRejected) {
+			return err
+		}
+
+		slog.Info("git push rejected, retrying", "remote", r.remote, "branch", r.branch)
+	}
+
+	return err
+}
+
+func (r *Repository) checkout(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err != nil {
+		if err := os.MkdirAll(r.dir, 0o700); err != nil {
+			return err
+		}
+
+		if _, err := r.git(ctx, "init", "--quiet"); err != nil {
+			return err
+		}
+
+		if _, err := r.git(ctx, "remote", "add", "origin", r.remote); err != nil {
+			return err
+		}
+	}
+
+	if _, err := r.git(ctx, "fetch", "--quiet", "--prune", "origin"); err != nil {
+		return err
+	}
+
+	remoteRef := "refs/remotes/origin/" + r.branch
+
+	if _, err := r.git(ctx, "rev-parse", "--verify", "--quiet", remoteRef); err != nil {
+		// The branch does not exist on the remote yet, so start from an empty tree.
+		if _, err := r.git(ctx, "symbolic-ref", "HEAD", "refs/heads/"+r.branch); err != nil {
+			return err
+		}
+
+		_, _ = r.git(ctx, "update-ref", "-d", "refs/heads/"+r.branch)
+
+		if _, err := r.git(ctx, "read-tree", "--empty"); err != nil {
+			return err
+		}
+	} else if _, err := r.git(ctx, "checkout", "--quiet", "--force", "-B", r.branch, remoteRef); err != nil {
+		return err
+	}
+
+	_, err := r.git(ctx, "clean", "--quiet", "-fdx")
+	return err
+}
+
+func (r *Repository) commit(ctx context.Context, message string) error {
+	if _, err := r.git(ctx, "add", "--all"); err != nil {
+		return err
+	}
+
+	status, err := r.git(ctx, "status", "--porcelain")
+
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+
+	if _, err := r.git(ctx, "-c", "user.name="+authorName, "-c", "user.email="+authorEmail, "commit", "--quiet", "-m", message); err != nil {
+		return err
+	}
+
+	if out, err := r.git(ctx, "push", "--quiet", "origin", "HEAD:refs/heads/"+r.branch); err != nil {
+		if strings.Contains(out, "[rejected]") || strings.Contains(out, "fetch first") || strings.Contains(out, "non-fast-forward") {
+			return fmt.Errorf("%w: %w", errRejected, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) git(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return stderr.String(), fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// Tree gives access to the files of a checked out repository. Paths are slash separated and relative to the repository root.
+type Tree struct {
+	dir string
+}
+
+// ReadFile returns the content of the file, or None if it does not exist.
+func (t *Tree) ReadFile(path string) mo.Result[mo.Option[string]] {
+	p, err := t.resolve(path)
+
+	if err != nil {
+		return mo.Err[mo.Option[string]](err)
+	}
+
+	b, err := os.ReadFile(p)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return mo.Ok(mo.None[string]())
+	}
+
+	if err != nil {
+		return mo.Err[mo.Option[string]](err)
+	}
+
+	return mo.Ok(mo.Some(string(b)))
+}
+
+func (t *Tree) WriteFile(path, content string) error {
+	p, err := t.resolve(path)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, []byte(content), 0o600)
+}
+
+func (t *Tree) RemoveFile(path string) error {
+	p, err := t.resolve(path)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// ListFiles returns the names of the regular files directly inside dir.
+func (t *Tree) ListFiles(dir string) mo.Result[[]string] {
+	p, err := t.resolve(dir)
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	entries, err := os.ReadDir(p)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return mo.Ok([]string{})
+	}
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return mo.Ok(names)
+}
+
+// resolve returns the path of a file in the tree. Symbolic links come from the remote, so no component of the
+// path may be one, or a pushed link could read or write files outside the tree.
+func (t *Tree) resolve(path string) (string, error) {
+	p := filepath.Join(t.dir, filepath.FromSlash(path))
+	rel, err := filepath.Rel(t.dir, p)
+
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) ||
+		rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrPathOutsideRepository, path)
+	}
+
+	component := t.dir
+
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		component = filepath.Join(component, name)
+		info, err := os.Lstat(component)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %s is a symbolic link", ErrPathOutsideRepository, path)
+		}
+	}
+
+	return p, nil
+}
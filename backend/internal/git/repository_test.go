@@ -0,0 +1,66 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTreeRejectsPathsOutsideRepository(t *testing.T) {
+	tree := &Tree{dir: t.TempDir()}
+
+	for _, p := range []string{"../outside.usm", "a/../../outside.usm", ".git/config", ""} {
+		if err := tree.WriteFile(p, "text"); !errors.Is(err, ErrPathOutsideRepository) {
+			t.Errorf("WriteFile(%q) error = %v, want ErrPathOutsideRepository", p, err)
+		}
+	}
+
+	if err := tree.WriteFile("uid/.github/a.usm", "text"); err != nil {
+		t.Errorf("WriteFile() error = %v", err)
+	}
+}
+
+func TestTreeRejectsSymbolicLinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret")
+
+	if err := os.WriteFile(secret, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "uid"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(secret, filepath.Join(dir, "uid", "a.usm")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := &Tree{dir: dir}
+
+	if ret := tree.ReadFile("uid/a.usm"); !errors.Is(ret.Error(), ErrPathOutsideRepository) {
+		t.Errorf("ReadFile() of a link = %v, want ErrPathOutsideRepository", ret.Error())
+	}
+
+	if err := tree.WriteFile("uid/a.usm", "text"); !errors.Is(err, ErrPathOutsideRepository) {
+		t.Errorf("WriteFile() to a link error = %v, want ErrPathOutsideRepository", err)
+	}
+
+	if err := tree.WriteFile("linked/b.usm", "text"); !errors.Is(err, ErrPathOutsideRepository) {
+		t.Errorf("WriteFile() through a linked directory error = %v, want ErrPathOutsideRepository", err)
+	}
+
+	if b, err := os.ReadFile(secret); err != nil || string(b) != "secret" {
+		t.Errorf("file outside the tree = %q, %v", b, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "b.usm")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file was written outside the tree: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+const (
+	DefaultBaseURL      = "https://api.github.com"
+	DefaultOAuthBaseURL = "https://github.com"
+)
+
+type BaseURL string
+type OAuthBaseURL string
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	Scope       string `json:"scope"`
+	TokenType   string `json:"token_type"`
+}
+
+type File struct {
+	Filename  string `json:"filename"`
+	RawURL    string `json:"raw_url"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+type History struct {
+	Version string `json:"version"`
+}
+
+type Gist struct {
+	UpdatedAt time.Time       `json:"updated_at"`
+	Files     map[string]File `json:"files"`
+	History   []History       `json:"history"`
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	HTMLURL   string          `json:"html_url"`
+}
+
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	oauthBaseURL string
+}
+
+func NewClient(baseURL BaseURL, oauthBaseURL OAuthBaseURL) *Client {
+	u := string(baseURL)
+	o := string(oauthBaseURL)
+
+	if u == "" {
+		u = DefaultBaseURL
+	}
+
+	if o == "" {
+		o = DefaultOAuthBaseURL
+	}
+
+	return &Client{
+		httpClient:   &http.Client{Timeout: time.Duration(30) * time.Second},
+		baseURL:      strings.TrimSuffix(u, "/"),
+		oauthBaseURL: strings.TrimSuffix(o, "/"),
+	}
+}
+
+// GistID converts an item ID back to a gist ID. Postgres stores gist IDs as UUIDs, which adds dashes.
+func GistID(itemID string) string {
+	return strings.ReplaceAll(itemID, "-", "")
+}
+
+// GetGist fetches the gist. accessToken may be empty for public and secret gists.
+func (c *Client) GetGist(ctx context.Context, gistID, accessToken string) mo.Result[*Gist] {
+	res := c.get(ctx, c.baseURL+"/gists/"+url.PathEscape(gistID), accessToken)
+
+	if res.IsError() {
+		return mo.Err[*Gist](res.Error())
+	}
+
+	return c.decodeGist(ctx, res.MustGet(), accessToken)
+}
+
+// GetGistRevision fetches the gist as it was at the given revision.
+func (c *Client) GetGistRevision(ctx context.Context, gistID, revision, accessToken string) mo.Result[*Gist] {
+	res := c.get(ctx, c.baseURL+"/gists/"+url.PathEscape(gistID)+"/"+url.PathEscape(revision), accessToken)
+
+	if res.IsError() {
+		return mo.Err[*Gist](res.Error())
+	}
+
+	return c.decodeGist(ctx, res.MustGet(), accessToken)
+}
+
+// CreateGist creates a secret gist holding a single file.
+func (c *Client) CreateGist(ctx context.Context, accessToken, description, filename, content string) mo.Result[*Gist] {
+	body := map[string]interface{}{
+		"description": description,
+		"public":      false,
+		"files":       map[string]interface{}{filename: map[string]string{"content": content}},
+	}
+	res := c.send(ctx, http.MethodPost, c.baseURL+"/gists", body, bearer(accessToken))
+
+	if res.IsError() {
+		return mo.Err[*Gist](res.Error())
+	}
+
+	return c.decodeGist(ctx, res.MustGet(), accessToken)
+}
+
+// UpdateGist replaces the content of a single file in the gist.
+func (c *Client) UpdateGist(ctx context.Context, accessToken, gistID, filename, content string) mo.Result[*Gist] {
+	body := map[string]interface{}{
+		"files": map[string]interface{}{filename: map[string]string{"content": content}},
+	}
+	res := c.send(ctx, http.MethodPatch, c.baseURL+"/gists/"+url.PathEscape(gistID), body, bearer(accessToken))
+
+	if res.IsError() {
+		return mo.Err[*Gist](res.Error())
+	}
+
+	return c.decodeGist(ctx, res.MustGet(), accessToken)
+}
+
+// ExchangeCode exchanges an OAuth authorization code for an access token.
+func (c *Client) ExchangeCode(ctx context.Context, clientID, clientSecret, code string) mo.Result[*Token] {
+	body := map[string]string{
+		"client_id":     clientID,
+		"client_secret": clientSecret,
+		"code":          code,
+	}
+	res := c.send(ctx, http.MethodPost, c.oauthBaseURL+"/login/oauth/access_token", body, func(req *http.Request) {
+		req.Header.Set("Accept", "application/json")
+	})
+
+	if res.IsError() {
+		return mo.Err[*Token](res.Error())
+	}
+
+	var token struct {
+		Token
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.Unmarshal(res.MustGet(), &token); err != nil {
+		return mo.Err[*Token](err)
+	}
+
+	// GitHub reports a bad or expired code with 200 and an error field.
+	if token.Error != "" || token.AccessToken == "" {
+		return mo.Err[*Token](e.InvalidParameterError(fmt.Errorf("%w: %s %s", e.ErrInvalidOAuthCode, token.Error, token.ErrorDescription)))
+	}
+
+	return mo.Ok(&token.Token)
+}
+
+// RevokeToken revokes the access token granted to the OAuth app.
+func (c *Client) RevokeToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
+	body := map[string]string{"access_token": accessToken}
+	res := c.send(ctx, http.MethodDelete, c.baseURL+"/applications/"+url.PathEscape(clientID)+"/token", body, func(req *http.Request) {
+		req.SetBasicAuth(clientID, clientSecret)
+	})
+
+	return res.Error()
+}
+
+func (c *Client) decodeGist(ctx context.Context, body []byte, accessToken string) mo.Result[*Gist] {
+	var gist Gist
+
+	if err := json.Unmarshal(body, &gist); err != nil {
+		return mo.Err[*Gist](err)
+	}
+
+	for name, file := range gist.Files {
+		if !file.Truncated || file.RawURL == "" {
+			continue
+		}
+
+		raw := c.get(ctx, file.RawURL, accessToken)
+
+		if raw.IsError() {
+			return mo.Err[*Gist](raw.Error())
+		}
+
+		file.Content = string(raw.MustGet())
+		file.Truncated = false
+		gist.Files[name] = file
+	}
+
+	return mo.Ok(&gist)
+}
+
+// Revision returns the SHA of the latest version of the gist.
+func (g *Gist) Revision() string {
+	if len(g.History) == 0 {
+		return ""
+	}
+
+	return g.History[0].Version
+}
+
+// Content returns the content of the file with the given name, falling back to the first file by name.
+func (g *Gist) Content(filename string) mo.Option[string] {
+	if file, ok := g.Files[filename]; ok {
+		return mo.Some(file.Content)
+	}
+
+	names := make([]string, 0, len(g.Files))
+
+	for name := range g.Files {
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return mo.None[string]()
+	}
+
+	slices.Sort(names)
+	return mo.Some(g.Files[names[0]].Content)
+}
+
+func bearer(accessToken string) func(req *http.Request) {
+	return func(req *http.Request) {
+		if accessToken != "" {
+			req.Header.Add("Authorization", "token "+accessToken)
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, u, accessToken string) mo.Result[[]byte] {
+	return c.send(ctx, http.MethodGet, u, nil, bearer(accessToken))
+}
+
+func (c *Client) send(ctx context.Context, method, u string, body interface{}, auth func(req *http.Request)) mo.Result[[]byte] {
+	var reader io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+
+		if err != nil {
+			return mo.Err[[]byte](err)
+		}
+
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+
+	if err != nil {
+		return mo.Err[[]byte](err)
+	}
+
+	req.Header.Add("Accept", "application/vnd.github.v3+json")
+
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	if auth != nil {
+		auth(req)
+	}
+
+	res, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return mo.Err[[]byte](err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode == http.StatusNotFound {
+		return mo.Err[[]byte](e.NotFoundError(fmt.Errorf("github: %s not found", u)))
+	}
+
+	// GitHub answers 403 instead of 429 when the primary rate limit is exhausted.
+	if res.StatusCode == http.StatusTooManyRequests || (res.StatusCode == http.StatusForbidden && res.Header.Get("X-RateLimit-Remaining") == "0") {
+		return mo.Err[[]byte](e.RateLimitedError(fmt.Errorf("%w: reset at %s", e.ErrGithubRateLimited, res.Header.Get("X-RateLimit-Reset"))))
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return mo.Err[[]byte](fmt.Errorf("github: unexpected status %d", res.StatusCode))
+	}
+
+	b, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return mo.Err[[]byte](err)
+	}
+
+	return mo.Ok(b)
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	e "github.com/harehare/textusm/internal/error"
+)
+
+func TestGetGist(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/gists/exists", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"id":"exists","files":{"b":{"content":"b"},"a":{"truncated":true,"raw_url":"` + server.URL + `/raw/a"}}}`))
+	})
+	mux.HandleFunc("/raw/a", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("full content"))
+	})
+
+	client := NewClient(BaseURL(server.URL+"/"), "")
+
+	gist := client.GetGist(context.Background(), "exists", "secret")
+
+	if gist.IsError() {
+		t.Fatal(gist.Error())
+	}
+
+	if got := gist.MustGet().Content("b").OrEmpty(); got != "b" {
+		t.Fatalf("expected b, got %s", got)
+	}
+
+	if got := gist.MustGet().Content("missing").OrEmpty(); got != "full content" {
+		t.Fatalf("expected truncated file to be fetched, got %s", got)
+	}
+
+	missing := client.GetGist(context.Background(), "missing", "secret")
+
+	if e.GetCode(missing.Error()) != e.NotFound {
+		t.Fatalf("expected not found, got %v", missing.Error())
+	}
+}
+
+func TestGetGistRateLimited(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		remaining string
+		want      e.Code
+	}{
+		{"primary rate limit", http.StatusForbidden, "0", e.RateLimited},
+		{"secondary rate limit", http.StatusTooManyRequests, "", e.RateLimited},
+		{"forbidden", http.StatusForbidden, "10", e.UnKnown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.remaining != "" {
+					w.Header().Set("X-RateLimit-Remaining", tt.remaining)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			gist := NewClient(BaseURL(server.URL+"/"), "").GetGist(context.Background(), "id", "")
+
+			if gist.IsOk() || e.GetCode(gist.Error()) != tt.want {
+				t.Fatalf("expected %s, got %v", tt.want, gist.Error())
+			}
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "id" || secret != "secret" || r.URL.Path != "/applications/id/token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewClient(BaseURL(server.URL), "")
+
+	if err := client.RevokeToken(context.Background(), "id", "secret", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	status = http.StatusUnprocessableEntity
+
+	if err := client.RevokeToken(context.Background(), "id", "secret", "token"); err == nil {
+		t.Fatal("expected an error for a failed revocation")
+	}
+}
+
+func TestGistID(t *testing.T) {
+	if got := GistID("aa5a315d-61ae-9438-b18d-0f7f7d0e0e5d"); got != "aa5a315d61ae9438b18d0f7f7d0e0e5d" {
+		t.Fatalf("unexpected gist id %s", got)
+	}
+}
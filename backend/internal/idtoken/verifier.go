@@ -0,0 +1,133 @@
+// Package idtoken verifies Firebase ID tokens, remembering the tokens it has verified so that a request
+// seldom waits for the revocation check.
+package idtoken
+
+import (
+	"context"
+	"crypto/sha256"
+	"expvar"
+	"sync"
+	"time"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+)
+
+// maxTokens bounds the tokens remembered at once. Expired tokens are dropped when it is reached,
+// and every token when that is not enough.
+const maxTokens = 10000
+
+// Stats counts the tokens found in the cache and the ones verified with Firebase.
+// It is published with expvar as "idtoken".
+var Stats = expvar.NewMap("idtoken")
+
+type verifyFunc func(ctx context.Context, idToken string) (*auth.Token, error)
+
+type entry struct {
+	token     *auth.Token
+	expiresAt time.Time
+	checkedAt time.Time
+}
+
+// Verifier verifies ID tokens and checks that they have not been revoked. A verified token is trusted until
+// it expires, and checked for revocation again once revocationCheckInterval has passed since the last check.
+type Verifier struct {
+	verify                  verifyFunc
+	revocationCheckInterval time.Duration
+	now                     func() time.Time
+
+	mu     sync.Mutex
+	tokens map[[sha256.Size]byte]*entry
+	// generation changes on every Forget, so that a verification that raced with it is not remembered.
+	generation uint64
+}
+
+func NewVerifier(app *firebase.App, revocationCheckInterval time.Duration) *Verifier {
+	return newVerifier(func(ctx context.Context, idToken string) (*auth.Token, error) {
+		client, err := app.Auth(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return client.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	}, revocationCheckInterval)
+}
+
+func newVerifier(verify verifyFunc, revocationCheckInterval time.Duration) *Verifier {
+	return &Verifier{
+		verify:                  verify,
+		revocationCheckInterval: revocationCheckInterval,
+		now:                     time.Now,
+		tokens:                  map[[sha256.Size]byte]*entry{},
+	}
+}
+
+// Verify returns the claims of idToken, verifying it with Firebase unless it was verified recently enough.
+func (v *Verifier) Verify(ctx context.Context, idToken string) (*auth.Token, error) {
+	key := sha256.Sum256([]byte(idToken))
+	now := v.now()
+
+	v.mu.Lock()
+	ent, ok := v.tokens[key]
+	generation := v.generation
+	v.mu.Unlock()
+
+	if ok && now.Before(ent.expiresAt) && now.Sub(ent.checkedAt) < v.revocationCheckInterval {
+		Stats.Add("hits", 1)
+		return ent.token, nil
+	}
+
+	Stats.Add("misses", 1)
+	token, err := v.verify(ctx, idToken)
+
+	if err != nil {
+		v.mu.Lock()
+		delete(v.tokens, key)
+		v.mu.Unlock()
+
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.generation != generation {
+		return token, nil
+	}
+
+	if len(v.tokens) >= maxTokens {
+		v.evict(now)
+	}
+
+	v.tokens[key] = &entry{token: token, expiresAt: time.Unix(token.Expires, 0), checkedAt: now}
+
+	return token, nil
+}
+
+// Forget drops the tokens of uid, so that they are checked for revocation on their next use.
+func (v *Verifier) Forget(uid string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.generation++
+
+	for key, ent := range v.tokens {
+		if ent.token.UID == uid {
+			delete(v.tokens, key)
+		}
+	}
+}
+
+// evict drops the expired tokens, or every token when that frees too little. It must be called with v.mu held.
+func (v *Verifier) evict(now time.Time) {
+	for key, ent := range v.tokens {
+		if !now.Before(ent.expiresAt) {
+			delete(v.tokens, key)
+		}
+	}
+
+	if len(v.tokens) >= maxTokens {
+		clear(v.tokens)
+	}
+}
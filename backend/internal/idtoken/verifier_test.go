@@ -0,0 +1,125 @@
+package idtoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFirebase struct {
+	calls   int
+	expires time.Time
+	err     error
+	during  func()
+}
+
+func (f *fakeFirebase) verify(ctx context.Context, idToken string) (*auth.Token, error) {
+	f.calls++
+
+	if f.during != nil {
+		f.during()
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &auth.Token{UID: idToken + "-uid", Expires: f.expires.Unix()}, nil
+}
+
+func newTestVerifier(now *time.Time) (*Verifier, *fakeFirebase) {
+	f := &fakeFirebase{expires: now.Add(time.Hour)}
+	v := newVerifier(f.verify, 5*time.Minute)
+	v.now = func() time.Time { return *now }
+
+	return v, f
+}
+
+func TestVerifyCachesToken(t *testing.T) {
+	now := time.Now()
+	v, f := newTestVerifier(&now)
+
+	for range 3 {
+		token, err := v.Verify(context.Background(), "token")
+		require.NoError(t, err)
+		assert.Equal(t, "token-uid", token.UID)
+	}
+
+	assert.Equal(t, 1, f.calls)
+
+	_, err := v.Verify(context.Background(), "other")
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.calls)
+}
+
+func TestVerifyChecksRevocationAgain(t *testing.T) {
+	now := time.Now()
+	v, f := newTestVerifier(&now)
+
+	_, err := v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+
+	now = now.Add(5 * time.Minute)
+	f.err = errors.New("revoked")
+
+	_, err = v.Verify(context.Background(), "token")
+	assert.Error(t, err)
+	assert.Equal(t, 2, f.calls)
+
+	_, err = v.Verify(context.Background(), "token")
+	assert.Error(t, err)
+	assert.Equal(t, 3, f.calls)
+}
+
+func TestVerifyExpiredToken(t *testing.T) {
+	now := time.Now()
+	v, f := newTestVerifier(&now)
+	f.expires = now.Add(time.Minute)
+
+	_, err := v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+
+	_, err = v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.calls)
+}
+
+func TestForget(t *testing.T) {
+	now := time.Now()
+	v, f := newTestVerifier(&now)
+
+	_, err := v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), "other")
+	require.NoError(t, err)
+
+	v.Forget("token-uid")
+
+	_, err = v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), "other")
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.calls)
+}
+
+func TestForgetDuringVerify(t *testing.T) {
+	now := time.Now()
+	v, f := newTestVerifier(&now)
+	f.during = func() { v.Forget("token-uid") }
+
+	_, err := v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+
+	f.during = nil
+
+	_, err = v.Verify(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.calls)
+}
@@ -0,0 +1,269 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"golang.org/x/sync/errgroup"
+)
+
+// TextThreshold is the size in bytes of the stored text above which it is moved to blob storage.
+type TextThreshold int
+
+const (
+	textBlob        = "text"
+	thumbnailBlob   = "thumbnail"
+	textContentType = "text/plain; charset=utf-8"
+	maxLoads        = 8
+	trashPageSize   = 100
+)
+
+// ItemRepository moves large text and thumbnails of the items it saves to blob storage and loads them back on read.
+type ItemRepository struct {
+	repo      itemRepo.ItemRepository
+	store     blobRepo.BlobStore
+	threshold int
+}
+
+// NewItemRepository returns repo unchanged when store is nil.
+func NewItemRepository(repo itemRepo.ItemRepository, store blobRepo.BlobStore, threshold TextThreshold) itemRepo.ItemRepository {
+	if store == nil {
+		return repo
+	}
+
+	return &ItemRepository{repo: repo, store: store, threshold: int(threshold)}
+}
+
+func (r *ItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	item := r.repo.FindByID(ctx, userID, itemID, isPublic, fields)
+
+	if item.IsError() {
+		return item
+	}
+
+	if err := r.load(ctx, item.MustGet(), fields); err != nil {
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	return item
+}
+
+func (r *ItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	items := r.repo.FindByIDs(ctx, userID, itemIDs, isPublic, fields)
+
+	if items.IsError() || fields == v.MetadataOnly {
+		return items
+	}
+
+	return r.loadAll(ctx, items.MustGet(), fields)
+}
+
+func (r *ItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	items := r.repo.Find(ctx, userID, offset, limit, isPublic, filter, fields)
+
+	if items.IsError() || fields == v.MetadataOnly {
+		return items
+	}
+
+	return r.loadAll(ctx, items.MustGet(), fields)
+}
+
+// Save writes the blobs of each save under new keys, so that no blob an item refers to is overwritten before the
+// item is, and deletes the blobs it replaces once the save has committed.
+func (r *ItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	prefix := keyPrefix(userID, item.ID(), isPublic) + uuid.NewString() + "/"
+	stored := item
+	var written []string
+
+	if text := item.EncryptedText(); item.TextRef().IsAbsent() && len(text) > r.threshold {
+		key := prefix + textBlob
+
+		if err := r.store.Put(ctx, key, &blobRepo.Blob{ContentType: textContentType, Data: []byte(text)}).Error(); err != nil {
+			return mo.Err[*diagramitem.DiagramItem](err)
+		}
+
+		written = append(written, key)
+		stored = stored.OffloadText(key)
+	}
+
+	if thumbnail := item.Thumbnail(); thumbnail != nil && item.ThumbnailRef().IsAbsent() {
+		if blob, ok := blobRepo.ParseDataURL(*thumbnail); ok {
+			key := prefix + thumbnailBlob
+
+			if err := r.store.Put(ctx, key, blob).Error(); err != nil {
+				deleteBlobs(ctx, r.store, written)
+				return mo.Err[*diagramitem.DiagramItem](err)
+			}
+
+			written = append(written, key)
+			stored = stored.OffloadThumbnail(key)
+		}
+	}
+
+	// The blob references are kept in place of the text and the thumbnail.
+	previous, err := r.blobsOf(ctx, userID, item.ID(), isPublic)
+
+	if err != nil {
+		deleteBlobs(ctx, r.store, written)
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	saved := r.repo.Save(ctx, userID, stored, isPublic)
+
+	if saved.IsError() {
+		deleteBlobs(ctx, r.store, written)
+		return saved
+	}
+
+	// Blobs the item no longer refers to are left for a rolled back transaction until it commits.
+	replaced := lo.Without(previous, lo.Compact([]string{stored.TextRef().OrEmpty(), stored.ThumbnailRef().OrEmpty()})...)
+	db.AfterCommit(ctx, func() { deleteBlobs(context.WithoutCancel(ctx), r.store, replaced) })
+
+	return mo.Ok(item)
+}
+
+func (r *ItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	blobs, err := r.blobsOf(ctx, userID, itemID, isPublic)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	ret := r.repo.Delete(ctx, userID, itemID, isPublic)
+
+	if ret.IsError() {
+		return ret
+	}
+
+	db.AfterCommit(ctx, func() { deleteBlobs(context.WithoutCancel(ctx), r.store, blobs) })
+	return ret
+}
+
+// blobsOf returns the keys of the blobs the stored item refers to. Trashed items are only found in the trash.
+func (r *ItemRepository) blobsOf(ctx context.Context, userID, itemID string, isPublic bool) ([]string, error) {
+	item := r.repo.FindByID(ctx, userID, itemID, isPublic, v.AllFields)
+
+	if item.IsError() && e.GetCode(item.Error()) != e.NotFound {
+		return nil, item.Error()
+	}
+
+	if item.IsError() && !isPublic {
+		item = r.findTrashed(ctx, userID, itemID)
+	}
+
+	if item.IsError() {
+		return nil, nil
+	}
+
+	return lo.Compact([]string{item.MustGet().TextRef().OrEmpty(), item.MustGet().ThumbnailRef().OrEmpty()}), nil
+}
+
+func (r *ItemRepository) findTrashed(ctx context.Context, userID, itemID string) mo.Result[*diagramitem.DiagramItem] {
+	for offset := 0; ; offset += trashPageSize {
+		items := r.repo.FindTrash(ctx, userID, offset, trashPageSize, v.AllFields)
+
+		if items.IsError() {
+			return mo.Err[*diagramitem.DiagramItem](items.Error())
+		}
+
+		if item, ok := lo.Find(items.MustGet(), func(item *diagramitem.DiagramItem) bool { return item.ID() == itemID }); ok {
+			return mo.Ok(item)
+		}
+
+		if len(items.MustGet()) < trashPageSize {
+			return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrNotDiagramOwner))
+		}
+	}
+}
+
+func (r *ItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	items := r.repo.FindTrash(ctx, userID, offset, limit, fields)
+
+	if items.IsError() || fields == v.MetadataOnly {
+		return items
+	}
+
+	return r.loadAll(ctx, items.MustGet(), fields)
+}
+
+func (r *ItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	return r.repo.FindExpiredTrash(ctx, deletedBefore, limit)
+}
+
+// Trash and Restore leave the blobs of the item where they are, since the private item keeps referring to them.
+func (r *ItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	return r.repo.Trash(ctx, userID, itemID, deletedAt)
+}
+
+func (r *ItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	return r.repo.Restore(ctx, userID, itemID)
+}
+
+func (r *ItemRepository) loadAll(ctx context.Context, items []*diagramitem.DiagramItem, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxLoads)
+
+	for _, item := range items {
+		g.Go(func() error {
+			return r.load(gctx, item, fields)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *ItemRepository) load(ctx context.Context, item *diagramitem.DiagramItem, fields v.Projection) error {
+	if key, ok := item.TextRef().Get(); ok && fields.Text {
+		blob := r.store.Get(ctx, key)
+
+		if blob.IsError() {
+			return fmt.Errorf("load text of %s: %w", item.ID(), blob.Error())
+		}
+
+		item.UpdateEncryptedText(string(blob.MustGet().Data))
+	}
+
+	if key, ok := item.ThumbnailRef().Get(); ok && fields.Thumbnail {
+		blob := r.store.Get(ctx, key)
+
+		if blob.IsError() {
+			return fmt.Errorf("load thumbnail of %s: %w", item.ID(), blob.Error())
+		}
+
+		item.LoadThumbnail(blob.MustGet().DataURL())
+	}
+
+	return nil
+}
+
+// deleteBlobs removes blobs that are no longer referenced. A failure only leaves garbage behind, so it is logged.
+func deleteBlobs(ctx context.Context, store blobRepo.BlobStore, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key).Error(); err != nil {
+			slog.Warn("Failed delete blob", "key", key, "error", err)
+		}
+	}
+}
+
+func keyPrefix(userID, itemID string, isPublic bool) string {
+	if isPublic {
+		return "public/" + itemID + "/"
+	}
+
+	return "users/" + userID + "/items/" + itemID + "/"
+}
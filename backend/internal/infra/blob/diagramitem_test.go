@@ -0,0 +1,263 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/infra/local"
+	"github.com/samber/mo"
+)
+
+// memoryItemRepository keeps the items exactly as the wrapped repository would store them.
+type memoryItemRepository struct {
+	items map[string]*diagramitem.DiagramItem
+}
+
+func (r *memoryItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields values.Projection) mo.Result[*diagramitem.DiagramItem] {
+	item, ok := r.items[itemID]
+
+	if !ok || item.IsTrashed() {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(e.ErrNotDiagramOwner))
+	}
+
+	copied := *item
+	return mo.Ok(&copied)
+}
+
+func (r *memoryItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields values.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+
+	for _, itemID := range itemIDs {
+		if item, err := r.FindByID(ctx, userID, itemID, isPublic, fields).Get(); err == nil {
+			items = append(items, item)
+		}
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *memoryItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter values.ItemFilter, fields values.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return r.list(false)
+}
+
+func (r *memoryItemRepository) list(trashed bool) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+
+	for _, item := range r.items {
+		if item.IsTrashed() == trashed {
+			copied := *item
+			items = append(items, &copied)
+		}
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *memoryItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	r.items[item.ID()] = item
+	return mo.Ok(item)
+}
+
+func (r *memoryItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	delete(r.items, itemID)
+	return mo.Ok(true)
+}
+
+func (r *memoryItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields values.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return r.list(true)
+}
+
+func (r *memoryItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	return mo.Ok([]itemRepo.TrashedItem{})
+}
+
+func (r *memoryItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	item, ok := r.items[itemID]
+
+	if !ok || item.IsTrashed() {
+		return mo.Ok(false)
+	}
+
+	r.items[itemID] = item.Trash(deletedAt)
+	return mo.Ok(true)
+}
+
+func (r *memoryItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	item, ok := r.items[itemID]
+
+	if !ok || !item.IsTrashed() {
+		return mo.Ok(false)
+	}
+
+	r.items[itemID] = item.Restore()
+	return mo.Ok(true)
+}
+
+const thumbnail = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+func newItem(text string) *diagramitem.DiagramItem {
+	return diagramitem.New().
+		WithID("item1").
+		WithTitle("title").
+		WithPlainText(text).
+		WithThumbnail(mo.Some(thumbnail)).
+		WithDiagram(values.DiagramUserStoryMap).
+		WithCreatedAt(time.Now()).
+		WithUpdatedAt(time.Now()).
+		Build().
+		MustGet()
+}
+
+func TestSaveOffloadsLargeTextAndThumbnail(t *testing.T) {
+	inner := &memoryItemRepository{items: map[string]*diagramitem.DiagramItem{}}
+	store := local.NewBlobStore(local.Dir(t.TempDir()))
+	repo := NewItemRepository(inner, store, 16)
+	ctx := context.Background()
+	text := strings.Repeat("story\n", 10)
+
+	saved := repo.Save(ctx, "uid", newItem(text), false)
+
+	if saved.IsError() || saved.MustGet().Text() != text || *saved.MustGet().Thumbnail() != thumbnail {
+		t.Fatalf("Save() should return the item as given, got %v", saved)
+	}
+
+	stored := inner.items["item1"]
+	textKey, thumbnailKey := stored.TextRef().OrEmpty(), stored.ThumbnailRef().OrEmpty()
+
+	if !strings.HasPrefix(textKey, "users/uid/items/item1/") || !strings.HasPrefix(thumbnailKey, "users/uid/items/item1/") {
+		t.Fatalf("stored item should refer to blobs, got text %q thumbnail %v", stored.EncryptedText(), stored.Thumbnail())
+	}
+
+	found := repo.FindByID(ctx, "uid", "item1", false, values.AllFields)
+
+	if found.IsError() || found.MustGet().Text() != text || *found.MustGet().Thumbnail() != thumbnail {
+		t.Errorf("FindByID() should load blobs, got %v", found)
+	}
+
+	items := repo.Find(ctx, "uid", 0, 10, false, values.ItemFilter{}, values.Projection{Thumbnail: true}).MustGet()
+
+	if items[0].TextRef().IsAbsent() || *items[0].Thumbnail() != thumbnail {
+		t.Errorf("Find() should only load the requested fields, got text %q", items[0].EncryptedText())
+	}
+
+	repo.Save(ctx, "uid", newItem("short"), false)
+
+	if store.Get(ctx, textKey).IsOk() || store.Get(ctx, thumbnailKey).IsOk() {
+		t.Error("blobs of the previous save should be deleted")
+	}
+
+	if inner.items["item1"].EncryptedText() != "short" {
+		t.Errorf("short text should be stored inline, got %q", inner.items["item1"].EncryptedText())
+	}
+
+	thumbnailKey = inner.items["item1"].ThumbnailRef().OrEmpty()
+	repo.Delete(ctx, "uid", "item1", false)
+
+	if store.Get(ctx, thumbnailKey).IsOk() {
+		t.Error("thumbnail blob should be deleted with the item")
+	}
+}
+
+func TestSaveKeepsBlobsUntilCommit(t *testing.T) {
+	inner := &memoryItemRepository{items: map[string]*diagramitem.DiagramItem{}}
+	store := local.NewBlobStore(local.Dir(t.TempDir()))
+	repo := NewItemRepository(inner, store, 16)
+	tx := db.NewMemoryTx(&config.Config{Memory: memdb.New()})
+	ctx := context.Background()
+	text := strings.Repeat("story\n", 10)
+
+	repo.Save(ctx, "uid", newItem(text), false)
+	saved := *inner.items["item1"]
+
+	err := tx.Do(ctx, func(ctx context.Context) error {
+		repo.Save(ctx, "uid", newItem(strings.Repeat("epic\n", 10)), false)
+
+		if store.Get(ctx, saved.TextRef().OrEmpty()).IsError() {
+			t.Error("text blob should be kept until the save commits")
+		}
+
+		return errors.New("rollback")
+	})
+
+	if err == nil {
+		t.Fatal("Do() should return the error of the transaction")
+	}
+
+	// The wrapped repository does not roll back, so the item is put back as the database would have it.
+	inner.items["item1"] = &saved
+
+	if found := repo.FindByID(ctx, "uid", "item1", false, values.AllFields); found.IsError() || found.MustGet().Text() != text {
+		t.Fatalf("item should be readable after the rollback, got %v", found)
+	}
+
+	if err := tx.Do(ctx, func(ctx context.Context) error {
+		return repo.Delete(ctx, "uid", "item1", false).Error()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.Get(ctx, saved.TextRef().OrEmpty()).IsOk() {
+		t.Error("text blob should be deleted once the delete commits")
+	}
+}
+
+func TestDeleteTrashedItemDeletesBlobs(t *testing.T) {
+	inner := &memoryItemRepository{items: map[string]*diagramitem.DiagramItem{}}
+	store := local.NewBlobStore(local.Dir(t.TempDir()))
+	repo := NewItemRepository(inner, store, 16)
+	ctx := context.Background()
+
+	repo.Save(ctx, "uid", newItem(strings.Repeat("story\n", 10)), false)
+	textKey := inner.items["item1"].TextRef().OrEmpty()
+	repo.Trash(ctx, "uid", "item1", time.Now())
+	repo.Delete(ctx, "uid", "item1", false)
+
+	if store.Get(ctx, textKey).IsOk() {
+		t.Error("blobs of a trashed item should be deleted with it")
+	}
+}
+
+func TestNewItemRepositoryWithoutStore(t *testing.T) {
+	inner := &memoryItemRepository{items: map[string]*diagramitem.DiagramItem{}}
+
+	if repo := NewItemRepository(inner, nil, 16); repo != inner {
+		t.Error("NewItemRepository() without a store should return the repository as is")
+	}
+}
+
+func TestTrashKeepsBlobs(t *testing.T) {
+	inner := &memoryItemRepository{items: map[string]*diagramitem.DiagramItem{}}
+	repo := NewItemRepository(inner, local.NewBlobStore(local.Dir(t.TempDir())), 16)
+	ctx := context.Background()
+	text := strings.Repeat("story\n", 10)
+
+	repo.Save(ctx, "uid", newItem(text), false)
+
+	if !repo.Trash(ctx, "uid", "item1", time.Now()).MustGet() {
+		t.Fatal("Trash() should move the item to the trash")
+	}
+
+	trash := repo.FindTrash(ctx, "uid", 0, 10, values.AllFields)
+
+	if trash.IsError() || len(trash.MustGet()) != 1 || trash.MustGet()[0].Text() != text || *trash.MustGet()[0].Thumbnail() != thumbnail {
+		t.Fatalf("FindTrash() should load the blobs of trashed items, got %v", trash)
+	}
+
+	if !repo.Restore(ctx, "uid", "item1").MustGet() {
+		t.Fatal("Restore() should take the item out of the trash")
+	}
+
+	if item := repo.FindByID(ctx, "uid", "item1", false, values.AllFields); item.IsError() || item.MustGet().Text() != text {
+		t.Fatalf("restored item should be readable, got %v", item)
+	}
+}
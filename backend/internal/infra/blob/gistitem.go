@@ -0,0 +1,179 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	gistRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"golang.org/x/sync/errgroup"
+)
+
+// GistItemRepository moves the thumbnails of the gist items it saves to blob storage and loads them back on read.
+type GistItemRepository struct {
+	repo  gistRepo.GistItemRepository
+	store blobRepo.BlobStore
+}
+
+// NewGistItemRepository returns repo unchanged when store is nil.
+func NewGistItemRepository(repo gistRepo.GistItemRepository, store blobRepo.BlobStore) gistRepo.GistItemRepository {
+	if store == nil {
+		return repo
+	}
+
+	return &GistItemRepository{repo: repo, store: store}
+}
+
+func (r *GistItemRepository) FindByID(ctx context.Context, userID string, gistID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
+	item := r.repo.FindByID(ctx, userID, gistID, fields)
+
+	if item.IsError() || !fields.Thumbnail {
+		return item
+	}
+
+	if err := r.load(ctx, item.MustGet()); err != nil {
+		return mo.Err[*gistitem.GistItem](err)
+	}
+
+	return item
+}
+
+func (r *GistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	items := r.repo.FindByIDs(ctx, userID, gistIDs, fields)
+
+	if items.IsError() || !fields.Thumbnail {
+		return items
+	}
+
+	return r.loadAll(ctx, items.MustGet())
+}
+
+func (r *GistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	items := r.repo.Find(ctx, userID, offset, limit, fields)
+
+	if items.IsError() || !fields.Thumbnail {
+		return items
+	}
+
+	return r.loadAll(ctx, items.MustGet())
+}
+
+// Save writes the thumbnail of each save under a new key and deletes the one it replaces once the save has committed,
+// like ItemRepository.Save.
+func (r *GistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
+	stored := item
+	var written []string
+
+	if thumbnail := item.Thumbnail(); thumbnail != nil && item.ThumbnailRef().IsAbsent() {
+		if blob, ok := blobRepo.ParseDataURL(*thumbnail); ok {
+			key := gistKeyPrefix(userID, item.ID()) + uuid.NewString() + "/" + thumbnailBlob
+
+			if err := r.store.Put(ctx, key, blob).Error(); err != nil {
+				return mo.Err[*gistitem.GistItem](err)
+			}
+
+			written = append(written, key)
+			stored = stored.OffloadThumbnail(key)
+		}
+	}
+
+	previous, err := r.blobsOf(ctx, userID, item.ID())
+
+	if err != nil {
+		deleteBlobs(ctx, r.store, written)
+		return mo.Err[*gistitem.GistItem](err)
+	}
+
+	saved := r.repo.Save(ctx, userID, stored)
+
+	if saved.IsError() {
+		deleteBlobs(ctx, r.store, written)
+		return saved
+	}
+
+	replaced := lo.Without(previous, stored.ThumbnailRef().OrEmpty())
+	db.AfterCommit(ctx, func() { deleteBlobs(context.WithoutCancel(ctx), r.store, replaced) })
+
+	return mo.Ok(item)
+}
+
+func (r *GistItemRepository) Delete(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	blobs, err := r.blobsOf(ctx, userID, itemID)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	ret := r.repo.Delete(ctx, userID, itemID)
+
+	if ret.IsError() {
+		return ret
+	}
+
+	db.AfterCommit(ctx, func() { deleteBlobs(context.WithoutCancel(ctx), r.store, blobs) })
+	return ret
+}
+
+// blobsOf returns the key of the thumbnail the stored gist item refers to, if any.
+func (r *GistItemRepository) blobsOf(ctx context.Context, userID, gistID string) ([]string, error) {
+	item := r.repo.FindByID(ctx, userID, gistID, v.AllFields)
+
+	if item.IsError() && e.GetCode(item.Error()) != e.NotFound {
+		return nil, item.Error()
+	}
+
+	if item.IsError() {
+		return nil, nil
+	}
+
+	if key, ok := item.MustGet().ThumbnailRef().Get(); ok {
+		return []string{key}, nil
+	}
+
+	return nil, nil
+}
+
+func (r *GistItemRepository) loadAll(ctx context.Context, items []*gistitem.GistItem) mo.Result[[]*gistitem.GistItem] {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxLoads)
+
+	for _, item := range items {
+		g.Go(func() error {
+			return r.load(gctx, item)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *GistItemRepository) load(ctx context.Context, item *gistitem.GistItem) error {
+	key, ok := item.ThumbnailRef().Get()
+
+	if !ok {
+		return nil
+	}
+
+	blob := r.store.Get(ctx, key)
+
+	if blob.IsError() {
+		return fmt.Errorf("load thumbnail of %s: %w", item.ID(), blob.Error())
+	}
+
+	item.LoadThumbnail(blob.MustGet().DataURL())
+	return nil
+}
+
+func gistKeyPrefix(userID, itemID string) string {
+	return "users/" + userID + "/gists/" + itemID + "/"
+}
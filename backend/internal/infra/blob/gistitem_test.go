@@ -0,0 +1,91 @@
+package blob
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/infra/local"
+	"github.com/samber/mo"
+)
+
+type memoryGistItemRepository struct {
+	items map[string]*gistitem.GistItem
+}
+
+func (r *memoryGistItemRepository) FindByID(ctx context.Context, userID string, gistID string, fields values.Projection) mo.Result[*gistitem.GistItem] {
+	item, ok := r.items[gistID]
+
+	if !ok {
+		return mo.Err[*gistitem.GistItem](e.NotFoundError(e.ErrNotDiagramOwner))
+	}
+
+	copied := *item
+	return mo.Ok(&copied)
+}
+
+func (r *memoryGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields values.Projection) mo.Result[[]*gistitem.GistItem] {
+	var items []*gistitem.GistItem
+
+	for _, gistID := range gistIDs {
+		if item, err := r.FindByID(ctx, userID, gistID, fields).Get(); err == nil {
+			items = append(items, item)
+		}
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *memoryGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields values.Projection) mo.Result[[]*gistitem.GistItem] {
+	var items []*gistitem.GistItem
+
+	for _, item := range r.items {
+		copied := *item
+		items = append(items, &copied)
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *memoryGistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
+	r.items[item.ID()] = item
+	return mo.Ok(item)
+}
+
+func (r *memoryGistItemRepository) Delete(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	delete(r.items, itemID)
+	return mo.Ok(true)
+}
+
+func TestGistSaveOffloadsThumbnail(t *testing.T) {
+	inner := &memoryGistItemRepository{items: map[string]*gistitem.GistItem{}}
+	store := local.NewBlobStore(local.Dir(t.TempDir()))
+	repo := NewGistItemRepository(inner, store)
+	ctx := context.Background()
+	item := gistitem.New().WithID("gist1").WithTitle("title").WithThumbnail(mo.Some(thumbnail)).Build().MustGet()
+
+	if saved := repo.Save(ctx, "uid", item); saved.IsError() || *saved.MustGet().Thumbnail() != thumbnail {
+		t.Fatalf("Save() should return the item as given, got %v", saved)
+	}
+
+	ref := inner.items["gist1"].ThumbnailRef().OrEmpty()
+
+	if !strings.HasPrefix(ref, "users/uid/gists/gist1/") {
+		t.Fatalf("stored item should refer to the thumbnail blob, got %q", ref)
+	}
+
+	items := repo.Find(ctx, "uid", 0, 10, values.AllFields).MustGet()
+
+	if *items[0].Thumbnail() != thumbnail {
+		t.Errorf("Find() should load thumbnails, got %v", items[0].Thumbnail())
+	}
+
+	repo.Delete(ctx, "uid", "gist1")
+
+	if store.Get(ctx, ref).IsOk() {
+		t.Error("thumbnail blob should be deleted with the item")
+	}
+}
@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/event"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/mo"
+)
+
+// ItemRead is a read of a public item by a user, with the fields it loaded.
+type ItemRead struct {
+	UserID string
+	Fields v.Projection
+}
+
+// ItemReads are the reads of one public item kept in the store, so that they are dropped together.
+type ItemReads map[ItemRead]diagramitem.DiagramItem
+
+// ItemRepository reads public items through a Store, keyed by item ID. Private reads and lists always go to the
+// repository. Any write to an item publishes ItemChanged once the transaction has committed, and the reads of the
+// item are dropped from the stores of every instance that receives it.
+type ItemRepository struct {
+	repo  itemRepo.ItemRepository
+	store ItemStore
+	bus   event.Bus
+
+	mu sync.Mutex
+	// generation changes whenever items are dropped, so that a read that raced with it is not kept.
+	generation uint64
+}
+
+// NewItemRepository returns repo unchanged when store is nil.
+func NewItemRepository(repo itemRepo.ItemRepository, store ItemStore, bus event.Bus) itemRepo.ItemRepository {
+	if store == nil {
+		return repo
+	}
+
+	r := &ItemRepository{repo: repo, store: store, bus: bus}
+	bus.Subscribe(r.handle)
+
+	return r
+}
+
+func (r *ItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	if !isPublic || db.Writing(ctx) {
+		return r.repo.FindByID(ctx, userID, itemID, isPublic, fields)
+	}
+
+	read := ItemRead{UserID: userID, Fields: fields}
+
+	if item, ok := r.lookup(itemID, read); ok {
+		return mo.Ok(item)
+	}
+
+	generation := r.currentGeneration()
+	found := r.repo.FindByID(ctx, userID, itemID, isPublic, fields)
+
+	if found.IsOk() {
+		r.keep(generation, read, found.MustGet())
+	}
+
+	return found
+}
+
+func (r *ItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	if !isPublic || db.Writing(ctx) {
+		return r.repo.FindByIDs(ctx, userID, itemIDs, isPublic, fields)
+	}
+
+	read := ItemRead{UserID: userID, Fields: fields}
+	items := make([]*diagramitem.DiagramItem, 0, len(itemIDs))
+	var missing []string
+
+	for _, itemID := range itemIDs {
+		if item, ok := r.lookup(itemID, read); ok {
+			items = append(items, item)
+		} else {
+			missing = append(missing, itemID)
+		}
+	}
+
+	if len(missing) == 0 {
+		return mo.Ok(items)
+	}
+
+	generation := r.currentGeneration()
+	found := r.repo.FindByIDs(ctx, userID, missing, isPublic, fields)
+
+	if found.IsError() {
+		return found
+	}
+
+	for _, item := range found.MustGet() {
+		r.keep(generation, read, item)
+	}
+
+	return mo.Ok(append(items, found.MustGet()...))
+}
+
+func (r *ItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return r.repo.Find(ctx, userID, offset, limit, isPublic, filter, fields)
+}
+
+func (r *ItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	saved := r.repo.Save(ctx, userID, item, isPublic)
+
+	if saved.IsOk() {
+		r.changed(ctx, userID, item.ID())
+	}
+
+	return saved
+}
+
+func (r *ItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	deleted := r.repo.Delete(ctx, userID, itemID, isPublic)
+
+	if deleted.IsOk() {
+		r.changed(ctx, userID, itemID)
+	}
+
+	return deleted
+}
+
+func (r *ItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return r.repo.FindTrash(ctx, userID, offset, limit, fields)
+}
+
+func (r *ItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	return r.repo.FindExpiredTrash(ctx, deletedBefore, limit)
+}
+
+func (r *ItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	trashed := r.repo.Trash(ctx, userID, itemID, deletedAt)
+
+	if trashed.IsOk() {
+		r.changed(ctx, userID, itemID)
+	}
+
+	return trashed
+}
+
+func (r *ItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	restored := r.repo.Restore(ctx, userID, itemID)
+
+	if restored.IsOk() {
+		r.changed(ctx, userID, itemID)
+	}
+
+	return restored
+}
+
+// lookup returns a copy of the kept read, so that callers may change the item.
+func (r *ItemRepository) lookup(itemID string, read ItemRead) (*diagramitem.DiagramItem, bool) {
+	if reads, ok := r.store.Get(itemID); ok {
+		if item, ok := reads[read]; ok {
+			Stats.Add("items_hits", 1)
+			return &item, true
+		}
+	}
+
+	Stats.Add("items_misses", 1)
+	return nil, false
+}
+
+func (r *ItemRepository) currentGeneration() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.generation
+}
+
+// keep adds a copy of item to the reads of the item, unless items were dropped since generation.
+func (r *ItemRepository) keep(generation uint64, read ItemRead, item *diagramitem.DiagramItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.generation != generation {
+		return
+	}
+
+	reads, _ := r.store.Get(item.ID())
+	reads = maps.Clone(reads)
+
+	if reads == nil {
+		reads = ItemReads{}
+	}
+
+	reads[read] = *item
+	r.store.Set(item.ID(), reads)
+}
+
+func (r *ItemRepository) changed(ctx context.Context, userID, itemID string) {
+	publish(ctx, r.bus, event.Event{Kind: event.ItemChanged, UserID: userID, Key: itemID})
+}
+
+func (r *ItemRepository) handle(e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Kind {
+	case event.ItemChanged:
+		r.generation++
+		r.store.Delete(e.Key)
+	case event.Resync:
+		r.generation++
+		r.store.Purge()
+	}
+}
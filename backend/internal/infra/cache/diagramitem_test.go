@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/event"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/harehare/textusm/internal/infra/memory"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingItemRepository counts the item IDs read from the repository.
+type countingItemRepository struct {
+	itemRepo.ItemRepository
+	reads int
+}
+
+func (r *countingItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	r.reads++
+	return r.ItemRepository.FindByID(ctx, userID, itemID, isPublic, fields)
+}
+
+func (r *countingItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	r.reads += len(itemIDs)
+	return r.ItemRepository.FindByIDs(ctx, userID, itemIDs, isPublic, fields)
+}
+
+func newCachedItems(t *testing.T) (itemRepo.ItemRepository, *countingItemRepository) {
+	t.Helper()
+
+	counting := &countingItemRepository{ItemRepository: memory.NewItemRepository(&config.Config{Memory: memdb.New()})}
+	repo := NewItemRepository(counting, NewLRU[string, ItemReads](10, 0), event.NewLocalBus())
+
+	for _, id := range []string{"item1", "item2"} {
+		item := diagramitem.New().
+			WithID(id).
+			WithTitle("title " + id).
+			WithEncryptedText("text").
+			WithDiagram(v.DiagramUserStoryMap).
+			Build().MustGet()
+		require.NoError(t, repo.Save(context.Background(), "user", item, true).Error())
+	}
+
+	return repo, counting
+}
+
+func TestPublicItemReadThrough(t *testing.T) {
+	ctx := context.Background()
+	repo, counting := newCachedItems(t)
+
+	for range 3 {
+		found := repo.FindByID(ctx, "user", "item1", true, v.AllFields)
+		require.NoError(t, found.Error())
+		assert.Equal(t, "text", found.MustGet().EncryptedText())
+
+		// Callers may change the items they get.
+		found.MustGet().UpdateEncryptedText("changed")
+	}
+
+	assert.Equal(t, 1, counting.reads)
+
+	// Each projection is read once.
+	require.NoError(t, repo.FindByID(ctx, "user", "item1", true, v.MetadataOnly).Error())
+	assert.Equal(t, 2, counting.reads)
+
+	// Private reads always go to the repository.
+	require.NoError(t, repo.FindByID(ctx, "user", "item1", false, v.AllFields).Error())
+	require.NoError(t, repo.FindByID(ctx, "user", "item1", false, v.AllFields).Error())
+	assert.Equal(t, 4, counting.reads)
+
+	items := repo.FindByIDs(ctx, "user", []string{"item1", "item2"}, true, v.AllFields)
+	require.NoError(t, items.Error())
+	assert.Len(t, items.MustGet(), 2)
+	assert.Equal(t, 5, counting.reads)
+
+	require.NoError(t, repo.FindByIDs(ctx, "user", []string{"item1", "item2"}, true, v.AllFields).Error())
+	assert.Equal(t, 5, counting.reads)
+}
+
+func TestPublicItemChanged(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		change func(repo itemRepo.ItemRepository) error
+	}{
+		{"save", func(repo itemRepo.ItemRepository) error {
+			item := diagramitem.New().WithID("item1").WithEncryptedText("saved").WithDiagram(v.DiagramUserStoryMap).Build().MustGet()
+			return repo.Save(ctx, "user", item, true).Error()
+		}},
+		{"delete", func(repo itemRepo.ItemRepository) error { return repo.Delete(ctx, "user", "item1", true).Error() }},
+		{"trash", func(repo itemRepo.ItemRepository) error { return repo.Trash(ctx, "user", "item1", now).Error() }},
+		{"restore", func(repo itemRepo.ItemRepository) error { return repo.Restore(ctx, "user", "item1").Error() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, counting := newCachedItems(t)
+
+			require.NoError(t, repo.FindByID(ctx, "user", "item1", true, v.AllFields).Error())
+			require.NoError(t, repo.FindByID(ctx, "user", "item2", true, v.AllFields).Error())
+			require.NoError(t, tt.change(repo))
+
+			repo.FindByID(ctx, "user", "item1", true, v.AllFields)
+			assert.Equal(t, 3, counting.reads)
+
+			require.NoError(t, repo.FindByID(ctx, "user", "item2", true, v.AllFields).Error())
+			assert.Equal(t, 3, counting.reads)
+		})
+	}
+}
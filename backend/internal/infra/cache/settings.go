@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/event"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/mo"
+)
+
+type SettingsKey struct {
+	UserID  string
+	Diagram v.Diagram
+}
+
+// SettingsRepository reads settings through a Store. Saving or deleting settings publishes SettingsChanged once
+// the transaction has committed, and the settings are dropped from the stores of every instance that receives it.
+type SettingsRepository struct {
+	repo  settingsRepo.SettingsRepository
+	store SettingsStore
+	bus   event.Bus
+
+	mu sync.Mutex
+	// generation changes whenever settings are dropped, so that a read that raced with it is not kept.
+	generation uint64
+}
+
+// NewSettingsRepository returns repo unchanged when store is nil.
+func NewSettingsRepository(repo settingsRepo.SettingsRepository, store SettingsStore, bus event.Bus) settingsRepo.SettingsRepository {
+	if store == nil {
+		return repo
+	}
+
+	r := &SettingsRepository{repo: repo, store: store, bus: bus}
+	bus.Subscribe(r.handle)
+
+	return r
+}
+
+func (r *SettingsRepository) Peek(userID string, diagram v.Diagram) mo.Option[*settings.Settings] {
+	if s, ok := r.store.Get(SettingsKey{UserID: userID, Diagram: diagram}); ok {
+		Stats.Add("settings_hits", 1)
+		return mo.Some(&s)
+	}
+
+	return mo.None[*settings.Settings]()
+}
+
+// Find reads the settings from the store, unless ctx is in a transaction that may have changed them.
+func (r *SettingsRepository) Find(ctx context.Context, userID string, diagram v.Diagram) mo.Result[*settings.Settings] {
+	if db.Writing(ctx) {
+		return r.repo.Find(ctx, userID, diagram)
+	}
+
+	if s, ok := r.Peek(userID, diagram).Get(); ok {
+		return mo.Ok(s)
+	}
+
+	Stats.Add("settings_misses", 1)
+
+	r.mu.Lock()
+	generation := r.generation
+	r.mu.Unlock()
+
+	found := r.repo.Find(ctx, userID, diagram)
+
+	if found.IsOk() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.generation == generation {
+			r.store.Set(SettingsKey{UserID: userID, Diagram: diagram}, *found.MustGet())
+		}
+	}
+
+	return found
+}
+
+func (r *SettingsRepository) Save(ctx context.Context, userID string, diagram v.Diagram, s *settings.Settings) mo.Result[*settings.Settings] {
+	saved := r.repo.Save(ctx, userID, diagram, s)
+
+	if saved.IsOk() {
+		publish(ctx, r.bus, event.Event{Kind: event.SettingsChanged, UserID: userID, Key: string(diagram)})
+	}
+
+	return saved
+}
+
+func (r *SettingsRepository) Delete(ctx context.Context, userID string, diagram v.Diagram) mo.Result[bool] {
+	deleted := r.repo.Delete(ctx, userID, diagram)
+
+	if deleted.IsOk() {
+		publish(ctx, r.bus, event.Event{Kind: event.SettingsChanged, UserID: userID, Key: string(diagram)})
+	}
+
+	return deleted
+}
+
+func (r *SettingsRepository) handle(e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Kind {
+	case event.SettingsChanged:
+		r.generation++
+		r.store.Delete(SettingsKey{UserID: e.UserID, Diagram: v.Diagram(e.Key)})
+	case event.Resync:
+		r.generation++
+		r.store.Purge()
+	}
+}
+
+// publish publishes e once the transaction in ctx has committed, as the other instances could otherwise read
+// and keep the data it replaces.
+func publish(ctx context.Context, bus event.Bus, e event.Event) {
+	ctx = context.WithoutCancel(ctx)
+	db.AfterCommit(ctx, func() { bus.Publish(ctx, e) })
+}
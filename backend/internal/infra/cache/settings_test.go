@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/event"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/harehare/textusm/internal/infra/memory"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSettingsRepository counts the reads that reach the repository.
+type countingSettingsRepository struct {
+	settingsRepo.SettingsRepository
+	finds int
+}
+
+func (r *countingSettingsRepository) Find(ctx context.Context, userID string, diagram v.Diagram) mo.Result[*settings.Settings] {
+	r.finds++
+	return r.SettingsRepository.Find(ctx, userID, diagram)
+}
+
+// instance is one server sharing the database and the event bus with the others of a test.
+type instance struct {
+	repo     settingsRepo.SettingsRepository
+	counting *countingSettingsRepository
+}
+
+func newInstance(cfg *config.Config, bus event.Bus) instance {
+	counting := &countingSettingsRepository{SettingsRepository: memory.NewSettingsRepository(cfg)}
+	return instance{repo: NewSettingsRepository(counting, NewLRU[SettingsKey, settings.Settings](10, 0), bus), counting: counting}
+}
+
+func TestSettingsReadThrough(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Memory: memdb.New()}
+	tx := db.NewMemoryTx(cfg)
+	i := newInstance(cfg, event.NewLocalBus())
+
+	require.NoError(t, i.repo.Save(ctx, "user", v.DiagramUserStoryMap, &settings.Settings{Font: "a"}).Error())
+	assert.True(t, i.repo.(settingsRepo.SettingsCache).Peek("user", v.DiagramUserStoryMap).IsAbsent())
+
+	for range 3 {
+		found := i.repo.Find(ctx, "user", v.DiagramUserStoryMap)
+		require.NoError(t, found.Error())
+		assert.Equal(t, "a", found.MustGet().Font)
+	}
+
+	assert.Equal(t, 1, i.counting.finds)
+	assert.Equal(t, "a", i.repo.(settingsRepo.SettingsCache).Peek("user", v.DiagramUserStoryMap).MustGet().Font)
+
+	// Reads within the transaction see its writes, and the cache is only dropped once it commits.
+	err := tx.Do(ctx, func(ctx context.Context) error {
+		require.NoError(t, i.repo.Save(ctx, "user", v.DiagramUserStoryMap, &settings.Settings{Font: "b"}).Error())
+		assert.Equal(t, "b", i.repo.Find(ctx, "user", v.DiagramUserStoryMap).MustGet().Font)
+		assert.True(t, i.repo.(settingsRepo.SettingsCache).Peek("user", v.DiagramUserStoryMap).IsPresent())
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.True(t, i.repo.(settingsRepo.SettingsCache).Peek("user", v.DiagramUserStoryMap).IsAbsent())
+	assert.Equal(t, "b", i.repo.Find(ctx, "user", v.DiagramUserStoryMap).MustGet().Font)
+}
+
+func TestSettingsRollback(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Memory: memdb.New()}
+	tx := db.NewMemoryTx(cfg)
+	i := newInstance(cfg, event.NewLocalBus())
+
+	require.NoError(t, i.repo.Save(ctx, "user", v.DiagramUserStoryMap, &settings.Settings{Font: "a"}).Error())
+	require.NoError(t, i.repo.Find(ctx, "user", v.DiagramUserStoryMap).Error())
+
+	err := tx.Do(ctx, func(ctx context.Context) error {
+		require.NoError(t, i.repo.Save(ctx, "user", v.DiagramUserStoryMap, &settings.Settings{Font: "b"}).Error())
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, "a", i.repo.Find(ctx, "user", v.DiagramUserStoryMap).MustGet().Font)
+	assert.Equal(t, 1, i.counting.finds)
+}
+
+func TestSettingsChangedOnOtherInstance(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Memory: memdb.New()}
+	bus := event.NewLocalBus()
+	a := newInstance(cfg, bus)
+	b := newInstance(cfg, bus)
+
+	require.NoError(t, a.repo.Save(ctx, "user", v.DiagramUserStoryMap, &settings.Settings{Font: "a"}).Error())
+	require.NoError(t, a.repo.Save(ctx, "user", v.DiagramMindMap, &settings.Settings{Font: "a"}).Error())
+	require.NoError(t, b.repo.Find(ctx, "user", v.DiagramUserStoryMap).Error())
+	require.NoError(t, b.repo.Find(ctx, "user", v.DiagramMindMap).Error())
+
+	require.NoError(t, a.repo.Delete(ctx, "user", v.DiagramUserStoryMap).Error())
+
+	assert.True(t, b.repo.(settingsRepo.SettingsCache).Peek("user", v.DiagramUserStoryMap).IsAbsent())
+
+	bus.Publish(ctx, event.Event{Kind: event.Resync})
+	assert.True(t, b.repo.(settingsRepo.SettingsCache).Peek("user", v.DiagramMindMap).IsAbsent())
+}
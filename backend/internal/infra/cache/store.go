@@ -0,0 +1,114 @@
+// Package cache keeps the results of frequent reads in front of the repositories, and drops them when the events
+// of the event bus tell that the stored data changed.
+package cache
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/harehare/textusm/internal/domain/model/settings"
+)
+
+// Stats counts the reads answered by the caches and the ones passed to the repositories.
+// It is published with expvar as "cache".
+var Stats = expvar.NewMap("cache")
+
+// Store keeps values by key. It may drop any value at any time, but never returns one that was deleted or purged.
+// The repositories never change a value once it is set, so a Store may hand out the value it keeps.
+type Store[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V)
+	Delete(key K)
+	Purge()
+}
+
+// ItemStore and SettingsStore are the stores of the public item and settings caches.
+type (
+	ItemStore     = Store[string, ItemReads]
+	SettingsStore = Store[SettingsKey, settings.Settings]
+)
+
+// LRU is the default Store. It keeps up to size values in memory, each for ttl at most, and drops the least
+// recently used value first. Values never expire with a zero ttl.
+type LRU[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{size: size, ttl: ttl, now: time.Now, entries: map[K]*list.Element{}, order: list.New()}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.entries[key]
+
+	if !ok {
+		return zero, false
+	}
+
+	entry := elem.Value.(*lruEntry[K, V])
+
+	if c.ttl > 0 && !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: c.now().Add(c.ttl)})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.order.Init()
+}
+
+// remove drops elem. It must be called with c.mu held.
+func (c *LRU[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Set("c", 3)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	value, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+}
+
+func TestLRUExpires(t *testing.T) {
+	now := time.Now()
+	c := NewLRU[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Set("a", 1)
+
+	now = now.Add(59 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestLRUDeleteAndPurge(t *testing.T) {
+	c := NewLRU[string, int](10, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	c.Delete("a")
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Purge()
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	c.Set("a", 4)
+	value, _ := c.Get("a")
+	assert.Equal(t, 4, value)
+}
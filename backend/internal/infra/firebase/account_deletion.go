@@ -0,0 +1,191 @@
+package firebase
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	accountRepo "github.com/harehare/textusm/internal/domain/repository/account"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var activeDeletionStatuses = []string{string(account.DeletionPending), string(account.DeletionPurging)}
+
+type FirestoreDeletionRepository struct {
+	client *firestore.Client
+}
+
+func NewDeletionRepository(config *config.Config) accountRepo.DeletionRepository {
+	return &FirestoreDeletionRepository{client: config.FirestoreClient}
+}
+
+func (r *FirestoreDeletionRepository) FindByID(ctx context.Context, deletionID string) mo.Result[*account.Deletion] {
+	fields, err := r.client.Collection(deletionsCollection).Doc(deletionID).Get(ctx)
+
+	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return account.MapToDeletion(fields.Data())
+}
+
+func (r *FirestoreDeletionRepository) FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion] {
+	ret := r.find(ctx, r.client.Collection(deletionsCollection).
+		Where("UserID", "==", userID).
+		Where("Status", "in", activeDeletionStatuses).
+		Limit(1))
+
+	if ret.IsError() {
+		return mo.Err[*account.Deletion](ret.Error())
+	}
+
+	if len(ret.MustGet()) == 0 {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	return mo.Ok(ret.MustGet()[0])
+}
+
+func (r *FirestoreDeletionRepository) FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion] {
+	ret := r.find(ctx, r.client.Collection(deletionsCollection).
+		Where("Status", "in", activeDeletionStatuses).
+		Where("ScheduledAt", "<=", now).
+		OrderBy("ScheduledAt", firestore.Asc).
+		Limit(limit))
+
+	if ret.IsError() {
+		return ret
+	}
+
+	due := []*account.Deletion{}
+
+	for _, d := range ret.MustGet() {
+		if isClaimable(d, now) {
+			due = append(due, d)
+		}
+	}
+
+	return mo.Ok(due)
+}
+
+func (r *FirestoreDeletionRepository) Create(ctx context.Context, deletion *account.Deletion) mo.Result[bool] {
+	_, err := r.client.Collection(deletionsCollection).Doc(deletion.ID).Create(ctx, deletion.ToMap())
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *FirestoreDeletionRepository) Claim(ctx context.Context, deletionID string, now, leaseUntil time.Time) mo.Result[bool] {
+	return r.updateIf(ctx, deletionID, func(d *account.Deletion) bool {
+		return isClaimable(d, now)
+	}, []firestore.Update{
+		{Path: "Status", Value: string(account.DeletionPurging)},
+		{Path: "LeaseUntil", Value: leaseUntil},
+	})
+}
+
+func (r *FirestoreDeletionRepository) Cancel(ctx context.Context, deletionID string) mo.Result[bool] {
+	return r.updateIf(ctx, deletionID, func(d *account.Deletion) bool {
+		return d.Status == account.DeletionPending
+	}, []firestore.Update{
+		{Path: "Status", Value: string(account.DeletionCancelled)},
+	})
+}
+
+func (r *FirestoreDeletionRepository) Complete(ctx context.Context, deletionID string, completedAt time.Time, receipt string) mo.Result[bool] {
+	return r.updateIf(ctx, deletionID, func(d *account.Deletion) bool {
+		return d.Status == account.DeletionPurging
+	}, []firestore.Update{
+		{Path: "Status", Value: string(account.DeletionCompleted)},
+		{Path: "LeaseUntil", Value: time.Time{}},
+		{Path: "CompletedAt", Value: completedAt},
+		{Path: "Receipt", Value: receipt},
+	})
+}
+
+func (r *FirestoreDeletionRepository) find(ctx context.Context, query firestore.Query) mo.Result[[]*account.Deletion] {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	deletions := []*account.Deletion{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return mo.Err[[]*account.Deletion](err)
+		}
+
+		d := account.MapToDeletion(doc.Data())
+
+		if d.IsError() {
+			return mo.Err[[]*account.Deletion](d.Error())
+		}
+
+		deletions = append(deletions, d.MustGet())
+	}
+
+	return mo.Ok(deletions)
+}
+
+// updateIf applies the updates in a transaction when cond holds for the stored deletion, so concurrent workers cannot both claim it.
+func (r *FirestoreDeletionRepository) updateIf(ctx context.Context, deletionID string, cond func(d *account.Deletion) bool, updates []firestore.Update) mo.Result[bool] {
+	updated := false
+	ref := r.client.Collection(deletionsCollection).Doc(deletionID)
+
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		updated = false
+		fields, err := tx.Get(ref)
+
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		d := account.MapToDeletion(fields.Data())
+
+		if d.IsError() {
+			return d.Error()
+		}
+
+		if !cond(d.MustGet()) {
+			return nil
+		}
+
+		updated = true
+		return tx.Update(ref, updates)
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(updated)
+}
+
+func isClaimable(d *account.Deletion, now time.Time) bool {
+	if d.ScheduledAt.After(now) {
+		return false
+	}
+
+	return d.Status == account.DeletionPending || (d.Status == account.DeletionPurging && d.LeaseUntil.Before(now))
+}
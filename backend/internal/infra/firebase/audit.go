@@ -0,0 +1,27 @@
+package firebase
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	auditRepo "github.com/harehare/textusm/internal/domain/repository/audit"
+	"github.com/samber/mo"
+)
+
+type FirestoreAuditLogRepository struct {
+	client *firestore.Client
+}
+
+func NewAuditLogRepository(config *config.Config) auditRepo.AuditLogRepository {
+	return &FirestoreAuditLogRepository{client: config.FirestoreClient}
+}
+
+func (r *FirestoreAuditLogRepository) Append(ctx context.Context, log *audit.Log) mo.Result[bool] {
+	if _, _, err := r.client.Collection(auditLogsCollection).Add(ctx, log.ToMap()); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,85 @@
+package firebase
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	gcs "cloud.google.com/go/storage"
+	"firebase.google.com/go/v4/storage"
+	"github.com/harehare/textusm/internal/config"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type FirebaseBlobStore struct {
+	storage *storage.Client
+}
+
+func NewBlobStore(config *config.Config) blobRepo.BlobStore {
+	return &FirebaseBlobStore{storage: config.StorageClient}
+}
+
+func (s *FirebaseBlobStore) Get(ctx context.Context, key string) mo.Result[*blobRepo.Blob] {
+	bucket, err := s.storage.DefaultBucket()
+
+	if err != nil {
+		return mo.Err[*blobRepo.Blob](err)
+	}
+
+	reader, err := bucket.Object(key).NewReader(ctx)
+
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return mo.Err[*blobRepo.Blob](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[*blobRepo.Blob](err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	data, err := io.ReadAll(reader)
+
+	if err != nil {
+		return mo.Err[*blobRepo.Blob](err)
+	}
+
+	return mo.Ok(&blobRepo.Blob{ContentType: reader.Attrs.ContentType, Data: data})
+}
+
+func (s *FirebaseBlobStore) Put(ctx context.Context, key string, blob *blobRepo.Blob) mo.Result[bool] {
+	bucket, err := s.storage.DefaultBucket()
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	writer := bucket.Object(key).NewWriter(ctx)
+	writer.ContentType = blob.ContentType
+
+	if _, err := writer.Write(blob.Data); err != nil {
+		_ = writer.Close()
+		return mo.Err[bool](err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (s *FirebaseBlobStore) Delete(ctx context.Context, key string) mo.Result[bool] {
+	bucket, err := s.storage.DefaultBucket()
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	if err := bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,53 @@
+package firebase
+
+import (
+	"context"
+	"sort"
+
+	"cloud.google.com/go/firestore"
+	"github.com/harehare/textusm/internal/config"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	"github.com/samber/mo"
+	"google.golang.org/api/iterator"
+)
+
+type FirestoreCatalogRepository struct {
+	client *firestore.Client
+}
+
+func NewCatalogRepository(config *config.Config) catalogRepo.CatalogRepository {
+	return &FirestoreCatalogRepository{client: config.FirestoreClient}
+}
+
+// UserIDs lists the user documents including the ones that only exist as parents of subcollections.
+func (r *FirestoreCatalogRepository) UserIDs(ctx context.Context) mo.Result[[]string] {
+	return r.documentIDs(r.client.Collection(usersCollection).DocumentRefs(ctx))
+}
+
+func (r *FirestoreCatalogRepository) ItemIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.documentIDs(r.client.Collection(usersCollection).Doc(userID).Collection(itemsCollection).DocumentRefs(ctx))
+}
+
+func (r *FirestoreCatalogRepository) GistIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.documentIDs(r.client.Collection(usersCollection).Doc(userID).Collection(gistItemsCollection).DocumentRefs(ctx))
+}
+
+func (r *FirestoreCatalogRepository) documentIDs(iter *firestore.DocumentRefIterator) mo.Result[[]string] {
+	ids := []string{}
+
+	for {
+		ref, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return mo.Err[[]string](err)
+		}
+
+		ids = append(ids, ref.ID)
+	}
+
+	sort.Strings(ids)
+	return mo.Ok(ids)
+}
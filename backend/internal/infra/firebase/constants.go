@@ -2,11 +2,20 @@ package firebase
 
 const (
 	itemsCollection     = "items"
+	trashCollection     = "trash"
 	publicCollection    = "public"
 	usersCollection     = "users"
 	usersStorageRoot    = usersCollection
 	gistItemsCollection = "gistitems"
 	settingsCollection  = "settings"
 	shareCollection     = "share"
+	shareCodeCollection = "shareCodes"
 	shareStorageRoot    = shareCollection
+	tokensCollection    = "tokens"
+	githubTokenDoc      = "github"
+	imagesCollection    = "images"
+	imageQuotaDoc       = "imageQuota"
+	locksCollection     = "locks"
+	deletionsCollection = "accountDeletions"
+	auditLogsCollection = "auditLogs"
 )
@@ -2,6 +2,7 @@ package firebase
 
 import (
 	"context"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"firebase.google.com/go/v4/storage"
@@ -9,6 +10,7 @@ import (
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
 	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 	"golang.org/x/exp/slog"
@@ -26,24 +28,63 @@ func NewItemRepository(config *config.Config) itemRepo.ItemRepository {
 	return &FirestoreItemRepository{firestore: config.FirestoreClient, storage: config.StorageClient}
 }
 
-func (r *FirestoreItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
-	return r.findFromFirestore(ctx, userID, itemID, isPublic)
+func (r *FirestoreItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	return r.findFromFirestore(ctx, userID, itemID, isPublic, fields)
 }
 
-func (r *FirestoreItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, isBookmark bool, shouldLoadText bool) mo.Result[[]*diagramitem.DiagramItem] {
-	var (
-		items []*diagramitem.DiagramItem
-		iter  *firestore.DocumentIterator
-	)
-	switch {
-	case isPublic:
-		iter = r.firestore.Collection(publicCollection).OrderBy("UpdatedAt", firestore.Desc).Offset(offset).Limit(limit).Documents(ctx)
-	case isBookmark:
-		iter = r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).Where("IsBookmark", "==", isBookmark).OrderBy("UpdatedAt", firestore.Desc).Offset(offset).Limit(limit).Documents(ctx)
-	default:
-		iter = r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).OrderBy("UpdatedAt", firestore.Desc).Offset(offset).Limit(limit).Documents(ctx)
+func (r *FirestoreItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	collection := r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection)
+
+	if isPublic {
+		collection = r.firestore.Collection(publicCollection)
+	}
+
+	refs := make([]*firestore.DocumentRef, len(itemIDs))
+
+	for i, itemID := range itemIDs {
+		refs[i] = collection.Doc(itemID)
+	}
+
+	docs, err := getDocuments(ctx, r.firestore, refs, selectFields(itemFields, fields))
+
+	if err != nil {
+		slog.Error("Failed find diagrams", "userID", userID, "itemIDs", itemIDs, "isPublic", isPublic)
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	items := make([]*diagramitem.DiagramItem, 0, len(docs))
+
+	for _, doc := range docs {
+		i := diagramitem.MapToDiagramItem(doc.Data())
+		if i.IsError() {
+			return mo.Err[[]*diagramitem.DiagramItem](i.Error())
+		}
+
+		items = append(items, i.MustGet())
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *FirestoreItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+	query := r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).Query
+
+	if isPublic {
+		query = r.firestore.Collection(publicCollection).Query
 	}
 
+	if filter.IsBookmark {
+		query = query.Where("IsBookmark", "==", true)
+	}
+
+	if diagram, ok := filter.Diagram.Get(); ok {
+		query = query.Where("Diagram", "==", string(diagram))
+	}
+
+	query = orderItems(query, filter.SortOrDefault()).Offset(offset).Limit(limit)
+	iter := selectQuery(query, selectFields(itemFields, fields)).Documents(ctx)
+
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -51,7 +92,7 @@ func (r *FirestoreItemRepository) Find(ctx context.Context, userID string, offse
 		}
 
 		if err != nil {
-			slog.Error("Failed find diagrams", "userID", userID, "offset", offset, "limit", limit, "isPublic", isPublic, "isBookmark", isBookmark)
+			slog.Error("Failed find diagrams", "userID", userID, "offset", offset, "limit", limit, "isPublic", isPublic, "filter", filter)
 			return mo.Err[[]*diagramitem.DiagramItem](err)
 		}
 
@@ -66,6 +107,18 @@ func (r *FirestoreItemRepository) Find(ctx context.Context, userID string, offse
 	return mo.Ok(items)
 }
 
+// orderItems orders query by sort. Each filter combined with each order needs a composite index in firestore.indexes.json.
+func orderItems(query firestore.Query, sort v.ItemSort) firestore.Query {
+	switch sort {
+	case v.ItemSortCreatedAt:
+		return query.OrderBy("CreatedAt", firestore.Desc)
+	case v.ItemSortTitle:
+		return query.OrderBy("Title", firestore.Asc)
+	default:
+		return query.OrderBy("UpdatedAt", firestore.Desc)
+	}
+}
+
 func (r *FirestoreItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
 	if err := r.saveToFirestore(ctx, userID, item, isPublic).Error(); err != nil {
 		slog.Error("Delete failed.", "userID", userID, "itemID", item.ID())
@@ -79,17 +132,126 @@ func (r *FirestoreItemRepository) Delete(ctx context.Context, userID string, ite
 	return r.deleteToFirestore(ctx, userID, itemID, isPublic)
 }
 
-func (r *FirestoreItemRepository) findFromFirestore(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
-	var (
-		fields *firestore.DocumentSnapshot
-		err    error
-	)
-	if isPublic {
-		fields, err = r.firestore.Collection(publicCollection).Doc(itemID).Get(ctx)
+func (r *FirestoreItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+	query := r.firestore.Collection(usersCollection).Doc(userID).Collection(trashCollection).OrderBy("DeletedAt", firestore.Desc).Offset(offset).Limit(limit)
+	iter := selectQuery(query, selectFields(itemFields, fields)).Documents(ctx)
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			slog.Error("Failed find trash", "userID", userID, "offset", offset, "limit", limit)
+			return mo.Err[[]*diagramitem.DiagramItem](err)
+		}
+
+		i := diagramitem.MapToDiagramItem(doc.Data())
+		if i.IsError() {
+			return mo.Err[[]*diagramitem.DiagramItem](i.Error())
+		}
+
+		items = append(items, i.MustGet())
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *FirestoreItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	trashed := []itemRepo.TrashedItem{}
+	iter := r.firestore.CollectionGroup(trashCollection).Where("DeletedAt", "<", deletedBefore).OrderBy("DeletedAt", firestore.Asc).Limit(limit).Documents(ctx)
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return mo.Err[[]itemRepo.TrashedItem](err)
+		}
+
+		// Trashed items are stored at users/{userID}/trash/{itemID}.
+		trashed = append(trashed, itemRepo.TrashedItem{UserID: doc.Ref.Parent.Parent.ID, ItemID: doc.Ref.ID})
+	}
+
+	return mo.Ok(trashed)
+}
+
+// Trash moves the item to the trash collection of the user, so that the queries on the items collection need no filter.
+func (r *FirestoreItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	user := r.firestore.Collection(usersCollection).Doc(userID)
+
+	return r.move(ctx, user.Collection(itemsCollection).Doc(itemID), user.Collection(trashCollection).Doc(itemID), func(fields map[string]interface{}) {
+		fields["DeletedAt"] = deletedAt
+		fields["IsPublic"] = false
+	})
+}
+
+func (r *FirestoreItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	user := r.firestore.Collection(usersCollection).Doc(userID)
+
+	return r.move(ctx, user.Collection(trashCollection).Doc(itemID), user.Collection(itemsCollection).Doc(itemID), func(fields map[string]interface{}) {
+		delete(fields, "DeletedAt")
+	})
+}
+
+// move moves the document at from to to, changing its fields with update. It returns false when from does not exist.
+// The document is read first, so move must come before any write in the transaction of ctx.
+func (r *FirestoreItemRepository) move(ctx context.Context, from, to *firestore.DocumentRef, update func(map[string]interface{})) mo.Result[bool] {
+	moved := false
+	fn := func(tx *firestore.Transaction) error {
+		moved = false
+		doc, err := tx.Get(from)
+
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fields := doc.Data()
+		update(fields)
+
+		if err := tx.Set(to, fields); err != nil {
+			return err
+		}
+
+		moved = true
+		return tx.Delete(from)
+	}
+
+	var err error
+
+	if tx := values.GetFirestoreTx(ctx); tx.IsPresent() {
+		err = fn(tx.MustGet())
 	} else {
-		fields, err = r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).Doc(itemID).Get(ctx)
+		err = r.firestore.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+			return fn(tx)
+		})
+	}
+
+	if err != nil {
+		slog.Error("Failed move item", "from", from.Path, "to", to.Path, "error", err)
+		return mo.Err[bool](err)
 	}
 
+	return mo.Ok(moved)
+}
+
+func (r *FirestoreItemRepository) findFromFirestore(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	ref := r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).Doc(itemID)
+
+	if isPublic {
+		ref = r.firestore.Collection(publicCollection).Doc(itemID)
+	}
+
+	doc, err := getDocument(ctx, ref, selectFields(itemFields, fields))
+
 	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
 		slog.Error("Diagram not found", "userID", userID, "itemID", itemID, "isPublic", isPublic)
 		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(err))
@@ -100,7 +262,7 @@ func (r *FirestoreItemRepository) findFromFirestore(ctx context.Context, userID
 		return mo.Err[*diagramitem.DiagramItem](err)
 	}
 
-	return diagramitem.MapToDiagramItem(fields.Data())
+	return diagramitem.MapToDiagramItem(doc.Data())
 }
 
 func (r *FirestoreItemRepository) saveToFirestore(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[bool] {
@@ -137,7 +299,14 @@ func (r *FirestoreItemRepository) deleteToFirestore(ctx context.Context, userID
 
 			return mo.Ok(true)
 		} else {
-			_, err := r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).Doc(itemID).Delete(ctx)
+			user := r.firestore.Collection(usersCollection).Doc(userID)
+			err := r.firestore.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+				if err := tx.Delete(user.Collection(itemsCollection).Doc(itemID)); err != nil {
+					return err
+				}
+
+				return tx.Delete(user.Collection(trashCollection).Doc(itemID))
+			})
 
 			if err != nil {
 				slog.Error("Failed delete firestore", "userID", userID, "itemID", itemID, "isPublic", isPublic)
@@ -159,8 +328,12 @@ func (r *FirestoreItemRepository) deleteToFirestore(ctx context.Context, userID
 
 		return mo.Ok(true)
 	} else {
-		ref := r.firestore.Collection(usersCollection).Doc(userID).Collection(itemsCollection).Doc(itemID)
-		err := tx.OrEmpty().Delete(ref)
+		user := r.firestore.Collection(usersCollection).Doc(userID)
+		err := tx.OrEmpty().Delete(user.Collection(itemsCollection).Doc(itemID))
+
+		if err == nil {
+			err = tx.OrEmpty().Delete(user.Collection(trashCollection).Doc(itemID))
+		}
 
 		if err != nil {
 			slog.Error("Failed delete firestore", "userID", userID, "itemID", itemID, "isPublic", isPublic)
@@ -7,6 +7,7 @@ import (
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	v "github.com/harehare/textusm/internal/domain/values"
 	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 	"google.golang.org/api/iterator"
@@ -22,8 +23,9 @@ func NewGistItemRepository(config *config.Config) itemRepo.GistItemRepository {
 	return &FirestoreGistItemRepository{client: config.FirestoreClient}
 }
 
-func (r *FirestoreGistItemRepository) FindByID(ctx context.Context, userID string, itemID string) mo.Result[*gistitem.GistItem] {
-	fields, err := r.client.Collection(usersCollection).Doc(userID).Collection(gistItemsCollection).Doc(itemID).Get(ctx)
+func (r *FirestoreGistItemRepository) FindByID(ctx context.Context, userID string, itemID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
+	ref := r.client.Collection(usersCollection).Doc(userID).Collection(gistItemsCollection).Doc(itemID)
+	doc, err := getDocument(ctx, ref, selectFields(gistItemFields, fields))
 
 	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
 		return mo.Err[*gistitem.GistItem](e.NotFoundError(err))
@@ -33,12 +35,41 @@ func (r *FirestoreGistItemRepository) FindByID(ctx context.Context, userID strin
 		return mo.Err[*gistitem.GistItem](e.NotFoundError(err))
 	}
 
-	return gistitem.MapToGistItem(fields.Data())
+	return gistitem.MapToGistItem(doc.Data())
 }
 
-func (r *FirestoreGistItemRepository) Find(ctx context.Context, userID string, offset, limit int) mo.Result[[]*gistitem.GistItem] {
+func (r *FirestoreGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	collection := r.client.Collection(usersCollection).Doc(userID).Collection(gistItemsCollection)
+	refs := make([]*firestore.DocumentRef, len(gistIDs))
+
+	for i, gistID := range gistIDs {
+		refs[i] = collection.Doc(gistID)
+	}
+
+	docs, err := getDocuments(ctx, r.client, refs, selectFields(gistItemFields, fields))
+
+	if err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
+	}
+
+	items := make([]*gistitem.GistItem, 0, len(docs))
+
+	for _, doc := range docs {
+		ret := gistitem.MapToGistItem(doc.Data())
+		if ret.IsError() {
+			return mo.Err[[]*gistitem.GistItem](ret.Error())
+		}
+
+		items = append(items, ret.OrEmpty())
+	}
+
+	return mo.Ok(items)
+}
+
+func (r *FirestoreGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
 	var items []*gistitem.GistItem
-	iter := r.client.Collection(usersCollection).Doc(userID).Collection(gistItemsCollection).OrderBy("UpdatedAt", firestore.Desc).Offset(offset).Limit(limit).Documents(ctx)
+	query := r.client.Collection(usersCollection).Doc(userID).Collection(gistItemsCollection).OrderBy("UpdatedAt", firestore.Desc).Offset(offset).Limit(limit)
+	iter := selectQuery(query, selectFields(gistItemFields, fields)).Documents(ctx)
 
 	for {
 		doc, err := iter.Next()
@@ -0,0 +1,82 @@
+package firebase
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreGithubTokenRepository struct {
+	client *firestore.Client
+}
+
+func NewGithubTokenRepository(config *config.Config) userRepo.GithubTokenRepository {
+	return &FirestoreGithubTokenRepository{client: config.FirestoreClient}
+}
+
+func (r *FirestoreGithubTokenRepository) doc(uid string) *firestore.DocumentRef {
+	return r.client.Collection(usersCollection).Doc(uid).Collection(tokensCollection).Doc(githubTokenDoc)
+}
+
+func (r *FirestoreGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*user.GithubToken] {
+	fields, err := r.doc(uid).Get(ctx)
+
+	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+		return mo.Err[*user.GithubToken](e.NotFoundError(e.ErrGithubNotConnected))
+	}
+
+	if err != nil {
+		return mo.Err[*user.GithubToken](err)
+	}
+
+	data := fields.Data()
+	accessToken, _ := data["AccessToken"].(string)
+	scope, _ := data["Scope"].(string)
+	createdAt, _ := data["CreatedAt"].(time.Time)
+	updatedAt, _ := data["UpdatedAt"].(time.Time)
+
+	return mo.Ok(user.NewGithubTokenFromEncrypted(accessToken, scope, createdAt, updatedAt))
+}
+
+func (r *FirestoreGithubTokenRepository) Save(ctx context.Context, uid string, token *user.GithubToken) mo.Result[bool] {
+	tx := values.GetFirestoreTx(ctx)
+	var err error
+
+	if tx.IsPresent() {
+		err = tx.MustGet().Set(r.doc(uid), token.ToMap())
+	} else {
+		_, err = r.doc(uid).Set(ctx, token.ToMap())
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *FirestoreGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	tx := values.GetFirestoreTx(ctx)
+	var err error
+
+	if tx.IsPresent() {
+		err = tx.MustGet().Delete(r.doc(uid))
+	} else {
+		_, err = r.doc(uid).Delete(ctx)
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,207 @@
+package firebase
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FirestoreImageRepository struct {
+	client *firestore.Client
+}
+
+func NewImageRepository(config *config.Config) imageRepo.ImageRepository {
+	return &FirestoreImageRepository{client: config.FirestoreClient}
+}
+
+func (r *FirestoreImageRepository) FindByID(ctx context.Context, imageID string) mo.Result[*image.Image] {
+	fields, err := r.client.Collection(imagesCollection).Doc(imageID).Get(ctx)
+
+	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+		return mo.Err[*image.Image](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[*image.Image](err)
+	}
+
+	return image.MapToImage(fields.Data())
+}
+
+func (r *FirestoreImageRepository) FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image] {
+	iter := r.client.Collection(imagesCollection).Where("UserID", "==", userID).OrderBy("CreatedAt", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	images := []*image.Image{}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return mo.Err[[]*image.Image](err)
+		}
+
+		img := image.MapToImage(doc.Data())
+
+		if img.IsError() {
+			return mo.Err[[]*image.Image](img.Error())
+		}
+
+		images = append(images, img.MustGet())
+	}
+
+	return mo.Ok(images)
+}
+
+func (r *FirestoreImageRepository) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	var usage int64
+	iter := r.client.Collection(imagesCollection).Where("UserID", "==", userID).Select("Size").Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return mo.Err[int64](err)
+		}
+
+		size, _ := doc.Data()["Size"].(int64)
+		usage += size
+	}
+
+	return mo.Ok(usage)
+}
+
+func (r *FirestoreImageRepository) Save(ctx context.Context, img *image.Image) mo.Result[bool] {
+	tx := values.GetFirestoreTx(ctx)
+	ref := r.client.Collection(imagesCollection).Doc(img.ID)
+	var err error
+
+	if tx.IsPresent() {
+		err = tx.MustGet().Set(ref, img.ToMap())
+	} else {
+		_, err = ref.Set(ctx, img.ToMap())
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+// SaveWithinQuota serializes the saves of a user through a lock document that each of them reads and writes, so
+// that a concurrent save makes the transaction retry and count the image that save added.
+func (r *FirestoreImageRepository) SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool] {
+	save := func(tx *firestore.Transaction) (bool, error) {
+		lockRef := r.quotaLock(img.UserID)
+
+		if _, err := tx.Get(lockRef); err != nil && status.Code(err) != codes.NotFound {
+			return false, err
+		}
+
+		docs, err := tx.Documents(r.client.Collection(imagesCollection).Where("UserID", "==", img.UserID).Select("Size")).GetAll()
+
+		if err != nil {
+			return false, err
+		}
+
+		usage := img.Size
+
+		for _, doc := range docs {
+			size, _ := doc.Data()["Size"].(int64)
+			usage += size
+		}
+
+		if usage > quota {
+			return false, nil
+		}
+
+		if err := tx.Set(lockRef, map[string]interface{}{"updatedAt": time.Now()}); err != nil {
+			return false, err
+		}
+
+		return true, tx.Set(r.client.Collection(imagesCollection).Doc(img.ID), img.ToMap())
+	}
+
+	var (
+		saved bool
+		err   error
+	)
+
+	if tx, ok := values.GetFirestoreTx(ctx).Get(); ok {
+		saved, err = save(tx)
+	} else {
+		err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			saved, err = save(tx)
+			return err
+		})
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(saved)
+}
+
+// quotaLock is the lock document of SaveWithinQuota. It is deleted with the last image of the user.
+func (r *FirestoreImageRepository) quotaLock(userID string) *firestore.DocumentRef {
+	return r.client.Collection(usersCollection).Doc(userID).Collection(locksCollection).Doc(imageQuotaDoc)
+}
+
+func (r *FirestoreImageRepository) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	img := r.FindByID(ctx, imageID)
+
+	if img.IsError() {
+		return mo.Err[bool](img.Error())
+	}
+
+	if img.MustGet().UserID != userID {
+		return mo.Err[bool](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	others, err := r.client.Collection(imagesCollection).Where("UserID", "==", userID).Select().Limit(2).Documents(ctx).GetAll()
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	refs := []*firestore.DocumentRef{r.client.Collection(imagesCollection).Doc(imageID)}
+
+	if len(others) <= 1 {
+		refs = append(refs, r.quotaLock(userID))
+	}
+
+	tx := values.GetFirestoreTx(ctx)
+
+	for _, ref := range refs {
+		if tx.IsPresent() {
+			err = tx.MustGet().Delete(ref)
+		} else {
+			_, err = ref.Delete(ctx)
+		}
+
+		if err != nil {
+			return mo.Err[bool](err)
+		}
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,106 @@
+package firebase
+
+import (
+	"context"
+	"slices"
+
+	"cloud.google.com/go/firestore"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	itemFields     = []string{"ID", "Title", "Diagram", "IsPublic", "IsBookmark", "CreatedAt", "UpdatedAt", "DeletedAt"}
+	gistItemFields = []string{"ID", "URL", "Title", "Diagram", "IsBookmark", "CreatedAt", "UpdatedAt", "Revision"}
+)
+
+// selectFields returns the fields of a document to read for the projection, or nil when the whole document is read.
+func selectFields(base []string, fields v.Projection) []string {
+	if fields == v.AllFields {
+		return nil
+	}
+
+	paths := append([]string{}, base...)
+
+	if fields.Text {
+		paths = append(paths, "Text")
+	}
+
+	if fields.Thumbnail {
+		paths = append(paths, "Thumbnail")
+	}
+
+	return paths
+}
+
+// selectQuery reads only paths of the documents of query, unless paths is nil.
+func selectQuery(query firestore.Query, paths []string) firestore.Query {
+	if paths == nil {
+		return query
+	}
+
+	return query.Select(paths...)
+}
+
+// getDocument reads the document at ref. A document cannot be read in part, so with paths it is queried by its ID
+// instead, and a missing document fails with codes.NotFound as Get does.
+func getDocument(ctx context.Context, ref *firestore.DocumentRef, paths []string) (*firestore.DocumentSnapshot, error) {
+	if paths == nil {
+		return ref.Get(ctx)
+	}
+
+	iter := ref.Parent.Where(firestore.DocumentID, "==", ref).Select(paths...).Limit(1).Documents(ctx)
+	defer iter.Stop()
+
+	doc, err := iter.Next()
+
+	if err == iterator.Done {
+		return nil, status.Errorf(codes.NotFound, "%s not found", ref.Path)
+	}
+
+	return doc, err
+}
+
+// maxInQuery is the most values a Firestore "in" filter takes.
+const maxInQuery = 30
+
+// getDocuments reads the documents at refs, which must be in the same collection, leaving out the ones that do not exist.
+// Without paths they are read in one GetAll call, otherwise they are queried by their IDs in chunks.
+func getDocuments(ctx context.Context, client *firestore.Client, refs []*firestore.DocumentRef, paths []string) ([]*firestore.DocumentSnapshot, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	var docs []*firestore.DocumentSnapshot
+
+	if paths == nil {
+		all, err := client.GetAll(ctx, refs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range all {
+			if doc.Exists() {
+				docs = append(docs, doc)
+			}
+		}
+
+		return docs, nil
+	}
+
+	for chunk := range slices.Chunk(refs, maxInQuery) {
+		iter := chunk[0].Parent.Where(firestore.DocumentID, "in", chunk).Select(paths...).Documents(ctx)
+		all, err := iter.GetAll()
+
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, all...)
+	}
+
+	return docs, nil
+}
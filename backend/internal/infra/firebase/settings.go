@@ -52,3 +52,13 @@ func (r *FirestoreSettingsRepository) Save(ctx context.Context, userID string, d
 
 	return mo.Ok(s)
 }
+
+func (r *FirestoreSettingsRepository) Delete(ctx context.Context, userID string, diagram values.Diagram) mo.Result[bool] {
+	_, err := r.client.Collection(usersCollection).Doc(userID).Collection(settingsCollection).Doc(diagram.String()).Delete(ctx)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -8,11 +8,12 @@ import (
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	"github.com/harehare/textusm/internal/domain/model/share"
 	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	v "github.com/harehare/textusm/internal/domain/values"
 	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
-	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -30,12 +31,50 @@ func (r *FirestoreShareRepository) Find(ctx context.Context, hashKey string) mo.
 	return r.findFromFirestore(ctx, hashKey)
 }
 
+func (r *FirestoreShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	fields, err := r.client.Collection(shareCodeCollection).Doc(code).Get(ctx)
+
+	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[shareRepo.ShareValue](err)
+	}
+
+	hashKey, ok := fields.Data()["hashKey"].(string)
+
+	if !ok {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(e.ErrInvalidShareCode))
+	}
+
+	ret := r.findFromFirestore(ctx, hashKey)
+
+	if ret.IsError() {
+		return ret
+	}
+
+	// The share may have been re-issued with another code, leaving this one stale.
+	if ret.MustGet().ShareInfo.Code != code {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(e.ErrInvalidShareCode))
+	}
+
+	return ret
+}
+
 func (r *FirestoreShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *share.Share) mo.Result[bool] {
-	return r.saveToFirestore(ctx, hashKey, item, shareInfo)
+	return r.saveToFirestore(ctx, userID, hashKey, item.ID(), item.ToMap(), shareInfo)
+}
+
+func (r *FirestoreShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *share.Share) mo.Result[bool] {
+	data := item.ToMap()
+	data["Location"] = v.LocationGist.String()
+	return r.saveToFirestore(ctx, userID, hashKey, item.ID(), data, shareInfo)
 }
 
 func (r *FirestoreShareRepository) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
 	tx := values.GetFirestoreTx(ctx)
+	code := r.findCode(ctx, hashKey)
 
 	if tx.IsAbsent() {
 		_, err := r.client.Collection(shareCollection).Doc(hashKey).Delete(ctx)
@@ -44,6 +83,12 @@ func (r *FirestoreShareRepository) Delete(ctx context.Context, userID, hashKey s
 			return mo.Err[bool](err)
 		}
 
+		if c, ok := code.Get(); ok {
+			if _, err := r.client.Collection(shareCodeCollection).Doc(c).Delete(ctx); err != nil {
+				return mo.Err[bool](err)
+			}
+		}
+
 		return mo.Ok(true)
 	}
 
@@ -54,9 +99,29 @@ func (r *FirestoreShareRepository) Delete(ctx context.Context, userID, hashKey s
 		return mo.Err[bool](err)
 	}
 
+	if c, ok := code.Get(); ok {
+		if err := tx.MustGet().Delete(r.client.Collection(shareCodeCollection).Doc(c)); err != nil {
+			return mo.Err[bool](err)
+		}
+	}
+
 	return mo.Ok(true)
 }
 
+func (r *FirestoreShareRepository) findCode(ctx context.Context, hashKey string) mo.Option[string] {
+	fields, err := r.client.Collection(shareCollection).Doc(hashKey).Get(ctx)
+
+	if err != nil {
+		return mo.None[string]()
+	}
+
+	if code, ok := fields.Data()["code"].(string); ok && code != "" {
+		return mo.Some(code)
+	}
+
+	return mo.None[string]()
+}
+
 func (r *FirestoreShareRepository) findFromFirestore(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
 	fields, err := r.client.Collection(shareCollection).Doc(hashKey).Get(ctx)
 
@@ -69,17 +134,33 @@ func (r *FirestoreShareRepository) findFromFirestore(ctx context.Context, hashKe
 	}
 
 	data := fields.Data()
-	item := diagramitem.MapToDiagramItem(data)
+	shareValue := shareRepo.ShareValue{}
 
-	if item.IsError() {
-		return mo.Err[shareRepo.ShareValue](item.Error())
+	if location, ok := data["Location"].(string); ok && location == v.LocationGist.String() {
+		item := gistitem.MapToGistItem(data)
+
+		if item.IsError() {
+			return mo.Err[shareRepo.ShareValue](item.Error())
+		}
+
+		shareValue.GistItem = item.MustGet()
+	} else {
+		item := diagramitem.MapToDiagramItem(data)
+
+		if item.IsError() {
+			return mo.Err[shareRepo.ShareValue](item.Error())
+		}
+
+		shareValue.DiagramItem = item.MustGet()
 	}
 
 	var (
 		allowIPList    []string
 		allowEmailList []string
 		token          string
+		code           string
 		expireTime     int64
+		remainingViews = mo.None[int]()
 	)
 	p := data["password"].(string)
 
@@ -99,41 +180,107 @@ func (r *FirestoreShareRepository) findFromFirestore(ctx context.Context, hashKe
 		token = v.(string)
 	}
 
+	if v, ok := data["code"]; ok {
+		code = v.(string)
+	}
+
 	if v, ok := data["expireTime"]; ok {
 		expireTime = v.(int64)
 	}
 
+	if v, ok := data["remainingViews"].(int64); ok {
+		remainingViews = mo.Some(int(v))
+	}
+
 	shareInfo := share.Share{
 		Token:          token,
+		Code:           code,
+		RemainingViews: remainingViews,
 		ExpireTime:     expireTime,
 		Password:       p,
 		AllowIPList:    allowIPList,
 		AllowEmailList: allowEmailList,
 	}
-	return mo.Ok(shareRepo.ShareValue{DiagramItem: item.OrEmpty(), ShareInfo: &shareInfo})
+	shareValue.ShareInfo = &shareInfo
+	shareValue.UserID, _ = data["uid"].(string)
+	return mo.Ok(shareValue)
 }
 
-func (r *FirestoreShareRepository) saveToFirestore(ctx context.Context, hashKey string, item *diagramitem.DiagramItem, shareInfo *share.Share) mo.Result[bool] {
-	var savePassword string
+// saveToFirestore stores the share and reserves its code in one transaction, so that two shares racing for the same
+// code cannot both take it.
+func (r *FirestoreShareRepository) saveToFirestore(ctx context.Context, userID, hashKey, itemID string, data map[string]interface{}, shareInfo *share.Share) mo.Result[bool] {
+	data["uid"] = userID
+	data["password"] = shareInfo.Password
+	data["allowIPList"] = shareInfo.AllowIPList
+	data["token"] = shareInfo.Token
+	data["code"] = shareInfo.Code
+	data["expireTime"] = shareInfo.ExpireTime
+	data["allowEmailList"] = shareInfo.AllowEmailList
+
+	if views, ok := shareInfo.RemainingViews.Get(); ok {
+		data["remainingViews"] = views
+	}
+
+	shareRef := r.client.Collection(shareCollection).Doc(hashKey)
 
-	if shareInfo.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(shareInfo.Password), bcrypt.DefaultCost)
+	// Firestore transactions read every document before they write any.
+	save := func(ctx context.Context, tx *firestore.Transaction) error {
+		previousCode, err := r.codeOf(tx, shareRef)
 
 		if err != nil {
-			return mo.Err[bool](err)
+			return err
 		}
-		savePassword = string(hashedPassword)
+
+		var codeRef *firestore.DocumentRef
+		unused := false
+
+		if shareInfo.Code != "" {
+			codeRef = r.client.Collection(shareCodeCollection).Doc(shareInfo.Code)
+
+			if unused, err = r.checkCode(tx, codeRef, hashKey); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Set(shareRef, data); err != nil {
+			return err
+		}
+
+		if c, ok := previousCode.Get(); ok && c != shareInfo.Code {
+			if err := tx.Delete(r.client.Collection(shareCodeCollection).Doc(c)); err != nil {
+				return err
+			}
+		}
+
+		if codeRef == nil {
+			return nil
+		}
+
+		reservation := map[string]interface{}{
+			"hashKey": hashKey,
+			"itemID":  itemID,
+		}
+
+		// Create fails when another share took the code since it was read.
+		if unused {
+			return tx.Create(codeRef, reservation)
+		}
+
+		return tx.Set(codeRef, reservation)
+	}
+
+	tx := values.GetFirestoreTx(ctx)
+	var err error
+
+	if tx.IsPresent() {
+		err = save(ctx, tx.MustGet())
 	} else {
-		savePassword = ""
+		err = r.client.RunTransaction(ctx, save)
 	}
 
-	v := item.ToMap()
-	v["password"] = savePassword
-	v["allowIPList"] = shareInfo.AllowIPList
-	v["token"] = shareInfo.Token
-	v["expireTime"] = shareInfo.ExpireTime
-	v["allowEmailList"] = shareInfo.AllowEmailList
-	_, err := r.client.Collection(shareCollection).Doc(hashKey).Set(ctx, v)
+	if status.Code(err) == codes.AlreadyExists {
+		return mo.Err[bool](e.ErrShareCodeConflict)
+	}
 
 	if err != nil {
 		return mo.Err[bool](err)
@@ -141,3 +288,92 @@ func (r *FirestoreShareRepository) saveToFirestore(ctx context.Context, hashKey
 
 	return mo.Ok(true)
 }
+
+// codeOf returns the code of the stored share, if any.
+func (r *FirestoreShareRepository) codeOf(tx *firestore.Transaction, shareRef *firestore.DocumentRef) (mo.Option[string], error) {
+	doc, err := tx.Get(shareRef)
+
+	if status.Code(err) == codes.NotFound {
+		return mo.None[string](), nil
+	}
+
+	if err != nil {
+		return mo.None[string](), err
+	}
+
+	if code, ok := doc.Data()["code"].(string); ok && code != "" {
+		return mo.Some(code), nil
+	}
+
+	return mo.None[string](), nil
+}
+
+// checkCode fails with ErrShareCodeConflict when the code is reserved for another share. unused reports that
+// no share reserved the code at all. A reservation left behind by a share that has moved on to another code
+// does not count, like FindByCode does not return it.
+func (r *FirestoreShareRepository) checkCode(tx *firestore.Transaction, codeRef *firestore.DocumentRef, hashKey string) (unused bool, err error) {
+	doc, err := tx.Get(codeRef)
+
+	if status.Code(err) == codes.NotFound {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	owner, _ := doc.Data()["hashKey"].(string)
+
+	if owner == hashKey || owner == "" {
+		return false, nil
+	}
+
+	ownerCode, err := r.codeOf(tx, r.client.Collection(shareCollection).Doc(owner))
+
+	if err != nil {
+		return false, err
+	}
+
+	if ownerCode.OrEmpty() == codeRef.ID {
+		return false, e.ErrShareCodeConflict
+	}
+
+	return false, nil
+}
+
+func (r *FirestoreShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	ref := r.client.Collection(shareCollection).Doc(hashKey)
+	var remaining int
+
+	consume := func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+
+		if err != nil {
+			return err
+		}
+
+		views, ok := doc.Data()["remainingViews"].(int64)
+
+		if !ok || views <= 0 {
+			return e.NotFoundError(e.ErrShareViewsExceeded)
+		}
+
+		remaining = int(views - 1)
+		return tx.Update(ref, []firestore.Update{{Path: "remainingViews", Value: firestore.Increment(-1)}})
+	}
+
+	tx := values.GetFirestoreTx(ctx)
+	var err error
+
+	if tx.IsPresent() {
+		err = consume(ctx, tx.MustGet())
+	} else {
+		err = r.client.RunTransaction(ctx, consume)
+	}
+
+	if err != nil {
+		return mo.Err[int](err)
+	}
+
+	return mo.Ok(remaining)
+}
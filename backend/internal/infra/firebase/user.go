@@ -1,26 +1,27 @@
 package firebase
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"net/http"
-	"time"
 
 	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/domain/model/user"
 	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	"github.com/harehare/textusm/internal/github"
+	"github.com/harehare/textusm/internal/idtoken"
 	"github.com/samber/mo"
 )
 
 type FirebaseUserRepository struct {
-	app *firebase.App
+	app          *firebase.App
+	verifier     *idtoken.Verifier
+	githubClient *github.Client
 }
 
-func NewUserRepository(config *config.Config) userRepo.UserRepository {
-	return &FirebaseUserRepository{app: config.FirebaseApp}
+func NewUserRepository(config *config.Config, githubClient *github.Client) userRepo.UserRepository {
+	return &FirebaseUserRepository{app: config.FirebaseApp, verifier: config.TokenVerifier, githubClient: githubClient}
 }
 
 func (r *FirebaseUserRepository) Find(ctx context.Context, uid string) mo.Result[*user.User] {
@@ -38,36 +39,40 @@ func (r *FirebaseUserRepository) Find(ctx context.Context, uid string) mo.Result
 }
 
 func (r *FirebaseUserRepository) RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
-	client := &http.Client{Timeout: time.Duration(30) * time.Second}
-	body := `{"access_token":"` + accessToken + `"}`
-	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("https://api.github.com/applications/%s/token", clientID), bytes.NewBuffer([]byte(body)))
+	return r.githubClient.RevokeToken(ctx, clientID, clientSecret, accessToken)
+}
+
+func (r *FirebaseUserRepository) RevokeToken(ctx context.Context) error {
+	client, err := r.app.Auth(ctx)
+
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth(clientID, clientSecret)
-	req.Header.Add("Accept", "application/vnd.github.v3+json")
-	res, err := client.Do(req)
+
+	uid := values.GetUID(ctx).MustGet()
+	err = client.RevokeRefreshTokens(ctx, uid)
 
 	if err != nil {
 		return err
 	}
-	defer func() { _ = res.Body.Close() }()
+
+	r.verifier.Forget(uid)
 
 	return nil
 }
 
-func (r *FirebaseUserRepository) RevokeToken(ctx context.Context) error {
+func (r *FirebaseUserRepository) Delete(ctx context.Context, uid string) error {
 	client, err := r.app.Auth(ctx)
 
 	if err != nil {
 		return err
 	}
 
-	err = client.RevokeRefreshTokens(ctx, values.GetUID(ctx).MustGet())
-
-	if err != nil {
+	if err := client.DeleteUser(ctx, uid); err != nil && !auth.IsUserNotFound(err) {
 		return err
 	}
 
+	r.verifier.Forget(uid)
+
 	return nil
 }
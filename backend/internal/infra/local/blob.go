@@ -0,0 +1,97 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type Dir string
+
+// FileBlobStore keeps blobs as files under a directory. The content type is detected from the data when read.
+type FileBlobStore struct {
+	dir string
+}
+
+func NewBlobStore(dir Dir) blobRepo.BlobStore {
+	return &FileBlobStore{dir: string(dir)}
+}
+
+func (s *FileBlobStore) Get(ctx context.Context, key string) mo.Result[*blobRepo.Blob] {
+	p, err := s.path(key)
+
+	if err != nil {
+		return mo.Err[*blobRepo.Blob](err)
+	}
+
+	data, err := os.ReadFile(p)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return mo.Err[*blobRepo.Blob](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[*blobRepo.Blob](err)
+	}
+
+	return mo.Ok(&blobRepo.Blob{ContentType: http.DetectContentType(data), Data: data})
+}
+
+func (s *FileBlobStore) Put(ctx context.Context, key string, blob *blobRepo.Blob) mo.Result[bool] {
+	p, err := s.path(key)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	// Write to a temporary file first so readers never see a partial blob.
+	tmp := p + ".tmp"
+
+	if err := os.WriteFile(tmp, blob.Data, 0o600); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	if err := os.Rename(tmp, p); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (s *FileBlobStore) Delete(ctx context.Context, key string) mo.Result[bool] {
+	p, err := s.path(key)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (s *FileBlobStore) path(key string) (string, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(s.dir, p)
+
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", e.InvalidParameterError(fmt.Errorf("invalid blob key %q", key))
+	}
+
+	return p, nil
+}
@@ -0,0 +1,39 @@
+package local
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	"github.com/harehare/textusm/internal/github"
+	"github.com/samber/mo"
+)
+
+// UserRepository stands in for Firebase Authentication in local mode, where the only user is user.LocalUID.
+type UserRepository struct {
+	githubClient *github.Client
+}
+
+func NewUserRepository(githubClient *github.Client) userRepo.UserRepository {
+	return &UserRepository{githubClient: githubClient}
+}
+
+func (r *UserRepository) Find(ctx context.Context, uid string) mo.Result[*user.User] {
+	u := user.LocalUser()
+	u.UID = uid
+	return mo.Ok(&u)
+}
+
+func (r *UserRepository) RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
+	return r.githubClient.RevokeToken(ctx, clientID, clientSecret, accessToken)
+}
+
+// RevokeToken does nothing: requests in local mode are not authenticated with tokens.
+func (r *UserRepository) RevokeToken(ctx context.Context) error {
+	return nil
+}
+
+// Delete does nothing: there is no sign-in account to remove in local mode.
+func (r *UserRepository) Delete(ctx context.Context, uid string) error {
+	return nil
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	accountRepo "github.com/harehare/textusm/internal/domain/repository/account"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemoryDeletionRepository struct {
+	db *memdb.DB
+}
+
+func NewDeletionRepository(config *config.Config) accountRepo.DeletionRepository {
+	return &MemoryDeletionRepository{db: config.Memory}
+}
+
+func (r *MemoryDeletionRepository) FindByID(ctx context.Context, deletionID string) mo.Result[*account.Deletion] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*account.Deletion] {
+		d, ok := t.Deletions[deletionID]
+
+		if !ok {
+			return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+		}
+
+		return mo.Ok(&d)
+	})
+}
+
+func (r *MemoryDeletionRepository) FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*account.Deletion] {
+		for _, d := range t.Deletions {
+			if d.UserID == userID && d.Status.IsActive() {
+				return mo.Ok(&d)
+			}
+		}
+
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	})
+}
+
+func (r *MemoryDeletionRepository) FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*account.Deletion] {
+		deletions := []*account.Deletion{}
+
+		for _, d := range t.Deletions {
+			if isDue(d, now) {
+				deletions = append(deletions, &d)
+			}
+		}
+
+		slices.SortFunc(deletions, func(a, b *account.Deletion) int {
+			return cmp.Or(a.ScheduledAt.Compare(b.ScheduledAt), cmp.Compare(a.ID, b.ID))
+		})
+
+		return mo.Ok(page(deletions, 0, limit))
+	})
+}
+
+func (r *MemoryDeletionRepository) Create(ctx context.Context, deletion *account.Deletion) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		t.Deletions[deletion.ID] = account.Deletion{
+			ID:          deletion.ID,
+			UserID:      deletion.UserID,
+			Status:      deletion.Status,
+			RequestedAt: deletion.RequestedAt,
+			ScheduledAt: deletion.ScheduledAt,
+		}
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryDeletionRepository) Claim(ctx context.Context, deletionID string, now, leaseUntil time.Time) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		d, ok := t.Deletions[deletionID]
+
+		if !ok || !isDue(d, now) {
+			return mo.Ok(false)
+		}
+
+		d.Status = account.DeletionPurging
+		d.LeaseUntil = leaseUntil
+		t.Deletions[deletionID] = d
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryDeletionRepository) Cancel(ctx context.Context, deletionID string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		d, ok := t.Deletions[deletionID]
+
+		if !ok || d.Status != account.DeletionPending {
+			return mo.Ok(false)
+		}
+
+		d.Status = account.DeletionCancelled
+		t.Deletions[deletionID] = d
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryDeletionRepository) Complete(ctx context.Context, deletionID string, completedAt time.Time, receipt string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		d, ok := t.Deletions[deletionID]
+
+		if !ok || d.Status != account.DeletionPurging {
+			return mo.Ok(false)
+		}
+
+		d.Status = account.DeletionCompleted
+		d.LeaseUntil = time.Time{}
+		d.CompletedAt = completedAt
+		d.Receipt = receipt
+		t.Deletions[deletionID] = d
+
+		return mo.Ok(true)
+	})
+}
+
+// isDue reports whether the deletion is scheduled before now and is pending or has an expired lease.
+func isDue(d account.Deletion, now time.Time) bool {
+	if d.ScheduledAt.After(now) {
+		return false
+	}
+
+	return d.Status == account.DeletionPending || (d.Status == account.DeletionPurging && d.LeaseUntil.Before(now))
+}
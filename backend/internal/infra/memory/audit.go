@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	auditRepo "github.com/harehare/textusm/internal/domain/repository/audit"
+	"github.com/samber/mo"
+)
+
+type MemoryAuditLogRepository struct {
+	db *memdb.DB
+}
+
+func NewAuditLogRepository(config *config.Config) auditRepo.AuditLogRepository {
+	return &MemoryAuditLogRepository{db: config.Memory}
+}
+
+func (r *MemoryAuditLogRepository) Append(ctx context.Context, log *audit.Log) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		t.AuditLogs = append(t.AuditLogs, *log)
+		return mo.Ok(true)
+	})
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	"github.com/samber/mo"
+)
+
+type MemoryCatalogRepository struct {
+	db *memdb.DB
+}
+
+func NewCatalogRepository(config *config.Config) catalogRepo.CatalogRepository {
+	return &MemoryCatalogRepository{db: config.Memory}
+}
+
+func (r *MemoryCatalogRepository) UserIDs(ctx context.Context) mo.Result[[]string] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]string] {
+		uids := []string{}
+
+		for key := range t.Items {
+			uids = append(uids, key.UserID)
+		}
+
+		for key := range t.Settings {
+			uids = append(uids, key.UserID)
+		}
+
+		for _, s := range t.Shares {
+			uids = append(uids, s.UserID)
+		}
+
+		return mo.Ok(sortedIDs(uids))
+	})
+}
+
+func (r *MemoryCatalogRepository) ItemIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, locationSystem)
+}
+
+func (r *MemoryCatalogRepository) GistIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, locationGist)
+}
+
+func (r *MemoryCatalogRepository) listItemIDs(ctx context.Context, userID, location string) mo.Result[[]string] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]string] {
+		ids := []string{}
+
+		for key, i := range t.Items {
+			if key.UserID == userID && key.Location == location && i.DeletedAt == nil {
+				ids = append(ids, key.ID)
+			}
+		}
+
+		return mo.Ok(sortedIDs(ids))
+	})
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/memdb"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/mo"
+)
+
+const (
+	locationSystem = "system"
+	locationGist   = "gist"
+)
+
+// read runs fn on the tables of the transaction in ctx, or in a read-only transaction of its own.
+func read[T any](ctx context.Context, db *memdb.DB, fn func(t *memdb.Tables) mo.Result[T]) mo.Result[T] {
+	if tx, ok := values.GetMemoryTx(ctx).Get(); ok {
+		return fn(tx.Tables())
+	}
+
+	tx := db.Begin(true)
+	defer tx.Rollback()
+
+	return fn(tx.Tables())
+}
+
+// write runs fn on the tables of the transaction in ctx, or in a transaction of its own that is rolled back
+// when fn fails.
+func write[T any](ctx context.Context, db *memdb.DB, fn func(t *memdb.Tables) mo.Result[T]) mo.Result[T] {
+	if tx, ok := values.GetMemoryTx(ctx).Get(); ok {
+		if tx.ReadOnly() {
+			return mo.Err[T](memdb.ErrReadOnly)
+		}
+
+		return fn(tx.Tables())
+	}
+
+	tx := db.Begin(false)
+	defer tx.Rollback()
+
+	result := fn(tx.Tables())
+
+	if result.IsOk() {
+		tx.Commit()
+	}
+
+	return result
+}
+
+// page returns the rows between offset and offset+limit.
+func page[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) {
+		return []T{}
+	}
+
+	return rows[offset:min(offset+limit, len(rows))]
+}
+
+// project clears the text and thumbnail of the rows unless fields selects them, as the SQL queries do.
+func project(rows []memdb.Item, fields v.Projection) []memdb.Item {
+	for n := range rows {
+		rows[n] = projectItem(rows[n], fields)
+	}
+
+	return rows
+}
+
+func projectItem(i memdb.Item, fields v.Projection) memdb.Item {
+	if !fields.Text {
+		i.Text = ""
+	}
+
+	if !fields.Thumbnail {
+		i.Thumbnail = nil
+	}
+
+	return i
+}
+
+func sortedIDs(ids []string) []string {
+	slices.SortFunc(ids, strings.Compare)
+	return slices.Compact(ids)
+}
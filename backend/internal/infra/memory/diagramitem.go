@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemoryItemRepository struct {
+	db *memdb.DB
+}
+
+func NewItemRepository(config *config.Config) itemRepo.ItemRepository {
+	return &MemoryItemRepository{db: config.Memory}
+}
+
+func (r *MemoryItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*diagramitem.DiagramItem] {
+		i, ok := t.Items[memdb.ItemKey{UserID: userID, Location: locationSystem, ID: itemID}]
+
+		if !ok || i.DeletedAt != nil {
+			return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(memdb.ErrNoRows))
+		}
+
+		return toItem(projectItem(i, fields))
+	})
+}
+
+func (r *MemoryItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*diagramitem.DiagramItem] {
+		rows := []memdb.Item{}
+
+		for _, itemID := range itemIDs {
+			if i, ok := t.Items[memdb.ItemKey{UserID: userID, Location: locationSystem, ID: itemID}]; ok && i.DeletedAt == nil {
+				rows = append(rows, i)
+			}
+		}
+
+		return toItems(project(rows, fields))
+	})
+}
+
+func (r *MemoryItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*diagramitem.DiagramItem] {
+		rows := listItems(t, userID, locationSystem, func(i memdb.Item) bool {
+			return i.DeletedAt == nil && i.IsPublic == isPublic &&
+				(!filter.IsBookmark || i.IsBookmark) &&
+				filter.Diagram.OrElse(v.Diagram(i.Diagram)) == v.Diagram(i.Diagram)
+		}, sortBy(filter.SortOrDefault()))
+
+		return toItems(project(page(rows, offset, limit), fields))
+	})
+}
+
+func (r *MemoryItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[*diagramitem.DiagramItem] {
+		key := memdb.ItemKey{UserID: userID, Location: locationSystem, ID: item.ID()}
+		row := memdb.Item{
+			UserID:     userID,
+			Location:   locationSystem,
+			ID:         item.ID(),
+			Title:      item.Title(),
+			Text:       item.EncryptedText(),
+			Thumbnail:  item.Thumbnail(),
+			Diagram:    string(item.Diagram()),
+			IsPublic:   isPublic,
+			IsBookmark: item.IsBookmark(),
+			CreatedAt:  item.CreatedAt(),
+			UpdatedAt:  item.UpdatedAt(),
+		}
+
+		if current, ok := t.Items[key]; ok {
+			row.CreatedAt = current.CreatedAt
+			row.DeletedAt = current.DeletedAt
+		}
+
+		t.Items[key] = row
+
+		return mo.Ok(item)
+	})
+}
+
+func (r *MemoryItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		key := memdb.ItemKey{UserID: userID, Location: locationSystem, ID: itemID}
+
+		// A public item is the private row marked public, so the public copy goes by clearing the mark.
+		if isPublic {
+			if i, ok := t.Items[key]; ok {
+				i.IsPublic = false
+				t.Items[key] = i
+			}
+		} else {
+			delete(t.Items, key)
+		}
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*diagramitem.DiagramItem] {
+		rows := listItems(t, userID, locationSystem, func(i memdb.Item) bool {
+			return i.DeletedAt != nil
+		}, func(a, b memdb.Item) int {
+			return cmp.Or(b.DeletedAt.Compare(*a.DeletedAt), cmp.Compare(a.ID, b.ID))
+		})
+
+		return toItems(project(page(rows, offset, limit), fields))
+	})
+}
+
+func (r *MemoryItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]itemRepo.TrashedItem] {
+		var rows []memdb.Item
+
+		for _, i := range t.Items {
+			if i.DeletedAt != nil && i.DeletedAt.Before(deletedBefore) {
+				rows = append(rows, i)
+			}
+		}
+
+		slices.SortFunc(rows, func(a, b memdb.Item) int {
+			return cmp.Or(a.DeletedAt.Compare(*b.DeletedAt), cmp.Compare(a.UserID, b.UserID), cmp.Compare(a.ID, b.ID))
+		})
+
+		trashed := []itemRepo.TrashedItem{}
+
+		for _, i := range page(rows, 0, limit) {
+			trashed = append(trashed, itemRepo.TrashedItem{UserID: i.UserID, ItemID: i.ID})
+		}
+
+		return mo.Ok(trashed)
+	})
+}
+
+func (r *MemoryItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		key := memdb.ItemKey{UserID: userID, Location: locationSystem, ID: itemID}
+		i, ok := t.Items[key]
+
+		if !ok || i.DeletedAt != nil {
+			return mo.Ok(false)
+		}
+
+		i.IsPublic = false
+		i.DeletedAt = &deletedAt
+		t.Items[key] = i
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		key := memdb.ItemKey{UserID: userID, Location: locationSystem, ID: itemID}
+		i, ok := t.Items[key]
+
+		if !ok || i.DeletedAt == nil {
+			return mo.Ok(false)
+		}
+
+		i.DeletedAt = nil
+		t.Items[key] = i
+
+		return mo.Ok(true)
+	})
+}
+
+// listItems returns the items of the user at location that match, sorted with compare.
+func listItems(t *memdb.Tables, userID, location string, match func(i memdb.Item) bool, compare func(a, b memdb.Item) int) []memdb.Item {
+	var rows []memdb.Item
+
+	for _, i := range t.Items {
+		if i.UserID == userID && i.Location == location && match(i) {
+			rows = append(rows, i)
+		}
+	}
+
+	slices.SortFunc(rows, compare)
+
+	return rows
+}
+
+// byUpdatedAt sorts the most recently updated items first, as the Firestore backend does.
+func byUpdatedAt(a, b memdb.Item) int {
+	return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(a.ID, b.ID))
+}
+
+func byCreatedAt(a, b memdb.Item) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.ID, b.ID))
+}
+
+func byTitle(a, b memdb.Item) int {
+	return cmp.Or(cmp.Compare(a.Title, b.Title), cmp.Compare(a.ID, b.ID))
+}
+
+func sortBy(sort v.ItemSort) func(a, b memdb.Item) int {
+	switch sort {
+	case v.ItemSortCreatedAt:
+		return byCreatedAt
+	case v.ItemSortTitle:
+		return byTitle
+	default:
+		return byUpdatedAt
+	}
+}
+
+func toItems(rows []memdb.Item) mo.Result[[]*diagramitem.DiagramItem] {
+	items := []*diagramitem.DiagramItem{}
+
+	for _, row := range rows {
+		item := toItem(row)
+
+		if item.IsError() {
+			return mo.Err[[]*diagramitem.DiagramItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
+
+func toItem(i memdb.Item) mo.Result[*diagramitem.DiagramItem] {
+	return diagramitem.New().
+		WithID(i.ID).
+		WithTitle(i.Title).
+		WithEncryptedText(i.Text).
+		WithThumbnail(mo.PointerToOption(i.Thumbnail)).
+		WithDiagramString(i.Diagram).
+		WithIsPublic(i.IsPublic).
+		WithIsBookmark(i.IsBookmark).
+		WithCreatedAt(i.CreatedAt).
+		WithUpdatedAt(i.UpdatedAt).
+		WithDeletedAt(mo.PointerToOption(i.DeletedAt)).
+		Build()
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newItem(t *testing.T, id string, updatedAt time.Time) *diagramitem.DiagramItem {
+	t.Helper()
+
+	return diagramitem.New().
+		WithID(id).
+		WithTitle("title " + id).
+		WithEncryptedText("text").
+		WithDiagram(v.DiagramUserStoryMap).
+		WithCreatedAt(updatedAt).
+		WithUpdatedAt(updatedAt).
+		Build().MustGet()
+}
+
+func TestItemRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewItemRepository(&config.Config{Memory: memdb.New()})
+
+	for i := range 3 {
+		item := newItem(t, fmt.Sprintf("item%d", i), now.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, repo.Save(ctx, "user", item, false).Error())
+	}
+
+	items := repo.Find(ctx, "user", 0, 2, false, v.ItemFilter{}, v.AllFields)
+	require.NoError(t, items.Error())
+	require.Len(t, items.MustGet(), 2)
+	assert.Equal(t, "item2", items.MustGet()[0].ID())
+	assert.Equal(t, "item1", items.MustGet()[1].ID())
+
+	assert.Empty(t, repo.Find(ctx, "other", 0, 10, false, v.ItemFilter{}, v.AllFields).MustGet())
+
+	trashed := repo.Trash(ctx, "user", "item0", now)
+	require.NoError(t, trashed.Error())
+	assert.True(t, trashed.MustGet())
+	assert.False(t, repo.Trash(ctx, "user", "item0", now).MustGet())
+
+	found := repo.FindByID(ctx, "user", "item0", false, v.AllFields)
+	assert.Equal(t, e.NotFound, e.GetCode(found.Error()))
+
+	expired := repo.FindExpiredTrash(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, expired.Error())
+	assert.Len(t, expired.MustGet(), 1)
+
+	assert.True(t, repo.Restore(ctx, "user", "item0").MustGet())
+	assert.Equal(t, "item0", repo.FindByID(ctx, "user", "item0", false, v.AllFields).MustGet().ID())
+
+	require.NoError(t, repo.Delete(ctx, "user", "item0", false).Error())
+	assert.Len(t, repo.Find(ctx, "user", 0, 10, false, v.ItemFilter{}, v.AllFields).MustGet(), 2)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Memory: memdb.New()}
+	repo := NewItemRepository(cfg)
+	tx := db.NewMemoryTx(cfg)
+	failed := errors.New("failed")
+
+	err := tx.Do(ctx, func(ctx context.Context) error {
+		if err := repo.Save(ctx, "user", newItem(t, "item", time.Now()), false).Error(); err != nil {
+			return err
+		}
+
+		// A nested transaction joins the outer one.
+		return tx.Do(ctx, func(ctx context.Context) error {
+			assert.True(t, repo.FindByID(ctx, "user", "item", false, v.AllFields).IsOk())
+			return failed
+		})
+	})
+
+	assert.ErrorIs(t, err, failed)
+	assert.True(t, repo.FindByID(ctx, "user", "item", false, v.AllFields).IsError())
+
+	err = tx.DoReadOnly(ctx, func(ctx context.Context) error {
+		return repo.Save(ctx, "user", newItem(t, "item", time.Now()), false).Error()
+	})
+
+	assert.ErrorIs(t, err, memdb.ErrReadOnly)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	const (
+		writers        = 8
+		readers        = 16
+		itemsPerWriter = 25
+	)
+
+	ctx := context.Background()
+	cfg := &config.Config{Memory: memdb.New()}
+	repo := NewItemRepository(cfg)
+	tx := db.NewMemoryTx(cfg)
+
+	var wg sync.WaitGroup
+
+	for w := range writers {
+		wg.Go(func() {
+			userID := fmt.Sprintf("user%d", w)
+
+			for i := range itemsPerWriter {
+				err := tx.Do(values.WithUID(ctx, userID), func(ctx context.Context) error {
+					return repo.Save(ctx, userID, newItem(t, fmt.Sprintf("item%d", i), time.Now()), false).Error()
+				})
+
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	for r := range readers {
+		wg.Go(func() {
+			userID := fmt.Sprintf("user%d", r%writers)
+
+			for range itemsPerWriter {
+				err := tx.DoReadOnly(ctx, func(ctx context.Context) error {
+					return repo.Find(ctx, userID, 0, 100, false, v.ItemFilter{}, v.Projection{Text: true}).Error()
+				})
+
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	for w := range writers {
+		assert.Len(t, repo.Find(ctx, fmt.Sprintf("user%d", w), 0, 100, false, v.ItemFilter{}, v.Projection{Text: true}).MustGet(), itemsPerWriter)
+	}
+}
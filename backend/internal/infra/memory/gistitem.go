@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemoryGistItemRepository struct {
+	db *memdb.DB
+}
+
+func NewGistItemRepository(config *config.Config) itemRepo.GistItemRepository {
+	return &MemoryGistItemRepository{db: config.Memory}
+}
+
+func (r *MemoryGistItemRepository) FindByID(ctx context.Context, userID string, gistID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*gistitem.GistItem] {
+		i, ok := t.Items[memdb.ItemKey{UserID: userID, Location: locationGist, ID: gistID}]
+
+		if !ok {
+			return mo.Err[*gistitem.GistItem](e.NotFoundError(memdb.ErrNoRows))
+		}
+
+		return toGistItem(projectItem(i, fields))
+	})
+}
+
+func (r *MemoryGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*gistitem.GistItem] {
+		rows := []memdb.Item{}
+
+		for _, gistID := range gistIDs {
+			if i, ok := t.Items[memdb.ItemKey{UserID: userID, Location: locationGist, ID: gistID}]; ok {
+				rows = append(rows, i)
+			}
+		}
+
+		return toGistItems(project(rows, fields))
+	})
+}
+
+func (r *MemoryGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*gistitem.GistItem] {
+		rows := listItems(t, userID, locationGist, func(i memdb.Item) bool {
+			return !i.IsPublic && !i.IsBookmark
+		}, byUpdatedAt)
+
+		return toGistItems(project(page(rows, offset, limit), fields))
+	})
+}
+
+func (r *MemoryGistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[*gistitem.GistItem] {
+		key := memdb.ItemKey{UserID: userID, Location: locationGist, ID: item.ID()}
+		row := memdb.Item{
+			UserID:     userID,
+			Location:   locationGist,
+			ID:         item.ID(),
+			Title:      item.Title(),
+			Text:       item.EncryptedText(),
+			Thumbnail:  item.Thumbnail(),
+			Diagram:    string(item.Diagram()),
+			IsBookmark: item.IsBookmark(),
+			Revision:   item.Revision(),
+			CreatedAt:  item.CreatedAt(),
+			UpdatedAt:  item.UpdatedAt(),
+		}
+
+		if current, ok := t.Items[key]; ok {
+			row.CreatedAt = current.CreatedAt
+		}
+
+		t.Items[key] = row
+
+		return mo.Ok(item)
+	})
+}
+
+func (r *MemoryGistItemRepository) Delete(ctx context.Context, userID string, gistID string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		delete(t.Items, memdb.ItemKey{UserID: userID, Location: locationGist, ID: gistID})
+		return mo.Ok(true)
+	})
+}
+
+func toGistItem(i memdb.Item) mo.Result[*gistitem.GistItem] {
+	return gistitem.New().
+		WithID(i.ID).
+		WithTitle(i.Title).
+		WithThumbnail(mo.PointerToOption(i.Thumbnail)).
+		WithDiagramString(i.Diagram).
+		WithIsBookmark(i.IsBookmark).
+		WithCreatedAt(i.CreatedAt).
+		WithUpdatedAt(i.UpdatedAt).
+		WithRevision(i.Revision).
+		WithEncryptedText(i.Text).
+		Build()
+}
+
+func toGistItems(rows []memdb.Item) mo.Result[[]*gistitem.GistItem] {
+	items := []*gistitem.GistItem{}
+
+	for _, row := range rows {
+		item := toGistItem(row)
+
+		if item.IsError() {
+			return mo.Err[[]*gistitem.GistItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemoryGithubTokenRepository struct {
+	db *memdb.DB
+}
+
+func NewGithubTokenRepository(config *config.Config) userRepo.GithubTokenRepository {
+	return &MemoryGithubTokenRepository{db: config.Memory}
+}
+
+func (r *MemoryGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*user.GithubToken] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*user.GithubToken] {
+		token, ok := t.GithubTokens[uid]
+
+		if !ok {
+			return mo.Err[*user.GithubToken](e.NotFoundError(e.ErrGithubNotConnected))
+		}
+
+		return mo.Ok(user.NewGithubTokenFromEncrypted(token.AccessToken, token.Scope, token.CreatedAt, token.UpdatedAt))
+	})
+}
+
+func (r *MemoryGithubTokenRepository) Save(ctx context.Context, uid string, token *user.GithubToken) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		row := memdb.GithubToken{
+			UserID:      uid,
+			AccessToken: token.EncryptedAccessToken(),
+			Scope:       token.Scope(),
+			CreatedAt:   token.CreatedAt(),
+			UpdatedAt:   token.UpdatedAt(),
+		}
+
+		if current, ok := t.GithubTokens[uid]; ok {
+			row.CreatedAt = current.CreatedAt
+		}
+
+		t.GithubTokens[uid] = row
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		delete(t.GithubTokens, uid)
+		return mo.Ok(true)
+	})
+}
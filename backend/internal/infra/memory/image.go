@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemoryImageRepository struct {
+	db *memdb.DB
+}
+
+func NewImageRepository(config *config.Config) imageRepo.ImageRepository {
+	return &MemoryImageRepository{db: config.Memory}
+}
+
+func (r *MemoryImageRepository) FindByID(ctx context.Context, imageID string) mo.Result[*image.Image] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*image.Image] {
+		img, ok := t.Images[imageID]
+
+		if !ok {
+			return mo.Err[*image.Image](e.NotFoundError(e.ErrImageNotFound))
+		}
+
+		return mo.Ok(&img)
+	})
+}
+
+func (r *MemoryImageRepository) FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[[]*image.Image] {
+		images := []*image.Image{}
+
+		for _, img := range t.Images {
+			if img.UserID == userID {
+				images = append(images, &img)
+			}
+		}
+
+		slices.SortFunc(images, func(a, b *image.Image) int {
+			return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+		})
+
+		return mo.Ok(images)
+	})
+}
+
+func (r *MemoryImageRepository) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[int64] {
+		var usage int64
+
+		for _, img := range t.Images {
+			if img.UserID == userID {
+				usage += img.Size
+			}
+		}
+
+		return mo.Ok(usage)
+	})
+}
+
+func (r *MemoryImageRepository) Save(ctx context.Context, img *image.Image) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		t.Images[img.ID] = *img
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryImageRepository) SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		var usage int64
+
+		for _, i := range t.Images {
+			if i.UserID == img.UserID {
+				usage += i.Size
+			}
+		}
+
+		if usage+img.Size > quota {
+			return mo.Ok(false)
+		}
+
+		t.Images[img.ID] = *img
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryImageRepository) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		if img, ok := t.Images[imageID]; ok && img.UserID == userID {
+			delete(t.Images, imageID)
+		}
+
+		return mo.Ok(true)
+	})
+}
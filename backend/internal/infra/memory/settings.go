@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemorySettingsRepository struct {
+	db *memdb.DB
+}
+
+func NewSettingsRepository(config *config.Config) settingsRepo.SettingsRepository {
+	return &MemorySettingsRepository{db: config.Memory}
+}
+
+func (r *MemorySettingsRepository) Find(ctx context.Context, userID string, diagram values.Diagram) mo.Result[*settings.Settings] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*settings.Settings] {
+		s, ok := t.Settings[memdb.SettingsKey{UserID: userID, Diagram: string(diagram)}]
+
+		if !ok {
+			return mo.Err[*settings.Settings](e.NotFoundError(memdb.ErrNoRows))
+		}
+
+		return mo.Ok(&s.Settings)
+	})
+}
+
+func (r *MemorySettingsRepository) Save(ctx context.Context, userID string, diagram values.Diagram, s *settings.Settings) mo.Result[*settings.Settings] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[*settings.Settings] {
+		row := memdb.Settings{UserID: userID, Diagram: string(diagram), Settings: *s}
+		t.Settings[row.Key()] = row
+
+		return mo.Ok(s)
+	})
+}
+
+func (r *MemorySettingsRepository) Delete(ctx context.Context, userID string, diagram values.Diagram) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		delete(t.Settings, memdb.SettingsKey{UserID: userID, Diagram: string(diagram)})
+		return mo.Ok(true)
+	})
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/share"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MemoryShareRepository struct {
+	db *memdb.DB
+}
+
+func NewShareRepository(config *config.Config) shareRepo.ShareRepository {
+	return &MemoryShareRepository{db: config.Memory}
+}
+
+func (r *MemoryShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[shareRepo.ShareValue] {
+		s, ok := t.Shares[hashKey]
+
+		if !ok {
+			return mo.Err[shareRepo.ShareValue](e.NotFoundError(memdb.ErrNoRows))
+		}
+
+		return toShareValue(t, s)
+	})
+}
+
+func (r *MemoryShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[shareRepo.ShareValue] {
+		for _, s := range t.Shares {
+			if code != "" && s.Code == code {
+				return toShareValue(t, s)
+			}
+		}
+
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(memdb.ErrNoRows))
+	})
+}
+
+func (r *MemoryShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), locationSystem, shareInfo)
+}
+
+func (r *MemoryShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), locationGist, shareInfo)
+}
+
+// save replaces the share of the item, keeping share codes unique as the unique index of the SQL backends does.
+func (r *MemoryShareRepository) save(ctx context.Context, userID, hashKey, itemID, location string, shareInfo *share.Share) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		isItemShare := func(s memdb.Share) bool {
+			return s.UserID == userID && s.Location == location && s.ItemID == itemID
+		}
+
+		for key, s := range t.Shares {
+			if key != hashKey && !isItemShare(s) && shareInfo.Code != "" && s.Code == shareInfo.Code {
+				return mo.Err[bool](e.ErrShareCodeConflict)
+			}
+		}
+
+		maps.DeleteFunc(t.Shares, func(_ string, s memdb.Share) bool {
+			return isItemShare(s)
+		})
+
+		t.Shares[hashKey] = memdb.Share{
+			HashKey:        hashKey,
+			UserID:         userID,
+			Location:       location,
+			ItemID:         itemID,
+			Token:          shareInfo.Token,
+			Code:           shareInfo.Code,
+			Password:       shareInfo.Password,
+			AllowIPList:    slices.Clone(shareInfo.AllowIPList),
+			AllowEmailList: slices.Clone(shareInfo.AllowEmailList),
+			ExpireTime:     shareInfo.ExpireTime,
+			RemainingViews: shareInfo.RemainingViews.ToPointer(),
+			CreatedAt:      time.Now(),
+		}
+
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryShareRepository) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		delete(t.Shares, hashKey)
+		return mo.Ok(true)
+	})
+}
+
+func (r *MemoryShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[int] {
+		s, ok := t.Shares[hashKey]
+
+		if !ok || s.RemainingViews == nil || *s.RemainingViews <= 0 {
+			return mo.Err[int](e.NotFoundError(e.ErrShareViewsExceeded))
+		}
+
+		remaining := *s.RemainingViews - 1
+		s.RemainingViews = &remaining
+		t.Shares[hashKey] = s
+
+		return mo.Ok(remaining)
+	})
+}
+
+func toShareValue(t *memdb.Tables, s memdb.Share) mo.Result[shareRepo.ShareValue] {
+	i, ok := t.Items[memdb.ItemKey{UserID: s.UserID, Location: s.Location, ID: s.ItemID}]
+
+	if !ok {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(memdb.ErrNoRows))
+	}
+
+	shareInfo := share.Share{
+		Token:          s.Token,
+		Code:           s.Code,
+		RemainingViews: mo.PointerToOption(s.RemainingViews),
+		ExpireTime:     s.ExpireTime,
+		Password:       s.Password,
+		AllowIPList:    slices.Clone(s.AllowIPList),
+		AllowEmailList: slices.Clone(s.AllowEmailList),
+	}
+
+	if s.Location == locationGist {
+		gistItem := toGistItem(i)
+
+		if gistItem.IsError() {
+			return mo.Err[shareRepo.ShareValue](gistItem.Error())
+		}
+
+		return mo.Ok(shareRepo.ShareValue{GistItem: gistItem.MustGet(), ShareInfo: &shareInfo, UserID: s.UserID})
+	}
+
+	item := toItem(i)
+
+	if item.IsError() {
+		return mo.Err[shareRepo.ShareValue](item.Error())
+	}
+
+	return mo.Ok(shareRepo.ShareValue{DiagramItem: item.MustGet(), ShareInfo: &shareInfo, UserID: s.UserID})
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/memdb"
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/harehare/textusm/internal/github"
+	"github.com/samber/mo"
+)
+
+// MemoryUserRepository stands in for Firebase Authentication. Its users come from the loaded snapshot.
+type MemoryUserRepository struct {
+	db           *memdb.DB
+	githubClient *github.Client
+}
+
+func NewUserRepository(config *config.Config, githubClient *github.Client) userRepo.UserRepository {
+	return &MemoryUserRepository{db: config.Memory, githubClient: githubClient}
+}
+
+func (r *MemoryUserRepository) Find(ctx context.Context, uid string) mo.Result[*user.User] {
+	return read(ctx, r.db, func(t *memdb.Tables) mo.Result[*user.User] {
+		u, ok := t.Users[uid]
+
+		if !ok {
+			return mo.Err[*user.User](e.NotFoundError(memdb.ErrNoRows))
+		}
+
+		return mo.Ok(&u)
+	})
+}
+
+func (r *MemoryUserRepository) RevokeGistToken(ctx context.Context, clientID, clientSecret, accessToken string) error {
+	return r.githubClient.RevokeToken(ctx, clientID, clientSecret, accessToken)
+}
+
+// RevokeToken does nothing: there are no refresh tokens to revoke.
+func (r *MemoryUserRepository) RevokeToken(ctx context.Context) error {
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, uid string) error {
+	return write(ctx, r.db, func(t *memdb.Tables) mo.Result[bool] {
+		delete(t.Users, uid)
+		return mo.Ok(true)
+	}).Error()
+}
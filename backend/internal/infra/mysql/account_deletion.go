@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	accountRepo "github.com/harehare/textusm/internal/domain/repository/account"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlDeletionRepository struct {
+	_db *mysql.Queries
+}
+
+func NewDeletionRepository(config *config.Config) accountRepo.DeletionRepository {
+	return &MysqlDeletionRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlDeletionRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlDeletionRepository) FindByID(ctx context.Context, deletionID string) mo.Result[*account.Deletion] {
+	d, err := r.tx(ctx).GetAccountDeletion(ctx, deletionID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return mo.Ok(toDeletion(d))
+}
+
+func (r *MysqlDeletionRepository) FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion] {
+	d, err := r.tx(ctx).GetActiveAccountDeletion(ctx, userID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return mo.Ok(toDeletion(d))
+}
+
+func (r *MysqlDeletionRepository) FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion] {
+	rows, err := r.tx(ctx).ListDueAccountDeletions(ctx, mysql.ListDueAccountDeletionsParams{
+		ScheduledAt: now,
+		LeaseUntil:  timeToNullTime(now),
+		Limit:       int32(limit),
+	})
+
+	if err != nil {
+		return mo.Err[[]*account.Deletion](err)
+	}
+
+	deletions := make([]*account.Deletion, len(rows))
+
+	for i, row := range rows {
+		deletions[i] = toDeletion(row)
+	}
+
+	return mo.Ok(deletions)
+}
+
+func (r *MysqlDeletionRepository) Create(ctx context.Context, deletion *account.Deletion) mo.Result[bool] {
+	err := r.tx(ctx).CreateAccountDeletion(ctx, mysql.CreateAccountDeletionParams{
+		DeletionID:  deletion.ID,
+		Uid:         deletion.UserID,
+		Status:      string(deletion.Status),
+		RequestedAt: deletion.RequestedAt,
+		ScheduledAt: deletion.ScheduledAt,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *MysqlDeletionRepository) Claim(ctx context.Context, deletionID string, now, leaseUntil time.Time) mo.Result[bool] {
+	n, err := r.tx(ctx).ClaimAccountDeletion(ctx, mysql.ClaimAccountDeletionParams{
+		LeaseUntil:   timeToNullTime(leaseUntil),
+		DeletionID:   deletionID,
+		ScheduledAt:  now,
+		LeaseUntil_2: timeToNullTime(now),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *MysqlDeletionRepository) Cancel(ctx context.Context, deletionID string) mo.Result[bool] {
+	n, err := r.tx(ctx).CancelAccountDeletion(ctx, deletionID)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *MysqlDeletionRepository) Complete(ctx context.Context, deletionID string, completedAt time.Time, receipt string) mo.Result[bool] {
+	n, err := r.tx(ctx).CompleteAccountDeletion(ctx, mysql.CompleteAccountDeletionParams{
+		CompletedAt: timeToNullTime(completedAt),
+		Receipt:     sql.NullString{String: receipt, Valid: true},
+		DeletionID:  deletionID,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func toDeletion(d mysql.AccountDeletion) *account.Deletion {
+	return &account.Deletion{
+		ID:          d.DeletionID,
+		UserID:      d.Uid,
+		Status:      account.DeletionStatus(d.Status),
+		RequestedAt: d.RequestedAt,
+		ScheduledAt: d.ScheduledAt,
+		LeaseUntil:  nullTimeToTime(d.LeaseUntil),
+		CompletedAt: nullTimeToTime(d.CompletedAt),
+		Receipt:     d.Receipt.String,
+	}
+}
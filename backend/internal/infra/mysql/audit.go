@@ -0,0 +1,46 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	auditRepo "github.com/harehare/textusm/internal/domain/repository/audit"
+	"github.com/samber/mo"
+)
+
+type MysqlAuditLogRepository struct {
+	_db *mysql.Queries
+}
+
+func NewAuditLogRepository(config *config.Config) auditRepo.AuditLogRepository {
+	return &MysqlAuditLogRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlAuditLogRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlAuditLogRepository) Append(ctx context.Context, log *audit.Log) mo.Result[bool] {
+	err := r.tx(ctx).InsertAuditLog(ctx, mysql.InsertAuditLogParams{
+		Uid:       log.UserID,
+		Event:     string(log.Event),
+		TargetID:  log.TargetID,
+		Detail:    log.Detail,
+		CreatedAt: log.CreatedAt,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	"github.com/samber/mo"
+)
+
+type MysqlCatalogRepository struct {
+	_db *mysql.Queries
+}
+
+func NewCatalogRepository(config *config.Config) catalogRepo.CatalogRepository {
+	return &MysqlCatalogRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlCatalogRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlCatalogRepository) UserIDs(ctx context.Context) mo.Result[[]string] {
+	uids, err := r.tx(ctx).ListUIDs(ctx)
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	return mo.Ok(append([]string{}, uids...))
+}
+
+func (r *MysqlCatalogRepository) ItemIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, LocationSYSTEM)
+}
+
+func (r *MysqlCatalogRepository) GistIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, LocationGIST)
+}
+
+func (r *MysqlCatalogRepository) listItemIDs(ctx context.Context, userID, location string) mo.Result[[]string] {
+	ids, err := r.tx(ctx).ListItemIDs(ctx, mysql.ListItemIDsParams{
+		Uid:      userID,
+		Location: location,
+	})
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	return mo.Ok(append([]string{}, ids...))
+}
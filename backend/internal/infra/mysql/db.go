@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	LocationSYSTEM = "system"
+	LocationGIST   = "gist"
+)
+
+// erDupEntry is the error number of MySQL and MariaDB for a duplicate key in a unique index.
+const erDupEntry = 1062
+
+func StringToNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{String: "", Valid: false}
+	} else {
+		return sql.NullString{String: *s, Valid: true}
+	}
+}
+
+func NullStringToString(s sql.NullString) *string {
+	if s.Valid {
+		return &s.String
+	} else {
+		return nil
+	}
+}
+
+func NullBoolToBool(b sql.NullBool) *bool {
+	if b.Valid {
+		return &b.Bool
+	} else {
+		return nil
+	}
+}
+
+func BoolToNullBool(b *bool) sql.NullBool {
+	if b != nil {
+		return sql.NullBool{Bool: *b, Valid: true}
+	} else {
+		return sql.NullBool{Bool: false, Valid: false}
+	}
+}
+
+func timeToNullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func nullTimeToTime(t sql.NullTime) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+
+	return t.Time
+}
+
+// isDuplicateEntry reports whether err is a duplicate key in the unique index named index.
+func isDuplicateEntry(err error, index string) bool {
+	var mysqlErr *driver.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erDupEntry && strings.Contains(mysqlErr.Message, index)
+}
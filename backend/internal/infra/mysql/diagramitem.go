@@ -0,0 +1,280 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlItemRepository struct {
+	_db *mysql.Queries
+}
+
+func NewItemRepository(config *config.Config) itemRepo.ItemRepository {
+	return &MysqlItemRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlItemRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := values.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
+	i, err := r.tx(ctx).GetItem(ctx, mysql.GetItemParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		DiagramID:     itemID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	if i.DeletedAt.Valid {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(sql.ErrNoRows))
+	}
+
+	return toItem(&i)
+}
+
+func (r *MysqlItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := r.tx(ctx).ListItemsByIDs(ctx, mysql.ListItemsByIDsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		DiagramIds:    itemIDs,
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return toItems(dbItems)
+}
+
+func (r *MysqlItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := listItems(ctx, r.tx(ctx), filter.SortOrDefault(), mysql.ListItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		IsPublic:      isPublic,
+		IsBookmark:    sql.NullBool{Bool: true, Valid: filter.IsBookmark},
+		Diagram:       sql.NullString{String: string(filter.Diagram.OrEmpty()), Valid: filter.Diagram.IsPresent()},
+		Limit:         int32(limit),
+		Offset:        int32(offset),
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return toItems(dbItems)
+}
+
+func (r *MysqlItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+	_, err := r.tx(ctx).GetItem(ctx, mysql.GetItemParams{
+		Uid:       userID,
+		Location:  LocationSYSTEM,
+		DiagramID: item.ID(),
+	})
+
+	title := item.Title()
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = r.tx(ctx).CreateItem(ctx, mysql.CreateItemParams{
+			Uid:        userID,
+			Diagram:    string(item.Diagram()),
+			DiagramID:  item.ID(),
+			IsBookmark: item.IsBookmark(),
+			IsPublic:   isPublic,
+			Title:      sql.NullString{String: title, Valid: true},
+			Text:       item.EncryptedText(),
+			Thumbnail:  StringToNullString(item.Thumbnail()),
+			Location:   LocationSYSTEM,
+			CreatedAt:  item.CreatedAt(),
+			UpdatedAt:  item.UpdatedAt(),
+		})
+	} else if err == nil {
+		err = r.tx(ctx).UpdateItem(ctx, mysql.UpdateItemParams{
+			Diagram:    string(item.Diagram()),
+			IsBookmark: item.IsBookmark(),
+			IsPublic:   isPublic,
+			Title:      sql.NullString{String: title, Valid: true},
+			Text:       item.EncryptedText(),
+			Thumbnail:  StringToNullString(item.Thumbnail()),
+			UpdatedAt:  item.UpdatedAt(),
+			Uid:        userID,
+			Location:   LocationSYSTEM,
+			DiagramID:  item.ID(),
+		})
+	}
+
+	if err != nil {
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	return mo.Ok(item)
+}
+
+func (r *MysqlItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
+	var err error
+
+	// A public item is the private row marked public, so the public copy goes by clearing the mark.
+	if isPublic {
+		err = r.tx(ctx).UnpublishItem(ctx, mysql.UnpublishItemParams{
+			Uid:       userID,
+			Location:  LocationSYSTEM,
+			DiagramID: itemID,
+		})
+	} else {
+		err = r.tx(ctx).DeleteItem(ctx, mysql.DeleteItemParams{
+			Uid:       userID,
+			Location:  LocationSYSTEM,
+			DiagramID: itemID,
+		})
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *MysqlItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := r.tx(ctx).ListTrashedItems(ctx, mysql.ListTrashedItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		Limit:         int32(limit),
+		Offset:        int32(offset),
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return toItems(dbItems)
+}
+
+func (r *MysqlItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	rows, err := r.tx(ctx).ListExpiredTrashedItems(ctx, mysql.ListExpiredTrashedItemsParams{
+		DeletedAt: timeToNullTime(deletedBefore),
+		Limit:     int32(limit),
+	})
+
+	if err != nil {
+		return mo.Err[[]itemRepo.TrashedItem](err)
+	}
+
+	trashed := make([]itemRepo.TrashedItem, 0, len(rows))
+
+	for _, row := range rows {
+		trashed = append(trashed, itemRepo.TrashedItem{UserID: row.Uid, ItemID: row.DiagramID})
+	}
+
+	return mo.Ok(trashed)
+}
+
+func (r *MysqlItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	n, err := r.tx(ctx).TrashItem(ctx, mysql.TrashItemParams{
+		DeletedAt: timeToNullTime(deletedAt),
+		Uid:       userID,
+		Location:  LocationSYSTEM,
+		DiagramID: itemID,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *MysqlItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	n, err := r.tx(ctx).RestoreItem(ctx, mysql.RestoreItemParams{
+		Uid:       userID,
+		Location:  LocationSYSTEM,
+		DiagramID: itemID,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func toItems(dbItems []mysql.Item) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+
+	for idx := range dbItems {
+		item := toItem(&dbItems[idx])
+
+		if item.IsError() {
+			return mo.Err[[]*diagramitem.DiagramItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
+
+func toItem(i *mysql.Item) mo.Result[*diagramitem.DiagramItem] {
+	var deletedAt mo.Option[time.Time]
+
+	if i.DeletedAt.Valid {
+		deletedAt = mo.Some(i.DeletedAt.Time)
+	}
+
+	return diagramitem.New().
+		WithID(i.DiagramID).
+		WithTitle(i.Title.String).
+		WithEncryptedText(i.Text).
+		WithThumbnail(mo.PointerToOption(NullStringToString(i.Thumbnail))).
+		WithDiagramString(i.Diagram).
+		WithIsPublic(i.IsPublic).
+		WithIsBookmark(i.IsBookmark).
+		WithCreatedAt(i.CreatedAt).
+		WithUpdatedAt(i.UpdatedAt).
+		WithDeletedAt(deletedAt).
+		Build()
+}
+
+// listItems lists items with the query of sort. The queries differ only in their order, so that each one can be
+// served by the index on that order.
+func listItems(ctx context.Context, q *mysql.Queries, sort v.ItemSort, params mysql.ListItemsParams) ([]mysql.Item, error) {
+	switch sort {
+	case v.ItemSortCreatedAt:
+		return q.ListItemsByCreatedAt(ctx, mysql.ListItemsByCreatedAtParams(params))
+	case v.ItemSortTitle:
+		return q.ListItemsByTitle(ctx, mysql.ListItemsByTitleParams(params))
+	default:
+		return q.ListItems(ctx, params)
+	}
+}
@@ -0,0 +1,178 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlGistItemRepository struct {
+	_db *mysql.Queries
+}
+
+func NewGistItemRepository(config *config.Config) itemRepo.GistItemRepository {
+	return &MysqlGistItemRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlGistItemRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := values.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlGistItemRepository) FindByID(ctx context.Context, userID string, itemID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
+	i, err := r.tx(ctx).GetItem(ctx, mysql.GetItemParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationGIST,
+		DiagramID:     itemID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*gistitem.GistItem](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[*gistitem.GistItem](err)
+	}
+
+	return toGistItem(&i)
+}
+
+func (r *MysqlGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	dbItems, err := r.tx(ctx).ListItemsByIDs(ctx, mysql.ListItemsByIDsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationGIST,
+		DiagramIds:    gistIDs,
+	})
+
+	if err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
+	}
+
+	return toGistItems(dbItems)
+}
+
+func (r *MysqlGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	dbItems, err := r.tx(ctx).ListItems(ctx, mysql.ListItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationGIST,
+		IsPublic:      false,
+		IsBookmark:    sql.NullBool{Bool: false, Valid: true},
+		Limit:         int32(limit),
+		Offset:        int32(offset),
+	})
+
+	if err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
+	}
+
+	return toGistItems(dbItems)
+}
+
+func (r *MysqlGistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
+	_, err := r.tx(ctx).GetItem(ctx, mysql.GetItemParams{
+		Uid:       userID,
+		Location:  LocationGIST,
+		DiagramID: item.ID(),
+	})
+
+	title := item.Title()
+	revision := StringToNullString(mo.EmptyableToOption(item.Revision()).ToPointer())
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = r.tx(ctx).CreateItem(ctx, mysql.CreateItemParams{
+			Uid:        userID,
+			Diagram:    string(item.Diagram()),
+			DiagramID:  item.ID(),
+			IsBookmark: item.IsBookmark(),
+			IsPublic:   false,
+			Title:      sql.NullString{String: title, Valid: true},
+			Text:       item.EncryptedText(),
+			Thumbnail:  StringToNullString(item.Thumbnail()),
+			Location:   LocationGIST,
+			Revision:   revision,
+			CreatedAt:  item.CreatedAt(),
+			UpdatedAt:  item.UpdatedAt(),
+		})
+	} else if err == nil {
+		err = r.tx(ctx).UpdateItem(ctx, mysql.UpdateItemParams{
+			Diagram:    string(item.Diagram()),
+			IsBookmark: item.IsBookmark(),
+			IsPublic:   false,
+			Title:      sql.NullString{String: title, Valid: true},
+			Text:       item.EncryptedText(),
+			Thumbnail:  StringToNullString(item.Thumbnail()),
+			Revision:   revision,
+			UpdatedAt:  item.UpdatedAt(),
+			Uid:        userID,
+			Location:   LocationGIST,
+			DiagramID:  item.ID(),
+		})
+	}
+
+	if err != nil {
+		return mo.Err[*gistitem.GistItem](err)
+	}
+
+	return mo.Ok(item)
+}
+
+func (r *MysqlGistItemRepository) Delete(ctx context.Context, userID string, gistID string) mo.Result[bool] {
+	err := r.tx(ctx).DeleteItem(ctx, mysql.DeleteItemParams{Uid: userID, Location: LocationGIST, DiagramID: gistID})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func toGistItem(i *mysql.Item) mo.Result[*gistitem.GistItem] {
+	return gistitem.New().
+		WithID(i.DiagramID).
+		WithTitle(i.Title.String).
+		WithThumbnail(mo.PointerToOption(NullStringToString(i.Thumbnail))).
+		WithDiagramString(i.Diagram).
+		WithIsBookmark(i.IsBookmark).
+		WithCreatedAt(i.CreatedAt).
+		WithUpdatedAt(i.UpdatedAt).
+		WithRevision(i.Revision.String).
+		WithEncryptedText(i.Text).
+		Build()
+}
+
+// toGistItems maps the rows of gist items.
+func toGistItems(dbItems []mysql.Item) mo.Result[[]*gistitem.GistItem] {
+	var items []*gistitem.GistItem
+
+	for idx := range dbItems {
+		item := toGistItem(&dbItems[idx])
+
+		if item.IsError() {
+			return mo.Err[[]*gistitem.GistItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
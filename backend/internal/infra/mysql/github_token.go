@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlGithubTokenRepository struct {
+	_db *mysql.Queries
+}
+
+func NewGithubTokenRepository(config *config.Config) userRepo.GithubTokenRepository {
+	return &MysqlGithubTokenRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlGithubTokenRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*user.GithubToken] {
+	t, err := r.tx(ctx).GetGithubToken(ctx, uid)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*user.GithubToken](e.NotFoundError(e.ErrGithubNotConnected))
+	}
+
+	if err != nil {
+		return mo.Err[*user.GithubToken](err)
+	}
+
+	return mo.Ok(user.NewGithubTokenFromEncrypted(t.AccessToken, t.Scope, t.CreatedAt, t.UpdatedAt))
+}
+
+func (r *MysqlGithubTokenRepository) Save(ctx context.Context, uid string, token *user.GithubToken) mo.Result[bool] {
+	err := r.tx(ctx).UpsertGithubToken(ctx, mysql.UpsertGithubTokenParams{
+		Uid:         uid,
+		AccessToken: token.EncryptedAccessToken(),
+		Scope:       token.Scope(),
+		CreatedAt:   token.CreatedAt(),
+		UpdatedAt:   token.UpdatedAt(),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *MysqlGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	if err := r.tx(ctx).DeleteGithubToken(ctx, uid); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,151 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlImageRepository struct {
+	_db *mysql.Queries
+}
+
+func NewImageRepository(config *config.Config) imageRepo.ImageRepository {
+	return &MysqlImageRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlImageRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlImageRepository) FindByID(ctx context.Context, imageID string) mo.Result[*image.Image] {
+	i, err := r.tx(ctx).GetImage(ctx, imageID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*image.Image](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*image.Image](err)
+	}
+
+	return mo.Ok(toImage(i))
+}
+
+func (r *MysqlImageRepository) FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image] {
+	rows, err := r.tx(ctx).ListImages(ctx, userID)
+
+	if err != nil {
+		return mo.Err[[]*image.Image](err)
+	}
+
+	images := make([]*image.Image, len(rows))
+
+	for i, row := range rows {
+		images[i] = toImage(row)
+	}
+
+	return mo.Ok(images)
+}
+
+func (r *MysqlImageRepository) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	usage, err := r.tx(ctx).GetImageUsage(ctx, userID)
+
+	if err != nil {
+		return mo.Err[int64](err)
+	}
+
+	return mo.Ok(usage)
+}
+
+func (r *MysqlImageRepository) Save(ctx context.Context, img *image.Image) mo.Result[bool] {
+	err := r.tx(ctx).InsertImage(ctx, mysql.InsertImageParams{
+		ImageID:     img.ID,
+		Uid:         img.UserID,
+		DiagramID:   img.ItemID,
+		Location:    fromLocation(img.Location),
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Hash:        img.Hash,
+		CreatedAt:   img.CreatedAt,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+// SaveWithinQuota serializes the saves of a user by locking the range of their images in the uid index.
+func (r *MysqlImageRepository) SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool] {
+	if v.GetDBTx(ctx).IsAbsent() {
+		return mo.Err[bool](errors.New("saving an image within the quota needs a transaction"))
+	}
+
+	usage, err := r.tx(ctx).GetImageUsageForUpdate(ctx, img.UserID)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	if usage+img.Size > quota {
+		return mo.Ok(false)
+	}
+
+	return r.Save(ctx, img)
+}
+
+func (r *MysqlImageRepository) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	err := r.tx(ctx).DeleteImage(ctx, mysql.DeleteImageParams{Uid: userID, ImageID: imageID})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func toImage(i mysql.Image) *image.Image {
+	return &image.Image{
+		ID:          i.ImageID,
+		UserID:      i.Uid,
+		ItemID:      i.DiagramID,
+		Location:    toLocation(i.Location),
+		ContentType: i.ContentType,
+		Size:        i.Size,
+		Hash:        i.Hash,
+		CreatedAt:   i.CreatedAt,
+	}
+}
+
+func fromLocation(location values.Location) string {
+	if location == values.LocationGist {
+		return LocationGIST
+	}
+
+	return LocationSYSTEM
+}
+
+func toLocation(location string) values.Location {
+	if location == LocationGIST {
+		return values.LocationGist
+	}
+
+	return values.LocationSystem
+}
@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlSettingsRepository struct {
+	_db *mysql.Queries
+}
+
+func NewSettingsRepository(config *config.Config) settingsRepo.SettingsRepository {
+	return &MysqlSettingsRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlSettingsRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *MysqlSettingsRepository) Find(ctx context.Context, userID string, diagram values.Diagram) mo.Result[*settings.Settings] {
+	s, err := r.tx(ctx).GetSettings(ctx, mysql.GetSettingsParams{Uid: userID, Diagram: string(diagram)})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*settings.Settings](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[*settings.Settings](err)
+	}
+
+	scale := s.Scale
+
+	ss := settings.Settings{
+		Font:            s.Font,
+		Width:           int(s.Width),
+		Height:          int(s.Height),
+		BackgroundColor: s.BackgroundColor,
+		ActivityColor:   settings.Color{ForegroundColor: s.ActivityColor, BackgroundColor: s.ActivityBackgroundColor},
+		TaskColor:       settings.Color{ForegroundColor: s.TaskColor, BackgroundColor: s.TaskBackgroundColor},
+		StoryColor:      settings.Color{ForegroundColor: s.StoryColor, BackgroundColor: s.StoryBackgroundColor},
+		LineColor:       s.LineColor,
+		LabelColor:      s.LabelColor,
+		TextColor:       NullStringToString(s.TextColor),
+		ZoomControl:     NullBoolToBool(s.ZoomControl),
+		Scale:           &scale,
+		Toolbar:         NullBoolToBool(s.Toolbar),
+		LockEditing:     NullBoolToBool(s.LockEditing),
+		ShowGrid:        NullBoolToBool(s.ShowGrid),
+	}
+
+	return mo.Ok(&ss)
+}
+
+func (r *MysqlSettingsRepository) Save(ctx context.Context, userID string, diagram values.Diagram, s *settings.Settings) mo.Result[*settings.Settings] {
+	now := time.Now()
+	err := r.tx(ctx).UpsertSettings(ctx, mysql.UpsertSettingsParams{
+		Uid:                     userID,
+		Diagram:                 string(diagram),
+		ActivityColor:           s.ActivityColor.ForegroundColor,
+		ActivityBackgroundColor: s.ActivityColor.BackgroundColor,
+		BackgroundColor:         s.BackgroundColor,
+		Height:                  int32(s.Height),
+		LineColor:               s.LineColor,
+		LabelColor:              s.LabelColor,
+		LockEditing:             BoolToNullBool(s.LockEditing),
+		TextColor:               StringToNullString(s.TextColor),
+		Toolbar:                 BoolToNullBool(s.Toolbar),
+		Scale:                   *s.Scale,
+		ShowGrid:                BoolToNullBool(s.ShowGrid),
+		StoryColor:              s.StoryColor.ForegroundColor,
+		StoryBackgroundColor:    s.StoryColor.BackgroundColor,
+		TaskColor:               s.TaskColor.ForegroundColor,
+		TaskBackgroundColor:     s.TaskColor.BackgroundColor,
+		Width:                   int32(s.Width),
+		ZoomControl:             BoolToNullBool(s.ZoomControl),
+		Font:                    s.Font,
+		CreatedAt:               now,
+		UpdatedAt:               now,
+	})
+
+	if err != nil {
+		return mo.Err[*settings.Settings](err)
+	}
+
+	return mo.Ok(s)
+}
+
+func (r *MysqlSettingsRepository) Delete(ctx context.Context, userID string, diagram values.Diagram) mo.Result[bool] {
+	err := r.tx(ctx).DeleteSettings(ctx, mysql.DeleteSettingsParams{Uid: userID, Diagram: string(diagram)})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,201 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/mysql"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/share"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type MysqlShareRepository struct {
+	_db *mysql.Queries
+}
+
+func NewShareRepository(config *config.Config) shareRepo.ShareRepository {
+	return &MysqlShareRepository{_db: mysql.New(config.MysqlConn)}
+}
+
+func (r *MysqlShareRepository) tx(ctx context.Context) *mysql.Queries {
+	tx := values.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+// Find and FindByCode are not scoped to a user, as shares are opened by the users the item is shared with. The
+// item is then read as its owner, the uid of the share.
+func (r *MysqlShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
+	s, err := r.tx(ctx).GetShareCondition(ctx, hashKey)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[shareRepo.ShareValue](err)
+	}
+
+	return r.toShareValue(ctx, s)
+}
+
+func (r *MysqlShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	s, err := r.tx(ctx).GetShareConditionByCode(ctx, sql.NullString{String: code, Valid: true})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[shareRepo.ShareValue](err)
+	}
+
+	return r.toShareValue(ctx, s)
+}
+
+func (r *MysqlShareRepository) toShareValue(ctx context.Context, s mysql.ShareCondition) mo.Result[shareRepo.ShareValue] {
+	item, err := r.tx(ctx).GetItem(ctx, mysql.GetItemParams{
+		LoadText:      true,
+		LoadThumbnail: true,
+		Uid:           s.Uid,
+		Location:      s.Location,
+		DiagramID:     s.DiagramID,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[shareRepo.ShareValue](err)
+	}
+
+	remainingViews := mo.None[int]()
+
+	if s.RemainingViews.Valid {
+		remainingViews = mo.Some(int(s.RemainingViews.Int32))
+	}
+
+	shareInfo := share.Share{
+		Token:          s.Token,
+		Code:           s.Code.String,
+		RemainingViews: remainingViews,
+		ExpireTime:     s.ExpireTime.Int64,
+		Password:       s.Password.String,
+		AllowIPList:    strings.Split(s.AllowIpList.String, ","),
+		AllowEmailList: strings.Split(s.AllowEmailList.String, ","),
+	}
+
+	if s.Location == LocationGIST {
+		gistItem := toGistItem(&item)
+
+		if gistItem.IsError() {
+			return mo.Err[shareRepo.ShareValue](gistItem.Error())
+		}
+
+		return mo.Ok(shareRepo.ShareValue{GistItem: gistItem.MustGet(), ShareInfo: &shareInfo, UserID: s.Uid})
+	}
+
+	diagramItem := toItem(&item)
+
+	if diagramItem.IsError() {
+		return mo.Err[shareRepo.ShareValue](diagramItem.Error())
+	}
+
+	return mo.Ok(shareRepo.ShareValue{DiagramItem: diagramItem.MustGet(), ShareInfo: &shareInfo, UserID: s.Uid})
+}
+
+func (r *MysqlShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), LocationSYSTEM, shareInfo)
+}
+
+func (r *MysqlShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), LocationGIST, shareInfo)
+}
+
+func (r *MysqlShareRepository) save(ctx context.Context, userID, hashKey, itemID, location string, shareInfo *share.Share) mo.Result[bool] {
+	err := r.tx(ctx).DeleteShareConditionItem(ctx, mysql.DeleteShareConditionItemParams{
+		Uid:       userID,
+		Location:  location,
+		DiagramID: itemID,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	now := time.Now()
+	err = r.tx(ctx).CreateShareCondition(ctx, mysql.CreateShareConditionParams{
+		Uid:            userID,
+		Hashkey:        hashKey,
+		DiagramID:      itemID,
+		Location:       location,
+		AllowIpList:    sql.NullString{String: strings.Join(shareInfo.AllowIPList, ","), Valid: true},
+		AllowEmailList: sql.NullString{String: strings.Join(shareInfo.AllowEmailList, ","), Valid: true},
+		ExpireTime:     sql.NullInt64{Int64: shareInfo.ExpireTime, Valid: true},
+		Password:       sql.NullString{String: shareInfo.Password, Valid: true},
+		Token:          shareInfo.Token,
+		Code:           sql.NullString{String: shareInfo.Code, Valid: shareInfo.Code != ""},
+		RemainingViews: sql.NullInt32{Int32: int32(shareInfo.RemainingViews.OrEmpty()), Valid: shareInfo.RemainingViews.IsPresent()},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+
+	if isDuplicateEntry(err, "share_code_idx") {
+		return mo.Err[bool](e.ErrShareCodeConflict)
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *MysqlShareRepository) Delete(ctx context.Context, userID, hashKey string) mo.Result[bool] {
+	err := r.tx(ctx).DeleteShareCondition(ctx, mysql.DeleteShareConditionParams{Uid: userID, Hashkey: hashKey})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *MysqlShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	result, err := r.tx(ctx).DecrementShareConditionViews(ctx, mysql.DecrementShareConditionViewsParams{
+		UpdatedAt: time.Now(),
+		Hashkey:   hashKey,
+	})
+
+	if err != nil {
+		return mo.Err[int](err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return mo.Err[int](err)
+	} else if n == 0 {
+		return mo.Err[int](e.NotFoundError(e.ErrShareViewsExceeded))
+	}
+
+	remaining, err := result.LastInsertId()
+
+	if err != nil {
+		return mo.Err[int](err)
+	}
+
+	return mo.Ok(int(remaining))
+}
@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/postgres"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	accountRepo "github.com/harehare/textusm/internal/domain/repository/account"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/mo"
+)
+
+type PostgresDeletionRepository struct {
+	_db *postgres.Queries
+}
+
+func NewDeletionRepository(config *config.Config) accountRepo.DeletionRepository {
+	return &PostgresDeletionRepository{_db: postgres.New(config.PostgresConn)}
+}
+
+func (r *PostgresDeletionRepository) tx(ctx context.Context) *postgres.Queries {
+	tx := v.GetPostgresTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(*tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *PostgresDeletionRepository) FindByID(ctx context.Context, deletionID string) mo.Result[*account.Deletion] {
+	id, err := uuid.Parse(deletionID)
+
+	if err != nil {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	d, err := r.tx(ctx).GetAccountDeletion(ctx, pgtype.UUID{Bytes: id, Valid: true})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return mo.Ok(toDeletion(d))
+}
+
+func (r *PostgresDeletionRepository) FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion] {
+	d, err := r.tx(ctx).GetActiveAccountDeletion(ctx, userID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return mo.Ok(toDeletion(d))
+}
+
+func (r *PostgresDeletionRepository) FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion] {
+	rows, err := r.tx(ctx).ListDueAccountDeletions(ctx, postgres.ListDueAccountDeletionsParams{
+		ScheduledAt: toTimestamp(now),
+		Limit:       int32(limit),
+	})
+
+	if err != nil {
+		return mo.Err[[]*account.Deletion](err)
+	}
+
+	deletions := make([]*account.Deletion, len(rows))
+
+	for i, row := range rows {
+		deletions[i] = toDeletion(row)
+	}
+
+	return mo.Ok(deletions)
+}
+
+func (r *PostgresDeletionRepository) Create(ctx context.Context, deletion *account.Deletion) mo.Result[bool] {
+	id, err := uuid.Parse(deletion.ID)
+
+	if err != nil {
+		return mo.Err[bool](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	err = r.tx(ctx).CreateAccountDeletion(ctx, postgres.CreateAccountDeletionParams{
+		DeletionID:  pgtype.UUID{Bytes: id, Valid: true},
+		Uid:         deletion.UserID,
+		Status:      string(deletion.Status),
+		RequestedAt: toTimestamp(deletion.RequestedAt),
+		ScheduledAt: toTimestamp(deletion.ScheduledAt),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *PostgresDeletionRepository) Claim(ctx context.Context, deletionID string, now, leaseUntil time.Time) mo.Result[bool] {
+	id, err := uuid.Parse(deletionID)
+
+	if err != nil {
+		return mo.Err[bool](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	n, err := r.tx(ctx).ClaimAccountDeletion(ctx, postgres.ClaimAccountDeletionParams{
+		DeletionID:  pgtype.UUID{Bytes: id, Valid: true},
+		LeaseUntil:  toTimestamp(leaseUntil),
+		ScheduledAt: toTimestamp(now),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *PostgresDeletionRepository) Cancel(ctx context.Context, deletionID string) mo.Result[bool] {
+	id, err := uuid.Parse(deletionID)
+
+	if err != nil {
+		return mo.Err[bool](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	n, err := r.tx(ctx).CancelAccountDeletion(ctx, pgtype.UUID{Bytes: id, Valid: true})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *PostgresDeletionRepository) Complete(ctx context.Context, deletionID string, completedAt time.Time, receipt string) mo.Result[bool] {
+	id, err := uuid.Parse(deletionID)
+
+	if err != nil {
+		return mo.Err[bool](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	n, err := r.tx(ctx).CompleteAccountDeletion(ctx, postgres.CompleteAccountDeletionParams{
+		DeletionID:  pgtype.UUID{Bytes: id, Valid: true},
+		CompletedAt: toTimestamp(completedAt),
+		Receipt:     &receipt,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func toDeletion(d postgres.AccountDeletion) *account.Deletion {
+	receipt := ""
+
+	if d.Receipt != nil {
+		receipt = *d.Receipt
+	}
+
+	return &account.Deletion{
+		ID:          uuid.UUID(d.DeletionID.Bytes).String(),
+		UserID:      d.Uid,
+		Status:      account.DeletionStatus(d.Status),
+		RequestedAt: d.RequestedAt.Time,
+		ScheduledAt: d.ScheduledAt.Time,
+		LeaseUntil:  d.LeaseUntil.Time,
+		CompletedAt: d.CompletedAt.Time,
+		Receipt:     receipt,
+	}
+}
+
+// toTimestamp stores times in UTC, since the columns are timestamps without time zone.
+func toTimestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/postgres"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	auditRepo "github.com/harehare/textusm/internal/domain/repository/audit"
+	"github.com/samber/mo"
+)
+
+type PostgresAuditLogRepository struct {
+	_db *postgres.Queries
+}
+
+func NewAuditLogRepository(config *config.Config) auditRepo.AuditLogRepository {
+	return &PostgresAuditLogRepository{_db: postgres.New(config.PostgresConn)}
+}
+
+func (r *PostgresAuditLogRepository) tx(ctx context.Context) *postgres.Queries {
+	tx := v.GetPostgresTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(*tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *PostgresAuditLogRepository) Append(ctx context.Context, log *audit.Log) mo.Result[bool] {
+	err := r.tx(ctx).InsertAuditLog(ctx, postgres.InsertAuditLogParams{
+		Uid:       log.UserID,
+		Event:     string(log.Event),
+		TargetID:  log.TargetID,
+		Detail:    log.Detail,
+		CreatedAt: toTimestamp(log.CreatedAt),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
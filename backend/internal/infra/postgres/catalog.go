@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/postgres"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	"github.com/samber/mo"
+)
+
+// PostgresCatalogRepository lists IDs for maintenance tools. UserIDs reads across users, so only a tool with its own
+// connection using a role that bypasses row level security may call it. The other reads run as the user like the API.
+type PostgresCatalogRepository struct {
+	_db *postgres.Queries
+}
+
+func NewCatalogRepository(config *config.Config) catalogRepo.CatalogRepository {
+	return &PostgresCatalogRepository{_db: postgres.New(config.PostgresConn)}
+}
+
+func (r *PostgresCatalogRepository) tx(ctx context.Context) *postgres.Queries {
+	tx := values.GetPostgresTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(*tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *PostgresCatalogRepository) UserIDs(ctx context.Context) mo.Result[[]string] {
+	uids, err := r.tx(ctx).ListUIDs(ctx)
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	return mo.Ok(append([]string{}, uids...))
+}
+
+func (r *PostgresCatalogRepository) ItemIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, postgres.LocationSYSTEM)
+}
+
+func (r *PostgresCatalogRepository) GistIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, postgres.LocationGIST)
+}
+
+func (r *PostgresCatalogRepository) listItemIDs(ctx context.Context, userID string, location postgres.Location) mo.Result[[]string] {
+	dbIDs, err := r.tx(ctx).ListItemIDs(ctx, postgres.ListItemIDsParams{
+		Uid:      userID,
+		Location: location,
+	})
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	ids := make([]string, 0, len(dbIDs))
+
+	for _, dbID := range dbIDs {
+		id, err := dbID.Value()
+
+		if err != nil {
+			return mo.Err[[]string](err)
+		}
+
+		ids = append(ids, id.(string))
+	}
+
+	return mo.Ok(ids)
+}
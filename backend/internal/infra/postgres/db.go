@@ -0,0 +1,17 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the SQLSTATE of an insert or update that breaks a unique constraint.
+const uniqueViolation = "23505"
+
+// isUniqueViolation reports whether err breaks the unique constraint or index named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
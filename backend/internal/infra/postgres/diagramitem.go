@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/harehare/textusm/internal/config"
@@ -11,6 +12,8 @@ import (
 	"github.com/harehare/textusm/internal/db/postgres"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/samber/mo"
 )
@@ -33,7 +36,7 @@ func (r *PostgresItemRepository) tx(ctx context.Context) *postgres.Queries {
 	}
 }
 
-func (r *PostgresItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+func (r *PostgresItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
 	u, err := uuid.Parse(itemID)
 
 	if err != nil {
@@ -41,92 +44,59 @@ func (r *PostgresItemRepository) FindByID(ctx context.Context, userID string, it
 	}
 
 	i, err := r.tx(ctx).GetItem(ctx, postgres.GetItemParams{
-		DiagramID: pgtype.UUID{Bytes: u, Valid: true},
-		Location:  postgres.LocationSYSTEM,
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		DiagramID:     pgtype.UUID{Bytes: u, Valid: true},
+		Location:      postgres.LocationSYSTEM,
 	})
 
-	if err != nil {
-		return mo.Err[*diagramitem.DiagramItem](err)
-	}
-
-	var thumbnail mo.Option[string]
-
-	if i.Thumbnail == nil {
-		thumbnail = mo.None[string]()
-	} else {
-		thumbnail = mo.Some[string](*i.Thumbnail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(err))
 	}
 
-	id, err := i.DiagramID.Value()
-
 	if err != nil {
 		return mo.Err[*diagramitem.DiagramItem](err)
 	}
 
-	return diagramitem.New().
-		WithID(id.(string)).
-		WithTitle(*i.Title).
-		WithEncryptedText(i.Text).
-		WithThumbnail(thumbnail).
-		WithDiagramString(string(i.Diagram)).
-		WithIsPublic(*i.IsPublic).
-		WithIsBookmark(*i.IsBookmark).
-		WithCreatedAt(i.CreatedAt.Time).
-		WithUpdatedAt(i.UpdatedAt.Time).
-		Build()
+	if i.DeletedAt.Valid {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(sql.ErrNoRows))
+	}
+
+	return toItem(&i)
 }
 
-func (r *PostgresItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, isBookmark bool, shouldLoadText bool) mo.Result[[]*diagramitem.DiagramItem] {
-	dbItems, err := r.tx(ctx).ListItems(ctx, postgres.ListItemsParams{
-		Location:   postgres.LocationSYSTEM,
-		IsPublic:   &isPublic,
-		IsBookmark: &isBookmark,
-		Limit:      int32(limit),  //nolint:gosec
-		Offset:     int32(offset), //nolint:gosec
+func (r *PostgresItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := r.tx(ctx).ListItemsByIDs(ctx, postgres.ListItemsByIDsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Location:      postgres.LocationSYSTEM,
+		DiagramIds:    toUUIDs(itemIDs),
 	})
 
 	if err != nil {
 		return mo.Err[[]*diagramitem.DiagramItem](err)
 	}
 
-	var items []*diagramitem.DiagramItem
-
-	for idx := range dbItems {
-		i := &dbItems[idx]
-		var thumbnail mo.Option[string]
-
-		if i.Thumbnail == nil {
-			thumbnail = mo.None[string]()
-		} else {
-			thumbnail = mo.Some[string](*i.Thumbnail)
-		}
-
-		id, err := i.DiagramID.Value()
-
-		if err != nil {
-			return mo.Err[[]*diagramitem.DiagramItem](err)
-		}
-
-		item := diagramitem.New().
-			WithID(id.(string)).
-			WithTitle(*i.Title).
-			WithEncryptedText(i.Text).
-			WithThumbnail(thumbnail).
-			WithDiagramString(string(i.Diagram)).
-			WithIsPublic(*i.IsPublic).
-			WithIsBookmark(*i.IsBookmark).
-			WithCreatedAt(i.CreatedAt.Time).
-			WithUpdatedAt(i.UpdatedAt.Time).
-			Build()
+	return toItems(dbItems)
+}
 
-		if item.IsError() {
-			return mo.Err[[]*diagramitem.DiagramItem](item.Error())
-		}
+func (r *PostgresItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := listItems(ctx, r.tx(ctx), filter.SortOrDefault(), postgres.ListItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Location:      postgres.LocationSYSTEM,
+		IsPublic:      &isPublic,
+		IsBookmark:    bookmarkFilter(filter),
+		Diagram:       postgres.NullDiagram{Diagram: postgres.Diagram(filter.Diagram.OrEmpty()), Valid: filter.Diagram.IsPresent()},
+		Limit:         int32(limit),  //nolint:gosec
+		Offset:        int32(offset), //nolint:gosec
+	})
 
-		items = append(items, item.MustGet())
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
 	}
 
-	return mo.Ok(items)
+	return toItems(dbItems)
 }
 
 func (r *PostgresItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
@@ -157,9 +127,11 @@ func (r *PostgresItemRepository) Save(ctx context.Context, userID string, item *
 			IsBookmark: isBookmarkPtr,
 			IsPublic:   isPublicPtr,
 			Title:      titlePtr,
-			Text:       item.Text(),
+			Text:       item.EncryptedText(),
 			Thumbnail:  item.Thumbnail(),
 			Location:   postgres.LocationSYSTEM,
+			CreatedAt:  pgtype.Timestamp{Time: item.CreatedAt().UTC(), Valid: true},
+			UpdatedAt:  pgtype.Timestamp{Time: item.UpdatedAt().UTC(), Valid: true},
 		}); err != nil {
 			return mo.Err[*diagramitem.DiagramItem](err)
 		}
@@ -171,10 +143,11 @@ func (r *PostgresItemRepository) Save(ctx context.Context, userID string, item *
 			IsBookmark: isBookmarkPtr,
 			IsPublic:   isPublicPtr,
 			Title:      &title,
-			Text:       item.Text(),
+			Text:       item.EncryptedText(),
 			Thumbnail:  item.Thumbnail(),
 			DiagramID:  pgtype.UUID{Bytes: u, Valid: true},
 			Location:   postgres.LocationSYSTEM,
+			UpdatedAt:  pgtype.Timestamp{Time: item.UpdatedAt().UTC(), Valid: true},
 		}); err != nil {
 			return mo.Err[*diagramitem.DiagramItem](err)
 		}
@@ -189,7 +162,12 @@ func (r *PostgresItemRepository) Delete(ctx context.Context, userID string, item
 		return mo.Err[bool](err)
 	}
 
-	err = r.tx(ctx).DeleteItem(ctx, pgtype.UUID{Bytes: u, Valid: true})
+	// A public item is the private row marked public, so the public copy goes by clearing the mark.
+	if isPublic {
+		err = r.tx(ctx).UnpublishItem(ctx, postgres.UnpublishItemParams{Uid: userID, DiagramID: pgtype.UUID{Bytes: u, Valid: true}})
+	} else {
+		err = r.tx(ctx).DeleteItem(ctx, postgres.DeleteItemParams{Uid: userID, DiagramID: pgtype.UUID{Bytes: u, Valid: true}})
+	}
 
 	if err != nil {
 		return mo.Err[bool](err)
@@ -197,3 +175,176 @@ func (r *PostgresItemRepository) Delete(ctx context.Context, userID string, item
 
 	return mo.Ok(true)
 }
+
+func (r *PostgresItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := r.tx(ctx).ListTrashedItems(ctx, postgres.ListTrashedItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      postgres.LocationSYSTEM,
+		Limit:         int32(limit),  //nolint:gosec
+		Offset:        int32(offset), //nolint:gosec
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return toItems(dbItems)
+}
+
+// FindExpiredTrash reads across users through the expired_trashed_items function, which runs as a role that may
+// only see the uid and diagram_id of trashed items, so row level security stays in force for the application role.
+func (r *PostgresItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	rows, err := r.tx(ctx).ListExpiredTrashedItems(ctx, postgres.ListExpiredTrashedItemsParams{
+		DeletedBefore: toTimestamp(deletedBefore),
+		MaxItems:      int32(limit), //nolint:gosec
+	})
+
+	if err != nil {
+		return mo.Err[[]itemRepo.TrashedItem](err)
+	}
+
+	trashed := make([]itemRepo.TrashedItem, 0, len(rows))
+
+	for _, row := range rows {
+		id, err := row.DiagramID.Value()
+
+		if err != nil {
+			return mo.Err[[]itemRepo.TrashedItem](err)
+		}
+
+		if row.Uid == nil {
+			continue
+		}
+
+		trashed = append(trashed, itemRepo.TrashedItem{UserID: *row.Uid, ItemID: id.(string)})
+	}
+
+	return mo.Ok(trashed)
+}
+
+func (r *PostgresItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	u, err := uuid.Parse(itemID)
+
+	if err != nil {
+		return mo.Err[bool](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	n, err := r.tx(ctx).TrashItem(ctx, postgres.TrashItemParams{
+		DeletedAt: toTimestamp(deletedAt),
+		Location:  postgres.LocationSYSTEM,
+		DiagramID: pgtype.UUID{Bytes: u, Valid: true},
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *PostgresItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	u, err := uuid.Parse(itemID)
+
+	if err != nil {
+		return mo.Err[bool](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	n, err := r.tx(ctx).RestoreItem(ctx, postgres.RestoreItemParams{
+		Location:  postgres.LocationSYSTEM,
+		DiagramID: pgtype.UUID{Bytes: u, Valid: true},
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func toItems(dbItems []postgres.Item) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+
+	for idx := range dbItems {
+		item := toItem(&dbItems[idx])
+
+		if item.IsError() {
+			return mo.Err[[]*diagramitem.DiagramItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
+
+func toItem(i *postgres.Item) mo.Result[*diagramitem.DiagramItem] {
+	var thumbnail mo.Option[string]
+
+	if i.Thumbnail == nil {
+		thumbnail = mo.None[string]()
+	} else {
+		thumbnail = mo.Some[string](*i.Thumbnail)
+	}
+
+	var deletedAt mo.Option[time.Time]
+
+	if i.DeletedAt.Valid {
+		deletedAt = mo.Some(i.DeletedAt.Time)
+	}
+
+	id, err := i.DiagramID.Value()
+
+	if err != nil {
+		return mo.Err[*diagramitem.DiagramItem](err)
+	}
+
+	return diagramitem.New().
+		WithID(id.(string)).
+		WithTitle(*i.Title).
+		WithEncryptedText(i.Text).
+		WithThumbnail(thumbnail).
+		WithDiagramString(string(i.Diagram)).
+		WithIsPublic(*i.IsPublic).
+		WithIsBookmark(*i.IsBookmark).
+		WithCreatedAt(i.CreatedAt.Time).
+		WithUpdatedAt(i.UpdatedAt.Time).
+		WithDeletedAt(deletedAt).
+		Build()
+}
+
+// toUUIDs parses ids, leaving out the ones that are not UUIDs since no item can have them.
+func toUUIDs(ids []string) []pgtype.UUID {
+	uuids := make([]pgtype.UUID, 0, len(ids))
+
+	for _, id := range ids {
+		if u, err := uuid.Parse(id); err == nil {
+			uuids = append(uuids, pgtype.UUID{Bytes: u, Valid: true})
+		}
+	}
+
+	return uuids
+}
+
+// listItems lists items with the query of sort. The queries differ only in their order, so that each one can be
+// served by the index on that order.
+func listItems(ctx context.Context, q *postgres.Queries, sort v.ItemSort, params postgres.ListItemsParams) ([]postgres.Item, error) {
+	switch sort {
+	case v.ItemSortCreatedAt:
+		return q.ListItemsByCreatedAt(ctx, postgres.ListItemsByCreatedAtParams(params))
+	case v.ItemSortTitle:
+		return q.ListItemsByTitle(ctx, postgres.ListItemsByTitleParams(params))
+	default:
+		return q.ListItems(ctx, params)
+	}
+}
+
+// bookmarkFilter returns true to list bookmarked items only, and nil to list every item.
+func bookmarkFilter(filter v.ItemFilter) *bool {
+	if !filter.IsBookmark {
+		return nil
+	}
+
+	return &filter.IsBookmark
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/domain/event"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	eventsChannel    = "textusm_events"
+	maxListenBackoff = 30 * time.Second
+)
+
+// envelope carries an event between instances. Origin tells an instance the events it published itself,
+// which it has delivered already.
+type envelope struct {
+	Origin string `json:"origin"`
+	event.Event
+}
+
+// EventBus delivers events to the instances that share the database with LISTEN/NOTIFY. It holds one
+// connection of the pool for as long as it runs.
+type EventBus struct {
+	pool   *pgxpool.Pool
+	origin string
+	local  *event.LocalBus
+}
+
+// NewEventBus starts listening for the events of other instances until the returned function is called.
+func NewEventBus(config *config.Config) (event.Bus, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &EventBus{pool: config.PostgresConn, origin: uuid.NewString(), local: &event.LocalBus{}}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		b.listen(ctx)
+	}()
+
+	return b, func() {
+		cancel()
+		<-done
+	}
+}
+
+// Publish delivers the event on this instance and notifies the others. A failed notification is only logged,
+// and the caches of the other instances then expire on their own.
+func (b *EventBus) Publish(ctx context.Context, e event.Event) {
+	b.local.Deliver(e)
+
+	payload, err := json.Marshal(envelope{Origin: b.origin, Event: e})
+
+	if err != nil {
+		slog.Error("failed to encode event", "kind", e.Kind, "error", err)
+		return
+	}
+
+	if _, err := b.pool.Exec(ctx, "SELECT pg_notify($1, $2)", eventsChannel, string(payload)); err != nil {
+		slog.Error("failed to publish event", "kind", e.Kind, "error", err)
+	}
+}
+
+func (b *EventBus) Subscribe(handler func(event.Event)) {
+	b.local.Subscribe(handler)
+}
+
+// listen delivers the events of other instances until ctx is done, listening again when the connection is lost.
+func (b *EventBus) listen(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		listened, err := b.receive(ctx)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if listened {
+			backoff = time.Second
+		}
+
+		slog.Error("event listener stopped, retrying", "backoff", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxListenBackoff)
+	}
+}
+
+// receive listens on one connection until it fails. listened tells whether it got as far as listening.
+func (b *EventBus) receive(ctx context.Context) (listened bool, err error) {
+	conn, err := b.pool.Acquire(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+eventsChannel); err != nil {
+		return false, err
+	}
+
+	// Events published while no connection was listening are lost.
+	b.local.Deliver(event.Event{Kind: event.Resync})
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+
+		if err != nil {
+			return true, err
+		}
+
+		var env envelope
+
+		if err := json.Unmarshal([]byte(notification.Payload), &env); err != nil {
+			slog.Error("failed to decode event", "payload", notification.Payload, "error", err)
+			continue
+		}
+
+		if env.Origin != b.origin {
+			b.local.Deliver(env.Event)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/harehare/textusm/internal/db/postgres"
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/samber/mo"
 )
@@ -33,7 +35,7 @@ func (r *PostgresGistItemRepository) tx(ctx context.Context) *postgres.Queries {
 	}
 }
 
-func (r *PostgresGistItemRepository) FindByID(ctx context.Context, userID string, itemID string) mo.Result[*gistitem.GistItem] {
+func (r *PostgresGistItemRepository) FindByID(ctx context.Context, userID string, itemID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
 	u, err := uuid.Parse(itemID)
 
 	if err != nil {
@@ -41,10 +43,16 @@ func (r *PostgresGistItemRepository) FindByID(ctx context.Context, userID string
 	}
 
 	i, err := r.tx(ctx).GetItem(ctx, postgres.GetItemParams{
-		DiagramID: pgtype.UUID{Bytes: u, Valid: true},
-		Location:  postgres.LocationGIST,
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		DiagramID:     pgtype.UUID{Bytes: u, Valid: true},
+		Location:      postgres.LocationGIST,
 	})
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*gistitem.GistItem](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[*gistitem.GistItem](err)
 	}
@@ -71,60 +79,44 @@ func (r *PostgresGistItemRepository) FindByID(ctx context.Context, userID string
 		WithIsBookmark(*i.IsBookmark).
 		WithCreatedAt(i.CreatedAt.Time).
 		WithUpdatedAt(i.UpdatedAt.Time).
+		WithRevision(mo.PointerToOption(i.Revision).OrEmpty()).
+		WithEncryptedText(i.Text).
 		Build()
 }
 
-func (r *PostgresGistItemRepository) Find(ctx context.Context, userID string, offset, limit int) mo.Result[[]*gistitem.GistItem] {
-	isPublic := false
-	isBookmark := false
-	dbItems, err := r.tx(ctx).ListItems(ctx, postgres.ListItemsParams{
-		IsPublic:   &isPublic,
-		IsBookmark: &isBookmark,
-		Location:   postgres.LocationGIST,
-		Limit:      int32(limit),  //nolint:gosec
-		Offset:     int32(offset), //nolint:gosec
+func (r *PostgresGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	dbItems, err := r.tx(ctx).ListItemsByIDs(ctx, postgres.ListItemsByIDsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Location:      postgres.LocationGIST,
+		DiagramIds:    toUUIDs(gistIDs),
 	})
 
 	if err != nil {
 		return mo.Err[[]*gistitem.GistItem](err)
 	}
 
-	var items []*gistitem.GistItem
-
-	for idx := range dbItems {
-		i := &dbItems[idx]
-		var thumbnail mo.Option[string]
-
-		if i.Thumbnail == nil {
-			thumbnail = mo.None[string]()
-		} else {
-			thumbnail = mo.Some[string](*i.Thumbnail)
-		}
-
-		id, err := i.DiagramID.Value()
-
-		if err != nil {
-			return mo.Err[[]*gistitem.GistItem](err)
-		}
-
-		item := gistitem.New().
-			WithID(id.(string)).
-			WithTitle(*i.Title).
-			WithThumbnail(thumbnail).
-			WithDiagramString(string(i.Diagram)).
-			WithIsBookmark(*i.IsBookmark).
-			WithCreatedAt(i.CreatedAt.Time).
-			WithUpdatedAt(i.UpdatedAt.Time).
-			Build()
+	return toGistItems(dbItems)
+}
 
-		if item.IsError() {
-			return mo.Err[[]*gistitem.GistItem](item.Error())
-		}
+func (r *PostgresGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	isPublic := false
+	isBookmark := false
+	dbItems, err := r.tx(ctx).ListItems(ctx, postgres.ListItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		IsPublic:      &isPublic,
+		IsBookmark:    &isBookmark,
+		Location:      postgres.LocationGIST,
+		Limit:         int32(limit),  //nolint:gosec
+		Offset:        int32(offset), //nolint:gosec
+	})
 
-		items = append(items, item.MustGet())
+	if err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
 	}
 
-	return mo.Ok(items)
+	return toGistItems(dbItems)
 }
 
 func (r *PostgresGistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
@@ -145,6 +137,7 @@ func (r *PostgresGistItemRepository) Save(ctx context.Context, userID string, it
 	isBookmarkPtr := &isBookmark
 	isPublicPtr := false
 	titlePtr := &title
+	revision := mo.EmptyableToOption(item.Revision()).ToPointer()
 
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
@@ -155,8 +148,12 @@ func (r *PostgresGistItemRepository) Save(ctx context.Context, userID string, it
 			IsBookmark: isBookmarkPtr,
 			IsPublic:   &isPublicPtr,
 			Title:      titlePtr,
+			Text:       item.EncryptedText(),
 			Thumbnail:  item.Thumbnail(),
 			Location:   postgres.LocationGIST,
+			Revision:   revision,
+			CreatedAt:  pgtype.Timestamp{Time: item.CreatedAt().UTC(), Valid: true},
+			UpdatedAt:  pgtype.Timestamp{Time: item.UpdatedAt().UTC(), Valid: true},
 		}); err != nil {
 			return mo.Err[*gistitem.GistItem](err)
 		}
@@ -168,9 +165,12 @@ func (r *PostgresGistItemRepository) Save(ctx context.Context, userID string, it
 			IsBookmark: isBookmarkPtr,
 			IsPublic:   &isPublicPtr,
 			Title:      &title,
+			Text:       item.EncryptedText(),
 			Thumbnail:  item.Thumbnail(),
 			DiagramID:  pgtype.UUID{Bytes: u, Valid: true},
 			Location:   postgres.LocationGIST,
+			Revision:   revision,
+			UpdatedAt:  pgtype.Timestamp{Time: item.UpdatedAt().UTC(), Valid: true},
 		}); err != nil {
 			return mo.Err[*gistitem.GistItem](err)
 		}
@@ -185,7 +185,7 @@ func (r *PostgresGistItemRepository) Delete(ctx context.Context, userID string,
 		return mo.Err[bool](err)
 	}
 
-	err = r.tx(ctx).DeleteItem(ctx, pgtype.UUID{Bytes: u, Valid: true})
+	err = r.tx(ctx).DeleteItem(ctx, postgres.DeleteItemParams{Uid: userID, DiagramID: pgtype.UUID{Bytes: u, Valid: true}})
 
 	if err != nil {
 		return mo.Err[bool](err)
@@ -193,3 +193,45 @@ func (r *PostgresGistItemRepository) Delete(ctx context.Context, userID string,
 
 	return mo.Ok(true)
 }
+
+// toGistItems maps the rows of gist items.
+func toGistItems(dbItems []postgres.Item) mo.Result[[]*gistitem.GistItem] {
+	var items []*gistitem.GistItem
+
+	for idx := range dbItems {
+		i := &dbItems[idx]
+		var thumbnail mo.Option[string]
+
+		if i.Thumbnail == nil {
+			thumbnail = mo.None[string]()
+		} else {
+			thumbnail = mo.Some[string](*i.Thumbnail)
+		}
+
+		id, err := i.DiagramID.Value()
+
+		if err != nil {
+			return mo.Err[[]*gistitem.GistItem](err)
+		}
+
+		item := gistitem.New().
+			WithID(id.(string)).
+			WithTitle(*i.Title).
+			WithThumbnail(thumbnail).
+			WithDiagramString(string(i.Diagram)).
+			WithIsBookmark(*i.IsBookmark).
+			WithCreatedAt(i.CreatedAt.Time).
+			WithUpdatedAt(i.UpdatedAt.Time).
+			WithRevision(mo.PointerToOption(i.Revision).OrEmpty()).
+			WithEncryptedText(i.Text).
+			Build()
+
+		if item.IsError() {
+			return mo.Err[[]*gistitem.GistItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
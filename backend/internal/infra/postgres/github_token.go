@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/postgres"
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type PostgresGithubTokenRepository struct {
+	_db *postgres.Queries
+}
+
+func NewGithubTokenRepository(config *config.Config) userRepo.GithubTokenRepository {
+	return &PostgresGithubTokenRepository{_db: postgres.New(config.PostgresConn)}
+}
+
+func (r *PostgresGithubTokenRepository) tx(ctx context.Context) *postgres.Queries {
+	tx := v.GetPostgresTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(*tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *PostgresGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*user.GithubToken] {
+	t, err := r.tx(ctx).GetGithubToken(ctx, uid)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*user.GithubToken](e.NotFoundError(e.ErrGithubNotConnected))
+	}
+
+	if err != nil {
+		return mo.Err[*user.GithubToken](err)
+	}
+
+	return mo.Ok(user.NewGithubTokenFromEncrypted(t.AccessToken, t.Scope, t.CreatedAt.Time, t.UpdatedAt.Time))
+}
+
+func (r *PostgresGithubTokenRepository) Save(ctx context.Context, uid string, token *user.GithubToken) mo.Result[bool] {
+	err := r.tx(ctx).UpsertGithubToken(ctx, postgres.UpsertGithubTokenParams{
+		Uid:         uid,
+		AccessToken: token.EncryptedAccessToken(),
+		Scope:       token.Scope(),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *PostgresGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	if err := r.tx(ctx).DeleteGithubToken(ctx, uid); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
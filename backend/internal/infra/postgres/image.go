@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/postgres"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/mo"
+)
+
+type PostgresImageRepository struct {
+	_db *postgres.Queries
+}
+
+func NewImageRepository(config *config.Config) imageRepo.ImageRepository {
+	return &PostgresImageRepository{_db: postgres.New(config.PostgresConn)}
+}
+
+func (r *PostgresImageRepository) tx(ctx context.Context) *postgres.Queries {
+	tx := v.GetPostgresTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(*tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *PostgresImageRepository) FindByID(ctx context.Context, imageID string) mo.Result[*image.Image] {
+	id, err := uuid.Parse(imageID)
+
+	if err != nil {
+		return mo.Err[*image.Image](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	i, err := r.tx(ctx).GetImage(ctx, pgtype.UUID{Bytes: id, Valid: true})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*image.Image](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*image.Image](err)
+	}
+
+	return mo.Ok(toImage(i))
+}
+
+func (r *PostgresImageRepository) FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image] {
+	rows, err := r.tx(ctx).ListImages(ctx, userID)
+
+	if err != nil {
+		return mo.Err[[]*image.Image](err)
+	}
+
+	images := make([]*image.Image, len(rows))
+
+	for i, row := range rows {
+		images[i] = toImage(row)
+	}
+
+	return mo.Ok(images)
+}
+
+func (r *PostgresImageRepository) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	usage, err := r.tx(ctx).GetImageUsage(ctx, userID)
+
+	if err != nil {
+		return mo.Err[int64](err)
+	}
+
+	return mo.Ok(usage)
+}
+
+func (r *PostgresImageRepository) Save(ctx context.Context, img *image.Image) mo.Result[bool] {
+	id, err := uuid.Parse(img.ID)
+
+	if err != nil {
+		return mo.Err[bool](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	itemID, err := uuid.Parse(img.ItemID)
+
+	if err != nil {
+		return mo.Err[bool](e.InvalidParameterError(e.ErrInvalidId))
+	}
+
+	err = r.tx(ctx).InsertImage(ctx, postgres.InsertImageParams{
+		ImageID:     pgtype.UUID{Bytes: id, Valid: true},
+		Uid:         img.UserID,
+		DiagramID:   pgtype.UUID{Bytes: itemID, Valid: true},
+		Location:    postgres.Location(img.Location),
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Hash:        img.Hash,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+// SaveWithinQuota serializes the saves of a user with an advisory lock held until the transaction ends.
+func (r *PostgresImageRepository) SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool] {
+	if v.GetPostgresTx(ctx).IsAbsent() {
+		return mo.Err[bool](errors.New("saving an image within the quota needs a transaction"))
+	}
+
+	if err := r.tx(ctx).LockImageUsage(ctx, img.UserID); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	usage := r.Usage(ctx, img.UserID)
+
+	if usage.IsError() {
+		return mo.Err[bool](usage.Error())
+	}
+
+	if usage.MustGet()+img.Size > quota {
+		return mo.Ok(false)
+	}
+
+	return r.Save(ctx, img)
+}
+
+func (r *PostgresImageRepository) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	id, err := uuid.Parse(imageID)
+
+	if err != nil {
+		return mo.Err[bool](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	if err := r.tx(ctx).DeleteImage(ctx, postgres.DeleteImageParams{Uid: userID, ImageID: pgtype.UUID{Bytes: id, Valid: true}}); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func toImage(i postgres.Image) *image.Image {
+	return &image.Image{
+		ID:          uuid.UUID(i.ImageID.Bytes).String(),
+		UserID:      i.Uid,
+		ItemID:      uuid.UUID(i.DiagramID.Bytes).String(),
+		Location:    values.Location(i.Location),
+		ContentType: i.ContentType,
+		Size:        i.Size,
+		Hash:        i.Hash,
+		CreatedAt:   i.CreatedAt.Time,
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/harehare/textusm/internal/domain/model/settings"
 	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
 	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 )
 
@@ -35,6 +36,10 @@ func (r *PostgresSettingsRepository) tx(ctx context.Context) *postgres.Queries {
 func (r *PostgresSettingsRepository) Find(ctx context.Context, userID string, diagram values.Diagram) mo.Result[*settings.Settings] {
 	s, err := r.tx(ctx).GetSettings(ctx, postgres.Diagram(diagram))
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*settings.Settings](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[*settings.Settings](err)
 	}
@@ -91,6 +96,7 @@ func (r *PostgresSettingsRepository) Save(ctx context.Context, userID string, di
 			TaskBackgroundColor:     s.TaskColor.BackgroundColor,
 			Width:                   width,
 			ZoomControl:             s.ZoomControl,
+			Font:                    s.Font,
 		})
 	} else if err != nil {
 		return mo.Err[*settings.Settings](err)
@@ -113,6 +119,7 @@ func (r *PostgresSettingsRepository) Save(ctx context.Context, userID string, di
 			TaskBackgroundColor:     s.TaskColor.BackgroundColor,
 			Width:                   width,
 			ZoomControl:             s.ZoomControl,
+			Font:                    s.Font,
 		})
 	}
 
@@ -122,3 +129,13 @@ func (r *PostgresSettingsRepository) Save(ctx context.Context, userID string, di
 
 	return mo.Ok(s)
 }
+
+func (r *PostgresSettingsRepository) Delete(ctx context.Context, userID string, diagram values.Diagram) mo.Result[bool] {
+	err := r.tx(ctx).DeleteSettings(ctx, postgres.DeleteSettingsParams{Uid: userID, Diagram: postgres.Diagram(diagram)})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -2,17 +2,21 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/db/postgres"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	"github.com/harehare/textusm/internal/domain/model/share"
 	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/samber/mo"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type PostgresShareRepository struct {
@@ -36,21 +40,59 @@ func (r *PostgresShareRepository) tx(ctx context.Context) *postgres.Queries {
 func (r *PostgresShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
 	s, err := r.tx(ctx).GetShareCondition(ctx, hashKey)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[shareRepo.ShareValue](err)
+	}
+
+	return r.toShareValue(ctx, s)
+}
+
+func (r *PostgresShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	s, err := r.tx(ctx).GetShareConditionByCode(ctx, &code)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[shareRepo.ShareValue](err)
 	}
 
+	return r.toShareValue(ctx, s)
+}
+
+func (r *PostgresShareRepository) toShareValue(ctx context.Context, s postgres.ShareCondition) mo.Result[shareRepo.ShareValue] {
 	item, err := r.tx(ctx).GetItem(ctx, postgres.GetItemParams{
-		DiagramID: s.DiagramID,
-		Location:  s.Location,
+		LoadText:      true,
+		LoadThumbnail: true,
+		DiagramID:     s.DiagramID,
+		Location:      s.Location,
 	})
 
 	if err != nil {
 		return mo.Err[shareRepo.ShareValue](err)
 	}
 
+	var code string
+
+	if s.Code != nil {
+		code = *s.Code
+	}
+
+	remainingViews := mo.None[int]()
+
+	if s.RemainingViews != nil {
+		remainingViews = mo.Some(int(*s.RemainingViews))
+	}
+
 	shareInfo := share.Share{
 		Token:          s.Token,
+		Code:           code,
+		RemainingViews: remainingViews,
 		ExpireTime:     int64(*s.ExpireTime),
 		Password:       *s.Password,
 		AllowIPList:    s.AllowIpList,
@@ -71,6 +113,20 @@ func (r *PostgresShareRepository) Find(ctx context.Context, hashKey string) mo.R
 		return mo.Err[shareRepo.ShareValue](err)
 	}
 
+	if s.Location == postgres.LocationGIST {
+		gistItem := gistitem.New().
+			WithID(id.(string)).
+			WithTitle(*item.Title).
+			WithThumbnail(thumbnail).
+			WithDiagramString(string(item.Diagram)).
+			WithIsBookmark(*item.IsBookmark).
+			WithCreatedAt(item.CreatedAt.Time).
+			WithUpdatedAt(item.UpdatedAt.Time).
+			Build().OrEmpty()
+
+		return mo.Ok(shareRepo.ShareValue{GistItem: gistItem, ShareInfo: &shareInfo, UserID: s.Uid})
+	}
+
 	diagramitem := diagramitem.New().
 		WithID(id.(string)).
 		WithTitle(*item.Title).
@@ -83,25 +139,33 @@ func (r *PostgresShareRepository) Find(ctx context.Context, hashKey string) mo.R
 		WithUpdatedAt(item.UpdatedAt.Time).
 		Build().OrEmpty()
 
-	return mo.Ok(shareRepo.ShareValue{DiagramItem: diagramitem, ShareInfo: &shareInfo})
+	return mo.Ok(shareRepo.ShareValue{DiagramItem: diagramitem, ShareInfo: &shareInfo, UserID: s.Uid})
 }
 
 func (r *PostgresShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), postgres.LocationSYSTEM, shareInfo)
+}
+
+func (r *PostgresShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), postgres.LocationGIST, shareInfo)
+}
+
+func (r *PostgresShareRepository) save(ctx context.Context, userID, hashKey, itemID string, location postgres.Location, shareInfo *share.Share) mo.Result[bool] {
 	expireTime := shareInfo.ExpireTime
-	id, err := uuid.Parse(item.ID())
+	id, err := uuid.Parse(itemID)
 
 	if err != nil {
 		return mo.Err[bool](err)
 	}
 
 	_, err = r.tx(ctx).GetShareConditionItem(ctx, postgres.GetShareConditionItemParams{
-		Location:  postgres.LocationSYSTEM,
+		Location:  location,
 		DiagramID: pgtype.UUID{Bytes: id, Valid: true},
 	})
 
 	if err == nil {
 		err = r.tx(ctx).DeleteShareConditionItem(ctx, postgres.DeleteShareConditionItemParams{
-			Location:  postgres.LocationSYSTEM,
+			Location:  location,
 			DiagramID: pgtype.UUID{Bytes: id, Valid: true},
 		})
 
@@ -110,31 +174,58 @@ func (r *PostgresShareRepository) Save(ctx context.Context, userID, hashKey stri
 		}
 	}
 
-	var savePassword string
+	password := shareInfo.Password
+	var code *string
+
+	if shareInfo.Code != "" {
+		code = &shareInfo.Code
+	}
 
-	if shareInfo.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(shareInfo.Password), bcrypt.DefaultCost)
+	var remainingViews *int32
 
-		if err != nil {
+	if v, ok := shareInfo.RemainingViews.Get(); ok {
+		views := int32(v) //nolint:gosec
+		remainingViews = &views
+	}
+
+	// A failed insert aborts the whole transaction, so it runs in a savepoint that lets callers retry with another code.
+	queries := r.tx(ctx)
+	var savepoint pgx.Tx
+
+	if tx, ok := values.GetPostgresTx(ctx).Get(); ok {
+		if savepoint, err = (*tx).Begin(ctx); err != nil {
 			return mo.Err[bool](err)
 		}
-		savePassword = string(hashedPassword)
-	} else {
-		savePassword = ""
+
+		queries = r._db.WithTx(savepoint)
 	}
 
-	err = r.tx(ctx).CreateShareCondition(ctx, postgres.CreateShareConditionParams{
+	err = queries.CreateShareCondition(ctx, postgres.CreateShareConditionParams{
 		Uid:            userID,
 		Hashkey:        hashKey,
 		DiagramID:      pgtype.UUID{Bytes: id, Valid: true},
-		Location:       postgres.LocationSYSTEM,
+		Location:       location,
 		AllowIpList:    shareInfo.AllowIPList,
 		AllowEmailList: shareInfo.AllowEmailList,
 		ExpireTime:     &expireTime,
-		Password:       &savePassword,
+		Password:       &password,
 		Token:          shareInfo.Token,
+		Code:           code,
+		RemainingViews: remainingViews,
 	})
 
+	if savepoint != nil {
+		if err != nil {
+			_ = savepoint.Rollback(ctx)
+		} else {
+			err = savepoint.Commit(ctx)
+		}
+	}
+
+	if isUniqueViolation(err, "share_code_idx") {
+		return mo.Err[bool](e.ErrShareCodeConflict)
+	}
+
 	if err != nil {
 		return mo.Err[bool](err)
 	}
@@ -151,3 +242,17 @@ func (r *PostgresShareRepository) Delete(ctx context.Context, userID, hashKey st
 
 	return mo.Ok(true)
 }
+
+func (r *PostgresShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	remaining, err := r.tx(ctx).DecrementShareConditionViews(ctx, hashKey)
+
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && remaining == nil) {
+		return mo.Err[int](e.NotFoundError(e.ErrShareViewsExceeded))
+	}
+
+	if err != nil {
+		return mo.Err[int](err)
+	}
+
+	return mo.Ok(int(*remaining))
+}
@@ -0,0 +1,219 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	blobRepo "github.com/harehare/textusm/internal/domain/repository/blob"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+// S3BlobStore talks to any S3 compatible service with path-style URLs and Signature Version 4.
+type S3BlobStore struct {
+	httpClient      *http.Client
+	endpoint        string
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+func NewBlobStore(env *config.Env) blobRepo.BlobStore {
+	region := env.S3Region
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3BlobStore{
+		httpClient:      &http.Client{Timeout: time.Duration(30) * time.Second},
+		endpoint:        strings.TrimSuffix(env.S3Endpoint, "/"),
+		region:          region,
+		bucket:          env.S3Bucket,
+		accessKeyID:     env.S3AccessKeyID,
+		secretAccessKey: env.S3SecretAccessKey,
+	}
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, key string) mo.Result[*blobRepo.Blob] {
+	res := s.do(ctx, http.MethodGet, key, nil)
+
+	if res.IsError() {
+		return mo.Err[*blobRepo.Blob](res.Error())
+	}
+
+	r := res.MustGet()
+	defer func() { _ = r.Body.Close() }()
+
+	data, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		return mo.Err[*blobRepo.Blob](err)
+	}
+
+	return mo.Ok(&blobRepo.Blob{ContentType: r.Header.Get("Content-Type"), Data: data})
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, blob *blobRepo.Blob) mo.Result[bool] {
+	res := s.do(ctx, http.MethodPut, key, blob)
+
+	if res.IsError() {
+		return mo.Err[bool](res.Error())
+	}
+
+	_ = res.MustGet().Body.Close()
+	return mo.Ok(true)
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) mo.Result[bool] {
+	res := s.do(ctx, http.MethodDelete, key, nil)
+
+	if res.IsError() {
+		if e.GetCode(res.Error()) == e.NotFound {
+			return mo.Ok(true)
+		}
+		return mo.Err[bool](res.Error())
+	}
+
+	_ = res.MustGet().Body.Close()
+	return mo.Ok(true)
+}
+
+func (s *S3BlobStore) do(ctx context.Context, method, key string, blob *blobRepo.Blob) mo.Result[*http.Response] {
+	segments := strings.Split(key, "/")
+
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+
+	var body []byte
+
+	if blob != nil {
+		body = blob.Data
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+"/"+escape(s.bucket)+"/"+strings.Join(segments, "/"), bytes.NewReader(body))
+
+	if err != nil {
+		return mo.Err[*http.Response](err)
+	}
+
+	if blob != nil && blob.ContentType != "" {
+		req.Header.Set("Content-Type", blob.ContentType)
+	}
+
+	sign(req, body, s.accessKeyID, s.secretAccessKey, s.region, time.Now())
+	res, err := s.httpClient.Do(req)
+
+	if err != nil {
+		return mo.Err[*http.Response](err)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+		return mo.Err[*http.Response](e.NotFoundError(fmt.Errorf("s3: %s not found", key)))
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		return mo.Err[*http.Response](fmt.Errorf("s3: unexpected status %d: %s", res.StatusCode, b))
+	}
+
+	return mo.Ok(res)
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host, content type, range and x-amz-* headers.
+func sign(req *http.Request, body []byte, accessKeyID, secretAccessKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+
+	for name, values := range req.Header {
+		n := strings.ToLower(name)
+
+		if n == "content-type" || n == "range" || strings.HasPrefix(n, "x-amz-") {
+			headers[n] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+// escape encodes everything but the unreserved characters, as Signature Version 4 expects.
+func escape(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
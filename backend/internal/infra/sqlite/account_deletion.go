@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/sqlite"
+	"github.com/harehare/textusm/internal/domain/model/account"
+	accountRepo "github.com/harehare/textusm/internal/domain/repository/account"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type SqliteDeletionRepository struct {
+	_db *sqlite.Queries
+}
+
+func NewDeletionRepository(config *config.Config) accountRepo.DeletionRepository {
+	return &SqliteDeletionRepository{_db: sqlite.New(config.Sqlite)}
+}
+
+func (r *SqliteDeletionRepository) tx(ctx context.Context) *sqlite.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *SqliteDeletionRepository) FindByID(ctx context.Context, deletionID string) mo.Result[*account.Deletion] {
+	d, err := r.tx(ctx).GetAccountDeletion(ctx, deletionID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return mo.Ok(toDeletion(d))
+}
+
+func (r *SqliteDeletionRepository) FindActive(ctx context.Context, userID string) mo.Result[*account.Deletion] {
+	d, err := r.tx(ctx).GetActiveAccountDeletion(ctx, userID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*account.Deletion](e.NotFoundError(e.ErrAccountDeletionNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*account.Deletion](err)
+	}
+
+	return mo.Ok(toDeletion(d))
+}
+
+func (r *SqliteDeletionRepository) FindDue(ctx context.Context, now time.Time, limit int) mo.Result[[]*account.Deletion] {
+	rows, err := r.tx(ctx).ListDueAccountDeletions(ctx, sqlite.ListDueAccountDeletionsParams{
+		ScheduledAt: DateTimeToInt(now),
+		LeaseUntil:  timeToNullInt(now),
+		Limit:       int64(limit),
+	})
+
+	if err != nil {
+		return mo.Err[[]*account.Deletion](err)
+	}
+
+	deletions := make([]*account.Deletion, len(rows))
+
+	for i, row := range rows {
+		deletions[i] = toDeletion(row)
+	}
+
+	return mo.Ok(deletions)
+}
+
+func (r *SqliteDeletionRepository) Create(ctx context.Context, deletion *account.Deletion) mo.Result[bool] {
+	err := r.tx(ctx).CreateAccountDeletion(ctx, sqlite.CreateAccountDeletionParams{
+		DeletionID:  deletion.ID,
+		Uid:         deletion.UserID,
+		Status:      string(deletion.Status),
+		RequestedAt: DateTimeToInt(deletion.RequestedAt),
+		ScheduledAt: DateTimeToInt(deletion.ScheduledAt),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *SqliteDeletionRepository) Claim(ctx context.Context, deletionID string, now, leaseUntil time.Time) mo.Result[bool] {
+	n, err := r.tx(ctx).ClaimAccountDeletion(ctx, sqlite.ClaimAccountDeletionParams{
+		LeaseUntil:   timeToNullInt(leaseUntil),
+		DeletionID:   deletionID,
+		ScheduledAt:  DateTimeToInt(now),
+		LeaseUntil_2: timeToNullInt(now),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *SqliteDeletionRepository) Cancel(ctx context.Context, deletionID string) mo.Result[bool] {
+	n, err := r.tx(ctx).CancelAccountDeletion(ctx, deletionID)
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func (r *SqliteDeletionRepository) Complete(ctx context.Context, deletionID string, completedAt time.Time, receipt string) mo.Result[bool] {
+	n, err := r.tx(ctx).CompleteAccountDeletion(ctx, sqlite.CompleteAccountDeletionParams{
+		CompletedAt: timeToNullInt(completedAt),
+		Receipt:     sql.NullString{String: receipt, Valid: true},
+		DeletionID:  deletionID,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func toDeletion(d sqlite.AccountDeletion) *account.Deletion {
+	return &account.Deletion{
+		ID:          d.DeletionID,
+		UserID:      d.Uid,
+		Status:      account.DeletionStatus(d.Status),
+		RequestedAt: IntToDateTime(d.RequestedAt),
+		ScheduledAt: IntToDateTime(d.ScheduledAt),
+		LeaseUntil:  nullIntToTime(d.LeaseUntil),
+		CompletedAt: nullIntToTime(d.CompletedAt),
+		Receipt:     d.Receipt.String,
+	}
+}
+
+func timeToNullInt(t time.Time) sql.NullInt64 {
+	return sql.NullInt64{Int64: DateTimeToInt(t), Valid: true}
+}
+
+func nullIntToTime(i sql.NullInt64) time.Time {
+	if !i.Valid {
+		return time.Time{}
+	}
+
+	return IntToDateTime(i.Int64)
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/sqlite"
+	"github.com/harehare/textusm/internal/domain/model/audit"
+	auditRepo "github.com/harehare/textusm/internal/domain/repository/audit"
+	"github.com/samber/mo"
+)
+
+type SqliteAuditLogRepository struct {
+	_db *sqlite.Queries
+}
+
+func NewAuditLogRepository(config *config.Config) auditRepo.AuditLogRepository {
+	return &SqliteAuditLogRepository{_db: sqlite.New(config.Sqlite)}
+}
+
+func (r *SqliteAuditLogRepository) tx(ctx context.Context) *sqlite.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *SqliteAuditLogRepository) Append(ctx context.Context, log *audit.Log) mo.Result[bool] {
+	err := r.tx(ctx).InsertAuditLog(ctx, sqlite.InsertAuditLogParams{
+		Uid:       log.UserID,
+		Event:     string(log.Event),
+		TargetID:  log.TargetID,
+		Detail:    log.Detail,
+		CreatedAt: DateTimeToInt(log.CreatedAt),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/sqlite"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	"github.com/samber/mo"
+)
+
+type SqliteCatalogRepository struct {
+	_db *sqlite.Queries
+}
+
+func NewCatalogRepository(config *config.Config) catalogRepo.CatalogRepository {
+	return &SqliteCatalogRepository{_db: sqlite.New(config.Sqlite)}
+}
+
+func (r *SqliteCatalogRepository) tx(ctx context.Context) *sqlite.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *SqliteCatalogRepository) UserIDs(ctx context.Context) mo.Result[[]string] {
+	uids, err := r.tx(ctx).ListUIDs(ctx)
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	return mo.Ok(append([]string{}, uids...))
+}
+
+func (r *SqliteCatalogRepository) ItemIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, LocationSYSTEM)
+}
+
+func (r *SqliteCatalogRepository) GistIDs(ctx context.Context, userID string) mo.Result[[]string] {
+	return r.listItemIDs(ctx, userID, LocationGIST)
+}
+
+func (r *SqliteCatalogRepository) listItemIDs(ctx context.Context, userID, location string) mo.Result[[]string] {
+	ids, err := r.tx(ctx).ListItemIDs(ctx, sqlite.ListItemIDsParams{
+		Uid:      userID,
+		Location: location,
+	})
+
+	if err != nil {
+		return mo.Err[[]string](err)
+	}
+
+	return mo.Ok(append([]string{}, ids...))
+}
@@ -2,7 +2,11 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 const (
@@ -62,3 +66,13 @@ func IntToDateTime(i int64) time.Time {
 func DateTimeToInt(t time.Time) int64 {
 	return t.Unix()
 }
+
+// isUniqueViolation reports whether err breaks a unique index on column, given as table.column. SQLite names the
+// columns of the index in the error instead of the index itself.
+func isUniqueViolation(err error, column string) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) &&
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.HasSuffix(sqliteErr.Error(), "failed: "+column)
+}
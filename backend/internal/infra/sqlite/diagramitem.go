@@ -11,6 +11,8 @@ import (
 	"github.com/harehare/textusm/internal/db/sqlite"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 )
 
@@ -19,7 +21,7 @@ type SqliteItemRepository struct {
 }
 
 func NewItemRepository(config *config.Config) itemRepo.ItemRepository {
-	return &SqliteItemRepository{_db: sqlite.New(config.SqlConn)}
+	return &SqliteItemRepository{_db: sqlite.New(config.Sqlite)}
 }
 
 func (r *SqliteItemRepository) tx(ctx context.Context) *sqlite.Queries {
@@ -32,84 +34,64 @@ func (r *SqliteItemRepository) tx(ctx context.Context) *sqlite.Queries {
 	}
 }
 
-func (r *SqliteItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
+func (r *SqliteItemRepository) FindByID(ctx context.Context, userID string, itemID string, isPublic bool, fields v.Projection) mo.Result[*diagramitem.DiagramItem] {
 	i, err := r.tx(ctx).GetItem(ctx, sqlite.GetItemParams{
-		Uid:       userID,
-		DiagramID: itemID,
-		Location:  "system",
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		DiagramID:     itemID,
+		Location:      "system",
 	})
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[*diagramitem.DiagramItem](err)
 	}
 
-	var thumbnail mo.Option[string]
-
-	if i.Thumbnail.Valid {
-		thumbnail = mo.None[string]()
-	} else {
-		thumbnail = mo.Some[string](i.Thumbnail.String)
+	if i.DeletedAt.Valid {
+		return mo.Err[*diagramitem.DiagramItem](e.NotFoundError(sql.ErrNoRows))
 	}
 
-	return diagramitem.New().
-		WithID(i.DiagramID).
-		WithTitle(i.Title.String).
-		WithEncryptedText(i.Text).
-		WithThumbnail(thumbnail).
-		WithDiagramString(string(i.Diagram)).
-		WithIsPublic(IntToBool(i.IsPublic)).
-		WithIsBookmark(IntToBool(i.IsBookmark)).
-		WithCreatedAt(IntToDateTime(i.CreatedAt)).
-		WithUpdatedAt(IntToDateTime(i.UpdatedAt)).
-		Build()
+	return toItem(&i)
 }
 
-func (r *SqliteItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, isBookmark bool, shouldLoadText bool) mo.Result[[]*diagramitem.DiagramItem] {
-	dbItems, err := r.tx(ctx).ListItems(ctx, sqlite.ListItemsParams{
-		Uid:        userID,
-		Location:   LocationSYSTEM,
-		IsPublic:   BoolToInt(isPublic),
-		IsBookmark: BoolToInt(isBookmark),
-		Limit:      int64(limit),
-		Offset:     int64(offset),
+func (r *SqliteItemRepository) FindByIDs(ctx context.Context, userID string, itemIDs []string, isPublic bool, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := r.tx(ctx).ListItemsByIDs(ctx, sqlite.ListItemsByIDsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		DiagramIds:    itemIDs,
 	})
 
 	if err != nil {
 		return mo.Err[[]*diagramitem.DiagramItem](err)
 	}
 
-	var items []*diagramitem.DiagramItem
-
-	for idx := range dbItems {
-		i := &dbItems[idx]
-		var thumbnail mo.Option[string]
-
-		if i.Thumbnail.Valid {
-			thumbnail = mo.Some[string](i.Thumbnail.String)
-		} else {
-			thumbnail = mo.None[string]()
-		}
-
-		item := diagramitem.New().
-			WithID(i.DiagramID).
-			WithTitle(i.Title.String).
-			WithEncryptedText(i.Text).
-			WithThumbnail(thumbnail).
-			WithDiagramString(string(i.Diagram)).
-			WithIsPublic(i.IsPublic == 1).
-			WithIsBookmark(i.IsBookmark == 1).
-			WithCreatedAt(time.Unix(i.CreatedAt, 0)).
-			WithUpdatedAt(time.Unix(i.UpdatedAt, 0)).
-			Build()
+	return toItems(dbItems)
+}
 
-		if item.IsError() {
-			return mo.Err[[]*diagramitem.DiagramItem](item.Error())
-		}
+func (r *SqliteItemRepository) Find(ctx context.Context, userID string, offset, limit int, isPublic bool, filter v.ItemFilter, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := listItems(ctx, r.tx(ctx), filter.SortOrDefault(), sqlite.ListItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		IsPublic:      BoolToInt(isPublic),
+		IsBookmark:    sql.NullInt64{Int64: 1, Valid: filter.IsBookmark},
+		Diagram:       sql.NullString{String: string(filter.Diagram.OrEmpty()), Valid: filter.Diagram.IsPresent()},
+		Limit:         int64(limit),
+		Offset:        int64(offset),
+	})
 
-		items = append(items, item.MustGet())
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
 	}
 
-	return mo.Ok(items)
+	return toItems(dbItems)
 }
 
 func (r *SqliteItemRepository) Save(ctx context.Context, userID string, item *diagramitem.DiagramItem, isPublic bool) mo.Result[*diagramitem.DiagramItem] {
@@ -130,11 +112,11 @@ func (r *SqliteItemRepository) Save(ctx context.Context, userID string, item *di
 			IsBookmark: BoolToInt(isBookmark),
 			IsPublic:   BoolToInt(isPublic),
 			Title:      sql.NullString{String: title, Valid: true},
-			Text:       item.Text(),
+			Text:       item.EncryptedText(),
 			Thumbnail:  StringToNullString(item.Thumbnail()),
 			Location:   LocationSYSTEM,
-			CreatedAt:  DateTimeToInt(time.Now()),
-			UpdatedAt:  DateTimeToInt(time.Now()),
+			CreatedAt:  DateTimeToInt(item.CreatedAt()),
+			UpdatedAt:  DateTimeToInt(item.UpdatedAt()),
 		})
 
 		if err != nil {
@@ -150,11 +132,11 @@ func (r *SqliteItemRepository) Save(ctx context.Context, userID string, item *di
 			IsBookmark: BoolToInt(isBookmark),
 			IsPublic:   BoolToInt(isPublic),
 			Title:      sql.NullString{String: title, Valid: true},
-			Text:       item.Text(),
+			Text:       item.EncryptedText(),
 			Thumbnail:  StringToNullString(item.Thumbnail()),
 			DiagramID:  item.ID(),
 			Location:   LocationSYSTEM,
-			UpdatedAt:  DateTimeToInt(time.Now()),
+			UpdatedAt:  DateTimeToInt(item.UpdatedAt()),
 		})
 
 		if err != nil {
@@ -165,8 +147,69 @@ func (r *SqliteItemRepository) Save(ctx context.Context, userID string, item *di
 }
 
 func (r *SqliteItemRepository) Delete(ctx context.Context, userID string, itemID string, isPublic bool) mo.Result[bool] {
-	err := r.tx(ctx).DeleteItem(ctx, sqlite.DeleteItemParams{
+	var err error
+
+	// A public item is the private row marked public, so the public copy goes by clearing the mark.
+	if isPublic {
+		err = r.tx(ctx).UnpublishItem(ctx, sqlite.UnpublishItemParams{
+			Uid:       userID,
+			DiagramID: itemID,
+		})
+	} else {
+		err = r.tx(ctx).DeleteItem(ctx, sqlite.DeleteItemParams{
+			Uid:       userID,
+			DiagramID: itemID,
+		})
+	}
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *SqliteItemRepository) FindTrash(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*diagramitem.DiagramItem] {
+	dbItems, err := r.tx(ctx).ListTrashedItems(ctx, sqlite.ListTrashedItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationSYSTEM,
+		Limit:         int64(limit),
+		Offset:        int64(offset),
+	})
+
+	if err != nil {
+		return mo.Err[[]*diagramitem.DiagramItem](err)
+	}
+
+	return toItems(dbItems)
+}
+
+func (r *SqliteItemRepository) FindExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) mo.Result[[]itemRepo.TrashedItem] {
+	rows, err := r.tx(ctx).ListExpiredTrashedItems(ctx, sqlite.ListExpiredTrashedItemsParams{
+		DeletedAt: timeToNullInt(deletedBefore),
+		Limit:     int64(limit),
+	})
+
+	if err != nil {
+		return mo.Err[[]itemRepo.TrashedItem](err)
+	}
+
+	trashed := make([]itemRepo.TrashedItem, 0, len(rows))
+
+	for _, row := range rows {
+		trashed = append(trashed, itemRepo.TrashedItem{UserID: row.Uid, ItemID: row.DiagramID})
+	}
+
+	return mo.Ok(trashed)
+}
+
+func (r *SqliteItemRepository) Trash(ctx context.Context, userID string, itemID string, deletedAt time.Time) mo.Result[bool] {
+	n, err := r.tx(ctx).TrashItem(ctx, sqlite.TrashItemParams{
+		DeletedAt: timeToNullInt(deletedAt),
 		Uid:       userID,
+		Location:  LocationSYSTEM,
 		DiagramID: itemID,
 	})
 
@@ -174,5 +217,77 @@ func (r *SqliteItemRepository) Delete(ctx context.Context, userID string, itemID
 		return mo.Err[bool](err)
 	}
 
-	return mo.Ok(true)
+	return mo.Ok(n > 0)
+}
+
+func (r *SqliteItemRepository) Restore(ctx context.Context, userID string, itemID string) mo.Result[bool] {
+	n, err := r.tx(ctx).RestoreItem(ctx, sqlite.RestoreItemParams{
+		Uid:       userID,
+		Location:  LocationSYSTEM,
+		DiagramID: itemID,
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(n > 0)
+}
+
+func toItems(dbItems []sqlite.Item) mo.Result[[]*diagramitem.DiagramItem] {
+	var items []*diagramitem.DiagramItem
+
+	for idx := range dbItems {
+		item := toItem(&dbItems[idx])
+
+		if item.IsError() {
+			return mo.Err[[]*diagramitem.DiagramItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
+
+func toItem(i *sqlite.Item) mo.Result[*diagramitem.DiagramItem] {
+	var thumbnail mo.Option[string]
+
+	if i.Thumbnail.Valid {
+		thumbnail = mo.Some[string](i.Thumbnail.String)
+	} else {
+		thumbnail = mo.None[string]()
+	}
+
+	var deletedAt mo.Option[time.Time]
+
+	if i.DeletedAt.Valid {
+		deletedAt = mo.Some(IntToDateTime(i.DeletedAt.Int64))
+	}
+
+	return diagramitem.New().
+		WithID(i.DiagramID).
+		WithTitle(i.Title.String).
+		WithEncryptedText(i.Text).
+		WithThumbnail(thumbnail).
+		WithDiagramString(string(i.Diagram)).
+		WithIsPublic(IntToBool(i.IsPublic)).
+		WithIsBookmark(IntToBool(i.IsBookmark)).
+		WithCreatedAt(IntToDateTime(i.CreatedAt)).
+		WithUpdatedAt(IntToDateTime(i.UpdatedAt)).
+		WithDeletedAt(deletedAt).
+		Build()
+}
+
+// listItems lists items with the query of sort. The queries differ only in their order, so that each one can be
+// served by the index on that order.
+func listItems(ctx context.Context, q *sqlite.Queries, sort v.ItemSort, params sqlite.ListItemsParams) ([]sqlite.Item, error) {
+	switch sort {
+	case v.ItemSortCreatedAt:
+		return q.ListItemsByCreatedAt(ctx, sqlite.ListItemsByCreatedAtParams(params))
+	case v.ItemSortTitle:
+		return q.ListItemsByTitle(ctx, sqlite.ListItemsByTitleParams(params))
+	default:
+		return q.ListItems(ctx, params)
+	}
 }
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	schema "github.com/harehare/textusm/db"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	benchItems = 100_000
+	benchUsers = 10
+)
+
+// newBenchConfig migrates a new database and fills it with benchItems items spread over benchUsers users.
+// The rows are inserted directly in one transaction, as saving them one by one takes minutes.
+func newBenchConfig(b *testing.B) *config.Config {
+	b.Helper()
+
+	ctx := context.Background()
+	database, err := sqlitedb.Open(ctx, filepath.Join(b.TempDir(), "textusm.db"), 4)
+	require.NoError(b, err)
+	b.Cleanup(func() { database.Close() })
+
+	migrations, err := migration.Load(schema.SqliteMigrations())
+	require.NoError(b, err)
+	_, err = migration.New(migration.NewSqliteDriver(database.Writer()), migrations).Up(ctx)
+	require.NoError(b, err)
+
+	tx, err := database.Writer().BeginTx(ctx, nil)
+	require.NoError(b, err)
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO items
+		(uid, diagram, diagram_id, is_bookmark, is_public, title, text, location, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	require.NoError(b, err)
+
+	diagrams := []v.Diagram{v.DiagramUserStoryMap, v.DiagramMindMap, v.DiagramBusinessModelCanvas, v.DiagramFourls}
+	now := time.Now()
+
+	for i := range benchItems {
+		_, err := stmt.ExecContext(ctx,
+			fmt.Sprintf("user%d", i%benchUsers),
+			diagrams[(i/benchUsers)%len(diagrams)],
+			uuid.NewString(),
+			(i/benchUsers)%7 == 0,
+			false,
+			uuid.NewString(),
+			"text",
+			LocationSYSTEM,
+			DateTimeToInt(now.Add(-time.Duration(i)*time.Minute)),
+			DateTimeToInt(now.Add(-time.Duration((i*7919)%benchItems)*time.Minute)),
+		)
+		require.NoError(b, err)
+	}
+
+	require.NoError(b, stmt.Close())
+	require.NoError(b, tx.Commit())
+
+	return &config.Config{Sqlite: database}
+}
+
+func BenchmarkFind(b *testing.B) {
+	repo := NewItemRepository(newBenchConfig(b))
+	ctx := context.Background()
+
+	benchmarks := []struct {
+		name   string
+		offset int
+		filter v.ItemFilter
+	}{
+		{"UpdatedAt", 0, v.ItemFilter{}},
+		{"CreatedAt", 0, v.ItemFilter{Sort: v.ItemSortCreatedAt}},
+		{"Title", 0, v.ItemFilter{Sort: v.ItemSortTitle}},
+		{"TitleDeepPage", 5_000, v.ItemFilter{Sort: v.ItemSortTitle}},
+		{"Bookmark", 0, v.ItemFilter{IsBookmark: true}},
+		{"Diagram", 0, v.ItemFilter{Diagram: mo.Some(v.DiagramMindMap)}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for b.Loop() {
+				items := repo.Find(ctx, "user0", bm.offset, 30, false, bm.filter, v.MetadataOnly)
+
+				if items.IsError() {
+					b.Fatal(items.Error())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	schema "github.com/harehare/textusm/db"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	ctx := context.Background()
+	database, err := sqlitedb.Open(ctx, filepath.Join(t.TempDir(), "textusm.db"), 1)
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	migrations, err := migration.Load(schema.SqliteMigrations())
+	require.NoError(t, err)
+	_, err = migration.New(migration.NewSqliteDriver(database.Writer()), migrations).Up(ctx)
+	require.NoError(t, err)
+
+	return &config.Config{Sqlite: database}
+}
+
+func TestFindProjection(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := NewItemRepository(cfg)
+	thumbnail := "data:image/png;base64,iVBORw0KGgo="
+	item := diagramitem.New().
+		WithID(uuid.NewString()).
+		WithTitle("title").
+		WithEncryptedText("text").
+		WithThumbnail(mo.Some(thumbnail)).
+		WithDiagram(v.DiagramUserStoryMap).
+		WithCreatedAt(time.Now()).
+		WithUpdatedAt(time.Now()).
+		Build().MustGet()
+	require.NoError(t, repo.Save(ctx, "user", item, false).Error())
+
+	found := repo.FindByID(ctx, "user", item.ID(), false, v.MetadataOnly)
+	require.NoError(t, found.Error())
+	assert.Equal(t, "title", found.MustGet().Title())
+	assert.Empty(t, found.MustGet().EncryptedText())
+	assert.Nil(t, found.MustGet().Thumbnail())
+
+	found = repo.FindByID(ctx, "user", item.ID(), false, v.AllFields)
+	require.NoError(t, found.Error())
+	assert.Equal(t, "text", found.MustGet().EncryptedText())
+	assert.Equal(t, thumbnail, *found.MustGet().Thumbnail())
+
+	items := repo.Find(ctx, "user", 0, 10, false, v.ItemFilter{}, v.Projection{Thumbnail: true})
+	require.NoError(t, items.Error())
+	require.Len(t, items.MustGet(), 1)
+	assert.Empty(t, items.MustGet()[0].EncryptedText())
+	assert.Equal(t, thumbnail, *items.MustGet()[0].Thumbnail())
+
+	// Shared items are read whole whatever the projection of the reads above.
+	shares := NewShareRepository(cfg)
+	require.NoError(t, shares.Save(ctx, "user", "hash", item, &share.Share{Token: "token"}).Error())
+
+	shared := shares.Find(ctx, "hash")
+	require.NoError(t, shared.Error())
+	assert.Equal(t, "text", shared.MustGet().DiagramItem.EncryptedText())
+	assert.Equal(t, thumbnail, *shared.MustGet().DiagramItem.Thumbnail())
+}
+
+// The stored text is written as is, so that blob references and encrypted text survive a save and an update.
+func TestSaveKeepsTextRef(t *testing.T) {
+	ctx := context.Background()
+	repo := NewItemRepository(newTestConfig(t))
+	item := diagramitem.New().
+		WithID(uuid.NewString()).
+		WithTitle("title").
+		WithEncryptedText("text").
+		WithDiagram(v.DiagramUserStoryMap).
+		WithCreatedAt(time.Now()).
+		WithUpdatedAt(time.Now()).
+		Build().MustGet()
+
+	for _, key := range []string{"users/user/items/a/text", "users/user/items/b/text"} {
+		require.NoError(t, repo.Save(ctx, "user", item.OffloadText(key), false).Error())
+
+		found := repo.FindByID(ctx, "user", item.ID(), false, v.AllFields)
+		require.NoError(t, found.Error())
+		assert.Equal(t, key, found.MustGet().TextRef().OrEmpty())
+	}
+}
+
+func TestFindByIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewItemRepository(newTestConfig(t))
+	var ids []string
+
+	for range 3 {
+		item := diagramitem.New().
+			WithID(uuid.NewString()).
+			WithTitle("title").
+			WithEncryptedText("text").
+			WithDiagram(v.DiagramUserStoryMap).
+			WithCreatedAt(time.Now()).
+			WithUpdatedAt(time.Now()).
+			Build().MustGet()
+		require.NoError(t, repo.Save(ctx, "user", item, false).Error())
+		ids = append(ids, item.ID())
+	}
+
+	require.True(t, repo.Trash(ctx, "user", ids[2], time.Now()).MustGet())
+
+	items := repo.FindByIDs(ctx, "user", append(ids, uuid.NewString()), false, v.Projection{Text: true})
+	require.NoError(t, items.Error())
+
+	found := lo.Map(items.MustGet(), func(i *diagramitem.DiagramItem, _ int) string { return i.ID() })
+	assert.ElementsMatch(t, ids[:2], found)
+	assert.Equal(t, "text", items.MustGet()[0].EncryptedText())
+
+	others := repo.FindByIDs(ctx, "other", ids, false, v.AllFields)
+	require.NoError(t, others.Error())
+	assert.Empty(t, others.MustGet())
+}
+
+func TestFindSortAndFilter(t *testing.T) {
+	ctx := context.Background()
+	repo := NewItemRepository(newTestConfig(t))
+	now := time.Now().Truncate(time.Second)
+
+	for i, title := range []string{"b", "c", "a"} {
+		diagram := v.DiagramUserStoryMap
+
+		if i == 1 {
+			diagram = v.DiagramMindMap
+		}
+
+		item := diagramitem.New().
+			WithID(uuid.NewString()).
+			WithTitle(title).
+			WithEncryptedText("text").
+			WithDiagram(diagram).
+			WithIsBookmark(i != 0).
+			WithCreatedAt(now.Add(time.Duration(i) * time.Hour)).
+			WithUpdatedAt(now.Add(-time.Duration(i) * time.Hour)).
+			Build().MustGet()
+		require.NoError(t, repo.Save(ctx, "user", item, false).Error())
+	}
+
+	titles := func(filter v.ItemFilter) []string {
+		items := repo.Find(ctx, "user", 0, 10, false, filter, v.MetadataOnly)
+		require.NoError(t, items.Error())
+
+		return lo.Map(items.MustGet(), func(i *diagramitem.DiagramItem, _ int) string { return i.Title() })
+	}
+
+	assert.Equal(t, []string{"b", "c", "a"}, titles(v.ItemFilter{}))
+	assert.Equal(t, []string{"a", "c", "b"}, titles(v.ItemFilter{Sort: v.ItemSortCreatedAt}))
+	assert.Equal(t, []string{"a", "b", "c"}, titles(v.ItemFilter{Sort: v.ItemSortTitle}))
+	assert.Equal(t, []string{"a", "c"}, titles(v.ItemFilter{IsBookmark: true, Sort: v.ItemSortTitle}))
+	assert.Equal(t, []string{"a", "b"}, titles(v.ItemFilter{Diagram: mo.Some(v.DiagramUserStoryMap), Sort: v.ItemSortTitle}))
+	assert.Equal(t, []string{"a"}, titles(v.ItemFilter{IsBookmark: true, Diagram: mo.Some(v.DiagramUserStoryMap)}))
+}
+
+// TestBookmark saves the item in a transaction nested in the one that reads it, which must not wait for the
+// single writer connection that the outer transaction holds.
+func TestBookmark(t *testing.T) {
+	ctx, cancel := context.WithTimeout(values.WithUID(context.Background(), "user"), 5*time.Second)
+	defer cancel()
+
+	cfg := newTestConfig(t)
+	repo := NewItemRepository(cfg)
+	svc := itemService.NewService(repo, nil, NewShareRepository(cfg), nil, nil, db.NewDBTx(cfg), "", "", nil, "", nil)
+	item := diagramitem.New().
+		WithID(uuid.NewString()).
+		WithTitle("title").
+		WithEncryptedText("text").
+		WithDiagram(v.DiagramUserStoryMap).
+		WithCreatedAt(time.Now()).
+		WithUpdatedAt(time.Now()).
+		Build().MustGet()
+	require.NoError(t, repo.Save(ctx, "user", item, false).Error())
+
+	bookmarked := svc.Bookmark(ctx, item.ID(), true)
+	require.NoError(t, bookmarked.Error())
+	assert.True(t, bookmarked.MustGet().IsBookmark())
+
+	found := repo.FindByID(ctx, "user", item.ID(), false, v.AllFields)
+	require.NoError(t, found.Error())
+	assert.True(t, found.MustGet().IsBookmark())
+}
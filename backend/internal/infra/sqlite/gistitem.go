@@ -10,6 +10,8 @@ import (
 	"github.com/harehare/textusm/internal/db/sqlite"
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	itemRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 )
 
@@ -18,7 +20,7 @@ type SqliteGistItemRepository struct {
 }
 
 func NewGistItemRepository(config *config.Config) itemRepo.GistItemRepository {
-	return &SqliteGistItemRepository{_db: sqlite.New(config.SqlConn)}
+	return &SqliteGistItemRepository{_db: sqlite.New(config.Sqlite)}
 }
 
 func (r *SqliteGistItemRepository) tx(ctx context.Context) *sqlite.Queries {
@@ -31,13 +33,19 @@ func (r *SqliteGistItemRepository) tx(ctx context.Context) *sqlite.Queries {
 	}
 }
 
-func (r *SqliteGistItemRepository) FindByID(ctx context.Context, userID string, itemID string) mo.Result[*gistitem.GistItem] {
+func (r *SqliteGistItemRepository) FindByID(ctx context.Context, userID string, itemID string, fields v.Projection) mo.Result[*gistitem.GistItem] {
 	i, err := r.tx(ctx).GetItem(ctx, sqlite.GetItemParams{
-		Uid:       userID,
-		DiagramID: itemID,
-		Location:  LocationGIST,
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		DiagramID:     itemID,
+		Location:      LocationGIST,
 	})
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*gistitem.GistItem](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[*gistitem.GistItem](err)
 	}
@@ -58,55 +66,46 @@ func (r *SqliteGistItemRepository) FindByID(ctx context.Context, userID string,
 		WithIsBookmark(IntToBool(i.IsBookmark)).
 		WithCreatedAt(IntToDateTime(i.CreatedAt)).
 		WithUpdatedAt(IntToDateTime(i.UpdatedAt)).
+		WithRevision(i.Revision.String).
+		WithEncryptedText(i.Text).
 		Build()
 }
 
-func (r *SqliteGistItemRepository) Find(ctx context.Context, userID string, offset, limit int) mo.Result[[]*gistitem.GistItem] {
-	isPublic := false
-	isBookmark := false
-	dbItems, err := r.tx(ctx).ListItems(ctx, sqlite.ListItemsParams{
-		Uid:        userID,
-		IsPublic:   BoolToInt(isPublic),
-		IsBookmark: BoolToInt(isBookmark),
-		Location:   LocationGIST,
-		Limit:      int64(limit),
-		Offset:     int64(offset),
+func (r *SqliteGistItemRepository) FindByIDs(ctx context.Context, userID string, gistIDs []string, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	dbItems, err := r.tx(ctx).ListItemsByIDs(ctx, sqlite.ListItemsByIDsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		Location:      LocationGIST,
+		DiagramIds:    gistIDs,
 	})
 
 	if err != nil {
 		return mo.Err[[]*gistitem.GistItem](err)
 	}
 
-	var items []*gistitem.GistItem
-
-	for idx := range dbItems {
-		i := &dbItems[idx]
-		var thumbnail mo.Option[string]
-
-		if i.Thumbnail.Valid {
-			thumbnail = mo.Some[string](i.Thumbnail.String)
-		} else {
-			thumbnail = mo.None[string]()
-		}
-
-		item := gistitem.New().
-			WithID(i.DiagramID).
-			WithTitle(i.Title.String).
-			WithThumbnail(thumbnail).
-			WithDiagramString(string(i.Diagram)).
-			WithIsBookmark(IntToBool(i.IsBookmark)).
-			WithCreatedAt(IntToDateTime(i.CreatedAt)).
-			WithUpdatedAt(IntToDateTime(i.UpdatedAt)).
-			Build()
+	return toGistItems(dbItems)
+}
 
-		if item.IsError() {
-			return mo.Err[[]*gistitem.GistItem](item.Error())
-		}
+func (r *SqliteGistItemRepository) Find(ctx context.Context, userID string, offset, limit int, fields v.Projection) mo.Result[[]*gistitem.GistItem] {
+	isPublic := false
+	isBookmark := false
+	dbItems, err := r.tx(ctx).ListItems(ctx, sqlite.ListItemsParams{
+		LoadText:      fields.Text,
+		LoadThumbnail: fields.Thumbnail,
+		Uid:           userID,
+		IsPublic:      BoolToInt(isPublic),
+		IsBookmark:    sql.NullInt64{Int64: BoolToInt(isBookmark), Valid: true},
+		Location:      LocationGIST,
+		Limit:         int64(limit),
+		Offset:        int64(offset),
+	})
 
-		items = append(items, item.MustGet())
+	if err != nil {
+		return mo.Err[[]*gistitem.GistItem](err)
 	}
 
-	return mo.Ok(items)
+	return toGistItems(dbItems)
 }
 
 func (r *SqliteGistItemRepository) Save(ctx context.Context, userID string, item *gistitem.GistItem) mo.Result[*gistitem.GistItem] {
@@ -127,10 +126,12 @@ func (r *SqliteGistItemRepository) Save(ctx context.Context, userID string, item
 			IsBookmark: BoolToInt(isBookmark),
 			IsPublic:   BoolToInt(false),
 			Title:      sql.NullString{String: title, Valid: true},
+			Text:       item.EncryptedText(),
 			Thumbnail:  StringToNullString(item.Thumbnail()),
 			Location:   LocationGIST,
+			Revision:   StringToNullString(mo.EmptyableToOption(item.Revision()).ToPointer()),
 			CreatedAt:  DateTimeToInt(item.CreatedAt()),
-			UpdatedAt:  DateTimeToInt(item.CreatedAt()),
+			UpdatedAt:  DateTimeToInt(item.UpdatedAt()),
 		}); err != nil {
 			return mo.Err[*gistitem.GistItem](err)
 		}
@@ -143,10 +144,12 @@ func (r *SqliteGistItemRepository) Save(ctx context.Context, userID string, item
 			IsBookmark: BoolToInt(isBookmark),
 			IsPublic:   BoolToInt(false),
 			Title:      sql.NullString{String: title, Valid: true},
+			Text:       item.EncryptedText(),
 			Thumbnail:  StringToNullString(item.Thumbnail()),
 			DiagramID:  item.ID(),
 			Location:   LocationGIST,
-			UpdatedAt:  DateTimeToInt(item.CreatedAt()),
+			Revision:   StringToNullString(mo.EmptyableToOption(item.Revision()).ToPointer()),
+			UpdatedAt:  DateTimeToInt(item.UpdatedAt()),
 		}); err != nil {
 			return mo.Err[*gistitem.GistItem](err)
 		}
@@ -163,3 +166,39 @@ func (r *SqliteGistItemRepository) Delete(ctx context.Context, userID string, gi
 
 	return mo.Ok(true)
 }
+
+// toGistItems maps the rows of gist items.
+func toGistItems(dbItems []sqlite.Item) mo.Result[[]*gistitem.GistItem] {
+	var items []*gistitem.GistItem
+
+	for idx := range dbItems {
+		i := &dbItems[idx]
+		var thumbnail mo.Option[string]
+
+		if i.Thumbnail.Valid {
+			thumbnail = mo.Some[string](i.Thumbnail.String)
+		} else {
+			thumbnail = mo.None[string]()
+		}
+
+		item := gistitem.New().
+			WithID(i.DiagramID).
+			WithTitle(i.Title.String).
+			WithThumbnail(thumbnail).
+			WithDiagramString(string(i.Diagram)).
+			WithIsBookmark(IntToBool(i.IsBookmark)).
+			WithCreatedAt(IntToDateTime(i.CreatedAt)).
+			WithUpdatedAt(IntToDateTime(i.UpdatedAt)).
+			WithRevision(i.Revision.String).
+			WithEncryptedText(i.Text).
+			Build()
+
+		if item.IsError() {
+			return mo.Err[[]*gistitem.GistItem](item.Error())
+		}
+
+		items = append(items, item.MustGet())
+	}
+
+	return mo.Ok(items)
+}
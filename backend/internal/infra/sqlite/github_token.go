@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/sqlite"
+	"github.com/harehare/textusm/internal/domain/model/user"
+	userRepo "github.com/harehare/textusm/internal/domain/repository/user"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type SqliteGithubTokenRepository struct {
+	_db *sqlite.Queries
+}
+
+func NewGithubTokenRepository(config *config.Config) userRepo.GithubTokenRepository {
+	return &SqliteGithubTokenRepository{_db: sqlite.New(config.Sqlite)}
+}
+
+func (r *SqliteGithubTokenRepository) tx(ctx context.Context) *sqlite.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *SqliteGithubTokenRepository) Find(ctx context.Context, uid string) mo.Result[*user.GithubToken] {
+	t, err := r.tx(ctx).GetGithubToken(ctx, uid)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*user.GithubToken](e.NotFoundError(e.ErrGithubNotConnected))
+	}
+
+	if err != nil {
+		return mo.Err[*user.GithubToken](err)
+	}
+
+	return mo.Ok(user.NewGithubTokenFromEncrypted(t.AccessToken, t.Scope, time.Unix(t.CreatedAt, 0), time.Unix(t.UpdatedAt, 0)))
+}
+
+func (r *SqliteGithubTokenRepository) Save(ctx context.Context, uid string, token *user.GithubToken) mo.Result[bool] {
+	err := r.tx(ctx).UpsertGithubToken(ctx, sqlite.UpsertGithubTokenParams{
+		Uid:         uid,
+		AccessToken: token.EncryptedAccessToken(),
+		Scope:       token.Scope(),
+		CreatedAt:   DateTimeToInt(token.CreatedAt()),
+		UpdatedAt:   DateTimeToInt(token.UpdatedAt()),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func (r *SqliteGithubTokenRepository) Delete(ctx context.Context, uid string) mo.Result[bool] {
+	if err := r.tx(ctx).DeleteGithubToken(ctx, uid); err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
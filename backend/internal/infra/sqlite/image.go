@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/harehare/textusm/internal/config"
+	v "github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/sqlite"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	imageRepo "github.com/harehare/textusm/internal/domain/repository/image"
+	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
+)
+
+type SqliteImageRepository struct {
+	_db *sqlite.Queries
+}
+
+func NewImageRepository(config *config.Config) imageRepo.ImageRepository {
+	return &SqliteImageRepository{_db: sqlite.New(config.Sqlite)}
+}
+
+func (r *SqliteImageRepository) tx(ctx context.Context) *sqlite.Queries {
+	tx := v.GetDBTx(ctx)
+
+	if tx.IsPresent() {
+		return r._db.WithTx(tx.MustGet())
+	} else {
+		return r._db
+	}
+}
+
+func (r *SqliteImageRepository) FindByID(ctx context.Context, imageID string) mo.Result[*image.Image] {
+	i, err := r.tx(ctx).GetImage(ctx, imageID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*image.Image](e.NotFoundError(e.ErrImageNotFound))
+	}
+
+	if err != nil {
+		return mo.Err[*image.Image](err)
+	}
+
+	return mo.Ok(toImage(i))
+}
+
+func (r *SqliteImageRepository) FindByUserID(ctx context.Context, userID string) mo.Result[[]*image.Image] {
+	rows, err := r.tx(ctx).ListImages(ctx, userID)
+
+	if err != nil {
+		return mo.Err[[]*image.Image](err)
+	}
+
+	images := make([]*image.Image, len(rows))
+
+	for i, row := range rows {
+		images[i] = toImage(row)
+	}
+
+	return mo.Ok(images)
+}
+
+func (r *SqliteImageRepository) Usage(ctx context.Context, userID string) mo.Result[int64] {
+	usage, err := r.tx(ctx).GetImageUsage(ctx, userID)
+
+	if err != nil {
+		return mo.Err[int64](err)
+	}
+
+	return mo.Ok(usage)
+}
+
+func (r *SqliteImageRepository) Save(ctx context.Context, img *image.Image) mo.Result[bool] {
+	err := r.tx(ctx).InsertImage(ctx, sqlite.InsertImageParams{
+		ImageID:     img.ID,
+		Uid:         img.UserID,
+		DiagramID:   img.ItemID,
+		Location:    fromLocation(img.Location),
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Hash:        img.Hash,
+		CreatedAt:   DateTimeToInt(img.CreatedAt),
+	})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+// SaveWithinQuota relies on write transactions, which take the database lock as they begin, running one at a time.
+func (r *SqliteImageRepository) SaveWithinQuota(ctx context.Context, img *image.Image, quota int64) mo.Result[bool] {
+	if v.GetDBTx(ctx).IsAbsent() {
+		return mo.Err[bool](errors.New("saving an image within the quota needs a transaction"))
+	}
+
+	usage := r.Usage(ctx, img.UserID)
+
+	if usage.IsError() {
+		return mo.Err[bool](usage.Error())
+	}
+
+	if usage.MustGet()+img.Size > quota {
+		return mo.Ok(false)
+	}
+
+	return r.Save(ctx, img)
+}
+
+func (r *SqliteImageRepository) Delete(ctx context.Context, userID string, imageID string) mo.Result[bool] {
+	err := r.tx(ctx).DeleteImage(ctx, sqlite.DeleteImageParams{Uid: userID, ImageID: imageID})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
+
+func toImage(i sqlite.Image) *image.Image {
+	return &image.Image{
+		ID:          i.ImageID,
+		UserID:      i.Uid,
+		ItemID:      i.DiagramID,
+		Location:    toLocation(i.Location),
+		ContentType: i.ContentType,
+		Size:        i.Size,
+		Hash:        i.Hash,
+		CreatedAt:   IntToDateTime(i.CreatedAt),
+	}
+}
+
+func fromLocation(location values.Location) string {
+	if location == values.LocationGist {
+		return LocationGIST
+	}
+
+	return LocationSYSTEM
+}
+
+func toLocation(location string) values.Location {
+	if location == LocationGIST {
+		return values.LocationGist
+	}
+
+	return values.LocationSystem
+}
@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/domain/model/image"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveWithinQuotaConcurrently(t *testing.T) {
+	const (
+		uploads = 8
+		quota   = 35
+	)
+
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	repo := NewImageRepository(cfg)
+	tx := db.NewDBTx(cfg)
+
+	var (
+		wg    sync.WaitGroup
+		saved atomic.Int32
+	)
+
+	for range uploads {
+		wg.Go(func() {
+			img := image.New("user", "item", v.LocationSystem, "image/png", make([]byte, 10), time.Now())
+
+			err := tx.Do(ctx, func(ctx context.Context) error {
+				ret := repo.SaveWithinQuota(ctx, img, quota)
+
+				if ret.IsOk() && ret.MustGet() {
+					saved.Add(1)
+				}
+
+				return ret.Error()
+			})
+			assert.NoError(t, err)
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(3), saved.Load())
+
+	usage := repo.Usage(ctx, "user")
+	require.NoError(t, usage.Error())
+	assert.Equal(t, int64(30), usage.MustGet())
+}
+
+func TestSaveWithinQuotaRequiresTransaction(t *testing.T) {
+	repo := NewImageRepository(newTestConfig(t))
+	img := image.New("user", "item", v.LocationSystem, "image/png", make([]byte, 10), time.Now())
+
+	assert.Error(t, repo.SaveWithinQuota(context.Background(), img, 100).Error())
+}
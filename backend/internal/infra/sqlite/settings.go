@@ -12,6 +12,7 @@ import (
 	"github.com/harehare/textusm/internal/domain/model/settings"
 	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
 	"github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
 )
 
@@ -20,7 +21,7 @@ type SqliteSettingsRepository struct {
 }
 
 func NewSettingsRepository(config *config.Config) settingsRepo.SettingsRepository {
-	return &SqliteSettingsRepository{_db: sqlite.New(config.SqlConn)}
+	return &SqliteSettingsRepository{_db: sqlite.New(config.Sqlite)}
 }
 
 func (r *SqliteSettingsRepository) tx(ctx context.Context) *sqlite.Queries {
@@ -36,6 +37,10 @@ func (r *SqliteSettingsRepository) tx(ctx context.Context) *sqlite.Queries {
 func (r *SqliteSettingsRepository) Find(ctx context.Context, userID string, diagram values.Diagram) mo.Result[*settings.Settings] {
 	s, err := r.tx(ctx).GetSettings(ctx, sqlite.GetSettingsParams{Uid: userID, Diagram: string(diagram)})
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[*settings.Settings](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[*settings.Settings](err)
 	}
@@ -92,6 +97,7 @@ func (r *SqliteSettingsRepository) Save(ctx context.Context, userID string, diag
 			TaskBackgroundColor:     s.TaskColor.BackgroundColor,
 			Width:                   int64(width),
 			ZoomControl:             BoolToNullInt(s.ZoomControl),
+			Font:                    s.Font,
 			CreatedAt:               DateTimeToInt(time.Now()),
 			UpdatedAt:               DateTimeToInt(time.Now()),
 		})
@@ -117,6 +123,7 @@ func (r *SqliteSettingsRepository) Save(ctx context.Context, userID string, diag
 			TaskBackgroundColor:     s.TaskColor.BackgroundColor,
 			Width:                   int64(width),
 			ZoomControl:             BoolToNullInt(s.ZoomControl),
+			Font:                    s.Font,
 			UpdatedAt:               DateTimeToInt(time.Now()),
 		})
 	}
@@ -127,3 +134,13 @@ func (r *SqliteSettingsRepository) Save(ctx context.Context, userID string, diag
 
 	return mo.Ok(s)
 }
+
+func (r *SqliteSettingsRepository) Delete(ctx context.Context, userID string, diagram values.Diagram) mo.Result[bool] {
+	err := r.tx(ctx).DeleteSettings(ctx, sqlite.DeleteSettingsParams{Uid: userID, Diagram: string(diagram)})
+
+	if err != nil {
+		return mo.Err[bool](err)
+	}
+
+	return mo.Ok(true)
+}
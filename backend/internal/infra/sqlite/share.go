@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
@@ -10,10 +11,11 @@ import (
 	"github.com/harehare/textusm/internal/context/values"
 	"github.com/harehare/textusm/internal/db/sqlite"
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
 	"github.com/harehare/textusm/internal/domain/model/share"
 	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	e "github.com/harehare/textusm/internal/error"
 	"github.com/samber/mo"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type SqliteShareRepository struct {
@@ -21,7 +23,7 @@ type SqliteShareRepository struct {
 }
 
 func NewShareRepository(config *config.Config) shareRepo.ShareRepository {
-	return &SqliteShareRepository{_db: sqlite.New(config.SqlConn)}
+	return &SqliteShareRepository{_db: sqlite.New(config.Sqlite)}
 }
 
 func (r *SqliteShareRepository) tx(ctx context.Context) *sqlite.Queries {
@@ -37,21 +39,54 @@ func (r *SqliteShareRepository) tx(ctx context.Context) *sqlite.Queries {
 func (r *SqliteShareRepository) Find(ctx context.Context, hashKey string) mo.Result[shareRepo.ShareValue] {
 	s, err := r.tx(ctx).GetShareCondition(ctx, hashKey)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
+	if err != nil {
+		return mo.Err[shareRepo.ShareValue](err)
+	}
+
+	return r.toShareValue(ctx, s)
+}
+
+func (r *SqliteShareRepository) FindByCode(ctx context.Context, code string) mo.Result[shareRepo.ShareValue] {
+	s, err := r.tx(ctx).GetShareConditionByCode(ctx, sql.NullString{String: code, Valid: true})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return mo.Err[shareRepo.ShareValue](e.NotFoundError(err))
+	}
+
 	if err != nil {
 		return mo.Err[shareRepo.ShareValue](err)
 	}
 
+	return r.toShareValue(ctx, s)
+}
+
+func (r *SqliteShareRepository) toShareValue(ctx context.Context, s sqlite.ShareCondition) mo.Result[shareRepo.ShareValue] {
 	item, err := r.tx(ctx).GetItem(ctx, sqlite.GetItemParams{
-		DiagramID: s.DiagramID,
-		Location:  s.Location,
+		LoadText:      true,
+		LoadThumbnail: true,
+		Uid:           s.Uid,
+		DiagramID:     s.DiagramID,
+		Location:      s.Location,
 	})
 
 	if err != nil {
 		return mo.Err[shareRepo.ShareValue](err)
 	}
 
+	remainingViews := mo.None[int]()
+
+	if s.RemainingViews.Valid {
+		remainingViews = mo.Some(int(s.RemainingViews.Int64))
+	}
+
 	shareInfo := share.Share{
 		Token:          s.Token,
+		Code:           s.Code.String,
+		RemainingViews: remainingViews,
 		ExpireTime:     s.ExpireTime.Int64,
 		Password:       s.Password.String,
 		AllowIPList:    strings.Split(s.AllowIpList.String, ","),
@@ -66,6 +101,20 @@ func (r *SqliteShareRepository) Find(ctx context.Context, hashKey string) mo.Res
 		thumbnail = mo.None[string]()
 	}
 
+	if s.Location == LocationGIST {
+		gistItem := gistitem.New().
+			WithID(item.DiagramID).
+			WithTitle(item.Title.String).
+			WithThumbnail(thumbnail).
+			WithDiagramString(string(item.Diagram)).
+			WithIsBookmark(IntToBool(item.IsBookmark)).
+			WithCreatedAt(IntToDateTime(item.CreatedAt)).
+			WithUpdatedAt(IntToDateTime(item.UpdatedAt)).
+			Build().OrEmpty()
+
+		return mo.Ok(shareRepo.ShareValue{GistItem: gistItem, ShareInfo: &shareInfo, UserID: s.Uid})
+	}
+
 	diagramitem := diagramitem.New().
 		WithID(item.DiagramID).
 		WithTitle(item.Title.String).
@@ -78,20 +127,30 @@ func (r *SqliteShareRepository) Find(ctx context.Context, hashKey string) mo.Res
 		WithUpdatedAt(IntToDateTime(item.UpdatedAt)).
 		Build().OrEmpty()
 
-	return mo.Ok(shareRepo.ShareValue{DiagramItem: diagramitem, ShareInfo: &shareInfo})
+	return mo.Ok(shareRepo.ShareValue{DiagramItem: diagramitem, ShareInfo: &shareInfo, UserID: s.Uid})
 }
 
 func (r *SqliteShareRepository) Save(ctx context.Context, userID, hashKey string, item *diagramitem.DiagramItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), LocationSYSTEM, shareInfo)
+}
+
+func (r *SqliteShareRepository) SaveGist(ctx context.Context, userID, hashKey string, item *gistitem.GistItem, shareInfo *share.Share) mo.Result[bool] {
+	return r.save(ctx, userID, hashKey, item.ID(), LocationGIST, shareInfo)
+}
+
+func (r *SqliteShareRepository) save(ctx context.Context, userID, hashKey, itemID, location string, shareInfo *share.Share) mo.Result[bool] {
 	expireTime := shareInfo.ExpireTime
 	_, err := r.tx(ctx).GetShareConditionItem(ctx, sqlite.GetShareConditionItemParams{
-		Location:  LocationSYSTEM,
-		DiagramID: item.ID(),
+		Uid:       userID,
+		Location:  location,
+		DiagramID: itemID,
 	})
 
 	if err == nil {
 		err = r.tx(ctx).DeleteShareConditionItem(ctx, sqlite.DeleteShareConditionItemParams{
-			Location:  LocationSYSTEM,
-			DiagramID: item.ID(),
+			Uid:       userID,
+			Location:  location,
+			DiagramID: itemID,
 		})
 
 		if err != nil {
@@ -99,33 +158,26 @@ func (r *SqliteShareRepository) Save(ctx context.Context, userID, hashKey string
 		}
 	}
 
-	var savePassword string
-
-	if shareInfo.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(shareInfo.Password), bcrypt.DefaultCost)
-
-		if err != nil {
-			return mo.Err[bool](err)
-		}
-		savePassword = string(hashedPassword)
-	} else {
-		savePassword = ""
-	}
-
 	err = r.tx(ctx).CreateShareCondition(ctx, sqlite.CreateShareConditionParams{
 		Uid:            userID,
 		Hashkey:        hashKey,
-		DiagramID:      item.ID(),
-		Location:       LocationSYSTEM,
+		DiagramID:      itemID,
+		Location:       location,
 		AllowIpList:    sql.NullString{String: strings.Join(shareInfo.AllowIPList, ","), Valid: true},
 		AllowEmailList: sql.NullString{String: strings.Join(shareInfo.AllowEmailList, ","), Valid: true},
 		ExpireTime:     sql.NullInt64{Int64: expireTime, Valid: true},
-		Password:       sql.NullString{String: savePassword, Valid: true},
+		Password:       sql.NullString{String: shareInfo.Password, Valid: true},
 		Token:          shareInfo.Token,
+		Code:           sql.NullString{String: shareInfo.Code, Valid: shareInfo.Code != ""},
+		RemainingViews: sql.NullInt64{Int64: int64(shareInfo.RemainingViews.OrEmpty()), Valid: shareInfo.RemainingViews.IsPresent()},
 		CreatedAt:      DateTimeToInt(time.Now()),
 		UpdatedAt:      DateTimeToInt(time.Now()),
 	})
 
+	if isUniqueViolation(err, "share_conditions.code") {
+		return mo.Err[bool](e.ErrShareCodeConflict)
+	}
+
 	if err != nil {
 		return mo.Err[bool](err)
 	}
@@ -142,3 +194,20 @@ func (r *SqliteShareRepository) Delete(ctx context.Context, userID, hashKey stri
 
 	return mo.Ok(true)
 }
+
+func (r *SqliteShareRepository) ConsumeView(ctx context.Context, hashKey string) mo.Result[int] {
+	remaining, err := r.tx(ctx).DecrementShareConditionViews(ctx, sqlite.DecrementShareConditionViewsParams{
+		UpdatedAt: DateTimeToInt(time.Now()),
+		Hashkey:   hashKey,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !remaining.Valid) {
+		return mo.Err[int](e.NotFoundError(e.ErrShareViewsExceeded))
+	}
+
+	if err != nil {
+		return mo.Err[int](err)
+	}
+
+	return mo.Ok(int(remaining.Int64))
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/share"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveShareCodeConflict(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	items := NewItemRepository(cfg)
+	shares := NewShareRepository(cfg)
+
+	newItem := func() *diagramitem.DiagramItem {
+		item := diagramitem.New().
+			WithID(uuid.NewString()).
+			WithTitle("title").
+			WithEncryptedText("text").
+			WithDiagram(v.DiagramUserStoryMap).
+			WithCreatedAt(time.Now()).
+			WithUpdatedAt(time.Now()).
+			Build().MustGet()
+		require.NoError(t, items.Save(ctx, "user", item, false).Error())
+		return item
+	}
+
+	first, second := newItem(), newItem()
+	require.NoError(t, shares.Save(ctx, "user", "hash1", first, &share.Share{Token: "token", Code: "my-slug"}).Error())
+
+	err := shares.Save(ctx, "user", "hash2", second, &share.Share{Token: "token", Code: "my-slug"}).Error()
+	assert.ErrorIs(t, err, e.ErrShareCodeConflict)
+
+	// Sharing the same item again keeps its code.
+	require.NoError(t, shares.Save(ctx, "user", "hash1", first, &share.Share{Token: "token", Code: "my-slug"}).Error())
+	require.NoError(t, shares.Save(ctx, "user", "hash2", second, &share.Share{Token: "token", Code: "other-slug"}).Error())
+}
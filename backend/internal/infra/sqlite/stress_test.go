@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	schema "github.com/harehare/textusm/db"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db"
+	"github.com/harehare/textusm/internal/db/migration"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConcurrentRequests saves and lists items from many goroutines at once, as concurrent GraphQL requests do,
+// and expects no "database is locked" or "transaction already in progress" errors.
+func TestConcurrentRequests(t *testing.T) {
+	const (
+		writers         = 8
+		readers         = 16
+		itemsPerWriter  = 25
+		readsPerReader  = 50
+		deletesPerWrite = 5
+	)
+
+	ctx := context.Background()
+	database, err := sqlitedb.Open(ctx, filepath.Join(t.TempDir(), "textusm.db"), readers)
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	migrations, err := migration.Load(schema.SqliteMigrations())
+	require.NoError(t, err)
+	_, err = migration.New(migration.NewSqliteDriver(database.Writer()), migrations).Up(ctx)
+	require.NoError(t, err)
+
+	cfg := &config.Config{Sqlite: database}
+	repo := NewItemRepository(cfg)
+	tx := db.NewDBTx(cfg)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*itemsPerWriter+readers*readsPerReader)
+
+	for w := range writers {
+		wg.Go(func() {
+			userID := fmt.Sprintf("user%d", w)
+			ctx := values.WithUID(ctx, userID)
+
+			for i := range itemsPerWriter {
+				item := diagramitem.New().
+					WithID(uuid.NewString()).
+					WithTitle("title").
+					WithEncryptedText("text").
+					WithDiagram(v.DiagramUserStoryMap).
+					WithCreatedAt(time.Now()).
+					WithUpdatedAt(time.Now()).
+					Build().MustGet()
+
+				errs <- tx.Do(ctx, func(ctx context.Context) error {
+					if err := repo.Save(ctx, userID, item, false).Error(); err != nil {
+						return err
+					}
+
+					if i%deletesPerWrite == 0 {
+						return repo.Delete(ctx, userID, item.ID(), false).Error()
+					}
+
+					return nil
+				})
+			}
+		})
+	}
+
+	for r := range readers {
+		wg.Go(func() {
+			userID := fmt.Sprintf("user%d", r%writers)
+			ctx := values.WithUID(ctx, userID)
+
+			for range readsPerReader {
+				errs <- tx.DoReadOnly(ctx, func(ctx context.Context) error {
+					return repo.Find(ctx, userID, 0, 100, false, v.ItemFilter{}, v.Projection{Text: true}).Error()
+				})
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for w := range writers {
+		userID := fmt.Sprintf("user%d", w)
+		items := repo.Find(values.WithUID(ctx, userID), userID, 0, 100, false, v.ItemFilter{}, v.MetadataOnly)
+
+		require.NoError(t, items.Error())
+		require.Len(t, items.MustGet(), itemsPerWriter-itemsPerWriter/deletesPerWrite)
+	}
+}
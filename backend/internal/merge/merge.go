@@ -0,0 +1,139 @@
+package merge
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	oursMarker   = "<<<<<<< ours"
+	sepMarker    = "======="
+	theirsMarker = ">>>>>>> theirs"
+	// maxTableCells bounds the memory of the LCS table at 16 MiB. Changed regions larger than that are not
+	// matched line by line, so they merge only when one side left them unchanged and conflict otherwise.
+	maxTableCells = 1 << 22
+)
+
+type Result struct {
+	Text       string
+	Conflicted bool
+}
+
+// ThreeWay merges the line based changes from base to ours and from base to theirs.
+// Regions changed differently on both sides are kept with conflict markers.
+func ThreeWay(base, ours, theirs string) Result {
+	b, o, t := lines(base), lines(ours), lines(theirs)
+	bo, bt := matches(b, o), matches(b, t)
+
+	var (
+		out        []string
+		conflicted bool
+		i, j, k    int
+	)
+
+	resolve := func(bc, oc, tc []string) {
+		switch {
+		case slices.Equal(oc, bc):
+			out = append(out, tc...)
+		case slices.Equal(tc, bc), slices.Equal(oc, tc):
+			out = append(out, oc...)
+		default:
+			conflicted = true
+			out = append(out, oursMarker)
+			out = append(out, oc...)
+			out = append(out, sepMarker)
+			out = append(out, tc...)
+			out = append(out, theirsMarker)
+		}
+	}
+
+	for {
+		stable := -1
+
+		for bi := i; bi < len(b); bi++ {
+			if _, ok := bo[bi]; !ok {
+				continue
+			}
+			if _, ok := bt[bi]; ok {
+				stable = bi
+				break
+			}
+		}
+
+		if stable < 0 {
+			resolve(b[i:], o[j:], t[k:])
+			break
+		}
+
+		oi, ti := bo[stable], bt[stable]
+		resolve(b[i:stable], o[j:oi], t[k:ti])
+		out = append(out, b[stable])
+		i, j, k = stable+1, oi+1, ti+1
+	}
+
+	return Result{Text: strings.Join(out, "\n"), Conflicted: conflicted}
+}
+
+func lines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// matches returns the line pairs of the longest common subsequence of a and b, keyed by the index in a.
+func matches(a, b []string) map[int]int {
+	m := make(map[int]int)
+	prefix := 0
+
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		m[prefix] = prefix
+		prefix++
+	}
+
+	suffix := 0
+
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		m[len(a)-1-suffix] = len(b) - 1 - suffix
+		suffix++
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, w := len(ma), len(mb)
+
+	if (n+1)*(w+1) > maxTableCells {
+		return m
+	}
+
+	table := make([][]int32, n+1)
+
+	for x := range table {
+		table[x] = make([]int32, w+1)
+	}
+
+	for x := n - 1; x >= 0; x-- {
+		for y := w - 1; y >= 0; y-- {
+			if ma[x] == mb[y] {
+				table[x][y] = table[x+1][y+1] + 1
+			} else {
+				table[x][y] = max(table[x+1][y], table[x][y+1])
+			}
+		}
+	}
+
+	for x, y := 0, 0; x < n && y < w; {
+		switch {
+		case ma[x] == mb[y]:
+			m[prefix+x] = prefix + y
+			x++
+			y++
+		case table[x+1][y] >= table[x][y+1]:
+			x++
+		default:
+			y++
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,104 @@
+package merge
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestThreeWay(t *testing.T) {
+	base := "# title\nactivity\n    task\n        story"
+
+	tests := []struct {
+		name       string
+		ours       string
+		theirs     string
+		expected   string
+		conflicted bool
+	}{
+		{
+			name:     "only ours changed",
+			ours:     "# title\nactivity\n    task\n        story\n        story2",
+			theirs:   base,
+			expected: "# title\nactivity\n    task\n        story\n        story2",
+		},
+		{
+			name:     "only theirs changed",
+			ours:     base,
+			theirs:   "# new title\nactivity\n    task\n        story",
+			expected: "# new title\nactivity\n    task\n        story",
+		},
+		{
+			name:     "both changed different lines",
+			ours:     "# title\nactivity\n    task\n        story\n        story2",
+			theirs:   "# new title\nactivity\n    task\n        story",
+			expected: "# new title\nactivity\n    task\n        story\n        story2",
+		},
+		{
+			name:     "both made the same change",
+			ours:     "# title\nactivity2\n    task\n        story",
+			theirs:   "# title\nactivity2\n    task\n        story",
+			expected: "# title\nactivity2\n    task\n        story",
+		},
+		{
+			name:       "both changed the same line",
+			ours:       "# title\nactivity\n    ours\n        story",
+			theirs:     "# title\nactivity\n    theirs\n        story",
+			expected:   "# title\nactivity\n<<<<<<< ours\n    ours\n=======\n    theirs\n>>>>>>> theirs\n        story",
+			conflicted: true,
+		},
+		{
+			name:     "deleted on one side",
+			ours:     "# title\nactivity\n        story",
+			theirs:   "# title\nactivity\n    task\n        story\n        story2",
+			expected: "# title\nactivity\n        story\n        story2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ThreeWay(base, tt.ours, tt.theirs)
+
+			if r.Text != tt.expected {
+				t.Errorf("ThreeWay() text = %q, want %q", r.Text, tt.expected)
+			}
+
+			if r.Conflicted != tt.conflicted {
+				t.Errorf("ThreeWay() conflicted = %v, want %v", r.Conflicted, tt.conflicted)
+			}
+		})
+	}
+}
+
+func TestThreeWayEmptyBase(t *testing.T) {
+	r := ThreeWay("", "ours", "theirs")
+
+	if !r.Conflicted {
+		t.Error("ThreeWay() should conflict when both sides add different text")
+	}
+}
+
+func TestThreeWayLargeChange(t *testing.T) {
+	base := make([]string, 3000)
+
+	for i := range base {
+		base[i] = fmt.Sprintf("line %d", i)
+	}
+
+	// Changing the first and the last line leaves nearly every line to match against the LCS table.
+	ours := slices.Clone(base)
+	ours[0], ours[len(ours)-1] = "first", "last"
+	theirs := slices.Clone(base)
+	theirs[1500] = "middle"
+
+	join := func(lines []string) string { return strings.Join(lines, "\n") }
+
+	if r := ThreeWay(join(base), join(ours), join(base)); r.Conflicted || r.Text != join(ours) {
+		t.Error("ThreeWay() should take a large change when the other side left the text unchanged")
+	}
+
+	if r := ThreeWay(join(base), join(ours), join(theirs)); !r.Conflicted {
+		t.Error("ThreeWay() should conflict when both sides change a region too large to match line by line")
+	}
+}
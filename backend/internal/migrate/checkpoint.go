@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint records the last user whose data was fully copied so an interrupted migration can resume.
+// Users are migrated in ascending ID order, so every user up to LastUserID is done.
+// A Checkpoint without a path keeps its state in memory only.
+type Checkpoint struct {
+	path       string
+	LastUserID string `json:"lastUserID"`
+}
+
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path}
+
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Done reports whether the data of userID was already copied.
+func (c *Checkpoint) Done(userID string) bool {
+	return c.LastUserID != "" && userID <= c.LastUserID
+}
+
+// Save marks userID as copied. The file is replaced atomically so a crash never leaves a truncated checkpoint.
+func (c *Checkpoint) Save(userID string) error {
+	c.LastUserID = userID
+
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+)
+
+// defaultScale is the diagram scale the frontend uses when none is stored. The SQL stores require one.
+const defaultScale = 1.0
+
+// Stats counts the records copied per kind.
+type Stats struct {
+	Users    int
+	Items    int
+	Public   int
+	Trash    int
+	Gists    int
+	Settings int
+	Shares   int
+}
+
+func (s *Stats) add(snap *snapshot) {
+	s.Users++
+	s.Items += len(snap.items)
+	s.Public += len(snap.public)
+	s.Trash += len(snap.trash)
+	s.Gists += len(snap.gists)
+	s.Settings += len(snap.settings)
+	s.Shares += len(snap.shares)
+}
+
+// Migrator copies items, gist items, settings and share conditions from one store into another.
+// IDs and timestamps are kept, and share conditions stay under the same hash key,
+// so both stores must be used with the same SHARE_ENCRYPT_KEY.
+type Migrator struct {
+	source          *Store
+	target          *Store
+	shareEncryptKey string
+	checkpoint      *Checkpoint
+}
+
+func New(source, target *Store, shareEncryptKey string, checkpoint *Checkpoint) *Migrator {
+	return &Migrator{source: source, target: target, shareEncryptKey: shareEncryptKey, checkpoint: checkpoint}
+}
+
+// Run copies the data of every user not covered by the checkpoint yet.
+// Saving is an upsert in every store, so users interrupted halfway are simply copied again.
+func (m *Migrator) Run(ctx context.Context) (*Stats, error) {
+	userIDs := m.source.Catalog.UserIDs(ctx)
+
+	if userIDs.IsError() {
+		return nil, userIDs.Error()
+	}
+
+	stats := &Stats{}
+
+	for _, userID := range userIDs.MustGet() {
+		if m.checkpoint.Done(userID) {
+			continue
+		}
+
+		snap, err := load(ctx, m.source, userID, m.shareEncryptKey)
+
+		if err != nil {
+			slog.Error("Failed to read user data", "userID", userID, "error", err)
+			return stats, err
+		}
+
+		if err := m.save(ctx, userID, snap); err != nil {
+			slog.Error("Failed to write user data", "userID", userID, "error", err)
+			return stats, err
+		}
+
+		if err := m.checkpoint.Save(userID); err != nil {
+			return stats, err
+		}
+
+		stats.add(snap)
+		slog.Info("Migrated user", "userID", userID, "items", len(snap.items), "trash", len(snap.trash), "gists", len(snap.gists), "settings", len(snap.settings), "shares", len(snap.shares))
+	}
+
+	return stats, nil
+}
+
+// save writes every record in its own transaction.
+// Firestore transactions cannot read after writing, which saving a share does, and are limited to 500 writes.
+func (m *Migrator) save(ctx context.Context, userID string, snap *snapshot) error {
+	ctx = values.WithUID(ctx, userID)
+	write := func(fn func(ctx context.Context) error) error {
+		return m.target.Transaction.Do(ctx, fn)
+	}
+
+	for _, item := range snap.items {
+		if err := write(func(ctx context.Context) error {
+			return m.target.Items.Save(ctx, userID, item, false).Error()
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Published copies go last as the SQL stores keep both copies in one row and saving the private one unpublishes it.
+	for _, item := range snap.public {
+		if err := write(func(ctx context.Context) error {
+			return m.target.Items.Save(ctx, userID, item, true).Error()
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Trashing is a write of its own, as Firestore reads the item to move it to the trash.
+	for _, item := range snap.trash {
+		if err := write(func(ctx context.Context) error {
+			return m.target.Items.Save(ctx, userID, item, false).Error()
+		}); err != nil {
+			return err
+		}
+
+		if err := write(func(ctx context.Context) error {
+			return m.target.Items.Trash(ctx, userID, item.ID(), *item.DeletedAt()).Error()
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, gist := range snap.gists {
+		if err := write(func(ctx context.Context) error {
+			return m.target.Gists.Save(ctx, userID, gist).Error()
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range snap.settings {
+		s := withDefaultScale(entry.settings)
+
+		if err := write(func(ctx context.Context) error {
+			return m.target.Settings.Save(ctx, userID, entry.diagram, s).Error()
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range snap.shares {
+		if err := write(func(ctx context.Context) error {
+			if entry.value.GistItem != nil {
+				return m.target.Shares.SaveGist(ctx, userID, entry.hashKey, entry.value.GistItem, entry.value.ShareInfo).Error()
+			}
+			return m.target.Shares.Save(ctx, userID, entry.hashKey, entry.value.DiagramItem, entry.value.ShareInfo).Error()
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func withDefaultScale(s *settings.Settings) *settings.Settings {
+	if s.Scale != nil {
+		return s
+	}
+
+	scale := defaultScale
+	copied := *s
+	copied.Scale = &scale
+	return &copied
+}
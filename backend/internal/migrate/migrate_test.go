@@ -0,0 +1,210 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/db/sqlitedb"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	"github.com/harehare/textusm/internal/domain/model/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const shareEncryptKey = "share-key"
+
+func newSqliteStore(t *testing.T) *Store {
+	t.Helper()
+	ctx := context.Background()
+
+	schema, err := os.ReadFile("../../db/sqlite/schema.sql")
+	require.NoError(t, err)
+
+	db, err := sqlitedb.Open(ctx, ":memory:", 1)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.ExecContext(ctx, string(schema))
+	require.NoError(t, err)
+
+	store, err := NewStore(Sqlite, &config.Config{Sqlite: db})
+	require.NoError(t, err)
+
+	return store
+}
+
+type fixture struct {
+	item    *diagramitem.DiagramItem
+	gist    *gistitem.GistItem
+	trashed *diagramitem.DiagramItem
+}
+
+func seed(t *testing.T, store *Store, userID string) fixture {
+	t.Helper()
+	ctx := values.WithUID(context.Background(), userID)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+
+	item := diagramitem.New().
+		WithID(uuid.NewString()).
+		WithTitle("title").
+		WithEncryptedText("encrypted").
+		WithThumbnail(mo.Some("thumbnail")).
+		WithDiagram(v.DiagramUserStoryMap).
+		WithIsPublic(true).
+		WithIsBookmark(true).
+		WithCreatedAt(createdAt).
+		WithUpdatedAt(updatedAt).
+		Build().MustGet()
+	gist := gistitem.New().
+		WithID(uuid.NewString()).
+		WithTitle("gist").
+		WithEncryptedText("encrypted gist").
+		WithDiagram(v.DiagramMindMap).
+		WithRevision("rev").
+		WithCreatedAt(createdAt).
+		WithUpdatedAt(updatedAt).
+		Build().MustGet()
+	scale := 1.5
+	toolbar := true
+	hashKey := itemService.ShareID(shareEncryptKey, item.ID()).MustGet()
+	password, err := share.HashPassword("password")
+	require.NoError(t, err)
+
+	trashed := diagramitem.New().
+		WithID(uuid.NewString()).
+		WithTitle("trashed").
+		WithEncryptedText("encrypted trashed").
+		WithDiagram(v.DiagramKpt).
+		WithCreatedAt(createdAt).
+		WithUpdatedAt(updatedAt).
+		Build().MustGet()
+
+	err = store.Transaction.Do(ctx, func(ctx context.Context) error {
+		if r := store.Items.Save(ctx, userID, item, true); r.IsError() {
+			return r.Error()
+		}
+		if r := store.Items.Save(ctx, userID, trashed, false); r.IsError() {
+			return r.Error()
+		}
+		if r := store.Items.Trash(ctx, userID, trashed.ID(), updatedAt.Add(time.Hour)); r.IsError() {
+			return r.Error()
+		}
+		if r := store.Gists.Save(ctx, userID, gist); r.IsError() {
+			return r.Error()
+		}
+		if r := store.Settings.Save(ctx, userID, v.DiagramUserStoryMap, &settings.Settings{Font: "Nunito", Width: 140, Height: 65, Scale: &scale, Toolbar: &toolbar}); r.IsError() {
+			return r.Error()
+		}
+		return store.Shares.Save(ctx, userID, hashKey, item, &share.Share{
+			Token:          "token",
+			Code:           "code-" + userID,
+			Password:       password,
+			AllowIPList:    []string{"127.0.0.1"},
+			AllowEmailList: []string{"user@example.com"},
+			ExpireTime:     updatedAt.UnixMilli(),
+			RemainingViews: mo.Some(3),
+		}).Error()
+	})
+	require.NoError(t, err)
+
+	return fixture{item: item, gist: gist, trashed: trashed}
+}
+
+func TestRun(t *testing.T) {
+	source := newSqliteStore(t)
+	target := newSqliteStore(t)
+	f := seed(t, source, "user1")
+	seed(t, source, "user2")
+
+	checkpoint, err := LoadCheckpoint("")
+	require.NoError(t, err)
+
+	m := New(source, target, shareEncryptKey, checkpoint)
+	stats, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Users: 2, Items: 2, Public: 2, Trash: 2, Gists: 2, Settings: 2, Shares: 2}, stats)
+	assert.Equal(t, "user2", checkpoint.LastUserID)
+
+	snap, err := load(context.Background(), target, "user1", shareEncryptKey)
+	require.NoError(t, err)
+	require.Len(t, snap.items, 1)
+	assert.Equal(t, f.item.ID(), snap.items[0].ID())
+	assert.Equal(t, f.item.CreatedAt().Unix(), snap.items[0].CreatedAt().Unix())
+	assert.Equal(t, f.item.UpdatedAt().Unix(), snap.items[0].UpdatedAt().Unix())
+	require.Len(t, snap.trash, 1)
+	assert.Equal(t, f.trashed.ID(), snap.trash[0].ID())
+	assert.Equal(t, f.trashed.UpdatedAt().Add(time.Hour).Unix(), snap.trash[0].DeletedAt().Unix())
+	require.Len(t, snap.shares, 1)
+	assert.NoError(t, snap.shares[0].value.ShareInfo.ComparePassword("password"))
+
+	report, err := m.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 2, report.Target[KindShares].Count)
+
+	for _, kind := range Kinds {
+		assert.Equal(t, report.Source[kind].Checksum(), report.Target[kind].Checksum(), kind)
+	}
+}
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	source := newSqliteStore(t)
+	target := newSqliteStore(t)
+	seed(t, source, "user1")
+	seed(t, source, "user2")
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	require.NoError(t, checkpoint.Save("user1"))
+
+	resumed, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, "user1", resumed.LastUserID)
+
+	stats, err := New(source, target, shareEncryptKey, resumed).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Users)
+
+	ids := target.Catalog.UserIDs(context.Background())
+	require.NoError(t, ids.Error())
+	assert.Equal(t, []string{"user2"}, ids.MustGet())
+}
+
+func TestVerifyDetectsMismatch(t *testing.T) {
+	source := newSqliteStore(t)
+	target := newSqliteStore(t)
+	f := seed(t, source, "user1")
+
+	checkpoint, err := LoadCheckpoint("")
+	require.NoError(t, err)
+
+	m := New(source, target, shareEncryptKey, checkpoint)
+	_, err = m.Run(context.Background())
+	require.NoError(t, err)
+
+	ctx := values.WithUID(context.Background(), "user1")
+	err = target.Transaction.Do(ctx, func(ctx context.Context) error {
+		return target.Gists.Save(ctx, "user1", f.gist.Bookmark(true)).Error()
+	})
+	require.NoError(t, err)
+
+	report, err := m.Verify(context.Background())
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []Mismatch{{UserID: "user1", Kind: KindGists}}, report.Mismatches)
+	assert.Equal(t, report.Source[KindGists].Count, report.Target[KindGists].Count)
+	assert.NotEqual(t, report.Source[KindGists].Checksum(), report.Target[KindGists].Checksum())
+}
@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	itemService "github.com/harehare/textusm/internal/domain/service/diagramitem"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+)
+
+// trashPageSize is how many items in the trash are read at once.
+const trashPageSize = 100
+
+type settingsEntry struct {
+	diagram  v.Diagram
+	settings *settings.Settings
+}
+
+type shareEntry struct {
+	hashKey string
+	value   shareRepo.ShareValue
+}
+
+// snapshot holds everything one user owns in a store.
+type snapshot struct {
+	items []*diagramitem.DiagramItem
+	// public holds the published copies of items, which Firestore keeps apart from the private ones.
+	public []*diagramitem.DiagramItem
+	// trash holds the items in the trash, which the catalog does not list.
+	trash    []*diagramitem.DiagramItem
+	gists    []*gistitem.GistItem
+	settings []settingsEntry
+	shares   []shareEntry
+}
+
+// load reads the data of userID from store as that user, so row level security applies as it does for the API.
+func load(ctx context.Context, store *Store, userID, shareEncryptKey string) (*snapshot, error) {
+	var snap snapshot
+
+	err := store.Transaction.Do(values.WithUID(ctx, userID), func(ctx context.Context) error {
+		itemIDs := store.Catalog.ItemIDs(ctx, userID)
+
+		if itemIDs.IsError() {
+			return itemIDs.Error()
+		}
+
+		for _, itemID := range itemIDs.MustGet() {
+			item := store.Items.FindByID(ctx, userID, itemID, false, v.AllFields)
+
+			if item.IsError() {
+				return item.Error()
+			}
+
+			snap.items = append(snap.items, item.MustGet())
+
+			public := store.Items.FindByID(ctx, userID, itemID, true, v.AllFields)
+
+			if public.IsError() && e.GetCode(public.Error()) != e.NotFound {
+				return public.Error()
+			}
+
+			if public.IsOk() && public.MustGet().IsPublic() {
+				snap.public = append(snap.public, public.MustGet())
+			}
+		}
+
+		for offset := 0; ; offset += trashPageSize {
+			page := store.Items.FindTrash(ctx, userID, offset, trashPageSize, v.AllFields)
+
+			if page.IsError() {
+				return page.Error()
+			}
+
+			snap.trash = append(snap.trash, page.MustGet()...)
+
+			if len(page.MustGet()) < trashPageSize {
+				break
+			}
+		}
+
+		gistIDs := store.Catalog.GistIDs(ctx, userID)
+
+		if gistIDs.IsError() {
+			return gistIDs.Error()
+		}
+
+		for _, gistID := range gistIDs.MustGet() {
+			gist := store.Gists.FindByID(ctx, userID, gistID, v.AllFields)
+
+			if gist.IsError() {
+				return gist.Error()
+			}
+
+			snap.gists = append(snap.gists, gist.MustGet())
+		}
+
+		for _, diagram := range v.AllDiagram {
+			s := store.Settings.Find(ctx, userID, diagram)
+
+			if s.IsError() && e.GetCode(s.Error()) == e.NotFound {
+				continue
+			}
+
+			if s.IsError() {
+				return s.Error()
+			}
+
+			snap.settings = append(snap.settings, settingsEntry{diagram: diagram, settings: s.MustGet()})
+		}
+
+		for _, itemID := range append(itemIDs.MustGet(), gistIDs.MustGet()...) {
+			hashKey := itemService.ShareID(shareEncryptKey, itemID)
+
+			if hashKey.IsError() {
+				return hashKey.Error()
+			}
+
+			share := store.Shares.Find(ctx, hashKey.MustGet())
+
+			if share.IsError() && e.GetCode(share.Error()) == e.NotFound {
+				continue
+			}
+
+			if share.IsError() {
+				return share.Error()
+			}
+
+			snap.shares = append(snap.shares, shareEntry{hashKey: hashKey.MustGet(), value: share.MustGet()})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &snap, nil
+}
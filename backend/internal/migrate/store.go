@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/harehare/textusm/internal/config"
+	"github.com/harehare/textusm/internal/db"
+	catalogRepo "github.com/harehare/textusm/internal/domain/repository/catalog"
+	itemRepo "github.com/harehare/textusm/internal/domain/repository/diagramitem"
+	gistRepo "github.com/harehare/textusm/internal/domain/repository/gistitem"
+	settingsRepo "github.com/harehare/textusm/internal/domain/repository/settings"
+	shareRepo "github.com/harehare/textusm/internal/domain/repository/share"
+	"github.com/harehare/textusm/internal/infra/firebase"
+	"github.com/harehare/textusm/internal/infra/postgres"
+	"github.com/harehare/textusm/internal/infra/sqlite"
+)
+
+const (
+	Firestore = "firestore"
+	Postgres  = "postgres"
+	Sqlite    = "sqlite"
+)
+
+// Store groups the repositories of one storage backend.
+// Each repository handles its own encoding, such as the location column being SYSTEM in Postgres and system in SQLite,
+// so data read from one store can be saved to another as is.
+type Store struct {
+	Catalog     catalogRepo.CatalogRepository
+	Items       itemRepo.ItemRepository
+	Gists       gistRepo.GistItemRepository
+	Settings    settingsRepo.SettingsRepository
+	Shares      shareRepo.ShareRepository
+	Transaction db.Transaction
+}
+
+func NewStore(dbType string, config *config.Config) (*Store, error) {
+	switch dbType {
+	case Firestore:
+		return &Store{
+			Catalog:     firebase.NewCatalogRepository(config),
+			Items:       firebase.NewItemRepository(config),
+			Gists:       firebase.NewGistItemRepository(config),
+			Settings:    firebase.NewSettingsRepository(config),
+			Shares:      firebase.NewShareRepository(config),
+			Transaction: db.NewFirestoreTx(config),
+		}, nil
+	case Postgres:
+		return &Store{
+			Catalog:     postgres.NewCatalogRepository(config),
+			Items:       postgres.NewItemRepository(config),
+			Gists:       postgres.NewGistItemRepository(config),
+			Settings:    postgres.NewSettingsRepository(config),
+			Shares:      postgres.NewShareRepository(config),
+			Transaction: db.NewPostgresTx(config),
+		}, nil
+	case Sqlite:
+		return &Store{
+			Catalog:     sqlite.NewCatalogRepository(config),
+			Items:       sqlite.NewItemRepository(config),
+			Gists:       sqlite.NewGistItemRepository(config),
+			Settings:    sqlite.NewSettingsRepository(config),
+			Shares:      sqlite.NewShareRepository(config),
+			Transaction: db.NewDBTx(config),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown store %q", dbType)
+	}
+}
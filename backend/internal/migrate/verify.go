@@ -0,0 +1,226 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"slices"
+	"strings"
+
+	"github.com/harehare/textusm/internal/domain/model/settings"
+)
+
+const (
+	KindItems    = "items"
+	KindPublic   = "public"
+	KindTrash    = "trash"
+	KindGists    = "gists"
+	KindSettings = "settings"
+	KindShares   = "shares"
+)
+
+var Kinds = []string{KindItems, KindPublic, KindTrash, KindGists, KindSettings, KindShares}
+
+// Tally is the number of records of one kind and a checksum over their contents.
+type Tally struct {
+	Count int
+	hash  hash.Hash
+}
+
+func (t *Tally) Checksum() string {
+	return hex.EncodeToString(t.hash.Sum(nil))
+}
+
+// Mismatch names a user whose records of a kind differ between the stores.
+type Mismatch struct {
+	UserID string
+	Kind   string
+}
+
+type Report struct {
+	Source     map[string]*Tally
+	Target     map[string]*Tally
+	Mismatches []Mismatch
+}
+
+func newTallies() map[string]*Tally {
+	tallies := make(map[string]*Tally, len(Kinds))
+
+	for _, kind := range Kinds {
+		tallies[kind] = &Tally{hash: sha256.New()}
+	}
+
+	return tallies
+}
+
+func (r *Report) OK() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Verify reads back the data of every user of the source from both stores and compares row counts and checksums.
+// Fields are compared in the precision every store keeps, so timestamps are compared in seconds,
+// and the URL of gist items is left out as the SQL stores do not keep it.
+func (m *Migrator) Verify(ctx context.Context) (*Report, error) {
+	userIDs := m.source.Catalog.UserIDs(ctx)
+
+	if userIDs.IsError() {
+		return nil, userIDs.Error()
+	}
+
+	report := &Report{Source: newTallies(), Target: newTallies()}
+
+	for _, userID := range userIDs.MustGet() {
+		source, err := load(ctx, m.source, userID, m.shareEncryptKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := load(ctx, m.target, userID, m.shareEncryptKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sourceRecords := records(source)
+		targetRecords := records(target)
+
+		for _, kind := range Kinds {
+			report.Source[kind].add(sourceRecords[kind])
+			report.Target[kind].add(targetRecords[kind])
+
+			if !slices.Equal(sourceRecords[kind], targetRecords[kind]) {
+				report.Mismatches = append(report.Mismatches, Mismatch{UserID: userID, Kind: kind})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (t *Tally) add(records []string) {
+	t.Count += len(records)
+
+	for _, r := range records {
+		t.hash.Write([]byte(r))
+		t.hash.Write([]byte{'\n'})
+	}
+}
+
+// records returns the canonical form of every record of snap per kind, sorted.
+func records(snap *snapshot) map[string][]string {
+	result := make(map[string][]string, len(Kinds))
+	published := make(map[string]bool, len(snap.public))
+
+	for _, item := range snap.public {
+		published[item.ID()] = true
+		result[KindPublic] = append(result[KindPublic], item.ID())
+	}
+
+	for _, item := range snap.items {
+		result[KindItems] = append(result[KindItems], canonical(
+			item.ID(),
+			item.Title(),
+			item.EncryptedText(),
+			deref(item.Thumbnail()),
+			item.Diagram(),
+			item.IsPublic() || published[item.ID()],
+			item.IsBookmark(),
+			item.CreatedAt().Unix(),
+			item.UpdatedAt().Unix(),
+		))
+	}
+
+	for _, item := range snap.trash {
+		result[KindTrash] = append(result[KindTrash], canonical(
+			item.ID(),
+			item.Title(),
+			item.EncryptedText(),
+			deref(item.Thumbnail()),
+			item.Diagram(),
+			item.IsBookmark(),
+			item.CreatedAt().Unix(),
+			item.UpdatedAt().Unix(),
+			item.DeletedAt().Unix(),
+		))
+	}
+
+	for _, gist := range snap.gists {
+		result[KindGists] = append(result[KindGists], canonical(
+			gist.ID(),
+			gist.Title(),
+			gist.EncryptedText(),
+			deref(gist.Thumbnail()),
+			gist.Diagram(),
+			gist.IsBookmark(),
+			gist.Revision(),
+			gist.CreatedAt().Unix(),
+			gist.UpdatedAt().Unix(),
+		))
+	}
+
+	for _, entry := range snap.settings {
+		result[KindSettings] = append(result[KindSettings], canonical(entry.diagram, canonicalSettings(entry.settings)))
+	}
+
+	for _, entry := range snap.shares {
+		info := entry.value.ShareInfo
+		result[KindShares] = append(result[KindShares], canonical(
+			entry.hashKey,
+			entry.value.ItemID(),
+			entry.value.GistItem != nil,
+			info.Token,
+			info.Code,
+			info.Password,
+			strings.Join(info.AllowIPList, ","),
+			strings.Join(info.AllowEmailList, ","),
+			info.ExpireTime,
+			info.RemainingViews.OrElse(-1),
+		))
+	}
+
+	for _, kind := range Kinds {
+		slices.Sort(result[kind])
+	}
+
+	return result
+}
+
+func canonical(fields ...any) string {
+	data, _ := json.Marshal(fields)
+	return string(data)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// canonicalSettings evens out how the stores encode optional settings.
+// SQLite keeps false as NULL and the SQL stores keep the scale as a 32-bit float.
+func canonicalSettings(s *settings.Settings) []any {
+	flag := func(b *bool) bool { return b != nil && *b }
+	scale := float32(*withDefaultScale(s).Scale)
+
+	return []any{
+		s.Font,
+		s.Width,
+		s.Height,
+		s.BackgroundColor,
+		s.ActivityColor,
+		s.TaskColor,
+		s.StoryColor,
+		s.LineColor,
+		s.LabelColor,
+		deref(s.TextColor),
+		flag(s.ZoomControl),
+		scale,
+		flag(s.Toolbar),
+		flag(s.LockEditing),
+		flag(s.ShowGrid),
+	}
+}
@@ -1,27 +1,48 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	accountModel "github.com/harehare/textusm/internal/domain/model/account"
+	"github.com/harehare/textusm/internal/domain/service/account"
 	"github.com/harehare/textusm/internal/domain/service/diagramitem"
 	"github.com/harehare/textusm/internal/domain/service/gistitem"
+	"github.com/harehare/textusm/internal/domain/service/image"
 	"github.com/harehare/textusm/internal/domain/service/settings"
+	"github.com/harehare/textusm/internal/domain/service/thumbnail"
+	v "github.com/harehare/textusm/internal/domain/values"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/samber/mo"
 )
 
 type Api struct {
-	service         *diagramitem.Service
-	gistService     *gistitem.Service
-	settingsService *settings.Service
+	service          *diagramitem.Service
+	gistService      *gistitem.Service
+	settingsService  *settings.Service
+	thumbnailService *thumbnail.Service
+	imageService     *image.Service
+	accountService   *account.Service
+	deletionService  *account.DeletionService
 }
 
-func New(service *diagramitem.Service, gistService *gistitem.Service, settingsService *settings.Service) *Api {
+func New(service *diagramitem.Service, gistService *gistitem.Service, settingsService *settings.Service, thumbnailService *thumbnail.Service, imageService *image.Service, accountService *account.Service, deletionService *account.DeletionService) *Api {
 	return &Api{
-		service:         service,
-		gistService:     gistService,
-		settingsService: settingsService,
+		service:          service,
+		gistService:      gistService,
+		settingsService:  settingsService,
+		thumbnailService: thumbnailService,
+		imageService:     imageService,
+		accountService:   accountService,
+		deletionService:  deletionService,
 	}
 }
 
@@ -54,6 +75,56 @@ func (a *Api) RevokeGistToken(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+type OAuthCode struct {
+	Code string `json:"code"`
+}
+
+func (a *Api) ConnectGithub(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1*1024*1024)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Error("failed to read request body", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var code OAuthCode
+	if err := json.Unmarshal(body, &code); err != nil {
+		slog.Warn("failed to unmarshal oauth code", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := a.gistService.Connect(r.Context(), code.Code); err != nil {
+		if e.GetCode(err) == e.InvalidParameter {
+			slog.Warn("invalid github oauth code", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		slog.Error("failed to connect github", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *Api) DisconnectGithub(w http.ResponseWriter, r *http.Request) {
+	if err := a.gistService.Disconnect(r.Context()); err != nil {
+		if e.GetCode(err) == e.NotFound {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		slog.Error("failed to disconnect github", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (a *Api) RevokeToken(w http.ResponseWriter, r *http.Request) {
 	err := a.service.RevokeToken(r.Context())
 
@@ -64,3 +135,321 @@ func (a *Api) RevokeToken(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (a *Api) JWKS(w http.ResponseWriter, _ *http.Request) {
+	jwks, err := a.service.JWKS().Get()
+
+	if err != nil {
+		slog.Error("failed to load jwks", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+
+	if err := json.NewEncoder(w).Encode(jwks); err != nil {
+		slog.Error("failed to write jwks response", "error", err)
+	}
+}
+
+// Thumbnail serves the thumbnail of an item scaled down to the width given by w.
+// It is authorized by the signed token of the URL returned from GraphQL, or by the owner's Authorization header.
+// The format is PNG unless format=webp is given or the Accept header allows WebP.
+func (a *Api) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	width := 0
+
+	if q := query.Get("w"); q != "" {
+		parsed, err := strconv.Atoi(q)
+
+		if err != nil || parsed < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		width = parsed
+	}
+
+	format := thumbnail.Format(query.Get("format"))
+
+	if format == "" {
+		format = thumbnail.FormatPNG
+
+		if strings.Contains(r.Header.Get("Accept"), thumbnail.FormatWebP.ContentType()) {
+			format = thumbnail.FormatWebP
+		}
+	}
+
+	location := v.LocationSystem
+
+	if q := query.Get("location"); q != "" {
+		location = v.Location(q)
+	}
+
+	token := query.Get("token")
+	ret := a.thumbnailService.Find(r.Context(), chi.URLParam(r, "id"), token, location, width, format)
+
+	if ret.IsError() {
+		switch e.GetCode(ret.Error()) {
+		case e.InvalidParameter:
+			w.WriteHeader(http.StatusBadRequest)
+		case e.NoAuthorization:
+			w.WriteHeader(http.StatusUnauthorized)
+		case e.Forbidden:
+			w.WriteHeader(http.StatusForbidden)
+		case e.NotFound:
+			w.WriteHeader(http.StatusNotFound)
+		case e.URLExpired:
+			w.WriteHeader(http.StatusGone)
+		case e.RateLimited:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			slog.Error("failed to render thumbnail", "error", ret.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	t := ret.MustGet()
+	w.Header().Set("Content-Type", t.ContentType)
+	w.Header().Set("ETag", t.ETag)
+	w.Header().Add("Vary", "Accept")
+
+	// Signed URLs change whenever the thumbnail does, so they can be cached until the signature expires.
+	if token != "" {
+		w.Header().Set("Cache-Control", "private, max-age=43200")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), t.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(t.Data)))
+
+	if _, err := w.Write(t.Data); err != nil {
+		slog.Error("failed to write thumbnail response", "error", err)
+	}
+}
+
+type UploadedImage struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// UploadImage stores the image sent as the request body for the item given by itemID and location.
+// The URL in the response can be put in the diagram text in place of a data URL.
+func (a *Api) UploadImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, a.imageService.MaxSize())
+	body, err := io.ReadAll(r.Body)
+
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to read request body", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	location := v.LocationSystem
+
+	if q := r.URL.Query().Get("location"); q != "" {
+		location = v.Location(q)
+	}
+
+	ret := a.imageService.Upload(r.Context(), r.URL.Query().Get("itemID"), location, body)
+
+	if ret.IsError() {
+		switch {
+		case errors.Is(ret.Error(), e.ErrImageTooLarge):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		case errors.Is(ret.Error(), e.ErrUnsupportedImage):
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		default:
+			writeError(w, ret.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(UploadedImage{ID: ret.MustGet().ID, URL: a.imageService.URL(ret.MustGet())}); err != nil {
+		slog.Error("failed to write image response", "error", err)
+	}
+}
+
+// Image serves an uploaded image to anyone who can see the diagram it belongs to.
+func (a *Api) Image(w http.ResponseWriter, r *http.Request) {
+	ret := a.imageService.Find(r.Context(), chi.URLParam(r, "id"))
+
+	if ret.IsError() {
+		writeError(w, ret.Error())
+		return
+	}
+
+	f := ret.MustGet()
+	w.Header().Set("Content-Type", f.ContentType)
+	w.Header().Set("ETag", f.ETag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Images never change, but whether they can be seen does, e.g. when a share expires.
+	w.Header().Set("Cache-Control", "private, max-age=300")
+
+	if etagMatches(r.Header.Get("If-None-Match"), f.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(f.Data)))
+
+	if _, err := w.Write(f.Data); err != nil {
+		slog.Error("failed to write image response", "error", err)
+	}
+}
+
+func (a *Api) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	if ret := a.imageService.Delete(r.Context(), chi.URLParam(r, "id")); ret.IsError() {
+		writeError(w, ret.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxArchiveSize limits the size of an uploaded account archive.
+const maxArchiveSize = 64 * 1024 * 1024
+
+// ExportAccount streams a zip archive of all the data of the signed-in user.
+func (a *Api) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="textusm-export.zip"`)
+
+	if err := a.accountService.Export(r.Context(), w); err != nil {
+		// Nothing has been written yet when the user is not signed in. Otherwise the archive is cut short.
+		if e.GetCode(err) == e.NoAuthorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		slog.Error("failed to export account", "error", err)
+	}
+}
+
+// ImportAccount restores an archive written by ExportAccount into the account of the signed-in user.
+func (a *Api) ImportAccount(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxArchiveSize)
+	body, err := io.ReadAll(r.Body)
+
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to read request body", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ret := a.accountService.Import(r.Context(), bytes.NewReader(body), int64(len(body)))
+
+	if ret.IsError() {
+		writeError(w, ret.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(ret.MustGet()); err != nil {
+		slog.Error("failed to write import response", "error", err)
+	}
+}
+
+type AccountDeletion struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requestedAt"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+}
+
+// DeleteAccount schedules the deletion of the signed-in user. It can be cancelled until the grace period ends.
+func (a *Api) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	writeDeletion(w, http.StatusAccepted, a.deletionService.Request(r.Context()))
+}
+
+func (a *Api) AccountDeletion(w http.ResponseWriter, r *http.Request) {
+	writeDeletion(w, http.StatusOK, a.deletionService.Find(r.Context()))
+}
+
+func (a *Api) CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	writeDeletion(w, http.StatusOK, a.deletionService.Cancel(r.Context()))
+}
+
+// DeletionReceipt returns the signed receipt of a completed deletion as a JWT, verifiable with /.well-known/jwks.json.
+func (a *Api) DeletionReceipt(w http.ResponseWriter, r *http.Request) {
+	ret := a.deletionService.Receipt(r.Context(), chi.URLParam(r, "id"))
+
+	if ret.IsError() {
+		writeError(w, ret.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jwt")
+
+	if _, err := w.Write([]byte(ret.MustGet())); err != nil {
+		slog.Error("failed to write deletion receipt", "error", err)
+	}
+}
+
+func writeDeletion(w http.ResponseWriter, status int, ret mo.Result[*accountModel.Deletion]) {
+	if ret.IsError() {
+		writeError(w, ret.Error())
+		return
+	}
+
+	d := ret.MustGet()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(AccountDeletion{ID: d.ID, Status: string(d.Status), RequestedAt: d.RequestedAt, ScheduledAt: d.ScheduledAt}); err != nil {
+		slog.Error("failed to write account deletion response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch e.GetCode(err) {
+	case e.InvalidParameter:
+		w.WriteHeader(http.StatusBadRequest)
+	case e.NoAuthorization:
+		w.WriteHeader(http.StatusUnauthorized)
+	case e.Forbidden:
+		w.WriteHeader(http.StatusForbidden)
+	case e.NotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case e.Conflict:
+		w.WriteHeader(http.StatusConflict)
+	case e.RateLimited:
+		w.WriteHeader(http.StatusTooManyRequests)
+	default:
+		slog.Error("failed to handle request", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/"); tag == etag || tag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -4,11 +4,11 @@ import (
 	"net/http"
 	"strings"
 
-	firebase "firebase.google.com/go/v4"
 	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/idtoken"
 )
 
-func AuthMiddleware(app *firebase.App) func(http.Handler) http.Handler {
+func AuthMiddleware(verifier *idtoken.Verifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -24,13 +24,7 @@ func AuthMiddleware(app *firebase.App) func(http.Handler) http.Handler {
 				return
 			}
 
-			client, err := app.Auth(r.Context())
-			if err != nil {
-				http.Error(w, "{\"error\": \"authorization failed\"}", http.StatusUnauthorized)
-				return
-			}
-
-			token, err := client.VerifyIDTokenAndCheckRevoked(r.Context(), idToken[1])
+			token, err := verifier.Verify(r.Context(), idToken[1])
 			if err != nil {
 				http.Error(w, "{\"error\": \"authorization failed\"}", http.StatusForbidden)
 				return
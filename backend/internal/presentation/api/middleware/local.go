@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/harehare/textusm/internal/context/values"
+	"github.com/harehare/textusm/internal/domain/model/user"
+)
+
+// LocalAuthMiddleware makes every request as user.LocalUID. It replaces AuthMiddleware in local mode, where
+// the server is only reachable from the machine it runs on.
+func LocalAuthMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(values.WithUID(r.Context(), user.LocalUID)))
+		})
+	}
+}
+
+// LoopbackHostMiddleware rejects requests whose Host is not the loopback address the server listens on in local
+// mode. Listening on 127.0.0.1 alone does not stop a web page whose domain is rebound to 127.0.0.1 from calling
+// the server as its own origin, but the browser still sends that domain as the Host.
+func LoopbackHostMiddleware(port string) func(http.Handler) http.Handler {
+	allowed := map[string]bool{}
+
+	for _, host := range []string{"localhost", "127.0.0.1", "[::1]"} {
+		allowed[host+":"+port] = true
+
+		if port == "80" {
+			allowed[host] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !allowed[strings.ToLower(r.Host)] {
+				http.Error(w, "{\"error\": \"host not allowed\"}", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoopbackHostMiddleware(t *testing.T) {
+	handler := LoopbackHostMiddleware("8001")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"localhost:8001", http.StatusOK},
+		{"127.0.0.1:8001", http.StatusOK},
+		{"[::1]:8001", http.StatusOK},
+		{"LOCALHOST:8001", http.StatusOK},
+		{"localhost:3000", http.StatusForbidden},
+		{"localhost", http.StatusForbidden},
+		{"attacker.example.com:8001", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
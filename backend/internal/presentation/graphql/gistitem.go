@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *Resolver) GistItem() GistItemResolver { return &gistItemResolver{r} }
+
+type gistItemResolver struct{ *Resolver }
+
+func (r *gistItemResolver) Text(ctx context.Context, obj *gistitem.GistItem) (*string, error) {
+	if cached, ok := obj.Text().Get(); ok {
+		return &cached, nil
+	}
+
+	text := r.gistService.FindContent(ctx, obj.ID())
+
+	// Users who have not connected GitHub on the server still load gists in the browser.
+	if errors.Is(text.Error(), e.ErrGithubNotConnected) {
+		return nil, nil
+	}
+
+	if text.IsError() {
+		return nil, text.Error()
+	}
+
+	t := text.MustGet()
+	return &t, nil
+}
+
+func (r *gistItemResolver) Thumbnail(ctx context.Context, obj *gistitem.GistItem) (*string, error) {
+	return r.thumbnailService.GistItemURL(ctx, obj).Get()
+}
+
+// conflictError returns the three-way merge of a conflicting gist save in the error extensions,
+// so the client can resolve it and save again with the upstream revision.
+func conflictError(ctx context.Context, conflict *gistitem.Conflict) error {
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: conflict.Error(),
+		Extensions: map[string]interface{}{
+			"code":         e.Conflict,
+			"baseRevision": conflict.BaseRevision,
+			"revision":     conflict.Revision,
+			"base":         conflict.Base,
+			"ours":         conflict.Ours,
+			"theirs":       conflict.Theirs,
+			"merged":       conflict.Merged,
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+)
+
+func (r *Resolver) Item() ItemResolver { return &itemResolver{r} }
+
+type itemResolver struct{ *Resolver }
+
+// Thumbnail returns a signed URL of the thumbnail, so that lists of items do not carry the images themselves.
+func (r *itemResolver) Thumbnail(ctx context.Context, obj *diagramitem.DiagramItem) (*string, error) {
+	return r.thumbnailService.ItemURL(ctx, obj).Get()
+}
@@ -0,0 +1,148 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/harehare/textusm/internal/dataloader"
+	"github.com/harehare/textusm/internal/domain/model/diagramitem"
+	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/settings"
+	shareModel "github.com/harehare/textusm/internal/domain/model/share"
+	"github.com/harehare/textusm/internal/domain/values"
+	"github.com/samber/mo"
+)
+
+type loadersKey struct{}
+
+type itemKey struct {
+	id       string
+	isPublic bool
+	fields   values.Projection
+}
+
+type gistKey struct {
+	id     string
+	fields values.Projection
+}
+
+// loaders batch the lookups of one request. Items and gist items are read in one round trip per batch,
+// while settings and share conditions are only deduplicated and cached.
+type loaders struct {
+	items           *dataloader.Loader[itemKey, *diagramitem.DiagramItem]
+	gistItems       *dataloader.Loader[gistKey, *gistitem.GistItem]
+	settings        *dataloader.Loader[values.Diagram, *settings.Settings]
+	shareConditions *dataloader.Loader[string, *shareModel.ShareCondition]
+}
+
+// WithLoaders gives every request its own loaders, so that nothing is cached across requests or users.
+func (r *Resolver) WithLoaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), loadersKey{}, r.newLoaders())
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// loaders returns the loaders of the request, or new ones when it has none.
+func (r *Resolver) loaders(ctx context.Context) *loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*loaders); ok {
+		return l
+	}
+
+	return r.newLoaders()
+}
+
+func (r *Resolver) newLoaders() *loaders {
+	return &loaders{
+		items:           dataloader.New(r.loadItems),
+		gistItems:       dataloader.New(r.loadGistItems),
+		settings:        dataloader.New(r.loadSettings),
+		shareConditions: dataloader.New(r.loadShareConditions),
+	}
+}
+
+func (r *Resolver) loadItems(ctx context.Context, keys []itemKey) map[itemKey]mo.Result[*diagramitem.DiagramItem] {
+	groups := map[itemKey][]string{}
+
+	for _, key := range keys {
+		group := itemKey{isPublic: key.isPublic, fields: key.fields}
+		groups[group] = append(groups[group], key.id)
+	}
+
+	results := map[itemKey]mo.Result[*diagramitem.DiagramItem]{}
+
+	for group, ids := range groups {
+		items := r.service.FindByIDs(ctx, ids, group.isPublic, group.fields)
+
+		for _, id := range ids {
+			key := itemKey{id: id, isPublic: group.isPublic, fields: group.fields}
+
+			if items.IsError() {
+				results[key] = mo.Err[*diagramitem.DiagramItem](items.Error())
+			}
+		}
+
+		for _, item := range items.OrEmpty() {
+			results[itemKey{id: item.ID(), isPublic: group.isPublic, fields: group.fields}] = mo.Ok(item)
+		}
+	}
+
+	return results
+}
+
+func (r *Resolver) loadGistItems(ctx context.Context, keys []gistKey) map[gistKey]mo.Result[*gistitem.GistItem] {
+	groups := map[values.Projection][]string{}
+
+	for _, key := range keys {
+		groups[key.fields] = append(groups[key.fields], key.id)
+	}
+
+	results := map[gistKey]mo.Result[*gistitem.GistItem]{}
+
+	for fields, ids := range groups {
+		items := r.gistService.FindByIDs(ctx, ids, fields)
+
+		for _, id := range ids {
+			if items.IsError() {
+				results[gistKey{id: id, fields: fields}] = mo.Err[*gistitem.GistItem](items.Error())
+			}
+		}
+
+		for _, item := range items.OrEmpty() {
+			results[gistKey{id: item.ID(), fields: fields}] = mo.Ok(item)
+		}
+	}
+
+	return results
+}
+
+func (r *Resolver) loadSettings(ctx context.Context, diagrams []values.Diagram) map[values.Diagram]mo.Result[*settings.Settings] {
+	results := map[values.Diagram]mo.Result[*settings.Settings]{}
+
+	for _, diagram := range diagrams {
+		results[diagram] = r.settingsService.Find(ctx, diagram)
+	}
+
+	return results
+}
+
+func (r *Resolver) loadShareConditions(ctx context.Context, itemIDs []string) map[string]mo.Result[*shareModel.ShareCondition] {
+	results := map[string]mo.Result[*shareModel.ShareCondition]{}
+
+	for _, itemID := range itemIDs {
+		results[itemID] = r.service.FindShareCondition(ctx, itemID)
+	}
+
+	return results
+}
+
+// forgetItem drops every cached read of the item, whatever its projection.
+func (l *loaders) forgetItem(itemID string) {
+	l.items.ClearFunc(func(key itemKey) bool { return key.id == itemID })
+	l.shareConditions.Clear(itemID)
+}
+
+// forgetGistItem drops every cached read of the gist item, whatever its projection.
+func (l *loaders) forgetGistItem(gistID string) {
+	l.gistItems.ClearFunc(func(key gistKey) bool { return key.id == gistID })
+}
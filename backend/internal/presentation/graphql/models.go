@@ -18,6 +18,8 @@ type InputGistItem struct {
 	Diagram    *values.Diagram `json:"diagram"`
 	IsBookmark bool            `json:"isBookmark"`
 	URL        string          `json:"url"`
+	Text       *string         `json:"text,omitempty"`
+	Revision   *string         `json:"revision,omitempty"`
 }
 
 type InputItem struct {
@@ -49,9 +51,12 @@ type InputSettings struct {
 }
 
 type InputShareItem struct {
-	ItemID         string   `json:"itemID"`
-	ExpSecond      *int     `json:"expSecond,omitempty"`
-	Password       *string  `json:"password,omitempty"`
-	AllowIPList    []string `json:"allowIPList,omitempty"`
-	AllowEmailList []string `json:"allowEmailList,omitempty"`
+	ItemID         string           `json:"itemID"`
+	Location       *values.Location `json:"location,omitempty"`
+	ExpSecond      *int             `json:"expSecond,omitempty"`
+	Password       *string          `json:"password,omitempty"`
+	AllowIPList    []string         `json:"allowIPList,omitempty"`
+	AllowEmailList []string         `json:"allowEmailList,omitempty"`
+	Slug           *string          `json:"slug,omitempty"`
+	MaxViews       *int             `json:"maxViews,omitempty"`
 }
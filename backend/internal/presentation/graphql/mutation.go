@@ -2,10 +2,12 @@ package graphql
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/harehare/textusm/internal/domain/model/diagramitem"
 	"github.com/harehare/textusm/internal/domain/model/gistitem"
+	"github.com/harehare/textusm/internal/domain/model/gitsync"
 	settingsModel "github.com/harehare/textusm/internal/domain/model/settings"
 	v "github.com/harehare/textusm/internal/domain/values"
 	"github.com/harehare/textusm/internal/util"
@@ -25,9 +27,9 @@ func (r *mutationResolver) Save(ctx context.Context, input InputItem, isPublic *
 			return nil, saveItem.Error()
 		}
 
-		return util.ResultToTuple(r.service.Save(ctx, saveItem.OrEmpty(), *isPublic))
+		return r.save(ctx, saveItem.OrEmpty(), *isPublic)
 	}
-	baseItem := r.service.FindByID(ctx, *input.ID, false)
+	baseItem := r.loaders(ctx).items.Load(ctx, itemKey{id: *input.ID, fields: v.MetadataOnly})
 
 	if baseItem.IsError() {
 		return nil, baseItem.Error()
@@ -40,11 +42,42 @@ func (r *mutationResolver) Save(ctx context.Context, input InputItem, isPublic *
 		return nil, saveItem.Error()
 	}
 
-	return util.ResultToTuple(r.service.Save(ctx, saveItem.OrEmpty(), *isPublic))
+	return r.save(ctx, saveItem.OrEmpty(), *isPublic)
+}
+
+// save stores the item and replaces what the loaders of the request know about it.
+func (r *mutationResolver) save(ctx context.Context, item *diagramitem.DiagramItem, isPublic bool) (*diagramitem.DiagramItem, error) {
+	saved, err := util.ResultToTuple(r.service.Save(ctx, item, isPublic))
+
+	if err != nil {
+		return nil, err
+	}
+
+	loaders := r.loaders(ctx)
+	loaders.forgetItem(saved.ID())
+	loaders.items.Prime(itemKey{id: saved.ID(), isPublic: isPublic, fields: v.AllFields}, saved)
+	return saved, nil
 }
 
 func (r *mutationResolver) Delete(ctx context.Context, itemID string, isPublic *bool) (string, error) {
 	err := r.service.Delete(ctx, itemID, *isPublic)
+	r.loaders(ctx).forgetItem(itemID)
+
+	if err != nil {
+		return "", err
+	}
+
+	return itemID, nil
+}
+
+func (r *mutationResolver) Restore(ctx context.Context, itemID string) (*diagramitem.DiagramItem, error) {
+	r.loaders(ctx).forgetItem(itemID)
+	return util.ResultToTuple(r.service.Restore(ctx, itemID))
+}
+
+func (r *mutationResolver) Purge(ctx context.Context, itemID string) (string, error) {
+	err := r.service.Purge(ctx, itemID)
+	r.loaders(ctx).forgetItem(itemID)
 
 	if err != nil {
 		return "", err
@@ -54,6 +87,7 @@ func (r *mutationResolver) Delete(ctx context.Context, itemID string, isPublic *
 }
 
 func (r *mutationResolver) Bookmark(ctx context.Context, itemID string, isBookmark bool) (*diagramitem.DiagramItem, error) {
+	r.loaders(ctx).forgetItem(itemID)
 	return util.ResultToTuple(r.service.Bookmark(ctx, itemID, isBookmark))
 }
 
@@ -64,13 +98,34 @@ func (r *mutationResolver) Share(ctx context.Context, input InputShareItem) (str
 	} else {
 		p = *input.Password
 	}
-	return util.ResultToTuple(r.service.Share(ctx, input.ItemID, *input.ExpSecond, p, input.AllowIPList, input.AllowEmailList))
+
+	var slug string
+	if input.Slug != nil {
+		slug = *input.Slug
+	}
+
+	var maxViews int
+	if input.MaxViews != nil {
+		maxViews = *input.MaxViews
+	}
+
+	location := v.LocationSystem
+	if input.Location != nil {
+		location = *input.Location
+	}
+	r.loaders(ctx).forgetItem(input.ItemID)
+	return util.ResultToTuple(r.service.Share(ctx, input.ItemID, location, *input.ExpSecond, p, input.AllowIPList, input.AllowEmailList, slug, maxViews))
 }
 
 func (r *mutationResolver) SaveGist(ctx context.Context, input InputGistItem) (*gistitem.GistItem, error) {
 	currentTime := time.Now()
+	var id string
+	if input.ID != nil {
+		id = *input.ID
+	}
+
 	gist := gistitem.New().
-		WithID(*input.ID).
+		WithID(id).
 		WithURL(input.URL).
 		WithTitle(input.Title).
 		WithThumbnail(util.ToOption(input.Thumbnail)).
@@ -84,17 +139,47 @@ func (r *mutationResolver) SaveGist(ctx context.Context, input InputGistItem) (*
 		return nil, gist.Error()
 	}
 
+	if input.ID != nil {
+		r.loaders(ctx).forgetGistItem(*input.ID)
+	}
+
+	if input.Text != nil {
+		var revision string
+		if input.Revision != nil {
+			revision = *input.Revision
+		}
+
+		saved := r.gistService.SaveContent(ctx, gist.OrEmpty(), *input.Text, revision)
+
+		var conflict *gistitem.Conflict
+		if errors.As(saved.Error(), &conflict) {
+			return nil, conflictError(ctx, conflict)
+		}
+
+		return util.ResultToTuple(saved)
+	}
+
 	return util.ResultToTuple(r.gistService.Save(ctx, gist.OrEmpty()))
 }
 
 func (r *mutationResolver) DeleteGist(ctx context.Context, gistID string) (string, error) {
 	result := r.gistService.Delete(ctx, gistID)
+	r.loaders(ctx).forgetGistItem(gistID)
 	if result.IsError() {
 		return "", result.Error()
 	}
 	return gistID, nil
 }
 
+func (r *mutationResolver) RefreshGist(ctx context.Context, gistID string) (*gistitem.GistItem, error) {
+	r.loaders(ctx).forgetGistItem(gistID)
+	return util.ResultToTuple(r.gistService.Refresh(ctx, gistID))
+}
+
+func (r *mutationResolver) SyncGitRepository(ctx context.Context, itemIDs []string) ([]*gitsync.Result, error) {
+	return util.ResultToTuple(r.gitSyncService.Sync(ctx, itemIDs))
+}
+
 func (r *mutationResolver) SaveSettings(ctx context.Context, diagram *v.Diagram, input InputSettings) (*settingsModel.Settings, error) { //nolint:gocritic
 	settings := settingsModel.Settings{
 		Font:            input.Font,
@@ -111,6 +196,7 @@ func (r *mutationResolver) SaveSettings(ctx context.Context, diagram *v.Diagram,
 		Scale:           input.Scale,
 		Toolbar:         input.Toolbar,
 	}
+	r.loaders(ctx).settings.Clear(*diagram)
 	return util.ResultToTuple(r.settingsService.Save(ctx, *diagram, &settings))
 }
 
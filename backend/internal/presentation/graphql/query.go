@@ -45,11 +45,17 @@ func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 type queryResolver struct{ *Resolver }
 
 func (r *queryResolver) Item(ctx context.Context, id string, isPublic *bool) (*diagramitem.DiagramItem, error) {
-	return util.ResultToTuple(r.service.FindByID(ctx, id, *isPublic))
+	key := itemKey{id: id, isPublic: *isPublic, fields: values.ProjectionOf(getPreloads(ctx))}
+	return util.ResultToTuple(r.loaders(ctx).items.Load(ctx, key))
 }
 
-func (r *queryResolver) Items(ctx context.Context, offset *int, limit *int, isBookmark *bool, isPublic *bool) ([]*diagramitem.DiagramItem, error) {
-	return util.ResultToTuple(r.service.Find(ctx, *offset, *limit, *isPublic, *isBookmark, getPreloads(ctx)))
+func (r *queryResolver) Items(ctx context.Context, offset *int, limit *int, isBookmark *bool, isPublic *bool, diagram *values.Diagram, sort *values.ItemSort) ([]*diagramitem.DiagramItem, error) {
+	filter := values.ItemFilter{IsBookmark: *isBookmark, Diagram: util.ToOption(diagram), Sort: *sort}
+	return util.ResultToTuple(r.service.Find(ctx, *offset, *limit, *isPublic, filter, values.ProjectionOf(getPreloads(ctx))))
+}
+
+func (r *queryResolver) Trash(ctx context.Context, offset *int, limit *int) ([]*diagramitem.DiagramItem, error) {
+	return util.ResultToTuple(r.service.FindTrash(ctx, *offset, *limit, values.ProjectionOf(getPreloads(ctx))))
 }
 
 func (r *queryResolver) ShareItem(ctx context.Context, token string, password *string) (*diagramitem.DiagramItem, error) {
@@ -63,18 +69,19 @@ func (r *queryResolver) ShareItem(ctx context.Context, token string, password *s
 }
 
 func (r *queryResolver) ShareCondition(ctx context.Context, itemID string) (*shareModel.ShareCondition, error) {
-	return util.ResultToTuple(r.service.FindShareCondition(ctx, itemID))
+	return util.ResultToTuple(r.loaders(ctx).shareConditions.Load(ctx, itemID))
 }
 
 func (r *queryResolver) AllItems(ctx context.Context, offset, limit *int) ([]union.DiagramItem, error) {
 	var diagramItems []union.DiagramItem
-	items, err := util.ResultToTuple(r.service.Find(ctx, *offset, *limit, false, false, getPreloads(ctx)))
+	fields := values.ProjectionOf(getPreloads(ctx))
+	items, err := util.ResultToTuple(r.service.Find(ctx, *offset, *limit, false, values.ItemFilter{}, fields))
 
 	if err != nil {
 		return nil, err
 	}
 
-	gistItems := r.gistService.Find(ctx, *offset, *limit)
+	gistItems := r.gistService.Find(ctx, *offset, *limit, fields)
 
 	for _, item := range items {
 		diagramItems = append(diagramItems, item)
@@ -88,13 +95,14 @@ func (r *queryResolver) AllItems(ctx context.Context, offset, limit *int) ([]uni
 }
 
 func (r *queryResolver) GistItem(ctx context.Context, id string) (*gistitem.GistItem, error) {
-	return util.ResultToTuple(r.gistService.FindByID(ctx, id))
+	key := gistKey{id: id, fields: values.ProjectionOf(getPreloads(ctx))}
+	return util.ResultToTuple(r.loaders(ctx).gistItems.Load(ctx, key))
 }
 
 func (r *queryResolver) GistItems(ctx context.Context, offset, limit *int) ([]*gistitem.GistItem, error) {
-	return util.ResultToTuple(r.gistService.Find(ctx, *offset, *limit))
+	return util.ResultToTuple(r.gistService.Find(ctx, *offset, *limit, values.ProjectionOf(getPreloads(ctx))))
 }
 
 func (r *queryResolver) Settings(ctx context.Context, diagram *values.Diagram) (*settings.Settings, error) {
-	return util.ResultToTuple(r.settingsService.Find(ctx, *diagram))
+	return util.ResultToTuple(r.loaders(ctx).settings.Load(ctx, *diagram))
 }
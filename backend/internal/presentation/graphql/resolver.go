@@ -7,16 +7,20 @@ import (
 	"github.com/harehare/textusm/internal/config"
 	"github.com/harehare/textusm/internal/domain/service/diagramitem"
 	"github.com/harehare/textusm/internal/domain/service/gistitem"
+	"github.com/harehare/textusm/internal/domain/service/gitsync"
 	"github.com/harehare/textusm/internal/domain/service/settings"
+	"github.com/harehare/textusm/internal/domain/service/thumbnail"
 )
 
 type Resolver struct {
-	service         *diagramitem.Service
-	gistService     *gistitem.Service
-	settingsService *settings.Service
+	service          *diagramitem.Service
+	gistService      *gistitem.Service
+	gitSyncService   *gitsync.Service
+	settingsService  *settings.Service
+	thumbnailService *thumbnail.Service
 }
 
-func New(service *diagramitem.Service, gistService *gistitem.Service, settingsService *settings.Service, config *config.Config) *Resolver {
-	r := Resolver{service: service, gistService: gistService, settingsService: settingsService}
+func New(service *diagramitem.Service, gistService *gistitem.Service, gitSyncService *gitsync.Service, settingsService *settings.Service, thumbnailService *thumbnail.Service, config *config.Config) *Resolver {
+	r := Resolver{service: service, gistService: gistService, gitSyncService: gitSyncService, settingsService: settingsService, thumbnailService: thumbnailService}
 	return &r
 }
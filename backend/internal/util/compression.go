@@ -0,0 +1,66 @@
+package util
+
+import (
+	"expvar"
+
+	e "github.com/harehare/textusm/internal/error"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionHeader starts every compressed text and is followed by the version of its encoding.
+// 0xff never occurs in UTF-8, so text stored before compression is never mistaken for compressed text.
+const compressionHeader = 0xff
+
+// compressionZstd is the first version, a single zstd frame.
+const compressionZstd byte = 1
+
+// maxDecompressedSize bounds the memory a single text may decompress to.
+const maxDecompressedSize = 64 << 20
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+)
+
+// CompressionStats counts the texts passed to Compress, and their sizes before and after.
+// It is published with expvar as "compression".
+var CompressionStats = expvar.NewMap("compression")
+
+// Compress encodes text with the latest compression version. Text that does not get smaller is returned as is.
+func Compress(text string) string {
+	compressed := zstdEncoder.EncodeAll([]byte(text), []byte{compressionHeader, compressionZstd})
+
+	CompressionStats.Add("texts", 1)
+	CompressionStats.Add("plain_bytes", int64(len(text)))
+
+	if len(compressed) >= len(text) {
+		CompressionStats.Add("stored_bytes", int64(len(text)))
+		return text
+	}
+
+	CompressionStats.Add("compressed_texts", 1)
+	CompressionStats.Add("stored_bytes", int64(len(compressed)))
+	CompressionStats.Add("saved_bytes", int64(len(text)-len(compressed)))
+
+	return string(compressed)
+}
+
+// Decompress reverses Compress. Text that was never compressed is returned as is.
+func Decompress(text string) (string, error) {
+	if len(text) < 2 || text[0] != compressionHeader {
+		return text, nil
+	}
+
+	switch text[1] {
+	case compressionZstd:
+		plain, err := zstdDecoder.DecodeAll([]byte(text[2:]), nil)
+
+		if err != nil {
+			return "", err
+		}
+
+		return string(plain), nil
+	default:
+		return "", e.ErrUnknownCompression
+	}
+}
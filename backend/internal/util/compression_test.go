@@ -0,0 +1,63 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	e "github.com/harehare/textusm/internal/error"
+)
+
+func TestCompressDecompressRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty string", ""},
+		{"short text", "hello"},
+		{"unicode", strings.Repeat("日本語テキスト\n", 100)},
+		{"user story map", strings.Repeat("activity\n    task\n        story\n", 500)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decompressed, err := Decompress(Compress(tt.input))
+			if err != nil {
+				t.Fatalf("Decompress() error = %v", err)
+			}
+
+			if decompressed != tt.input {
+				t.Errorf("round-trip failed: got %q, want %q", decompressed, tt.input)
+			}
+		})
+	}
+}
+
+func TestCompressShrinksLargeText(t *testing.T) {
+	text := strings.Repeat("activity\n    task\n        story\n", 500)
+	compressed := Compress(text)
+
+	if len(compressed) >= len(text)/10 {
+		t.Errorf("Compress() = %d bytes, want less than %d", len(compressed), len(text)/10)
+	}
+}
+
+func TestCompressKeepsTextThatDoesNotShrink(t *testing.T) {
+	if got := Compress("abc"); got != "abc" {
+		t.Errorf("Compress() = %q, want the text as is", got)
+	}
+}
+
+func TestDecompressUncompressedText(t *testing.T) {
+	got, err := Decompress("stored before compression")
+	if err != nil || got != "stored before compression" {
+		t.Errorf("Decompress() = %q, %v, want the text as is", got, err)
+	}
+}
+
+func TestDecompressUnknownVersion(t *testing.T) {
+	_, err := Decompress(string([]byte{compressionHeader, 0x7f, 'a'}))
+	if !errors.Is(err, e.ErrUnknownCompression) {
+		t.Errorf("Decompress() error = %v, want %v", err, e.ErrUnknownCompression)
+	}
+}